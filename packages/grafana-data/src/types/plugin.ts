@@ -41,12 +41,16 @@ export enum PluginErrorCode {
   missingSignature = 'signatureMissing',
   invalidSignature = 'signatureInvalid',
   modifiedSignature = 'signatureModified',
+  invalidPluginJson = 'invalidPluginJson',
+  missingExecutable = 'missingExecutable',
 }
 
 /** Describes error returned from Grafana plugins API call */
 export interface PluginError {
   errorCode: PluginErrorCode;
   pluginId: string;
+  path?: string;
+  message?: string;
 }
 
 export interface PluginMeta<T extends KeyValue = {}> {
@@ -271,7 +271,7 @@ func (hs *HTTPServer) registerRoutes() {
 		// Data sources
 		apiRoute.Group("/datasources", func(datasourceRoute routing.RouteRegister) {
 			datasourceRoute.Get("/", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead, ScopeDatasourcesAll)), routing.Wrap(hs.GetDataSources))
-			datasourceRoute.Post("/", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesCreate)), quota("data_source"), bind(models.AddDataSourceCommand{}), routing.Wrap(AddDataSource))
+			datasourceRoute.Post("/", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesCreate)), quota("data_source"), bind(models.AddDataSourceCommand{}), routing.Wrap(hs.AddDataSource))
 			datasourceRoute.Put("/:id", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesWrite, ScopeDatasourceID)), bind(models.UpdateDataSourceCommand{}), routing.Wrap(hs.UpdateDataSource))
 			datasourceRoute.Delete("/:id", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesDelete, ScopeDatasourceID)), routing.Wrap(hs.DeleteDataSourceById))
 			datasourceRoute.Delete("/uid/:uid", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesDelete, ScopeDatasourceUID)), routing.Wrap(hs.DeleteDataSourceByUID))
@@ -284,16 +284,27 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Get("/datasources/id/:name", authorize(reqSignedIn, ac.EvalPermission(ActionDatasourcesIDRead, ScopeDatasourceName)), routing.Wrap(GetDataSourceIdByName))
 
 		apiRoute.Get("/plugins", routing.Wrap(hs.GetPluginList))
+		apiRoute.Get("/plugins/search", routing.Wrap(hs.SearchPlugins))
 		apiRoute.Get("/plugins/:pluginId/settings", routing.Wrap(hs.GetPluginSettingByID))
 		apiRoute.Get("/plugins/:pluginId/markdown/:name", routing.Wrap(hs.GetPluginMarkdown))
 		apiRoute.Get("/plugins/:pluginId/health", routing.Wrap(hs.CheckHealth))
+		apiRoute.Get("/plugins/:pluginId/capabilities", routing.Wrap(hs.GetPluginCapabilities))
 		apiRoute.Any("/plugins/:pluginId/resources", hs.CallResource)
 		apiRoute.Any("/plugins/:pluginId/resources/*", hs.CallResource)
+		apiRoute.Get("/plugins/:pluginId/streams/*", hs.StreamPluginEvents)
 		apiRoute.Get("/plugins/errors", routing.Wrap(hs.GetPluginErrorsList))
 
 		apiRoute.Group("/plugins", func(pluginRoute routing.RouteRegister) {
+			pluginRoute.Get("/state", routing.Wrap(hs.GetPluginStates))
+			pluginRoute.Get("/dependencies", routing.Wrap(hs.GetPluginDependencies))
 			pluginRoute.Post("/:pluginId/install", bind(dtos.InstallPluginCommand{}), routing.Wrap(hs.InstallPlugin))
 			pluginRoute.Post("/:pluginId/uninstall", routing.Wrap(hs.UninstallPlugin))
+			pluginRoute.Post("/:pluginId/canary", bind(dtos.InstallPluginCommand{}), routing.Wrap(hs.InstallPluginCanary))
+			pluginRoute.Delete("/:pluginId/canary", routing.Wrap(hs.RemovePluginCanary))
+			pluginRoute.Post("/:pluginId/canary/promote", routing.Wrap(hs.PromotePluginCanary))
+			pluginRoute.Get("/:pluginId/update", routing.Wrap(hs.CheckPluginUpdate))
+			pluginRoute.Get("/:pluginId/logs", routing.Wrap(hs.GetPluginLogs))
+			pluginRoute.Get("/:pluginId/logs/stream", routing.Wrap(hs.StreamPluginLogs))
 		}, reqGrafanaAdmin)
 
 		apiRoute.Group("/plugins", func(pluginRoute routing.RouteRegister) {
@@ -308,6 +319,7 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Any("/datasources/:id/resources", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/resources/*", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/health", routing.Wrap(hs.CheckDatasourceHealth))
+		apiRoute.Get("/datasources/:id/health/history", routing.Wrap(hs.GetDatasourceHealthHistory))
 
 		// Folders
 		apiRoute.Group("/folders", func(folderRoute routing.RouteRegister) {
@@ -462,6 +474,15 @@ func (hs *HTTPServer) registerRoutes() {
 		adminRoute.Post("/ldap/sync/:id", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPUsersSync)), routing.Wrap(hs.PostSyncUserWithLDAP))
 		adminRoute.Get("/ldap/:username", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPUsersRead)), routing.Wrap(hs.GetUserFromLDAP))
 		adminRoute.Get("/ldap/status", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionLDAPStatusRead)), routing.Wrap(hs.GetLDAPStatus))
+
+		adminRoute.Get("/plugins/runtime-stats", reqGrafanaAdmin, routing.Wrap(hs.AdminGetPluginRuntimeStats))
+		adminRoute.Get("/plugins/init-failures", reqGrafanaAdmin, routing.Wrap(hs.AdminGetPluginInitFailures))
+		adminRoute.Post("/plugins/scan", reqGrafanaAdmin, bind(dtos.ScanPluginsCommand{}), routing.Wrap(hs.AdminScanPlugins))
+		adminRoute.Get("/plugins/:pluginId/log-level", reqGrafanaAdmin, routing.Wrap(hs.AdminGetPluginLogLevel))
+		adminRoute.Put("/plugins/:pluginId/log-level", reqGrafanaAdmin, bind(dtos.UpdatePluginLogLevelCommand{}), routing.Wrap(hs.AdminSetPluginLogLevel))
+		adminRoute.Post("/plugins/:pluginId/restart", reqGrafanaAdmin, routing.Wrap(hs.AdminRestartPlugin))
+		adminRoute.Post("/plugins/:pluginId/reload", reqGrafanaAdmin, routing.Wrap(hs.AdminReloadPlugin))
+		adminRoute.Post("/plugins/:pluginId/decommission", reqGrafanaAdmin, routing.Wrap(hs.AdminDecommissionPlugin))
 	})
 
 	// Administering users
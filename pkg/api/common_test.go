@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/macaron.v1"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/fs"
 	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
 	"github.com/grafana/grafana/pkg/services/auth"
@@ -211,6 +213,32 @@ func (s *fakeRenderService) Init() error {
 	return nil
 }
 
+type fakeBackendPluginManager struct {
+	backendplugin.Manager
+
+	registered       bool
+	validationResult *backendplugin.ValidateConfigResult
+	validated        *backend.PluginContext
+	capabilities     map[string]backendplugin.PluginCapabilities
+}
+
+func (m *fakeBackendPluginManager) PluginCapabilities(ctx context.Context, pluginID string) (backendplugin.PluginCapabilities, error) {
+	capabilities, exists := m.capabilities[pluginID]
+	if !exists {
+		return backendplugin.PluginCapabilities{}, backendplugin.ErrPluginNotRegistered
+	}
+	return capabilities, nil
+}
+
+func (m *fakeBackendPluginManager) IsRegistered(pluginID string) bool {
+	return m.registered
+}
+
+func (m *fakeBackendPluginManager) ValidateConfig(ctx context.Context, pCtx backend.PluginContext) (*backendplugin.ValidateConfigResult, error) {
+	m.validated = &pCtx
+	return m.validationResult, nil
+}
+
 func setupAccessControlScenarioContext(t *testing.T, cfg *setting.Cfg, url string, permissions []*accesscontrol.Permission) (*scenarioContext, *HTTPServer) {
 	cfg.FeatureToggles = make(map[string]bool)
 	cfg.FeatureToggles["accesscontrol"] = true
@@ -223,6 +251,8 @@ func setupAccessControlScenarioContext(t *testing.T, cfg *setting.Cfg, url strin
 		QuotaService:  &quota.QuotaService{Cfg: cfg},
 		RouteRegister: routing.NewRouteRegister(),
 		AccessControl: accesscontrolmock.New().WithPermissions(permissions),
+
+		BackendPluginManager: &fakeBackendPluginManager{},
 	}
 
 	sc := setupScenarioContext(t, url)
@@ -5,14 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/datasource"
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
+	"github.com/grafana/grafana/pkg/plugins/secrets"
 	"github.com/grafana/grafana/pkg/util"
 	macaron "gopkg.in/macaron.v1"
 
@@ -208,13 +211,28 @@ func validateURL(tp string, u string) response.Response {
 	return nil
 }
 
-func AddDataSource(c *models.ReqContext, cmd models.AddDataSourceCommand) response.Response {
+func (hs *HTTPServer) AddDataSource(c *models.ReqContext, cmd models.AddDataSourceCommand) response.Response {
 	datasourcesLogger.Debug("Received command to add data source", "url", cmd.Url)
 	cmd.OrgId = c.OrgId
 	if resp := validateURL(cmd.Type, cmd.Url); resp != nil {
 		return resp
 	}
 
+	settings := &backend.DataSourceInstanceSettings{
+		Name:                    cmd.Name,
+		UID:                     cmd.Uid,
+		URL:                     cmd.Url,
+		User:                    cmd.User,
+		Database:                cmd.Database,
+		BasicAuthEnabled:        cmd.BasicAuth,
+		BasicAuthUser:           cmd.BasicAuthUser,
+		DecryptedSecureJSONData: cmd.SecureJsonData,
+	}
+	owner := secrets.DataSourceOwner(cmd.OrgId, 0)
+	if resp := hs.validateDataSourceConfig(c, cmd.Type, owner, cmd.JsonData, settings); resp != nil {
+		return resp
+	}
+
 	if err := bus.Dispatch(&cmd); err != nil {
 		if errors.Is(err, models.ErrDataSourceNameExists) || errors.Is(err, models.ErrDataSourceUidExists) {
 			return response.Error(409, err.Error(), err)
@@ -245,6 +263,28 @@ func (hs *HTTPServer) UpdateDataSource(c *models.ReqContext, cmd models.UpdateDa
 		return response.Error(500, "Failed to update datasource", err)
 	}
 
+	settings := &backend.DataSourceInstanceSettings{
+		ID:                      cmd.Id,
+		Name:                    cmd.Name,
+		UID:                     cmd.Uid,
+		URL:                     cmd.Url,
+		User:                    cmd.User,
+		Database:                cmd.Database,
+		BasicAuthEnabled:        cmd.BasicAuth,
+		BasicAuthUser:           cmd.BasicAuthUser,
+		DecryptedSecureJSONData: cmd.SecureJsonData,
+	}
+	if len(cmd.SecureJsonData) == 0 {
+		// secure settings that aren't given are kept, so they're validated along with the given ones
+		if ds, err := getRawDataSourceById(cmd.Id, cmd.OrgId); err == nil {
+			settings.DecryptedSecureJSONData = ds.DecryptedValues()
+		}
+	}
+	owner := secrets.DataSourceOwner(cmd.OrgId, cmd.Id)
+	if resp := hs.validateDataSourceConfig(c, cmd.Type, owner, cmd.JsonData, settings); resp != nil {
+		return resp
+	}
+
 	err = bus.Dispatch(&cmd)
 	if err != nil {
 		if errors.Is(err, models.ErrDataSourceUpdatingOldVersion) {
@@ -277,6 +317,36 @@ func (hs *HTTPServer) UpdateDataSource(c *models.ReqContext, cmd models.UpdateDa
 	})
 }
 
+// validateDataSourceConfig asks the backend plugin of a data source to validate its settings before they're saved,
+// returning an error response if they're invalid. Settings are saved without validation if the plugin can't be
+// asked.
+func (hs *HTTPServer) validateDataSourceConfig(c *models.ReqContext, pluginID string, owner secrets.Owner,
+	jsonData *simplejson.Json, settings *backend.DataSourceInstanceSettings) response.Response {
+	if !hs.BackendPluginManager.IsRegistered(pluginID) {
+		return nil
+	}
+
+	if jsonData == nil {
+		jsonData = simplejson.New()
+	}
+	jsonDataBytes, err := jsonData.MarshalJSON()
+	if err != nil {
+		return response.Error(400, "Invalid datasource settings", err)
+	}
+	settings.JSONData = jsonDataBytes
+	settings.DecryptedSecureJSONData, _, err = secrets.Resolve(c.Req.Context(), owner, settings.DecryptedSecureJSONData)
+	if err != nil {
+		return response.Error(400, "Failed to resolve datasource secrets", err)
+	}
+
+	return hs.validatePluginConfig(c, backend.PluginContext{
+		OrgID:                      c.OrgId,
+		PluginID:                   pluginID,
+		User:                       adapters.BackendUserFromSignedInUser(c.SignedInUser),
+		DataSourceInstanceSettings: settings,
+	}, "datasource")
+}
+
 func fillWithSecureJSONData(cmd *models.UpdateDataSourceCommand) error {
 	if len(cmd.SecureJsonData) == 0 {
 		return nil
@@ -456,14 +526,24 @@ func (hs *HTTPServer) CheckDatasourceHealth(c *models.ReqContext) response.Respo
 		DataSourceInstanceSettings: dsInstanceSettings,
 	}
 
-	resp, err := hs.BackendPluginManager.CheckHealth(c.Req.Context(), pCtx)
+	var resp *backend.CheckHealthResult
+	checkedAt := time.Now()
+	status, cached := hs.BackendPluginManager.CachedHealthCheck(plugin.Id, ds.Uid)
+	if cached && c.Query("refresh") != "true" {
+		resp, err = status.Result, status.Error
+		checkedAt = status.CheckedAt
+	} else {
+		resp, err = hs.BackendPluginManager.CheckHealth(c.Req.Context(), pCtx)
+	}
 	if err != nil {
 		return translatePluginRequestErrorToAPIError(err)
 	}
 
 	payload := map[string]interface{}{
-		"status":  resp.Status.String(),
-		"message": resp.Message,
+		"status":    resp.Status.String(),
+		"message":   resp.Message,
+		"checkedAt": checkedAt,
+		"flapping":  hs.BackendPluginManager.HealthCheckHistory(plugin.Id, ds.Uid).Flapping,
 	}
 
 	// Unmarshal JSONDetails if it's not empty.
@@ -483,3 +563,39 @@ func (hs *HTTPServer) CheckDatasourceHealth(c *models.ReqContext) response.Respo
 
 	return response.JSON(200, payload)
 }
+
+// GetDatasourceHealthHistory returns the recent health check results of a data source and whether its health
+// is flapping.
+//
+// /api/datasources/:id/health/history
+func (hs *HTTPServer) GetDatasourceHealthHistory(c *models.ReqContext) response.Response {
+	datasourceID := c.ParamsInt64(":id")
+
+	ds, err := hs.DataSourceCache.GetDatasource(datasourceID, c.SignedInUser, c.SkipCache)
+	if err != nil {
+		if errors.Is(err, models.ErrDataSourceAccessDenied) {
+			return response.Error(403, "Access denied to datasource", err)
+		}
+		return response.Error(500, "Unable to load datasource metadata", err)
+	}
+
+	history := hs.BackendPluginManager.HealthCheckHistory(ds.Type, ds.Uid)
+	checks := make([]dtos.DataSourceHealthCheck, 0, len(history.Statuses))
+	for _, status := range history.Statuses {
+		check := dtos.DataSourceHealthCheck{CheckedAt: status.CheckedAt}
+		switch {
+		case status.Error != nil:
+			check.Status = backend.HealthStatusError.String()
+			check.Message = status.Error.Error()
+		case status.Result != nil:
+			check.Status = status.Result.Status.String()
+			check.Message = status.Result.Message
+		}
+		checks = append(checks, check)
+	}
+
+	return response.JSON(200, dtos.DataSourceHealthHistory{
+		Flapping: history.Flapping,
+		Checks:   checks,
+	})
+}
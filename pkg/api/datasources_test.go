@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
@@ -78,9 +79,10 @@ func TestAddDataSource_InvalidURL(t *testing.T) {
 	defer bus.ClearBusHandlers()
 
 	sc := setupScenarioContext(t, "/api/datasources")
+	hs := &HTTPServer{BackendPluginManager: &fakeBackendPluginManager{}}
 
 	sc.m.Post(sc.url, routing.Wrap(func(c *models.ReqContext) response.Response {
-		return AddDataSource(c, models.AddDataSourceCommand{
+		return hs.AddDataSource(c, models.AddDataSourceCommand{
 			Name: "Test",
 			Url:  "invalid:url",
 		})
@@ -108,9 +110,10 @@ func TestAddDataSource_URLWithoutProtocol(t *testing.T) {
 	})
 
 	sc := setupScenarioContext(t, "/api/datasources")
+	hs := &HTTPServer{BackendPluginManager: &fakeBackendPluginManager{}}
 
 	sc.m.Post(sc.url, routing.Wrap(func(c *models.ReqContext) response.Response {
-		return AddDataSource(c, models.AddDataSourceCommand{
+		return hs.AddDataSource(c, models.AddDataSourceCommand{
 			Name: name,
 			Url:  url,
 		})
@@ -121,14 +124,59 @@ func TestAddDataSource_URLWithoutProtocol(t *testing.T) {
 	assert.Equal(t, 200, sc.resp.Code)
 }
 
+// Adding data sources with settings their plugin rejects should lead to an error.
+func TestAddDataSource_InvalidConfig(t *testing.T) {
+	defer bus.ClearBusHandlers()
+
+	bus.AddHandler("sql", func(cmd *models.AddDataSourceCommand) error {
+		cmd.Result = &models.DataSource{}
+		return nil
+	})
+
+	sc := setupScenarioContext(t, "/api/datasources")
+	backendPluginManager := &fakeBackendPluginManager{
+		registered: true,
+		validationResult: &backendplugin.ValidateConfigResult{
+			Errors: []backendplugin.ConfigFieldError{{Field: "url", Message: "URL must use HTTPS"}},
+		},
+	}
+	hs := &HTTPServer{BackendPluginManager: backendPluginManager}
+
+	sc.m.Post(sc.url, routing.Wrap(func(c *models.ReqContext) response.Response {
+		return hs.AddDataSource(c, models.AddDataSourceCommand{
+			Name:           "Test",
+			Type:           "test-datasource",
+			Url:            "http://localhost:5432",
+			SecureJsonData: map[string]string{"password": "secret"},
+		})
+	}))
+
+	sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+
+	assert.Equal(t, 400, sc.resp.Code)
+	assert.JSONEq(t, `{"message":"Invalid datasource settings","errors":[{"field":"url","message":"URL must use HTTPS"}]}`,
+		sc.resp.Body.String())
+	require.NotNil(t, backendPluginManager.validated)
+	assert.Equal(t, "test-datasource", backendPluginManager.validated.PluginID)
+	assert.Equal(t, "http://localhost:5432", backendPluginManager.validated.DataSourceInstanceSettings.URL)
+	assert.Equal(t, map[string]string{"password": "secret"},
+		backendPluginManager.validated.DataSourceInstanceSettings.DecryptedSecureJSONData)
+
+	backendPluginManager.validationResult = &backendplugin.ValidateConfigResult{}
+	sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+
+	assert.Equal(t, 200, sc.resp.Code)
+}
+
 // Updating data sources with invalid URLs should lead to an error.
 func TestUpdateDataSource_InvalidURL(t *testing.T) {
 	defer bus.ClearBusHandlers()
 
 	sc := setupScenarioContext(t, "/api/datasources/1234")
+	hs := &HTTPServer{BackendPluginManager: &fakeBackendPluginManager{}}
 
 	sc.m.Put(sc.url, routing.Wrap(func(c *models.ReqContext) response.Response {
-		return AddDataSource(c, models.AddDataSourceCommand{
+		return hs.AddDataSource(c, models.AddDataSourceCommand{
 			Name: "Test",
 			Url:  "invalid:url",
 		})
@@ -156,9 +204,10 @@ func TestUpdateDataSource_URLWithoutProtocol(t *testing.T) {
 	})
 
 	sc := setupScenarioContext(t, "/api/datasources/1234")
+	hs := &HTTPServer{BackendPluginManager: &fakeBackendPluginManager{}}
 
 	sc.m.Put(sc.url, routing.Wrap(func(c *models.ReqContext) response.Response {
-		return AddDataSource(c, models.AddDataSourceCommand{
+		return hs.AddDataSource(c, models.AddDataSourceCommand{
 			Name: name,
 			Url:  url,
 		})
@@ -2,6 +2,7 @@ package dtos
 
 import (
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
@@ -51,6 +52,17 @@ type DataSourceListItemDTO struct {
 
 type DataSourceList []DataSourceListItemDTO
 
+type DataSourceHealthCheck struct {
+	Status    string    `json:"status"`
+	Message   string    `json:"message"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+type DataSourceHealthHistory struct {
+	Flapping bool                    `json:"flapping"`
+	Checks   []DataSourceHealthCheck `json:"checks"`
+}
+
 func (slice DataSourceList) Len() int {
 	return len(slice)
 }
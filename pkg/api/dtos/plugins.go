@@ -1,8 +1,13 @@
 package dtos
 
 import (
+	"sort"
+	"time"
+
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 )
 
 type PluginSetting struct {
@@ -19,29 +24,219 @@ type PluginSetting struct {
 	JsonData      map[string]interface{}      `json:"jsonData"`
 	DefaultNavUrl string                      `json:"defaultNavUrl"`
 
-	LatestVersion string                        `json:"latestVersion"`
-	HasUpdate     bool                          `json:"hasUpdate"`
-	State         plugins.PluginState           `json:"state"`
-	Signature     plugins.PluginSignatureStatus `json:"signature"`
-	SignatureType plugins.PluginSignatureType   `json:"signatureType"`
-	SignatureOrg  string                        `json:"signatureOrg"`
+	LatestVersion   string                        `json:"latestVersion"`
+	HasUpdate       bool                          `json:"hasUpdate"`
+	CanaryVersion   string                        `json:"canaryVersion,omitempty"`
+	State           plugins.PluginState           `json:"state"`
+	Signature       plugins.PluginSignatureStatus `json:"signature"`
+	SignatureType   plugins.PluginSignatureType   `json:"signatureType"`
+	SignatureOrg    string                        `json:"signatureOrg"`
+	AngularDetected bool                          `json:"angularDetected"`
+	// Deprecation is why the plugin is deprecated, by its metadata or the plugin catalog, nil if it isn't.
+	Deprecation *plugins.PluginDeprecation `json:"deprecation,omitempty"`
+
+	// Parent is the app plugin bundling the plugin, and Children are the plugins bundled by an app plugin.
+	Parent   *PluginReference   `json:"parent,omitempty"`
+	Children []*PluginReference `json:"children,omitempty"`
 }
 
+// Optional fields of listed plugins, which are only included if requested.
+const (
+	PluginListFieldSignedFiles = "signedFiles"
+	PluginListFieldIncludes    = "includes"
+	PluginListFieldRuntime     = "runtime"
+)
+
+// PluginListFields are the optional fields of listed plugins.
+var PluginListFields = []string{PluginListFieldSignedFiles, PluginListFieldIncludes, PluginListFieldRuntime}
+
+// PluginListItem is a listed plugin. Its JSON shape is part of the HTTP API, so fields may be added but not changed.
 type PluginListItem struct {
-	Name          string                        `json:"name"`
-	Type          string                        `json:"type"`
-	Id            string                        `json:"id"`
-	Enabled       bool                          `json:"enabled"`
-	Pinned        bool                          `json:"pinned"`
-	Info          *plugins.PluginInfo           `json:"info"`
-	LatestVersion string                        `json:"latestVersion"`
-	HasUpdate     bool                          `json:"hasUpdate"`
-	DefaultNavUrl string                        `json:"defaultNavUrl"`
-	Category      string                        `json:"category"`
-	State         plugins.PluginState           `json:"state"`
-	Signature     plugins.PluginSignatureStatus `json:"signature"`
-	SignatureType plugins.PluginSignatureType   `json:"signatureType"`
-	SignatureOrg  string                        `json:"signatureOrg"`
+	Name            string                        `json:"name"`
+	Type            string                        `json:"type"`
+	Id              string                        `json:"id"`
+	Enabled         bool                          `json:"enabled"`
+	Pinned          bool                          `json:"pinned"`
+	Info            *PluginInfo                   `json:"info"`
+	LatestVersion   string                        `json:"latestVersion"`
+	HasUpdate       bool                          `json:"hasUpdate"`
+	DefaultNavUrl   string                        `json:"defaultNavUrl"`
+	Category        string                        `json:"category"`
+	State           plugins.PluginState           `json:"state"`
+	Signature       plugins.PluginSignatureStatus `json:"signature"`
+	SignatureType   plugins.PluginSignatureType   `json:"signatureType"`
+	SignatureOrg    string                        `json:"signatureOrg"`
+	AngularDetected bool                          `json:"angularDetected"`
+	// Deprecation is why the plugin is deprecated, by its metadata or the plugin catalog, nil if it isn't.
+	Deprecation *plugins.PluginDeprecation `json:"deprecation,omitempty"`
+
+	// Parent is the app plugin bundling the plugin, and Children are the plugins bundled by an app plugin.
+	Parent   *PluginReference   `json:"parent,omitempty"`
+	Children []*PluginReference `json:"children,omitempty"`
+
+	// SignedFiles are the files covered by the signature of the plugin, sorted.
+	SignedFiles []string         `json:"signedFiles,omitempty"`
+	Includes    []*PluginInclude `json:"includes,omitempty"`
+	// Runtime is the state of the backend process of the plugin, nil if it has no registered backend.
+	Runtime *PluginRuntime `json:"runtime,omitempty"`
+}
+
+// PluginReference refers to a related plugin.
+type PluginReference struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// PluginInfo is the metadata of a plugin.
+type PluginInfo struct {
+	Author      PluginInfoLink     `json:"author"`
+	Description string             `json:"description"`
+	Links       []PluginInfoLink   `json:"links"`
+	Logos       PluginLogos        `json:"logos"`
+	Build       PluginBuildInfo    `json:"build"`
+	Screenshots []PluginScreenshot `json:"screenshots"`
+	Version     string             `json:"version"`
+	Updated     string             `json:"updated"`
+	Keywords    []string           `json:"keywords,omitempty"`
+}
+
+type PluginInfoLink struct {
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
+
+type PluginLogos struct {
+	Small string `json:"small"`
+	Large string `json:"large"`
+}
+
+type PluginBuildInfo struct {
+	Time   int64  `json:"time,omitempty"`
+	Repo   string `json:"repo,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+type PluginScreenshot struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+// PluginInclude is a page, dashboard or plugin included in a plugin.
+type PluginInclude struct {
+	Name       string          `json:"name"`
+	Path       string          `json:"path"`
+	Type       string          `json:"type"`
+	Component  string          `json:"component"`
+	Role       models.RoleType `json:"role"`
+	AddToNav   bool            `json:"addToNav"`
+	DefaultNav bool            `json:"defaultNav"`
+	Slug       string          `json:"slug"`
+	Icon       string          `json:"icon"`
+	UID        string          `json:"uid"`
+}
+
+// PluginRuntime is the state of the backend process of a plugin.
+type PluginRuntime struct {
+	Status        backendplugin.PluginStatus `json:"status"`
+	Managed       bool                       `json:"managed"`
+	LastError     string                     `json:"lastError,omitempty"`
+	Restarts      int                        `json:"restarts"`
+	LastRestart   *time.Time                 `json:"lastRestart,omitempty"`
+	StartedAt     *time.Time                 `json:"startedAt,omitempty"`
+	UptimeSeconds int64                      `json:"uptimeSeconds"`
+}
+
+// NewPluginRelatives returns the references to the parent and the children of a plugin, sorted by ID.
+func NewPluginRelatives(plugin *plugins.PluginBase) (*PluginReference, []*PluginReference) {
+	var parent *PluginReference
+	if plugin.Parent != nil {
+		parent = &PluginReference{Id: plugin.Parent.Id, Name: plugin.Parent.Name, Type: plugin.Parent.Type}
+	}
+
+	var children []*PluginReference
+	for _, child := range plugin.Children {
+		children = append(children, &PluginReference{Id: child.Id, Name: child.Name, Type: child.Type})
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Id < children[j].Id
+	})
+
+	return parent, children
+}
+
+func NewPluginInfo(info plugins.PluginInfo) *PluginInfo {
+	result := &PluginInfo{
+		Author:      PluginInfoLink{Name: info.Author.Name, Url: info.Author.Url},
+		Description: info.Description,
+		Logos:       PluginLogos{Small: info.Logos.Small, Large: info.Logos.Large},
+		Build: PluginBuildInfo{
+			Time:   info.Build.Time,
+			Repo:   info.Build.Repo,
+			Branch: info.Build.Branch,
+			Hash:   info.Build.Hash,
+		},
+		Version:  info.Version,
+		Updated:  info.Updated,
+		Keywords: info.Keywords,
+	}
+
+	// nil slices are kept, so that they are marshaled as null like before
+	if info.Links != nil {
+		result.Links = make([]PluginInfoLink, 0, len(info.Links))
+		for _, link := range info.Links {
+			result.Links = append(result.Links, PluginInfoLink{Name: link.Name, Url: link.Url})
+		}
+	}
+	if info.Screenshots != nil {
+		result.Screenshots = make([]PluginScreenshot, 0, len(info.Screenshots))
+		for _, screenshot := range info.Screenshots {
+			result.Screenshots = append(result.Screenshots, PluginScreenshot{Path: screenshot.Path, Name: screenshot.Name})
+		}
+	}
+
+	return result
+}
+
+func NewPluginIncludes(includes []*plugins.PluginInclude) []*PluginInclude {
+	result := make([]*PluginInclude, 0, len(includes))
+	for _, include := range includes {
+		result = append(result, &PluginInclude{
+			Name:       include.Name,
+			Path:       include.Path,
+			Type:       include.Type,
+			Component:  include.Component,
+			Role:       include.Role,
+			AddToNav:   include.AddToNav,
+			DefaultNav: include.DefaultNav,
+			Slug:       include.Slug,
+			Icon:       include.Icon,
+			UID:        include.UID,
+		})
+	}
+	return result
+}
+
+func NewPluginSignedFiles(files plugins.PluginFiles) []string {
+	result := make([]string, 0, len(files))
+	for file := range files {
+		result = append(result, file)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func NewPluginRuntime(process *plugins.PluginProcessState) *PluginRuntime {
+	return &PluginRuntime{
+		Status:        process.Status,
+		Managed:       process.Managed,
+		LastError:     process.LastError,
+		Restarts:      process.Restarts,
+		LastRestart:   process.LastRestart,
+		StartedAt:     process.StartedAt,
+		UptimeSeconds: process.UptimeSeconds,
+	}
 }
 
 type PluginList []PluginListItem
@@ -58,6 +253,14 @@ func (slice PluginList) Swap(i, j int) {
 	slice[i], slice[j] = slice[j], slice[i]
 }
 
+// SearchPluginsResult is a page of plugins matching a plugin search.
+type SearchPluginsResult struct {
+	TotalCount int        `json:"totalCount"`
+	Plugins    PluginList `json:"plugins"`
+	Page       int        `json:"page"`
+	PerPage    int        `json:"perPage"`
+}
+
 type ImportDashboardCommand struct {
 	PluginId  string                         `json:"pluginId"`
 	Path      string                         `json:"path"`
@@ -70,4 +273,30 @@ type ImportDashboardCommand struct {
 
 type InstallPluginCommand struct {
 	Version string `json:"version"`
+	// AllowDowngrade allows replacing an installed plugin with a lower version.
+	AllowDowngrade bool `json:"allowDowngrade"`
+}
+
+type InstalledPlugin struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+}
+
+type PluginCanary struct {
+	Id      string `json:"id"`
+	Version string `json:"version"`
+}
+
+// ScanPluginsCommand requests a scan of plugin directories, which reports the plugins that would be loaded from them
+// without loading them.
+type ScanPluginsCommand struct {
+	Paths []string `json:"paths" binding:"Required"`
+	// AllowUnsigned scans the directories like the core plugin directories, whose plugins don't need a signature.
+	AllowUnsigned bool `json:"allowUnsigned"`
+}
+
+type UpdatePluginLogLevelCommand struct {
+	Level string `json:"level" binding:"Required"`
 }
@@ -0,0 +1,30 @@
+package dtos
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPluginRelatives(t *testing.T) {
+	app := &plugins.PluginBase{Id: "test-app", Name: "Test App", Type: "app"}
+	panel := &plugins.PluginBase{Id: "test-panel", Name: "Test Panel", Type: "panel", Parent: app}
+	datasource := &plugins.PluginBase{Id: "test-datasource", Name: "Test Data Source", Type: "datasource", Parent: app}
+	app.Children = []*plugins.PluginBase{panel, datasource}
+
+	t.Run("app plugins refer to their children", func(t *testing.T) {
+		parent, children := NewPluginRelatives(app)
+		assert.Nil(t, parent)
+		assert.Equal(t, []*PluginReference{
+			{Id: "test-datasource", Name: "Test Data Source", Type: "datasource"},
+			{Id: "test-panel", Name: "Test Panel", Type: "panel"},
+		}, children)
+	})
+
+	t.Run("bundled plugins refer to their parent", func(t *testing.T) {
+		parent, children := NewPluginRelatives(panel)
+		assert.Equal(t, &PluginReference{Id: "test-app", Name: "Test App", Type: "app"}, parent)
+		assert.Empty(t, children)
+	})
+}
@@ -5,7 +5,16 @@ import "github.com/grafana/grafana/pkg/plugins"
 type fakePluginManager struct {
 	plugins.Manager
 
-	staticRoutes []*plugins.PluginStaticRoute
+	staticRoutes  []*plugins.PluginStaticRoute
+	pluginsHealth plugins.PluginsHealth
+	pluginStates  []plugins.PluginRuntimeState
+	listedPlugins []plugins.PluginListItem
+	// listQuery is the query of the last call of ListPlugins.
+	listQuery plugins.PluginListQuery
+	// scannedPlugins is returned by Scan, which records the arguments of its last call.
+	scannedPlugins []plugins.ScannedPlugin
+	scanDirs       []string
+	scanSigned     bool
 }
 
 func (pm *fakePluginManager) GetPlugin(id string) *plugins.PluginBase {
@@ -23,3 +32,26 @@ func (pm *fakePluginManager) Renderer() *plugins.RendererPlugin {
 func (pm *fakePluginManager) StaticRoutes() []*plugins.PluginStaticRoute {
 	return pm.staticRoutes
 }
+
+func (pm *fakePluginManager) PluginsHealth() plugins.PluginsHealth {
+	return pm.pluginsHealth
+}
+
+func (pm *fakePluginManager) PluginStates() []plugins.PluginRuntimeState {
+	return pm.pluginStates
+}
+
+func (pm *fakePluginManager) CanaryVersion(pluginID string) (string, bool) {
+	return "", false
+}
+
+func (pm *fakePluginManager) ListPlugins(query plugins.PluginListQuery) (plugins.PluginListResult, error) {
+	pm.listQuery = query
+	return plugins.PluginListResult{Plugins: pm.listedPlugins, TotalCount: len(pm.listedPlugins)}, nil
+}
+
+func (pm *fakePluginManager) Scan(pluginDirs []string, requireSigned bool) ([]plugins.ScannedPlugin, error) {
+	pm.scanDirs = pluginDirs
+	pm.scanSigned = requireSigned
+	return pm.scannedPlugins, nil
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/require"
 	macaron "gopkg.in/macaron.v1"
@@ -167,6 +169,63 @@ func TestHealthAPI_DatabaseHealthCached(t *testing.T) {
 	require.True(t, healthy.(bool))
 }
 
+func TestHealthAPI_Plugins(t *testing.T) {
+	pm := &fakePluginManager{}
+	m, _ := setupHealthAPITestEnvironment(t)
+	hs := &HTTPServer{PluginManager: pm}
+	m.Get("/api/health/plugins", hs.apiPluginsHealthHandler)
+
+	t.Run("Should return plugins health without the status of each plugin", func(t *testing.T) {
+		pm.pluginsHealth = plugins.PluginsHealth{
+			Running: 1,
+			Plugins: []plugins.PluginHealth{{ID: "test", Status: backendplugin.PluginStatusRunning, Managed: true}},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/health/plugins", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+
+		require.Equal(t, 200, rec.Code)
+		expectedBody := `
+			{
+				"notStarted": 0,
+				"running": 1,
+				"degraded": 0,
+				"crashLooping": 0,
+				"decommissioned": 0,
+				"failed": 0
+			}
+		`
+		require.JSONEq(t, expectedBody, rec.Body.String())
+	})
+
+	t.Run("Should return 503 when a plugin is crash-looping", func(t *testing.T) {
+		pm.pluginsHealth = plugins.PluginsHealth{
+			CrashLooping: 1,
+			Plugins:      []plugins.PluginHealth{{ID: "test", Status: backendplugin.PluginStatusCrashLooping, Restarts: 3}},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/health/plugins", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+
+		require.Equal(t, 503, rec.Code)
+	})
+
+	t.Run("Should return 503 when a plugin failed", func(t *testing.T) {
+		pm.pluginsHealth = plugins.PluginsHealth{
+			Failed:  1,
+			Plugins: []plugins.PluginHealth{{ID: "test", Status: backendplugin.PluginStatusFailed, Restarts: 11}},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/health/plugins", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+
+		require.Equal(t, 503, rec.Code)
+	})
+}
+
 func setupHealthAPITestEnvironment(t *testing.T, cbs ...func(*setting.Cfg)) (*macaron.Macaron, *HTTPServer) {
 	t.Helper()
 
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -419,6 +420,7 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 	// and should not be redirected or rejected.
 	m.Use(hs.healthzHandler)
 	m.Use(hs.apiHealthHandler)
+	m.Use(hs.apiPluginsHealthHandler)
 	m.Use(hs.metricsEndpoint)
 
 	m.Use(hs.ContextHandler.Middleware)
@@ -442,7 +444,17 @@ func (hs *HTTPServer) metricsEndpoint(ctx *macaron.Context) {
 		return
 	}
 
-	if ctx.Req.Method != http.MethodGet || ctx.Req.URL.Path != "/metrics" {
+	if ctx.Req.Method != http.MethodGet {
+		return
+	}
+
+	var gatherer prometheus.Gatherer
+	switch ctx.Req.URL.Path {
+	case "/metrics":
+		gatherer = prometheus.DefaultGatherer
+	case "/metrics/plugins":
+		gatherer = prometheus.GathererFunc(hs.BackendPluginManager.GatherMetrics)
+	default:
 		return
 	}
 
@@ -452,7 +464,7 @@ func (hs *HTTPServer) metricsEndpoint(ctx *macaron.Context) {
 	}
 
 	promhttp.
-		HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}).
+		HandlerFor(gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}).
 		ServeHTTP(ctx.Resp, ctx.Req)
 }
 
@@ -506,6 +518,37 @@ func (hs *HTTPServer) apiHealthHandler(ctx *macaron.Context) {
 	}
 }
 
+// apiPluginsHealthHandler returns an aggregated health summary of the backend plugin processes. The endpoint
+// isn't authenticated, so only the number of plugins in each status is returned; the status of each plugin is
+// available to Grafana admins through the plugin states API.
+// If any backend plugin is crash-looping or failed it will return http status code 503.
+func (hs *HTTPServer) apiPluginsHealthHandler(ctx *macaron.Context) {
+	notHeadOrGet := ctx.Req.Method != http.MethodGet && ctx.Req.Method != http.MethodHead
+	if notHeadOrGet || ctx.Req.URL.Path != "/api/health/plugins" {
+		return
+	}
+
+	health := hs.PluginManager.PluginsHealth()
+	health.Plugins = nil
+	dataBytes, err := json.MarshalIndent(health, "", "  ")
+	if err != nil {
+		hs.log.Error("Failed to encode data", "err", err)
+		ctx.Resp.WriteHeader(500)
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if health.Healthy() {
+		ctx.Resp.WriteHeader(200)
+	} else {
+		ctx.Resp.WriteHeader(503)
+	}
+
+	if _, err := ctx.Resp.Write(dataBytes); err != nil {
+		hs.log.Error("Failed to write to response", "err", err)
+	}
+}
+
 func (hs *HTTPServer) mapStatic(m *macaron.Macaron, rootDir string, dir string, prefix string) {
 	headers := func(c *macaron.Context) {
 		c.Resp.Header().Set("Cache-Control", "public, max-age=3600")
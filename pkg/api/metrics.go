@@ -12,6 +12,8 @@ import (
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	jsoniter "github.com/json-iterator/go"
 )
 
 // QueryMetricsV2 returns query metrics.
@@ -82,9 +84,17 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDTO dtos.MetricReq
 		return response.Error(http.StatusForbidden, "Access denied", err)
 	}
 
+	if c.QueryBool("stream") {
+		return &queryDataStreamResponse{
+			stream: func(sender backendplugin.QueryDataResponseSender) error {
+				return hs.DataService.HandleStreamingRequest(c.Req.Context(), ds, request, sender)
+			},
+		}
+	}
+
 	resp, err := hs.DataService.HandleRequest(c.Req.Context(), ds, request)
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Metric request error", err)
+		return metricRequestErrorResponse(err)
 	}
 
 	// This is insanity... but ¯\_(ツ)_/¯, the current query path looks like:
@@ -97,6 +107,70 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDTO dtos.MetricReq
 	return toMacronResponse(qdr)
 }
 
+func metricRequestErrorResponse(err error) response.Response {
+	code := backendplugin.ErrorCodeOf(err)
+	switch code {
+	case backendplugin.ErrorCodeCrashed:
+		return response.ErrorWithCode(code.HTTPStatus(), "Plugin crashed", string(code), err)
+	case backendplugin.ErrorCodeTimeout:
+		return response.ErrorWithCode(code.HTTPStatus(), "Metric request timed out", string(code), err)
+	case backendplugin.ErrorCodeTooManyRequests:
+		return response.ErrorWithCode(code.HTTPStatus(), "Too many concurrent queries", string(code), err)
+	default:
+		return response.ErrorWithCode(code.HTTPStatus(), "Metric request error", string(code), err)
+	}
+}
+
+// queryDataStreamResponse streams the result of each query to the client as soon as it's available, as a line of
+// newline delimited JSON in the format of a regular query response containing a single result.
+type queryDataStreamResponse struct {
+	stream func(sender backendplugin.QueryDataResponseSender) error
+}
+
+// Status gets the response's status.
+// Required to implement api.Response.
+func (r *queryDataStreamResponse) Status() int {
+	return http.StatusOK
+}
+
+// Body gets the response's body.
+// Required to implement api.Response.
+func (r *queryDataStreamResponse) Body() []byte {
+	return nil
+}
+
+// WriteTo writes the response to the provided context.
+// Required to implement api.Response.
+func (r *queryDataStreamResponse) WriteTo(c *models.ReqContext) {
+	enc := jsoniter.ConfigCompatibleWithStandardLibrary.NewEncoder(c.Resp)
+	err := r.stream(backendplugin.QueryDataResponseSenderFunc(func(refID string, resp backend.DataResponse) error {
+		if !c.Resp.Written() {
+			c.Resp.Header().Set("Content-Type", "application/x-ndjson")
+			c.Resp.WriteHeader(http.StatusOK)
+		}
+
+		qdr := &backend.QueryDataResponse{Responses: backend.Responses{refID: resp}}
+		if err := enc.Encode(qdr); err != nil {
+			return err
+		}
+		c.Resp.Flush()
+		return nil
+	}))
+	if err == nil {
+		return
+	}
+
+	if !c.Resp.Written() {
+		metricRequestErrorResponse(err).WriteTo(c)
+		return
+	}
+
+	c.Logger.Error("Streaming metric request failed", "error", err)
+	if encodeErr := enc.Encode(map[string]string{"error": err.Error()}); encodeErr != nil {
+		c.Logger.Error("Error writing to response", "err", encodeErr)
+	}
+}
+
 func toMacronResponse(qdr *backend.QueryDataResponse) response.Response {
 	statusCode := http.StatusOK
 	for _, res := range qdr.Responses {
@@ -208,7 +282,7 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext, reqDto dtos.MetricReque
 
 	resp, err := hs.DataService.HandleRequest(c.Req.Context(), ds, request)
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Metric request error", err)
+		return metricRequestErrorResponse(err)
 	}
 
 	statusCode := http.StatusOK
@@ -3,10 +3,12 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
-	"sort"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/api/dtos"
@@ -14,90 +16,189 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/adapters"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/plugins/manager/installer"
+	"github.com/grafana/grafana/pkg/plugins/secrets"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 	macaron "gopkg.in/macaron.v1"
 )
 
 func (hs *HTTPServer) GetPluginList(c *models.ReqContext) response.Response {
-	typeFilter := c.Query("type")
-	enabledFilter := c.Query("enabled")
-	embeddedFilter := c.Query("embedded")
-	coreFilter := c.Query("core")
-
-	// For users with viewer role we only return core plugins
-	if !c.HasRole(models.ROLE_ADMIN) {
-		coreFilter = "1"
+	fields, err := pluginListFields(c)
+	if err != nil {
+		return response.Error(400, err.Error(), nil)
 	}
 
-	pluginSettingsMap, err := hs.PluginManager.GetPluginSettings(c.OrgId)
+	result, err := hs.PluginManager.ListPlugins(pluginListQuery(c))
 	if err != nil {
 		return response.Error(500, "Failed to get list of plugins", err)
 	}
 
-	result := make(dtos.PluginList, 0)
-	for _, pluginDef := range hs.PluginManager.Plugins() {
-		// filter out app sub plugins
-		if embeddedFilter == "0" && pluginDef.IncludedInAppId != "" {
-			continue
-		}
+	return response.JSON(200, hs.pluginListItems(result.Plugins, fields))
+}
+
+// GET /api/plugins/search
+func (hs *HTTPServer) SearchPlugins(c *models.ReqContext) response.Response {
+	perPage := c.QueryInt("perpage")
+	if perPage <= 0 {
+		perPage = 1000
+	}
+	page := c.QueryInt("page")
+	if page < 1 {
+		page = 1
+	}
+	fields, err := pluginListFields(c)
+	if err != nil {
+		return response.Error(400, err.Error(), nil)
+	}
+
+	query := pluginListQuery(c)
+	query.Page = page
+	query.PerPage = perPage
+
+	result, err := hs.PluginManager.ListPlugins(query)
+	if err != nil {
+		return response.Error(500, "Failed to search plugins", err)
+	}
+
+	return response.JSON(200, dtos.SearchPluginsResult{
+		TotalCount: result.TotalCount,
+		Plugins:    hs.pluginListItems(result.Plugins, fields),
+		Page:       page,
+		PerPage:    perPage,
+	})
+}
+
+// pluginListQuery returns the plugin list query of the filters of a plugin list request. Users who aren't admins
+// only get core plugins listed.
+func pluginListQuery(c *models.ReqContext) plugins.PluginListQuery {
+	query := plugins.PluginListQuery{
+		OrgID:     c.OrgId,
+		Query:     c.Query("query"),
+		Category:  c.Query("category"),
+		Keywords:  c.QueryStrings("keyword"),
+		Signature: plugins.PluginSignatureStatus(c.Query("signature")),
+		State:     plugins.PluginState(c.Query("state")),
+	}
+
+	if typeFilter := c.Query("type"); typeFilter != "" {
+		query.Types = []string{typeFilter}
+	}
+
+	// only disabled plugins and app sub plugins can be filtered out
+	if c.Query("enabled") == "1" {
+		query.Enabled = boolFilter(true)
+	}
+	if c.Query("embedded") == "0" {
+		query.Embedded = boolFilter(false)
+	}
+
+	switch c.Query("core") {
+	case "0":
+		query.Core = boolFilter(false)
+	case "1":
+		query.Core = boolFilter(true)
+	}
+	if !c.HasRole(models.ROLE_ADMIN) {
+		query.Core = boolFilter(true)
+	}
+
+	switch c.Query("hasUpdate") {
+	case "0":
+		query.HasUpdate = boolFilter(false)
+	case "1":
+		query.HasUpdate = boolFilter(true)
+	}
+
+	return query
+}
 
-		// filter out core plugins
-		if (coreFilter == "0" && pluginDef.IsCorePlugin) || (coreFilter == "1" && !pluginDef.IsCorePlugin) {
+func boolFilter(b bool) *bool {
+	return &b
+}
+
+// pluginListFields returns the optional fields of listed plugins requested by the comma separated fields query
+// parameter of a plugin list request.
+func pluginListFields(c *models.ReqContext) (map[string]bool, error) {
+	fields := map[string]bool{}
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
 			continue
 		}
 
-		// filter on type
-		if typeFilter != "" && typeFilter != pluginDef.Type {
-			continue
+		valid := false
+		for _, f := range dtos.PluginListFields {
+			valid = valid || f == field
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown plugin field %q, supported fields are %s", field,
+				strings.Join(dtos.PluginListFields, ", "))
 		}
+		fields[field] = true
+	}
+	return fields, nil
+}
 
-		if pluginDef.State == plugins.PluginStateAlpha && !hs.Cfg.PluginsEnableAlpha {
-			continue
+// pluginListItems returns the DTOs of listed plugins, including the requested optional fields.
+func (hs *HTTPServer) pluginListItems(items []plugins.PluginListItem, fields map[string]bool) dtos.PluginList {
+	var processes map[string]*plugins.PluginProcessState
+	if fields[dtos.PluginListFieldRuntime] {
+		processes = map[string]*plugins.PluginProcessState{}
+		for _, state := range hs.PluginManager.PluginStates() {
+			if state.Process != nil {
+				processes[state.ID] = state.Process
+			}
 		}
+	}
 
+	result := make(dtos.PluginList, 0, len(items))
+	for _, item := range items {
+		pluginDef := item.Plugin
 		listItem := dtos.PluginListItem{
-			Id:            pluginDef.Id,
-			Name:          pluginDef.Name,
-			Type:          pluginDef.Type,
-			Category:      pluginDef.Category,
-			Info:          &pluginDef.Info,
-			LatestVersion: pluginDef.GrafanaNetVersion,
-			HasUpdate:     pluginDef.GrafanaNetHasUpdate,
-			DefaultNavUrl: pluginDef.DefaultNavUrl,
-			State:         pluginDef.State,
-			Signature:     pluginDef.Signature,
-			SignatureType: pluginDef.SignatureType,
-			SignatureOrg:  pluginDef.SignatureOrg,
+			Id:              pluginDef.Id,
+			Name:            pluginDef.Name,
+			Type:            pluginDef.Type,
+			Category:        pluginDef.Category,
+			Info:            dtos.NewPluginInfo(pluginDef.Info),
+			LatestVersion:   pluginDef.GrafanaNetVersion,
+			HasUpdate:       pluginDef.GrafanaNetHasUpdate,
+			DefaultNavUrl:   pluginDef.DefaultNavUrl,
+			State:           pluginDef.State,
+			Signature:       pluginDef.Signature,
+			SignatureType:   pluginDef.SignatureType,
+			SignatureOrg:    pluginDef.SignatureOrg,
+			AngularDetected: pluginDef.AngularDetected,
+			Deprecation:     pluginDef.DeprecationInfo(),
 		}
+		listItem.Parent, listItem.Children = dtos.NewPluginRelatives(pluginDef)
 
-		if pluginSetting, exists := pluginSettingsMap[pluginDef.Id]; exists {
-			listItem.Enabled = pluginSetting.Enabled
-			listItem.Pinned = pluginSetting.Pinned
+		if item.Settings != nil {
+			listItem.Enabled = item.Settings.Enabled
+			listItem.Pinned = item.Settings.Pinned
 		}
 
 		if listItem.DefaultNavUrl == "" || !listItem.Enabled {
 			listItem.DefaultNavUrl = hs.Cfg.AppSubURL + "/plugins/" + listItem.Id + "/"
 		}
 
-		// filter out disabled plugins
-		if enabledFilter == "1" && !listItem.Enabled {
-			continue
+		if fields[dtos.PluginListFieldSignedFiles] {
+			listItem.SignedFiles = dtos.NewPluginSignedFiles(pluginDef.SignedFiles)
 		}
-
-		// filter out built in data sources
-		if ds := hs.PluginManager.GetDataSource(pluginDef.Id); ds != nil {
-			if ds.BuiltIn {
-				continue
-			}
+		if fields[dtos.PluginListFieldIncludes] {
+			listItem.Includes = dtos.NewPluginIncludes(pluginDef.Includes)
+		}
+		if process, exists := processes[pluginDef.Id]; exists {
+			listItem.Runtime = dtos.NewPluginRuntime(process)
 		}
 
 		result = append(result, listItem)
 	}
 
-	sort.Sort(result)
-	return response.JSON(200, result)
+	return result
 }
 
 func (hs *HTTPServer) GetPluginSettingByID(c *models.ReqContext) response.Response {
@@ -109,37 +210,46 @@ func (hs *HTTPServer) GetPluginSettingByID(c *models.ReqContext) response.Respon
 	}
 
 	dto := &dtos.PluginSetting{
-		Type:          def.Type,
-		Id:            def.Id,
-		Name:          def.Name,
-		Info:          &def.Info,
-		Dependencies:  &def.Dependencies,
-		Includes:      def.Includes,
-		BaseUrl:       def.BaseUrl,
-		Module:        def.Module,
-		DefaultNavUrl: def.DefaultNavUrl,
-		LatestVersion: def.GrafanaNetVersion,
-		HasUpdate:     def.GrafanaNetHasUpdate,
-		State:         def.State,
-		Signature:     def.Signature,
-		SignatureType: def.SignatureType,
-		SignatureOrg:  def.SignatureOrg,
-	}
+		Type:            def.Type,
+		Id:              def.Id,
+		Name:            def.Name,
+		Info:            &def.Info,
+		Dependencies:    &def.Dependencies,
+		Includes:        def.Includes,
+		BaseUrl:         def.BaseUrl,
+		Module:          def.Module,
+		DefaultNavUrl:   def.DefaultNavUrl,
+		LatestVersion:   def.GrafanaNetVersion,
+		HasUpdate:       def.GrafanaNetHasUpdate,
+		State:           def.State,
+		Signature:       def.Signature,
+		SignatureType:   def.SignatureType,
+		SignatureOrg:    def.SignatureOrg,
+		AngularDetected: def.AngularDetected,
+		Deprecation:     def.DeprecationInfo(),
+	}
+	dto.Parent, dto.Children = dtos.NewPluginRelatives(def)
+	dto.CanaryVersion, _ = hs.PluginManager.CanaryVersion(def.Id)
 
 	if app := hs.PluginManager.GetApp(def.Id); app != nil {
-		dto.Enabled = app.AutoEnabled
-		dto.Pinned = app.AutoEnabled
-	}
-
-	query := models.GetPluginSettingByIdQuery{PluginId: pluginID, OrgId: c.OrgId}
-	if err := bus.Dispatch(&query); err != nil {
-		if !errors.Is(err, models.ErrPluginSettingNotFound) {
-			return response.Error(500, "Failed to get login settings", nil)
+		settings, err := hs.PluginManager.GetAppSettings(c.OrgId, def.Id)
+		if err != nil {
+			return response.Error(500, "Failed to get plugin settings", err)
 		}
+		dto.Enabled = settings.Enabled
+		dto.Pinned = settings.Pinned
+		dto.JsonData = settings.JsonData
 	} else {
-		dto.Enabled = query.Result.Enabled
-		dto.Pinned = query.Result.Pinned
-		dto.JsonData = query.Result.JsonData
+		query := models.GetPluginSettingByIdQuery{PluginId: pluginID, OrgId: c.OrgId}
+		if err := bus.Dispatch(&query); err != nil {
+			if !errors.Is(err, models.ErrPluginSettingNotFound) {
+				return response.Error(500, "Failed to get login settings", nil)
+			}
+		} else {
+			dto.Enabled = query.Result.Enabled
+			dto.Pinned = query.Result.Pinned
+			dto.JsonData = query.Result.JsonData
+		}
 	}
 
 	return response.JSON(200, dto)
@@ -148,19 +258,80 @@ func (hs *HTTPServer) GetPluginSettingByID(c *models.ReqContext) response.Respon
 func (hs *HTTPServer) UpdatePluginSetting(c *models.ReqContext, cmd models.UpdatePluginSettingCmd) response.Response {
 	pluginID := macaron.Params(c.Req)[":pluginId"]
 
-	if app := hs.PluginManager.GetApp(pluginID); app == nil {
-		return response.Error(404, "Plugin not installed", nil)
-	}
-
 	cmd.OrgId = c.OrgId
 	cmd.PluginId = pluginID
-	if err := bus.Dispatch(&cmd); err != nil {
+	if resp := hs.validateAppConfig(c, cmd); resp != nil {
+		return resp
+	}
+	if err := hs.PluginManager.UpdateAppSettings(&cmd); err != nil {
+		var notFound plugins.PluginNotFoundError
+		if errors.As(err, &notFound) {
+			return response.Error(404, "Plugin not installed", nil)
+		}
 		return response.Error(500, "Failed to update plugin setting", err)
 	}
 
 	return response.Success("Plugin settings updated")
 }
 
+// validateAppConfig asks the backend plugin of an app to validate its settings before they're saved, returning an
+// error response if they're invalid. Settings are saved without validation if the plugin can't be asked.
+func (hs *HTTPServer) validateAppConfig(c *models.ReqContext, cmd models.UpdatePluginSettingCmd) response.Response {
+	if !hs.BackendPluginManager.IsRegistered(cmd.PluginId) {
+		return nil
+	}
+
+	// secure settings that aren't given are kept, so they're validated along with the given ones
+	secureJSONData := map[string]string{}
+	if ps, err := hs.PluginManager.GetAppSettings(cmd.OrgId, cmd.PluginId); err == nil {
+		for key, value := range ps.DecryptedValues() {
+			secureJSONData[key] = value
+		}
+	}
+	for key, value := range cmd.SecureJsonData {
+		secureJSONData[key] = value
+	}
+	secureJSONData, _, err := secrets.Resolve(c.Req.Context(), secrets.AppOwner(cmd.OrgId, cmd.PluginId),
+		secureJSONData)
+	if err != nil {
+		return response.Error(400, "Failed to resolve plugin secrets", err)
+	}
+
+	jsonData, err := json.Marshal(cmd.JsonData)
+	if err != nil {
+		return response.Error(400, "Invalid plugin settings", err)
+	}
+
+	return hs.validatePluginConfig(c, backend.PluginContext{
+		OrgID:    cmd.OrgId,
+		PluginID: cmd.PluginId,
+		User:     adapters.BackendUserFromSignedInUser(c.SignedInUser),
+		AppInstanceSettings: &backend.AppInstanceSettings{
+			JSONData:                jsonData,
+			DecryptedSecureJSONData: secureJSONData,
+		},
+	}, "plugin")
+}
+
+// validatePluginConfig asks a backend plugin to validate the data source or app instance settings of pCtx,
+// returning a response with the errors of the invalid settings, or nil if they're valid or can't be validated.
+func (hs *HTTPServer) validatePluginConfig(c *models.ReqContext, pCtx backend.PluginContext, kind string) response.Response {
+	result, err := hs.BackendPluginManager.ValidateConfig(c.Req.Context(), pCtx)
+	if err != nil {
+		c.Logger.Warn("Failed to validate plugin config, saving settings without validation", "pluginId", pCtx.PluginID,
+			"error", err)
+		return nil
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	return response.JSON(400, util.DynMap{
+		"message": fmt.Sprintf("Invalid %s settings", kind),
+		"errors":  result.Errors,
+	})
+}
+
 func (hs *HTTPServer) GetPluginDashboards(c *models.ReqContext) response.Response {
 	pluginID := macaron.Params(c.Req)[":pluginId"]
 
@@ -188,6 +359,10 @@ func (hs *HTTPServer) GetPluginMarkdown(c *models.ReqContext) response.Response
 			return response.Error(404, notFound.Error(), nil)
 		}
 
+		if errors.Is(err, plugins.ErrPluginMarkdownNotSigned) {
+			return response.Error(403, "Markdown file is not included in the plugin signature", nil)
+		}
+
 		return response.Error(500, "Could not get markdown file", err)
 	}
 
@@ -195,6 +370,9 @@ func (hs *HTTPServer) GetPluginMarkdown(c *models.ReqContext) response.Response
 	if len(content) == 0 {
 		content, err = hs.PluginManager.GetPluginMarkdown(pluginID, "readme")
 		if err != nil {
+			if errors.Is(err, plugins.ErrPluginMarkdownNotSigned) {
+				return response.Error(403, "Markdown file is not included in the plugin signature", nil)
+			}
 			return response.Error(501, "Could not get markdown file", err)
 		}
 	}
@@ -351,6 +529,20 @@ func (hs *HTTPServer) CheckHealth(c *models.ReqContext) response.Response {
 	return response.JSON(200, payload)
 }
 
+// GetPluginCapabilities returns the backend methods a backend plugin implements, so that features it doesn't
+// support can be hidden.
+// /api/plugins/:pluginId/capabilities
+func (hs *HTTPServer) GetPluginCapabilities(c *models.ReqContext) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+
+	capabilities, err := hs.BackendPluginManager.PluginCapabilities(c.Req.Context(), pluginID)
+	if err != nil {
+		return translatePluginRequestErrorToAPIError(err)
+	}
+
+	return response.JSON(200, capabilities)
+}
+
 // CallResource passes a resource call from a plugin to the backend plugin.
 //
 // /api/plugins/:pluginId/resources/*
@@ -369,39 +561,134 @@ func (hs *HTTPServer) CallResource(c *models.ReqContext) {
 	hs.BackendPluginManager.CallResource(pCtx, c, macaron.Params(c.Req)["*"])
 }
 
+// StreamPluginEvents sends the packets of a stream channel of a backend plugin as server-sent events. The
+// optional datasource query parameter is the UID of a data source of the plugin whose stream to subscribe to.
+//
+// /api/plugins/:pluginId/streams/*
+func (hs *HTTPServer) StreamPluginEvents(c *models.ReqContext) {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+	dsUID := c.Query("datasource")
+
+	if dsUID != "" {
+		ds, err := hs.DataSourceCache.GetDatasourceByUID(dsUID, c.SignedInUser, false)
+		if err != nil {
+			if errors.Is(err, models.ErrDataSourceNotFound) {
+				c.JsonApiErr(http.StatusNotFound, "Data source not found", nil)
+				return
+			}
+			c.JsonApiErr(http.StatusInternalServerError, "Failed to get data source", err)
+			return
+		}
+		if ds.Type != pluginID {
+			c.JsonApiErr(http.StatusNotFound, "Data source not found", nil)
+			return
+		}
+	}
+
+	pCtx, found, err := hs.PluginContextProvider.Get(pluginID, dsUID, c.SignedInUser, false)
+	if err != nil {
+		c.JsonApiErr(http.StatusInternalServerError, "Failed to get plugin settings", err)
+		return
+	}
+	if !found {
+		c.JsonApiErr(http.StatusNotFound, "Plugin not found", nil)
+		return
+	}
+	// the stream authorizers check the subscription against the signed in user
+	c.Req = c.Req.WithContext(livecontext.SetContextSignedUser(c.Req.Context(), c.SignedInUser))
+	hs.BackendPluginManager.ServeStreamEvents(pCtx, c, macaron.Params(c.Req)["*"])
+}
+
 func (hs *HTTPServer) GetPluginErrorsList(_ *models.ReqContext) response.Response {
 	return response.JSON(200, hs.PluginManager.ScanningErrors())
 }
 
-func (hs *HTTPServer) InstallPlugin(c *models.ReqContext, dto dtos.InstallPluginCommand) response.Response {
+// GetPluginStates returns all registered plugins with the runtime state of their backend process.
+//
+// /api/plugins/state
+func (hs *HTTPServer) GetPluginStates(_ *models.ReqContext) response.Response {
+	return response.JSON(200, hs.PluginManager.PluginStates())
+}
+
+// GetPluginDependencies returns the dependency graph of the plugins.
+//
+// /api/plugins/dependencies
+func (hs *HTTPServer) GetPluginDependencies(_ *models.ReqContext) response.Response {
+	return response.JSON(200, hs.PluginManager.DependencyGraph())
+}
+
+// CheckPluginUpdate returns whether a newer version of an installed plugin is available.
+//
+// /api/plugins/:pluginId/update
+func (hs *HTTPServer) CheckPluginUpdate(c *models.ReqContext) response.Response {
 	pluginID := macaron.Params(c.Req)[":pluginId"]
 
-	err := hs.PluginManager.Install(c.Req.Context(), pluginID, dto.Version)
+	update, err := hs.PluginManager.CheckUpdate(pluginID)
 	if err != nil {
-		var dupeErr plugins.DuplicatePluginError
-		if errors.As(err, &dupeErr) {
-			return response.Error(http.StatusConflict, "Plugin already installed", err)
-		}
-		var versionUnsupportedErr installer.ErrVersionUnsupported
-		if errors.As(err, &versionUnsupportedErr) {
-			return response.Error(http.StatusConflict, "Plugin version not supported", err)
-		}
-		var versionNotFoundErr installer.ErrVersionNotFound
-		if errors.As(err, &versionNotFoundErr) {
-			return response.Error(http.StatusNotFound, "Plugin version not found", err)
+		var notFound plugins.PluginNotFoundError
+		if errors.As(err, &notFound) {
+			return response.Error(http.StatusNotFound, notFound.Error(), nil)
 		}
 		var clientError installer.Response4xxError
 		if errors.As(err, &clientError) {
 			return response.Error(clientError.StatusCode, clientError.Message, err)
 		}
-		if errors.Is(err, plugins.ErrInstallCorePlugin) {
-			return response.Error(http.StatusForbidden, "Cannot install or change a Core plugin", err)
+		if errors.Is(err, plugins.ErrUpdateCorePlugin) {
+			return response.Error(http.StatusBadRequest, "Core plugins are updated with Grafana", err)
 		}
 
-		return response.Error(http.StatusInternalServerError, "Failed to install plugin", err)
+		return response.Error(http.StatusInternalServerError, "Failed to check for plugin update", err)
 	}
 
-	return response.JSON(http.StatusOK, []byte{})
+	return response.JSON(http.StatusOK, update)
+}
+
+func (hs *HTTPServer) InstallPlugin(c *models.ReqContext, dto dtos.InstallPluginCommand) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+
+	err := hs.PluginManager.Install(c.Req.Context(), pluginID, dto.Version,
+		plugins.InstallOpts{AllowDowngrade: dto.AllowDowngrade})
+	if err != nil {
+		return pluginInstallErrorResponse(err, "Failed to install plugin")
+	}
+
+	result := dtos.InstalledPlugin{Id: pluginID}
+	if plugin := hs.PluginManager.GetPlugin(pluginID); plugin != nil {
+		result.Name = plugin.Name
+		result.Type = plugin.Type
+		result.Version = plugin.Info.Version
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+// pluginInstallErrorResponse returns the response for an error installing a plugin.
+func pluginInstallErrorResponse(err error, message string) response.Response {
+	var dupeErr plugins.DuplicatePluginError
+	if errors.As(err, &dupeErr) {
+		return response.Error(http.StatusConflict, "Plugin already installed", err)
+	}
+	var downgradeErr plugins.DowngradeError
+	if errors.As(err, &downgradeErr) {
+		return response.Error(http.StatusConflict, "Plugin is installed in a higher version", err)
+	}
+	var versionUnsupportedErr installer.ErrVersionUnsupported
+	if errors.As(err, &versionUnsupportedErr) {
+		return response.Error(http.StatusConflict, "Plugin version not supported", err)
+	}
+	var versionNotFoundErr installer.ErrVersionNotFound
+	if errors.As(err, &versionNotFoundErr) {
+		return response.Error(http.StatusNotFound, "Plugin version not found", err)
+	}
+	var clientError installer.Response4xxError
+	if errors.As(err, &clientError) {
+		return response.Error(clientError.StatusCode, clientError.Message, err)
+	}
+	if errors.Is(err, plugins.ErrInstallCorePlugin) {
+		return response.Error(http.StatusForbidden, "Cannot install or change a Core plugin", err)
+	}
+
+	return response.Error(http.StatusInternalServerError, message, err)
 }
 
 func (hs *HTTPServer) UninstallPlugin(c *models.ReqContext) response.Response {
@@ -424,22 +711,275 @@ func (hs *HTTPServer) UninstallPlugin(c *models.ReqContext) response.Response {
 	return response.JSON(http.StatusOK, []byte{})
 }
 
+// InstallPluginCanary installs a version of an installed backend plugin side by side with it, to handle the requests
+// selected by the canary settings of the plugin.
+func (hs *HTTPServer) InstallPluginCanary(c *models.ReqContext, dto dtos.InstallPluginCommand) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+
+	if err := hs.PluginManager.InstallCanary(c.Req.Context(), pluginID, dto.Version); err != nil {
+		if errors.Is(err, plugins.ErrPluginNotInstalled) {
+			return response.Error(http.StatusNotFound, "Plugin not installed", err)
+		}
+		if errors.Is(err, plugins.ErrCanaryNotBackend) {
+			return response.Error(http.StatusBadRequest, "Only backend plugins can have canary versions", err)
+		}
+		return pluginInstallErrorResponse(err, "Failed to install canary version of plugin")
+	}
+
+	version, _ := hs.PluginManager.CanaryVersion(pluginID)
+	return response.JSON(http.StatusOK, dtos.PluginCanary{Id: pluginID, Version: version})
+}
+
+// RemovePluginCanary stops and removes the canary version of a plugin.
+func (hs *HTTPServer) RemovePluginCanary(c *models.ReqContext) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+
+	if err := hs.PluginManager.RemoveCanary(c.Req.Context(), pluginID); err != nil {
+		if errors.Is(err, plugins.ErrCanaryNotInstalled) {
+			return response.Error(http.StatusNotFound, "Plugin has no canary version", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to remove canary version of plugin", err)
+	}
+
+	return response.Success("Canary version of plugin removed")
+}
+
+// PromotePluginCanary upgrades a plugin to the version of its canary version.
+func (hs *HTTPServer) PromotePluginCanary(c *models.ReqContext) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+
+	if err := hs.PluginManager.PromoteCanary(c.Req.Context(), pluginID); err != nil {
+		if errors.Is(err, plugins.ErrCanaryNotInstalled) {
+			return response.Error(http.StatusNotFound, "Plugin has no canary version", err)
+		}
+		return pluginInstallErrorResponse(err, "Failed to promote canary version of plugin")
+	}
+
+	result := dtos.InstalledPlugin{Id: pluginID}
+	if plugin := hs.PluginManager.GetPlugin(pluginID); plugin != nil {
+		result.Name = plugin.Name
+		result.Type = plugin.Type
+		result.Version = plugin.Info.Version
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+// GetPluginLogs returns the latest stdout and stderr output of the processes of a backend plugin.
+func (hs *HTTPServer) GetPluginLogs(c *models.ReqContext) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+
+	output, err := hs.BackendPluginManager.ProcessOutput(pluginID)
+	if err != nil {
+		return translatePluginRequestErrorToAPIError(err)
+	}
+
+	resp := response.Respond(http.StatusOK, output)
+	resp.SetHeader("Content-Type", "text/plain; charset=utf-8")
+	return resp
+}
+
+// StreamPluginLogs streams the log entries of a backend plugin as server-sent events, filtered by the level
+// query parameter.
+//
+// /api/plugins/:pluginId/logs/stream
+func (hs *HTTPServer) StreamPluginLogs(c *models.ReqContext) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+	level := c.Query("level")
+	if level == "" {
+		level = "debug"
+	}
+
+	entries, err := hs.BackendPluginManager.StreamLogs(c.Req.Context(), pluginID, level)
+	if err != nil {
+		if errors.Is(err, backendplugin.ErrInvalidLogLevel) {
+			return response.Error(http.StatusBadRequest, "Invalid log level", err)
+		}
+		return translatePluginRequestErrorToAPIError(err)
+	}
+
+	return &pluginLogStreamResponse{entries: entries}
+}
+
+// pluginLogStreamKeepAliveInterval is the interval of comments sent to keep idle plugin log streams open.
+const pluginLogStreamKeepAliveInterval = 30 * time.Second
+
+// pluginLogStreamResponse streams plugin log entries as server-sent events.
+type pluginLogStreamResponse struct {
+	entries <-chan backendplugin.LogEntry
+}
+
+// Status gets the response's status.
+// Required to implement api.Response.
+func (r *pluginLogStreamResponse) Status() int {
+	return http.StatusOK
+}
+
+// Body gets the response's body.
+// Required to implement api.Response.
+func (r *pluginLogStreamResponse) Body() []byte {
+	return nil
+}
+
+// WriteTo writes the response to the provided context.
+// Required to implement api.Response.
+func (r *pluginLogStreamResponse) WriteTo(c *models.ReqContext) {
+	c.Resp.Header().Set("Content-Type", "text/event-stream")
+	c.Resp.Header().Set("Cache-Control", "no-cache")
+	c.Resp.WriteHeader(http.StatusOK)
+	c.Resp.Flush()
+
+	ticker := time.NewTicker(pluginLogStreamKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case entry, ok := <-r.entries:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				c.Logger.Error("Failed to marshal plugin log entry", "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Resp, "event: log\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := c.Resp.Write([]byte(": keep-alive\n\n")); err != nil {
+				return
+			}
+		}
+		c.Resp.Flush()
+	}
+}
+
+// AdminGetPluginLogLevel returns the log level of a backend plugin.
+func (hs *HTTPServer) AdminGetPluginLogLevel(c *models.ReqContext) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+
+	level, err := hs.BackendPluginManager.LogLevel(pluginID)
+	if err != nil {
+		return translatePluginRequestErrorToAPIError(err)
+	}
+
+	return response.JSON(http.StatusOK, util.DynMap{"level": level})
+}
+
+// AdminSetPluginLogLevel changes the log level of a backend plugin.
+func (hs *HTTPServer) AdminSetPluginLogLevel(c *models.ReqContext, cmd dtos.UpdatePluginLogLevelCommand) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+
+	if err := hs.BackendPluginManager.SetLogLevel(c.Req.Context(), pluginID, cmd.Level); err != nil {
+		if errors.Is(err, backendplugin.ErrInvalidLogLevel) {
+			return response.Error(http.StatusBadRequest, "Invalid log level", err)
+		}
+		return translatePluginRequestErrorToAPIError(err)
+	}
+
+	return response.Success("Plugin log level updated")
+}
+
+// AdminRestartPlugin restarts the process of a backend plugin.
+func (hs *HTTPServer) AdminRestartPlugin(c *models.ReqContext) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+
+	hs.log.Info("Plugin restart requested", "pluginId", pluginID, "userId", c.UserId, "login", c.Login)
+	if err := hs.BackendPluginManager.RestartPlugin(c.Req.Context(), pluginID); err != nil {
+		if errors.Is(err, backendplugin.ErrPluginNotRestartable) {
+			return response.Error(http.StatusBadRequest, "Plugin cannot be restarted", err)
+		}
+		hs.log.Error("Failed to restart plugin", "pluginId", pluginID, "userId", c.UserId, "login", c.Login, "error", err)
+		return translatePluginRequestErrorToAPIError(err)
+	}
+	hs.log.Info("Plugin restarted", "pluginId", pluginID, "userId", c.UserId, "login", c.Login)
+
+	return response.Success("Plugin restarted")
+}
+
+// AdminReloadPlugin reloads the settings of a backend plugin, replacing its instance.
+func (hs *HTTPServer) AdminReloadPlugin(c *models.ReqContext) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+
+	hs.log.Info("Plugin reload requested", "pluginId", pluginID, "userId", c.UserId, "login", c.Login)
+	if err := hs.BackendPluginManager.ReloadPlugin(c.Req.Context(), pluginID); err != nil {
+		if errors.Is(err, backendplugin.ErrPluginNotReloadable) {
+			return response.Error(http.StatusBadRequest, "Plugin cannot be reloaded", err)
+		}
+		hs.log.Error("Failed to reload plugin", "pluginId", pluginID, "userId", c.UserId, "login", c.Login, "error", err)
+		return translatePluginRequestErrorToAPIError(err)
+	}
+	hs.log.Info("Plugin reloaded", "pluginId", pluginID, "userId", c.UserId, "login", c.Login)
+
+	return response.Success("Plugin reloaded")
+}
+
+// AdminDecommissionPlugin decommissions a backend plugin, stopping it once its in-flight requests complete.
+func (hs *HTTPServer) AdminDecommissionPlugin(c *models.ReqContext) response.Response {
+	pluginID := macaron.Params(c.Req)[":pluginId"]
+
+	hs.log.Info("Plugin decommission requested", "pluginId", pluginID, "userId", c.UserId, "login", c.Login)
+	if err := hs.BackendPluginManager.DecommissionPlugin(c.Req.Context(), pluginID); err != nil {
+		hs.log.Error("Failed to decommission plugin", "pluginId", pluginID, "userId", c.UserId, "login", c.Login,
+			"error", err)
+		return translatePluginRequestErrorToAPIError(err)
+	}
+	hs.log.Info("Plugin decommissioned", "pluginId", pluginID, "userId", c.UserId, "login", c.Login)
+
+	return response.Success("Plugin decommissioned")
+}
+
+// AdminGetPluginRuntimeStats returns the runtime stats of the backend plugins.
+func (hs *HTTPServer) AdminGetPluginRuntimeStats(_ *models.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, hs.BackendPluginManager.RuntimeStats())
+}
+
+// AdminGetPluginInitFailures returns the plugin directories that failed to initialize.
+func (hs *HTTPServer) AdminGetPluginInitFailures(_ *models.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, hs.PluginManager.InitFailures())
+}
+
+// AdminScanPlugins reports the plugins that would be loaded from directories, with their signature status and
+// errors, without registering or starting them, so that a directory can be verified before it's enabled.
+func (hs *HTTPServer) AdminScanPlugins(c *models.ReqContext, cmd dtos.ScanPluginsCommand) response.Response {
+	hs.log.Info("Plugin scan requested", "paths", cmd.Paths, "userId", c.UserId, "login", c.Login)
+	scanned, err := hs.PluginManager.Scan(cmd.Paths, !cmd.AllowUnsigned)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Failed to scan plugin directories", err)
+	}
+	if scanned == nil {
+		scanned = []plugins.ScannedPlugin{}
+	}
+
+	return response.JSON(http.StatusOK, scanned)
+}
+
 func translatePluginRequestErrorToAPIError(err error) response.Response {
+	code := backendplugin.ErrorCodeOf(err)
+
 	if errors.Is(err, backendplugin.ErrPluginNotRegistered) {
-		return response.Error(404, "Plugin not found", err)
+		return response.ErrorWithCode(404, "Plugin not found", string(code), err)
 	}
 
 	if errors.Is(err, backendplugin.ErrMethodNotImplemented) {
-		return response.Error(404, "Not found", err)
+		return response.ErrorWithCode(404, "Not found", string(code), err)
 	}
 
 	if errors.Is(err, backendplugin.ErrHealthCheckFailed) {
-		return response.Error(500, "Plugin health check failed", err)
+		return response.ErrorWithCode(500, "Plugin health check failed", string(code), err)
 	}
 
-	if errors.Is(err, backendplugin.ErrPluginUnavailable) {
-		return response.Error(503, "Plugin unavailable", err)
+	switch code {
+	case backendplugin.ErrorCodeUnavailable:
+		return response.ErrorWithCode(code.HTTPStatus(), "Plugin unavailable", string(code), err)
+	case backendplugin.ErrorCodeCrashed:
+		return response.ErrorWithCode(code.HTTPStatus(), "Plugin crashed", string(code), err)
+	case backendplugin.ErrorCodeTimeout:
+		return response.ErrorWithCode(code.HTTPStatus(), "Plugin request timed out", string(code), err)
+	case backendplugin.ErrorCodeTooManyRequests:
+		return response.ErrorWithCode(code.HTTPStatus(), "Too many concurrent queries", string(code), err)
+	default:
+		return response.ErrorWithCode(code.HTTPStatus(), "Plugin request failed", string(code), err)
 	}
-
-	return response.Error(500, "Plugin request failed", err)
 }
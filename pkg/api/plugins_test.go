@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -10,11 +11,15 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/plugins/manager"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -180,6 +185,174 @@ func pluginAssetScenario(t *testing.T, desc string, url string, urlPattern strin
 	})
 }
 
+func Test_GetPluginList_Fields(t *testing.T) {
+	pm := &fakePluginManager{
+		listedPlugins: []plugins.PluginListItem{
+			{Plugin: &plugins.PluginBase{
+				Id:          "test",
+				Name:        "Test",
+				Type:        "datasource",
+				Info:        plugins.PluginInfo{Version: "1.0.0", Author: plugins.PluginInfoLink{Name: "Grafana Labs"}},
+				SignedFiles: plugins.PluginFiles{"plugin.json": {}, "module.js": {}},
+				Includes:    []*plugins.PluginInclude{{Name: "Overview", Type: "dashboard", Id: "internal"}},
+			}},
+		},
+		pluginStates: []plugins.PluginRuntimeState{
+			{ID: "test", Process: &plugins.PluginProcessState{Status: backendplugin.PluginStatusRunning, Restarts: 2}},
+		},
+	}
+	hs := &HTTPServer{Cfg: setting.NewCfg(), PluginManager: pm}
+
+	loggedInUserScenarioWithRole(t, "When listing plugins without fields", "GET", "/api/plugins", "/api/plugins",
+		models.ROLE_ADMIN, func(sc *scenarioContext) {
+			sc.handlerFunc = hs.GetPluginList
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			require.Equal(t, 200, sc.resp.Code)
+			var result []map[string]interface{}
+			require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+			require.Len(t, result, 1)
+			assert.Equal(t, "test", result[0]["id"])
+			assert.Equal(t, "1.0.0", result[0]["info"].(map[string]interface{})["version"])
+			assert.Equal(t, "Grafana Labs",
+				result[0]["info"].(map[string]interface{})["author"].(map[string]interface{})["name"])
+			assert.NotContains(t, result[0], "signedFiles")
+			assert.NotContains(t, result[0], "includes")
+			assert.NotContains(t, result[0], "runtime")
+		})
+
+	loggedInUserScenarioWithRole(t, "When listing plugins with fields", "GET", "/api/plugins", "/api/plugins",
+		models.ROLE_ADMIN, func(sc *scenarioContext) {
+			sc.handlerFunc = hs.GetPluginList
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{"fields": "signedFiles, includes,runtime"}).exec()
+
+			require.Equal(t, 200, sc.resp.Code)
+			var result []dtos.PluginListItem
+			require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+			require.Len(t, result, 1)
+			assert.Equal(t, []string{"module.js", "plugin.json"}, result[0].SignedFiles)
+			require.Len(t, result[0].Includes, 1)
+			assert.Equal(t, "Overview", result[0].Includes[0].Name)
+			require.NotNil(t, result[0].Runtime)
+			assert.Equal(t, backendplugin.PluginStatusRunning, result[0].Runtime.Status)
+			assert.Equal(t, 2, result[0].Runtime.Restarts)
+		})
+
+	loggedInUserScenarioWithRole(t, "When listing plugins with unknown fields", "GET", "/api/plugins", "/api/plugins",
+		models.ROLE_ADMIN, func(sc *scenarioContext) {
+			sc.handlerFunc = hs.GetPluginList
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{"fields": "pluginDir"}).exec()
+
+			assert.Equal(t, 400, sc.resp.Code)
+		})
+}
+
+func Test_SearchPlugins_Filters(t *testing.T) {
+	pm := &fakePluginManager{}
+	hs := &HTTPServer{Cfg: setting.NewCfg(), PluginManager: pm}
+
+	loggedInUserScenarioWithRole(t, "When searching plugins", "GET", "/api/plugins/search", "/api/plugins/search",
+		models.ROLE_ADMIN, func(sc *scenarioContext) {
+			sc.handlerFunc = hs.SearchPlugins
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{
+				"query":    "cloud monitoring",
+				"type":     "datasource",
+				"category": "cloud",
+				"keyword":  "metrics",
+				"state":    "beta",
+			}).exec()
+
+			require.Equal(t, 200, sc.resp.Code)
+			assert.Equal(t, "cloud monitoring", pm.listQuery.Query)
+			assert.Equal(t, []string{"datasource"}, pm.listQuery.Types)
+			assert.Equal(t, "cloud", pm.listQuery.Category)
+			assert.Equal(t, []string{"metrics"}, pm.listQuery.Keywords)
+			assert.Equal(t, plugins.PluginStateBeta, pm.listQuery.State)
+		})
+}
+
+func Test_GetPluginCapabilities(t *testing.T) {
+	hs := &HTTPServer{
+		Cfg: setting.NewCfg(),
+		BackendPluginManager: &fakeBackendPluginManager{
+			capabilities: map[string]backendplugin.PluginCapabilities{
+				"test": {QueryData: true, CheckHealth: true},
+			},
+		},
+	}
+
+	loggedInUserScenarioWithRole(t, "When getting the capabilities of a plugin", "GET",
+		"/api/plugins/test/capabilities", "/api/plugins/:pluginId/capabilities", models.ROLE_VIEWER,
+		func(sc *scenarioContext) {
+			sc.handlerFunc = hs.GetPluginCapabilities
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			require.Equal(t, 200, sc.resp.Code)
+			require.JSONEq(t, `{"queryData": true, "callResource": false, "streaming": false, "checkHealth": true}`,
+				sc.resp.Body.String())
+		})
+
+	loggedInUserScenarioWithRole(t, "When getting the capabilities of an unknown plugin", "GET",
+		"/api/plugins/unknown/capabilities", "/api/plugins/:pluginId/capabilities", models.ROLE_VIEWER,
+		func(sc *scenarioContext) {
+			sc.handlerFunc = hs.GetPluginCapabilities
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			require.Equal(t, 404, sc.resp.Code)
+		})
+}
+
+func Test_AdminScanPlugins(t *testing.T) {
+	pm := &fakePluginManager{scannedPlugins: []plugins.ScannedPlugin{
+		{ID: "test-app", Type: "app", Version: "1.0.0", PluginDir: "/plugins/test-app",
+			Signature: plugins.PluginSignatureValid},
+	}}
+	hs := &HTTPServer{Cfg: setting.NewCfg(), PluginManager: pm, log: log.New("test")}
+
+	resp := hs.AdminScanPlugins(&models.ReqContext{SignedInUser: &models.SignedInUser{}},
+		dtos.ScanPluginsCommand{Paths: []string{"/plugins"}})
+	require.Equal(t, 200, resp.Status())
+	require.JSONEq(t, `[{"id": "test-app", "type": "app", "version": "1.0.0", "pluginDir": "/plugins/test-app",
+		"signature": "valid", "alreadyLoaded": false}]`, string(resp.Body()))
+	require.Equal(t, []string{"/plugins"}, pm.scanDirs)
+	require.True(t, pm.scanSigned)
+
+	resp = hs.AdminScanPlugins(&models.ReqContext{SignedInUser: &models.SignedInUser{}},
+		dtos.ScanPluginsCommand{Paths: []string{"/plugins"}, AllowUnsigned: true})
+	require.Equal(t, 200, resp.Status())
+	require.False(t, pm.scanSigned)
+}
+
+func Test_TranslatePluginRequestErrorToAPIError(t *testing.T) {
+	tcs := []struct {
+		desc   string
+		err    error
+		status int
+		code   string
+	}{
+		{desc: "unregistered plugin", err: backendplugin.ErrPluginNotRegistered, status: 404, code: "plugin.notFound"},
+		{desc: "unavailable plugin", err: backendplugin.ErrPluginUnavailable, status: 503, code: "plugin.unavailable"},
+		{desc: "timeout", err: fmt.Errorf("query: %w", backendplugin.ErrPluginTimeout), status: 504, code: "plugin.timeout"},
+		{desc: "too many queries", err: backendplugin.ErrTooManyQueries, status: 429, code: "plugin.tooManyRequests"},
+		{desc: "unauthorized upstream", err: status.Error(codes.Unauthenticated, "invalid password"), status: 502,
+			code: "plugin.unauthorizedUpstream"},
+		{desc: "bad query", err: status.Error(codes.InvalidArgument, "syntax error"), status: 400, code: "plugin.badQuery"},
+		{desc: "internal error", err: errors.New("boom"), status: 500, code: "plugin.internal"},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			resp := translatePluginRequestErrorToAPIError(tc.err)
+			require.Equal(t, tc.status, resp.Status())
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(resp.Body(), &body))
+			require.Equal(t, tc.code, body["errorCode"])
+		})
+	}
+}
+
 type pluginManager struct {
 	manager.PluginManager
 
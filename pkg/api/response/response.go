@@ -176,6 +176,12 @@ func Success(message string) *NormalResponse {
 
 // Error creates an error response.
 func Error(status int, message string, err error) *NormalResponse {
+	return ErrorWithCode(status, message, "", err)
+}
+
+// ErrorWithCode creates an error response with a machine-readable error code in the errorCode field, if code isn't
+// empty.
+func ErrorWithCode(status int, message string, code string, err error) *NormalResponse {
 	data := make(map[string]interface{})
 
 	switch status {
@@ -189,6 +195,10 @@ func Error(status int, message string, err error) *NormalResponse {
 		data["message"] = message
 	}
 
+	if code != "" {
+		data["errorCode"] = code
+	}
+
 	if err != nil {
 		if setting.Env != setting.Prod {
 			data["error"] = err.Error()
@@ -56,6 +56,12 @@ func runPluginCommand(command func(commandLine utils.CommandLine) error) func(co
 			return err
 		}
 
+		// plugins installed through a running Grafana server are loaded by the server
+		if cmd.String("server") != "" {
+			logger.Info("\n")
+			return nil
+		}
+
 		logger.Info(color.GreenString("Please restart Grafana after installing plugins. Refer to Grafana documentation for instructions if necessary.\n\n"))
 		return nil
 	}
@@ -81,6 +87,22 @@ var pluginCommands = []*cli.Command{
 		Name:   "install",
 		Usage:  "install <plugin id> <plugin version (optional)>",
 		Action: runPluginCommand(cmd.installCommand),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "server",
+				Usage: "URL of a running Grafana server to install the plugin through, which loads it without a restart",
+			},
+			&cli.StringFlag{
+				Name:  "server-user",
+				Usage: "Login of a Grafana server admin, used with --server",
+				Value: "admin",
+			},
+			&cli.StringFlag{
+				Name:    "server-password",
+				Usage:   "Password of the Grafana server admin, used with --server",
+				EnvVars: []string{"GF_CLI_SERVER_PASSWORD"},
+			},
+		},
 	}, {
 		Name:   "list-remote",
 		Usage:  "list remote available plugins",
@@ -103,6 +125,32 @@ var pluginCommands = []*cli.Command{
 		Name:   "ls",
 		Usage:  "list all installed plugins",
 		Action: runPluginCommand(cmd.lsCommand),
+	}, {
+		Name:   "verify",
+		Usage:  "verify <plugin directory (optional)>...",
+		Action: runPluginCommand(cmd.verifyCommand),
+		Description: `verify reports which plugins a running Grafana server would load from directories on its host,
+defaulting to the plugins directory, without loading them.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "server",
+				Usage: "URL of the running Grafana server to verify the plugins with",
+			},
+			&cli.StringFlag{
+				Name:  "server-user",
+				Usage: "Login of a Grafana server admin, used with --server",
+				Value: "admin",
+			},
+			&cli.StringFlag{
+				Name:    "server-password",
+				Usage:   "Password of the Grafana server admin, used with --server",
+				EnvVars: []string{"GF_CLI_SERVER_PASSWORD"},
+			},
+			&cli.BoolFlag{
+				Name:  "allow-unsigned",
+				Usage: "Don't require plugins to be signed, like in the core plugin directories",
+			},
+		},
 	}, {
 		Name:    "uninstall",
 		Aliases: []string{"remove"},
@@ -51,6 +51,10 @@ func validateInput(c utils.CommandLine, pluginFolder string) error {
 }
 
 func (cmd Command) installCommand(c utils.CommandLine) error {
+	if c.String("server") != "" {
+		return installOnServer(c)
+	}
+
 	pluginFolder := c.PluginDirectory()
 	if err := validateInput(c, pluginFolder); err != nil {
 		return err
@@ -64,6 +68,41 @@ func (cmd Command) installCommand(c utils.CommandLine) error {
 	return i.Install(context.Background(), pluginID, version, c.PluginDirectory(), c.PluginURL(), c.PluginRepoURL())
 }
 
+// installOnServer instructs a running Grafana server to install a plugin, which the server loads without a
+// restart.
+func installOnServer(c utils.CommandLine) error {
+	pluginID := c.Args().First()
+	if pluginID == "" {
+		return errors.New("please specify plugin to install")
+	}
+	if c.PluginURL() != "" {
+		return errors.New("the pluginUrl flag can't be used when installing through a Grafana server")
+	}
+
+	client := &services.GrafanaServerClient{
+		ServerURL: c.String("server"),
+		User:      c.String("server-user"),
+		Password:  c.String("server-password"),
+	}
+
+	version := c.Args().Get(1)
+	if version == "" {
+		logger.Infof("installing %v @ latest\n", pluginID)
+	} else {
+		logger.Infof("installing %v @ %v\n", pluginID, version)
+	}
+	logger.Infof("through: %v\n", client.ServerURL)
+	logger.Info("\n")
+
+	plugin, err := client.InstallPlugin(pluginID, version)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("%s Installed %s successfully @ %s\n", color.GreenString("✔"), plugin.ID, plugin.Version)
+	return nil
+}
+
 // InstallPlugin downloads the plugin code as a zip file from the Grafana.com API
 // and then extracts the zip into the plugins directory.
 func InstallPlugin(pluginName, version string, c utils.CommandLine, client utils.ApiClient) error {
@@ -234,16 +273,13 @@ func extractFiles(archiveFile string, pluginName string, dstDir string, allowSym
 	}
 	logger.Debugf("Extracting archive %q to %q...\n", archiveFile, dstDir)
 
-	existingInstallDir := filepath.Join(dstDir, pluginName)
-	if _, err := os.Stat(existingInstallDir); !os.IsNotExist(err) {
-		err = os.RemoveAll(existingInstallDir)
-		if err != nil {
-			return err
-		}
-
-		logger.Infof("Removed existing installation of %s\n\n", pluginName)
-	}
+	return installer.ExtractStaged(dstDir, pluginName, func(stagingDir string) error {
+		return extractArchive(archiveFile, pluginName, stagingDir, dstDir, allowSymlinks)
+	})
+}
 
+// extractArchive extracts the plugin archive into the staging directory dstDir of the plugins directory pluginsDir.
+func extractArchive(archiveFile, pluginName, dstDir, pluginsDir string, allowSymlinks bool) error {
 	r, err := zip.OpenReader(archiveFile)
 	if err != nil {
 		return err
@@ -252,7 +288,7 @@ func extractFiles(archiveFile string, pluginName string, dstDir string, allowSym
 		if filepath.IsAbs(zf.Name) || strings.HasPrefix(zf.Name, ".."+string(filepath.Separator)) {
 			return fmt.Errorf(
 				"archive member %q tries to write outside of plugin directory: %q, this can be a security risk",
-				zf.Name, dstDir)
+				zf.Name, pluginsDir)
 		}
 
 		dstPath := filepath.Clean(filepath.Join(dstDir, removeGitBuildFromName(pluginName, zf.Name)))
@@ -3,13 +3,16 @@ package commands
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/commandstest"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
+	"github.com/grafana/grafana/pkg/plugins/manager/installer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -105,6 +108,61 @@ func TestExtractFiles(t *testing.T) {
 			pluginsDir,
 		))
 	})
+
+	t.Run("Should replace an existing installation", func(t *testing.T) {
+		pluginsDir := setupFakePluginsDir(t)
+		oldFile := filepath.Join(pluginsDir, "plugin-with-symlink", "old.txt")
+		require.NoError(t, os.MkdirAll(filepath.Dir(oldFile), 0750))
+		require.NoError(t, ioutil.WriteFile(oldFile, []byte("old"), 0600))
+
+		err := extractFiles("testdata/plugin-with-symlink.zip", "plugin-with-symlink", pluginsDir, false)
+		require.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(pluginsDir, "plugin-with-symlink", "text.txt"))
+		require.NoError(t, err)
+		_, err = os.Stat(oldFile)
+		require.True(t, os.IsNotExist(err))
+		requireNoStagingDirs(t, pluginsDir)
+	})
+
+	t.Run("Should keep an existing installation when extracting fails", func(t *testing.T) {
+		pluginsDir := setupFakePluginsDir(t)
+		oldFile := filepath.Join(pluginsDir, "plugin-with-parent-member", "old.txt")
+		require.NoError(t, os.MkdirAll(filepath.Dir(oldFile), 0750))
+		require.NoError(t, ioutil.WriteFile(oldFile, []byte("old"), 0600))
+
+		err := extractFiles("testdata/plugin-with-parent-member.zip", "plugin-with-parent-member", pluginsDir, true)
+		require.Error(t, err)
+
+		_, err = os.Stat(oldFile)
+		require.NoError(t, err)
+		requireNoStagingDirs(t, pluginsDir)
+	})
+
+	t.Run("Should remove staging directories of interrupted installs", func(t *testing.T) {
+		pluginsDir := setupFakePluginsDir(t)
+		leftover := filepath.Join(pluginsDir, installer.StagingDirPrefix+"plugin-with-symlink-123", "plugin-with-symlink")
+		require.NoError(t, os.MkdirAll(leftover, 0750))
+		otherPlugin := filepath.Join(pluginsDir, installer.StagingDirPrefix+"plugin-with-symlink-other-123")
+		require.NoError(t, os.MkdirAll(otherPlugin, 0750))
+
+		err := extractFiles("testdata/plugin-with-symlink.zip", "plugin-with-symlink", pluginsDir, false)
+		require.NoError(t, err)
+
+		_, err = os.Stat(filepath.Dir(leftover))
+		require.True(t, os.IsNotExist(err))
+		_, err = os.Stat(otherPlugin)
+		require.NoError(t, err)
+	})
+}
+
+func requireNoStagingDirs(t *testing.T, pluginsDir string) {
+	t.Helper()
+	entries, err := ioutil.ReadDir(pluginsDir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		require.False(t, strings.HasPrefix(e.Name(), installer.StagingDirPrefix), "staging directory %s wasn't removed", e.Name())
+	}
 }
 
 func TestInstallPluginCommand(t *testing.T) {
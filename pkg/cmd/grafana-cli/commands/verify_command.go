@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+)
+
+// verifyCommand reports which plugins a running Grafana server would load from directories on its host, with their
+// signature status and errors, without loading them. It defaults to the plugins directory.
+func (cmd Command) verifyCommand(c utils.CommandLine) error {
+	if c.String("server") == "" {
+		return errors.New("the server flag is required to verify plugins")
+	}
+
+	paths := c.Args().Slice()
+	if len(paths) == 0 {
+		paths = []string{c.PluginDirectory()}
+	}
+
+	client := &services.GrafanaServerClient{
+		ServerURL: c.String("server"),
+		User:      c.String("server-user"),
+		Password:  c.String("server-password"),
+	}
+	scanned, err := client.ScanPlugins(paths, c.Bool("allow-unsigned"))
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, plugin := range scanned {
+		if plugin.Error != nil {
+			failed++
+			id := plugin.ID
+			if id == "" {
+				id = plugin.PluginDir
+			}
+			logger.Infof("%s %s: %s %s\n", color.RedString("✘"), id, plugin.Error.ErrorCode, plugin.Error.Message)
+			continue
+		}
+		logger.Infof("%s %s %s %s (%s)\n", color.GreenString("✔"), plugin.ID, color.YellowString("@"), plugin.Version,
+			plugin.Signature)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d plugins can't be loaded", failed, len(scanned))
+	}
+	return nil
+}
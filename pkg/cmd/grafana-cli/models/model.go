@@ -13,6 +13,31 @@ type InstalledPlugin struct {
 	Dependencies Dependencies `json:"dependencies"`
 }
 
+// ServerPlugin is a plugin installed through the API of a running Grafana server.
+type ServerPlugin struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+}
+
+// ScannedPlugin is a plugin a running Grafana server would load from a scanned directory. Plugins that can't be
+// loaded have an error.
+type ScannedPlugin struct {
+	ID        string        `json:"id"`
+	Type      string        `json:"type"`
+	Version   string        `json:"version"`
+	PluginDir string        `json:"pluginDir"`
+	Signature string        `json:"signature"`
+	Error     *ScannedError `json:"error,omitempty"`
+}
+
+// ScannedError is the error of a scanned plugin.
+type ScannedError struct {
+	ErrorCode string `json:"errorCode"`
+	Message   string `json:"message,omitempty"`
+}
+
 type Dependencies struct {
 	GrafanaVersion string   `json:"grafanaVersion"`
 	Plugins        []Plugin `json:"plugins"`
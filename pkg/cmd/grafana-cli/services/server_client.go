@@ -0,0 +1,109 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
+)
+
+// GrafanaServerClient manages plugins through the API of a running Grafana server, which loads installed plugins
+// without a restart. The API requires the credentials of a Grafana server admin.
+type GrafanaServerClient struct {
+	ServerURL string
+	User      string
+	Password  string
+}
+
+// InstallPlugin instructs the server to install a plugin, at its latest version if version is empty.
+func (client *GrafanaServerClient) InstallPlugin(pluginID, version string) (models.ServerPlugin, error) {
+	resBody, err := client.post(path.Join("api/plugins", pluginID, "install"), map[string]string{"version": version})
+	if err != nil {
+		return models.ServerPlugin{}, err
+	}
+
+	var installed models.ServerPlugin
+	if err := json.Unmarshal(resBody, &installed); err != nil {
+		logger.Debugf("Failed to unmarshal install response: %v\n", err)
+	}
+	if installed.ID == "" {
+		installed.ID = pluginID
+	}
+
+	return installed, nil
+}
+
+// ScanPlugins asks the server which plugins it would load from directories on its host, without loading them.
+// Unless allowUnsigned is set, unsigned plugins are reported with a signature error, like in the plugins directory.
+func (client *GrafanaServerClient) ScanPlugins(paths []string, allowUnsigned bool) ([]models.ScannedPlugin, error) {
+	resBody, err := client.post("api/admin/plugins/scan", map[string]interface{}{
+		"paths":         paths,
+		"allowUnsigned": allowUnsigned,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var scanned []models.ScannedPlugin
+	if err := json.Unmarshal(resBody, &scanned); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scan response: %w", err)
+	}
+
+	return scanned, nil
+}
+
+// post sends body as JSON to the API of the server at apiPath and returns the body of the response.
+func (client *GrafanaServerClient) post(apiPath string, body interface{}) ([]byte, error) {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(client.ServerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL %q: %w", client.ServerURL, err)
+	}
+	u.Path = path.Join(u.Path, apiPath)
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "grafana "+GrafanaVersion)
+	req.SetBasicAuth(client.User, client.Password)
+
+	// installing includes downloading the plugin, which can take long on slow networks
+	res, err := HttpClientNoTimeout.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Grafana server: %w", err)
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode/100 != 2 {
+		var jsonBody map[string]interface{}
+		if err := json.Unmarshal(resBody, &jsonBody); err == nil {
+			if message, ok := jsonBody["message"].(string); ok && message != "" {
+				return nil, &BadRequestError{Status: res.Status, Message: message}
+			}
+		}
+		return nil, &BadRequestError{Status: res.Status}
+	}
+
+	return resBody, nil
+}
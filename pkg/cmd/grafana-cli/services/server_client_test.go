@@ -0,0 +1,80 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaServerClient_InstallPlugin(t *testing.T) {
+	t.Run("Should install plugin through the server API", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/grafana/api/plugins/test-plugin/install", r.URL.Path)
+			user, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "admin", user)
+			assert.Equal(t, "secret", password)
+
+			var body map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "1.0.0", body["version"])
+
+			_, err := w.Write([]byte(`{"id":"test-plugin","name":"Test","type":"datasource","version":"1.0.0"}`))
+			assert.NoError(t, err)
+		}))
+		t.Cleanup(server.Close)
+
+		client := &GrafanaServerClient{ServerURL: server.URL + "/grafana", User: "admin", Password: "secret"}
+		plugin, err := client.InstallPlugin("test-plugin", "1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, models.ServerPlugin{ID: "test-plugin", Name: "Test", Type: "datasource", Version: "1.0.0"}, plugin)
+	})
+
+	t.Run("Should return error message of the server", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+			_, err := w.Write([]byte(`{"message":"Plugin already installed"}`))
+			assert.NoError(t, err)
+		}))
+		t.Cleanup(server.Close)
+
+		client := &GrafanaServerClient{ServerURL: server.URL}
+		_, err := client.InstallPlugin("test-plugin", "")
+		require.Error(t, err)
+		assert.Equal(t, "Plugin already installed", asBadRequestError(t, err).Message)
+	})
+}
+
+func TestGrafanaServerClient_ScanPlugins(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/admin/plugins/scan", r.URL.Path)
+
+		var body struct {
+			Paths         []string `json:"paths"`
+			AllowUnsigned bool     `json:"allowUnsigned"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{"/var/lib/grafana/plugins"}, body.Paths)
+		assert.True(t, body.AllowUnsigned)
+
+		_, err := w.Write([]byte(`[{"id":"test-app","type":"app","version":"1.0.0","pluginDir":"/var/lib/grafana/plugins/test-app",
+			"signature":"unsigned","error":{"errorCode":"signatureMissing"}}]`))
+		assert.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	client := &GrafanaServerClient{ServerURL: server.URL, User: "admin", Password: "secret"}
+	scanned, err := client.ScanPlugins([]string{"/var/lib/grafana/plugins"}, true)
+	require.NoError(t, err)
+	require.Equal(t, []models.ScannedPlugin{{
+		ID: "test-app", Type: "app", Version: "1.0.0", PluginDir: "/var/lib/grafana/plugins/test-app",
+		Signature: "unsigned", Error: &models.ScannedError{ErrorCode: "signatureMissing"},
+	}}, scanned)
+}
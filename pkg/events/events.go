@@ -62,3 +62,19 @@ type DataSourceCreated struct {
 	UID       string    `json:"uid"`
 	OrgID     int64     `json:"org_id"`
 }
+
+type DataSourceUpdated struct {
+	Timestamp time.Time `json:"timestamp"`
+	Name      string    `json:"name"`
+	ID        int64     `json:"id"`
+	UID       string    `json:"uid"`
+	OrgID     int64     `json:"org_id"`
+	Type      string    `json:"type"`
+}
+
+type PluginSettingUpdated struct {
+	Timestamp time.Time `json:"timestamp"`
+	PluginID  string    `json:"plugin_id"`
+	OrgID     int64     `json:"org_id"`
+	Enabled   bool      `json:"enabled"`
+}
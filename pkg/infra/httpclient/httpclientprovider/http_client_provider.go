@@ -41,6 +41,10 @@ func New(cfg *setting.Cfg) *sdkhttpclient.Provider {
 	return newProviderFunc(sdkhttpclient.ProviderOptions{
 		Middlewares: middlewares,
 		ConfigureTransport: func(opts sdkhttpclient.Options, transport *http.Transport) {
+			if proxy, exists := cfg.PluginProxy(opts.Labels["datasource_type"]); exists {
+				transport.Proxy = proxy.ProxyFunc()
+			}
+
 			datasourceName, exists := opts.Labels["datasource_name"]
 			if !exists {
 				return
@@ -49,6 +49,12 @@ func (ctx *ReqContext) IsApiRequest() bool {
 }
 
 func (ctx *ReqContext) JsonApiErr(status int, message string, err error) {
+	ctx.JsonApiErrWithCode(status, message, "", err)
+}
+
+// JsonApiErrWithCode writes a JSON error response like JsonApiErr, with a machine-readable error code in the
+// errorCode field if code isn't empty.
+func (ctx *ReqContext) JsonApiErrWithCode(status int, message string, code string, err error) {
 	resp := make(map[string]interface{})
 
 	if err != nil {
@@ -69,6 +75,10 @@ func (ctx *ReqContext) JsonApiErr(status int, message string, err error) {
 		resp["message"] = message
 	}
 
+	if code != "" {
+		resp["errorCode"] = code
+	}
+
 	ctx.JSON(status, resp)
 }
 
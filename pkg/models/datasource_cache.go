@@ -107,6 +107,7 @@ func (ds *DataSource) HTTPClientOptions() (*sdkhttpclient.Options, error) {
 		Labels: map[string]string{
 			"datasource_name": ds.Name,
 			"datasource_uid":  ds.Uid,
+			"datasource_type": ds.Type,
 		},
 		TLS: &tlsOptions,
 	}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PluginInstallation is the installation state of a plugin shared by all Grafana instances using the same database,
+// so that plugins installed, upgraded or uninstalled on one instance are on all of them.
+type PluginInstallation struct {
+	Id       int64
+	PluginId string
+	// Version is the installed version, empty for an uninstalled plugin.
+	Version   string
+	Installed bool
+	Updated   time.Time
+}
@@ -0,0 +1,18 @@
+package models
+
+// PluginLeaderLease is the lease of the Grafana instance elected to run the background plugin tasks among the
+// instances using the same database. The instance holding the lease renews it before it expires, otherwise another
+// instance acquires it.
+type PluginLeaderLease struct {
+	Name       string `xorm:"pk"`
+	InstanceId string
+	// Expires is the unix time in seconds the lease expires at.
+	Expires int64
+	Version int64
+}
+
+// PluginLeadershipChanged is published on the bus when this Grafana instance became or stopped being the leader
+// running the background plugin tasks.
+type PluginLeadershipChanged struct {
+	Leader bool
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// PluginLifecycleEventType is the type of change of a plugin a PluginLifecycleEvent is published for.
+type PluginLifecycleEventType string
+
+const (
+	// PluginInstalled is published when a plugin is installed or upgraded.
+	PluginInstalled PluginLifecycleEventType = "installed"
+	// PluginUninstalled is published when a plugin is uninstalled.
+	PluginUninstalled PluginLifecycleEventType = "uninstalled"
+	// PluginFailed is published when a backend plugin exceeded its restart budget and is no longer restarted.
+	PluginFailed PluginLifecycleEventType = "failed"
+	// PluginDecommissioned is published when a backend plugin was drained and stopped.
+	PluginDecommissioned PluginLifecycleEventType = "decommissioned"
+)
+
+// PluginLifecycleEvent is published on the bus when the state of a plugin changes on this Grafana instance or, if
+// Remote, on another instance sharing the database. Events of this instance are stored in the database for the
+// other instances to publish them.
+type PluginLifecycleEvent struct {
+	Id       int64                    `json:"-"`
+	PluginId string                   `json:"pluginId"`
+	Type     PluginLifecycleEventType `json:"type"`
+	Version  string                   `json:"version,omitempty"`
+	Error    string                   `json:"error,omitempty"`
+	// InstanceId identifies the Grafana instance the event happened on, empty until the event is stored.
+	InstanceId string    `json:"instanceId,omitempty"`
+	Created    time.Time `json:"created"`
+	Remote     bool      `xorm:"-" json:"remote"`
+}
@@ -2,17 +2,32 @@
 package adapters
 
 import (
+	"context"
+
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/secrets"
 )
 
-// ModelToInstanceSettings converts a models.DataSource to a backend.DataSourceInstanceSettings.
+// ModelToInstanceSettings converts a models.DataSource to a backend.DataSourceInstanceSettings, resolving the
+// secureJsonData values referencing secrets of external secrets managers. When a referenced secret is rotated, the
+// update time of the settings is advanced, so that the plugin replaces its instance of the data source.
 func ModelToInstanceSettings(ds *models.DataSource) (*backend.DataSourceInstanceSettings, error) {
 	jsonDataBytes, err := ds.JsonData.MarshalJSON()
 	if err != nil {
 		return nil, err
 	}
 
+	decryptedSecureJSONData, secretsChanged, err := secrets.Resolve(context.Background(),
+		secrets.DataSourceOwner(ds.OrgId, ds.Id), ds.DecryptedValues())
+	if err != nil {
+		return nil, err
+	}
+	updated := ds.Updated
+	if secretsChanged.After(updated) {
+		updated = secretsChanged
+	}
+
 	return &backend.DataSourceInstanceSettings{
 		ID:                      ds.Id,
 		Name:                    ds.Name,
@@ -23,8 +38,8 @@ func ModelToInstanceSettings(ds *models.DataSource) (*backend.DataSourceInstance
 		BasicAuthEnabled:        ds.BasicAuth,
 		BasicAuthUser:           ds.BasicAuthUser,
 		JSONData:                jsonDataBytes,
-		DecryptedSecureJSONData: ds.DecryptedValues(),
-		Updated:                 ds.Updated,
+		DecryptedSecureJSONData: decryptedSecureJSONData,
+		Updated:                 updated,
 	}, nil
 }
 
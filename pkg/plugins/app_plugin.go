@@ -19,10 +19,31 @@ type AppPlugin struct {
 	Routes      []*AppPluginRoute `json:"routes"`
 	AutoEnabled bool              `json:"autoEnabled"`
 
-	FoundChildPlugins []*PluginInclude `json:"-"`
-	Pinned            bool             `json:"-"`
+	Pinned bool `json:"-"`
 
-	Executable string `json:"executable,omitempty"`
+	Executable string           `json:"executable,omitempty"`
+	Container  *PluginContainer `json:"container,omitempty"`
+}
+
+// Bundles reports whether a plugin located in pluginDir is bundled by the app, being located in its directory.
+func (app *AppPlugin) Bundles(pluginDir string) bool {
+	return strings.HasPrefix(pluginDir, app.PluginDir+string(filepath.Separator))
+}
+
+// LinkChildPlugins links the panels and data sources located in the directory of the app, which bundles them, as
+// its children. Plugins already linked to an app are skipped. The app and the linked plugins are modified.
+func (app *AppPlugin) LinkChildPlugins(panels map[string]*PanelPlugin, dataSources map[string]*DataSourcePlugin,
+	cfg *setting.Cfg) {
+	for _, panel := range panels {
+		if panel.Parent == nil && app.Bundles(panel.PluginDir) {
+			panel.setPathsBasedOnApp(app, cfg)
+		}
+	}
+	for _, ds := range dataSources {
+		if ds.Parent == nil && app.Bundles(ds.PluginDir) {
+			ds.setPathsBasedOnApp(app, cfg)
+		}
+	}
 }
 
 // AppPluginRoute describes a plugin route that is defined in
@@ -72,6 +93,9 @@ func (app *AppPlugin) Load(decoder *json.Decoder, base *PluginBase, backendPlugi
 		cmd := ComposePluginStartCommand(app.Executable)
 		fullpath := filepath.Join(base.PluginDir, cmd)
 		factory := grpcplugin.NewBackendPlugin(app.Id, fullpath)
+		if app.Container != nil && app.Container.Image != "" {
+			factory = grpcplugin.NewContainerBackendPlugin(app.Id, fullpath, app.Container.Image)
+		}
 		if err := backendPluginManager.RegisterAndStart(context.Background(), app.Id, factory); err != nil {
 			return nil, errutil.Wrapf(err, "failed to register backend plugin")
 		}
@@ -80,34 +104,10 @@ func (app *AppPlugin) Load(decoder *json.Decoder, base *PluginBase, backendPlugi
 	return app, nil
 }
 
-func (app *AppPlugin) InitApp(panels map[string]*PanelPlugin, dataSources map[string]*DataSourcePlugin,
-	cfg *setting.Cfg) []*PluginStaticRoute {
+// InitApp initializes the frontend of the app. The plugins it bundles are linked to it with LinkChildPlugins.
+func (app *AppPlugin) InitApp(cfg *setting.Cfg) []*PluginStaticRoute {
 	staticRoutes := app.InitFrontendPlugin(cfg)
 
-	// check if we have child panels
-	for _, panel := range panels {
-		if strings.HasPrefix(panel.PluginDir, app.PluginDir) {
-			panel.setPathsBasedOnApp(app, cfg)
-			app.FoundChildPlugins = append(app.FoundChildPlugins, &PluginInclude{
-				Name: panel.Name,
-				Id:   panel.Id,
-				Type: panel.Type,
-			})
-		}
-	}
-
-	// check if we have child datasources
-	for _, ds := range dataSources {
-		if strings.HasPrefix(ds.PluginDir, app.PluginDir) {
-			ds.setPathsBasedOnApp(app, cfg)
-			app.FoundChildPlugins = append(app.FoundChildPlugins, &PluginInclude{
-				Name: ds.Name,
-				Id:   ds.Id,
-				Type: ds.Type,
-			})
-		}
-	}
-
 	// slugify pages
 	for _, include := range app.Includes {
 		if include.Slug == "" {
@@ -0,0 +1,27 @@
+package backendplugin
+
+import "context"
+
+// PluginCapabilities are the backend methods a plugin implements. Calling methods a plugin doesn't implement
+// returns ErrMethodNotImplemented.
+type PluginCapabilities struct {
+	// QueryData is whether the plugin handles data queries.
+	QueryData bool `json:"queryData"`
+	// CallResource is whether the plugin handles resource calls.
+	CallResource bool `json:"callResource"`
+	// Streaming is whether the plugin handles subscribing and publishing to streams and running them.
+	Streaming bool `json:"streaming"`
+	// CheckHealth is whether the plugin handles health checks.
+	CheckHealth bool `json:"checkHealth"`
+}
+
+// AllPluginCapabilities are the capabilities of plugins implementing all backend methods. They're reported for
+// plugins whose capabilities can't be discovered, so that their methods keep being called.
+var AllPluginCapabilities = PluginCapabilities{QueryData: true, CallResource: true, Streaming: true, CheckHealth: true}
+
+// CapabilityProber is implemented by backend plugins that can discover which backend methods they implement.
+type CapabilityProber interface {
+	// Capabilities returns the backend methods the plugin implements. It returns ErrPluginUnavailable if the plugin
+	// isn't running.
+	Capabilities(ctx context.Context) (PluginCapabilities, error)
+}
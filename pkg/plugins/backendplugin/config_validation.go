@@ -0,0 +1,25 @@
+package backendplugin
+
+// ValidateConfigPath is the path of the resource backend plugins handle POST requests to for validating the settings
+// of a data source or app instance, given in the plugin context of the request, before they're saved. The plugin
+// responds with a ValidateConfigResult in JSON. Plugins without the resource accept all settings.
+const ValidateConfigPath = "_validate-config"
+
+// ValidateConfigResult is the result of validating the settings of a data source or app instance.
+type ValidateConfigResult struct {
+	// Errors are the errors of the invalid settings, empty if the settings are valid.
+	Errors []ConfigFieldError `json:"errors"`
+}
+
+// ConfigFieldError is the error of an invalid setting.
+type ConfigFieldError struct {
+	// Field is the path of the setting, such as url, jsonData.region or secureJsonData.apiKey.
+	Field string `json:"field"`
+	// Message tells the user what's wrong with the setting.
+	Message string `json:"message"`
+}
+
+// Valid reports whether the settings are valid.
+func (r *ValidateConfigResult) Valid() bool {
+	return len(r.Errors) == 0
+}
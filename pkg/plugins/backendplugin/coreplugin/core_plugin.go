@@ -64,6 +64,16 @@ func (cp *corePlugin) IsDecommissioned() bool {
 	return false
 }
 
+// Capabilities returns the backend methods the plugin has handlers for.
+func (cp *corePlugin) Capabilities(ctx context.Context) (backendplugin.PluginCapabilities, error) {
+	return backendplugin.PluginCapabilities{
+		QueryData:    cp.QueryDataHandler != nil,
+		CallResource: cp.CallResourceHandler != nil,
+		Streaming:    cp.StreamHandler != nil,
+		CheckHealth:  cp.CheckHealthHandler != nil,
+	}, nil
+}
+
 func (cp *corePlugin) CollectMetrics(ctx context.Context) (*backend.CollectMetricsResult, error) {
 	return nil, backendplugin.ErrMethodNotImplemented
 }
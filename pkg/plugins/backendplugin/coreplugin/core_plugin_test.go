@@ -30,6 +30,10 @@ func TestCorePlugin(t *testing.T) {
 
 		err = p.CallResource(context.Background(), nil, nil)
 		require.Equal(t, backendplugin.ErrMethodNotImplemented, err)
+
+		capabilities, err := p.(backendplugin.CapabilityProber).Capabilities(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, backendplugin.PluginCapabilities{}, capabilities)
 	})
 
 	t.Run("New core plugin with handlers set in opts should return expected values", func(t *testing.T) {
@@ -65,5 +69,9 @@ func TestCorePlugin(t *testing.T) {
 		err = p.CallResource(context.Background(), &backend.CallResourceRequest{}, nil)
 		require.NoError(t, err)
 		require.True(t, callResourceCalled)
+
+		capabilities, err := p.(backendplugin.CapabilityProber).Capabilities(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, backendplugin.PluginCapabilities{CallResource: true, CheckHealth: true}, capabilities)
 	})
 }
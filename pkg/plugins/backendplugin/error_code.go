@@ -0,0 +1,94 @@
+package backendplugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorCode is the machine-readable category of a failed plugin request, returned to clients in the errorCode field
+// of error responses so that they can act on it, for example by retrying requests to unavailable plugins or by
+// asking users to fix the credentials of a data source.
+type ErrorCode string
+
+const (
+	// ErrorCodeUnavailable is the code of requests to plugins that aren't running or are overloaded.
+	ErrorCodeUnavailable ErrorCode = "plugin.unavailable"
+	// ErrorCodeCrashed is the code of requests to plugins that kept crashing and are no longer restarted.
+	ErrorCodeCrashed ErrorCode = "plugin.crashed"
+	// ErrorCodeTimeout is the code of requests that exceeded their timeout.
+	ErrorCodeTimeout ErrorCode = "plugin.timeout"
+	// ErrorCodeUnauthorizedUpstream is the code of requests the upstream of the plugin, such as the database of a data
+	// source, rejected the credentials of.
+	ErrorCodeUnauthorizedUpstream ErrorCode = "plugin.unauthorizedUpstream"
+	// ErrorCodeBadQuery is the code of invalid queries and requests.
+	ErrorCodeBadQuery ErrorCode = "plugin.badQuery"
+	// ErrorCodeTooManyRequests is the code of requests rejected by the limits of the plugin or data source.
+	ErrorCodeTooManyRequests ErrorCode = "plugin.tooManyRequests"
+	// ErrorCodeNotFound is the code of requests to plugins or plugin methods that don't exist.
+	ErrorCodeNotFound ErrorCode = "plugin.notFound"
+	// ErrorCodeInternal is the code of all other failed requests.
+	ErrorCodeInternal ErrorCode = "plugin.internal"
+)
+
+// ErrorCodeOf returns the code of the error of a failed plugin request, from the errors of this package or from the
+// gRPC status of the error returned by the plugin.
+func ErrorCodeOf(err error) ErrorCode {
+	switch {
+	case errors.Is(err, ErrPluginCrashed):
+		return ErrorCodeCrashed
+	case errors.Is(err, ErrPluginUnavailable):
+		return ErrorCodeUnavailable
+	case errors.Is(err, ErrPluginTimeout), errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeTimeout
+	case errors.Is(err, ErrTooManyQueries):
+		return ErrorCodeTooManyRequests
+	case errors.Is(err, ErrPluginNotRegistered), errors.Is(err, ErrMethodNotImplemented):
+		return ErrorCodeNotFound
+	}
+
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &grpcErr) {
+		return ErrorCodeInternal
+	}
+	switch grpcErr.GRPCStatus().Code() {
+	case codes.Unavailable:
+		return ErrorCodeUnavailable
+	case codes.DeadlineExceeded:
+		return ErrorCodeTimeout
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return ErrorCodeUnauthorizedUpstream
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return ErrorCodeBadQuery
+	case codes.ResourceExhausted:
+		return ErrorCodeTooManyRequests
+	case codes.NotFound, codes.Unimplemented:
+		return ErrorCodeNotFound
+	default:
+		return ErrorCodeInternal
+	}
+}
+
+// HTTPStatus returns the HTTP status of the error responses of the code. Requests rejected by the upstream of the
+// plugin respond with 502 Bad Gateway, since 401 Unauthorized would tell clients that the Grafana session expired.
+func (c ErrorCode) HTTPStatus() int {
+	switch c {
+	case ErrorCodeUnavailable, ErrorCodeCrashed:
+		return http.StatusServiceUnavailable
+	case ErrorCodeTimeout:
+		return http.StatusGatewayTimeout
+	case ErrorCodeUnauthorizedUpstream:
+		return http.StatusBadGateway
+	case ErrorCodeBadQuery:
+		return http.StatusBadRequest
+	case ErrorCodeTooManyRequests:
+		return http.StatusTooManyRequests
+	case ErrorCodeNotFound:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
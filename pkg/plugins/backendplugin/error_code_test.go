@@ -0,0 +1,58 @@
+package backendplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	tcs := []struct {
+		desc string
+		err  error
+		code ErrorCode
+	}{
+		{desc: "unavailable plugin", err: ErrPluginUnavailable, code: ErrorCodeUnavailable},
+		{desc: "crashed plugin", err: ErrPluginCrashed, code: ErrorCodeCrashed},
+		{desc: "wrapped timeout", err: fmt.Errorf("query failed: %w", ErrPluginTimeout), code: ErrorCodeTimeout},
+		{desc: "exceeded deadline", err: context.DeadlineExceeded, code: ErrorCodeTimeout},
+		{desc: "too many queries", err: ErrTooManyQueries, code: ErrorCodeTooManyRequests},
+		{desc: "unregistered plugin", err: ErrPluginNotRegistered, code: ErrorCodeNotFound},
+		{desc: "unimplemented method", err: ErrMethodNotImplemented, code: ErrorCodeNotFound},
+		{desc: "unauthenticated upstream", err: status.Error(codes.Unauthenticated, "bad password"),
+			code: ErrorCodeUnauthorizedUpstream},
+		{desc: "permission denied upstream", err: status.Error(codes.PermissionDenied, "denied"),
+			code: ErrorCodeUnauthorizedUpstream},
+		{desc: "wrapped invalid argument", err: fmt.Errorf("failed: %w", status.Error(codes.InvalidArgument, "syntax")),
+			code: ErrorCodeBadQuery},
+		{desc: "unavailable status", err: status.Error(codes.Unavailable, "down"), code: ErrorCodeUnavailable},
+		{desc: "deadline status", err: status.Error(codes.DeadlineExceeded, "slow"), code: ErrorCodeTimeout},
+		{desc: "exhausted status", err: status.Error(codes.ResourceExhausted, "limit"), code: ErrorCodeTooManyRequests},
+		{desc: "unknown status", err: status.Error(codes.Unknown, "boom"), code: ErrorCodeInternal},
+		{desc: "plain error", err: errors.New("boom"), code: ErrorCodeInternal},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.code, ErrorCodeOf(tc.err))
+		})
+	}
+}
+
+func TestErrorCode_HTTPStatus(t *testing.T) {
+	require.Equal(t, http.StatusServiceUnavailable, ErrorCodeUnavailable.HTTPStatus())
+	require.Equal(t, http.StatusServiceUnavailable, ErrorCodeCrashed.HTTPStatus())
+	require.Equal(t, http.StatusGatewayTimeout, ErrorCodeTimeout.HTTPStatus())
+	require.Equal(t, http.StatusBadGateway, ErrorCodeUnauthorizedUpstream.HTTPStatus())
+	require.Equal(t, http.StatusBadRequest, ErrorCodeBadQuery.HTTPStatus())
+	require.Equal(t, http.StatusTooManyRequests, ErrorCodeTooManyRequests.HTTPStatus())
+	require.Equal(t, http.StatusNotFound, ErrorCodeNotFound.HTTPStatus())
+	require.Equal(t, http.StatusInternalServerError, ErrorCodeInternal.HTTPStatus())
+}
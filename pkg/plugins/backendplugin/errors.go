@@ -9,6 +9,21 @@ var (
 	ErrHealthCheckFailed = errors.New("health check failed")
 	// ErrPluginUnavailable error returned when plugin is unavailable.
 	ErrPluginUnavailable = errors.New("plugin unavailable")
+	// ErrPluginCrashed error returned when plugin exceeded its restart budget and is no longer restarted.
+	ErrPluginCrashed = errors.New("plugin crashed")
 	// ErrMethodNotImplemented error returned when plugin method not implemented.
 	ErrMethodNotImplemented = errors.New("method not implemented")
+	// ErrPluginTimeout error returned when a plugin request exceeds its configured timeout.
+	ErrPluginTimeout = errors.New("plugin request timed out")
+	// ErrTooManyQueries error returned when the concurrent query limit of a data source is reached.
+	ErrTooManyQueries = errors.New("too many concurrent queries")
+	// ErrInvalidLogLevel error returned when setting an unknown plugin log level.
+	ErrInvalidLogLevel = errors.New("invalid log level")
+	// ErrPluginNotRestartable error returned when restarting a plugin not running as a managed process.
+	ErrPluginNotRestartable = errors.New("plugin cannot be restarted")
+	// ErrPluginNotReloadable error returned when reloading a plugin not managed by Grafana.
+	ErrPluginNotReloadable = errors.New("plugin cannot be reloaded")
+	// ErrStreamSubscriberTooSlow error returned when a stream subscriber is disconnected for not keeping up with the
+	// messages of the stream.
+	ErrStreamSubscriberTooSlow = errors.New("stream subscriber too slow")
 )
@@ -0,0 +1,121 @@
+package grpcplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+// Names of the gRPC services of the plugin protocol. Plugins only register the services of the backend methods
+// they implement, except for the diagnostics service, which the plugin SDK always registers to collect metrics, and
+// whose health checks succeed if the plugin doesn't handle them.
+const (
+	dataServiceName        = "pluginv2.Data"
+	resourceServiceName    = "pluginv2.Resource"
+	streamServiceName      = "pluginv2.Stream"
+	diagnosticsServiceName = "pluginv2.Diagnostics"
+)
+
+// probeTimeout is the maximum duration of probing the capabilities of a plugin.
+const probeTimeout = 10 * time.Second
+
+// probeCapabilities returns the backend methods implemented by the plugin connected to with conn, listing the gRPC
+// services it registered with gRPC server reflection, which go-plugin registers. Plugins that don't support
+// reflection are reported to implement all methods.
+func probeCapabilities(ctx context.Context, conn *grpc.ClientConn) (backendplugin.PluginCapabilities, error) {
+	services, err := listServices(ctx, conn)
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.Unimplemented:
+		return backendplugin.AllPluginCapabilities, nil
+	case codes.Unavailable:
+		return backendplugin.PluginCapabilities{}, fmt.Errorf("%w: %s", backendplugin.ErrPluginUnavailable, err)
+	default:
+		return backendplugin.PluginCapabilities{}, err
+	}
+
+	capabilities := backendplugin.PluginCapabilities{}
+	for _, service := range services {
+		switch service {
+		case dataServiceName:
+			capabilities.QueryData = true
+		case resourceServiceName:
+			capabilities.CallResource = true
+		case streamServiceName:
+			capabilities.Streaming = true
+		case diagnosticsServiceName:
+			capabilities.CheckHealth = true
+		}
+	}
+	return capabilities, nil
+}
+
+// listServices returns the names of the gRPC services registered by the server connected to with conn.
+func listServices(ctx context.Context, conn *grpc.ClientConn) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return nil, fmt.Errorf("failed to list plugin services: %s", errResp.ErrorMessage)
+	}
+	if resp.GetListServicesResponse() == nil {
+		return nil, errors.New("failed to list plugin services: unexpected response")
+	}
+
+	names := make([]string, 0, len(resp.GetListServicesResponse().Service))
+	for _, service := range resp.GetListServicesResponse().Service {
+		names = append(names, service.Name)
+	}
+	return names, nil
+}
+
+// capabilitiesCache caches the capabilities probed on the connection to a plugin, which are probed again once the
+// plugin connects again. The zero value is ready to use.
+type capabilitiesCache struct {
+	mu           sync.Mutex
+	conn         *grpc.ClientConn
+	capabilities backendplugin.PluginCapabilities
+}
+
+func (c *capabilitiesCache) get(ctx context.Context, conn *grpc.ClientConn) (backendplugin.PluginCapabilities, error) {
+	c.mu.Lock()
+	if c.conn == conn {
+		capabilities := c.capabilities
+		c.mu.Unlock()
+		return capabilities, nil
+	}
+	c.mu.Unlock()
+
+	capabilities, err := probeCapabilities(ctx, conn)
+	if err != nil {
+		return backendplugin.PluginCapabilities{}, err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.capabilities = capabilities
+	c.mu.Unlock()
+	return capabilities, nil
+}
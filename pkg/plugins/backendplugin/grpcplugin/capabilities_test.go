@@ -0,0 +1,82 @@
+package grpcplugin
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/genproto/pluginv2"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+func newTestServer(t *testing.T, withReflection bool) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	pluginv2.RegisterDataServer(server, &testDataServer{})
+	pluginv2.RegisterStreamServer(server, &pluginv2.UnimplementedStreamServer{})
+	if withReflection {
+		reflection.Register(server)
+	}
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	dial := func(t *testing.T, addr string) *grpc.ClientConn {
+		conn, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithConnectParams(connectParams))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = conn.Close()
+		})
+		return conn
+	}
+
+	t.Run("Should report the services registered by the plugin", func(t *testing.T) {
+		capabilities, err := probeCapabilities(context.Background(), dial(t, newTestServer(t, true)))
+		require.NoError(t, err)
+		require.Equal(t, backendplugin.PluginCapabilities{QueryData: true, Streaming: true}, capabilities)
+	})
+
+	t.Run("Should report all methods for plugins without reflection", func(t *testing.T) {
+		capabilities, err := probeCapabilities(context.Background(), dial(t, newTestServer(t, false)))
+		require.NoError(t, err)
+		require.Equal(t, backendplugin.AllPluginCapabilities, capabilities)
+	})
+
+	t.Run("Should report unavailable plugins", func(t *testing.T) {
+		unused, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := unused.Addr().String()
+		require.NoError(t, unused.Close())
+
+		_, err = probeCapabilities(context.Background(), dial(t, addr))
+		require.ErrorIs(t, err, backendplugin.ErrPluginUnavailable)
+	})
+}
+
+func TestRemotePlugin_Capabilities(t *testing.T) {
+	p, err := NewRemoteBackendPlugin("test", newTestServer(t, true))("test", log.New("test"), nil)
+	require.NoError(t, err)
+	prober, ok := p.(backendplugin.CapabilityProber)
+	require.True(t, ok)
+
+	_, err = prober.Capabilities(context.Background())
+	require.ErrorIs(t, err, backendplugin.ErrPluginUnavailable)
+
+	require.NoError(t, p.Start(context.Background()))
+	capabilities, err := prober.Capabilities(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, backendplugin.PluginCapabilities{QueryData: true, Streaming: true}, capabilities)
+
+	require.NoError(t, p.Stop(context.Background()))
+	_, err = prober.Capabilities(context.Background())
+	require.ErrorIs(t, err, backendplugin.ErrPluginUnavailable)
+}
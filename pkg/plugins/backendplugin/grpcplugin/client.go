@@ -1,6 +1,7 @@
 package grpcplugin
 
 import (
+	"io"
 	"os/exec"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/grpcplugin"
@@ -8,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin/pluginextensionv2"
 	goplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
 )
 
 // Handshake is the HandshakeConfig used to configure clients and servers.
@@ -23,7 +25,7 @@ var handshake = goplugin.HandshakeConfig{
 }
 
 func newClientConfig(executablePath string, env []string, logger log.Logger,
-	versionedPlugins map[int]goplugin.PluginSet) *goplugin.ClientConfig {
+	versionedPlugins map[int]goplugin.PluginSet, output io.Writer) *goplugin.ClientConfig {
 	// We can ignore gosec G201 here, since the dynamic part of executablePath comes from the plugin definition
 	// nolint:gosec
 	cmd := exec.Command(executablePath)
@@ -35,6 +37,10 @@ func newClientConfig(executablePath string, env []string, logger log.Logger,
 		VersionedPlugins: versionedPlugins,
 		Logger:           logWrapper{Logger: logger},
 		AllowedProtocols: []goplugin.Protocol{goplugin.ProtocolGRPC},
+		Stderr:           output,
+		SyncStdout:       output,
+		SyncStderr:       output,
+		GRPCDialOptions:  []grpc.DialOption{grpc.WithConnectParams(connectParams)},
 	}
 }
 
@@ -45,6 +51,7 @@ type StartRendererFunc func(pluginID string, renderer pluginextensionv2.Renderer
 type PluginDescriptor struct {
 	pluginID         string
 	executablePath   string
+	containerImage   string
 	managed          bool
 	versionedPlugins map[int]goplugin.PluginSet
 	startRendererFn  StartRendererFunc
@@ -97,7 +97,7 @@ func (c *clientV2) CollectMetrics(ctx context.Context) (*backend.CollectMetricsR
 		return &backend.CollectMetricsResult{}, nil
 	}
 
-	protoResp, err := c.DiagnosticsClient.CollectMetrics(ctx, &pluginv2.CollectMetricsRequest{})
+	protoResp, err := c.DiagnosticsClient.CollectMetrics(withTraceMetadata(ctx), &pluginv2.CollectMetricsRequest{})
 	if err != nil {
 		if status.Code(err) == codes.Unimplemented {
 			return &backend.CollectMetricsResult{}, nil
@@ -115,7 +115,7 @@ func (c *clientV2) CheckHealth(ctx context.Context, req *backend.CheckHealthRequ
 	}
 
 	protoContext := backend.ToProto().PluginContext(req.PluginContext)
-	protoResp, err := c.DiagnosticsClient.CheckHealth(ctx, &pluginv2.CheckHealthRequest{PluginContext: protoContext})
+	protoResp, err := c.DiagnosticsClient.CheckHealth(withTraceMetadata(ctx), &pluginv2.CheckHealthRequest{PluginContext: protoContext})
 
 	if err != nil {
 		if status.Code(err) == codes.Unimplemented {
@@ -136,7 +136,7 @@ func (c *clientV2) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 	}
 
 	protoReq := backend.ToProto().QueryDataRequest(req)
-	protoResp, err := c.DataClient.QueryData(ctx, protoReq)
+	protoResp, err := c.DataClient.QueryData(withTraceMetadata(ctx), protoReq)
 
 	if err != nil {
 		if status.Code(err) == codes.Unimplemented {
@@ -155,7 +155,7 @@ func (c *clientV2) CallResource(ctx context.Context, req *backend.CallResourceRe
 	}
 
 	protoReq := backend.ToProto().CallResourceRequest(req)
-	protoStream, err := c.ResourceClient.CallResource(ctx, protoReq)
+	protoStream, err := c.ResourceClient.CallResource(withTraceMetadata(ctx), protoReq)
 	if err != nil {
 		if status.Code(err) == codes.Unimplemented {
 			return backendplugin.ErrMethodNotImplemented
@@ -188,7 +188,7 @@ func (c *clientV2) SubscribeStream(ctx context.Context, req *backend.SubscribeSt
 	if c.StreamClient == nil {
 		return nil, backendplugin.ErrMethodNotImplemented
 	}
-	protoResp, err := c.StreamClient.SubscribeStream(ctx, backend.ToProto().SubscribeStreamRequest(req))
+	protoResp, err := c.StreamClient.SubscribeStream(withTraceMetadata(ctx), backend.ToProto().SubscribeStreamRequest(req))
 	if err != nil {
 		return nil, err
 	}
@@ -199,7 +199,7 @@ func (c *clientV2) PublishStream(ctx context.Context, req *backend.PublishStream
 	if c.StreamClient == nil {
 		return nil, backendplugin.ErrMethodNotImplemented
 	}
-	protoResp, err := c.StreamClient.PublishStream(ctx, backend.ToProto().PublishStreamRequest(req))
+	protoResp, err := c.StreamClient.PublishStream(withTraceMetadata(ctx), backend.ToProto().PublishStreamRequest(req))
 	if err != nil {
 		return nil, err
 	}
@@ -212,7 +212,7 @@ func (c *clientV2) RunStream(ctx context.Context, req *backend.RunStreamRequest,
 	}
 
 	protoReq := backend.ToProto().RunStreamRequest(req)
-	protoStream, err := c.StreamClient.RunStream(ctx, protoReq)
+	protoStream, err := c.StreamClient.RunStream(withTraceMetadata(ctx), protoReq)
 	if err != nil {
 		if status.Code(err) == codes.Unimplemented {
 			return backendplugin.ErrMethodNotImplemented
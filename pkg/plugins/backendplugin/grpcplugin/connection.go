@@ -0,0 +1,70 @@
+package grpcplugin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+)
+
+// connectTimeout is the maximum duration of establishing the gRPC connection to a started plugin process.
+const connectTimeout = 10 * time.Second
+
+// connectParams configures how the gRPC connection to a plugin process is re-established. The plugin process runs
+// on the same host, so there is no need to back off for as long as the gRPC defaults do for remote servers.
+var connectParams = grpc.ConnectParams{
+	Backoff: backoff.Config{
+		BaseDelay:  100 * time.Millisecond,
+		Multiplier: 1.6,
+		Jitter:     0.2,
+		MaxDelay:   5 * time.Second,
+	},
+	MinConnectTimeout: 5 * time.Second,
+}
+
+// grpcConn returns the gRPC connection of rpcClient, nil if it isn't a gRPC client.
+func grpcConn(rpcClient plugin.ClientProtocol) *grpc.ClientConn {
+	if c, ok := rpcClient.(*plugin.GRPCClient); ok {
+		return c.Conn
+	}
+	return nil
+}
+
+// waitForReady blocks until conn is connected, so that the first request to a started plugin doesn't pay for
+// setting up the connection.
+func waitForReady(ctx context.Context, conn *grpc.ClientConn) error {
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return errors.New("plugin connection is shut down")
+		case connectivity.TransientFailure:
+			conn.ResetConnectBackoff()
+		}
+
+		if !conn.WaitForStateChange(ctx, state) {
+			return ctx.Err()
+		}
+	}
+}
+
+// ensureConnected reports whether conn can still be used. A failed connection is re-established right away instead
+// of after the remaining reconnect backoff, false is returned if conn is shut down.
+func ensureConnected(conn *grpc.ClientConn) bool {
+	switch conn.GetState() {
+	case connectivity.Shutdown:
+		return false
+	case connectivity.TransientFailure:
+		conn.ResetConnectBackoff()
+	}
+	return true
+}
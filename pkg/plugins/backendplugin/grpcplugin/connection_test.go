@@ -0,0 +1,53 @@
+package grpcplugin
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestWaitForReady(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure(), grpc.WithConnectParams(connectParams))
+	require.NoError(t, err)
+
+	t.Run("Should connect", func(t *testing.T) {
+		require.NoError(t, waitForReady(context.Background(), conn))
+		require.Equal(t, connectivity.Ready, conn.GetState())
+		require.True(t, ensureConnected(conn))
+	})
+
+	t.Run("Should fail when the connection is closed", func(t *testing.T) {
+		require.NoError(t, conn.Close())
+		require.Error(t, waitForReady(context.Background(), conn))
+		require.False(t, ensureConnected(conn))
+	})
+
+	t.Run("Should stop waiting when the context is done", func(t *testing.T) {
+		unused, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := unused.Addr().String()
+		require.NoError(t, unused.Close())
+
+		conn, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithConnectParams(connectParams))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = conn.Close()
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, waitForReady(ctx, conn), context.Canceled)
+	})
+}
@@ -0,0 +1,122 @@
+package grpcplugin
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/grpcplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	goplugin "github.com/hashicorp/go-plugin"
+)
+
+// containerRemoveTimeout is how long removing a plugin container may take.
+const containerRemoveTimeout = 10 * time.Second
+
+// containerEnv are the environment variables go-plugin sets for the plugin process, which are forwarded to the plugin
+// container along with those of the plugin.
+var containerEnv = []string{
+	grpcplugin.MagicCookieKey,
+	"PLUGIN_MIN_PORT",
+	"PLUGIN_MAX_PORT",
+	"PLUGIN_PROTOCOL_VERSIONS",
+	"PLUGIN_CLIENT_CERT",
+}
+
+// containerMountEnv are the environment variables of the plugin with the paths of the plugin directories, which are
+// mounted in the plugin container at the same path.
+var containerMountEnv = map[string]bool{
+	"GF_PLUGIN_DATA_DIR": true,
+	"TMPDIR":             true,
+}
+
+// NewContainerBackendPlugin creates a new backend plugin factory used for registering a backend plugin that runs the
+// executable at executablePath, or in a container of image when configured to run in a container.
+func NewContainerBackendPlugin(pluginID, executablePath, image string) backendplugin.PluginFactoryFunc {
+	return newPlugin(PluginDescriptor{
+		pluginID:       pluginID,
+		executablePath: executablePath,
+		containerImage: image,
+		managed:        true,
+		versionedPlugins: map[int]goplugin.PluginSet{
+			grpcplugin.ProtocolVersion: getV2PluginSet(),
+		},
+	})
+}
+
+// RunInContainer runs the plugin in a container of the image of its descriptor the next time it starts. Plugins
+// without an image keep running as a process of Grafana.
+func (p *grpcPlugin) RunInContainer(runtime string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.descriptor.containerImage == "" {
+		return
+	}
+	p.containerRuntime = runtime
+}
+
+// newContainerName returns a new name of a container of a plugin, unique to every time the plugin starts.
+func newContainerName(pluginID string) string {
+	return fmt.Sprintf("grafana-plugin-%s-%d", pluginID, time.Now().UnixNano())
+}
+
+// containerCommand returns the command running a plugin container of image with runtime. The container is removed
+// when it exits, and it has a read-only file system, no capabilities and no way to gain privileges, except for the
+// plugin directories. It shares the network of the host, since the plugin listens on the address of the loopback
+// interface it reports to Grafana.
+func containerCommand(runtime, name, image string, env []string) *exec.Cmd {
+	args := []string{
+		"run", "--rm",
+		"--name", name,
+		"--network", "host",
+		"--read-only",
+		"--tmpfs", "/tmp",
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+	}
+
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		// the values are passed through the environment of the runtime, so they don't show up in its arguments
+		args = append(args, "-e", parts[0])
+		if containerMountEnv[parts[0]] && parts[1] != "" {
+			args = append(args, "-v", parts[1]+":"+parts[1])
+		}
+	}
+	for _, key := range containerEnv {
+		args = append(args, "-e", key)
+	}
+	args = append(args, image)
+
+	// We can ignore gosec G204 here, since the runtime comes from the Grafana configuration and the image from the
+	// plugin definition
+	// nolint:gosec
+	cmd := exec.Command(runtime, args...)
+	cmd.Env = env
+	return cmd
+}
+
+// removeContainer removes the plugin container last started, which the runtime doesn't remove when it's killed before
+// the container exited. Must be called with mutex held.
+func (p *grpcPlugin) removeContainer() {
+	if p.containerName == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), containerRemoveTimeout)
+	defer cancel()
+
+	// nolint:gosec
+	if out, err := exec.CommandContext(ctx, p.containerRuntime, "rm", "-f", p.containerName).CombinedOutput(); err != nil {
+		// the container is usually already removed, since it's removed when it exits
+		p.logger.Debug("Failed to remove plugin container", "container", p.containerName, "error", err,
+			"output", strings.TrimSpace(string(out)))
+	}
+	p.containerName = ""
+}
@@ -0,0 +1,66 @@
+package grpcplugin
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerPlugin(t *testing.T) {
+	env := []string{"GF_PLUGIN_DATA_DIR=/var/lib/grafana/plugin-data/test/data", "GF_VERSION=8.2.0"}
+
+	t.Run("Should run plugins with an image in a container", func(t *testing.T) {
+		p, err := NewContainerBackendPlugin("test", "/plugins/test/gpx_test", "grafana/test:1.0.0")("test",
+			log.New("test"), env)
+		require.NoError(t, err)
+		gp := p.(*grpcPlugin)
+		gp.RunInContainer("nerdctl")
+
+		gp.clientFactory()
+		cmd := gp.clientConfig.Cmd
+		require.Equal(t, "nerdctl", cmd.Args[0])
+		require.Equal(t, []string{
+			"run", "--rm",
+			"--name", gp.containerName,
+			"--network", "host",
+			"--read-only",
+			"--tmpfs", "/tmp",
+			"--cap-drop", "ALL",
+			"--security-opt", "no-new-privileges",
+			"-e", "GF_PLUGIN_DATA_DIR",
+			"-v", "/var/lib/grafana/plugin-data/test/data:/var/lib/grafana/plugin-data/test/data",
+			"-e", "GF_VERSION",
+			"-e", "grafana_plugin_type",
+			"-e", "PLUGIN_MIN_PORT",
+			"-e", "PLUGIN_MAX_PORT",
+			"-e", "PLUGIN_PROTOCOL_VERSIONS",
+			"-e", "PLUGIN_CLIENT_CERT",
+			"grafana/test:1.0.0",
+		}, cmd.Args[1:])
+		require.Equal(t, env, cmd.Env)
+		require.Regexp(t, "^grafana-plugin-test-[0-9]+$", gp.containerName)
+	})
+
+	t.Run("Should run plugins with an image as a process unless configured to run in a container", func(t *testing.T) {
+		p, err := NewContainerBackendPlugin("test", "/plugins/test/gpx_test", "grafana/test:1.0.0")("test",
+			log.New("test"), env)
+		require.NoError(t, err)
+		gp := p.(*grpcPlugin)
+
+		gp.clientFactory()
+		require.Equal(t, "/plugins/test/gpx_test", gp.clientConfig.Cmd.Path)
+		require.Empty(t, gp.containerName)
+	})
+
+	t.Run("Should run plugins without an image as a process", func(t *testing.T) {
+		p, err := NewBackendPlugin("test", "/plugins/test/gpx_test")("test", log.New("test"), env)
+		require.NoError(t, err)
+		gp := p.(*grpcPlugin)
+		gp.RunInContainer("docker")
+
+		gp.clientFactory()
+		require.Equal(t, "/plugins/test/gpx_test", gp.clientConfig.Cmd.Path)
+		require.Empty(t, gp.containerName)
+	})
+}
@@ -0,0 +1,135 @@
+package grpcplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+)
+
+// discoveryRefreshInterval is how often the replicas of a remote plugin are discovered again, in addition to when
+// the connection to a replica fails.
+var discoveryRefreshInterval = 30 * time.Second
+
+// discoveryTimeout is the maximum duration of discovering the replicas of a remote plugin.
+const discoveryTimeout = 10 * time.Second
+
+// roundRobinServiceConfig balances the requests to a remote plugin across the replicas it's connected to. Replicas
+// whose connection failed don't get requests until they're reconnected.
+const roundRobinServiceConfig = `{"loadBalancingConfig": [{"round_robin": {}}]}`
+
+// discoverFunc returns the addresses of the replicas of a remote plugin.
+type discoverFunc func(ctx context.Context) ([]string, error)
+
+// discoveryResolvers returns the gRPC resolvers discovering the replicas of remote plugins, for the remote plugin
+// addresses with the consul and kubernetes schemes. Addresses with the dns scheme are resolved by the DNS resolver of
+// gRPC.
+func discoveryResolvers() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithResolvers(
+			&discoveryResolverBuilder{scheme: "consul", newDiscover: newConsulDiscover},
+			&discoveryResolverBuilder{scheme: "kubernetes", newDiscover: newKubernetesDiscover},
+		),
+		grpc.WithDefaultServiceConfig(roundRobinServiceConfig),
+	}
+}
+
+// discoveryResolverBuilder builds resolvers discovering the replicas of a remote plugin with the discoverFunc
+// newDiscover returns for the target.
+type discoveryResolverBuilder struct {
+	scheme      string
+	newDiscover func(target resolver.Target) (discoverFunc, error)
+}
+
+func (b *discoveryResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn,
+	_ resolver.BuildOptions) (resolver.Resolver, error) {
+	discover, err := b.newDiscover(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s address of remote plugin: %w", b.scheme, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &discoveryResolver{
+		discover: discover,
+		cc:       cc,
+		cancel:   cancel,
+		resolve:  make(chan struct{}, 1),
+	}
+	r.wg.Add(1)
+	go r.run(ctx)
+	return r, nil
+}
+
+func (b *discoveryResolverBuilder) Scheme() string {
+	return b.scheme
+}
+
+// discoveryResolver discovers the replicas of a remote plugin when it's built, every discoveryRefreshInterval and
+// whenever gRPC asks for it, such as when the connection to a replica failed.
+type discoveryResolver struct {
+	discover discoverFunc
+	cc       resolver.ClientConn
+	cancel   context.CancelFunc
+	resolve  chan struct{}
+	wg       sync.WaitGroup
+}
+
+func (r *discoveryResolver) run(ctx context.Context) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(discoveryRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		r.update(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.resolve:
+		}
+	}
+}
+
+func (r *discoveryResolver) update(ctx context.Context) {
+	discoverCtx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+
+	addresses, err := r.discover(discoverCtx)
+	if ctx.Err() != nil {
+		// the resolver was closed
+		return
+	}
+	if err != nil {
+		r.cc.ReportError(err)
+		return
+	}
+	if len(addresses) == 0 {
+		r.cc.ReportError(errors.New("no replicas of remote plugin found"))
+		return
+	}
+
+	state := resolver.State{}
+	for _, address := range addresses {
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: address})
+	}
+	_ = r.cc.UpdateState(state)
+}
+
+// ResolveNow discovers the replicas of the remote plugin again, unless that's already pending.
+func (r *discoveryResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolve <- struct{}{}:
+	default:
+	}
+}
+
+func (r *discoveryResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
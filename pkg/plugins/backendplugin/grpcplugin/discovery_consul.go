@@ -0,0 +1,88 @@
+package grpcplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// newConsulDiscover discovers the replicas of a remote plugin registered as a service in Consul, for addresses of
+// the form consul://<consul address>/<service name>. Only the replicas passing their health checks are discovered.
+// The Consul HTTP API is called with the ACL token in the CONSUL_HTTP_TOKEN environment variable, over HTTPS if
+// CONSUL_HTTP_SSL is true.
+func newConsulDiscover(target resolver.Target) (discoverFunc, error) {
+	service := strings.Trim(target.Endpoint, "/")
+	if target.Authority == "" || service == "" {
+		return nil, errors.New("expected consul://<consul address>/<service name>")
+	}
+
+	scheme := "http"
+	if ssl, _ := strconv.ParseBool(os.Getenv("CONSUL_HTTP_SSL")); ssl {
+		scheme = "https"
+	}
+	healthURL := url.URL{
+		Scheme:   scheme,
+		Host:     target.Authority,
+		Path:     "/v1/health/service/" + url.PathEscape(service),
+		RawQuery: "passing=true",
+	}
+
+	return func(ctx context.Context) ([]string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if token := os.Getenv("CONSUL_HTTP_TOKEN"); token != "" {
+			req.Header.Set("X-Consul-Token", token)
+		}
+
+		var entries []struct {
+			Node struct {
+				Address string
+			}
+			Service struct {
+				Address string
+				Port    int
+			}
+		}
+		if err := getJSON(http.DefaultClient, req, &entries); err != nil {
+			return nil, fmt.Errorf("failed to discover replicas of service %s in Consul: %w", service, err)
+		}
+
+		addresses := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			// services registered without address are reachable at the address of their node
+			host := entry.Service.Address
+			if host == "" {
+				host = entry.Node.Address
+			}
+			addresses = append(addresses, net.JoinHostPort(host, strconv.Itoa(entry.Service.Port)))
+		}
+		return addresses, nil
+	}, nil
+}
+
+// getJSON sends req with client and decodes the JSON response into v.
+func getJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
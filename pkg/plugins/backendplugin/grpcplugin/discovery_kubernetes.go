@@ -0,0 +1,117 @@
+package grpcplugin
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// kubernetesServiceAccountDir is the directory of the service account token and CA certificate of the pod Grafana
+// runs in.
+var kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// newKubernetesDiscover discovers the replicas of a remote plugin running in the pods of a Kubernetes service, for
+// addresses of the form kubernetes:///<service>.<namespace>:<port>, where port is the number or the name of the
+// port of the service. Only the ready pods are discovered, from the endpoints of the service, so Grafana must run in
+// the cluster with a service account allowed to get them.
+func newKubernetesDiscover(target resolver.Target) (discoverFunc, error) {
+	host, port, err := net.SplitHostPort(target.Endpoint)
+	if err != nil {
+		return nil, errors.New("expected kubernetes:///<service>.<namespace>:<port>")
+	}
+	parts := strings.SplitN(host, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || port == "" {
+		return nil, errors.New("expected kubernetes:///<service>.<namespace>:<port>")
+	}
+	service, namespace := parts[0], parts[1]
+
+	var client *http.Client
+	return func(ctx context.Context) ([]string, error) {
+		if client == nil {
+			if client, err = kubernetesClient(); err != nil {
+				return nil, err
+			}
+		}
+
+		endpointsURL := fmt.Sprintf("https://%s/api/v1/namespaces/%s/endpoints/%s",
+			net.JoinHostPort(os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")),
+			namespace, service)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointsURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		// the token is read every time, since projected service account tokens are rotated
+		token, err := ioutil.ReadFile(filepath.Join(kubernetesServiceAccountDir, "token"))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+
+		var endpoints struct {
+			Subsets []struct {
+				Addresses []struct {
+					IP string `json:"ip"`
+				} `json:"addresses"`
+				Ports []struct {
+					Name string `json:"name"`
+					Port int    `json:"port"`
+				} `json:"ports"`
+			} `json:"subsets"`
+		}
+		if err := getJSON(client, req, &endpoints); err != nil {
+			return nil, fmt.Errorf("failed to discover replicas of Kubernetes service %s in namespace %s: %w", service,
+				namespace, err)
+		}
+
+		var addresses []string
+		for _, subset := range endpoints.Subsets {
+			subsetPort := port
+			if _, err := strconv.Atoi(port); err != nil {
+				// named ports can map to different port numbers in every subset
+				subsetPort = ""
+				for _, p := range subset.Ports {
+					if p.Name == port {
+						subsetPort = strconv.Itoa(p.Port)
+					}
+				}
+				if subsetPort == "" {
+					continue
+				}
+			}
+
+			for _, address := range subset.Addresses {
+				addresses = append(addresses, net.JoinHostPort(address.IP, subsetPort))
+			}
+		}
+		return addresses, nil
+	}, nil
+}
+
+// kubernetesClient returns the HTTP client of the Kubernetes API, trusting the CA certificate of the cluster.
+func kubernetesClient() (*http.Client, error) {
+	// nolint:gosec
+	caCert, err := ioutil.ReadFile(filepath.Join(kubernetesServiceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("invalid Kubernetes CA certificate")
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}},
+	}, nil
+}
@@ -0,0 +1,168 @@
+package grpcplugin
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/genproto/pluginv2"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+)
+
+type countingDataServer struct {
+	testDataServer
+	mu       sync.Mutex
+	requests int
+}
+
+func (s *countingDataServer) QueryData(ctx context.Context, req *pluginv2.QueryDataRequest) (*pluginv2.QueryDataResponse, error) {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+	return s.testDataServer.QueryData(ctx, req)
+}
+
+func (s *countingDataServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func newConsulServer(t *testing.T, addresses ...string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/health/service/test-plugin", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("passing"))
+
+		var entries []map[string]interface{}
+		for _, address := range addresses {
+			host, port, err := net.SplitHostPort(address)
+			assert.NoError(t, err)
+			entries = append(entries, map[string]interface{}{
+				"Node":    map[string]interface{}{"Address": host},
+				"Service": map[string]interface{}{"Port": json.Number(port)},
+			})
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(entries))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDiscovery_Consul(t *testing.T) {
+	server := newConsulServer(t, "10.0.0.1:10001", "10.0.0.2:10001")
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	discover, err := newConsulDiscover(resolver.Target{Scheme: "consul", Authority: u.Host, Endpoint: "test-plugin"})
+	require.NoError(t, err)
+	addresses, err := discover(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:10001", "10.0.0.2:10001"}, addresses)
+
+	_, err = newConsulDiscover(resolver.Target{Scheme: "consul", Endpoint: "test-plugin"})
+	require.Error(t, err)
+}
+
+func TestDiscovery_Kubernetes(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/namespaces/plugins/endpoints/test-plugin", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		_, _ = w.Write([]byte(`{"subsets": [
+			{"addresses": [{"ip": "10.0.0.1"}, {"ip": "10.0.0.2"}], "ports": [{"name": "grpc", "port": 10001}]},
+			{"addresses": [{"ip": "10.0.0.3"}], "notReadyAddresses": [{"ip": "10.0.0.4"}],
+				"ports": [{"name": "grpc", "port": 10002}]}
+		]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte("token\n"), 0600))
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.crt"), caCert, 0600))
+	serviceAccountDir := kubernetesServiceAccountDir
+	kubernetesServiceAccountDir = dir
+	t.Cleanup(func() { kubernetesServiceAccountDir = serviceAccountDir })
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	t.Setenv("KUBERNETES_SERVICE_HOST", u.Hostname())
+	t.Setenv("KUBERNETES_SERVICE_PORT", u.Port())
+
+	t.Run("Should discover the ready pods of a service by port name", func(t *testing.T) {
+		discover, err := newKubernetesDiscover(resolver.Target{Scheme: "kubernetes", Endpoint: "test-plugin.plugins:grpc"})
+		require.NoError(t, err)
+		addresses, err := discover(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"10.0.0.1:10001", "10.0.0.2:10001", "10.0.0.3:10002"}, addresses)
+	})
+
+	t.Run("Should discover the ready pods of a service by port number", func(t *testing.T) {
+		discover, err := newKubernetesDiscover(resolver.Target{Scheme: "kubernetes", Endpoint: "test-plugin.plugins:9000"})
+		require.NoError(t, err)
+		addresses, err := discover(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"10.0.0.1:9000", "10.0.0.2:9000", "10.0.0.3:9000"}, addresses)
+	})
+
+	t.Run("Should require service, namespace and port", func(t *testing.T) {
+		for _, endpoint := range []string{"test-plugin:grpc", "test-plugin.plugins", ".plugins:grpc"} {
+			_, err := newKubernetesDiscover(resolver.Target{Scheme: "kubernetes", Endpoint: endpoint})
+			require.Error(t, err, endpoint)
+		}
+	})
+}
+
+func TestRemotePlugin_Discovery(t *testing.T) {
+	var servers []*countingDataServer
+	var addresses []string
+	for i := 0; i < 2; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		server := grpc.NewServer()
+		dataServer := &countingDataServer{}
+		pluginv2.RegisterDataServer(server, dataServer)
+		go func() {
+			_ = server.Serve(lis)
+		}()
+		t.Cleanup(server.Stop)
+
+		servers = append(servers, dataServer)
+		addresses = append(addresses, lis.Addr().String())
+	}
+	consul := newConsulServer(t, addresses...)
+	u, err := url.Parse(consul.URL)
+	require.NoError(t, err)
+
+	p, err := NewRemoteBackendPlugin("test", "consul://"+u.Host+"/test-plugin")("test", log.New("test"), nil)
+	require.NoError(t, err)
+	require.NoError(t, p.Start(context.Background()))
+	t.Cleanup(func() {
+		_ = p.Stop(context.Background())
+	})
+
+	// requests are balanced across the replicas once they're all connected
+	require.Eventually(t, func() bool {
+		_, err := p.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A"}},
+		})
+		require.NoError(t, err)
+		return servers[0].count() > 0 && servers[1].count() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -2,14 +2,19 @@ package grpcplugin
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"os/exec"
 	"sync"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/process"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
 )
 
 type pluginClient interface {
@@ -23,26 +28,77 @@ type pluginClient interface {
 type grpcPlugin struct {
 	descriptor     PluginDescriptor
 	clientFactory  func() *plugin.Client
+	clientConfig   *plugin.ClientConfig
 	client         *plugin.Client
+	conn           *grpc.ClientConn
 	pluginClient   pluginClient
 	logger         log.Logger
+	output         *outputBuffer
 	mutex          sync.RWMutex
 	decommissioned bool
+	// transportSecured is whether the plugin must connect with TLS, with the certificates of transportTLS or with
+	// generated ones if it's nil.
+	transportSecured bool
+	transportTLS     *tls.Config
+	// containerRuntime is the command line tool of the container runtime the plugin runs with, if it runs in a
+	// container of the image of its descriptor, and containerName the name of the plugin container last started.
+	containerRuntime string
+	containerName    string
+	// wrapProcess returns the command the plugin process is started with for the command of the plugin executable.
+	wrapProcess func(cmd *exec.Cmd) *exec.Cmd
+	// capabilities are the backend methods the plugin implements, probed on its current connection.
+	capabilities capabilitiesCache
 }
 
 // newPlugin allocates and returns a new gRPC (external) backendplugin.Plugin.
 func newPlugin(descriptor PluginDescriptor) backendplugin.PluginFactoryFunc {
 	return func(pluginID string, logger log.Logger, env []string) (backendplugin.Plugin, error) {
-		return &grpcPlugin{
+		output := newOutputBuffer(outputBufferSize)
+		p := &grpcPlugin{
 			descriptor: descriptor,
 			logger:     logger,
-			clientFactory: func() *plugin.Client {
-				return plugin.NewClient(newClientConfig(descriptor.executablePath, env, logger, descriptor.versionedPlugins))
-			},
-		}, nil
+			output:     output,
+		}
+		p.clientFactory = func() *plugin.Client {
+			config := newClientConfig(descriptor.executablePath, env, logger, descriptor.versionedPlugins, output)
+			if p.containerRuntime != "" {
+				p.containerName = newContainerName(descriptor.pluginID)
+				config.Cmd = containerCommand(p.containerRuntime, p.containerName, descriptor.containerImage, env)
+			} else if p.wrapProcess != nil {
+				config.Cmd = p.wrapProcess(config.Cmd)
+			}
+			if p.transportSecured {
+				if p.transportTLS != nil {
+					config.TLSConfig = p.transportTLS.Clone()
+				} else {
+					config.AutoMTLS = true
+				}
+			}
+			p.clientConfig = config
+			return plugin.NewClient(config)
+		}
+		return p, nil
 	}
 }
 
+// SecureTransport requires the plugin to connect with mutual TLS the next time it starts.
+func (p *grpcPlugin) SecureTransport(config *tls.Config) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.transportSecured = true
+	p.transportTLS = config
+}
+
+// WrapProcess starts the plugin process with the command wrap returns from the next time the plugin starts. Plugins
+// running in a container are started with the container runtime regardless.
+func (p *grpcPlugin) WrapProcess(wrap func(cmd *exec.Cmd) *exec.Cmd) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.wrapProcess = wrap
+}
+
 func (p *grpcPlugin) PluginID() string {
 	return p.descriptor.pluginID
 }
@@ -55,12 +111,20 @@ func (p *grpcPlugin) Start(ctx context.Context) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
+	_, _ = fmt.Fprintf(p.output, "=== Plugin process started at %s ===\n", time.Now().Format(time.RFC3339))
+	// the container of a plugin that crashed may still exist if the container runtime didn't remove it
+	p.removeContainer()
 	p.client = p.clientFactory()
 	rpcClient, err := p.client.Client()
 	if err != nil {
 		return err
 	}
 
+	// plugins not supporting automatic mutual TLS don't send their certificate, so they can't be connected to
+	if p.transportSecured && p.transportTLS == nil && p.clientConfig.TLSConfig.RootCAs == nil {
+		return errors.New("plugin doesn't support TLS")
+	}
+
 	if p.client.NegotiatedVersion() < 2 {
 		return errors.New("plugin protocol version not supported")
 	}
@@ -73,6 +137,13 @@ func (p *grpcPlugin) Start(ctx context.Context) error {
 		return errors.New("no compatible plugin implementation found")
 	}
 
+	p.conn = grpcConn(rpcClient)
+	if p.conn != nil {
+		if err := waitForReady(ctx, p.conn); err != nil {
+			return fmt.Errorf("failed to connect to plugin: %w", err)
+		}
+	}
+
 	elevated, err := process.IsRunningWithElevatedPrivileges()
 	if err != nil {
 		p.logger.Debug("Error checking plugin process execution privilege", "err", err)
@@ -91,6 +162,7 @@ func (p *grpcPlugin) Stop(ctx context.Context) error {
 	if p.client != nil {
 		p.client.Kill()
 	}
+	p.removeContainer()
 	return nil
 }
 
@@ -107,6 +179,26 @@ func (p *grpcPlugin) Exited() bool {
 	return true
 }
 
+// Pid returns the process ID of the plugin process, false if the plugin isn't running.
+func (p *grpcPlugin) Pid() (int, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.client == nil || p.client.Exited() {
+		return 0, false
+	}
+
+	reattach := p.client.ReattachConfig()
+	if reattach == nil {
+		return 0, false
+	}
+	return reattach.Pid, true
+}
+
+// Output returns the latest stdout and stderr output of the plugin processes.
+func (p *grpcPlugin) Output() []byte {
+	return p.output.Bytes()
+}
+
 func (p *grpcPlugin) Decommission() error {
 	p.mutex.RLock()
 	defer p.mutex.RUnlock()
@@ -120,9 +212,24 @@ func (p *grpcPlugin) IsDecommissioned() bool {
 	return p.decommissioned
 }
 
+// Capabilities returns the backend methods the plugin implements, probed once per start of the plugin process.
+func (p *grpcPlugin) Capabilities(ctx context.Context) (backendplugin.PluginCapabilities, error) {
+	if _, ok := p.getPluginClient(); !ok {
+		return backendplugin.PluginCapabilities{}, backendplugin.ErrPluginUnavailable
+	}
+
+	p.mutex.RLock()
+	conn := p.conn
+	p.mutex.RUnlock()
+	if conn == nil {
+		return backendplugin.AllPluginCapabilities, nil
+	}
+	return p.capabilities.get(ctx, conn)
+}
+
 func (p *grpcPlugin) getPluginClient() (pluginClient, bool) {
 	p.mutex.RLock()
-	if p.client == nil || p.client.Exited() || p.pluginClient == nil {
+	if p.client == nil || p.client.Exited() || p.pluginClient == nil || (p.conn != nil && !ensureConnected(p.conn)) {
 		p.mutex.RUnlock()
 		return nil, false
 	}
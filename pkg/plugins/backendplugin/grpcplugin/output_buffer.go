@@ -0,0 +1,57 @@
+package grpcplugin
+
+import "sync"
+
+// outputBufferSize is how many bytes of output of a plugin process are kept.
+const outputBufferSize = 64 * 1024
+
+// outputBuffer is a ring buffer keeping the latest output written to it.
+type outputBuffer struct {
+	mu   sync.Mutex
+	data []byte
+	pos  int
+	full bool
+}
+
+func newOutputBuffer(size int) *outputBuffer {
+	return &outputBuffer{data: make([]byte, size)}
+}
+
+// Write writes p to the buffer, overwriting the oldest output when the buffer is full.
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	size := len(b.data)
+	if len(p) >= size {
+		copy(b.data, p[len(p)-size:])
+		b.pos = 0
+		b.full = true
+		return len(p), nil
+	}
+
+	n := copy(b.data[b.pos:], p)
+	if n < len(p) {
+		copy(b.data, p[n:])
+	}
+	if b.pos+len(p) >= size {
+		b.full = true
+	}
+	b.pos = (b.pos + len(p)) % size
+
+	return len(p), nil
+}
+
+// Bytes returns a copy of the buffered output, oldest first.
+func (b *outputBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.full {
+		return append([]byte{}, b.data[:b.pos]...)
+	}
+
+	out := make([]byte, 0, len(b.data))
+	out = append(out, b.data[b.pos:]...)
+	return append(out, b.data[:b.pos]...)
+}
@@ -0,0 +1,47 @@
+package grpcplugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputBuffer(t *testing.T) {
+	t.Run("Should return written output", func(t *testing.T) {
+		b := newOutputBuffer(8)
+		_, err := b.Write([]byte("abc"))
+		require.NoError(t, err)
+		_, err = b.Write([]byte("de"))
+		require.NoError(t, err)
+		require.Equal(t, "abcde", string(b.Bytes()))
+	})
+
+	t.Run("Should keep latest output when full", func(t *testing.T) {
+		b := newOutputBuffer(8)
+		_, err := b.Write([]byte("abcdef"))
+		require.NoError(t, err)
+		_, err = b.Write([]byte("ghij"))
+		require.NoError(t, err)
+		require.Equal(t, "cdefghij", string(b.Bytes()))
+
+		_, err = b.Write([]byte("k"))
+		require.NoError(t, err)
+		require.Equal(t, "defghijk", string(b.Bytes()))
+	})
+
+	t.Run("Should keep end of output larger than buffer", func(t *testing.T) {
+		b := newOutputBuffer(4)
+		_, err := b.Write([]byte("ab"))
+		require.NoError(t, err)
+		_, err = b.Write([]byte("cdefgh"))
+		require.NoError(t, err)
+		require.Equal(t, "efgh", string(b.Bytes()))
+	})
+
+	t.Run("Should fill buffer exactly", func(t *testing.T) {
+		b := newOutputBuffer(4)
+		_, err := b.Write([]byte("abcd"))
+		require.NoError(t, err)
+		require.Equal(t, "abcd", string(b.Bytes()))
+	})
+}
@@ -0,0 +1,271 @@
+package grpcplugin
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	goplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+)
+
+// remotePlugin is a backend plugin running outside of Grafana, such as in a sidecar container or on another host,
+// whose gRPC server Grafana connects to instead of starting the plugin process. The plugin serves the plugin
+// protocol without the go-plugin handshake, like plugins started in the standalone mode of the plugin SDK. Plugins
+// with multiple replicas, discovered with DNS, Consul or Kubernetes, get requests balanced across their replicas.
+type remotePlugin struct {
+	descriptor     PluginDescriptor
+	address        string
+	logger         log.Logger
+	conn           *grpc.ClientConn
+	pluginClient   pluginClient
+	mutex          sync.RWMutex
+	decommissioned bool
+	// transportSecured is whether the connection to the plugin must use TLS, with the certificates of transportTLS.
+	transportSecured bool
+	transportTLS     *tls.Config
+	// capabilities are the backend methods the plugin implements, probed on its current connection.
+	capabilities capabilitiesCache
+}
+
+// NewRemoteBackendPlugin creates a new backend plugin factory used for registering a backend plugin that runs
+// outside of Grafana and serves gRPC on address.
+func NewRemoteBackendPlugin(pluginID, address string) backendplugin.PluginFactoryFunc {
+	return func(_ string, logger log.Logger, _ []string) (backendplugin.Plugin, error) {
+		return &remotePlugin{
+			descriptor: PluginDescriptor{
+				pluginID: pluginID,
+				managed:  true,
+			},
+			address: address,
+			logger:  logger,
+		}, nil
+	}
+}
+
+// SecureTransport requires the connection to the plugin to use TLS the next time it starts.
+func (p *remotePlugin) SecureTransport(config *tls.Config) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.transportSecured = true
+	p.transportTLS = config
+}
+
+func (p *remotePlugin) PluginID() string {
+	return p.descriptor.pluginID
+}
+
+func (p *remotePlugin) Logger() log.Logger {
+	return p.logger
+}
+
+// Start connects to the plugin. The plugin doesn't need to be reachable yet, the connection is retried in the
+// background until it is.
+func (p *remotePlugin) Start(ctx context.Context) error {
+	conn, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := waitForReady(ctx, conn); err != nil {
+		p.logger.Warn("Remote plugin isn't reachable yet", "address", p.address, "error", err)
+	}
+	return nil
+}
+
+func (p *remotePlugin) connect(ctx context.Context) (*grpc.ClientConn, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	transportCredentials := grpc.WithInsecure()
+	if p.transportSecured {
+		// certificates can't be generated for plugins that aren't started by Grafana
+		if p.transportTLS == nil {
+			return nil, errors.New("TLS connections to remote plugins require transport_tls_cert_file, " +
+				"transport_tls_key_file and transport_tls_ca_file")
+		}
+		transportCredentials = grpc.WithTransportCredentials(credentials.NewTLS(p.transportTLS.Clone()))
+	}
+
+	// the message sizes aren't limited, like on the connections go-plugin makes to plugin processes
+	opts := append([]grpc.DialOption{
+		transportCredentials,
+		grpc.WithConnectParams(connectParams),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(math.MaxInt32), grpc.MaxCallSendMsgSize(math.MaxInt32)),
+	}, discoveryResolvers()...)
+	conn, err := grpc.DialContext(ctx, p.address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to remote plugin at %s: %w", p.address, err)
+	}
+
+	pluginClient, err := newClientV2(p.descriptor, p.logger, &remoteClientProtocol{conn: conn})
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	p.conn = conn
+	p.pluginClient = pluginClient
+
+	return conn, nil
+}
+
+func (p *remotePlugin) Stop(ctx context.Context) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.conn != nil {
+		return p.conn.Close()
+	}
+	return nil
+}
+
+func (p *remotePlugin) IsManaged() bool {
+	return p.descriptor.managed
+}
+
+// Exited returns whether the connection to the plugin is closed. A plugin that isn't reachable hasn't exited, since
+// the connection to it is retried.
+func (p *remotePlugin) Exited() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.conn == nil || p.conn.GetState() == connectivity.Shutdown
+}
+
+func (p *remotePlugin) Decommission() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.decommissioned = true
+
+	return nil
+}
+
+func (p *remotePlugin) IsDecommissioned() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.decommissioned
+}
+
+// Capabilities returns the backend methods the plugin implements, probed once per connection to the plugin.
+func (p *remotePlugin) Capabilities(ctx context.Context) (backendplugin.PluginCapabilities, error) {
+	if _, ok := p.getPluginClient(); !ok {
+		return backendplugin.PluginCapabilities{}, backendplugin.ErrPluginUnavailable
+	}
+
+	p.mutex.RLock()
+	conn := p.conn
+	p.mutex.RUnlock()
+	return p.capabilities.get(ctx, conn)
+}
+
+func (p *remotePlugin) getPluginClient() (pluginClient, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if p.pluginClient == nil || p.conn == nil || !ensureConnected(p.conn) {
+		return nil, false
+	}
+	return p.pluginClient, true
+}
+
+func (p *remotePlugin) CollectMetrics(ctx context.Context) (*backend.CollectMetricsResult, error) {
+	pluginClient, ok := p.getPluginClient()
+	if !ok {
+		return nil, backendplugin.ErrPluginUnavailable
+	}
+	return pluginClient.CollectMetrics(ctx)
+}
+
+func (p *remotePlugin) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	pluginClient, ok := p.getPluginClient()
+	if !ok {
+		return nil, backendplugin.ErrPluginUnavailable
+	}
+	return pluginClient.CheckHealth(ctx, req)
+}
+
+func (p *remotePlugin) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	pluginClient, ok := p.getPluginClient()
+	if !ok {
+		return nil, backendplugin.ErrPluginUnavailable
+	}
+	return pluginClient.QueryData(ctx, req)
+}
+
+func (p *remotePlugin) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	pluginClient, ok := p.getPluginClient()
+	if !ok {
+		return backendplugin.ErrPluginUnavailable
+	}
+	return pluginClient.CallResource(ctx, req, sender)
+}
+
+func (p *remotePlugin) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	pluginClient, ok := p.getPluginClient()
+	if !ok {
+		return nil, backendplugin.ErrPluginUnavailable
+	}
+	return pluginClient.SubscribeStream(ctx, req)
+}
+
+func (p *remotePlugin) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	pluginClient, ok := p.getPluginClient()
+	if !ok {
+		return nil, backendplugin.ErrPluginUnavailable
+	}
+	return pluginClient.PublishStream(ctx, req)
+}
+
+func (p *remotePlugin) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	pluginClient, ok := p.getPluginClient()
+	if !ok {
+		return backendplugin.ErrPluginUnavailable
+	}
+	return pluginClient.RunStream(ctx, req, sender)
+}
+
+// remoteClientProtocol dispenses the clients of the plugin protocol services over the connection to a remote plugin,
+// like go-plugin does over the connection to a plugin process.
+type remoteClientProtocol struct {
+	conn *grpc.ClientConn
+}
+
+func (c *remoteClientProtocol) Dispense(name string) (interface{}, error) {
+	p, exists := getV2PluginSet()[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown plugin type: %s", name)
+	}
+
+	grpcPlugin, ok := p.(goplugin.GRPCPlugin)
+	if !ok {
+		return nil, fmt.Errorf("plugin type %s doesn't support gRPC", name)
+	}
+
+	return grpcPlugin.GRPCClient(context.Background(), nil, c.conn)
+}
+
+func (c *remoteClientProtocol) Ping() error {
+	if c.conn.GetState() == connectivity.Shutdown {
+		return errors.New("plugin connection is shut down")
+	}
+	return nil
+}
+
+func (c *remoteClientProtocol) Close() error {
+	return c.conn.Close()
+}
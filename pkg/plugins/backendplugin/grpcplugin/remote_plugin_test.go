@@ -0,0 +1,79 @@
+package grpcplugin
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/genproto/pluginv2"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type testDataServer struct {
+	pluginv2.UnimplementedDataServer
+}
+
+func (s *testDataServer) QueryData(ctx context.Context, req *pluginv2.QueryDataRequest) (*pluginv2.QueryDataResponse, error) {
+	resp := &pluginv2.QueryDataResponse{Responses: map[string]*pluginv2.DataResponse{}}
+	for _, q := range req.Queries {
+		resp.Responses[q.RefId] = &pluginv2.DataResponse{}
+	}
+	return resp, nil
+}
+
+func TestRemotePlugin(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	pluginv2.RegisterDataServer(server, &testDataServer{})
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	p, err := NewRemoteBackendPlugin("test", lis.Addr().String())("test", log.New("test"), nil)
+	require.NoError(t, err)
+	require.Equal(t, "test", p.PluginID())
+	require.True(t, p.IsManaged())
+	require.True(t, p.Exited())
+
+	_, err = p.QueryData(context.Background(), &backend.QueryDataRequest{})
+	require.ErrorIs(t, err, backendplugin.ErrPluginUnavailable)
+
+	require.NoError(t, p.Start(context.Background()))
+	require.False(t, p.Exited())
+
+	t.Run("Should send requests to the remote plugin", func(t *testing.T) {
+		resp, err := p.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A"}},
+		})
+		require.NoError(t, err)
+		require.Contains(t, resp.Responses, "A")
+	})
+
+	t.Run("Should report services the remote plugin doesn't serve as not implemented", func(t *testing.T) {
+		result, err := p.CheckHealth(context.Background(), &backend.CheckHealthRequest{})
+		require.NoError(t, err)
+		require.Equal(t, backend.HealthStatusUnknown, result.Status)
+	})
+
+	t.Run("Should close the connection when stopped", func(t *testing.T) {
+		require.NoError(t, p.Stop(context.Background()))
+		require.True(t, p.Exited())
+
+		_, err := p.QueryData(context.Background(), &backend.QueryDataRequest{})
+		require.ErrorIs(t, err, backendplugin.ErrPluginUnavailable)
+	})
+}
+
+func TestRemotePlugin_SecureTransport(t *testing.T) {
+	p, err := NewRemoteBackendPlugin("test", "127.0.0.1:0")("test", log.New("test"), nil)
+	require.NoError(t, err)
+
+	p.(backendplugin.TransportSecurer).SecureTransport(nil)
+	require.Error(t, p.Start(context.Background()))
+}
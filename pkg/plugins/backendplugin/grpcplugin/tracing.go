@@ -0,0 +1,54 @@
+package grpcplugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	"google.golang.org/grpc/metadata"
+)
+
+// traceparentHeader is the W3C Trace Context header.
+const traceparentHeader = "traceparent"
+
+// withTraceMetadata adds the span context of the span in ctx to the outgoing gRPC metadata, so spans emitted
+// by the plugin can be connected to the Grafana trace. The span context is added both in the format of the
+// configured tracer and as a W3C traceparent header when the tracer is Jaeger.
+func withTraceMetadata(ctx context.Context) context.Context {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return ctx
+	}
+
+	carrier := opentracing.TextMapCarrier{}
+	if err := opentracing.GlobalTracer().Inject(span.Context(), opentracing.TextMap, carrier); err != nil {
+		return ctx
+	}
+
+	if traceparent, ok := w3cTraceparent(span.Context()); ok {
+		carrier[traceparentHeader] = traceparent
+	}
+
+	kv := make([]string, 0, len(carrier)*2)
+	for k, v := range carrier {
+		kv = append(kv, strings.ToLower(k), v)
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// w3cTraceparent returns the W3C traceparent header value of a Jaeger span context.
+func w3cTraceparent(spanContext opentracing.SpanContext) (string, bool) {
+	sc, ok := spanContext.(jaeger.SpanContext)
+	if !ok || !sc.IsValid() {
+		return "", false
+	}
+
+	flags := "00"
+	if sc.IsSampled() {
+		flags = "01"
+	}
+	traceID := sc.TraceID()
+	return fmt.Sprintf("00-%016x%016x-%016x-%s", traceID.High, traceID.Low, uint64(sc.SpanID()), flags), true
+}
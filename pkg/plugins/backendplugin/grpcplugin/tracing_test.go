@@ -0,0 +1,55 @@
+package grpcplugin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-client-go"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestWithTraceMetadata(t *testing.T) {
+	globalTracer := opentracing.GlobalTracer()
+	t.Cleanup(func() {
+		opentracing.SetGlobalTracer(globalTracer)
+	})
+
+	t.Run("Should not add metadata without span", func(t *testing.T) {
+		ctx := withTraceMetadata(context.Background())
+		_, exists := metadata.FromOutgoingContext(ctx)
+		require.False(t, exists)
+	})
+
+	t.Run("Should add span context of the tracer", func(t *testing.T) {
+		tracer := mocktracer.New()
+		opentracing.SetGlobalTracer(tracer)
+		span := tracer.StartSpan("test")
+
+		ctx := withTraceMetadata(opentracing.ContextWithSpan(context.Background(), span))
+		md, exists := metadata.FromOutgoingContext(ctx)
+		require.True(t, exists)
+		require.Equal(t, []string{fmt.Sprint(span.Context().(mocktracer.MockSpanContext).SpanID)}, md.Get("mockpfx-ids-spanid"))
+		require.Empty(t, md.Get(traceparentHeader))
+	})
+
+	t.Run("Should add W3C traceparent of Jaeger spans", func(t *testing.T) {
+		tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+		t.Cleanup(func() {
+			_ = closer.Close()
+		})
+		opentracing.SetGlobalTracer(tracer)
+		span := tracer.StartSpan("test")
+		sc := span.Context().(jaeger.SpanContext)
+
+		ctx := withTraceMetadata(opentracing.ContextWithSpan(context.Background(), span))
+		md, exists := metadata.FromOutgoingContext(ctx)
+		require.True(t, exists)
+		require.Equal(t, []string{sc.String()}, md.Get("uber-trace-id"))
+		expected := fmt.Sprintf("00-%016x%016x-%016x-01", sc.TraceID().High, sc.TraceID().Low, uint64(sc.SpanID()))
+		require.Equal(t, []string{expected}, md.Get(traceparentHeader))
+	})
+}
@@ -0,0 +1,30 @@
+package backendplugin
+
+import (
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// HealthCheckStatus is the cached result of a data source health check.
+type HealthCheckStatus struct {
+	// Result is the result of the health check, nil if it failed.
+	Result *backend.CheckHealthResult
+	// Error is the error of a failed health check.
+	Error error
+	// CheckedAt is when the health check was done.
+	CheckedAt time.Time
+}
+
+// Healthy reports whether the health check succeeded with an OK status.
+func (s HealthCheckStatus) Healthy() bool {
+	return s.Error == nil && s.Result != nil && s.Result.Status == backend.HealthStatusOk
+}
+
+// HealthCheckHistory is the recent health check history of a data source.
+type HealthCheckHistory struct {
+	// Statuses are the recent health check statuses, oldest first.
+	Statuses []HealthCheckStatus
+	// Flapping is true if the data source is switching between healthy and unhealthy frequently.
+	Flapping bool
+}
@@ -2,10 +2,13 @@ package backendplugin
 
 import (
 	"context"
+	"crypto/tls"
+	"os/exec"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	dto "github.com/prometheus/client_model/go"
 )
 
 // Manager manages backend plugins.
@@ -16,6 +19,11 @@ type Manager interface {
 	RegisterAndStart(ctx context.Context, pluginID string, factory PluginFactoryFunc) error
 	// UnregisterAndStop unregisters and stops a backend plugin
 	UnregisterAndStop(ctx context.Context, pluginID string) error
+	// RegisterCanary registers the canary version of a registered backend plugin, which handles the requests of the
+	// data source instances and organizations selected by the canary settings of the plugin.
+	RegisterCanary(ctx context.Context, pluginID string, factory PluginFactoryFunc) error
+	// UnregisterCanary stops the canary version of a backend plugin.
+	UnregisterCanary(ctx context.Context, pluginID string) error
 	// IsRegistered checks if a plugin is registered with the manager
 	IsRegistered(pluginID string) bool
 	// StartPlugin starts a non-managed backend plugin
@@ -24,12 +32,69 @@ type Manager interface {
 	CollectMetrics(ctx context.Context, pluginID string) (*backend.CollectMetricsResult, error)
 	// CheckHealth checks the health of a registered backend plugin.
 	CheckHealth(ctx context.Context, pCtx backend.PluginContext) (*backend.CheckHealthResult, error)
+	// ValidateConfig asks a registered backend plugin to validate the data source or app instance settings of the
+	// plugin context before they're saved.
+	ValidateConfig(ctx context.Context, pCtx backend.PluginContext) (*ValidateConfigResult, error)
+	// CachedHealthCheck returns the latest health check status of a data source, if it's recent enough.
+	CachedHealthCheck(pluginID string, dataSourceUID string) (HealthCheckStatus, bool)
+	// HealthCheckHistory returns the recent health check statuses of a data source and whether it's flapping.
+	HealthCheckHistory(pluginID string, dataSourceUID string) HealthCheckHistory
 	// QueryData query data from a registered backend plugin.
 	QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error)
+	// QueryDataStream query data from a registered backend plugin, sending the result of each query as soon
+	// as it's available if the plugin implements QueryDataStreamHandler.
+	QueryDataStream(ctx context.Context, req *backend.QueryDataRequest, sender QueryDataResponseSender) error
 	// CallResource calls a plugin resource.
 	CallResource(pCtx backend.PluginContext, reqCtx *models.ReqContext, path string)
+	// SubscribeStream asks a registered backend plugin whether a subscription to a stream channel is allowed.
+	SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error)
+	// PublishStream asks a registered backend plugin whether publishing to a stream channel is allowed.
+	PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error)
+	// RunStream runs a stream of a registered backend plugin, sending its packets to sender until ctx is done.
+	RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error
+	// ServeStreamEvents sends the packets of a stream of a registered backend plugin to the client as server-sent
+	// events, until the client disconnects or the plugin ends the stream.
+	ServeStreamEvents(pCtx backend.PluginContext, reqCtx *models.ReqContext, path string)
 	// Get plugin by its ID.
 	Get(pluginID string) (Plugin, bool)
+	// GetRegistered returns a registered backend plugin by its ID, including a decommissioned plugin that Get
+	// doesn't return.
+	GetRegistered(pluginID string) (Plugin, bool)
+	// PluginStates returns the process state of all registered backend plugins.
+	PluginStates() []PluginState
+	// PluginCapabilities returns the backend methods a registered backend plugin implements, so that callers can
+	// avoid calling methods that return ErrMethodNotImplemented.
+	PluginCapabilities(ctx context.Context, pluginID string) (PluginCapabilities, error)
+	// LogLevel returns the log level of a registered backend plugin.
+	LogLevel(pluginID string) (string, error)
+	// SetLogLevel changes the log level of a registered backend plugin and forwards it to the plugin process.
+	SetLogLevel(ctx context.Context, pluginID string, level string) error
+	// ProcessOutput returns the latest stdout and stderr output of the processes of a registered backend plugin.
+	ProcessOutput(pluginID string) ([]byte, error)
+	// StreamLogs streams the log entries of a registered backend plugin of at most the given level, until ctx
+	// is done.
+	StreamLogs(ctx context.Context, pluginID string, level string) (<-chan LogEntry, error)
+	// RestartPlugin stops and starts the process of a registered managed backend plugin.
+	RestartPlugin(ctx context.Context, pluginID string) error
+	// ReloadPlugin re-reads the settings of a registered managed backend plugin and replaces its instances with
+	// new ones using them.
+	ReloadPlugin(ctx context.Context, pluginID string) error
+	// DecommissionPlugin decommissions a registered backend plugin, waits for its in-flight requests to complete
+	// and stops it, keeping it registered in the decommissioned state.
+	DecommissionPlugin(ctx context.Context, pluginID string) error
+	// GatherMetrics returns the latest scraped metrics of all backend plugins, labeled with their plugin ID.
+	GatherMetrics() ([]*dto.MetricFamily, error)
+	// RuntimeStats returns the runtime stats of the registered backend plugins.
+	RuntimeStats() RuntimeStats
+	// RegisterResourceMiddleware registers a middleware wrapping all plugin resource calls.
+	// Middlewares are applied in registration order, the first registered being the outermost.
+	RegisterResourceMiddleware(middleware ResourceMiddleware)
+	// RegisterQueryDataMiddleware registers a middleware wrapping all plugin data queries.
+	// Middlewares are applied in registration order, the first registered being the outermost.
+	RegisterQueryDataMiddleware(middleware QueryDataMiddleware)
+	// RegisterStreamAuthorizer registers an authorizer of all subscriptions to plugin stream channels.
+	// A subscription is only allowed if all registered authorizers allow it.
+	RegisterStreamAuthorizer(authorizer StreamAuthorizer)
 }
 
 // Plugin is the backend plugin interface.
@@ -48,3 +113,32 @@ type Plugin interface {
 	backend.CallResourceHandler
 	backend.StreamHandler
 }
+
+// ProcessPlugin is implemented by backend plugins running as a separate process.
+type ProcessPlugin interface {
+	// Pid returns the process ID of the plugin process, false if the plugin isn't running.
+	Pid() (int, bool)
+	// Output returns the latest stdout and stderr output of the plugin processes.
+	Output() []byte
+}
+
+// ContainerRunner is implemented by backend plugins that can run in a container instead of as a process of Grafana.
+type ContainerRunner interface {
+	// RunInContainer runs the plugin in a container the next time it starts, with runtime, the command line tool of
+	// a container runtime compatible with the Docker CLI, such as docker or nerdctl for containerd.
+	RunInContainer(runtime string)
+}
+
+// ProcessWrapper is implemented by backend plugins whose process can be started through another command.
+type ProcessWrapper interface {
+	// WrapProcess starts the plugin process with the command wrap returns for the command of the plugin executable,
+	// every time the plugin starts from then on.
+	WrapProcess(wrap func(cmd *exec.Cmd) *exec.Cmd)
+}
+
+// TransportSecurer is implemented by backend plugins whose connection to Grafana can be secured with TLS.
+type TransportSecurer interface {
+	// SecureTransport requires the plugin to connect with mutual TLS, with the certificates of config, or with
+	// certificates generated every time the plugin starts if config is nil.
+	SecureTransport(config *tls.Config)
+}
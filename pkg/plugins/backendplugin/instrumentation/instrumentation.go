@@ -3,9 +3,11 @@ package instrumentation
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -31,14 +33,17 @@ func init() {
 	prometheus.MustRegister(pluginRequestCounter, pluginRequestDuration)
 }
 
-// instrumentPluginRequest instruments success rate and latency of `fn`
+// instrumentPluginRequest instruments success rate and latency of `fn`. Requests exceeding their timeout are
+// counted with the timeout status, other failed requests with the error status.
 func instrumentPluginRequest(pluginID string, endpoint string, fn func() error) error {
 	status := "ok"
 
 	start := time.Now()
 
 	err := fn()
-	if err != nil {
+	if errors.Is(err, backendplugin.ErrPluginTimeout) {
+		status = "timeout"
+	} else if err != nil {
 		status = "error"
 	}
 
@@ -0,0 +1,11 @@
+package backendplugin
+
+import "time"
+
+// LogEntry is a log record of a backend plugin.
+type LogEntry struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
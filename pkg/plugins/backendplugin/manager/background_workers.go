@@ -0,0 +1,50 @@
+package manager
+
+import (
+	"context"
+	"sync"
+)
+
+// backgroundWorkers bounds the number of concurrent plugin calls made by background tasks, like health checks,
+// metrics scraping and warm-up, which share the same workers. Every task sets its own timeout, so that a slow
+// plugin only occupies a worker instead of delaying the other plugins. The zero value is ready to use.
+type backgroundWorkers struct {
+	once sync.Once
+	sem  chan struct{}
+}
+
+// run runs tasks on at most size workers, which is fixed by the first call, and waits for them to finish. Tasks
+// which haven't been started yet when ctx is done are skipped.
+func (w *backgroundWorkers) run(ctx context.Context, size int, tasks []func(ctx context.Context)) {
+	w.once.Do(func() {
+		if size < 1 {
+			size = 1
+		}
+		w.sem = make(chan struct{}, size)
+	})
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for _, task := range tasks {
+		select {
+		case <-ctx.Done():
+			return
+		case w.sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(task func(ctx context.Context)) {
+			defer func() {
+				<-w.sem
+				wg.Done()
+			}()
+			task(ctx)
+		}(task)
+	}
+}
+
+// runBackgroundTasks runs tasks on the background workers of the manager and waits for them to finish.
+func (m *Manager) runBackgroundTasks(ctx context.Context, tasks []func(ctx context.Context)) {
+	m.backgroundWorkers.run(ctx, m.Cfg.PluginsBackgroundWorkers, tasks)
+}
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackgroundWorkers(t *testing.T) {
+	t.Run("Should run all tasks on at most size workers", func(t *testing.T) {
+		w := backgroundWorkers{}
+		var running, maxRunning, completed int32
+		tasks := make([]func(ctx context.Context), 0, 10)
+		for i := 0; i < 10; i++ {
+			tasks = append(tasks, func(ctx context.Context) {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					max := atomic.LoadInt32(&maxRunning)
+					if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				atomic.AddInt32(&completed, 1)
+			})
+		}
+
+		w.run(context.Background(), 3, tasks)
+		require.Equal(t, int32(10), atomic.LoadInt32(&completed))
+		require.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(3))
+	})
+
+	t.Run("Should not delay other tasks by a slow task", func(t *testing.T) {
+		w := backgroundWorkers{}
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go w.run(context.Background(), 2, []func(ctx context.Context){func(ctx context.Context) {
+			<-release
+		}})
+		go w.run(context.Background(), 2, []func(ctx context.Context){
+			func(ctx context.Context) { wg.Done() },
+			func(ctx context.Context) { wg.Done() },
+		})
+
+		wg.Wait()
+		close(release)
+	})
+
+	t.Run("Should skip tasks which haven't been started when context is done", func(t *testing.T) {
+		w := backgroundWorkers{}
+		ctx, cancel := context.WithCancel(context.Background())
+		var completed int32
+		w.run(ctx, 1, []func(ctx context.Context){
+			func(ctx context.Context) {
+				cancel()
+				atomic.AddInt32(&completed, 1)
+			},
+			func(ctx context.Context) {
+				atomic.AddInt32(&completed, 1)
+			},
+		})
+		require.Equal(t, int32(1), atomic.LoadInt32(&completed))
+	})
+}
@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/instrumentation"
+)
+
+// ValidateConfig asks a registered backend plugin to validate the data source or app instance settings of the plugin
+// context, by sending a POST request to its backendplugin.ValidateConfigPath resource. Plugins that don't handle the
+// resource accept all settings.
+func (m *Manager) ValidateConfig(ctx context.Context, pCtx backend.PluginContext) (*backendplugin.ValidateConfigResult, error) {
+	p, registered := m.getForContext(pCtx)
+	if !registered {
+		return nil, backendplugin.ErrPluginNotRegistered
+	}
+	defer m.pluginRequests.begin(p.PluginID())()
+
+	req := &backend.CallResourceRequest{
+		PluginContext: pCtx,
+		Path:          backendplugin.ValidateConfigPath,
+		Method:        http.MethodPost,
+		URL:           backendplugin.ValidateConfigPath,
+		Headers:       map[string][]string{"Content-Type": {"application/json"}},
+	}
+	sender := &validateConfigResponseSender{}
+	err := m.labeledPluginRequest(ctx, p.PluginID(), "validateConfig", func(ctx context.Context) error {
+		return instrumentation.InstrumentCallResourceRequest(p.PluginID(), func() error {
+			timeoutCtx, cancel := m.withPluginTimeout(ctx, p.PluginID(), "resource_timeout", m.Cfg.PluginsResourceTimeout)
+			defer cancel()
+			return translateTimeoutError(ctx, timeoutCtx, p.CallResource(timeoutCtx, req, sender))
+		})
+	})
+	resp := sender.resp
+	if errors.Is(err, backendplugin.ErrMethodNotImplemented) {
+		return &backendplugin.ValidateConfigResult{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate plugin config: %w", err)
+	}
+	if resp == nil {
+		return nil, errors.New("failed to validate plugin config: plugin sent no response")
+	}
+
+	switch resp.Status {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return &backendplugin.ValidateConfigResult{}, nil
+	case http.StatusOK, http.StatusBadRequest:
+	default:
+		return nil, fmt.Errorf("failed to validate plugin config: plugin responded with status %d", resp.Status)
+	}
+
+	result := &backendplugin.ValidateConfigResult{}
+	if err := json.Unmarshal(resp.Body, result); err != nil {
+		return nil, fmt.Errorf("failed to decode plugin config validation result: %w", err)
+	}
+	return result, nil
+}
+
+// validateConfigResponseSender collects the response of a plugin to a config validation request, with the body of
+// all its chunks.
+type validateConfigResponseSender struct {
+	resp *backend.CallResourceResponse
+}
+
+func (s *validateConfigResponseSender) Send(resp *backend.CallResourceResponse) error {
+	if s.resp == nil {
+		s.resp = resp
+		return nil
+	}
+
+	s.resp.Body = append(s.resp.Body, resp.Body...)
+	return nil
+}
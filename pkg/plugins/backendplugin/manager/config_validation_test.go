@@ -0,0 +1,83 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ValidateConfig(t *testing.T) {
+	pCtx := backend.PluginContext{
+		PluginID:                   "test",
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{URL: "http://localhost"},
+	}
+	respond := func(status int, body string) backend.CallResourceHandlerFunc {
+		return func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+			if req.Path != backendplugin.ValidateConfigPath || req.Method != http.MethodPost {
+				return sender.Send(&backend.CallResourceResponse{Status: http.StatusNotFound})
+			}
+			if req.PluginContext.DataSourceInstanceSettings.URL != "http://localhost" {
+				return sender.Send(&backend.CallResourceResponse{Status: http.StatusInternalServerError})
+			}
+			return sender.Send(&backend.CallResourceResponse{Status: status, Body: []byte(body)})
+		}
+	}
+
+	t.Run("Should return the errors of the invalid settings", func(t *testing.T) {
+		m, p := newStreamTestManager(nil)
+		p.CallResourceHandlerFunc = respond(http.StatusBadRequest,
+			`{"errors":[{"field":"jsonData.region","message":"Region is required"}]}`)
+
+		result, err := m.ValidateConfig(context.Background(), pCtx)
+		require.NoError(t, err)
+		require.False(t, result.Valid())
+		require.Equal(t, []backendplugin.ConfigFieldError{
+			{Field: "jsonData.region", Message: "Region is required"},
+		}, result.Errors)
+	})
+
+	t.Run("Should accept valid settings", func(t *testing.T) {
+		m, p := newStreamTestManager(nil)
+		p.CallResourceHandlerFunc = respond(http.StatusOK, `{"errors":[]}`)
+
+		result, err := m.ValidateConfig(context.Background(), pCtx)
+		require.NoError(t, err)
+		require.True(t, result.Valid())
+	})
+
+	t.Run("Should accept all settings of plugins not validating them", func(t *testing.T) {
+		m, p := newStreamTestManager(nil)
+		result, err := m.ValidateConfig(context.Background(), pCtx)
+		require.NoError(t, err)
+		require.True(t, result.Valid())
+
+		p.CallResourceHandlerFunc = func(ctx context.Context, req *backend.CallResourceRequest,
+			sender backend.CallResourceResponseSender) error {
+			return sender.Send(&backend.CallResourceResponse{Status: http.StatusNotFound})
+		}
+		result, err = m.ValidateConfig(context.Background(), pCtx)
+		require.NoError(t, err)
+		require.True(t, result.Valid())
+	})
+
+	t.Run("Should return an error for unexpected responses", func(t *testing.T) {
+		m, p := newStreamTestManager(nil)
+		p.CallResourceHandlerFunc = respond(http.StatusInternalServerError, "")
+		_, err := m.ValidateConfig(context.Background(), pCtx)
+		require.EqualError(t, err, "failed to validate plugin config: plugin responded with status 500")
+
+		p.CallResourceHandlerFunc = respond(http.StatusOK, "not json")
+		_, err = m.ValidateConfig(context.Background(), pCtx)
+		require.Error(t, err)
+	})
+
+	t.Run("Should return an error when the plugin isn't registered", func(t *testing.T) {
+		m, _ := newStreamTestManager(nil)
+		_, err := m.ValidateConfig(context.Background(), backend.PluginContext{PluginID: "unknown"})
+		require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+	})
+}
@@ -0,0 +1,240 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/adapters"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// healthCheckTimeout is the maximum duration of a background health check.
+const healthCheckTimeout = 30 * time.Second
+
+// healthCheckHistorySize is the number of recent health check statuses kept per data source.
+const healthCheckHistorySize = 20
+
+// A data source is flapping when its health changes at least flapTransitions times within flapWindow.
+const (
+	flapTransitions = 4
+	flapWindow      = 10 * time.Minute
+)
+
+// healthCheckCache holds the recent health check statuses of data sources, latest last, and whether each data
+// source is flapping. The zero value is ready to use.
+type healthCheckCache struct {
+	mu       sync.RWMutex
+	history  map[string][]backendplugin.HealthCheckStatus
+	flapping map[string]bool
+}
+
+func (c *healthCheckCache) get(pluginID string, dsUID string) (backendplugin.HealthCheckStatus, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	history := c.history[pluginID+"/"+dsUID]
+	if len(history) == 0 {
+		return backendplugin.HealthCheckStatus{}, false
+	}
+	return history[len(history)-1], true
+}
+
+func (c *healthCheckCache) getHistory(pluginID string, dsUID string) backendplugin.HealthCheckHistory {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key := pluginID + "/" + dsUID
+	return backendplugin.HealthCheckHistory{
+		Statuses: append([]backendplugin.HealthCheckStatus{}, c.history[key]...),
+		Flapping: c.flapping[key],
+	}
+}
+
+// set adds status to the history of a data source, reporting whether its health changed and whether it was and
+// now is flapping.
+func (c *healthCheckCache) set(pluginID string, dsUID string, status backendplugin.HealthCheckStatus) (changed bool, wasFlapping bool, flapping bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.history == nil {
+		c.history = map[string][]backendplugin.HealthCheckStatus{}
+		c.flapping = map[string]bool{}
+	}
+
+	key := pluginID + "/" + dsUID
+	history := c.history[key]
+	if len(history) > 0 {
+		changed = history[len(history)-1].Healthy() != status.Healthy()
+	}
+	history = append(history, status)
+	if len(history) > healthCheckHistorySize {
+		history = history[len(history)-healthCheckHistorySize:]
+	}
+	c.history[key] = history
+
+	wasFlapping = c.flapping[key]
+	flapping = isFlapping(history, status.CheckedAt)
+	c.flapping[key] = flapping
+
+	return changed, wasFlapping, flapping
+}
+
+// isFlapping reports whether the health changed at least flapTransitions times within flapWindow before now.
+func isFlapping(history []backendplugin.HealthCheckStatus, now time.Time) bool {
+	transitions := 0
+	for i := 1; i < len(history); i++ {
+		if history[i].CheckedAt.Before(now.Add(-flapWindow)) {
+			continue
+		}
+		if history[i].Healthy() != history[i-1].Healthy() {
+			transitions++
+		}
+	}
+	return transitions >= flapTransitions
+}
+
+// CachedHealthCheck returns the latest health check status of a data source, if it was checked within the
+// configured health check interval.
+func (m *Manager) CachedHealthCheck(pluginID string, dataSourceUID string) (backendplugin.HealthCheckStatus, bool) {
+	if m.Cfg.PluginsHealthCheckInterval <= 0 {
+		return backendplugin.HealthCheckStatus{}, false
+	}
+
+	status, exists := m.healthChecks.get(pluginID, dataSourceUID)
+	if !exists || time.Since(status.CheckedAt) > time.Duration(m.Cfg.PluginsHealthCheckInterval)*time.Second {
+		return backendplugin.HealthCheckStatus{}, false
+	}
+
+	return status, true
+}
+
+// cacheHealthCheck caches the health check status of the data source of pCtx.
+func (m *Manager) cacheHealthCheck(pCtx backend.PluginContext, result *backend.CheckHealthResult, err error) {
+	if pCtx.DataSourceInstanceSettings == nil || pCtx.DataSourceInstanceSettings.UID == "" {
+		return
+	}
+
+	status := backendplugin.HealthCheckStatus{
+		Result:    result,
+		Error:     err,
+		CheckedAt: time.Now(),
+	}
+	changed, wasFlapping, flapping := m.healthChecks.set(pCtx.PluginID, pCtx.DataSourceInstanceSettings.UID, status)
+
+	// health changes aren't reported while flapping, to not flood the logs with them
+	logParams := []interface{}{"pluginId", pCtx.PluginID, "uid", pCtx.DataSourceInstanceSettings.UID}
+	switch {
+	case flapping && !wasFlapping:
+		m.logger.Warn("Data source health is flapping, suppressing health change notifications", logParams...)
+	case flapping:
+	case wasFlapping:
+		m.logger.Info("Data source health stopped flapping", append(logParams, "healthy", status.Healthy())...)
+	case changed && status.Healthy():
+		m.logger.Info("Data source became healthy", logParams...)
+	case changed:
+		m.logger.Warn("Data source became unhealthy", logParams...)
+	}
+}
+
+// HealthCheckHistory returns the recent health check statuses of a data source and whether it's flapping.
+func (m *Manager) HealthCheckHistory(pluginID string, dataSourceUID string) backendplugin.HealthCheckHistory {
+	return m.healthChecks.getHistory(pluginID, dataSourceUID)
+}
+
+// runHealthChecks periodically checks the health of the data sources of the configured plugins until ctx is done,
+// while this Grafana instance is the leader.
+func (m *Manager) runHealthChecks(ctx context.Context) {
+	interval := time.Duration(m.Cfg.PluginsHealthCheckInterval) * time.Second
+	if interval <= 0 || len(m.Cfg.PluginsHealthCheckPlugins) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// the data sources are shared by all Grafana instances, so only the leader checks them
+		if m.isLeader() {
+			m.checkDataSourcesHealth(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkDataSourcesHealth checks the health of all data sources of the configured plugins on the background workers.
+func (m *Manager) checkDataSourcesHealth(ctx context.Context) {
+	var tasks []func(ctx context.Context)
+	for _, pluginID := range m.healthCheckPluginIDs() {
+		query := &models.GetDataSourcesByTypeQuery{Type: pluginID}
+		if err := bus.Dispatch(query); err != nil {
+			m.logger.Error("Failed to get data sources for health check", "pluginId", pluginID, "error", err)
+			continue
+		}
+
+		for _, ds := range query.Result {
+			pluginID, ds := pluginID, ds
+			tasks = append(tasks, func(ctx context.Context) {
+				if err := m.checkDataSourceHealth(ctx, pluginID, ds); err != nil {
+					m.logger.Debug("Data source health check failed", "pluginId", pluginID, "uid", ds.Uid, "error", err)
+				}
+			})
+		}
+	}
+
+	m.runBackgroundTasks(ctx, tasks)
+}
+
+func (m *Manager) checkDataSourceHealth(ctx context.Context, pluginID string, ds *models.DataSource) error {
+	settings, err := adapters.ModelToInstanceSettings(ds)
+	if err != nil {
+		return fmt.Errorf("failed to convert data source settings: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	_, err = m.CheckHealth(ctx, backend.PluginContext{
+		OrgID:                      ds.OrgId,
+		PluginID:                   pluginID,
+		DataSourceInstanceSettings: settings,
+	})
+	return err
+}
+
+// healthCheckPluginIDs returns the IDs of the registered plugins whose data sources are checked in the background.
+func (m *Manager) healthCheckPluginIDs() []string {
+	return m.registeredPluginIDs(m.Cfg.PluginsHealthCheckPlugins)
+}
+
+// registeredPluginIDs returns the IDs of the registered plugins listed in configured, or of all registered plugins
+// if configured contains *.
+func (m *Manager) registeredPluginIDs(configured []string) []string {
+	m.pluginsMu.RLock()
+	defer m.pluginsMu.RUnlock()
+
+	var pluginIDs []string
+	for _, pluginID := range configured {
+		if pluginID == "*" {
+			pluginIDs = pluginIDs[:0]
+			for id := range m.plugins {
+				pluginIDs = append(pluginIDs, id)
+			}
+			return pluginIDs
+		}
+		if _, exists := m.plugins[pluginID]; exists {
+			pluginIDs = append(pluginIDs, pluginID)
+		}
+	}
+
+	return pluginIDs
+}
@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthCheckCache(t *testing.T) {
+	healthy := func(at time.Time) backendplugin.HealthCheckStatus {
+		return backendplugin.HealthCheckStatus{
+			Result:    &backend.CheckHealthResult{Status: backend.HealthStatusOk},
+			CheckedAt: at,
+		}
+	}
+	unhealthy := func(at time.Time) backendplugin.HealthCheckStatus {
+		return backendplugin.HealthCheckStatus{Error: errors.New("down"), CheckedAt: at}
+	}
+
+	t.Run("Should keep a bounded history of health checks", func(t *testing.T) {
+		c := healthCheckCache{}
+		now := time.Now()
+		for i := 0; i < healthCheckHistorySize+5; i++ {
+			c.set("test", "ds", healthy(now.Add(time.Duration(i)*time.Second)))
+		}
+
+		history := c.getHistory("test", "ds")
+		require.Len(t, history.Statuses, healthCheckHistorySize)
+		require.Equal(t, now.Add(5*time.Second), history.Statuses[0].CheckedAt)
+
+		latest, exists := c.get("test", "ds")
+		require.True(t, exists)
+		require.Equal(t, history.Statuses[healthCheckHistorySize-1], latest)
+
+		_, exists = c.get("test", "other")
+		require.False(t, exists)
+	})
+
+	t.Run("Should report health changes", func(t *testing.T) {
+		c := healthCheckCache{}
+		now := time.Now()
+
+		changed, _, _ := c.set("test", "ds", healthy(now))
+		require.False(t, changed)
+		changed, _, _ = c.set("test", "ds", healthy(now))
+		require.False(t, changed)
+		changed, _, _ = c.set("test", "ds", unhealthy(now))
+		require.True(t, changed)
+	})
+
+	t.Run("Should detect flapping within the flap window", func(t *testing.T) {
+		c := healthCheckCache{}
+		now := time.Now()
+
+		// health changing less often than flapTransitions times within the flap window isn't flapping
+		start := now.Add(-time.Duration(flapTransitions*2) * flapWindow)
+		for i := 0; i < flapTransitions*2; i++ {
+			status := healthy(start)
+			if i%2 == 1 {
+				status = unhealthy(start)
+			}
+			_, _, flapping := c.set("test", "ds", status)
+			require.False(t, flapping)
+			start = start.Add(flapWindow / 2)
+		}
+
+		var wasFlapping, flapping bool
+		for i := 0; i < flapTransitions; i++ {
+			status := unhealthy(now)
+			if i%2 == 0 {
+				status = healthy(now)
+			}
+			_, wasFlapping, flapping = c.set("test", "ds", status)
+		}
+		require.False(t, wasFlapping)
+		require.True(t, flapping)
+		require.True(t, c.getHistory("test", "ds").Flapping)
+
+		_, wasFlapping, flapping = c.set("test", "ds", unhealthy(now.Add(flapWindow+time.Second)))
+		require.True(t, wasFlapping)
+		require.False(t, flapping)
+	})
+}
@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/plugins/secrets"
+)
+
+// instanceSettingsChanges holds when the settings of data sources and app instances last changed. Plugins replace
+// their instance of a data source or app when the update time of its settings changes, which is stored with a
+// precision of seconds only, so settings changed twice within a second would otherwise be ignored until the plugin
+// instance expires. The zero value is ready to use.
+type instanceSettingsChanges struct {
+	mu          sync.RWMutex
+	dataSources map[int64]time.Time
+	apps        map[string]time.Time
+}
+
+func (c *instanceSettingsChanges) setDataSource(id int64, changed time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.dataSources == nil {
+		c.dataSources = map[int64]time.Time{}
+	}
+	c.dataSources[id] = changed
+}
+
+func (c *instanceSettingsChanges) deleteDataSource(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.dataSources, id)
+}
+
+func (c *instanceSettingsChanges) setApp(orgID int64, pluginID string, changed time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.apps == nil {
+		c.apps = map[string]time.Time{}
+	}
+	c.apps[appKey(orgID, pluginID)] = changed
+}
+
+func (c *instanceSettingsChanges) dataSource(id int64) (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	changed, exists := c.dataSources[id]
+	return changed, exists
+}
+
+func (c *instanceSettingsChanges) app(orgID int64, pluginID string) (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	changed, exists := c.apps[appKey(orgID, pluginID)]
+	return changed, exists
+}
+
+func appKey(orgID int64, pluginID string) string {
+	return fmt.Sprintf("%d/%s", orgID, pluginID)
+}
+
+func (m *Manager) handleDataSourceUpdated(evt *events.DataSourceUpdated) error {
+	m.instanceSettingsChanges.setDataSource(evt.ID, time.Now())
+	return nil
+}
+
+func (m *Manager) handleDataSourceDeleted(evt *events.DataSourceDeleted) error {
+	m.instanceSettingsChanges.deleteDataSource(evt.ID)
+	secrets.Forget(secrets.DataSourceOwner(evt.OrgID, evt.ID))
+	return nil
+}
+
+func (m *Manager) handlePluginSettingUpdated(evt *events.PluginSettingUpdated) error {
+	m.instanceSettingsChanges.setApp(evt.OrgID, evt.PluginID, time.Now())
+	return nil
+}
+
+// latestInstanceSettings returns the plugin context with the update time of its data source or app instance settings
+// moved to when they last changed, if later, so that the plugin replaces its instance on the first request after a
+// change and new settings, such as rotated credentials, take effect right away.
+func (m *Manager) latestInstanceSettings(pCtx backend.PluginContext) backend.PluginContext {
+	if ds := pCtx.DataSourceInstanceSettings; ds != nil {
+		if changed, exists := m.instanceSettingsChanges.dataSource(ds.ID); exists && changed.After(ds.Updated) {
+			settings := *ds
+			settings.Updated = changed
+			pCtx.DataSourceInstanceSettings = &settings
+		}
+	}
+
+	if app := pCtx.AppInstanceSettings; app != nil {
+		if changed, exists := m.instanceSettingsChanges.app(pCtx.OrgID, pCtx.PluginID); exists && changed.After(app.Updated) {
+			settings := *app
+			settings.Updated = changed
+			pCtx.AppInstanceSettings = &settings
+		}
+	}
+
+	return pCtx
+}
+
+// latestQueryDataSettings returns the data query request with the latest instance settings, copying the request if
+// they changed.
+func (m *Manager) latestQueryDataSettings(req *backend.QueryDataRequest) *backend.QueryDataRequest {
+	pCtx := m.latestInstanceSettings(req.PluginContext)
+	if pCtx == req.PluginContext {
+		return req
+	}
+
+	latest := *req
+	latest.PluginContext = pCtx
+	return &latest
+}
@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_LatestInstanceSettings(t *testing.T) {
+	updated := time.Now().Truncate(time.Second)
+
+	t.Run("Should send the time data source settings last changed as their update time", func(t *testing.T) {
+		m, p := newStreamTestManager(nil)
+		var received []time.Time
+		p.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			received = append(received, req.PluginContext.DataSourceInstanceSettings.Updated)
+			return backend.NewQueryDataResponse(), nil
+		}
+		req := &backend.QueryDataRequest{PluginContext: backend.PluginContext{
+			PluginID:                   "test",
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, Updated: updated},
+		}}
+
+		_, err := m.QueryData(context.Background(), req)
+		require.NoError(t, err)
+
+		require.NoError(t, m.handleDataSourceUpdated(&events.DataSourceUpdated{ID: 1}))
+		_, err = m.QueryData(context.Background(), req)
+		require.NoError(t, err)
+		_, err = m.QueryData(context.Background(), req)
+		require.NoError(t, err)
+
+		require.Len(t, received, 3)
+		require.Equal(t, updated, received[0])
+		require.True(t, received[1].After(updated))
+		require.Equal(t, received[1], received[2])
+		require.Equal(t, updated, req.PluginContext.DataSourceInstanceSettings.Updated)
+
+		require.NoError(t, m.handleDataSourceDeleted(&events.DataSourceDeleted{ID: 1}))
+		_, err = m.QueryData(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, updated, received[3])
+	})
+
+	t.Run("Should keep a later update time of data source settings", func(t *testing.T) {
+		m, _ := newStreamTestManager(nil)
+		require.NoError(t, m.handleDataSourceUpdated(&events.DataSourceUpdated{ID: 1}))
+
+		later := time.Now().Add(time.Minute)
+		pCtx := m.latestInstanceSettings(backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, Updated: later},
+		})
+		require.Equal(t, later, pCtx.DataSourceInstanceSettings.Updated)
+	})
+
+	t.Run("Should send the time app settings last changed as their update time", func(t *testing.T) {
+		m, _ := newStreamTestManager(nil)
+		require.NoError(t, m.handlePluginSettingUpdated(&events.PluginSettingUpdated{OrgID: 1, PluginID: "test"}))
+
+		pCtx := m.latestInstanceSettings(backend.PluginContext{
+			OrgID:               1,
+			PluginID:            "test",
+			AppInstanceSettings: &backend.AppInstanceSettings{Updated: updated},
+		})
+		require.True(t, pCtx.AppInstanceSettings.Updated.After(updated))
+
+		pCtx = m.latestInstanceSettings(backend.PluginContext{
+			OrgID:               2,
+			PluginID:            "test",
+			AppInstanceSettings: &backend.AppInstanceSettings{Updated: updated},
+		})
+		require.Equal(t, updated, pCtx.AppInstanceSettings.Updated)
+	})
+}
@@ -6,19 +6,24 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"math"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin/instrumentation"
+	"github.com/grafana/grafana/pkg/plugins/secrets"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"github.com/grafana/grafana/pkg/util/proxyutil"
@@ -32,7 +37,20 @@ func ProvideService(cfg *setting.Cfg, licensing models.Licensing,
 		PluginRequestValidator: pluginRequestValidator,
 		logger:                 log.New("plugins.backend"),
 		plugins:                map[string]backendplugin.Plugin{},
+		queryCache:             queryDataCache{maxEntries: cfg.PluginsQueryCacheMaxEntries},
 	}
+	if cfg.PluginsUnixSocketDir != "" && !unixSocketsSupported {
+		s.logger.Warn("Backend plugins can't listen on unix sockets on this platform, ignoring unix_socket_dir")
+	}
+	secrets.SetPolicy(secrets.Policy{
+		Providers:         cfg.PluginSecretsProviders,
+		AllowedReferences: cfg.PluginSecretsAllowedReferences,
+	})
+	s.terminateOrphanedPluginProcesses()
+	bus.AddEventListener(s.handleDataSourceUpdated)
+	bus.AddEventListener(s.handleDataSourceDeleted)
+	bus.AddEventListener(s.handlePluginSettingUpdated)
+	bus.AddEventListener(s.handlePluginLeadershipChanged)
 	return s
 }
 
@@ -42,10 +60,51 @@ type Manager struct {
 	PluginRequestValidator models.PluginRequestValidator
 	pluginsMu              sync.RWMutex
 	plugins                map[string]backendplugin.Plugin
+	registrations          map[string]pluginRegistration
+	isolatedPlugins        map[string]backendplugin.Plugin
 	logger                 log.Logger
+	// canaries are the factories of the canary versions of plugins, whose instances are isolated instances.
+	canaries map[string]backendplugin.PluginFactoryFunc
+
+	resourceMiddlewaresMu  sync.RWMutex
+	resourceMiddlewares    []backendplugin.ResourceMiddleware
+	queryDataMiddlewaresMu sync.RWMutex
+	queryDataMiddlewares   []backendplugin.QueryDataMiddleware
+	streamAuthorizersMu    sync.RWMutex
+	streamAuthorizers      []backendplugin.StreamAuthorizer
+
+	resourceRateLimiter resourceRateLimiter
+	resourceCache       resourceResponseCache
+	queryCache          queryDataCache
+	querySemaphores     querySemaphores
+	fairQueryQueues     fairQueryQueues
+	healthChecks        healthCheckCache
+	pluginRestarts      pluginRestarts
+	pluginFailures      pluginFailures
+	pluginPidFiles      pluginPidFiles
+	pluginProcesses     pluginProcesses
+	pluginStartLocks    pluginStartLocks
+	pluginRequests      pluginRequests
+	pluginLogFiles      pluginLogFiles
+	pluginLogLevels     pluginLogLevels
+	pluginLogStreams    pluginLogStreams
+	scrapedMetrics      scrapedMetrics
+	backgroundWorkers   backgroundWorkers
+	pluginGoroutines    pluginGoroutines
+	pluginStreams       pluginStreams
+
+	instanceSettingsChanges instanceSettingsChanges
+
+	// leader is 1 while this Grafana instance is elected to run the background tasks, accessed atomically.
+	leader int32
 }
 
 func (m *Manager) Run(ctx context.Context) error {
+	go m.runHealthChecks(ctx)
+	go m.runProcessMetrics(ctx)
+	go m.runMetricsScraping(ctx)
+	go m.warmUpPlugins(ctx)
+
 	<-ctx.Done()
 	m.stop(ctx)
 	return ctx.Err()
@@ -61,10 +120,46 @@ func (m *Manager) Register(pluginID string, factory backendplugin.PluginFactoryF
 		return fmt.Errorf("backend plugin %s already registered", pluginID)
 	}
 
+	if err := m.preparePluginDirectories(pluginID); err != nil {
+		return err
+	}
+	if err := m.preparePluginSocketDirectory(pluginID); err != nil {
+		return err
+	}
+	env := m.pluginEnv(pluginID)
+	factory = m.remotePluginFactory(pluginID, factory)
+
+	pluginLogger, err := m.newPluginLogger(pluginID)
+	if err != nil {
+		return fmt.Errorf("failed to create logger of backend plugin %s: %w", pluginID, err)
+	}
+
+	plugin, err := m.newPlugin(factory, pluginID, pluginLogger, env)
+	if err != nil {
+		if closeErr := m.pluginLogFiles.close(pluginID); closeErr != nil {
+			m.logger.Warn("Failed to close plugin log file", "pluginId", pluginID, "error", closeErr)
+		}
+		return err
+	}
+
+	m.plugins[pluginID] = plugin
+	if m.registrations == nil {
+		m.registrations = map[string]pluginRegistration{}
+	}
+	m.registrations[pluginID] = pluginRegistration{factory: factory, env: env, logger: pluginLogger}
+	m.logger.Debug("Backend plugin registered", "pluginId", pluginID)
+	return nil
+}
+
+// pluginEnv returns the environment variables of a backend plugin, from the host and the plugin settings.
+func (m *Manager) pluginEnv(pluginID string) []string {
 	hostEnv := []string{
 		fmt.Sprintf("GF_VERSION=%s", m.Cfg.BuildVersion),
 		fmt.Sprintf("GF_EDITION=%s", m.License.Edition()),
 	}
+	if toggles := m.enabledFeatureToggles(); len(toggles) > 0 {
+		hostEnv = append(hostEnv, fmt.Sprintf("GF_FEATURE_TOGGLES=%s", strings.Join(toggles, ",")))
+	}
 
 	if m.License.HasLicense() {
 		hostEnv = append(
@@ -81,19 +176,26 @@ func (m *Manager) Register(pluginID string, factory backendplugin.PluginFactoryF
 
 	hostEnv = append(hostEnv, m.getAWSEnvironmentVariables()...)
 	hostEnv = append(hostEnv, m.getAzureEnvironmentVariables()...)
+	if proxy, exists := m.Cfg.PluginProxy(pluginID); exists {
+		hostEnv = append(hostEnv, proxy.Env()...)
+	}
+	hostEnv = append(hostEnv, m.pluginDirectoriesEnv(pluginID)...)
 
 	pluginSettings := getPluginSettings(pluginID, m.Cfg)
-	env := pluginSettings.ToEnv("GF_PLUGIN", hostEnv)
+	return pluginSettings.ToEnv("GF_PLUGIN", hostEnv)
+}
 
-	pluginLogger := m.logger.New("pluginId", pluginID)
-	plugin, err := factory(pluginID, pluginLogger, env)
-	if err != nil {
-		return err
+// enabledFeatureToggles returns the sorted names of the feature toggles enabled in Grafana, so that plugins can adapt
+// their behavior to the features of the instance they run in.
+func (m *Manager) enabledFeatureToggles() []string {
+	toggles := make([]string, 0, len(m.Cfg.FeatureToggles))
+	for toggle, enabled := range m.Cfg.FeatureToggles {
+		if enabled {
+			toggles = append(toggles, toggle)
+		}
 	}
-
-	m.plugins[pluginID] = plugin
-	m.logger.Debug("Backend plugin registered", "pluginId", pluginID)
-	return nil
+	sort.Strings(toggles)
+	return toggles
 }
 
 // RegisterAndStart registers and starts a backend plugin
@@ -128,12 +230,35 @@ func (m *Manager) UnregisterAndStop(ctx context.Context, pluginID string) error
 	if err := p.Decommission(); err != nil {
 		return err
 	}
+	m.pluginStreams.stop(pluginID)
 
 	if err := p.Stop(ctx); err != nil {
 		return err
 	}
+	m.pluginPidFiles.remove(p)
+
+	for key, isolated := range m.isolatedInstances(pluginID) {
+		if err := isolated.Decommission(); err != nil {
+			return err
+		}
+		if err := isolated.Stop(ctx); err != nil {
+			return err
+		}
+		m.pluginPidFiles.remove(isolated)
+		delete(m.isolatedPlugins, key)
+	}
 
 	delete(m.plugins, pluginID)
+	delete(m.registrations, pluginID)
+	delete(m.canaries, pluginID)
+
+	if err := m.pluginLogFiles.close(pluginID); err != nil {
+		m.logger.Warn("Failed to close plugin log file", "pluginId", pluginID, "error", err)
+	}
+	m.scrapedMetrics.delete(pluginID)
+	m.removePluginTempDirectory(pluginID)
+	m.removePluginSocketDirectory(pluginID)
+	m.resetPluginFailure(pluginID)
 
 	m.logger.Debug("Backend plugin unregistered", "pluginId", pluginID)
 	return nil
@@ -147,7 +272,7 @@ func (m *Manager) IsRegistered(pluginID string) bool {
 
 func (m *Manager) Get(pluginID string) (backendplugin.Plugin, bool) {
 	m.pluginsMu.RLock()
-	p, ok := m.plugins[pluginID]
+	p, ok := m.plugins[m.resolveAlias(pluginID)]
 	m.pluginsMu.RUnlock()
 
 	if ok && p.IsDecommissioned() {
@@ -157,6 +282,30 @@ func (m *Manager) Get(pluginID string) (backendplugin.Plugin, bool) {
 	return p, ok
 }
 
+// GetRegistered returns a registered backend plugin by its ID, including a decommissioned plugin that Get doesn't
+// return.
+func (m *Manager) GetRegistered(pluginID string) (backendplugin.Plugin, bool) {
+	m.pluginsMu.RLock()
+	defer m.pluginsMu.RUnlock()
+
+	p, ok := m.plugins[m.resolveAlias(pluginID)]
+	return p, ok
+}
+
+// resolveAlias returns the ID of the plugin that is registered in place of the plugin with the provided ID, as
+// configured for renamed or forked plugins. The provided ID is returned if a plugin with that ID is registered
+// or if no alias is configured. The caller must hold pluginsMu.
+func (m *Manager) resolveAlias(pluginID string) string {
+	if _, exists := m.plugins[pluginID]; exists || m.Cfg == nil {
+		return pluginID
+	}
+	if aliasedID, exists := m.Cfg.PluginIDAliases[pluginID]; exists {
+		return aliasedID
+	}
+
+	return pluginID
+}
+
 func (m *Manager) getAWSEnvironmentVariables() []string {
 	variables := []string{}
 	if m.Cfg.AWSAssumeRoleEnabled {
@@ -190,7 +339,7 @@ func (m *Manager) start(ctx context.Context, p backendplugin.Plugin) {
 		return
 	}
 
-	if err := startPluginAndRestartKilledProcesses(ctx, p); err != nil {
+	if err := m.startPluginAndRestartKilledProcesses(ctx, p); err != nil {
 		p.Logger().Error("Failed to start plugin", "error", err)
 	}
 }
@@ -198,7 +347,7 @@ func (m *Manager) start(ctx context.Context, p backendplugin.Plugin) {
 // StartPlugin starts a non-managed backend plugin
 func (m *Manager) StartPlugin(ctx context.Context, pluginID string) error {
 	m.pluginsMu.RLock()
-	p, registered := m.plugins[pluginID]
+	p, registered := m.plugins[m.resolveAlias(pluginID)]
 	m.pluginsMu.RUnlock()
 	if !registered {
 		return backendplugin.ErrPluginNotRegistered
@@ -208,15 +357,23 @@ func (m *Manager) StartPlugin(ctx context.Context, pluginID string) error {
 		return errors.New("backend plugin is managed and cannot be manually started")
 	}
 
-	return startPluginAndRestartKilledProcesses(ctx, p)
+	return m.startPluginAndRestartKilledProcesses(ctx, p)
 }
 
 // stop stops all managed backend plugins
 func (m *Manager) stop(ctx context.Context) {
 	m.pluginsMu.RLock()
 	defer m.pluginsMu.RUnlock()
-	var wg sync.WaitGroup
+	plugins := make([]backendplugin.Plugin, 0, len(m.plugins)+len(m.isolatedPlugins))
 	for _, p := range m.plugins {
+		plugins = append(plugins, p)
+	}
+	for _, p := range m.isolatedPlugins {
+		plugins = append(plugins, p)
+	}
+
+	var wg sync.WaitGroup
+	for _, p := range plugins {
 		wg.Add(1)
 		go func(p backendplugin.Plugin, ctx context.Context) {
 			defer wg.Done()
@@ -228,6 +385,9 @@ func (m *Manager) stop(ctx context.Context) {
 		}(p, ctx)
 	}
 	wg.Wait()
+
+	m.pluginPidFiles.removeAll()
+	m.pluginLogFiles.closeAll()
 }
 
 // CollectMetrics collects metrics from a registered backend plugin.
@@ -236,11 +396,16 @@ func (m *Manager) CollectMetrics(ctx context.Context, pluginID string) (*backend
 	if !registered {
 		return nil, backendplugin.ErrPluginNotRegistered
 	}
+	defer m.pluginRequests.begin(p.PluginID())()
+
+	timeoutCtx, cancel := m.withDefaultTimeout(ctx, p.PluginID())
+	defer cancel()
 
 	var resp *backend.CollectMetricsResult
 	err := instrumentation.InstrumentCollectMetrics(p.PluginID(), func() (innerErr error) {
-		resp, innerErr = p.CollectMetrics(ctx)
-		return
+		defer m.recoverPluginPanic(p, "collectMetrics", &innerErr)
+		resp, innerErr = p.CollectMetrics(timeoutCtx)
+		return translateTimeoutError(ctx, timeoutCtx, innerErr)
 	})
 	if err != nil {
 		return nil, err
@@ -249,8 +414,14 @@ func (m *Manager) CollectMetrics(ctx context.Context, pluginID string) (*backend
 	return resp, nil
 }
 
-// CheckHealth checks the health of a registered backend plugin.
+// CheckHealth checks the health of a registered backend plugin, caching the result of data source health checks.
 func (m *Manager) CheckHealth(ctx context.Context, pluginContext backend.PluginContext) (*backend.CheckHealthResult, error) {
+	resp, err := m.checkHealth(ctx, pluginContext)
+	m.cacheHealthCheck(pluginContext, resp, err)
+	return resp, err
+}
+
+func (m *Manager) checkHealth(ctx context.Context, pluginContext backend.PluginContext) (*backend.CheckHealthResult, error) {
 	var dsURL string
 	if pluginContext.DataSourceInstanceSettings != nil {
 		dsURL = pluginContext.DataSourceInstanceSettings.URL
@@ -264,23 +435,33 @@ func (m *Manager) CheckHealth(ctx context.Context, pluginContext backend.PluginC
 		}, nil
 	}
 
-	p, registered := m.Get(pluginContext.PluginID)
+	pluginContext = m.latestInstanceSettings(pluginContext)
+	p, registered := m.getForContext(pluginContext)
 	if !registered {
 		return nil, backendplugin.ErrPluginNotRegistered
 	}
+	if err := m.checkPluginFailed(p); err != nil {
+		return nil, err
+	}
+	defer m.pluginRequests.begin(p.PluginID())()
 
 	var resp *backend.CheckHealthResult
-	err = instrumentation.InstrumentCheckHealthRequest(p.PluginID(), func() (innerErr error) {
-		resp, innerErr = p.CheckHealth(ctx, &backend.CheckHealthRequest{PluginContext: pluginContext})
-		return
-	})
+	err = m.labeledPluginRequest(ctx, p.PluginID(), "checkHealth", func(ctx context.Context) error {
+		timeoutCtx, cancel := m.withDefaultTimeout(ctx, p.PluginID())
+		defer cancel()
 
+		return instrumentation.InstrumentCheckHealthRequest(p.PluginID(), func() (innerErr error) {
+			defer m.recoverPluginPanic(p, "checkHealth", &innerErr)
+			resp, innerErr = p.CheckHealth(timeoutCtx, &backend.CheckHealthRequest{PluginContext: pluginContext})
+			return translateTimeoutError(ctx, timeoutCtx, innerErr)
+		})
+	})
 	if err != nil {
 		if errors.Is(err, backendplugin.ErrMethodNotImplemented) {
 			return nil, err
 		}
 
-		if errors.Is(err, backendplugin.ErrPluginUnavailable) {
+		if errors.Is(err, backendplugin.ErrPluginUnavailable) || errors.Is(err, backendplugin.ErrPluginTimeout) {
 			return nil, err
 		}
 
@@ -290,17 +471,91 @@ func (m *Manager) CheckHealth(ctx context.Context, pluginContext backend.PluginC
 	return resp, nil
 }
 
+// QueryData query data from a registered backend plugin, passing the query through all registered middlewares.
 func (m *Manager) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
-	p, registered := m.Get(req.PluginContext.PluginID)
+	return m.queryDataHandler().QueryData(ctx, req)
+}
+
+// RegisterQueryDataMiddleware registers a middleware wrapping all plugin data queries.
+func (m *Manager) RegisterQueryDataMiddleware(middleware backendplugin.QueryDataMiddleware) {
+	m.queryDataMiddlewaresMu.Lock()
+	defer m.queryDataMiddlewaresMu.Unlock()
+
+	m.queryDataMiddlewares = append(m.queryDataMiddlewares, middleware)
+}
+
+// hasQueryDataMiddlewares reports whether any data query middleware is registered.
+func (m *Manager) hasQueryDataMiddlewares() bool {
+	m.queryDataMiddlewaresMu.RLock()
+	defer m.queryDataMiddlewaresMu.RUnlock()
+
+	return len(m.queryDataMiddlewares) > 0
+}
+
+// queryDataHandler returns the data query handler wrapped by all registered middlewares.
+func (m *Manager) queryDataHandler() backend.QueryDataHandler {
+	m.queryDataMiddlewaresMu.RLock()
+	defer m.queryDataMiddlewaresMu.RUnlock()
+
+	var handler backend.QueryDataHandler = backend.QueryDataHandlerFunc(m.queryDataInternal)
+	for i := len(m.queryDataMiddlewares) - 1; i >= 0; i-- {
+		handler = m.queryDataMiddlewares[i].WrapQueryDataHandler(handler)
+	}
+
+	return handler
+}
+
+func (m *Manager) queryDataInternal(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	req = m.latestQueryDataSettings(req)
+	p, registered := m.getForContext(req.PluginContext)
 	if !registered {
 		return nil, backendplugin.ErrPluginNotRegistered
 	}
 
+	var cacheKey, staleCacheKey string
+	cacheTTL := m.queryCacheTTL(req.PluginContext)
+	staleTTL := m.queryCacheStaleTTL(p.PluginID())
+	if cacheTTL > 0 {
+		key, err := queryCacheKey(req, cacheTTL)
+		if err != nil {
+			p.Logger().Debug("Failed to build query cache key", "error", err)
+		} else if resp, exists := m.queryCache.get(key); exists {
+			return resp, nil
+		}
+		cacheKey = key
+
+		if staleTTL > 0 && err == nil {
+			staleCacheKey, _ = queryStaleCacheKey(req)
+		}
+	}
+
+	if err := m.checkPluginFailed(p); err != nil {
+		return m.staleQueryData(p, staleCacheKey, err)
+	}
+	defer m.pluginRequests.begin(p.PluginID())()
+
+	release, err := m.acquireQuerySlots(ctx, p.PluginID(), req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	var resp *backend.QueryDataResponse
-	err := instrumentation.InstrumentQueryDataRequest(p.PluginID(), func() (innerErr error) {
-		resp, innerErr = p.QueryData(ctx, req)
-		return
+	start := time.Now()
+	err = m.labeledPluginRequest(ctx, p.PluginID(), "queryData", func(ctx context.Context) error {
+		timeoutCtx, cancel := m.withQueryTimeout(ctx, req.PluginContext, false)
+		defer cancel()
+
+		return instrumentation.InstrumentQueryDataRequest(p.PluginID(), func() (innerErr error) {
+			resp, innerErr = m.queryPluginData(timeoutCtx, p, req)
+			if errors.Is(innerErr, backendplugin.ErrPluginUnavailable) && m.waitForPluginRestart(timeoutCtx, p) {
+				p.Logger().Debug("Retrying query after plugin restart")
+				resp, innerErr = m.queryPluginData(timeoutCtx, p, req)
+			}
+			return translateTimeoutError(ctx, timeoutCtx, innerErr)
+		})
 	})
+	m.logSlowQuery(p, req, time.Since(start), err)
 
 	if err != nil {
 		if errors.Is(err, backendplugin.ErrMethodNotImplemented) {
@@ -308,24 +563,148 @@ func (m *Manager) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		}
 
 		if errors.Is(err, backendplugin.ErrPluginUnavailable) {
+			return m.staleQueryData(p, staleCacheKey, err)
+		}
+
+		if errors.Is(err, backendplugin.ErrPluginTimeout) {
+			return nil, err
+		}
+
+		if errors.Is(err, context.Canceled) && ctx.Err() != nil {
 			return nil, err
 		}
 
 		return nil, errutil.Wrap("failed to query data", err)
 	}
 
+	m.newQueryResultLimiter(p.PluginID()).limitResponse(req, resp)
+
+	if cacheKey != "" && isCacheableQueryDataResponse(resp) {
+		m.queryCache.set(cacheKey, resp, cacheTTL)
+		if staleCacheKey != "" {
+			m.queryCache.setStale(staleCacheKey, resp, time.Now(), cacheTTL+staleTTL)
+		}
+	}
+
+	return resp, nil
+}
+
+// staleQueryData returns the last cached result of a data query to plugin p, which is unavailable or crashed, or
+// err if there's none.
+func (m *Manager) staleQueryData(p backendplugin.Plugin, staleCacheKey string, err error) (*backend.QueryDataResponse, error) {
+	if staleCacheKey == "" {
+		return nil, err
+	}
+
+	resp, cachedAt, exists := m.queryCache.getStale(staleCacheKey)
+	if !exists {
+		return nil, err
+	}
+
+	p.Logger().Warn("Serving stale query results of unavailable plugin", "cachedAt", cachedAt, "error", err)
+	pluginStaleQueryResults.WithLabelValues(p.PluginID()).Inc()
 	return resp, nil
 }
 
+// QueryDataStream query data from a registered backend plugin, sending the result of each query as soon as it's
+// available if the plugin implements backendplugin.QueryDataStreamHandler, otherwise when all of them are.
+// Results are also sent all at once when data query middlewares are registered, so they apply to every query.
+func (m *Manager) QueryDataStream(ctx context.Context, req *backend.QueryDataRequest,
+	sender backendplugin.QueryDataResponseSender) error {
+	req = m.latestQueryDataSettings(req)
+	p, registered := m.getForContext(req.PluginContext)
+	if !registered {
+		return backendplugin.ErrPluginNotRegistered
+	}
+
+	streamHandler, ok := p.(backendplugin.QueryDataStreamHandler)
+	if !ok || m.hasQueryDataMiddlewares() {
+		// the data query path checks and tracks the request to the plugin itself
+		resp, err := m.queryDataHandler().QueryData(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		refIDs := make([]string, 0, len(resp.Responses))
+		for refID := range resp.Responses {
+			refIDs = append(refIDs, refID)
+		}
+		sort.Strings(refIDs)
+		for _, refID := range refIDs {
+			if err := sender.Send(refID, resp.Responses[refID]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := m.checkPluginFailed(p); err != nil {
+		return err
+	}
+	defer m.pluginRequests.begin(p.PluginID())()
+
+	release, err := m.acquireQuerySlots(ctx, p.PluginID(), req.PluginContext)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if limiter := m.newQueryResultLimiter(p.PluginID()); limiter != nil {
+		next := sender
+		sender = backendplugin.QueryDataResponseSenderFunc(func(refID string, resp backend.DataResponse) error {
+			return next.Send(refID, limiter.limit(resp))
+		})
+	}
+
+	err = m.labeledPluginRequest(ctx, p.PluginID(), "queryDataStream", func(ctx context.Context) error {
+		timeoutCtx, cancel := m.withQueryTimeout(ctx, req.PluginContext, true)
+		defer cancel()
+
+		return instrumentation.InstrumentQueryDataRequest(p.PluginID(), func() (innerErr error) {
+			defer m.recoverPluginPanic(p, "queryDataStream", &innerErr)
+			return translateTimeoutError(ctx, timeoutCtx, streamHandler.QueryDataStream(timeoutCtx, req, sender))
+		})
+	})
+	if err != nil {
+		if errors.Is(err, backendplugin.ErrMethodNotImplemented) || errors.Is(err, backendplugin.ErrPluginUnavailable) ||
+			errors.Is(err, backendplugin.ErrPluginTimeout) || errors.Is(err, context.Canceled) {
+			return err
+		}
+
+		return errutil.Wrap("failed to query data", err)
+	}
+
+	return nil
+}
+
+// resourceResponseMaxBytes returns the maximum allowed total size of a resource response of a plugin,
+// 0 means unlimited.
+func (m *Manager) resourceResponseMaxBytes(pluginID string) int {
+	return getPluginIntSetting(pluginID, "resource_response_max_bytes", m.Cfg, m.Cfg.PluginsResourceResponseMaxBytes)
+}
+
+// resourceResponseBufferSize returns how many resource response chunks of a plugin can be buffered
+// before the plugin is blocked waiting for the client to receive them.
+func (m *Manager) resourceResponseBufferSize(pluginID string) int {
+	return getPluginIntSetting(pluginID, "resource_response_buffer_size", m.Cfg, m.Cfg.PluginsResourceResponseBufferSize)
+}
+
+var errResourceResponseTooLarge = errors.New("resource response too large")
+
 type keepCookiesJSONModel struct {
 	KeepCookies []string `json:"keepCookies"`
 }
 
 func (m *Manager) callResourceInternal(w http.ResponseWriter, req *http.Request, pCtx backend.PluginContext) error {
-	p, registered := m.Get(pCtx.PluginID)
+	pCtx = m.latestInstanceSettings(pCtx)
+	p, registered := m.getForContext(pCtx)
 	if !registered {
 		return backendplugin.ErrPluginNotRegistered
 	}
+	if err := m.checkPluginFailed(p); err != nil {
+		return err
+	}
+	defer m.pluginRequests.begin(p.PluginID())()
 
 	keepCookieModel := keepCookiesJSONModel{}
 	if dis := pCtx.DataSourceInstanceSettings; dis != nil {
@@ -338,49 +717,117 @@ func (m *Manager) callResourceInternal(w http.ResponseWriter, req *http.Request,
 	proxyutil.ClearCookieHeader(req, keepCookieModel.KeepCookies)
 	proxyutil.PrepareProxyRequest(req)
 
-	body, err := ioutil.ReadAll(req.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read request body: %w", err)
-	}
-
 	crReq := &backend.CallResourceRequest{
 		PluginContext: pCtx,
 		Path:          req.URL.Path,
 		Method:        req.Method,
 		URL:           req.URL.String(),
-		Headers:       req.Header,
-		Body:          body,
+		Headers:       m.resourceRequestHeaders(req.Header, pCtx),
+	}
+
+	if websocket.IsWebSocketUpgrade(req) {
+		return m.proxyResourceWebSocket(w, req, p, crReq)
 	}
 
+	maxBytes := m.resourceRequestMaxBytes(p.PluginID())
+	if err := checkResourceRequestSize(req.ContentLength, maxBytes); err != nil {
+		return err
+	}
+	if chunkSize := m.resourceRequestChunkSize(p.PluginID()); chunkSize > 0 && req.Body != nil && req.Body != http.NoBody {
+		return m.callResourceChunked(w, req, p, crReq, chunkSize, maxBytes)
+	}
+
+	body, err := readResourceRequestBody(req.Body, maxBytes)
+	if err != nil {
+		return err
+	}
+	crReq.Body = body
+
+	return m.callResourceStream(w, req, p, crReq)
+}
+
+// callResourceStream calls a plugin resource in a span propagated to the plugin and streams the plugin response to w.
+func (m *Manager) callResourceStream(w http.ResponseWriter, req *http.Request, p backendplugin.Plugin,
+	crReq *backend.CallResourceRequest) error {
+	return m.labeledPluginRequest(req.Context(), p.PluginID(), "callResource", func(ctx context.Context) error {
+		return m.callResourceStreamInstrumented(w, req.WithContext(ctx), p, crReq)
+	})
+}
+
+// callResourceStreamInstrumented calls a plugin resource and streams the plugin response to w, recording
+// request metrics.
+func (m *Manager) callResourceStreamInstrumented(w http.ResponseWriter, req *http.Request, p backendplugin.Plugin,
+	crReq *backend.CallResourceRequest) error {
 	return instrumentation.InstrumentCallResourceRequest(p.PluginID(), func() error {
-		childCtx, cancel := context.WithCancel(req.Context())
+		timeoutCtx, cancelTimeout := m.withResourceTimeout(req, p.PluginID())
+		defer cancelTimeout()
+		childCtx, cancel := context.WithCancel(timeoutCtx)
 		defer cancel()
-		stream := newCallResourceResponseStream(childCtx)
-
-		var wg sync.WaitGroup
-		wg.Add(1)
+		stream := newCallResourceResponseStream(childCtx, m.resourceResponseBufferSize(p.PluginID()))
+
+		rw := w
+		var cw *compressResponseWriter
+		if m.Cfg.PluginsResourceCompressionEnabled {
+			cw = newCompressResponseWriter(w, req.Header.Get("Accept-Encoding"),
+				m.Cfg.PluginsResourceCompressionMinBytes, m.Cfg.PluginsResourceCompressionContentTypes)
+			rw = cw
+		}
 
-		defer func() {
-			if err := stream.Close(); err != nil {
-				m.logger.Warn("Failed to close stream", "err", err)
+		flushStreamErrCh := make(chan error, 1)
+		m.goPlugin(childCtx, p.PluginID(), goroutineResponseStream, func(context.Context) {
+			err := m.flushPluginStream(p, stream, rw)
+			if err != nil {
+				// unblock the plugin if it's still sending responses that won't be received
+				cancel()
+			}
+			if cw != nil {
+				if closeErr := cw.Close(); closeErr != nil {
+					p.Logger().Warn("Failed to close compressed resource response", "error", closeErr)
+				}
 			}
-			wg.Wait()
-		}()
+			flushStreamErrCh <- err
+		})
 
-		var flushStreamErr error
-		go func() {
-			flushStreamErr = flushStream(p, stream, w)
-			wg.Done()
-		}()
+		callErr := m.callPluginResource(timeoutCtx, p, crReq, stream)
+		if err := stream.Close(); err != nil {
+			m.logger.Warn("Failed to close stream", "err", err)
+		}
+		flushStreamErr := <-flushStreamErrCh
+		callErr = translateTimeoutError(req.Context(), timeoutCtx, callErr)
+		flushStreamErr = translateTimeoutError(req.Context(), timeoutCtx, flushStreamErr)
 
-		if err := p.CallResource(req.Context(), crReq, stream); err != nil {
-			return err
+		if callErr != nil {
+			if errors.Is(flushStreamErr, errResourceResponseTooLarge) {
+				return flushStreamErr
+			}
+			return callErr
 		}
 
 		return flushStreamErr
 	})
 }
 
+// RegisterResourceMiddleware registers a middleware wrapping all plugin resource calls.
+func (m *Manager) RegisterResourceMiddleware(middleware backendplugin.ResourceMiddleware) {
+	m.resourceMiddlewaresMu.Lock()
+	defer m.resourceMiddlewaresMu.Unlock()
+
+	m.resourceMiddlewares = append(m.resourceMiddlewares, middleware)
+}
+
+// resourceHandler returns the resource call handler wrapped by all registered middlewares.
+func (m *Manager) resourceHandler() backendplugin.ResourceHandler {
+	m.resourceMiddlewaresMu.RLock()
+	defer m.resourceMiddlewaresMu.RUnlock()
+
+	var handler backendplugin.ResourceHandler = backendplugin.ResourceHandlerFunc(m.callResourceCached)
+	for i := len(m.resourceMiddlewares) - 1; i >= 0; i-- {
+		handler = m.resourceMiddlewares[i].WrapResourceHandler(handler)
+	}
+
+	return handler
+}
+
 // CallResource calls a plugin resource.
 func (m *Manager) CallResource(pCtx backend.PluginContext, reqCtx *models.ReqContext, path string) {
 	var dsURL string
@@ -394,6 +841,12 @@ func (m *Manager) CallResource(pCtx backend.PluginContext, reqCtx *models.ReqCon
 		return
 	}
 
+	if allowed, retryAfter := m.allowResourceCall(pCtx); !allowed {
+		reqCtx.Resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		reqCtx.JsonApiErr(http.StatusTooManyRequests, "Rate limit reached", nil)
+		return
+	}
+
 	clonedReq := reqCtx.Req.Clone(reqCtx.Req.Context())
 	rawURL := path
 	if clonedReq.URL.RawQuery != "" {
@@ -405,28 +858,80 @@ func (m *Manager) CallResource(pCtx backend.PluginContext, reqCtx *models.ReqCon
 		return
 	}
 	clonedReq.URL = urlPath
-	err = m.callResourceInternal(reqCtx.Resp, clonedReq, pCtx)
+	err = m.resourceHandler().CallResource(reqCtx.Resp, clonedReq, pCtx)
 	if err != nil {
 		handleCallResourceError(err, reqCtx)
 	}
 }
 
 func handleCallResourceError(err error, reqCtx *models.ReqContext) {
-	if errors.Is(err, backendplugin.ErrPluginUnavailable) {
-		reqCtx.JsonApiErr(503, "Plugin unavailable", err)
+	if reqCtx.Resp.Written() {
+		reqCtx.Logger.Error("Resource call failed after response was started", "error", err)
 		return
 	}
 
-	if errors.Is(err, backendplugin.ErrMethodNotImplemented) {
-		reqCtx.JsonApiErr(404, "Not found", err)
+	if errors.Is(err, errResourceRequestTooLarge) {
+		reqCtx.JsonApiErrWithCode(http.StatusRequestEntityTooLarge, "Resource request too large",
+			string(backendplugin.ErrorCodeBadQuery), err)
 		return
 	}
 
-	reqCtx.JsonApiErr(500, "Failed to call resource", err)
+	if errors.Is(err, errResourceResponseTooLarge) {
+		reqCtx.JsonApiErrWithCode(http.StatusBadGateway, "Resource response too large",
+			string(backendplugin.ErrorCodeInternal), err)
+		return
+	}
+
+	code := backendplugin.ErrorCodeOf(err)
+	switch code {
+	case backendplugin.ErrorCodeUnavailable:
+		reqCtx.JsonApiErrWithCode(code.HTTPStatus(), "Plugin unavailable", string(code), err)
+	case backendplugin.ErrorCodeCrashed:
+		reqCtx.JsonApiErrWithCode(code.HTTPStatus(), "Plugin crashed", string(code), err)
+	case backendplugin.ErrorCodeTimeout:
+		reqCtx.JsonApiErrWithCode(code.HTTPStatus(), "Plugin request timed out", string(code), err)
+	case backendplugin.ErrorCodeNotFound:
+		reqCtx.JsonApiErrWithCode(code.HTTPStatus(), "Not found", string(code), err)
+	default:
+		reqCtx.JsonApiErrWithCode(code.HTTPStatus(), "Failed to call resource", string(code), err)
+	}
+}
+
+// flushPluginStream writes the resource responses received from stream to w, limited to the maximum resource
+// response size of plugin p, recovering from panics on malformed responses.
+func (m *Manager) flushPluginStream(p backendplugin.Plugin, stream callResourceClientResponseStream,
+	w http.ResponseWriter) (err error) {
+	defer m.recoverPluginPanic(p, "callResource", &err)
+
+	return flushStream(p, stream, w, m.resourceResponseMaxBytes(p.PluginID()))
 }
 
-func flushStream(plugin backendplugin.Plugin, stream callResourceClientResponseStream, w http.ResponseWriter) error {
+// flushStream writes the resource responses received from stream to w. If maxBytes is greater than 0 and
+// the total size of the response bodies exceeds it, errResourceResponseTooLarge is returned. Small chunks which are received
+// while more chunks are already waiting are coalesced in a pooled buffer, larger chunks are written as is.
+func flushStream(plugin backendplugin.Plugin, stream callResourceClientResponseStream, w http.ResponseWriter, maxBytes int) error {
 	processedStreams := 0
+	writtenBytes := 0
+
+	buf := responseChunkBufferPool.Get().(*[]byte)
+	pending := (*buf)[:0]
+	defer func() {
+		*buf = pending[:0]
+		responseChunkBufferPool.Put(buf)
+	}()
+
+	write := func(b []byte) {
+		if _, err := w.Write(b); err != nil {
+			plugin.Logger().Error("Failed to write resource response", "error", err)
+		}
+	}
+	flush := func() {
+		write(pending)
+		pending = pending[:0]
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
 
 	for {
 		resp, err := stream.Recv()
@@ -441,10 +946,17 @@ func flushStream(plugin backendplugin.Plugin, stream callResourceClientResponseS
 				return errutil.Wrap("failed to receive response from resource call", err)
 			}
 
+			flush()
 			plugin.Logger().Error("Failed to receive response from resource call", "error", err)
 			return stream.Close()
 		}
 
+		writtenBytes += len(resp.Body)
+		if maxBytes > 0 && writtenBytes > maxBytes {
+			plugin.Logger().Error("Resource response exceeds the maximum allowed size", "maxBytes", maxBytes)
+			return fmt.Errorf("%w: limit is %d bytes", errResourceResponseTooLarge, maxBytes)
+		}
+
 		// Expected that headers and status are only part of first stream
 		if processedStreams == 0 && resp.Headers != nil {
 			// Make sure a content type always is returned in response
@@ -469,33 +981,40 @@ func flushStream(plugin backendplugin.Plugin, stream callResourceClientResponseS
 			w.WriteHeader(resp.Status)
 		}
 
-		if _, err := w.Write(resp.Body); err != nil {
-			plugin.Logger().Error("Failed to write resource response", "error", err)
+		if len(pending)+len(resp.Body) > cap(pending) && len(pending) > 0 {
+			write(pending)
+			pending = pending[:0]
 		}
-
-		if flusher, ok := w.(http.Flusher); ok {
-			flusher.Flush()
+		if len(resp.Body) > cap(pending) {
+			write(resp.Body)
+		} else {
+			pending = append(pending, resp.Body...)
 		}
 		processedStreams++
+
+		if s, ok := stream.(bufferedResponseStream); !ok || s.Buffered() == 0 {
+			flush()
+		}
 	}
 }
 
-func startPluginAndRestartKilledProcesses(ctx context.Context, p backendplugin.Plugin) error {
-	if err := p.Start(ctx); err != nil {
+func (m *Manager) startPluginAndRestartKilledProcesses(ctx context.Context, p backendplugin.Plugin) error {
+	if err := m.startPlugin(ctx, p); err != nil {
 		return err
 	}
 
-	go func(ctx context.Context, p backendplugin.Plugin) {
-		if err := restartKilledProcess(ctx, p); err != nil {
+	m.goPlugin(ctx, p.PluginID(), goroutineRestartLoop, func(ctx context.Context) {
+		if err := m.restartKilledProcess(ctx, p); err != nil {
 			p.Logger().Error("Attempt to restart killed plugin process failed", "error", err)
 		}
-	}(ctx, p)
+	})
 
 	return nil
 }
 
-func restartKilledProcess(ctx context.Context, p backendplugin.Plugin) error {
+func (m *Manager) restartKilledProcess(ctx context.Context, p backendplugin.Plugin) error {
 	ticker := time.NewTicker(time.Second * 1)
+	defer ticker.Stop()
 
 	for {
 		select {
@@ -510,22 +1029,42 @@ func restartKilledProcess(ctx context.Context, p backendplugin.Plugin) error {
 				return nil
 			}
 
-			if !p.Exited() {
-				continue
-			}
-
-			p.Logger().Debug("Restarting plugin")
-			if err := p.Start(ctx); err != nil {
-				p.Logger().Error("Failed to restart plugin", "error", err)
-				continue
-			}
-			p.Logger().Debug("Plugin restarted")
+			m.restartIfExited(ctx, p)
 		}
 	}
 }
 
+// restartIfExited restarts the process of plugin p if it exited.
+func (m *Manager) restartIfExited(ctx context.Context, p backendplugin.Plugin) {
+	unlock := m.pluginStartLocks.lock(p.PluginID())
+	defer unlock()
+
+	if p.IsDecommissioned() || !p.Exited() || m.pluginFailures.isFailed(p.PluginID()) {
+		return
+	}
+
+	now := time.Now()
+	m.pluginRestarts.record(p.PluginID(), now)
+	if m.exceededRestartBudget(p, now) {
+		return
+	}
+	p.Logger().Debug("Restarting plugin")
+	if err := m.startPlugin(ctx, p); err != nil {
+		p.Logger().Error("Failed to restart plugin", "error", err)
+		return
+	}
+	p.Logger().Debug("Plugin restarted")
+	m.forwardLogLevel(ctx, p)
+}
+
 // callResourceClientResponseStream is used for receiving resource call responses.
 type callResourceClientResponseStream interface {
 	Recv() (*backend.CallResourceResponse, error)
 	Close() error
 }
+
+// bufferedResponseStream is implemented by resource call response streams which know how many received responses
+// are waiting to be read.
+type bufferedResponseStream interface {
+	Buffered() int
+}
@@ -3,19 +3,26 @@ package manager
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -62,6 +69,22 @@ func TestManager(t *testing.T) {
 					require.Error(t, err)
 				})
 
+				t.Run("Should be able to get plugin by an aliased ID", func(t *testing.T) {
+					ctx.cfg.PluginIDAliases = map[string]string{"old-plugin": testPluginID}
+					t.Cleanup(func() {
+						ctx.cfg.PluginIDAliases = nil
+					})
+
+					p, exists := ctx.manager.Get("old-plugin")
+					require.True(t, exists)
+					require.Equal(t, testPluginID, p.PluginID())
+
+					ctx.cfg.PluginIDAliases[testPluginID] = "other-plugin"
+					p, exists = ctx.manager.Get(testPluginID)
+					require.True(t, exists)
+					require.Equal(t, testPluginID, p.PluginID())
+				})
+
 				t.Run("Should provide expected host environment variables", func(t *testing.T) {
 					require.Len(t, ctx.env, 7)
 					require.EqualValues(t, []string{
@@ -201,6 +224,542 @@ func TestManager(t *testing.T) {
 						require.NoError(t, err)
 						require.Equal(t, http.StatusOK, w.Code)
 					})
+
+					t.Run("Call resource should be wrapped by registered middlewares in order", func(t *testing.T) {
+						ctx.plugin.CallResourceHandlerFunc = func(ctx context.Context,
+							req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+							return sender.Send(&backend.CallResourceResponse{
+								Status: http.StatusOK,
+								Body:   []byte(strings.Join(req.Headers["X-Trace"], ",")),
+							})
+						}
+
+						traceMiddleware := func(name string) backendplugin.ResourceMiddleware {
+							return backendplugin.ResourceMiddlewareFunc(func(next backendplugin.ResourceHandler) backendplugin.ResourceHandler {
+								return backendplugin.ResourceHandlerFunc(func(w http.ResponseWriter, req *http.Request, pCtx backend.PluginContext) error {
+									req.Header.Add("X-Trace", name)
+									return next.CallResource(w, req, pCtx)
+								})
+							})
+						}
+						ctx.manager.RegisterResourceMiddleware(traceMiddleware("first"))
+						ctx.manager.RegisterResourceMiddleware(traceMiddleware("second"))
+						t.Cleanup(func() {
+							ctx.manager.resourceMiddlewares = nil
+						})
+
+						req, err := http.NewRequest(http.MethodGet, "/test", bytes.NewReader([]byte{}))
+						require.NoError(t, err)
+						w := httptest.NewRecorder()
+						err = ctx.manager.resourceHandler().CallResource(w, req, backend.PluginContext{PluginID: testPluginID})
+						require.NoError(t, err)
+						require.Equal(t, []string{"first", "second"}, req.Header.Values("X-Trace"))
+						require.Equal(t, "first,second", w.Body.String())
+					})
+
+					t.Run("Call resource should proxy WebSocket messages", func(t *testing.T) {
+						ctx.plugin.CallResourceHandlerFunc = func(ctx context.Context,
+							req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+							if _, exists := req.Headers["Sec-Websocket-Key"]; exists {
+								return errors.New("handshake headers should not be forwarded")
+							}
+							return sender.Send(&backend.CallResourceResponse{
+								Status: http.StatusOK,
+								Body:   append([]byte("echo "), req.Body...),
+							})
+						}
+
+						server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+							err := ctx.manager.callResourceInternal(w, r, backend.PluginContext{PluginID: testPluginID})
+							assert.NoError(t, err)
+						}))
+						t.Cleanup(server.Close)
+
+						conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/test", nil)
+						require.NoError(t, err)
+						t.Cleanup(func() {
+							_ = conn.Close()
+						})
+
+						for _, msg := range []string{"hello", "world"} {
+							err = conn.WriteMessage(websocket.TextMessage, []byte(msg))
+							require.NoError(t, err)
+							messageType, body, err := conn.ReadMessage()
+							require.NoError(t, err)
+							require.Equal(t, websocket.TextMessage, messageType)
+							require.Equal(t, "echo "+msg, string(body))
+						}
+					})
+
+					t.Run("Call resource should proxy WebSocket messages bidirectionally", func(t *testing.T) {
+						ctx.cfg.PluginSettings = setting.PluginSettings{
+							testPluginID: map[string]string{"resource_websocket_mode": "bidirectional"},
+						}
+						t.Cleanup(func() {
+							ctx.cfg.PluginSettings = nil
+						})
+
+						stop := make(chan struct{})
+						sessions := make(chan string, 2)
+						ctx.plugin.CallResourceHandlerFunc = func(ctx context.Context,
+							req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+							sessions <- req.Headers[resourceWebSocketSessionHeader][0]
+							seq := req.Headers[resourceWebSocketMessageHeader][0]
+							switch string(req.Body) {
+							case "stream":
+								if err := sender.Send(&backend.CallResourceResponse{Body: []byte("tick " + seq)}); err != nil {
+									return err
+								}
+								<-stop
+								return sender.Send(&backend.CallResourceResponse{Body: []byte("done " + seq)})
+							default:
+								close(stop)
+								return sender.Send(&backend.CallResourceResponse{Body: []byte("stopped " + seq)})
+							}
+						}
+
+						server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+							err := ctx.manager.callResourceInternal(w, r, backend.PluginContext{PluginID: testPluginID})
+							assert.NoError(t, err)
+						}))
+						t.Cleanup(server.Close)
+
+						conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/test", nil)
+						require.NoError(t, err)
+						t.Cleanup(func() {
+							_ = conn.Close()
+						})
+
+						require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("stream")))
+						_, body, err := conn.ReadMessage()
+						require.NoError(t, err)
+						require.Equal(t, "tick 1", string(body))
+
+						// the first call is still streaming while the client sends the next message
+						require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("stop")))
+						var messages []string
+						for i := 0; i < 2; i++ {
+							_, body, err := conn.ReadMessage()
+							require.NoError(t, err)
+							messages = append(messages, string(body))
+						}
+						require.ElementsMatch(t, []string{"stopped 2", "done 1"}, messages)
+
+						first, second := <-sessions, <-sessions
+						require.NotEmpty(t, first)
+						require.Equal(t, first, second)
+					})
+
+					t.Run("Call resource should serve cached GET responses", func(t *testing.T) {
+						ctx.cfg.PluginSettings = setting.PluginSettings{
+							testPluginID: map[string]string{"resource_cache_ttl": "60", "resource_cache_paths": "cached"},
+						}
+						t.Cleanup(func() {
+							ctx.cfg.PluginSettings = nil
+						})
+
+						calls := 0
+						ctx.plugin.CallResourceHandlerFunc = func(ctx context.Context,
+							req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+							calls++
+							return sender.Send(&backend.CallResourceResponse{
+								Status:  http.StatusOK,
+								Headers: map[string][]string{"Content-Type": {"text/plain"}},
+								Body:    []byte(fmt.Sprintf("call %d", calls)),
+							})
+						}
+
+						callResource := func(path string, header http.Header) string {
+							req, err := http.NewRequest(http.MethodGet, path, http.NoBody)
+							require.NoError(t, err)
+							for k, v := range header {
+								req.Header[k] = v
+							}
+							w := httptest.NewRecorder()
+							err = ctx.manager.resourceHandler().CallResource(w, req, backend.PluginContext{PluginID: testPluginID})
+							require.NoError(t, err)
+							require.Equal(t, http.StatusOK, w.Code)
+							require.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+							return w.Body.String()
+						}
+
+						require.Equal(t, "call 1", callResource("/cached/a?q=1", nil))
+						require.Equal(t, "call 1", callResource("/cached/a?q=1", nil))
+						require.Equal(t, "call 2", callResource("/cached/a?q=2", nil))
+						require.Equal(t, "call 3", callResource("/cached/a?q=1", http.Header{"Cache-Control": {"no-cache"}}))
+						require.Equal(t, "call 3", callResource("/cached/a?q=1", nil))
+						require.Equal(t, "call 4", callResource("/uncached", nil))
+						require.Equal(t, "call 5", callResource("/uncached", nil))
+					})
+
+					t.Run("Call resource should send request body in chunks when configured", func(t *testing.T) {
+						ctx.cfg.PluginSettings = setting.PluginSettings{
+							testPluginID: map[string]string{"resource_request_chunk_size": "4"},
+						}
+						t.Cleanup(func() {
+							ctx.cfg.PluginSettings = nil
+						})
+
+						var chunks []string
+						ctx.plugin.CallResourceHandlerFunc = func(ctx context.Context,
+							req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+							chunks = append(chunks, fmt.Sprintf("%s:%s:%s", req.Headers["X-Grafana-Upload-Offset"][0],
+								req.Headers["X-Grafana-Upload-Final"][0], req.Body))
+							return sender.Send(&backend.CallResourceResponse{
+								Status: http.StatusOK,
+								Body:   []byte(fmt.Sprintf("chunk %d", len(chunks))),
+							})
+						}
+
+						req, err := http.NewRequest(http.MethodPost, "/upload", strings.NewReader("abcdefghij"))
+						require.NoError(t, err)
+						w := httptest.NewRecorder()
+						err = ctx.manager.callResourceInternal(w, req, backend.PluginContext{PluginID: testPluginID})
+						require.NoError(t, err)
+						require.Equal(t, []string{"0:false:abcd", "4:false:efgh", "8:true:ij"}, chunks)
+						require.Equal(t, "chunk 3", w.Body.String())
+					})
+
+					t.Run("Call resource should fail when request exceeds max size", func(t *testing.T) {
+						ctx.cfg.PluginsResourceRequestMaxBytes = 4
+						t.Cleanup(func() {
+							ctx.cfg.PluginsResourceRequestMaxBytes = 0
+						})
+
+						req, err := http.NewRequest(http.MethodPost, "/upload", strings.NewReader("abcdefghij"))
+						require.NoError(t, err)
+						w := httptest.NewRecorder()
+						err = ctx.manager.callResourceInternal(w, req, backend.PluginContext{PluginID: testPluginID})
+						require.ErrorIs(t, err, errResourceRequestTooLarge)
+					})
+
+					t.Run("Call resource should fail when response exceeds max size", func(t *testing.T) {
+						ctx.cfg.PluginsResourceResponseMaxBytes = 4
+						t.Cleanup(func() {
+							ctx.cfg.PluginsResourceResponseMaxBytes = 0
+						})
+
+						ctx.plugin.CallResourceHandlerFunc = func(ctx context.Context,
+							req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+							for i := 0; i < 3; i++ {
+								if err := sender.Send(&backend.CallResourceResponse{
+									Status: http.StatusOK,
+									Body:   []byte("abc"),
+								}); err != nil {
+									return err
+								}
+							}
+							return nil
+						}
+
+						req, err := http.NewRequest(http.MethodGet, "/test", bytes.NewReader([]byte{}))
+						require.NoError(t, err)
+						w := httptest.NewRecorder()
+						err = ctx.manager.callResourceInternal(w, req, backend.PluginContext{PluginID: testPluginID})
+						require.ErrorIs(t, err, errResourceResponseTooLarge)
+						require.Equal(t, "abc", w.Body.String())
+					})
+				})
+
+				t.Run("Query data should serve cached results", func(t *testing.T) {
+					ctx.cfg.PluginsQueryCacheTTL = 60
+					t.Cleanup(func() {
+						ctx.cfg.PluginsQueryCacheTTL = 0
+					})
+
+					calls := 0
+					ctx.plugin.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+						calls++
+						resp := backend.NewQueryDataResponse()
+						resp.Responses["A"] = backend.DataResponse{}
+						return resp, nil
+					}
+
+					req := &backend.QueryDataRequest{
+						PluginContext: backend.PluginContext{PluginID: testPluginID},
+						Queries:       []backend.DataQuery{{RefID: "A", JSON: []byte(`{}`)}},
+					}
+					for i := 0; i < 2; i++ {
+						resp, err := ctx.manager.QueryData(context.Background(), req)
+						require.NoError(t, err)
+						require.Contains(t, resp.Responses, "A")
+					}
+					require.Equal(t, 1, calls)
+				})
+
+				t.Run("Background health checks should cache data source health", func(t *testing.T) {
+					ctx.cfg.PluginsHealthCheckInterval = 60
+					ctx.cfg.PluginsHealthCheckPlugins = []string{"*"}
+					t.Cleanup(func() {
+						ctx.cfg.PluginsHealthCheckInterval = 0
+						ctx.cfg.PluginsHealthCheckPlugins = nil
+						bus.ClearBusHandlers()
+					})
+
+					bus.AddHandler("test", func(query *models.GetDataSourcesByTypeQuery) error {
+						require.Equal(t, testPluginID, query.Type)
+						query.Result = []*models.DataSource{{Id: 1, Uid: "ds-1", OrgId: 2, Type: testPluginID, JsonData: simplejson.New()}}
+						return nil
+					})
+					ctx.plugin.CheckHealthHandlerFunc = func(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+						require.Equal(t, int64(2), req.PluginContext.OrgID)
+						return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: "Down"}, nil
+					}
+
+					_, cached := ctx.manager.CachedHealthCheck(testPluginID, "ds-1")
+					require.False(t, cached)
+
+					ctx.manager.checkDataSourcesHealth(context.Background())
+
+					status, cached := ctx.manager.CachedHealthCheck(testPluginID, "ds-1")
+					require.True(t, cached)
+					require.NoError(t, status.Error)
+					require.Equal(t, backend.HealthStatusError, status.Result.Status)
+					require.Equal(t, "Down", status.Result.Message)
+					require.WithinDuration(t, time.Now(), status.CheckedAt, time.Minute)
+
+					ctx.cfg.PluginsHealthCheckInterval = 0
+					_, cached = ctx.manager.CachedHealthCheck(testPluginID, "ds-1")
+					require.False(t, cached)
+				})
+
+				t.Run("Warm-up should health check data sources of configured plugins", func(t *testing.T) {
+					ctx.cfg.PluginsWarmUpPlugins = []string{testPluginID}
+					t.Cleanup(func() {
+						ctx.cfg.PluginsWarmUpPlugins = nil
+						ctx.plugin.CheckHealthHandlerFunc = nil
+						bus.ClearBusHandlers()
+					})
+
+					var dataSources []*models.DataSource
+					bus.AddHandler("test", func(query *models.GetDataSourcesByTypeQuery) error {
+						query.Result = dataSources
+						return nil
+					})
+					var checkedMu sync.Mutex
+					var checked []string
+					ctx.plugin.CheckHealthHandlerFunc = func(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+						uid := ""
+						if req.PluginContext.DataSourceInstanceSettings != nil {
+							uid = req.PluginContext.DataSourceInstanceSettings.UID
+						}
+						checkedMu.Lock()
+						checked = append(checked, uid)
+						checkedMu.Unlock()
+						return &backend.CheckHealthResult{Status: backend.HealthStatusOk}, nil
+					}
+
+					ctx.manager.warmUpPlugins(context.Background())
+					require.Equal(t, []string{""}, checked)
+
+					checked = nil
+					dataSources = []*models.DataSource{
+						{Id: 1, Uid: "ds-1", OrgId: 1, Type: testPluginID, JsonData: simplejson.New()},
+						{Id: 2, Uid: "ds-2", OrgId: 1, Type: testPluginID, JsonData: simplejson.New()},
+					}
+					ctx.manager.warmUpPlugins(context.Background())
+					sort.Strings(checked)
+					require.Equal(t, []string{"ds-1", "ds-2"}, checked)
+
+					checked = nil
+					ctx.cfg.PluginsWarmUpPlugins = []string{"other"}
+					ctx.manager.warmUpPlugins(context.Background())
+					require.Empty(t, checked)
+				})
+
+				t.Run("Query data should be retried once plugin is restarted", func(t *testing.T) {
+					ctx.cfg.PluginsQueryRetryTimeout = 5
+					t.Cleanup(func() {
+						ctx.cfg.PluginsQueryRetryTimeout = 0
+					})
+
+					calls := 0
+					ctx.plugin.QueryDataHandlerFunc = func(_ context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+						calls++
+						if calls == 1 {
+							ctx.plugin.kill()
+							go func() {
+								time.Sleep(50 * time.Millisecond)
+								_ = ctx.plugin.Start(context.Background())
+							}()
+							return nil, backendplugin.ErrPluginUnavailable
+						}
+						return backend.NewQueryDataResponse(), nil
+					}
+
+					_, err := ctx.manager.QueryData(context.Background(), &backend.QueryDataRequest{
+						PluginContext: backend.PluginContext{PluginID: testPluginID},
+					})
+					require.NoError(t, err)
+					require.Equal(t, 2, calls)
+				})
+
+				t.Run("Query data should be wrapped by registered middlewares in order", func(t *testing.T) {
+					ctx.plugin.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+						resp := backend.NewQueryDataResponse()
+						for _, q := range req.Queries {
+							resp.Responses[q.RefID] = backend.DataResponse{}
+						}
+						return resp, nil
+					}
+					t.Cleanup(func() {
+						ctx.manager.queryDataMiddlewares = nil
+					})
+
+					var calls []string
+					addRefID := func(name string) backendplugin.QueryDataMiddleware {
+						return backendplugin.QueryDataMiddlewareFunc(func(next backend.QueryDataHandler) backend.QueryDataHandler {
+							return backend.QueryDataHandlerFunc(func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+								calls = append(calls, name)
+								req.Queries = append(req.Queries, backend.DataQuery{RefID: name})
+								return next.QueryData(ctx, req)
+							})
+						})
+					}
+					ctx.manager.RegisterQueryDataMiddleware(addRefID("first"))
+					ctx.manager.RegisterQueryDataMiddleware(addRefID("second"))
+
+					resp, err := ctx.manager.QueryData(context.Background(), &backend.QueryDataRequest{
+						PluginContext: backend.PluginContext{PluginID: testPluginID},
+					})
+					require.NoError(t, err)
+					require.Equal(t, []string{"first", "second"}, calls)
+					require.Contains(t, resp.Responses, "first")
+					require.Contains(t, resp.Responses, "second")
+
+					ctx.manager.RegisterQueryDataMiddleware(backendplugin.QueryDataMiddlewareFunc(
+						func(next backend.QueryDataHandler) backend.QueryDataHandler {
+							return backend.QueryDataHandlerFunc(func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+								return nil, errors.New("denied")
+							})
+						}))
+					_, err = ctx.manager.QueryData(context.Background(), &backend.QueryDataRequest{
+						PluginContext: backend.PluginContext{PluginID: testPluginID},
+					})
+					require.EqualError(t, err, "denied")
+				})
+
+				t.Run("Query data stream should send all results of plugins not streaming results", func(t *testing.T) {
+					m := ctx.manager
+					var inFlight int
+					ctx.plugin.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+						inFlight = m.pluginRequests.count(testPluginID)
+						resp := backend.NewQueryDataResponse()
+						resp.Responses["B"] = backend.DataResponse{}
+						resp.Responses["A"] = backend.DataResponse{}
+						return resp, nil
+					}
+
+					var refIDs []string
+					err := ctx.manager.QueryDataStream(context.Background(), &backend.QueryDataRequest{
+						PluginContext: backend.PluginContext{PluginID: testPluginID},
+					}, backendplugin.QueryDataResponseSenderFunc(func(refID string, resp backend.DataResponse) error {
+						refIDs = append(refIDs, refID)
+						return nil
+					}))
+					require.NoError(t, err)
+					require.Equal(t, []string{"A", "B"}, refIDs)
+					require.Equal(t, 1, inFlight)
+				})
+
+				t.Run("Timeouts", func(t *testing.T) {
+					ctx.cfg.PluginSettings = setting.PluginSettings{
+						testPluginID: map[string]string{"query_timeout": "1", "resource_timeout": "1", "default_timeout": "1"},
+					}
+					t.Cleanup(func() {
+						ctx.cfg.PluginSettings = nil
+					})
+
+					t.Run("Query data should return timeout error when plugin doesn't respond in time", func(t *testing.T) {
+						ctx.plugin.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+							<-ctx.Done()
+							return nil, ctx.Err()
+						}
+
+						_, err := ctx.manager.QueryData(context.Background(), &backend.QueryDataRequest{
+							PluginContext: backend.PluginContext{PluginID: testPluginID},
+						})
+						require.ErrorIs(t, err, backendplugin.ErrPluginTimeout)
+					})
+
+					t.Run("Query data should return timeout error of data source", func(t *testing.T) {
+						ctx.cfg.PluginSettings[testPluginID]["query_timeout"] = "60"
+						t.Cleanup(func() {
+							ctx.cfg.PluginSettings[testPluginID]["query_timeout"] = "1"
+						})
+						ctx.plugin.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+							<-ctx.Done()
+							return nil, ctx.Err()
+						}
+
+						start := time.Now()
+						_, err := ctx.manager.QueryData(context.Background(), &backend.QueryDataRequest{
+							PluginContext: backend.PluginContext{
+								PluginID: testPluginID,
+								DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+									JSONData: []byte(`{"queryTimeout": "1s"}`),
+								},
+							},
+						})
+						require.ErrorIs(t, err, backendplugin.ErrPluginTimeout)
+						require.Less(t, time.Since(start), 30*time.Second)
+					})
+
+					t.Run("Query data should return when the request is canceled", func(t *testing.T) {
+						ctx.plugin.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+							<-ctx.Done()
+							return nil, ctx.Err()
+						}
+
+						reqCtx, cancel := context.WithCancel(context.Background())
+						cancel()
+						_, err := ctx.manager.QueryData(reqCtx, &backend.QueryDataRequest{
+							PluginContext: backend.PluginContext{PluginID: testPluginID},
+						})
+						require.ErrorIs(t, err, context.Canceled)
+						require.NotErrorIs(t, err, backendplugin.ErrPluginTimeout)
+					})
+
+					t.Run("Call resource should return timeout error when plugin doesn't respond in time", func(t *testing.T) {
+						ctx.plugin.CallResourceHandlerFunc = func(ctx context.Context,
+							req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+							<-ctx.Done()
+							return ctx.Err()
+						}
+
+						req, err := http.NewRequest(http.MethodGet, "/test", bytes.NewReader([]byte{}))
+						require.NoError(t, err)
+						w := httptest.NewRecorder()
+						err = ctx.manager.callResourceInternal(w, req, backend.PluginContext{PluginID: testPluginID})
+						require.ErrorIs(t, err, backendplugin.ErrPluginTimeout)
+					})
+
+					t.Run("Check health should return timeout error when plugin doesn't respond in time", func(t *testing.T) {
+						ctx.plugin.CheckHealthHandlerFunc = func(ctx context.Context,
+							req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+							<-ctx.Done()
+							return nil, ctx.Err()
+						}
+						t.Cleanup(func() {
+							ctx.plugin.CheckHealthHandlerFunc = nil
+						})
+
+						_, err := ctx.manager.CheckHealth(context.Background(), backend.PluginContext{PluginID: testPluginID})
+						require.ErrorIs(t, err, backendplugin.ErrPluginTimeout)
+					})
+
+					t.Run("Collect metrics should return timeout error when plugin doesn't respond in time", func(t *testing.T) {
+						ctx.plugin.CollectMetricsHandlerFunc = func(ctx context.Context) (*backend.CollectMetricsResult, error) {
+							<-ctx.Done()
+							return nil, ctx.Err()
+						}
+						t.Cleanup(func() {
+							ctx.plugin.CollectMetricsHandlerFunc = nil
+						})
+
+						_, err := ctx.manager.CollectMetrics(context.Background(), testPluginID)
+						require.ErrorIs(t, err, backendplugin.ErrPluginTimeout)
+					})
 				})
 
 				t.Run("Should be able to decommission a running plugin", func(t *testing.T) {
@@ -279,6 +838,63 @@ func TestManager(t *testing.T) {
 	})
 
 	newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+		t.Run("Isolated plugin scenario", func(t *testing.T) {
+			newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+				ctx.cfg.PluginSettings = setting.PluginSettings{
+					testPluginID: map[string]string{"isolation": "group", "isolation_groups": "2:a,3:a,4:b"},
+				}
+				var instances []*testPlugin
+				err := ctx.manager.Register(testPluginID, func(pluginID string, logger log.Logger, env []string) (backendplugin.Plugin, error) {
+					p := &testPlugin{pluginID: pluginID, logger: logger, managed: true}
+					p.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+						return backend.NewQueryDataResponse(), nil
+					}
+					instances = append(instances, p)
+					return p, nil
+				})
+				require.NoError(t, err)
+				require.Len(t, instances, 1)
+
+				for _, orgID := range []int64{1, 2, 3, 4, 4} {
+					_, err := ctx.manager.QueryData(context.Background(), &backend.QueryDataRequest{
+						PluginContext: backend.PluginContext{PluginID: testPluginID, OrgID: orgID},
+					})
+					require.NoError(t, err)
+				}
+				require.Len(t, instances, 3)
+				require.Equal(t, 0, instances[0].startCount)
+				require.Equal(t, 1, instances[1].startCount)
+				require.Equal(t, 1, instances[2].startCount)
+
+				err = ctx.manager.UnregisterAndStop(context.Background(), testPluginID)
+				require.NoError(t, err)
+				for _, p := range instances {
+					require.True(t, p.IsDecommissioned())
+				}
+				require.Empty(t, ctx.manager.isolatedPlugins)
+			})
+		})
+
+		t.Run("Streaming query plugin scenario", func(t *testing.T) {
+			newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+				err := ctx.manager.Register(testPluginID, func(pluginID string, logger log.Logger, env []string) (backendplugin.Plugin, error) {
+					return &testStreamingPlugin{testPlugin: &testPlugin{pluginID: pluginID, logger: logger}}, nil
+				})
+				require.NoError(t, err)
+
+				var refIDs []string
+				err = ctx.manager.QueryDataStream(context.Background(), &backend.QueryDataRequest{
+					PluginContext: backend.PluginContext{PluginID: testPluginID},
+					Queries:       []backend.DataQuery{{RefID: "A"}, {RefID: "B"}},
+				}, backendplugin.QueryDataResponseSenderFunc(func(refID string, resp backend.DataResponse) error {
+					refIDs = append(refIDs, refID)
+					return nil
+				}))
+				require.NoError(t, err)
+				require.Equal(t, []string{"A", "B"}, refIDs)
+			})
+		})
+
 		t.Run("Plugin registration scenario when Grafana is licensed", func(t *testing.T) {
 			ctx.license.edition = "Enterprise"
 			ctx.license.hasLicense = true
@@ -366,7 +982,8 @@ type testPlugin struct {
 	backend.CheckHealthHandlerFunc
 	backend.QueryDataHandlerFunc
 	backend.CallResourceHandlerFunc
-	mutex sync.RWMutex
+	streamHandler backend.StreamHandler
+	mutex         sync.RWMutex
 }
 
 func (tp *testPlugin) PluginID() string {
@@ -456,17 +1073,43 @@ func (tp *testPlugin) CallResource(ctx context.Context, req *backend.CallResourc
 }
 
 func (tp *testPlugin) SubscribeStream(ctx context.Context, request *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	if tp.streamHandler != nil {
+		return tp.streamHandler.SubscribeStream(ctx, request)
+	}
+
 	return nil, backendplugin.ErrMethodNotImplemented
 }
 
 func (tp *testPlugin) PublishStream(ctx context.Context, request *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	if tp.streamHandler != nil {
+		return tp.streamHandler.PublishStream(ctx, request)
+	}
+
 	return nil, backendplugin.ErrMethodNotImplemented
 }
 
 func (tp *testPlugin) RunStream(ctx context.Context, request *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	if tp.streamHandler != nil {
+		return tp.streamHandler.RunStream(ctx, request, sender)
+	}
+
 	return backendplugin.ErrMethodNotImplemented
 }
 
+type testStreamingPlugin struct {
+	*testPlugin
+}
+
+func (tp *testStreamingPlugin) QueryDataStream(ctx context.Context, req *backend.QueryDataRequest,
+	sender backendplugin.QueryDataResponseSender) error {
+	for _, q := range req.Queries {
+		if err := sender.Send(q.RefID, backend.DataResponse{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type testLicensingService struct {
 	edition    string
 	hasLicense bool
@@ -0,0 +1,169 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// metricsScrapeTimeout is the maximum duration of collecting the metrics of a plugin.
+const metricsScrapeTimeout = 10 * time.Second
+
+// pluginIDLabel is the label added to the scraped metrics of a plugin.
+const pluginIDLabel = "plugin_id"
+
+// scrapedMetrics holds the latest scraped metrics of backend plugins. The zero value is ready to use.
+type scrapedMetrics struct {
+	mu       sync.RWMutex
+	families map[string][]*dto.MetricFamily
+}
+
+func (s *scrapedMetrics) set(pluginID string, families []*dto.MetricFamily) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.families == nil {
+		s.families = map[string][]*dto.MetricFamily{}
+	}
+	s.families[pluginID] = families
+}
+
+func (s *scrapedMetrics) delete(pluginID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.families, pluginID)
+}
+
+// gather merges the scraped metric families of all plugins by name.
+func (s *scrapedMetrics) gather() []*dto.MetricFamily {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	merged := map[string]*dto.MetricFamily{}
+	for _, families := range s.families {
+		for _, mf := range families {
+			existing, exists := merged[mf.GetName()]
+			if !exists {
+				existing = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+				merged[mf.GetName()] = existing
+			} else if existing.GetType() != mf.GetType() {
+				// Prometheus doesn't allow metrics of different types with the same name.
+				continue
+			}
+			existing.Metric = append(existing.Metric, mf.Metric...)
+		}
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(merged))
+	for _, mf := range merged {
+		result = append(result, mf)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+
+	return result
+}
+
+// GatherMetrics returns the latest scraped metrics of all backend plugins, labeled with their plugin ID.
+func (m *Manager) GatherMetrics() ([]*dto.MetricFamily, error) {
+	return m.scrapedMetrics.gather(), nil
+}
+
+// runMetricsScraping periodically collects the metrics of all backend plugins until ctx is done.
+func (m *Manager) runMetricsScraping(ctx context.Context) {
+	interval := time.Duration(m.Cfg.PluginsMetricsScrapeInterval) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.scrapeMetrics(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrapeMetrics collects the metrics of all registered backend plugins on the background workers.
+func (m *Manager) scrapeMetrics(ctx context.Context) {
+	m.pluginsMu.RLock()
+	pluginIDs := make([]string, 0, len(m.plugins))
+	for pluginID := range m.plugins {
+		pluginIDs = append(pluginIDs, pluginID)
+	}
+	m.pluginsMu.RUnlock()
+
+	tasks := make([]func(ctx context.Context), 0, len(pluginIDs))
+	for _, pluginID := range pluginIDs {
+		pluginID := pluginID
+		tasks = append(tasks, func(ctx context.Context) {
+			families, err := m.scrapePluginMetrics(ctx, pluginID)
+			if err != nil {
+				m.scrapedMetrics.delete(pluginID)
+				if !errors.Is(err, backendplugin.ErrMethodNotImplemented) {
+					m.logger.Debug("Failed to scrape plugin metrics", "pluginId", pluginID, "error", err)
+				}
+				return
+			}
+			m.scrapedMetrics.set(pluginID, families)
+		})
+	}
+
+	m.runBackgroundTasks(ctx, tasks)
+}
+
+// scrapePluginMetrics collects the metrics of a plugin and adds the plugin ID label to them.
+func (m *Manager) scrapePluginMetrics(ctx context.Context, pluginID string) ([]*dto.MetricFamily, error) {
+	ctx, cancel := context.WithTimeout(ctx, metricsScrapeTimeout)
+	defer cancel()
+
+	resp, err := m.CollectMetrics(ctx, pluginID)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || len(resp.PrometheusMetrics) == 0 {
+		return nil, nil
+	}
+
+	var parser expfmt.TextParser
+	parsed, err := parser.TextToMetricFamilies(bytes.NewReader(resp.PrometheusMetrics))
+	if err != nil {
+		return nil, err
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(parsed))
+	for _, mf := range parsed {
+		for _, metric := range mf.Metric {
+			labels := make([]*dto.LabelPair, 0, len(metric.Label)+1)
+			for _, label := range metric.Label {
+				if label.GetName() != pluginIDLabel {
+					labels = append(labels, label)
+				}
+			}
+			labels = append(labels, &dto.LabelPair{Name: proto.String(pluginIDLabel), Value: proto.String(pluginID)})
+			sort.Slice(labels, func(i, j int) bool {
+				return labels[i].GetName() < labels[j].GetName()
+			})
+			metric.Label = labels
+		}
+		families = append(families, mf)
+	}
+
+	return families, nil
+}
@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ScrapeMetrics(t *testing.T) {
+	collect := func(metrics string) backend.CollectMetricsHandlerFunc {
+		return func(ctx context.Context) (*backend.CollectMetricsResult, error) {
+			return &backend.CollectMetricsResult{PrometheusMetrics: []byte(metrics)}, nil
+		}
+	}
+
+	pluginA := &testPlugin{pluginID: "plugin-a", CollectMetricsHandlerFunc: collect(
+		"# HELP requests_total Total requests\n# TYPE requests_total counter\nrequests_total{method=\"GET\"} 3\n")}
+	pluginB := &testPlugin{pluginID: "plugin-b", CollectMetricsHandlerFunc: collect(
+		"# HELP requests_total Total requests\n# TYPE requests_total counter\nrequests_total{plugin_id=\"spoofed\"} 5\n")}
+	m := &Manager{
+		Cfg:    setting.NewCfg(),
+		logger: log.New("test"),
+		plugins: map[string]backendplugin.Plugin{
+			"plugin-a": pluginA,
+			"plugin-b": pluginB,
+			"core":     &testPlugin{pluginID: "core"},
+		},
+	}
+
+	m.scrapeMetrics(context.Background())
+
+	families, err := m.GatherMetrics()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.Equal(t, "requests_total", families[0].GetName())
+	require.Len(t, families[0].Metric, 2)
+
+	values := map[string]float64{}
+	for _, metric := range families[0].Metric {
+		for _, label := range metric.Label {
+			if label.GetName() == pluginIDLabel {
+				values[label.GetValue()] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	require.Equal(t, map[string]float64{"plugin-a": 3, "plugin-b": 5}, values)
+
+	t.Run("Should drop metrics of plugins failing to collect metrics", func(t *testing.T) {
+		pluginB.CollectMetricsHandlerFunc = func(ctx context.Context) (*backend.CollectMetricsResult, error) {
+			return nil, errors.New("unavailable")
+		}
+
+		m.scrapeMetrics(context.Background())
+
+		families, err := m.GatherMetrics()
+		require.NoError(t, err)
+		require.Len(t, families, 1)
+		require.Len(t, families[0].Metric, 1)
+	})
+}
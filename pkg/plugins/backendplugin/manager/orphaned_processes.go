@@ -0,0 +1,160 @@
+package manager
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+var errProcessStartTimeUnsupported = errors.New("process start times are only supported on Linux")
+
+// pluginPidFiles tracks the pid files of the running backend plugin processes, which are kept in the plugin data
+// directories so that processes left over by an unclean shutdown of Grafana are terminated on its next start.
+// A pid file is named after the process ID and contains the start time of the process. The zero value is ready to
+// use.
+type pluginPidFiles struct {
+	mu    sync.Mutex
+	paths map[backendplugin.Plugin]string
+}
+
+// set records the pid file of the current process of plugin instance p, and returns the pid file of its previous
+// process.
+func (f *pluginPidFiles) set(p backendplugin.Plugin, path string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.paths == nil {
+		f.paths = map[backendplugin.Plugin]string{}
+	}
+
+	previous := f.paths[p]
+	f.paths[p] = path
+	return previous
+}
+
+// remove removes the pid file of plugin instance p.
+func (f *pluginPidFiles) remove(p backendplugin.Plugin) {
+	f.mu.Lock()
+	path, exists := f.paths[p]
+	delete(f.paths, p)
+	f.mu.Unlock()
+
+	if exists {
+		_ = os.Remove(path)
+	}
+}
+
+// removeAll removes the pid files of all plugin instances.
+func (f *pluginPidFiles) removeAll() {
+	f.mu.Lock()
+	paths := f.paths
+	f.paths = nil
+	f.mu.Unlock()
+
+	for _, path := range paths {
+		_ = os.Remove(path)
+	}
+}
+
+// recordPluginProcess writes the pid file of the process of plugin instance p, which was just started.
+func (m *Manager) recordPluginProcess(p backendplugin.Plugin) {
+	processPlugin, ok := p.(backendplugin.ProcessPlugin)
+	if !ok {
+		return
+	}
+
+	pid, running := processPlugin.Pid()
+	dir := m.Cfg.PluginPidPath(p.PluginID())
+	if !running || dir == "" {
+		return
+	}
+
+	startTime, err := processStartTime(pid)
+	if err != nil {
+		if !errors.Is(err, errProcessStartTimeUnsupported) {
+			p.Logger().Debug("Failed to read plugin process start time", "pid", pid, "error", err)
+		}
+		return
+	}
+
+	path := filepath.Join(dir, strconv.Itoa(pid))
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		p.Logger().Warn("Failed to create plugin pid directory", "path", dir, "error", err)
+		return
+	}
+	if err := ioutil.WriteFile(path, []byte(strconv.FormatUint(startTime, 10)), 0600); err != nil {
+		p.Logger().Warn("Failed to write plugin pid file", "path", path, "error", err)
+		return
+	}
+
+	if previous := m.pluginPidFiles.set(p, path); previous != "" && previous != path {
+		_ = os.Remove(previous)
+	}
+}
+
+// terminateOrphanedPluginProcesses kills the backend plugin processes left over by an unclean shutdown, which
+// would otherwise keep running next to the processes started for the same plugins, and removes their pid files.
+// Pid files whose process ID was reused by another process are removed without killing the process.
+func (m *Manager) terminateOrphanedPluginProcesses() {
+	if m.Cfg.PluginsDataDirectory == "" {
+		return
+	}
+
+	paths, err := filepath.Glob(filepath.Join(m.Cfg.PluginsDataDirectory, "*", "pids", "*"))
+	if err != nil {
+		m.logger.Warn("Failed to find plugin pid files", "error", err)
+		return
+	}
+
+	for _, path := range paths {
+		pluginID := filepath.Base(filepath.Dir(filepath.Dir(path)))
+		if pid, startTime, err := readPidFile(path); err != nil {
+			m.logger.Warn("Failed to read plugin pid file", "path", path, "error", err)
+		} else if current, err := processStartTime(pid); err == nil && current == startTime {
+			m.logger.Warn("Terminating orphaned plugin process", "pluginId", pluginID, "pid", pid)
+			if err := killProcess(pid); err != nil {
+				m.logger.Error("Failed to terminate orphaned plugin process", "pluginId", pluginID, "pid", pid,
+					"error", err)
+			}
+		}
+
+		if err := os.Remove(path); err != nil {
+			m.logger.Warn("Failed to remove plugin pid file", "path", path, "error", err)
+		}
+	}
+}
+
+func readPidFile(path string) (int, uint64, error) {
+	pid, err := strconv.Atoi(filepath.Base(path))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// We can ignore the gosec G304 warning since the path is in the plugin data directory
+	// nolint:gosec
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	startTime, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return pid, startTime, nil
+}
+
+func killProcess(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	return process.Kill()
+}
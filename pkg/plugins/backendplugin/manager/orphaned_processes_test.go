@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+type testPidPlugin struct {
+	*testPlugin
+	testProcessPlugin
+}
+
+func TestManager_OrphanedPluginProcesses(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process start times are only supported on Linux")
+	}
+
+	startProcess := func(t *testing.T) (*exec.Cmd, <-chan error) {
+		cmd := exec.Command("sleep", "60")
+		require.NoError(t, cmd.Start())
+		exited := make(chan error, 1)
+		go func() {
+			exited <- cmd.Wait()
+		}()
+		t.Cleanup(func() {
+			_ = cmd.Process.Kill()
+		})
+		return cmd, exited
+	}
+
+	newManager := func(t *testing.T) *Manager {
+		return &Manager{Cfg: &setting.Cfg{PluginsDataDirectory: t.TempDir()}, logger: log.New("test")}
+	}
+
+	t.Run("Should kill processes recorded in pid files", func(t *testing.T) {
+		m := newManager(t)
+		cmd, exited := startProcess(t)
+		m.recordPluginProcess(&testPidPlugin{
+			testPlugin:        &testPlugin{pluginID: "test", logger: log.New("test")},
+			testProcessPlugin: testProcessPlugin{pid: cmd.Process.Pid, running: true},
+		})
+		pidFile := filepath.Join(m.Cfg.PluginPidPath("test"), strconv.Itoa(cmd.Process.Pid))
+		require.FileExists(t, pidFile)
+
+		m.terminateOrphanedPluginProcesses()
+
+		select {
+		case <-exited:
+		case <-time.After(5 * time.Second):
+			t.Fatal("orphaned process wasn't killed")
+		}
+		require.NoFileExists(t, pidFile)
+	})
+
+	t.Run("Should not kill processes which reused the process ID", func(t *testing.T) {
+		m := newManager(t)
+		cmd, exited := startProcess(t)
+		pidFile := filepath.Join(m.Cfg.PluginPidPath("test"), strconv.Itoa(cmd.Process.Pid))
+		require.NoError(t, os.MkdirAll(filepath.Dir(pidFile), 0750))
+		require.NoError(t, ioutil.WriteFile(pidFile, []byte("1"), 0600))
+
+		m.terminateOrphanedPluginProcesses()
+
+		select {
+		case <-exited:
+			t.Fatal("process was killed")
+		case <-time.After(100 * time.Millisecond):
+		}
+		require.NoFileExists(t, pidFile)
+	})
+
+	t.Run("Should remove the pid file of the previous process when the plugin restarts", func(t *testing.T) {
+		m := newManager(t)
+		first, _ := startProcess(t)
+		second, _ := startProcess(t)
+		p := &testPidPlugin{
+			testPlugin:        &testPlugin{pluginID: "test", logger: log.New("test")},
+			testProcessPlugin: testProcessPlugin{pid: first.Process.Pid, running: true},
+		}
+
+		m.recordPluginProcess(p)
+		p.pid = second.Process.Pid
+		m.recordPluginProcess(p)
+
+		require.NoFileExists(t, filepath.Join(m.Cfg.PluginPidPath("test"), strconv.Itoa(first.Process.Pid)))
+		require.FileExists(t, filepath.Join(m.Cfg.PluginPidPath("test"), strconv.Itoa(second.Process.Pid)))
+
+		m.pluginPidFiles.removeAll()
+		require.NoFileExists(t, filepath.Join(m.Cfg.PluginPidPath("test"), strconv.Itoa(second.Process.Pid)))
+	})
+}
@@ -0,0 +1,154 @@
+package manager
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// canaryInstanceKey is the key of the canary instance of a plugin among its isolated instances, set apart from the
+// names of isolation groups by the colon.
+const canaryInstanceKey = ":canary"
+
+// RegisterCanary registers the canary version of a registered plugin, created by factory, replacing its previous
+// canary version. Requests of the data source instances and organizations selected by the canary_percent and
+// canary_orgs settings of the plugin are routed to it.
+func (m *Manager) RegisterCanary(ctx context.Context, pluginID string, factory backendplugin.PluginFactoryFunc) error {
+	m.pluginsMu.Lock()
+	p, registered := m.plugins[pluginID]
+	if !registered || !p.IsManaged() {
+		m.pluginsMu.Unlock()
+		return backendplugin.ErrPluginNotRegistered
+	}
+
+	if m.canaries == nil {
+		m.canaries = map[string]backendplugin.PluginFactoryFunc{}
+	}
+	m.canaries[pluginID] = factory
+	previous := m.removeCanaryInstance(pluginID)
+	m.pluginsMu.Unlock()
+
+	m.logger.Info("Registered canary version of plugin", "pluginId", pluginID)
+	m.stopCanaryInstance(ctx, previous)
+
+	// start the canary right away, rather than slowing down the first request routed to it
+	m.canaryInstance(pluginID)
+	return nil
+}
+
+// UnregisterCanary stops the canary version of a plugin and routes all requests to the plugin again.
+func (m *Manager) UnregisterCanary(ctx context.Context, pluginID string) error {
+	m.pluginsMu.Lock()
+	delete(m.canaries, pluginID)
+	previous := m.removeCanaryInstance(pluginID)
+	m.pluginsMu.Unlock()
+
+	m.stopCanaryInstance(ctx, previous)
+	return nil
+}
+
+// removeCanaryInstance removes the canary instance of a plugin and returns it, or nil if it isn't running. Must be
+// called with pluginsMu held.
+func (m *Manager) removeCanaryInstance(pluginID string) backendplugin.Plugin {
+	key := pluginID + "/" + canaryInstanceKey
+	canary, exists := m.isolatedPlugins[key]
+	if !exists {
+		return nil
+	}
+	delete(m.isolatedPlugins, key)
+	return canary
+}
+
+// stopCanaryInstance stops a canary instance after its in-flight requests completed.
+func (m *Manager) stopCanaryInstance(ctx context.Context, canary backendplugin.Plugin) {
+	if canary == nil {
+		return
+	}
+
+	if err := canary.Decommission(); err != nil {
+		canary.Logger().Warn("Failed to decommission canary plugin", "error", err)
+	}
+	if err := canary.Stop(ctx); err != nil {
+		canary.Logger().Warn("Failed to stop canary plugin", "error", err)
+	}
+	m.pluginPidFiles.remove(canary)
+}
+
+// routeToCanary returns whether a request of a plugin is handled by the canary version of the plugin. Requests of
+// the organizations of the canary_orgs setting are, and of the percentage of data source instances, or organizations
+// for app plugins, of the canary_percent setting. A data source instance or organization is always routed the same
+// way as long as the percentage doesn't change.
+func (m *Manager) routeToCanary(pluginID string, pCtx backend.PluginContext) bool {
+	m.pluginsMu.RLock()
+	_, exists := m.canaries[pluginID]
+	m.pluginsMu.RUnlock()
+	if !exists {
+		return false
+	}
+
+	for _, org := range getPluginStringListSetting(pluginID, "canary_orgs", m.Cfg, nil) {
+		if orgID, err := strconv.ParseInt(org, 10, 64); err == nil && orgID == pCtx.OrgID {
+			return true
+		}
+	}
+
+	percent := getPluginIntSetting(pluginID, "canary_percent", m.Cfg, 0)
+	if percent <= 0 {
+		return false
+	}
+
+	key := strconv.FormatInt(pCtx.OrgID, 10)
+	if pCtx.DataSourceInstanceSettings != nil {
+		key = pCtx.DataSourceInstanceSettings.UID
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(pluginID + "/" + key))
+	return int(h.Sum32()%100) < percent
+}
+
+// canaryInstance returns the running canary instance of a plugin, creating and starting it if needed.
+func (m *Manager) canaryInstance(pluginID string) (backendplugin.Plugin, bool) {
+	key := pluginID + "/" + canaryInstanceKey
+	m.pluginsMu.RLock()
+	canary, exists := m.isolatedPlugins[key]
+	m.pluginsMu.RUnlock()
+	if exists && !canary.IsDecommissioned() {
+		return canary, true
+	}
+
+	m.pluginsMu.Lock()
+	canary, exists = m.isolatedPlugins[key]
+	if exists && !canary.IsDecommissioned() {
+		m.pluginsMu.Unlock()
+		return canary, true
+	}
+
+	factory, hasCanary := m.canaries[pluginID]
+	registration, registered := m.registrations[pluginID]
+	if !hasCanary || !registered {
+		m.pluginsMu.Unlock()
+		return nil, false
+	}
+
+	env := append([]string{"GF_PLUGIN_CANARY=true"}, registration.env...)
+	canary, err := m.newPlugin(factory, pluginID, registration.logger.New("canary", true), env)
+	if err != nil {
+		m.pluginsMu.Unlock()
+		m.logger.Error("Failed to create canary plugin instance", "pluginId", pluginID, "error", err)
+		return nil, false
+	}
+	if m.isolatedPlugins == nil {
+		m.isolatedPlugins = map[string]backendplugin.Plugin{}
+	}
+	m.isolatedPlugins[key] = canary
+	m.pluginsMu.Unlock()
+
+	m.logger.Debug("Starting canary plugin instance", "pluginId", pluginID)
+	// the instance lives until the canary is unregistered or Grafana stops, not just for the current request
+	m.start(context.Background(), canary)
+
+	return canary, true
+}
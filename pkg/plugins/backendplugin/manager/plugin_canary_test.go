@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Canary(t *testing.T) {
+	newVersionFactory := func(version string, instances *[]*testPlugin, envs *[][]string) backendplugin.PluginFactoryFunc {
+		return func(pluginID string, logger log.Logger, env []string) (backendplugin.Plugin, error) {
+			p := &testPlugin{pluginID: pluginID, logger: logger, managed: true}
+			p.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+				return &backend.QueryDataResponse{Responses: backend.Responses{
+					"A": backend.DataResponse{Error: fmt.Errorf("%s", version)},
+				}}, nil
+			}
+			*instances = append(*instances, p)
+			*envs = append(*envs, env)
+			return p, nil
+		}
+	}
+	queryVersion := func(t *testing.T, m *Manager, orgID int64, dsUID string) string {
+		pCtx := backend.PluginContext{PluginID: testPluginID, OrgID: orgID}
+		if dsUID != "" {
+			pCtx.DataSourceInstanceSettings = &backend.DataSourceInstanceSettings{UID: dsUID}
+		}
+		resp, err := m.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: pCtx})
+		require.NoError(t, err)
+		return resp.Responses["A"].Error.Error()
+	}
+
+	t.Run("Routes the configured organizations to the canary version", func(t *testing.T) {
+		newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+			ctx.cfg.PluginSettings = setting.PluginSettings{testPluginID: map[string]string{"canary_orgs": "2,3"}}
+			var stable, canaries []*testPlugin
+			var stableEnvs, canaryEnvs [][]string
+			require.NoError(t, ctx.manager.Register(testPluginID, newVersionFactory("1.0.0", &stable, &stableEnvs)))
+			require.NoError(t, ctx.manager.RegisterCanary(context.Background(), testPluginID,
+				newVersionFactory("2.0.0", &canaries, &canaryEnvs)))
+			require.Len(t, canaries, 1)
+			require.Equal(t, 1, canaries[0].startCount)
+			require.Contains(t, canaryEnvs[0], "GF_PLUGIN_CANARY=true")
+
+			require.Equal(t, "1.0.0", queryVersion(t, ctx.manager, 1, ""))
+			require.Equal(t, "2.0.0", queryVersion(t, ctx.manager, 2, ""))
+			require.Equal(t, "2.0.0", queryVersion(t, ctx.manager, 3, ""))
+			require.Len(t, canaries, 1)
+
+			t.Run("Replaces the previous canary version", func(t *testing.T) {
+				var next []*testPlugin
+				var nextEnvs [][]string
+				require.NoError(t, ctx.manager.RegisterCanary(context.Background(), testPluginID,
+					newVersionFactory("2.1.0", &next, &nextEnvs)))
+				require.True(t, canaries[0].IsDecommissioned())
+				require.Equal(t, 1, canaries[0].stopCount)
+				require.Equal(t, "2.1.0", queryVersion(t, ctx.manager, 2, ""))
+			})
+
+			t.Run("Routes all requests to the plugin without canary version", func(t *testing.T) {
+				require.NoError(t, ctx.manager.UnregisterCanary(context.Background(), testPluginID))
+				require.Equal(t, "1.0.0", queryVersion(t, ctx.manager, 2, ""))
+				require.Empty(t, ctx.manager.isolatedPlugins)
+			})
+		})
+	})
+
+	t.Run("Routes a stable share of data source instances to the canary version", func(t *testing.T) {
+		newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+			ctx.cfg.PluginSettings = setting.PluginSettings{testPluginID: map[string]string{"canary_percent": "30"}}
+			var stable, canaries []*testPlugin
+			var envs [][]string
+			require.NoError(t, ctx.manager.Register(testPluginID, newVersionFactory("1.0.0", &stable, &envs)))
+			require.NoError(t, ctx.manager.RegisterCanary(context.Background(), testPluginID,
+				newVersionFactory("2.0.0", &canaries, &envs)))
+
+			routed := 0
+			for i := 0; i < 1000; i++ {
+				uid := fmt.Sprintf("ds-%d", i)
+				version := queryVersion(t, ctx.manager, 1, uid)
+				require.Equal(t, version, queryVersion(t, ctx.manager, 1, uid))
+				if version == "2.0.0" {
+					routed++
+				}
+			}
+			require.InDelta(t, 300, routed, 60)
+		})
+	})
+
+	t.Run("Stops the canary version with the plugin", func(t *testing.T) {
+		newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+			var stable, canaries []*testPlugin
+			var envs [][]string
+			require.NoError(t, ctx.manager.Register(testPluginID, newVersionFactory("1.0.0", &stable, &envs)))
+			require.NoError(t, ctx.manager.RegisterCanary(context.Background(), testPluginID,
+				newVersionFactory("2.0.0", &canaries, &envs)))
+
+			require.NoError(t, ctx.manager.UnregisterAndStop(context.Background(), testPluginID))
+			require.True(t, canaries[0].IsDecommissioned())
+			require.Empty(t, ctx.manager.isolatedPlugins)
+			require.Empty(t, ctx.manager.canaries)
+		})
+	})
+
+	t.Run("Requires the plugin to be registered", func(t *testing.T) {
+		newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+			var canaries []*testPlugin
+			var envs [][]string
+			err := ctx.manager.RegisterCanary(context.Background(), testPluginID,
+				newVersionFactory("2.0.0", &canaries, &envs))
+			require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+		})
+	})
+}
@@ -0,0 +1,22 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// PluginCapabilities returns the backend methods a registered backend plugin implements. Plugins whose capabilities
+// can't be discovered are reported to implement all methods.
+func (m *Manager) PluginCapabilities(ctx context.Context, pluginID string) (backendplugin.PluginCapabilities, error) {
+	p, registered := m.Get(pluginID)
+	if !registered {
+		return backendplugin.PluginCapabilities{}, backendplugin.ErrPluginNotRegistered
+	}
+
+	prober, ok := p.(backendplugin.CapabilityProber)
+	if !ok {
+		return backendplugin.AllPluginCapabilities, nil
+	}
+	return prober.Capabilities(ctx)
+}
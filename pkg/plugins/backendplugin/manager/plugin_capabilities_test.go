@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+type testCapabilitiesPlugin struct {
+	*testPlugin
+	capabilities backendplugin.PluginCapabilities
+}
+
+func (p *testCapabilitiesPlugin) Capabilities(ctx context.Context) (backendplugin.PluginCapabilities, error) {
+	return p.capabilities, nil
+}
+
+func TestManager_PluginCapabilities(t *testing.T) {
+	m := &Manager{
+		Cfg:    setting.NewCfg(),
+		logger: log.New("test"),
+		plugins: map[string]backendplugin.Plugin{
+			"prober": &testCapabilitiesPlugin{
+				testPlugin:   &testPlugin{pluginID: "prober", logger: log.New("test")},
+				capabilities: backendplugin.PluginCapabilities{QueryData: true, CheckHealth: true},
+			},
+			"other":          &testPlugin{pluginID: "other", logger: log.New("test")},
+			"decommissioned": &testPlugin{pluginID: "decommissioned", decommissioned: true, logger: log.New("test")},
+		},
+	}
+
+	t.Run("Should return the capabilities probed by the plugin", func(t *testing.T) {
+		capabilities, err := m.PluginCapabilities(context.Background(), "prober")
+		require.NoError(t, err)
+		require.Equal(t, backendplugin.PluginCapabilities{QueryData: true, CheckHealth: true}, capabilities)
+	})
+
+	t.Run("Should report all methods for plugins that can't probe their capabilities", func(t *testing.T) {
+		capabilities, err := m.PluginCapabilities(context.Background(), "other")
+		require.NoError(t, err)
+		require.Equal(t, backendplugin.AllPluginCapabilities, capabilities)
+	})
+
+	t.Run("Should return plugin not registered error for unknown and decommissioned plugins", func(t *testing.T) {
+		_, err := m.PluginCapabilities(context.Background(), "unknown")
+		require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+
+		_, err = m.PluginCapabilities(context.Background(), "decommissioned")
+		require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+	})
+}
@@ -0,0 +1,16 @@
+package manager
+
+import (
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// runInContainer runs a plugin with a container image in a container if container_runtime is set in the [plugins]
+// section. Otherwise, the plugin runs as a process of Grafana.
+func (m *Manager) runInContainer(p backendplugin.Plugin) {
+	runner, ok := p.(backendplugin.ContainerRunner)
+	if !ok || m.Cfg.PluginsContainerRuntime == "" {
+		return
+	}
+
+	runner.RunInContainer(m.Cfg.PluginsContainerRuntime)
+}
@@ -0,0 +1,39 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+type testContainerPlugin struct {
+	testPlugin
+	runtime string
+}
+
+func (p *testContainerPlugin) RunInContainer(runtime string) {
+	p.runtime = runtime
+}
+
+func TestManager_RunInContainer(t *testing.T) {
+	factory := func(pluginID string, logger log.Logger, env []string) (backendplugin.Plugin, error) {
+		return &testContainerPlugin{testPlugin: testPlugin{pluginID: pluginID, logger: logger, managed: true}}, nil
+	}
+
+	t.Run("Should run plugins in containers with the container runtime", func(t *testing.T) {
+		m := &Manager{Cfg: &setting.Cfg{PluginsContainerRuntime: "docker"}}
+		p, err := m.newPlugin(factory, "test", log.New("test"), nil)
+		require.NoError(t, err)
+		require.Equal(t, "docker", p.(*testContainerPlugin).runtime)
+	})
+
+	t.Run("Should run plugins as processes without container runtime", func(t *testing.T) {
+		m := &Manager{Cfg: &setting.Cfg{}}
+		p, err := m.newPlugin(factory, "test", log.New("test"), nil)
+		require.NoError(t, err)
+		require.Empty(t, p.(*testContainerPlugin).runtime)
+	})
+}
@@ -0,0 +1,103 @@
+package manager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var pluginFailed *prometheus.GaugeVec
+
+func init() {
+	pluginFailed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "plugin_failed",
+		Help:      "1 if the backend plugin exceeded its restart budget and is no longer restarted",
+	}, []string{"plugin_id"})
+
+	prometheus.MustRegister(pluginFailed)
+}
+
+// pluginFailures tracks the plugins that exceeded their restart budget. The zero value is ready to use.
+type pluginFailures struct {
+	mu     sync.Mutex
+	failed map[string]time.Time
+}
+
+// fail marks a plugin as failed, reporting whether it wasn't already.
+func (f *pluginFailures) fail(pluginID string, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.failed[pluginID]; exists {
+		return false
+	}
+	if f.failed == nil {
+		f.failed = map[string]time.Time{}
+	}
+	f.failed[pluginID] = now
+	return true
+}
+
+func (f *pluginFailures) isFailed(pluginID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, exists := f.failed[pluginID]
+	return exists
+}
+
+func (f *pluginFailures) reset(pluginID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.failed, pluginID)
+}
+
+// restartBudget returns the number of restarts within crashLoopWindow after which a plugin is marked as failed,
+// which can be set per plugin with the restart_budget setting and globally. Zero means plugins are restarted
+// indefinitely.
+func (m *Manager) restartBudget(pluginID string) int {
+	return getPluginIntSetting(pluginID, "restart_budget", m.Cfg, m.Cfg.PluginsRestartBudget)
+}
+
+// exceededRestartBudget reports whether plugin p was restarted more often than its restart budget allows, and if so
+// marks it as failed so it's no longer restarted.
+func (m *Manager) exceededRestartBudget(p backendplugin.Plugin, now time.Time) bool {
+	budget := m.restartBudget(p.PluginID())
+	if budget <= 0 {
+		return false
+	}
+	if _, recent, _ := m.pluginRestarts.get(p.PluginID(), now); recent <= budget {
+		return false
+	}
+
+	if m.pluginFailures.fail(p.PluginID(), now) {
+		p.Logger().Error("Plugin exceeded its restart budget and won't be restarted until restarted or reloaded",
+			"restarts", budget, "window", crashLoopWindow)
+		pluginFailed.WithLabelValues(p.PluginID()).Set(1)
+		publishLifecycleEvent(p, models.PluginFailed,
+			fmt.Sprintf("exceeded restart budget of %d restarts in %s", budget, crashLoopWindow))
+	}
+	return true
+}
+
+// resetPluginFailure clears the failed state and recent restarts of a plugin, so it's restarted again when it
+// exits.
+func (m *Manager) resetPluginFailure(pluginID string) {
+	m.pluginFailures.reset(pluginID)
+	m.pluginRestarts.reset(pluginID)
+	pluginFailed.DeleteLabelValues(pluginID)
+}
+
+// checkPluginFailed returns backendplugin.ErrPluginCrashed if plugin p exceeded its restart budget.
+func (m *Manager) checkPluginFailed(p backendplugin.Plugin) error {
+	if m.pluginFailures.isFailed(p.PluginID()) {
+		return backendplugin.ErrPluginCrashed
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_RestartBudget(t *testing.T) {
+	newPlugin := func(t *testing.T, budget int) (*Manager, *testPlugin) {
+		t.Helper()
+		m, p := newStreamTestManager(nil)
+		p.managed = true
+		p.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			return backend.NewQueryDataResponse(), nil
+		}
+		m.Cfg.PluginsRestartBudget = budget
+		m.PluginRequestValidator = &testPluginRequestValidator{}
+		t.Cleanup(func() {
+			pluginFailed.DeleteLabelValues(p.pluginID)
+		})
+		return m, p
+	}
+	crash := func(m *Manager, p *testPlugin, times int) {
+		for i := 0; i < times; i++ {
+			p.mutex.Lock()
+			p.exited = true
+			p.mutex.Unlock()
+			m.restartIfExited(context.Background(), p)
+		}
+	}
+	query := func(m *Manager) error {
+		_, err := m.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{PluginID: "test"},
+		})
+		return err
+	}
+
+	t.Run("Should stop restarting a plugin exceeding its restart budget", func(t *testing.T) {
+		m, p := newPlugin(t, 2)
+
+		crash(m, p, 2)
+		require.Equal(t, 2, p.startCount)
+		require.NoError(t, query(m))
+
+		crash(m, p, 2)
+		require.Equal(t, 2, p.startCount)
+		require.True(t, p.Exited())
+		require.Equal(t, backendplugin.PluginStatusFailed, m.PluginStates()[0].Status)
+		require.ErrorIs(t, query(m), backendplugin.ErrPluginCrashed)
+
+		err := m.callResourceInternal(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil),
+			backend.PluginContext{PluginID: "test"})
+		require.ErrorIs(t, err, backendplugin.ErrPluginCrashed)
+	})
+
+	t.Run("Should restart a failed plugin when restarted manually", func(t *testing.T) {
+		m, p := newPlugin(t, 1)
+		m.plugins["test"] = &testOutputPlugin{testPlugin: p}
+
+		crash(m, p, 2)
+		require.ErrorIs(t, query(m), backendplugin.ErrPluginCrashed)
+
+		require.NoError(t, m.RestartPlugin(context.Background(), "test"))
+		require.False(t, p.Exited())
+		require.NoError(t, query(m))
+
+		crash(m, p, 1)
+		require.False(t, p.Exited())
+		require.Equal(t, backendplugin.PluginStatusRunning, m.PluginStates()[0].Status)
+	})
+
+	t.Run("Should restart plugins indefinitely if the budget is 0", func(t *testing.T) {
+		m, p := newPlugin(t, 0)
+
+		crash(m, p, 5)
+		require.Equal(t, 5, p.startCount)
+		require.NoError(t, query(m))
+	})
+
+	t.Run("Should apply the restart budget of the plugin", func(t *testing.T) {
+		m, p := newPlugin(t, 0)
+		m.Cfg.PluginSettings = setting.PluginSettings{"test": {"restart_budget": "1"}}
+
+		crash(m, p, 2)
+		require.Equal(t, 1, p.startCount)
+		require.ErrorIs(t, query(m), backendplugin.ErrPluginCrashed)
+	})
+}
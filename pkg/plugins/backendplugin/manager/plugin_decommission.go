@@ -0,0 +1,130 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// pluginDrainTimeout is the maximum duration of waiting for the in-flight requests of a plugin being
+// decommissioned to complete before stopping it.
+const pluginDrainTimeout = 30 * time.Second
+
+// pluginRequests tracks the in-flight requests of plugins, so they can be drained. The zero value is ready to use.
+type pluginRequests struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+	drained  map[string][]chan struct{}
+}
+
+// begin tracks a request to a plugin, returning a function to call when the request completes.
+func (r *pluginRequests) begin(pluginID string) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.inFlight == nil {
+		r.inFlight = map[string]int{}
+	}
+	r.inFlight[pluginID]++
+
+	return func() {
+		r.end(pluginID)
+	}
+}
+
+func (r *pluginRequests) end(pluginID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inFlight[pluginID]--
+	if r.inFlight[pluginID] > 0 {
+		return
+	}
+
+	delete(r.inFlight, pluginID)
+	for _, ch := range r.drained[pluginID] {
+		close(ch)
+	}
+	delete(r.drained, pluginID)
+}
+
+// count returns the number of in-flight requests of a plugin.
+func (r *pluginRequests) count(pluginID string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.inFlight[pluginID]
+}
+
+// wait waits until a plugin has no in-flight requests or ctx is done.
+func (r *pluginRequests) wait(ctx context.Context, pluginID string) error {
+	r.mu.Lock()
+	if r.inFlight[pluginID] == 0 {
+		r.mu.Unlock()
+		return nil
+	}
+	if r.drained == nil {
+		r.drained = map[string][]chan struct{}{}
+	}
+	ch := make(chan struct{})
+	r.drained[pluginID] = append(r.drained[pluginID], ch)
+	r.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DecommissionPlugin decommissions a registered backend plugin, so it doesn't handle new requests, waits for its
+// in-flight requests to complete and stops it and its isolated instances. Unlike when unregistering it, the
+// plugin stays registered in the decommissioned state.
+func (m *Manager) DecommissionPlugin(ctx context.Context, pluginID string) error {
+	p, registered := m.Get(pluginID)
+	if !registered {
+		return backendplugin.ErrPluginNotRegistered
+	}
+	pluginID = p.PluginID()
+
+	unlock := m.pluginStartLocks.lock(pluginID)
+	defer unlock()
+
+	m.pluginsMu.Lock()
+	plugins := []backendplugin.Plugin{p}
+	for key, isolated := range m.isolatedInstances(pluginID) {
+		plugins = append(plugins, isolated)
+		delete(m.isolatedPlugins, key)
+	}
+	m.pluginsMu.Unlock()
+
+	p.Logger().Info("Decommissioning plugin")
+	for _, instance := range plugins {
+		if err := instance.Decommission(); err != nil {
+			return err
+		}
+	}
+	m.pluginStreams.stop(pluginID)
+
+	drainCtx, cancel := context.WithTimeout(ctx, pluginDrainTimeout)
+	defer cancel()
+	if err := m.pluginRequests.wait(drainCtx, pluginID); err != nil {
+		p.Logger().Warn("Stopping plugin with in-flight requests", "requests", m.pluginRequests.count(pluginID),
+			"error", err)
+	}
+
+	for _, instance := range plugins {
+		if err := instance.Stop(ctx); err != nil {
+			return err
+		}
+		m.pluginPidFiles.remove(instance)
+	}
+	p.Logger().Info("Plugin decommissioned")
+	publishLifecycleEvent(p, models.PluginDecommissioned, "")
+
+	return nil
+}
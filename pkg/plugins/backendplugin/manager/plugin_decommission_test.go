@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_DecommissionPlugin(t *testing.T) {
+	newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+		err := ctx.manager.RegisterAndStart(context.Background(), testPluginID, ctx.factory)
+		require.NoError(t, err)
+
+		queryStarted := make(chan struct{})
+		releaseQuery := make(chan struct{})
+		ctx.plugin.QueryDataHandlerFunc = func(_ context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			close(queryStarted)
+			<-releaseQuery
+			return backend.NewQueryDataResponse(), nil
+		}
+
+		queryErr := make(chan error, 1)
+		go func() {
+			_, err := ctx.manager.QueryData(context.Background(), &backend.QueryDataRequest{
+				PluginContext: backend.PluginContext{PluginID: testPluginID},
+			})
+			queryErr <- err
+		}()
+		<-queryStarted
+
+		decommissioned := make(chan error, 1)
+		go func() {
+			decommissioned <- ctx.manager.DecommissionPlugin(context.Background(), testPluginID)
+		}()
+
+		t.Run("Should wait for in-flight requests before stopping plugin", func(t *testing.T) {
+			require.Eventually(t, ctx.plugin.IsDecommissioned, time.Second, 10*time.Millisecond)
+			require.Equal(t, 1, ctx.manager.pluginRequests.count(testPluginID))
+
+			_, err := ctx.manager.QueryData(context.Background(), &backend.QueryDataRequest{
+				PluginContext: backend.PluginContext{PluginID: testPluginID},
+			})
+			require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+
+			select {
+			case <-decommissioned:
+				t.Fatal("plugin decommissioned before in-flight request completed")
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			close(releaseQuery)
+			require.NoError(t, <-queryErr)
+			require.NoError(t, <-decommissioned)
+			require.Equal(t, 1, ctx.plugin.stopCount)
+		})
+
+		t.Run("Should keep decommissioned plugin listed", func(t *testing.T) {
+			states := ctx.manager.PluginStates()
+			require.Len(t, states, 1)
+			require.Equal(t, testPluginID, states[0].PluginID)
+			require.Equal(t, backendplugin.PluginStatusDecommissioned, states[0].Status)
+		})
+
+		t.Run("Should return plugin not registered error for decommissioned plugin", func(t *testing.T) {
+			err := ctx.manager.DecommissionPlugin(context.Background(), testPluginID)
+			require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+		})
+
+		t.Run("Should unregister decommissioned plugin so it can be registered again", func(t *testing.T) {
+			_, exists := ctx.manager.Get(testPluginID)
+			require.False(t, exists)
+			p, registered := ctx.manager.GetRegistered(testPluginID)
+			require.True(t, registered)
+			require.True(t, p.IsDecommissioned())
+
+			err := ctx.manager.UnregisterAndStop(context.Background(), testPluginID)
+			require.NoError(t, err)
+			_, registered = ctx.manager.GetRegistered(testPluginID)
+			require.False(t, registered)
+
+			err = ctx.manager.Register(testPluginID, ctx.factory)
+			require.NoError(t, err)
+			require.True(t, ctx.manager.IsRegistered(testPluginID))
+		})
+	})
+}
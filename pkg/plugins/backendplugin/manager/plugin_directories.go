@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+)
+
+// preparePluginDirectories creates the data directory of a plugin, if missing, and an empty temporary directory, so
+// that plugins have a writable place for caches and state outside of their signed install directory.
+func (m *Manager) preparePluginDirectories(pluginID string) error {
+	dataPath := m.Cfg.PluginDataPath(pluginID)
+	if dataPath == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(dataPath, 0750); err != nil {
+		return fmt.Errorf("failed to create data directory of backend plugin %s: %w", pluginID, err)
+	}
+
+	tempPath := m.Cfg.PluginTempPath(pluginID)
+	if err := os.RemoveAll(tempPath); err != nil {
+		return fmt.Errorf("failed to empty temporary directory of backend plugin %s: %w", pluginID, err)
+	}
+	if err := os.MkdirAll(tempPath, 0750); err != nil {
+		return fmt.Errorf("failed to create temporary directory of backend plugin %s: %w", pluginID, err)
+	}
+
+	return nil
+}
+
+// removePluginTempDirectory removes the temporary directory of a plugin that is no longer running.
+func (m *Manager) removePluginTempDirectory(pluginID string) {
+	tempPath := m.Cfg.PluginTempPath(pluginID)
+	if tempPath == "" {
+		return
+	}
+
+	if err := os.RemoveAll(tempPath); err != nil {
+		m.logger.Warn("Failed to remove plugin temporary directory", "pluginId", pluginID, "error", err)
+	}
+}
+
+// pluginDirectoriesEnv returns the environment variables passing the data, temporary and unix socket directories to a
+// plugin.
+func (m *Manager) pluginDirectoriesEnv(pluginID string) []string {
+	var env []string
+	socketPath := m.pluginSocketPath(pluginID)
+	if socketPath != "" {
+		env = append(env, fmt.Sprintf("PLUGIN_UNIX_SOCKET_DIR=%s", socketPath))
+	}
+
+	dataPath := m.Cfg.PluginDataPath(pluginID)
+	if dataPath == "" {
+		if socketPath != "" {
+			env = append(env, fmt.Sprintf("TMPDIR=%s", socketPath))
+		}
+		return env
+	}
+
+	tempPath := m.Cfg.PluginTempPath(pluginID)
+	if socketPath != "" {
+		// plugins built with versions of go-plugin not supporting PLUGIN_UNIX_SOCKET_DIR create their unix socket in
+		// their temporary directory
+		tempPath = socketPath
+	}
+	return append(env,
+		fmt.Sprintf("GF_PLUGIN_DATA_DIR=%s", dataPath),
+		fmt.Sprintf("TMPDIR=%s", tempPath),
+		fmt.Sprintf("TMP=%s", tempPath),
+		fmt.Sprintf("TEMP=%s", tempPath),
+	)
+}
@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_PluginDirectories(t *testing.T) {
+	t.Run("Should not create directories when plugin data directories are disabled", func(t *testing.T) {
+		m := &Manager{Cfg: setting.NewCfg(), License: &testLicensingService{}}
+		require.NoError(t, m.preparePluginDirectories("test"))
+		for _, variable := range m.pluginEnv("test") {
+			require.NotContains(t, variable, "GF_PLUGIN_DATA_DIR=")
+		}
+	})
+
+	t.Run("Should keep the data directory and empty the temporary directory", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PluginsDataDirectory = t.TempDir()
+		m := &Manager{Cfg: cfg, License: &testLicensingService{}, logger: log.New("test")}
+		dataPath := filepath.Join(cfg.PluginsDataDirectory, "test", "data")
+		tempPath := filepath.Join(cfg.PluginsDataDirectory, "test", "tmp")
+
+		require.NoError(t, m.preparePluginDirectories("test"))
+		require.NoError(t, os.WriteFile(filepath.Join(dataPath, "state"), []byte("state"), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(tempPath, "cache"), []byte("cache"), 0600))
+
+		require.NoError(t, m.preparePluginDirectories("test"))
+		require.FileExists(t, filepath.Join(dataPath, "state"))
+		require.NoFileExists(t, filepath.Join(tempPath, "cache"))
+		require.DirExists(t, tempPath)
+
+		require.Subset(t, m.pluginEnv("test"), []string{
+			"GF_PLUGIN_DATA_DIR=" + dataPath,
+			"TMPDIR=" + tempPath,
+		})
+
+		m.removePluginTempDirectory("test")
+		require.NoDirExists(t, tempPath)
+		require.DirExists(t, dataPath)
+	})
+}
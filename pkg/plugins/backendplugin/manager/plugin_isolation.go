@@ -0,0 +1,125 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+const (
+	// pluginIsolationOrg runs a separate plugin instance per organization.
+	pluginIsolationOrg = "org"
+	// pluginIsolationGroup runs a separate plugin instance per configured group of organizations.
+	pluginIsolationGroup = "group"
+)
+
+// pluginRegistration is what's needed to create additional instances of a registered plugin.
+type pluginRegistration struct {
+	factory backendplugin.PluginFactoryFunc
+	env     []string
+	logger  log.Logger
+}
+
+// isolationGroup returns the isolation group of an organization for a plugin, or an empty string if requests of
+// the organization are handled by the shared plugin instance.
+func (m *Manager) isolationGroup(pluginID string, orgID int64) string {
+	settings := m.Cfg.PluginSettingsFor(pluginID)
+	switch settings["isolation"] {
+	case pluginIsolationOrg:
+		if orgID == 0 {
+			return ""
+		}
+		return fmt.Sprintf("org-%d", orgID)
+	case pluginIsolationGroup:
+		for _, orgGroup := range strings.Split(settings["isolation_groups"], ",") {
+			parts := strings.SplitN(strings.TrimSpace(orgGroup), ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			if id, err := strconv.ParseInt(parts[0], 10, 64); err == nil && id == orgID {
+				return strings.TrimSpace(parts[1])
+			}
+		}
+	}
+
+	return ""
+}
+
+// getForContext returns the plugin instance handling a request, creating and starting a separate instance when
+// the request is routed to the canary version of the plugin or the plugin is configured to isolate the organization
+// of the request.
+func (m *Manager) getForContext(pCtx backend.PluginContext) (backendplugin.Plugin, bool) {
+	p, registered := m.Get(pCtx.PluginID)
+	if !registered || !p.IsManaged() {
+		return p, registered
+	}
+
+	if m.routeToCanary(p.PluginID(), pCtx) {
+		if canary, exists := m.canaryInstance(p.PluginID()); exists {
+			return canary, true
+		}
+	}
+
+	group := m.isolationGroup(p.PluginID(), pCtx.OrgID)
+	if group == "" {
+		return p, true
+	}
+
+	key := p.PluginID() + "/" + group
+	m.pluginsMu.RLock()
+	isolated, exists := m.isolatedPlugins[key]
+	m.pluginsMu.RUnlock()
+	if exists && !isolated.IsDecommissioned() {
+		return isolated, true
+	}
+
+	m.pluginsMu.Lock()
+	isolated, exists = m.isolatedPlugins[key]
+	if exists && !isolated.IsDecommissioned() {
+		m.pluginsMu.Unlock()
+		return isolated, true
+	}
+
+	registration, exists := m.registrations[p.PluginID()]
+	if !exists {
+		m.pluginsMu.Unlock()
+		return p, true
+	}
+
+	env := append([]string{fmt.Sprintf("GF_PLUGIN_ISOLATION_GROUP=%s", group)}, registration.env...)
+	logger := registration.logger.New("isolationGroup", group)
+	isolated, err := m.newPlugin(registration.factory, p.PluginID(), logger, env)
+	if err != nil {
+		m.pluginsMu.Unlock()
+		m.logger.Error("Failed to create isolated plugin instance", "pluginId", p.PluginID(), "isolationGroup", group,
+			"error", err)
+		return nil, false
+	}
+	if m.isolatedPlugins == nil {
+		m.isolatedPlugins = map[string]backendplugin.Plugin{}
+	}
+	m.isolatedPlugins[key] = isolated
+	m.pluginsMu.Unlock()
+
+	m.logger.Debug("Starting isolated plugin instance", "pluginId", p.PluginID(), "isolationGroup", group)
+	// the instance lives until the plugin is unregistered or Grafana stops, not just for the current request
+	m.start(context.Background(), isolated)
+
+	return isolated, true
+}
+
+// isolatedInstances returns the isolated instances of a plugin. Must be called with pluginsMu held.
+func (m *Manager) isolatedInstances(pluginID string) map[string]backendplugin.Plugin {
+	instances := map[string]backendplugin.Plugin{}
+	for key, p := range m.isolatedPlugins {
+		if strings.HasPrefix(key, pluginID+"/") {
+			instances[key] = p
+		}
+	}
+	return instances
+}
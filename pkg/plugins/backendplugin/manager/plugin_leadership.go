@@ -0,0 +1,24 @@
+package manager
+
+import (
+	"sync/atomic"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// handlePluginLeadershipChanged records whether this Grafana instance was elected to run the background tasks shared
+// by all instances.
+func (m *Manager) handlePluginLeadershipChanged(event *models.PluginLeadershipChanged) error {
+	var leader int32
+	if event.Leader {
+		leader = 1
+	}
+	atomic.StoreInt32(&m.leader, leader)
+	return nil
+}
+
+// isLeader returns whether this Grafana instance runs the background tasks shared by all instances, like data source
+// health checks. Without leader election every instance does, otherwise none does until one is elected.
+func (m *Manager) isLeader() bool {
+	return m.Cfg.PluginsLeaderElectionInterval <= 0 || atomic.LoadInt32(&m.leader) == 1
+}
@@ -0,0 +1,27 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Leadership(t *testing.T) {
+	t.Run("Every instance is the leader without leader election", func(t *testing.T) {
+		m := &Manager{Cfg: &setting.Cfg{}}
+		require.True(t, m.isLeader())
+	})
+
+	t.Run("Only the elected instance is the leader with leader election", func(t *testing.T) {
+		m := &Manager{Cfg: &setting.Cfg{PluginsLeaderElectionInterval: 10}}
+		require.False(t, m.isLeader())
+
+		require.NoError(t, m.handlePluginLeadershipChanged(&models.PluginLeadershipChanged{Leader: true}))
+		require.True(t, m.isLeader())
+
+		require.NoError(t, m.handlePluginLeadershipChanged(&models.PluginLeadershipChanged{Leader: false}))
+		require.False(t, m.isLeader())
+	})
+}
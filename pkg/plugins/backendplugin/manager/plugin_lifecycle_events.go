@@ -0,0 +1,23 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// publishLifecycleEvent publishes a lifecycle event of plugin p on the bus, for the frontend and the other Grafana
+// instances to react to.
+func publishLifecycleEvent(p backendplugin.Plugin, eventType models.PluginLifecycleEventType, reason string) {
+	event := &models.PluginLifecycleEvent{
+		PluginId: p.PluginID(),
+		Type:     eventType,
+		Error:    reason,
+		Created:  time.Now(),
+	}
+	if err := bus.Publish(event); err != nil {
+		p.Logger().Warn("Failed to publish plugin lifecycle event", "type", eventType, "error", err)
+	}
+}
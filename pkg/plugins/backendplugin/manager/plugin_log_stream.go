@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/inconshreveable/log15"
+)
+
+// logStreamBufferSize is the number of log entries buffered per log stream. Entries are dropped when a stream's
+// buffer is full, so slow readers don't block the plugin logger.
+const logStreamBufferSize = 100
+
+type logSubscription struct {
+	lvl     log15.Lvl
+	entries chan backendplugin.LogEntry
+}
+
+// pluginLogStreams holds the subscriptions to the logs of plugins. The zero value is ready to use.
+type pluginLogStreams struct {
+	mu            sync.RWMutex
+	subscriptions map[string]map[*logSubscription]struct{}
+}
+
+func (s *pluginLogStreams) subscribe(pluginID string, lvl log15.Lvl) *logSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscriptions == nil {
+		s.subscriptions = map[string]map[*logSubscription]struct{}{}
+	}
+	if s.subscriptions[pluginID] == nil {
+		s.subscriptions[pluginID] = map[*logSubscription]struct{}{}
+	}
+
+	sub := &logSubscription{lvl: lvl, entries: make(chan backendplugin.LogEntry, logStreamBufferSize)}
+	s.subscriptions[pluginID][sub] = struct{}{}
+	return sub
+}
+
+func (s *pluginLogStreams) unsubscribe(pluginID string, sub *logSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscriptions[pluginID], sub)
+	if len(s.subscriptions[pluginID]) == 0 {
+		delete(s.subscriptions, pluginID)
+	}
+	close(sub.entries)
+}
+
+// publish sends a log record of a plugin to the subscriptions of at most its level.
+func (s *pluginLogStreams) publish(pluginID string, r *log15.Record) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subs := s.subscriptions[pluginID]
+	if len(subs) == 0 {
+		return
+	}
+
+	entry := backendplugin.LogEntry{
+		Time:    r.Time,
+		Level:   logLevelNames[r.Lvl],
+		Message: r.Msg,
+	}
+	if len(r.Ctx) > 0 {
+		entry.Fields = make(map[string]string, len(r.Ctx)/2)
+		for i := 0; i+1 < len(r.Ctx); i += 2 {
+			entry.Fields[fmt.Sprint(r.Ctx[i])] = fmt.Sprint(r.Ctx[i+1])
+		}
+	}
+
+	for sub := range subs {
+		if r.Lvl > sub.lvl {
+			continue
+		}
+		select {
+		case sub.entries <- entry:
+		default:
+		}
+	}
+}
+
+// pluginLogStreamHandler publishes the log records of a plugin to its log streams before passing them to next.
+func (m *Manager) pluginLogStreamHandler(pluginID string, next log15.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		m.pluginLogStreams.publish(pluginID, r)
+		return next.Log(r)
+	})
+}
+
+// StreamLogs streams the log entries of a registered backend plugin of at most the given level, until ctx is
+// done. Entries are dropped if the returned channel isn't read fast enough.
+func (m *Manager) StreamLogs(ctx context.Context, pluginID string, level string) (<-chan backendplugin.LogEntry, error) {
+	lvl, err := parseLogLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.IsRegistered(pluginID) {
+		return nil, backendplugin.ErrPluginNotRegistered
+	}
+
+	sub := m.pluginLogStreams.subscribe(pluginID, lvl)
+	m.goPlugin(ctx, pluginID, goroutineLogStream, func(ctx context.Context) {
+		<-ctx.Done()
+		m.pluginLogStreams.unsubscribe(pluginID, sub)
+	})
+
+	return sub.entries, nil
+}
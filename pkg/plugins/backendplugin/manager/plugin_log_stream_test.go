@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_StreamLogs(t *testing.T) {
+	newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+		err := ctx.manager.Register(testPluginID, ctx.factory)
+		require.NoError(t, err)
+
+		t.Run("Should return errors for unknown plugin and invalid level", func(t *testing.T) {
+			_, err := ctx.manager.StreamLogs(context.Background(), "unknown", "info")
+			require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+
+			_, err = ctx.manager.StreamLogs(context.Background(), testPluginID, "verbose")
+			require.ErrorIs(t, err, backendplugin.ErrInvalidLogLevel)
+		})
+
+		t.Run("Should stream plugin log entries of at most the requested level", func(t *testing.T) {
+			streamCtx, cancel := context.WithCancel(context.Background())
+			entries, err := ctx.manager.StreamLogs(streamCtx, testPluginID, "info")
+			require.NoError(t, err)
+
+			ctx.plugin.logger.Debug("Debug message")
+			ctx.plugin.logger.Info("Info message", "query", "A")
+			ctx.plugin.logger.Warn("Warn message")
+
+			entry := <-entries
+			require.Equal(t, "info", entry.Level)
+			require.Equal(t, "Info message", entry.Message)
+			require.Equal(t, "A", entry.Fields["query"])
+			require.Equal(t, testPluginID, entry.Fields["pluginId"])
+
+			entry = <-entries
+			require.Equal(t, "warn", entry.Level)
+			require.Equal(t, "Warn message", entry.Message)
+
+			cancel()
+			select {
+			case _, ok := <-entries:
+				require.False(t, ok)
+			case <-time.After(time.Second):
+				t.Fatal("log stream wasn't closed")
+			}
+		})
+	})
+}
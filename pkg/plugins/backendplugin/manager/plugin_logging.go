@@ -0,0 +1,286 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/inconshreveable/log15"
+)
+
+// pluginLogFiles holds the log files of plugins logging to their own file. The zero value is ready to use.
+type pluginLogFiles struct {
+	mu    sync.Mutex
+	files map[string]*log.FileLogWriter
+}
+
+func (f *pluginLogFiles) add(pluginID string, w *log.FileLogWriter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.files == nil {
+		f.files = map[string]*log.FileLogWriter{}
+	}
+	f.files[pluginID] = w
+}
+
+// close closes the log file of a plugin, if any.
+func (f *pluginLogFiles) close(pluginID string) error {
+	f.mu.Lock()
+	w, exists := f.files[pluginID]
+	delete(f.files, pluginID)
+	f.mu.Unlock()
+
+	if !exists {
+		return nil
+	}
+	return w.Close()
+}
+
+// closeAll closes the log files of all plugins.
+func (f *pluginLogFiles) closeAll() {
+	f.mu.Lock()
+	files := f.files
+	f.files = nil
+	f.mu.Unlock()
+
+	for _, w := range files {
+		_ = w.Close()
+	}
+}
+
+// pluginLogFile returns the path of the log file of a plugin, or an empty string if the plugin logs to the
+// Grafana log. Relative paths are relative to the Grafana logs directory.
+func (m *Manager) pluginLogFile(pluginID string) string {
+	fileName := m.Cfg.PluginSettingsFor(pluginID)["log_file"]
+	if fileName == "" && m.Cfg.PluginsLogDirectory != "" {
+		fileName = filepath.Join(m.Cfg.PluginsLogDirectory, pluginID+".log")
+	}
+	if fileName == "" || filepath.IsAbs(fileName) {
+		return fileName
+	}
+
+	return filepath.Join(m.Cfg.LogsPath, fileName)
+}
+
+// newPluginLogger returns the logger of a plugin. Plugins configured to log to their own file get a logger
+// writing to a rotated file, and to the Grafana log as well if configured.
+func (m *Manager) newPluginLogger(pluginID string) (log.Logger, error) {
+	if level := m.Cfg.PluginSettingsFor(pluginID)["log_level"]; level != "" {
+		if lvl, err := log15.LvlFromString(level); err == nil {
+			m.pluginLogLevels.set(pluginID, lvl)
+		} else {
+			m.logger.Warn("Invalid plugin log level", "pluginId", pluginID, "level", level)
+		}
+	}
+
+	logger := m.logger.New("pluginId", pluginID)
+	fileName := m.pluginLogFile(pluginID)
+	if fileName == "" {
+		// the Grafana log handlers filter records of lower level than the Grafana log level
+		logger.SetHandler(m.pluginLogStreamHandler(pluginID,
+			m.pluginLogLevelHandler(pluginID, log15.LvlDebug, logger.GetHandler())))
+		return logger, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fileName), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	// rotate plugin log files like the Grafana log file
+	sec := m.Cfg.Raw.Section("log.file")
+	fileHandler := log.NewFileWriter()
+	fileHandler.Filename = fileName
+	fileHandler.Format = log15.LogfmtFormat()
+	fileHandler.Rotate = sec.Key("log_rotate").MustBool(true)
+	fileHandler.Maxlines = sec.Key("max_lines").MustInt(1000000)
+	fileHandler.Maxsize = 1 << uint(sec.Key("max_size_shift").MustInt(28))
+	fileHandler.Daily = sec.Key("daily_rotate").MustBool(true)
+	fileHandler.Maxdays = sec.Key("max_days").MustInt64(7)
+	if err := fileHandler.Init(); err != nil {
+		return nil, err
+	}
+
+	var handler log15.Handler = fileHandler
+	if m.Cfg.PluginsLogToMainLog {
+		// the root handler is replaced when the logging configuration is reloaded
+		handler = log15.MultiHandler(fileHandler, log15.FuncHandler(func(r *log15.Record) error {
+			return log.Root.GetHandler().Log(r)
+		}))
+	}
+
+	logger.SetHandler(m.pluginLogStreamHandler(pluginID,
+		m.pluginLogLevelHandler(pluginID, m.defaultLogLevel(), handler)))
+	m.pluginLogFiles.add(pluginID, fileHandler)
+
+	return logger, nil
+}
+
+// logLevelNames are the names of the log levels that can be set for plugins.
+var logLevelNames = map[log15.Lvl]string{
+	log15.LvlDebug: "debug",
+	log15.LvlInfo:  "info",
+	log15.LvlWarn:  "warn",
+	log15.LvlError: "error",
+	log15.LvlCrit:  "critical",
+}
+
+// parseLogLevel parses the name of a log level.
+func parseLogLevel(level string) (log15.Lvl, error) {
+	if level == "critical" {
+		return log15.LvlCrit, nil
+	}
+
+	lvl, err := log15.LvlFromString(level)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", backendplugin.ErrInvalidLogLevel, level)
+	}
+	return lvl, nil
+}
+
+// pluginLogLevels holds the log levels set for plugins at runtime or in their settings. The zero value is ready
+// to use.
+type pluginLogLevels struct {
+	mu     sync.RWMutex
+	levels map[string]log15.Lvl
+}
+
+func (l *pluginLogLevels) get(pluginID string) (log15.Lvl, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	lvl, exists := l.levels[pluginID]
+	return lvl, exists
+}
+
+func (l *pluginLogLevels) set(pluginID string, lvl log15.Lvl) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.levels == nil {
+		l.levels = map[string]log15.Lvl{}
+	}
+	l.levels[pluginID] = lvl
+}
+
+// defaultLogLevel returns the Grafana log level.
+func (m *Manager) defaultLogLevel() log15.Lvl {
+	lvl, err := log15.LvlFromString(m.Cfg.Raw.Section("log").Key("level").MustString("info"))
+	if err != nil {
+		return log15.LvlInfo
+	}
+	return lvl
+}
+
+// pluginLogLevelHandler filters the log records of a plugin by the log level set for the plugin, falling back to
+// def if no level is set.
+func (m *Manager) pluginLogLevelHandler(pluginID string, def log15.Lvl, next log15.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		lvl, exists := m.pluginLogLevels.get(pluginID)
+		if !exists {
+			lvl = def
+		}
+		if r.Lvl > lvl {
+			return nil
+		}
+		return next.Log(r)
+	})
+}
+
+// LogLevel returns the log level of a registered backend plugin.
+func (m *Manager) LogLevel(pluginID string) (string, error) {
+	p, registered := m.Get(pluginID)
+	if !registered {
+		return "", backendplugin.ErrPluginNotRegistered
+	}
+
+	lvl, exists := m.pluginLogLevels.get(p.PluginID())
+	if !exists {
+		lvl = m.defaultLogLevel()
+	}
+	return logLevelNames[lvl], nil
+}
+
+// SetLogLevel changes the log level of a registered backend plugin and forwards it to the running plugin
+// processes. Plugin processes receive their configured log level in the GF_PLUGIN_LOG_LEVEL environment
+// variable when started, and level changes as a PUT request of the logLevelResourcePath resource.
+func (m *Manager) SetLogLevel(ctx context.Context, pluginID string, level string) error {
+	lvl, err := parseLogLevel(level)
+	if err != nil {
+		return err
+	}
+
+	m.pluginsMu.RLock()
+	pluginID = m.resolveAlias(pluginID)
+	p, exists := m.plugins[pluginID]
+	plugins := []backendplugin.Plugin{p}
+	for _, isolated := range m.isolatedInstances(pluginID) {
+		plugins = append(plugins, isolated)
+	}
+	m.pluginsMu.RUnlock()
+	if !exists {
+		return backendplugin.ErrPluginNotRegistered
+	}
+
+	m.pluginLogLevels.set(pluginID, lvl)
+	p.Logger().Info("Plugin log level changed", "level", logLevelNames[lvl])
+
+	for _, p := range plugins {
+		m.forwardLogLevel(ctx, p)
+	}
+
+	return nil
+}
+
+// logLevelResourcePath is the resource path plugins can implement to change their log level at runtime.
+const logLevelResourcePath = "_grafana/log-level"
+
+// forwardLogLevel sends the log level set for a plugin at runtime to the plugin process, if any. Plugins not
+// implementing the log level resource keep logging at their level.
+func (m *Manager) forwardLogLevel(ctx context.Context, p backendplugin.Plugin) {
+	lvl, exists := m.pluginLogLevels.get(p.PluginID())
+	if !exists || !p.IsManaged() || p.Exited() {
+		return
+	}
+
+	body, err := json.Marshal(map[string]string{"level": logLevelNames[lvl]})
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sender := &statusResponseSender{}
+	err = m.callPluginResource(ctx, p, &backend.CallResourceRequest{
+		PluginContext: backend.PluginContext{PluginID: p.PluginID()},
+		Path:          logLevelResourcePath,
+		Method:        http.MethodPut,
+		URL:           logLevelResourcePath,
+		Headers:       map[string][]string{"Content-Type": {"application/json"}},
+		Body:          body,
+	}, sender)
+	if err != nil || sender.status != http.StatusOK {
+		p.Logger().Debug("Plugin process didn't accept log level", "status", sender.status, "error", err)
+	}
+}
+
+// statusResponseSender records the status of a resource response, discarding the response body.
+type statusResponseSender struct {
+	status int
+}
+
+func (s *statusResponseSender) Send(res *backend.CallResourceResponse) error {
+	if s.status == 0 {
+		s.status = res.Status
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package manager
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_PluginLogFile(t *testing.T) {
+	logsPath := t.TempDir()
+	cfg := setting.NewCfg()
+	cfg.LogsPath = logsPath
+	m := &Manager{Cfg: cfg, logger: log.New("test")}
+
+	t.Run("Should log to Grafana log by default", func(t *testing.T) {
+		require.Empty(t, m.pluginLogFile("test"))
+	})
+
+	t.Run("Should log to plugin log directory", func(t *testing.T) {
+		cfg.PluginsLogDirectory = "plugins"
+		t.Cleanup(func() {
+			cfg.PluginsLogDirectory = ""
+		})
+
+		require.Equal(t, filepath.Join(logsPath, "plugins", "test.log"), m.pluginLogFile("test"))
+	})
+
+	t.Run("Should log to configured plugin log file", func(t *testing.T) {
+		cfg.PluginsLogDirectory = "plugins"
+		cfg.PluginSettings = setting.PluginSettings{"test": {"log_file": "/var/log/test.log"}}
+		t.Cleanup(func() {
+			cfg.PluginsLogDirectory = ""
+			cfg.PluginSettings = nil
+		})
+
+		require.Equal(t, "/var/log/test.log", m.pluginLogFile("test"))
+		require.Equal(t, filepath.Join(logsPath, "plugins", "other.log"), m.pluginLogFile("other"))
+	})
+
+	t.Run("Should write plugin logs to plugin log file", func(t *testing.T) {
+		cfg.PluginsLogDirectory = "plugins"
+		t.Cleanup(func() {
+			cfg.PluginsLogDirectory = ""
+		})
+
+		logger, err := m.newPluginLogger("test")
+		require.NoError(t, err)
+		logger.Info("Hello from plugin")
+		require.NoError(t, m.pluginLogFiles.close("test"))
+
+		content, err := ioutil.ReadFile(filepath.Join(logsPath, "plugins", "test.log"))
+		require.NoError(t, err)
+		require.Contains(t, string(content), "Hello from plugin")
+		require.Contains(t, string(content), "pluginId=test")
+	})
+}
+
+func TestManager_SetLogLevel(t *testing.T) {
+	newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+		err := ctx.manager.Register(testPluginID, ctx.factory)
+		require.NoError(t, err)
+
+		var forwarded *backend.CallResourceRequest
+		ctx.plugin.CallResourceHandlerFunc = func(ctx context.Context, req *backend.CallResourceRequest,
+			sender backend.CallResourceResponseSender) error {
+			forwarded = req
+			return sender.Send(&backend.CallResourceResponse{Status: http.StatusOK})
+		}
+
+		level, err := ctx.manager.LogLevel(testPluginID)
+		require.NoError(t, err)
+		require.Equal(t, "info", level)
+
+		err = ctx.manager.SetLogLevel(context.Background(), testPluginID, "debug")
+		require.NoError(t, err)
+
+		level, err = ctx.manager.LogLevel(testPluginID)
+		require.NoError(t, err)
+		require.Equal(t, "debug", level)
+		require.NotNil(t, forwarded)
+		require.Equal(t, http.MethodPut, forwarded.Method)
+		require.Equal(t, logLevelResourcePath, forwarded.Path)
+		require.JSONEq(t, `{"level":"debug"}`, string(forwarded.Body))
+
+		t.Run("Should fail for invalid level", func(t *testing.T) {
+			err := ctx.manager.SetLogLevel(context.Background(), testPluginID, "verbose")
+			require.ErrorIs(t, err, backendplugin.ErrInvalidLogLevel)
+		})
+
+		t.Run("Should fail for unregistered plugin", func(t *testing.T) {
+			err := ctx.manager.SetLogLevel(context.Background(), "unknown", "debug")
+			require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+			_, err = ctx.manager.LogLevel("unknown")
+			require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+		})
+	})
+}
+
+func TestManager_PluginLogLevelHandler(t *testing.T) {
+	m := &Manager{}
+	var records []*log15.Record
+	handler := m.pluginLogLevelHandler("test", log15.LvlInfo, log15.FuncHandler(func(r *log15.Record) error {
+		records = append(records, r)
+		return nil
+	}))
+
+	require.NoError(t, handler.Log(&log15.Record{Lvl: log15.LvlDebug}))
+	require.NoError(t, handler.Log(&log15.Record{Lvl: log15.LvlInfo}))
+	require.Len(t, records, 1)
+
+	m.pluginLogLevels.set("test", log15.LvlDebug)
+	require.NoError(t, handler.Log(&log15.Record{Lvl: log15.LvlDebug}))
+	require.Len(t, records, 2)
+
+	m.pluginLogLevels.set("test", log15.LvlError)
+	require.NoError(t, handler.Log(&log15.Record{Lvl: log15.LvlWarn}))
+	require.Len(t, records, 2)
+}
@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// pprofPluginIDLabel is the pprof label with the plugin ID set on the goroutines running plugin requests.
+const pprofPluginIDLabel = "plugin_id"
+
+// The kinds of goroutines run for a plugin.
+const (
+	goroutineRestartLoop      = "restartLoop"
+	goroutineResponseStream   = "responseStream"
+	goroutineQuery            = "query"
+	goroutineLogStream        = "logStream"
+	goroutineWebSocketMessage = "webSocketMessage"
+)
+
+// pluginGoroutines counts the goroutines run for backend plugins by plugin ID and kind. The zero value is ready
+// to use.
+type pluginGoroutines struct {
+	mu     sync.Mutex
+	counts map[string]map[string]int
+}
+
+func (g *pluginGoroutines) add(pluginID string, kind string, delta int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.counts == nil {
+		g.counts = map[string]map[string]int{}
+	}
+	kinds, exists := g.counts[pluginID]
+	if !exists {
+		kinds = map[string]int{}
+		g.counts[pluginID] = kinds
+	}
+
+	kinds[kind] += delta
+	if kinds[kind] <= 0 {
+		delete(kinds, kind)
+	}
+	if len(kinds) == 0 {
+		delete(g.counts, pluginID)
+	}
+}
+
+func (g *pluginGoroutines) get(pluginID string) map[string]int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	counts := make(map[string]int, len(g.counts[pluginID]))
+	for kind, count := range g.counts[pluginID] {
+		counts[kind] = count
+	}
+	return counts
+}
+
+// goPlugin runs fn in a goroutine of the given kind for the plugin with pluginID, which is counted in the runtime
+// stats and labeled with the plugin ID if profiling labels are enabled.
+func (m *Manager) goPlugin(ctx context.Context, pluginID string, kind string, fn func(ctx context.Context)) {
+	m.pluginGoroutines.add(pluginID, kind, 1)
+	go func() {
+		defer m.pluginGoroutines.add(pluginID, kind, -1)
+		m.withProfilingLabels(ctx, pluginID, fn)
+	}()
+}
+
+// withProfilingLabels runs fn with the plugin ID set as pprof label if profiling labels are enabled, so that CPU
+// and goroutine profiles can be attributed to plugins. Goroutines started by fn inherit the label.
+func (m *Manager) withProfilingLabels(ctx context.Context, pluginID string, fn func(ctx context.Context)) {
+	if !m.Cfg.PluginsProfilingLabels {
+		fn(ctx)
+		return
+	}
+
+	pprof.Do(ctx, pprof.Labels(pprofPluginIDLabel, pluginID), fn)
+}
+
+// labeledPluginRequest runs fn in a span around a plugin request, labeled with the plugin ID if profiling labels
+// are enabled.
+func (m *Manager) labeledPluginRequest(ctx context.Context, pluginID string, endpoint string,
+	fn func(ctx context.Context) error) error {
+	var err error
+	m.withProfilingLabels(ctx, pluginID, func(ctx context.Context) {
+		err = tracePluginRequest(ctx, pluginID, endpoint, fn)
+	})
+	return err
+}
+
+// RuntimeStats returns the runtime stats of the registered backend plugins, sorted by plugin ID.
+func (m *Manager) RuntimeStats() backendplugin.RuntimeStats {
+	m.pluginsMu.RLock()
+	pluginIDs := make([]string, 0, len(m.plugins))
+	for pluginID := range m.plugins {
+		pluginIDs = append(pluginIDs, pluginID)
+	}
+	m.pluginsMu.RUnlock()
+	sort.Strings(pluginIDs)
+
+	stats := backendplugin.RuntimeStats{
+		Goroutines:      runtime.NumGoroutine(),
+		ProfilingLabels: m.Cfg.PluginsProfilingLabels,
+		Plugins:         make([]backendplugin.PluginRuntimeStats, 0, len(pluginIDs)),
+	}
+	for _, pluginID := range pluginIDs {
+		stats.Plugins = append(stats.Plugins, backendplugin.PluginRuntimeStats{
+			PluginID:         pluginID,
+			InFlightRequests: m.pluginRequests.count(pluginID),
+			Goroutines:       m.pluginGoroutines.get(pluginID),
+		})
+	}
+
+	return stats
+}
@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginGoroutines(t *testing.T) {
+	g := pluginGoroutines{}
+	g.add("test", goroutineQuery, 1)
+	g.add("test", goroutineQuery, 1)
+	g.add("test", goroutineRestartLoop, 1)
+	require.Equal(t, map[string]int{goroutineQuery: 2, goroutineRestartLoop: 1}, g.get("test"))
+
+	g.add("test", goroutineQuery, -2)
+	g.add("test", goroutineRestartLoop, -1)
+	require.Empty(t, g.get("test"))
+	require.Empty(t, g.counts)
+}
+
+func TestRuntimeStats(t *testing.T) {
+	m := &Manager{
+		Cfg:     &setting.Cfg{PluginsProfilingLabels: true},
+		plugins: map[string]backendplugin.Plugin{"test": &testPlugin{pluginID: "test"}},
+	}
+
+	release := make(chan struct{})
+	label := make(chan string, 1)
+	m.goPlugin(context.Background(), "test", goroutineQuery, func(ctx context.Context) {
+		value, _ := pprof.Label(ctx, pprofPluginIDLabel)
+		label <- value
+		<-release
+	})
+	end := m.pluginRequests.begin("test")
+
+	require.Equal(t, "test", <-label)
+	stats := m.RuntimeStats()
+	require.True(t, stats.ProfilingLabels)
+	require.Positive(t, stats.Goroutines)
+	require.Equal(t, []backendplugin.PluginRuntimeStats{{
+		PluginID:         "test",
+		InFlightRequests: 1,
+		Goroutines:       map[string]int{goroutineQuery: 1},
+	}}, stats.Plugins)
+
+	close(release)
+	end()
+	require.Eventually(t, func() bool {
+		return len(m.RuntimeStats().Plugins[0].Goroutines) == 0
+	}, time.Second, 10*time.Millisecond)
+	require.Zero(t, m.RuntimeStats().Plugins[0].InFlightRequests)
+}
@@ -0,0 +1,59 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var errPluginPanicked = errors.New("plugin request panicked")
+
+var pluginRequestPanics *prometheus.CounterVec
+
+func init() {
+	pluginRequestPanics = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "plugin_request_panics_total",
+		Help:      "The total amount of plugin requests recovered from a panic",
+	}, []string{"plugin_id", "endpoint"})
+
+	prometheus.MustRegister(pluginRequestPanics)
+}
+
+// recoverPluginPanic recovers from a panic while dispatching a request to plugin p, for example on a malformed
+// plugin response, logging it with its stack trace and setting err to an error wrapping errPluginPanicked, so
+// the request fails instead of the goroutine crashing Grafana. It must be deferred directly.
+func (m *Manager) recoverPluginPanic(p backendplugin.Plugin, endpoint string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	p.Logger().Error("Plugin request panicked", "pluginId", p.PluginID(), "endpoint", endpoint, "panic", r,
+		"stack", string(debug.Stack()))
+	pluginRequestPanics.WithLabelValues(p.PluginID(), endpoint).Inc()
+	*err = fmt.Errorf("%w: %v", errPluginPanicked, r)
+}
+
+// callPluginResource calls a resource of plugin p, recovering from panics.
+func (m *Manager) callPluginResource(ctx context.Context, p backendplugin.Plugin, req *backend.CallResourceRequest,
+	sender backend.CallResourceResponseSender) (err error) {
+	defer m.recoverPluginPanic(p, "callResource", &err)
+
+	return p.CallResource(ctx, req, sender)
+}
+
+// queryPluginData queries data of plugin p, recovering from panics. The call is passed ctx and is waited for, so
+// that it keeps holding the query slots of the request until the plugin returns after the request was canceled
+// or timed out.
+func (m *Manager) queryPluginData(ctx context.Context, p backendplugin.Plugin,
+	req *backend.QueryDataRequest) (resp *backend.QueryDataResponse, err error) {
+	defer m.recoverPluginPanic(p, "queryData", &err)
+
+	return p.QueryData(ctx, req)
+}
@@ -0,0 +1,57 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_RecoversPluginPanics(t *testing.T) {
+	m, p := newStreamTestManager(nil)
+	m.PluginRequestValidator = &testPluginRequestValidator{}
+	p.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+		panic("malformed query response")
+	}
+	p.CheckHealthHandlerFunc = func(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+		panic("malformed health check response")
+	}
+	p.CollectMetricsHandlerFunc = func(ctx context.Context) (*backend.CollectMetricsResult, error) {
+		panic("malformed metrics")
+	}
+	p.CallResourceHandlerFunc = func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+		panic("malformed resource response")
+	}
+	pCtx := backend.PluginContext{PluginID: "test"}
+	t.Cleanup(func() {
+		for _, endpoint := range []string{"queryData", "checkHealth", "collectMetrics", "callResource"} {
+			pluginRequestPanics.DeleteLabelValues("test", endpoint)
+		}
+	})
+
+	t.Run("Should recover from panics of data queries", func(t *testing.T) {
+		_, err := m.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: pCtx})
+		require.ErrorIs(t, err, errPluginPanicked)
+		require.Contains(t, err.Error(), "malformed query response")
+	})
+
+	t.Run("Should recover from panics of health checks", func(t *testing.T) {
+		_, err := m.CheckHealth(context.Background(), pCtx)
+		require.ErrorIs(t, err, backendplugin.ErrHealthCheckFailed)
+	})
+
+	t.Run("Should recover from panics of metrics collection", func(t *testing.T) {
+		_, err := m.CollectMetrics(context.Background(), "test")
+		require.ErrorIs(t, err, errPluginPanicked)
+	})
+
+	t.Run("Should recover from panics of resource calls", func(t *testing.T) {
+		err := m.callResourceInternal(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), pCtx)
+		require.ErrorIs(t, err, errPluginPanicked)
+		require.Equal(t, backendplugin.ErrorCodeInternal, backendplugin.ErrorCodeOf(err))
+	})
+}
@@ -0,0 +1,75 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// ReloadPlugin re-reads the settings of a registered managed backend plugin from the configuration and replaces
+// the plugin with a new instance created with them, so that changed settings are applied without restarting
+// Grafana. The previous instance and the isolated instances of the plugin are stopped, the latter being
+// recreated with the new settings when next used.
+func (m *Manager) ReloadPlugin(ctx context.Context, pluginID string) error {
+	p, registered := m.Get(pluginID)
+	if !registered {
+		return backendplugin.ErrPluginNotRegistered
+	}
+	pluginID = p.PluginID()
+
+	if !p.IsManaged() {
+		return backendplugin.ErrPluginNotReloadable
+	}
+
+	if _, err := m.Cfg.ReloadPluginSettings(pluginID); err != nil {
+		return fmt.Errorf("failed to reload settings of backend plugin %s: %w", pluginID, err)
+	}
+	env := m.pluginEnv(pluginID)
+
+	unlock := m.pluginStartLocks.lock(pluginID)
+	defer unlock()
+
+	m.pluginsMu.Lock()
+	registration, exists := m.registrations[pluginID]
+	if !exists || m.plugins[pluginID] != p {
+		m.pluginsMu.Unlock()
+		return backendplugin.ErrPluginNotRegistered
+	}
+
+	reloaded, err := m.newPlugin(registration.factory, pluginID, registration.logger, env)
+	if err != nil {
+		m.pluginsMu.Unlock()
+		return fmt.Errorf("failed to create backend plugin %s: %w", pluginID, err)
+	}
+
+	previous := []backendplugin.Plugin{p}
+	for key, isolated := range m.isolatedInstances(pluginID) {
+		previous = append(previous, isolated)
+		delete(m.isolatedPlugins, key)
+	}
+	m.plugins[pluginID] = reloaded
+	m.resetPluginFailure(pluginID)
+	registration.env = env
+	m.registrations[pluginID] = registration
+	m.pluginsMu.Unlock()
+
+	p.Logger().Info("Reloading plugin")
+	for _, instance := range previous {
+		if err := instance.Decommission(); err != nil {
+			instance.Logger().Warn("Failed to decommission plugin", "error", err)
+		}
+		if err := instance.Stop(ctx); err != nil {
+			instance.Logger().Warn("Failed to stop plugin", "error", err)
+		}
+	}
+
+	// the new instance is watched for killed processes beyond the reload request
+	if err := m.startPluginAndRestartKilledProcesses(context.Background(), reloaded); err != nil {
+		return err
+	}
+	m.forwardLogLevel(ctx, reloaded)
+	reloaded.Logger().Info("Plugin reloaded")
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ReloadPlugin(t *testing.T) {
+	newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+		ctx.cfg.PluginSettings = map[string]map[string]string{
+			testPluginID: {"foo": "bar"},
+		}
+		err := ctx.manager.RegisterAndStart(context.Background(), testPluginID, ctx.factory)
+		require.NoError(t, err)
+		require.Contains(t, ctx.env, "GF_PLUGIN_FOO=bar")
+
+		previous := ctx.plugin
+		isolated := &testPlugin{pluginID: testPluginID, logger: previous.logger, managed: true}
+		ctx.manager.isolatedPlugins = map[string]backendplugin.Plugin{testPluginID + "/a": isolated}
+
+		err = ctx.manager.ReloadPlugin(context.Background(), testPluginID)
+		require.NoError(t, err)
+
+		t.Run("Should replace plugin with instance using reloaded settings", func(t *testing.T) {
+			require.NotSame(t, previous, ctx.plugin)
+			require.NotContains(t, ctx.env, "GF_PLUGIN_FOO=bar")
+			require.Empty(t, ctx.cfg.PluginSettingsFor(testPluginID))
+			require.Equal(t, 1, ctx.plugin.startCount)
+
+			p, exists := ctx.manager.Get(testPluginID)
+			require.True(t, exists)
+			require.Same(t, ctx.plugin, p)
+		})
+
+		t.Run("Should stop previous and isolated instances", func(t *testing.T) {
+			require.True(t, previous.IsDecommissioned())
+			require.Equal(t, 1, previous.stopCount)
+			require.True(t, isolated.IsDecommissioned())
+			require.Equal(t, 1, isolated.stopCount)
+			require.Empty(t, ctx.manager.isolatedPlugins)
+		})
+
+		t.Run("Should return plugin not registered error for unknown plugin", func(t *testing.T) {
+			err := ctx.manager.ReloadPlugin(context.Background(), "unknown")
+			require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+		})
+	})
+
+	newManagerScenario(t, false, func(t *testing.T, ctx *managerScenarioCtx) {
+		err := ctx.manager.Register(testPluginID, ctx.factory)
+		require.NoError(t, err)
+
+		t.Run("Should not reload unmanaged plugin", func(t *testing.T) {
+			err := ctx.manager.ReloadPlugin(context.Background(), testPluginID)
+			require.ErrorIs(t, err, backendplugin.ErrPluginNotReloadable)
+			require.Zero(t, ctx.plugin.stopCount)
+		})
+	})
+}
@@ -0,0 +1,27 @@
+package manager
+
+import (
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/grpcplugin"
+)
+
+// remotePluginFactory returns the factory of a plugin registered with factory, which connects to the plugin running
+// outside of Grafana if the plugin has a remote address instead of starting its process. Plugins that aren't
+// managed by Grafana, such as renderers, keep running with factory.
+func (m *Manager) remotePluginFactory(pluginID string, factory backendplugin.PluginFactoryFunc) backendplugin.PluginFactoryFunc {
+	address, exists := m.Cfg.PluginRemoteAddress(pluginID)
+	if !exists {
+		return factory
+	}
+
+	return func(pluginID string, logger log.Logger, env []string) (backendplugin.Plugin, error) {
+		p, err := factory(pluginID, logger, env)
+		if err != nil || !p.IsManaged() {
+			return p, err
+		}
+
+		logger.Info("Using remote plugin", "address", address)
+		return grpcplugin.NewRemoteBackendPlugin(pluginID, address)(pluginID, logger, env)
+	}
+}
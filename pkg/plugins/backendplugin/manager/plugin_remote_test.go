@@ -0,0 +1,41 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_RemotePluginFactory(t *testing.T) {
+	newFactory := func(managed bool) backendplugin.PluginFactoryFunc {
+		return func(pluginID string, logger log.Logger, env []string) (backendplugin.Plugin, error) {
+			return &testPlugin{pluginID: pluginID, logger: logger, managed: managed}, nil
+		}
+	}
+	m := &Manager{Cfg: &setting.Cfg{PluginSettings: setting.PluginSettings{
+		"remote": map[string]string{"remote_address": "plugin:10001"},
+	}}}
+
+	t.Run("Should start plugins without remote address", func(t *testing.T) {
+		p, err := m.remotePluginFactory("local", newFactory(true))("local", log.New("test"), nil)
+		require.NoError(t, err)
+		require.IsType(t, &testPlugin{}, p)
+	})
+
+	t.Run("Should connect to plugins with remote address", func(t *testing.T) {
+		p, err := m.remotePluginFactory("remote", newFactory(true))("remote", log.New("test"), nil)
+		require.NoError(t, err)
+		require.Equal(t, "remote", p.PluginID())
+		_, isTestPlugin := p.(*testPlugin)
+		require.False(t, isTestPlugin)
+	})
+
+	t.Run("Should start unmanaged plugins with remote address", func(t *testing.T) {
+		p, err := m.remotePluginFactory("remote", newFactory(false))("remote", log.New("test"), nil)
+		require.NoError(t, err)
+		require.IsType(t, &testPlugin{}, p)
+	})
+}
@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// pluginStartLocks serializes starting the process of a plugin, so a plugin being restarted isn't started again
+// by the watcher restarting killed processes. The zero value is ready to use.
+type pluginStartLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock locks starting the process of a plugin, returning a function unlocking it.
+func (l *pluginStartLocks) lock(pluginID string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*sync.Mutex{}
+	}
+	lock, exists := l.locks[pluginID]
+	if !exists {
+		lock = &sync.Mutex{}
+		l.locks[pluginID] = lock
+	}
+	l.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// RestartPlugin stops and starts the process of a registered managed backend plugin and of its isolated
+// instances. Core plugins, running in the Grafana process, can't be restarted.
+func (m *Manager) RestartPlugin(ctx context.Context, pluginID string) error {
+	p, registered := m.Get(pluginID)
+	if !registered {
+		return backendplugin.ErrPluginNotRegistered
+	}
+
+	if _, ok := p.(backendplugin.ProcessPlugin); !ok || !p.IsManaged() {
+		return backendplugin.ErrPluginNotRestartable
+	}
+
+	m.pluginsMu.RLock()
+	plugins := []backendplugin.Plugin{p}
+	for _, isolated := range m.isolatedInstances(p.PluginID()) {
+		plugins = append(plugins, isolated)
+	}
+	m.pluginsMu.RUnlock()
+
+	m.resetPluginFailure(p.PluginID())
+	for _, p := range plugins {
+		if err := m.restartPlugin(ctx, p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) restartPlugin(ctx context.Context, p backendplugin.Plugin) error {
+	unlock := m.pluginStartLocks.lock(p.PluginID())
+	defer unlock()
+
+	p.Logger().Info("Restarting plugin process")
+	if err := p.Stop(ctx); err != nil {
+		return err
+	}
+	if err := m.startPlugin(ctx, p); err != nil {
+		return err
+	}
+	p.Logger().Info("Plugin process restarted")
+	m.forwardLogLevel(ctx, p)
+
+	return nil
+}
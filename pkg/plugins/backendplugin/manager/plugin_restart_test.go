@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_RestartPlugin(t *testing.T) {
+	newPlugin := func(pluginID string, managed bool) *testPlugin {
+		return &testPlugin{pluginID: pluginID, managed: managed, logger: log.New("test")}
+	}
+
+	processPlugin := newPlugin("process", true)
+	isolatedPlugin := newPlugin("process", true)
+	unmanagedPlugin := newPlugin("unmanaged", false)
+	corePlugin := newPlugin("core", true)
+	m := &Manager{
+		Cfg:    setting.NewCfg(),
+		logger: log.New("test"),
+		plugins: map[string]backendplugin.Plugin{
+			"process":   &testOutputPlugin{testPlugin: processPlugin},
+			"unmanaged": &testOutputPlugin{testPlugin: unmanagedPlugin},
+			"core":      corePlugin,
+		},
+		isolatedPlugins: map[string]backendplugin.Plugin{
+			"process/a": &testOutputPlugin{testPlugin: isolatedPlugin},
+		},
+	}
+
+	t.Run("Should restart plugin process and isolated instances", func(t *testing.T) {
+		err := m.RestartPlugin(context.Background(), "process")
+		require.NoError(t, err)
+		require.Equal(t, 1, processPlugin.stopCount)
+		require.Equal(t, 1, processPlugin.startCount)
+		require.Equal(t, 1, isolatedPlugin.stopCount)
+		require.Equal(t, 1, isolatedPlugin.startCount)
+
+		startedAt, _ := m.pluginProcesses.get("process")
+		require.False(t, startedAt.IsZero())
+		total, _, _ := m.pluginRestarts.get("process", startedAt)
+		require.Zero(t, total, "manual restarts shouldn't count as crashes")
+	})
+
+	t.Run("Should not restart core or unmanaged plugins", func(t *testing.T) {
+		err := m.RestartPlugin(context.Background(), "core")
+		require.ErrorIs(t, err, backendplugin.ErrPluginNotRestartable)
+		require.Zero(t, corePlugin.stopCount)
+
+		err = m.RestartPlugin(context.Background(), "unmanaged")
+		require.ErrorIs(t, err, backendplugin.ErrPluginNotRestartable)
+		require.Zero(t, unmanagedPlugin.stopCount)
+	})
+
+	t.Run("Should return plugin not registered error for unknown plugin", func(t *testing.T) {
+		err := m.RestartPlugin(context.Background(), "unknown")
+		require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+	})
+}
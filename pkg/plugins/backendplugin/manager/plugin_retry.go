@@ -0,0 +1,51 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// pluginRestartPollInterval is how often a plugin process is checked while waiting for it to be restarted.
+const pluginRestartPollInterval = 100 * time.Millisecond
+
+// waitForPluginRestart waits for the process of an unavailable plugin to be brought back by the restart loop, for
+// at most the configured query retry timeout. It reports whether the plugin is running again.
+func (m *Manager) waitForPluginRestart(ctx context.Context, p backendplugin.Plugin) bool {
+	maxWait := getPluginIntSetting(p.PluginID(), "query_retry_timeout", m.Cfg, m.Cfg.PluginsQueryRetryTimeout)
+	if maxWait <= 0 {
+		return false
+	}
+
+	return waitForPluginProcess(ctx, p, time.Duration(maxWait)*time.Second)
+}
+
+// waitForPluginProcess waits for the process of plugin p to be running, for at most maxWait. It reports whether
+// the plugin is running.
+func waitForPluginProcess(ctx context.Context, p backendplugin.Plugin, maxWait time.Duration) bool {
+	if p.IsDecommissioned() {
+		return false
+	}
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	ticker := time.NewTicker(pluginRestartPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			return false
+		case <-ticker.C:
+			if p.IsDecommissioned() {
+				return false
+			}
+			if !p.Exited() {
+				return true
+			}
+		}
+	}
+}
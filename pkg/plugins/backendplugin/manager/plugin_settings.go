@@ -3,9 +3,11 @@ package manager
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 type pluginSettings map[string]string
@@ -28,7 +30,7 @@ func (ps pluginSettings) ToEnv(prefix string, hostEnv []string) []string {
 
 func getPluginSettings(plugID string, cfg *setting.Cfg) pluginSettings {
 	ps := pluginSettings{}
-	for k, v := range cfg.PluginSettings[plugID] {
+	for k, v := range cfg.PluginSettingsFor(plugID) {
 		if k == "path" || strings.ToLower(k) == "id" {
 			continue
 		}
@@ -38,3 +40,41 @@ func getPluginSettings(plugID string, cfg *setting.Cfg) pluginSettings {
 
 	return ps
 }
+
+// getPluginIntSetting returns the integer value of a setting configured for a plugin, falling back to def
+// when the setting is missing or invalid.
+func getPluginIntSetting(plugID string, key string, cfg *setting.Cfg, def int) int {
+	value, exists := cfg.PluginSettingsFor(plugID)[key]
+	if !exists || value == "" {
+		return def
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return def
+	}
+
+	return i
+}
+
+// getPluginStringSetting returns the value of a setting configured for a plugin, falling back to def when the
+// setting is missing or empty.
+func getPluginStringSetting(plugID string, key string, cfg *setting.Cfg, def string) string {
+	value, exists := cfg.PluginSettingsFor(plugID)[key]
+	if !exists || value == "" {
+		return def
+	}
+
+	return value
+}
+
+// getPluginStringListSetting returns the comma or space separated values of a setting configured for a plugin,
+// falling back to def when the setting is missing.
+func getPluginStringListSetting(plugID string, key string, cfg *setting.Cfg, def []string) []string {
+	value, exists := cfg.PluginSettingsFor(plugID)[key]
+	if !exists {
+		return def
+	}
+
+	return util.SplitString(value)
+}
@@ -94,3 +94,39 @@ func TestPluginSettings(t *testing.T) {
 		})
 	})
 }
+
+func TestManager_PluginEnvProxy(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.PluginSettings = setting.PluginSettings{
+		"proxied": map[string]string{"proxy_url": "http://proxy:3128", "no_proxy": "localhost,.internal"},
+	}
+	m := &Manager{Cfg: cfg, License: &testLicensingService{}}
+
+	env := m.pluginEnv("proxied")
+	require.Subset(t, env, []string{
+		"HTTP_PROXY=http://proxy:3128",
+		"HTTPS_PROXY=http://proxy:3128",
+		"NO_PROXY=localhost,.internal",
+	})
+
+	for _, variable := range m.pluginEnv("direct") {
+		require.NotContains(t, variable, "PROXY=")
+	}
+}
+
+func TestManager_PluginEnvFeatureToggles(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.BuildVersion = "8.2.0"
+	m := &Manager{Cfg: cfg, License: &testLicensingService{edition: "Open Source"}}
+
+	for _, variable := range m.pluginEnv("test") {
+		require.NotContains(t, variable, "GF_FEATURE_TOGGLES=")
+	}
+
+	cfg.FeatureToggles = map[string]bool{"ngalert": true, "live-config": true, "disabled": false}
+	require.Subset(t, m.pluginEnv("test"), []string{
+		"GF_VERSION=8.2.0",
+		"GF_EDITION=Open Source",
+		"GF_FEATURE_TOGGLES=live-config,ngalert",
+	})
+}
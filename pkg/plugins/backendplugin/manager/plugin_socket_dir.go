@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// maxUnixSocketPathLength is the maximum length of unix socket paths on macOS, the shortest of the supported
+// platforms.
+const maxUnixSocketPathLength = 104
+
+// pluginSocketNameLength is the length of the names of the unix sockets plugins create, a separator and "plugin"
+// followed by a random number of up to 10 digits.
+const pluginSocketNameLength = 1 + len("plugin") + 10
+
+// unixSocketsSupported is whether plugins can listen on unix sockets. Plugins on Windows listen on TCP loopback
+// ports.
+var unixSocketsSupported = runtime.GOOS != "windows"
+
+// pluginSocketPath returns the directory the plugin with pluginID creates its unix socket in, or an empty string if
+// it creates it in its temporary directory.
+func (m *Manager) pluginSocketPath(pluginID string) string {
+	if m.Cfg.PluginsUnixSocketDir == "" || !unixSocketsSupported {
+		return ""
+	}
+	return filepath.Join(m.Cfg.PluginsUnixSocketDir, pluginID)
+}
+
+// preparePluginSocketDirectory creates an empty unix socket directory for a plugin, removing the sockets left behind
+// by plugin processes that crashed.
+func (m *Manager) preparePluginSocketDirectory(pluginID string) error {
+	socketPath := m.pluginSocketPath(pluginID)
+	if socketPath == "" {
+		return nil
+	}
+
+	if len(socketPath)+pluginSocketNameLength > maxUnixSocketPathLength {
+		return fmt.Errorf("unix socket directory of backend plugin %s is too long, unix socket paths are limited to %d characters: %s",
+			pluginID, maxUnixSocketPathLength, socketPath)
+	}
+	if err := os.RemoveAll(socketPath); err != nil {
+		return fmt.Errorf("failed to empty unix socket directory of backend plugin %s: %w", pluginID, err)
+	}
+	// only Grafana, running as the same user as its plugins, connects to the sockets
+	if err := os.MkdirAll(socketPath, 0700); err != nil {
+		return fmt.Errorf("failed to create unix socket directory of backend plugin %s: %w", pluginID, err)
+	}
+
+	return nil
+}
+
+// removePluginSocketDirectory removes the unix socket directory of a plugin that is no longer running.
+func (m *Manager) removePluginSocketDirectory(pluginID string) {
+	socketPath := m.pluginSocketPath(pluginID)
+	if socketPath == "" {
+		return
+	}
+
+	if err := os.RemoveAll(socketPath); err != nil {
+		m.logger.Warn("Failed to remove plugin unix socket directory", "pluginId", pluginID, "error", err)
+	}
+}
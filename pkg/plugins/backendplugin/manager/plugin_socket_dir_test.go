@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_PluginSocketDirectory(t *testing.T) {
+	if !unixSocketsSupported {
+		t.Skip("unix sockets aren't supported on this platform")
+	}
+
+	newManager := func(t *testing.T) *Manager {
+		cfg := setting.NewCfg()
+		cfg.PluginsUnixSocketDir = t.TempDir()
+		return &Manager{Cfg: cfg, License: &testLicensingService{}, logger: log.New("test")}
+	}
+
+	t.Run("Should create an empty socket directory", func(t *testing.T) {
+		m := newManager(t)
+		socketPath := filepath.Join(m.Cfg.PluginsUnixSocketDir, "test")
+
+		require.NoError(t, m.preparePluginSocketDirectory("test"))
+		require.NoError(t, os.WriteFile(filepath.Join(socketPath, "plugin123"), nil, 0600))
+
+		require.NoError(t, m.preparePluginSocketDirectory("test"))
+		require.NoFileExists(t, filepath.Join(socketPath, "plugin123"))
+		require.DirExists(t, socketPath)
+
+		require.Subset(t, m.pluginEnv("test"), []string{
+			"PLUGIN_UNIX_SOCKET_DIR=" + socketPath,
+			"TMPDIR=" + socketPath,
+		})
+
+		m.removePluginSocketDirectory("test")
+		require.NoDirExists(t, socketPath)
+	})
+
+	t.Run("Should use the socket directory as temporary directory", func(t *testing.T) {
+		m := newManager(t)
+		m.Cfg.PluginsDataDirectory = t.TempDir()
+		socketPath := filepath.Join(m.Cfg.PluginsUnixSocketDir, "test")
+
+		env := m.pluginEnv("test")
+		require.Subset(t, env, []string{
+			"GF_PLUGIN_DATA_DIR=" + filepath.Join(m.Cfg.PluginsDataDirectory, "test", "data"),
+			"TMPDIR=" + socketPath,
+		})
+		require.NotContains(t, env, "TMPDIR="+filepath.Join(m.Cfg.PluginsDataDirectory, "test", "tmp"))
+	})
+
+	t.Run("Should reject socket directories too long for unix sockets", func(t *testing.T) {
+		m := newManager(t)
+		m.Cfg.PluginsUnixSocketDir = filepath.Join(m.Cfg.PluginsUnixSocketDir, strings.Repeat("a", maxUnixSocketPathLength))
+
+		require.Error(t, m.preparePluginSocketDirectory("test"))
+	})
+}
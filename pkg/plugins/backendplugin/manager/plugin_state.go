@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+const (
+	// crashLoopRestarts is the number of restarts within crashLoopWindow after which a plugin is considered
+	// crash-looping.
+	crashLoopRestarts = 3
+	crashLoopWindow   = 5 * time.Minute
+)
+
+// pluginRestarts tracks the restarts of plugin processes. The zero value is ready to use.
+type pluginRestarts struct {
+	mu       sync.Mutex
+	restarts map[string][]time.Time
+	counts   map[string]int
+}
+
+func (r *pluginRestarts) record(pluginID string, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.restarts == nil {
+		r.restarts = map[string][]time.Time{}
+		r.counts = map[string]int{}
+	}
+	r.restarts[pluginID] = append(recentRestarts(r.restarts[pluginID], now), now)
+	r.counts[pluginID]++
+}
+
+// reset forgets the recent restarts of a plugin, keeping its total number of restarts.
+func (r *pluginRestarts) reset(pluginID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.restarts, pluginID)
+}
+
+// get returns the total number of restarts of a plugin, the number of restarts within crashLoopWindow and the
+// time of the last restart.
+func (r *pluginRestarts) get(pluginID string, now time.Time) (int, int, time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	restarts := r.restarts[pluginID]
+	if len(restarts) == 0 {
+		return r.counts[pluginID], 0, time.Time{}
+	}
+
+	return r.counts[pluginID], len(recentRestarts(restarts, now)), restarts[len(restarts)-1]
+}
+
+// recentRestarts returns the restarts within crashLoopWindow of now.
+func recentRestarts(restarts []time.Time, now time.Time) []time.Time {
+	for i, t := range restarts {
+		if now.Sub(t) <= crashLoopWindow {
+			return restarts[i:]
+		}
+	}
+	return nil
+}
+
+// pluginProcesses tracks when plugin processes were started and why they last failed to start. The zero value is
+// ready to use.
+type pluginProcesses struct {
+	mu         sync.Mutex
+	startedAt  map[string]time.Time
+	lastErrors map[string]string
+}
+
+func (p *pluginProcesses) started(pluginID string, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.startedAt == nil {
+		p.startedAt = map[string]time.Time{}
+	}
+	p.startedAt[pluginID] = now
+}
+
+func (p *pluginProcesses) failed(pluginID string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.lastErrors == nil {
+		p.lastErrors = map[string]string{}
+	}
+	p.lastErrors[pluginID] = err.Error()
+}
+
+// get returns when a plugin process was last started and the error of its last failed start.
+func (p *pluginProcesses) get(pluginID string) (time.Time, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.startedAt[pluginID], p.lastErrors[pluginID]
+}
+
+// startPlugin starts the process of plugin p, keeping track of when it started or why it failed to.
+func (m *Manager) startPlugin(ctx context.Context, p backendplugin.Plugin) error {
+	if err := p.Start(ctx); err != nil {
+		m.pluginProcesses.failed(p.PluginID(), err)
+		return err
+	}
+
+	m.pluginProcesses.started(p.PluginID(), time.Now())
+	m.recordPluginProcess(p)
+	m.placePluginProcess(p)
+	return nil
+}
+
+// PluginStates returns the process state of all registered backend plugins, sorted by plugin ID.
+func (m *Manager) PluginStates() []backendplugin.PluginState {
+	m.pluginsMu.RLock()
+	plugins := make([]backendplugin.Plugin, 0, len(m.plugins))
+	for _, p := range m.plugins {
+		plugins = append(plugins, p)
+	}
+	m.pluginsMu.RUnlock()
+
+	now := time.Now()
+	states := make([]backendplugin.PluginState, 0, len(plugins))
+	for _, p := range plugins {
+		total, recent, last := m.pluginRestarts.get(p.PluginID(), now)
+		startedAt, lastError := m.pluginProcesses.get(p.PluginID())
+		state := backendplugin.PluginState{
+			PluginID:    p.PluginID(),
+			Managed:     p.IsManaged(),
+			Restarts:    total,
+			LastRestart: last,
+			StartedAt:   startedAt,
+			LastError:   lastError,
+		}
+
+		state.Status = m.pluginStatus(p, startedAt, recent)
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].PluginID < states[j].PluginID
+	})
+
+	return states
+}
+
+// pluginStatus returns the process status of plugin p, given when its process was last started and its number of
+// recent restarts, within crashLoopWindow.
+func (m *Manager) pluginStatus(p backendplugin.Plugin, startedAt time.Time, recent int) backendplugin.PluginStatus {
+	switch {
+	case p.IsDecommissioned():
+		return backendplugin.PluginStatusDecommissioned
+	case m.pluginFailures.isFailed(p.PluginID()):
+		return backendplugin.PluginStatusFailed
+	case recent >= crashLoopRestarts:
+		return backendplugin.PluginStatusCrashLooping
+	case p.Exited() && startedAt.IsZero():
+		return backendplugin.PluginStatusNotStarted
+	case p.Exited():
+		return backendplugin.PluginStatusDegraded
+	default:
+		return backendplugin.PluginStatusRunning
+	}
+}
@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginRestarts(t *testing.T) {
+	r := pluginRestarts{}
+	now := time.Now()
+
+	total, recent, last := r.get("test", now)
+	require.Zero(t, total)
+	require.Zero(t, recent)
+	require.True(t, last.IsZero())
+
+	r.record("test", now.Add(-2*crashLoopWindow))
+	r.record("test", now.Add(-time.Minute))
+	r.record("test", now)
+
+	total, recent, last = r.get("test", now)
+	require.Equal(t, 3, total)
+	require.Equal(t, 2, recent)
+	require.Equal(t, now, last)
+}
+
+func TestManager_PluginStates(t *testing.T) {
+	m := &Manager{
+		plugins: map[string]backendplugin.Plugin{
+			"running":        &testPlugin{pluginID: "running", managed: true, logger: log.New("test")},
+			"not-started":    &testPlugin{pluginID: "not-started", managed: true, exited: true, logger: log.New("test")},
+			"exited":         &testPlugin{pluginID: "exited", managed: true, exited: true, logger: log.New("test")},
+			"restarted":      &testPlugin{pluginID: "restarted", managed: true, logger: log.New("test")},
+			"crashing":       &testPlugin{pluginID: "crashing", managed: true, logger: log.New("test")},
+			"decommissioned": &testPlugin{pluginID: "decommissioned", decommissioned: true, logger: log.New("test")},
+		},
+	}
+	for i := 0; i < crashLoopRestarts; i++ {
+		m.pluginRestarts.record("crashing", time.Now())
+	}
+	m.pluginProcesses.started("exited", time.Now().Add(-time.Hour))
+	m.pluginRestarts.record("restarted", time.Now())
+
+	states := m.PluginStates()
+	require.Len(t, states, 6)
+	statuses := map[string]backendplugin.PluginStatus{}
+	for _, state := range states {
+		statuses[state.PluginID] = state.Status
+	}
+	require.Equal(t, map[string]backendplugin.PluginStatus{
+		"running":        backendplugin.PluginStatusRunning,
+		"not-started":    backendplugin.PluginStatusNotStarted,
+		"exited":         backendplugin.PluginStatusDegraded,
+		"restarted":      backendplugin.PluginStatusRunning,
+		"crashing":       backendplugin.PluginStatusCrashLooping,
+		"decommissioned": backendplugin.PluginStatusDecommissioned,
+	}, statuses)
+
+	require.Equal(t, "crashing", states[0].PluginID)
+	require.Equal(t, crashLoopRestarts, states[0].Restarts)
+	require.False(t, states[0].LastRestart.IsZero())
+	require.Equal(t, "running", states[5].PluginID)
+	require.True(t, states[5].LastRestart.IsZero())
+}
+
+type failingStartPlugin struct {
+	*testPlugin
+}
+
+func (p *failingStartPlugin) Start(ctx context.Context) error {
+	return errors.New("failed to start")
+}
+
+func TestManager_StartPluginTracksProcessState(t *testing.T) {
+	m := &Manager{}
+
+	ok := &testPlugin{pluginID: "ok", logger: log.New("test")}
+	require.NoError(t, m.startPlugin(context.Background(), ok))
+	startedAt, lastError := m.pluginProcesses.get("ok")
+	require.False(t, startedAt.IsZero())
+	require.Empty(t, lastError)
+
+	failing := &failingStartPlugin{testPlugin: &testPlugin{pluginID: "failing", logger: log.New("test")}}
+	require.Error(t, m.startPlugin(context.Background(), failing))
+	startedAt, lastError = m.pluginProcesses.get("failing")
+	require.True(t, startedAt.IsZero())
+	require.Equal(t, "failed to start", lastError)
+}
@@ -0,0 +1,384 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// goroutineStream is the kind of the goroutines running the streams of plugins.
+const goroutineStream = "stream"
+
+// pluginStream is a running stream of a plugin on a channel, fanning out its packets to all subscribers.
+type pluginStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	// done is closed when the plugin ends the stream, err being the error it ended with.
+	done     chan struct{}
+	err      error
+	pluginID string
+	limiter  *streamRateLimiter
+	replay   *streamReplayBuffer
+
+	mu          sync.RWMutex
+	subscribers map[*streamSubscriber]struct{}
+	// seq is the sequence number of the last packet sent to the subscribers.
+	seq uint64
+}
+
+// Send sends a packet of the plugin to all subscribers of the stream and buffers it for replay, unless it exceeds
+// the rate limit of the stream.
+func (s *pluginStream) Send(packet *backend.StreamPacket) error {
+	pluginStreamMessages.WithLabelValues(s.pluginID).Inc()
+	now := time.Now()
+	if allowed, reason := s.limiter.allow(len(packet.Data), now); !allowed {
+		pluginStreamDroppedMessages.WithLabelValues(s.pluginID, reason).Inc()
+		return nil
+	}
+
+	s.mu.Lock()
+	s.seq++
+	s.replay.add(packet.Data, now)
+	subscribers := make([]*streamSubscriber, 0, len(s.subscribers))
+	for sub := range s.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	s.mu.Unlock()
+
+	s.deliver(subscribers, packet.Data)
+
+	return nil
+}
+
+// sequence returns the sequence number of the last packet sent to the subscribers of the stream.
+func (s *pluginStream) sequence() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.seq
+}
+
+// pluginStreams tracks the running streams of plugins by plugin ID and channel, so that there's a single stream
+// per channel shared by all its subscribers and the streams of a plugin can be stopped with it. The zero value is
+// ready to use.
+type pluginStreams struct {
+	mu      sync.Mutex
+	streams map[string]map[string]*pluginStream
+	// replays holds the replay buffers of the channels of plugins, which outlive their streams.
+	replays map[string]map[string]*streamReplayBuffer
+}
+
+// subscribe adds a subscriber to the stream of a plugin on a channel, creating the stream limited by limiter if it
+// isn't running, in which case it returns true and the caller must run it. The subscriber first receives the
+// messages of the replay buffer of the channel, which is replay unless the channel already has one.
+func (s *pluginStreams) subscribe(pluginID string, channel string, sub *streamSubscriber,
+	limiter *streamRateLimiter, replay *streamReplayBuffer) (*pluginStream, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.streams == nil {
+		s.streams = map[string]map[string]*pluginStream{}
+	}
+	now := time.Now()
+	s.pruneReplays(pluginID, now)
+	replay = s.replayBuffer(pluginID, channel, replay)
+	channels, exists := s.streams[pluginID]
+	if !exists {
+		channels = map[string]*pluginStream{}
+		s.streams[pluginID] = channels
+	}
+
+	stream, running := channels[channel]
+	if !running {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream = &pluginStream{
+			ctx:         ctx,
+			cancel:      cancel,
+			done:        make(chan struct{}),
+			pluginID:    pluginID,
+			limiter:     limiter,
+			replay:      replay,
+			subscribers: map[*streamSubscriber]struct{}{},
+		}
+		channels[channel] = stream
+	}
+
+	stream.mu.Lock()
+	for _, data := range stream.replay.recent(now) {
+		sub.enqueue(data)
+	}
+	stream.subscribers[sub] = struct{}{}
+	stream.mu.Unlock()
+	s.updateMetrics(pluginID)
+
+	return stream, !running
+}
+
+// unsubscribe removes a subscriber from the stream of a plugin on a channel, stopping the stream when it was the
+// last one.
+func (s *pluginStreams) unsubscribe(pluginID string, channel string, stream *pluginStream, sub *streamSubscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.updateMetrics(pluginID)
+
+	stream.mu.Lock()
+	delete(stream.subscribers, sub)
+	remaining := len(stream.subscribers)
+	stream.mu.Unlock()
+
+	if remaining > 0 {
+		return
+	}
+	stream.cancel()
+	s.remove(pluginID, channel, stream)
+}
+
+// end records that the plugin ended its stream on a channel with err.
+func (s *pluginStreams) end(pluginID string, channel string, stream *pluginStream, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(pluginID, channel, stream)
+	s.updateMetrics(pluginID)
+	stream.err = err
+	close(stream.done)
+}
+
+// remove stops tracking the stream of a plugin on a channel, unless it has already been replaced. The caller must
+// hold s.mu.
+func (s *pluginStreams) remove(pluginID string, channel string, stream *pluginStream) {
+	if s.streams[pluginID][channel] != stream {
+		return
+	}
+	delete(s.streams[pluginID], channel)
+	if len(s.streams[pluginID]) == 0 {
+		delete(s.streams, pluginID)
+	}
+}
+
+// replayBuffer returns the replay buffer of a channel of a plugin, tracking replay as its buffer if it has none.
+// The caller must hold s.mu.
+func (s *pluginStreams) replayBuffer(pluginID string, channel string, replay *streamReplayBuffer) *streamReplayBuffer {
+	if existing, exists := s.replays[pluginID][channel]; exists {
+		return existing
+	}
+	if replay == nil {
+		return nil
+	}
+
+	if s.replays == nil {
+		s.replays = map[string]map[string]*streamReplayBuffer{}
+	}
+	if s.replays[pluginID] == nil {
+		s.replays[pluginID] = map[string]*streamReplayBuffer{}
+	}
+	s.replays[pluginID][channel] = replay
+	return replay
+}
+
+// pruneReplays stops tracking the expired replay buffers of the channels of a plugin without running stream. The
+// caller must hold s.mu.
+func (s *pluginStreams) pruneReplays(pluginID string, now time.Time) {
+	for channel, replay := range s.replays[pluginID] {
+		if _, running := s.streams[pluginID][channel]; running || !replay.expired(now) {
+			continue
+		}
+		delete(s.replays[pluginID], channel)
+	}
+	if len(s.replays[pluginID]) == 0 {
+		delete(s.replays, pluginID)
+	}
+}
+
+// stop cancels all running streams of a plugin and drops the replay buffers of its channels.
+func (s *pluginStreams) stop(pluginID string) {
+	s.mu.Lock()
+	channels := s.streams[pluginID]
+	delete(s.streams, pluginID)
+	delete(s.replays, pluginID)
+	s.updateMetrics(pluginID)
+	s.mu.Unlock()
+
+	for _, stream := range channels {
+		stream.cancel()
+	}
+}
+
+// count returns the number of running streams of a plugin.
+func (s *pluginStreams) count(pluginID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.streams[pluginID])
+}
+
+// streamChannel returns the channel of a plugin stream, which is unique per organization, data source and path.
+func streamChannel(pCtx backend.PluginContext, path string) string {
+	var dsUID string
+	if pCtx.DataSourceInstanceSettings != nil {
+		dsUID = pCtx.DataSourceInstanceSettings.UID
+	}
+	return fmt.Sprintf("%d/%s/%s", pCtx.OrgID, dsUID, path)
+}
+
+// SubscribeStream asks a registered backend plugin whether a subscription to a stream channel is allowed, unless a
+// registered stream authorizer denies it.
+func (m *Manager) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	latest := *req
+	latest.PluginContext = m.latestInstanceSettings(req.PluginContext)
+	req = &latest
+	p, registered := m.getForContext(req.PluginContext)
+	if !registered {
+		return nil, backendplugin.ErrPluginNotRegistered
+	}
+
+	allowed, err := m.authorizeStream(ctx, req)
+	if err != nil {
+		return nil, errutil.Wrap("failed to authorize stream subscription", err)
+	}
+	if !allowed {
+		p.Logger().Debug("Stream subscription denied", "path", req.Path, "orgId", req.PluginContext.OrgID)
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusPermissionDenied}, nil
+	}
+	defer m.pluginRequests.begin(p.PluginID())()
+
+	var resp *backend.SubscribeStreamResponse
+	err = m.labeledPluginRequest(ctx, p.PluginID(), "subscribeStream", func(ctx context.Context) (innerErr error) {
+		resp, innerErr = p.SubscribeStream(ctx, req)
+		return
+	})
+	if err != nil {
+		return nil, wrapStreamError("failed to subscribe to stream", err)
+	}
+
+	return resp, nil
+}
+
+// RegisterStreamAuthorizer registers an authorizer of all subscriptions to plugin stream channels.
+func (m *Manager) RegisterStreamAuthorizer(authorizer backendplugin.StreamAuthorizer) {
+	m.streamAuthorizersMu.Lock()
+	defer m.streamAuthorizersMu.Unlock()
+
+	m.streamAuthorizers = append(m.streamAuthorizers, authorizer)
+}
+
+// authorizeStream reports whether all registered stream authorizers allow a subscription, in registration order.
+func (m *Manager) authorizeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (bool, error) {
+	m.streamAuthorizersMu.RLock()
+	authorizers := m.streamAuthorizers
+	m.streamAuthorizersMu.RUnlock()
+
+	for _, authorizer := range authorizers {
+		allowed, err := authorizer.AuthorizeStream(ctx, req)
+		if err != nil || !allowed {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// PublishStream asks a registered backend plugin whether publishing to a stream channel is allowed.
+func (m *Manager) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	latest := *req
+	latest.PluginContext = m.latestInstanceSettings(req.PluginContext)
+	req = &latest
+	p, registered := m.getForContext(req.PluginContext)
+	if !registered {
+		return nil, backendplugin.ErrPluginNotRegistered
+	}
+	defer m.pluginRequests.begin(p.PluginID())()
+
+	var resp *backend.PublishStreamResponse
+	err := m.labeledPluginRequest(ctx, p.PluginID(), "publishStream", func(ctx context.Context) (innerErr error) {
+		resp, innerErr = p.PublishStream(ctx, req)
+		return
+	})
+	if err != nil {
+		return nil, wrapStreamError("failed to publish to stream", err)
+	}
+
+	return resp, nil
+}
+
+// RunStream subscribes sender to a stream of a registered backend plugin, sending it the packets of the stream from
+// the calling goroutine until ctx is done or the plugin ends the stream. There's a single running stream per
+// channel, shared by all its subscribers and stopped when the last one leaves or when the plugin is decommissioned
+// or unregistered.
+func (m *Manager) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	latest := *req
+	latest.PluginContext = m.latestInstanceSettings(req.PluginContext)
+	req = &latest
+	p, registered := m.getForContext(req.PluginContext)
+	if !registered {
+		return backendplugin.ErrPluginNotRegistered
+	}
+	pluginID := p.PluginID()
+	channel := streamChannel(req.PluginContext, req.Path)
+
+	sub := m.newPluginStreamSubscriber(pluginID, sender)
+	stream, started := m.pluginStreams.subscribe(pluginID, channel, sub, m.newStreamRateLimiter(pluginID),
+		m.newStreamReplayBuffer(pluginID))
+	defer m.pluginStreams.unsubscribe(pluginID, channel, stream, sub)
+	if started {
+		m.runPluginStream(p, channel, req, stream)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-sub.failed:
+			return err
+		case data := <-sub.packets:
+			if err := sender.SendBytes(data); err != nil {
+				return err
+			}
+		case <-stream.done:
+			if err := sub.flush(); err != nil {
+				return err
+			}
+			select {
+			case err := <-sub.failed:
+				return err
+			default:
+			}
+			if stream.err != nil {
+				if stream.ctx.Err() != nil {
+					return stream.ctx.Err()
+				}
+				return wrapStreamError("failed to run stream", stream.err)
+			}
+			return nil
+		}
+	}
+}
+
+// runPluginStream runs the stream of plugin p on a channel in a new goroutine, fanning out its packets to the
+// subscribers of the stream and resuming it after plugin restarts.
+func (m *Manager) runPluginStream(p backendplugin.Plugin, channel string, req *backend.RunStreamRequest,
+	stream *pluginStream) {
+	pluginID := p.PluginID()
+	p.Logger().Debug("Starting stream", "channel", channel)
+	m.goPlugin(stream.ctx, pluginID, goroutineStream, func(ctx context.Context) {
+		err := m.runStreamWithResume(ctx, p, channel, req, stream)
+		p.Logger().Debug("Stream ended", "channel", channel, "error", err)
+		m.pluginStreams.end(pluginID, channel, stream, err)
+	})
+}
+
+// wrapStreamError wraps an error of a plugin stream call, unless it's a plugin error callers handle.
+func wrapStreamError(message string, err error) error {
+	if errors.Is(err, backendplugin.ErrMethodNotImplemented) || errors.Is(err, backendplugin.ErrPluginUnavailable) ||
+		errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	return errutil.Wrap(message, err)
+}
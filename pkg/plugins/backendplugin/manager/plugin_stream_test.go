@@ -0,0 +1,282 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+type testStreamHandler struct {
+	subscribeStatus backend.SubscribeStreamStatus
+	runStream       func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error
+}
+
+func (h *testStreamHandler) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return &backend.SubscribeStreamResponse{Status: h.subscribeStatus}, nil
+}
+
+func (h *testStreamHandler) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return &backend.PublishStreamResponse{Status: backend.PublishStreamStatusPermissionDenied}, nil
+}
+
+func (h *testStreamHandler) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return h.runStream(ctx, req, sender)
+}
+
+type testPacketSender struct {
+	mu      sync.Mutex
+	packets []string
+}
+
+func (s *testPacketSender) Send(packet *backend.StreamPacket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.packets = append(s.packets, string(packet.Data))
+	return nil
+}
+
+func (s *testPacketSender) get() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.packets...)
+}
+
+type failingPacketSender struct{}
+
+func (failingPacketSender) Send(*backend.StreamPacket) error {
+	return errors.New("client gone")
+}
+
+func newStreamTestManager(handler backend.StreamHandler) (*Manager, *testPlugin) {
+	p := &testPlugin{pluginID: "test", logger: log.New("test"), streamHandler: handler}
+	return &Manager{
+		Cfg: &setting.Cfg{
+			PluginsStreamSubscriberBufferSize: 10,
+			PluginsStreamOverflowPolicy:       streamOverflowPolicyDropOldest,
+		},
+		logger:  log.New("test"),
+		plugins: map[string]backendplugin.Plugin{"test": p},
+	}, p
+}
+
+// waitForStreams waits until a plugin has the given number of running streams.
+func waitForStreams(t *testing.T, m *Manager, pluginID string, count int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return m.pluginStreams.count(pluginID) == count
+	}, time.Second, 10*time.Millisecond)
+}
+
+func subscriberCount(m *Manager, pluginID string, channel string) int {
+	m.pluginStreams.mu.Lock()
+	stream, exists := m.pluginStreams.streams[pluginID][channel]
+	m.pluginStreams.mu.Unlock()
+	if !exists {
+		return 0
+	}
+
+	stream.mu.RLock()
+	defer stream.mu.RUnlock()
+	return len(stream.subscribers)
+}
+
+func TestManager_StreamCalls(t *testing.T) {
+	t.Run("Should route subscribe and publish calls to the plugin", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{subscribeStatus: backend.SubscribeStreamStatusOK})
+		pCtx := backend.PluginContext{PluginID: "test"}
+
+		subscribeResp, err := m.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: pCtx})
+		require.NoError(t, err)
+		require.Equal(t, backend.SubscribeStreamStatusOK, subscribeResp.Status)
+
+		publishResp, err := m.PublishStream(context.Background(), &backend.PublishStreamRequest{PluginContext: pCtx})
+		require.NoError(t, err)
+		require.Equal(t, backend.PublishStreamStatusPermissionDenied, publishResp.Status)
+	})
+
+	t.Run("Should return not implemented if the plugin doesn't support streaming", func(t *testing.T) {
+		m, _ := newStreamTestManager(nil)
+		pCtx := backend.PluginContext{PluginID: "test"}
+
+		_, err := m.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: pCtx})
+		require.ErrorIs(t, err, backendplugin.ErrMethodNotImplemented)
+
+		err = m.RunStream(context.Background(), &backend.RunStreamRequest{PluginContext: pCtx}, nil)
+		require.ErrorIs(t, err, backendplugin.ErrMethodNotImplemented)
+	})
+
+	t.Run("Should return not registered for an unknown plugin", func(t *testing.T) {
+		m, _ := newStreamTestManager(nil)
+		pCtx := backend.PluginContext{PluginID: "unknown"}
+
+		_, err := m.PublishStream(context.Background(), &backend.PublishStreamRequest{PluginContext: pCtx})
+		require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+	})
+
+	t.Run("Should wrap other plugin errors", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				return errors.New("boom")
+			},
+		})
+
+		err := m.RunStream(context.Background(), &backend.RunStreamRequest{PluginContext: backend.PluginContext{PluginID: "test"}}, nil)
+		require.EqualError(t, err, "failed to run stream: boom")
+	})
+
+	t.Run("Should deny subscriptions a registered stream authorizer denies", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{subscribeStatus: backend.SubscribeStreamStatusOK})
+		var authorized []string
+		for _, allowedOrgID := range []int64{1, 2} {
+			allowedOrgID := allowedOrgID
+			m.RegisterStreamAuthorizer(backendplugin.StreamAuthorizerFunc(func(ctx context.Context, req *backend.SubscribeStreamRequest) (bool, error) {
+				authorized = append(authorized, req.Path)
+				return req.PluginContext.OrgID <= allowedOrgID, nil
+			}))
+		}
+
+		resp, err := m.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{
+			PluginContext: backend.PluginContext{PluginID: "test", OrgID: 1}, Path: "allowed"})
+		require.NoError(t, err)
+		require.Equal(t, backend.SubscribeStreamStatusOK, resp.Status)
+
+		resp, err = m.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{
+			PluginContext: backend.PluginContext{PluginID: "test", OrgID: 2}, Path: "denied"})
+		require.NoError(t, err)
+		require.Equal(t, backend.SubscribeStreamStatusPermissionDenied, resp.Status)
+		require.Equal(t, []string{"allowed", "allowed", "denied"}, authorized)
+	})
+
+	t.Run("Should fail subscriptions a registered stream authorizer fails to authorize", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{subscribeStatus: backend.SubscribeStreamStatusOK})
+		m.RegisterStreamAuthorizer(backendplugin.StreamAuthorizerFunc(func(ctx context.Context, req *backend.SubscribeStreamRequest) (bool, error) {
+			return false, errors.New("boom")
+		}))
+
+		_, err := m.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{
+			PluginContext: backend.PluginContext{PluginID: "test"}})
+		require.EqualError(t, err, "failed to authorize stream subscription: boom")
+	})
+}
+
+func TestManager_RunStream(t *testing.T) {
+	runUntilDone := func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+		if err := sender.SendJSON([]byte(`{"path":"` + req.Path + `"}`)); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	t.Run("Should send the packets of the plugin until the stream is canceled", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{runStream: runUntilDone})
+		sender := &testPacketSender{}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- m.RunStream(ctx, &backend.RunStreamRequest{
+				PluginContext: backend.PluginContext{PluginID: "test", OrgID: 1},
+				Path:          "random",
+			}, backend.NewStreamSender(sender))
+		}()
+
+		waitForStreams(t, m, "test", 1)
+		require.Eventually(t, func() bool { return len(sender.get()) == 1 }, time.Second, 10*time.Millisecond)
+		require.Equal(t, []string{`{"path":"random"}`}, sender.get())
+		require.Equal(t, map[string]int{goroutineStream: 1}, m.pluginGoroutines.get("test"))
+
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+		waitForStreams(t, m, "test", 0)
+	})
+
+	t.Run("Should share a single plugin stream between the subscribers of a channel", func(t *testing.T) {
+		var runs int32
+		m, _ := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				atomic.AddInt32(&runs, 1)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+		req := &backend.RunStreamRequest{PluginContext: backend.PluginContext{PluginID: "test", OrgID: 1}, Path: "random"}
+
+		senders := []*testPacketSender{{}, {}}
+		cancels := make([]context.CancelFunc, 0, len(senders))
+		done := make(chan error, len(senders))
+		for _, sender := range senders {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancels = append(cancels, cancel)
+			sender := sender
+			go func() {
+				done <- m.RunStream(ctx, req, backend.NewStreamSender(sender))
+			}()
+		}
+		require.Eventually(t, func() bool {
+			return subscriberCount(m, "test", streamChannel(req.PluginContext, req.Path)) == 2
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, 1, m.pluginStreams.count("test"))
+
+		stream := m.pluginStreams.streams["test"][streamChannel(req.PluginContext, req.Path)]
+		require.NoError(t, stream.Send(&backend.StreamPacket{Data: []byte(`{"value":1}`)}))
+		for _, sender := range senders {
+			require.Eventually(t, func() bool { return len(sender.get()) == 1 }, time.Second, 10*time.Millisecond)
+			require.Equal(t, []string{`{"value":1}`}, sender.get())
+		}
+
+		cancels[0]()
+		require.ErrorIs(t, <-done, context.Canceled)
+		require.Equal(t, 1, m.pluginStreams.count("test"))
+		require.NoError(t, stream.ctx.Err())
+
+		cancels[1]()
+		require.ErrorIs(t, <-done, context.Canceled)
+		waitForStreams(t, m, "test", 0)
+		require.Error(t, stream.ctx.Err())
+		require.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	})
+
+	t.Run("Should stop sending to a subscriber that failed to receive a packet", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				return sender.SendJSON([]byte(`{}`))
+			},
+		})
+		req := &backend.RunStreamRequest{PluginContext: backend.PluginContext{PluginID: "test"}, Path: "random"}
+
+		err := m.RunStream(context.Background(), req, backend.NewStreamSender(failingPacketSender{}))
+		require.EqualError(t, err, "client gone")
+		waitForStreams(t, m, "test", 0)
+	})
+
+	t.Run("Should stop the streams of a decommissioned plugin", func(t *testing.T) {
+		m, p := newStreamTestManager(&testStreamHandler{runStream: runUntilDone})
+
+		done := make(chan error, 2)
+		for _, path := range []string{"a", "b"} {
+			req := &backend.RunStreamRequest{PluginContext: backend.PluginContext{PluginID: "test"}, Path: path}
+			go func() {
+				done <- m.RunStream(context.Background(), req, backend.NewStreamSender(&testPacketSender{}))
+			}()
+		}
+		waitForStreams(t, m, "test", 2)
+
+		require.NoError(t, m.DecommissionPlugin(context.Background(), "test"))
+		require.True(t, p.IsDecommissioned())
+		require.ErrorIs(t, <-done, context.Canceled)
+		require.ErrorIs(t, <-done, context.Canceled)
+		waitForStreams(t, m, "test", 0)
+	})
+}
@@ -0,0 +1,128 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// withPluginTimeout returns a context canceled after the timeout in seconds configured for the plugin with
+// the given setting key, falling back to def. A timeout of 0 falls back to the default timeout of the plugin, a
+// negative one means the returned context never times out.
+func (m *Manager) withPluginTimeout(ctx context.Context, pluginID string, key string, def int) (context.Context, context.CancelFunc) {
+	timeout := getPluginIntSetting(pluginID, key, m.Cfg, def)
+	if timeout == 0 {
+		return m.withDefaultTimeout(ctx, pluginID)
+	}
+
+	return withTimeoutSeconds(ctx, timeout)
+}
+
+// withStreamingTimeout returns a context canceled after the timeout in seconds configured for the plugin with the
+// given setting key, falling back to def. Unlike withPluginTimeout, a timeout of 0 means the returned context never
+// times out, as streaming calls legitimately outlive the default timeout of plugin calls and only time out when
+// a specific timeout is set.
+func (m *Manager) withStreamingTimeout(ctx context.Context, pluginID string, key string, def int) (context.Context, context.CancelFunc) {
+	return withTimeoutSeconds(ctx, getPluginIntSetting(pluginID, key, m.Cfg, def))
+}
+
+// withResourceTimeout returns a context canceled after the resource call timeout in seconds of a plugin. Resource
+// calls of server-sent event streams are streaming calls, not subject to the default timeout.
+func (m *Manager) withResourceTimeout(req *http.Request, pluginID string) (context.Context, context.CancelFunc) {
+	if strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		return m.withStreamingTimeout(req.Context(), pluginID, "resource_timeout", m.Cfg.PluginsResourceTimeout)
+	}
+
+	return m.withPluginTimeout(req.Context(), pluginID, "resource_timeout", m.Cfg.PluginsResourceTimeout)
+}
+
+// withDefaultTimeout returns a context canceled after the default timeout in seconds of plugin calls, which can be
+// set per plugin with the default_timeout setting and globally, so that a wedged plugin can't hold requests
+// forever. If it's not positive the returned context never times out.
+func (m *Manager) withDefaultTimeout(ctx context.Context, pluginID string) (context.Context, context.CancelFunc) {
+	return withTimeoutSeconds(ctx, getPluginIntSetting(pluginID, "default_timeout", m.Cfg, m.Cfg.PluginsDefaultTimeout))
+}
+
+func withTimeoutSeconds(ctx context.Context, timeout int) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+}
+
+// translateTimeoutError returns an error wrapping backendplugin.ErrPluginTimeout if err was caused by the
+// deadline of timeoutCtx being exceeded while parent was still active, otherwise err is returned as is.
+func translateTimeoutError(parent context.Context, timeoutCtx context.Context, err error) error {
+	if err == nil || errors.Is(err, backendplugin.ErrPluginTimeout) {
+		return err
+	}
+
+	if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) && parent.Err() == nil {
+		return fmt.Errorf("%w: %s", backendplugin.ErrPluginTimeout, err)
+	}
+
+	return err
+}
+
+type queryTimeoutJSONModel struct {
+	QueryTimeout json.RawMessage `json:"queryTimeout"`
+}
+
+// dataSourceQueryTimeout returns the timeout in seconds set with the queryTimeout field of the JSON data of a data
+// source. It's either a number of seconds or a duration string such as "1m", which is the format some data sources,
+// for example Prometheus, already use for the field. Sub-second durations are rounded up to a second.
+func dataSourceQueryTimeout(jsonData []byte) (int, bool) {
+	model := queryTimeoutJSONModel{}
+	if err := json.Unmarshal(jsonData, &model); err != nil || len(model.QueryTimeout) == 0 ||
+		string(model.QueryTimeout) == "null" {
+		return 0, false
+	}
+
+	var seconds int
+	if err := json.Unmarshal(model.QueryTimeout, &seconds); err == nil {
+		return seconds, true
+	}
+
+	var str string
+	if err := json.Unmarshal(model.QueryTimeout, &str); err != nil || str == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(str); err == nil {
+		return seconds, true
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, false
+	}
+
+	return int(math.Ceil(d.Seconds())), true
+}
+
+// withQueryTimeout returns a context canceled after the data query timeout in seconds, which can be set per data
+// source with the queryTimeout JSON data field, per plugin with the query_timeout setting and globally. Streamed
+// queries are streaming calls, not subject to the default timeout.
+func (m *Manager) withQueryTimeout(ctx context.Context, pCtx backend.PluginContext, streaming bool) (context.Context, context.CancelFunc) {
+	if dis := pCtx.DataSourceInstanceSettings; dis != nil && len(dis.JSONData) > 0 {
+		if timeout, exists := dataSourceQueryTimeout(dis.JSONData); exists {
+			if timeout == 0 && !streaming {
+				return m.withDefaultTimeout(ctx, pCtx.PluginID)
+			}
+			return withTimeoutSeconds(ctx, timeout)
+		}
+	}
+
+	if streaming {
+		return m.withStreamingTimeout(ctx, pCtx.PluginID, "query_timeout", m.Cfg.PluginsQueryTimeout)
+	}
+	return m.withPluginTimeout(ctx, pCtx.PluginID, "query_timeout", m.Cfg.PluginsQueryTimeout)
+}
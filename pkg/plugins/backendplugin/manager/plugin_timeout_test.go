@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_WithPluginTimeout(t *testing.T) {
+	m := &Manager{Cfg: &setting.Cfg{
+		PluginsDefaultTimeout: 300,
+		PluginSettings: setting.PluginSettings{
+			"custom":    {"resource_timeout": "10"},
+			"unlimited": {"resource_timeout": "-1"},
+			"default":   {"default_timeout": "60"},
+		},
+	}}
+	timeout := func(pluginID string) time.Duration {
+		ctx, cancel := m.withPluginTimeout(context.Background(), pluginID, "resource_timeout", 0)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return 0
+		}
+		return time.Until(deadline).Round(time.Second)
+	}
+
+	require.Equal(t, 10*time.Second, timeout("custom"))
+	require.Zero(t, timeout("unlimited"))
+	require.Equal(t, 60*time.Second, timeout("default"))
+	require.Equal(t, 300*time.Second, timeout("other"))
+
+	m.Cfg.PluginsDefaultTimeout = 0
+	require.Zero(t, timeout("other"))
+}
+
+func TestManager_StreamingCallTimeouts(t *testing.T) {
+	m := &Manager{Cfg: &setting.Cfg{
+		PluginsDefaultTimeout: 300,
+		PluginSettings: setting.PluginSettings{
+			"custom": {"resource_timeout": "10", "query_timeout": "20"},
+		},
+	}}
+	deadline := func(ctx context.Context, cancel context.CancelFunc) time.Duration {
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return 0
+		}
+		return time.Until(deadline).Round(time.Second)
+	}
+	resourceTimeout := func(pluginID string, accept string) time.Duration {
+		req := httptest.NewRequest(http.MethodGet, "/api/plugins/"+pluginID+"/resources/events", nil)
+		req.Header.Set("Accept", accept)
+		return deadline(m.withResourceTimeout(req, pluginID))
+	}
+	queryTimeout := func(pluginID string, jsonData string, streaming bool) time.Duration {
+		pCtx := backend.PluginContext{PluginID: pluginID}
+		if jsonData != "" {
+			pCtx.DataSourceInstanceSettings = &backend.DataSourceInstanceSettings{JSONData: []byte(jsonData)}
+		}
+		return deadline(m.withQueryTimeout(context.Background(), pCtx, streaming))
+	}
+
+	t.Run("Should apply default timeout to unary calls", func(t *testing.T) {
+		require.Equal(t, 300*time.Second, resourceTimeout("other", "application/json"))
+		require.Equal(t, 300*time.Second, queryTimeout("other", "", false))
+		require.Equal(t, 300*time.Second, queryTimeout("other", `{"queryTimeout": 0}`, false))
+	})
+
+	t.Run("Should not apply default timeout to streaming calls", func(t *testing.T) {
+		require.Zero(t, resourceTimeout("other", "text/event-stream"))
+		require.Zero(t, queryTimeout("other", "", true))
+		require.Zero(t, queryTimeout("other", `{"queryTimeout": 0}`, true))
+	})
+
+	t.Run("Should apply specific timeouts to streaming calls", func(t *testing.T) {
+		require.Equal(t, 10*time.Second, resourceTimeout("custom", "text/event-stream"))
+		require.Equal(t, 20*time.Second, queryTimeout("custom", "", true))
+		require.Equal(t, 30*time.Second, queryTimeout("other", `{"queryTimeout": 30}`, true))
+	})
+}
+
+func TestDataSourceQueryTimeout(t *testing.T) {
+	tcs := []struct {
+		jsonData string
+		timeout  int
+		exists   bool
+	}{
+		{jsonData: `{}`},
+		{jsonData: `{"queryTimeout": null}`},
+		{jsonData: `{"queryTimeout": 30}`, timeout: 30, exists: true},
+		{jsonData: `{"queryTimeout": 0}`, timeout: 0, exists: true},
+		{jsonData: `{"queryTimeout": "45"}`, timeout: 45, exists: true},
+		{jsonData: `{"queryTimeout": "1m"}`, timeout: 60, exists: true},
+		{jsonData: `{"queryTimeout": "1m30s"}`, timeout: 90, exists: true},
+		{jsonData: `{"queryTimeout": "500ms"}`, timeout: 1, exists: true},
+		{jsonData: `{"queryTimeout": ""}`},
+		{jsonData: `{"queryTimeout": "soon"}`},
+		{jsonData: `{"queryTimeout": true}`},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.jsonData, func(t *testing.T) {
+			timeout, exists := dataSourceQueryTimeout([]byte(tc.jsonData))
+			require.Equal(t, tc.exists, exists)
+			require.Equal(t, tc.timeout, timeout)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+)
+
+// tracePluginRequest runs fn in a span around a plugin request. The span is passed to fn through its context,
+// so it's propagated to the plugin.
+func tracePluginRequest(ctx context.Context, pluginID string, endpoint string, fn func(ctx context.Context) error) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "plugin."+endpoint)
+	defer span.Finish()
+
+	span.SetTag("plugin_id", pluginID)
+	ext.SpanKindRPCClient.Set(span)
+
+	err := fn(ctx)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(otlog.Error(err))
+	}
+
+	return err
+}
@@ -0,0 +1,38 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracePluginRequest(t *testing.T) {
+	tracer := mocktracer.New()
+	globalTracer := opentracing.GlobalTracer()
+	opentracing.SetGlobalTracer(tracer)
+	t.Cleanup(func() {
+		opentracing.SetGlobalTracer(globalTracer)
+	})
+
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+	err := tracePluginRequest(ctx, "test-plugin", "queryData", func(ctx context.Context) error {
+		span := opentracing.SpanFromContext(ctx)
+		require.NotNil(t, span)
+		require.NotEqual(t, parent, span)
+		return errors.New("failed")
+	})
+	require.Error(t, err)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "plugin.queryData", spans[0].OperationName)
+	require.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, spans[0].ParentID)
+	require.Equal(t, "test-plugin", spans[0].Tag("plugin_id"))
+	require.Equal(t, true, spans[0].Tag("error"))
+}
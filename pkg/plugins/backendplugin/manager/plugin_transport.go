@@ -0,0 +1,36 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// newPlugin creates a backend plugin with the factory it was registered with, running it in a container if Grafana
+// runs plugins in containers or in a systemd scope if its process scope is systemd, and requiring it to connect with
+// TLS if its transport is secured. Plugins without a container, process or transport, such as core plugins, are
+// created as is.
+func (m *Manager) newPlugin(factory backendplugin.PluginFactoryFunc, pluginID string, logger log.Logger,
+	env []string) (backendplugin.Plugin, error) {
+	p, err := factory(pluginID, logger, env)
+	if err != nil {
+		return nil, err
+	}
+	m.runInContainer(p)
+	m.scopePluginProcess(p)
+
+	transportTLS, secured := m.Cfg.PluginTransportTLS(pluginID)
+	securer, ok := p.(backendplugin.TransportSecurer)
+	if !secured || !ok {
+		return p, nil
+	}
+
+	tlsConfig, err := transportTLS.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS of backend plugin %s: %w", pluginID, err)
+	}
+	securer.SecureTransport(tlsConfig)
+
+	return p, nil
+}
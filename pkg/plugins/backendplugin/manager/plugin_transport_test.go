@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+type testSecuredPlugin struct {
+	*testPlugin
+	secured   bool
+	tlsConfig *tls.Config
+}
+
+func (p *testSecuredPlugin) SecureTransport(config *tls.Config) {
+	p.secured = true
+	p.tlsConfig = config
+}
+
+func TestManager_PluginTransportTLS(t *testing.T) {
+	factory := func(pluginID string, logger log.Logger, env []string) (backendplugin.Plugin, error) {
+		return &testSecuredPlugin{testPlugin: &testPlugin{pluginID: pluginID, logger: logger}}, nil
+	}
+	cfg := setting.NewCfg()
+	cfg.PluginSettings = setting.PluginSettings{
+		"secured":    map[string]string{"transport_tls": "true"},
+		"incomplete": map[string]string{"transport_tls_ca_file": "/missing/ca.crt"},
+	}
+	m := &Manager{Cfg: cfg}
+
+	p, err := m.newPlugin(factory, "plain", log.New("test"), nil)
+	require.NoError(t, err)
+	require.False(t, p.(*testSecuredPlugin).secured)
+
+	p, err = m.newPlugin(factory, "secured", log.New("test"), nil)
+	require.NoError(t, err)
+	require.True(t, p.(*testSecuredPlugin).secured)
+	require.Nil(t, p.(*testSecuredPlugin).tlsConfig)
+
+	_, err = m.newPlugin(factory, "incomplete", log.New("test"), nil)
+	require.Error(t, err)
+
+	t.Run("Should create plugins without a transport as is", func(t *testing.T) {
+		cfg.PluginsTransportTLS = true
+		p, err := m.newPlugin(func(pluginID string, logger log.Logger, env []string) (backendplugin.Plugin, error) {
+			return &testPlugin{pluginID: pluginID, logger: logger}, nil
+		}, "core", log.New("test"), nil)
+		require.NoError(t, err)
+		require.IsType(t, &testPlugin{}, p)
+	})
+}
@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// warmUpPlugins checks the health of the data sources of the configured plugins once after startup, so that the
+// first user queries don't pay for creating the plugin instances of the data sources. Plugins without data sources
+// are sent a health check without instance settings, which establishes the connection to the plugin process.
+func (m *Manager) warmUpPlugins(ctx context.Context) {
+	pluginIDs := m.registeredPluginIDs(m.Cfg.PluginsWarmUpPlugins)
+	if len(pluginIDs) == 0 {
+		return
+	}
+
+	start := time.Now()
+	var tasks []func(ctx context.Context)
+	for _, pluginID := range pluginIDs {
+		tasks = append(tasks, m.warmUpTasks(pluginID)...)
+	}
+	m.runBackgroundTasks(ctx, tasks)
+
+	m.logger.Info("Backend plugins warmed up", "plugins", len(pluginIDs), "duration", time.Since(start))
+}
+
+// warmUpTasks returns the health checks warming up the plugin with pluginID.
+func (m *Manager) warmUpTasks(pluginID string) []func(ctx context.Context) {
+	query := &models.GetDataSourcesByTypeQuery{Type: pluginID}
+	if err := bus.Dispatch(query); err != nil {
+		m.logger.Warn("Failed to get data sources for plugin warm-up", "pluginId", pluginID, "error", err)
+		return nil
+	}
+
+	if len(query.Result) == 0 {
+		return []func(ctx context.Context){func(ctx context.Context) {
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			_, err := m.CheckHealth(ctx, backend.PluginContext{PluginID: pluginID})
+			if err != nil && !errors.Is(err, backendplugin.ErrMethodNotImplemented) {
+				m.logger.Debug("Plugin warm-up health check failed", "pluginId", pluginID, "error", err)
+			}
+		}}
+	}
+
+	tasks := make([]func(ctx context.Context), 0, len(query.Result))
+	for _, ds := range query.Result {
+		ds := ds
+		tasks = append(tasks, func(ctx context.Context) {
+			if err := m.checkDataSourceHealth(ctx, pluginID, ds); err != nil {
+				m.logger.Debug("Data source warm-up health check failed", "pluginId", pluginID, "uid", ds.Uid, "error", err)
+			}
+		})
+	}
+	return tasks
+}
@@ -0,0 +1,32 @@
+//go:build linux
+// +build linux
+
+package manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// moveProcessToCgroup moves a process into the cgroup v2 at dir, creating the cgroup if it doesn't exist. The memory of
+// the cgroup is limited to memoryMaxBytes if greater than 0, and the OOM killer kills all the processes of the cgroup
+// together when possible.
+func moveProcessToCgroup(dir string, pid, memoryMaxBytes int) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// memory.oom.group only exists if the memory controller is enabled for the cgroup
+	_ = ioutil.WriteFile(filepath.Join(dir, "memory.oom.group"), []byte("1"), 0600)
+
+	if memoryMaxBytes > 0 {
+		if err := ioutil.WriteFile(filepath.Join(dir, "memory.max"), []byte(strconv.Itoa(memoryMaxBytes)), 0600); err != nil {
+			return fmt.Errorf("failed to limit memory: %w", err)
+		}
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0600)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package manager
+
+// moveProcessToCgroup is only supported on Linux.
+func moveProcessToCgroup(dir string, pid, memoryMaxBytes int) error {
+	return errProcessCgroupUnsupported
+}
@@ -0,0 +1,156 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var errProcessStatsUnsupported = errors.New("process stats are only supported on Linux")
+
+var (
+	pluginProcessResidentMemory *prometheus.GaugeVec
+	pluginProcessCPUSeconds     *prometheus.CounterVec
+	pluginProcessOpenFDs        *prometheus.GaugeVec
+)
+
+func init() {
+	pluginProcessResidentMemory = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "plugin_process_resident_memory_bytes",
+		Help:      "Resident memory size of backend plugin processes in bytes",
+	}, []string{"plugin_id"})
+
+	pluginProcessCPUSeconds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "plugin_process_cpu_seconds_total",
+		Help:      "Total user and system CPU time spent by backend plugin processes in seconds",
+	}, []string{"plugin_id"})
+
+	pluginProcessOpenFDs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "plugin_process_open_fds",
+		Help:      "Number of open file descriptors of backend plugin processes",
+	}, []string{"plugin_id"})
+
+	prometheus.MustRegister(pluginProcessResidentMemory, pluginProcessCPUSeconds, pluginProcessOpenFDs)
+}
+
+// processStats is the resource usage of a process.
+type processStats struct {
+	residentMemoryBytes float64
+	cpuSeconds          float64
+	openFDs             float64
+}
+
+// processSampler exports the resource usage of plugin processes as metrics. It keeps the CPU time of the
+// previous sample to increment the CPU counter across process restarts.
+type processSampler struct {
+	cpuSeconds map[int]float64
+	pluginIDs  map[string]struct{}
+}
+
+// sample samples the processes of each plugin, summing up the resource usage of plugins running several
+// processes.
+func (s *processSampler) sample(processes map[string][]backendplugin.ProcessPlugin) error {
+	cpuSeconds := map[int]float64{}
+	pluginIDs := map[string]struct{}{}
+	for pluginID, plugins := range processes {
+		var total processStats
+		var cpuDelta float64
+		sampled := false
+		for _, p := range plugins {
+			pid, running := p.Pid()
+			if !running {
+				continue
+			}
+
+			stats, err := readProcessStats(pid)
+			if err != nil {
+				if errors.Is(err, errProcessStatsUnsupported) {
+					return err
+				}
+				continue
+			}
+
+			sampled = true
+			total.residentMemoryBytes += stats.residentMemoryBytes
+			total.openFDs += stats.openFDs
+			cpuSeconds[pid] = stats.cpuSeconds
+			if prev, exists := s.cpuSeconds[pid]; exists && stats.cpuSeconds >= prev {
+				cpuDelta += stats.cpuSeconds - prev
+			} else {
+				cpuDelta += stats.cpuSeconds
+			}
+		}
+
+		if !sampled {
+			continue
+		}
+
+		pluginIDs[pluginID] = struct{}{}
+		pluginProcessResidentMemory.WithLabelValues(pluginID).Set(total.residentMemoryBytes)
+		pluginProcessOpenFDs.WithLabelValues(pluginID).Set(total.openFDs)
+		pluginProcessCPUSeconds.WithLabelValues(pluginID).Add(cpuDelta)
+	}
+
+	for pluginID := range s.pluginIDs {
+		if _, exists := pluginIDs[pluginID]; !exists {
+			pluginProcessResidentMemory.DeleteLabelValues(pluginID)
+			pluginProcessOpenFDs.DeleteLabelValues(pluginID)
+		}
+	}
+
+	s.cpuSeconds = cpuSeconds
+	s.pluginIDs = pluginIDs
+	return nil
+}
+
+// processPlugins returns the registered plugins running as a separate process, including isolated instances.
+func (m *Manager) processPlugins() map[string][]backendplugin.ProcessPlugin {
+	m.pluginsMu.RLock()
+	defer m.pluginsMu.RUnlock()
+
+	processes := map[string][]backendplugin.ProcessPlugin{}
+	add := func(p backendplugin.Plugin) {
+		if pp, ok := p.(backendplugin.ProcessPlugin); ok {
+			processes[p.PluginID()] = append(processes[p.PluginID()], pp)
+		}
+	}
+	for _, p := range m.plugins {
+		add(p)
+	}
+	for _, p := range m.isolatedPlugins {
+		add(p)
+	}
+
+	return processes
+}
+
+// runProcessMetrics periodically samples the resource usage of plugin processes until ctx is done.
+func (m *Manager) runProcessMetrics(ctx context.Context) {
+	interval := time.Duration(m.Cfg.PluginsProcessMetricsInterval) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sampler := &processSampler{}
+	for {
+		if err := sampler.sample(m.processPlugins()); err != nil {
+			m.logger.Debug("Not sampling plugin process metrics", "error", err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type testProcessPlugin struct {
+	pid     int
+	running bool
+}
+
+func (p *testProcessPlugin) Pid() (int, bool) {
+	return p.pid, p.running
+}
+
+func (p *testProcessPlugin) Output() []byte {
+	return nil
+}
+
+func TestProcessSampler(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process stats are only supported on Linux")
+	}
+
+	p := &testProcessPlugin{pid: os.Getpid(), running: true}
+	s := &processSampler{}
+
+	err := s.sample(map[string][]backendplugin.ProcessPlugin{"test-process": {p}})
+	require.NoError(t, err)
+	require.Greater(t, testutil.ToFloat64(pluginProcessResidentMemory.WithLabelValues("test-process")), float64(0))
+	require.Greater(t, testutil.ToFloat64(pluginProcessOpenFDs.WithLabelValues("test-process")), float64(0))
+	cpuSeconds := testutil.ToFloat64(pluginProcessCPUSeconds.WithLabelValues("test-process"))
+
+	t.Run("Should not count CPU time of the same process twice", func(t *testing.T) {
+		err := s.sample(map[string][]backendplugin.ProcessPlugin{"test-process": {p}})
+		require.NoError(t, err)
+		require.Less(t, testutil.ToFloat64(pluginProcessCPUSeconds.WithLabelValues("test-process")), cpuSeconds+1)
+	})
+
+	t.Run("Should remove metrics of plugins that aren't running", func(t *testing.T) {
+		p.running = false
+		err := s.sample(map[string][]backendplugin.ProcessPlugin{"test-process": {p}})
+		require.NoError(t, err)
+		require.Equal(t, 0, testutil.CollectAndCount(pluginProcessResidentMemory))
+		require.Equal(t, 0, testutil.CollectAndCount(pluginProcessOpenFDs))
+	})
+}
@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// ProcessOutput returns the latest stdout and stderr output of the processes of a registered backend plugin,
+// followed by the output of its isolated instances.
+func (m *Manager) ProcessOutput(pluginID string) ([]byte, error) {
+	m.pluginsMu.RLock()
+	pluginID = m.resolveAlias(pluginID)
+	p, exists := m.plugins[pluginID]
+	isolated := m.isolatedInstances(pluginID)
+	m.pluginsMu.RUnlock()
+	if !exists {
+		return nil, backendplugin.ErrPluginNotRegistered
+	}
+
+	pp, ok := p.(backendplugin.ProcessPlugin)
+	if !ok {
+		return nil, backendplugin.ErrMethodNotImplemented
+	}
+
+	var buf bytes.Buffer
+	buf.Write(pp.Output())
+
+	keys := make([]string, 0, len(isolated))
+	for key := range isolated {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if pp, ok := isolated[key].(backendplugin.ProcessPlugin); ok {
+			fmt.Fprintf(&buf, "=== Isolation group %s ===\n", strings.TrimPrefix(key, pluginID+"/"))
+			buf.Write(pp.Output())
+		}
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,57 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+type testOutputPlugin struct {
+	*testPlugin
+	output []byte
+}
+
+func (p *testOutputPlugin) Pid() (int, bool) {
+	return 0, false
+}
+
+func (p *testOutputPlugin) Output() []byte {
+	return p.output
+}
+
+func TestManager_ProcessOutput(t *testing.T) {
+	t.Run("Should return plugin not registered error for unknown plugin", func(t *testing.T) {
+		m := &Manager{logger: log.New("test"), plugins: map[string]backendplugin.Plugin{}}
+		_, err := m.ProcessOutput(testPluginID)
+		require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+	})
+
+	t.Run("Should return method not implemented error for plugin without process", func(t *testing.T) {
+		m := &Manager{
+			logger:  log.New("test"),
+			plugins: map[string]backendplugin.Plugin{testPluginID: &testPlugin{pluginID: testPluginID}},
+		}
+		_, err := m.ProcessOutput(testPluginID)
+		require.ErrorIs(t, err, backendplugin.ErrMethodNotImplemented)
+	})
+
+	t.Run("Should return output of plugin and its isolated instances", func(t *testing.T) {
+		m := &Manager{
+			logger: log.New("test"),
+			plugins: map[string]backendplugin.Plugin{
+				testPluginID: &testOutputPlugin{testPlugin: &testPlugin{pluginID: testPluginID}, output: []byte("main\n")},
+			},
+			isolatedPlugins: map[string]backendplugin.Plugin{
+				testPluginID + "/b": &testOutputPlugin{testPlugin: &testPlugin{pluginID: testPluginID}, output: []byte("group b\n")},
+				testPluginID + "/a": &testOutputPlugin{testPlugin: &testPlugin{pluginID: testPluginID}, output: []byte("group a\n")},
+				"other-plugin/a":    &testOutputPlugin{testPlugin: &testPlugin{pluginID: "other-plugin"}, output: []byte("other\n")},
+			},
+		}
+		output, err := m.ProcessOutput(testPluginID)
+		require.NoError(t, err)
+		require.Equal(t, "main\n=== Isolation group a ===\ngroup a\n=== Isolation group b ===\ngroup b\n", string(output))
+	})
+}
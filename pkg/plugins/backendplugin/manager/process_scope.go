@@ -0,0 +1,98 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+const (
+	// processScopeSystemd places every plugin process in its own transient systemd scope.
+	processScopeSystemd = "systemd"
+	// processScopeCgroup places the processes of every plugin in its own cgroup.
+	processScopeCgroup = "cgroup"
+)
+
+var errProcessCgroupUnsupported = errors.New("placing processes in cgroups is only supported on Linux")
+
+// processScope returns how the processes of a plugin are placed in a scope or cgroup of their own, set by
+// process_scope in the [plugins] section or in the [plugin.<plugin id>] section of the plugin, or an empty string if
+// they run in the cgroup of Grafana.
+func (m *Manager) processScope(pluginID string) string {
+	return getPluginStringSetting(pluginID, "process_scope", m.Cfg, m.Cfg.PluginsProcessScope)
+}
+
+// processMemoryMaxBytes returns the memory limit of the processes of a plugin in their scope or cgroup, or 0 if
+// they're only accounted for.
+func (m *Manager) processMemoryMaxBytes(pluginID string) int {
+	return getPluginIntSetting(pluginID, "process_memory_max_bytes", m.Cfg, m.Cfg.PluginsProcessMemoryMaxBytes)
+}
+
+// scopePluginProcess starts the process of plugin p in a transient systemd scope of its own if its process scope is
+// systemd, so that its resource usage is accounted for and limited separately from Grafana, and the OOM killer
+// targets the plugin instead of Grafana.
+func (m *Manager) scopePluginProcess(p backendplugin.Plugin) {
+	wrapper, ok := p.(backendplugin.ProcessWrapper)
+	if !ok || m.processScope(p.PluginID()) != processScopeSystemd {
+		return
+	}
+
+	wrapper.WrapProcess(systemdScopeCommand(p.PluginID(), m.processMemoryMaxBytes(p.PluginID()), os.Geteuid() != 0))
+}
+
+// systemdScopeCommand returns a function wrapping the command of a plugin process in systemd-run, which starts it in
+// a new transient scope unit named after the plugin, with the memory limit memoryMaxBytes if greater than 0. The
+// scopes are started by the user service manager if user is true.
+func systemdScopeCommand(pluginID string, memoryMaxBytes int, user bool) func(cmd *exec.Cmd) *exec.Cmd {
+	return func(cmd *exec.Cmd) *exec.Cmd {
+		args := []string{
+			"--scope", "--quiet", "--collect",
+			"--unit", fmt.Sprintf("grafana-plugin-%s-%d", pluginID, time.Now().UnixNano()),
+			"--slice", "grafana-plugins",
+			"--description", fmt.Sprintf("Grafana plugin %s", pluginID),
+		}
+		if user {
+			args = append(args, "--user")
+		}
+		if memoryMaxBytes > 0 {
+			args = append(args, "--property", fmt.Sprintf("MemoryMax=%d", memoryMaxBytes))
+		}
+		// systemd-run executes the plugin executable in its own process, so the plugin process ID stays the same
+		args = append(args, "--", cmd.Path)
+		args = append(args, cmd.Args[1:]...)
+
+		// nolint:gosec
+		wrapped := exec.Command("systemd-run", args...)
+		wrapped.Env = cmd.Env
+		return wrapped
+	}
+}
+
+// placePluginProcess moves the process of plugin instance p, which was just started, into the cgroup of the plugin if
+// its process scope is cgroup. The cgroup is created in the cgroup set by process_cgroup_path in the [plugins]
+// section, which must be writable by Grafana. Failing to do so is logged, and the plugin keeps running in the cgroup
+// of Grafana.
+func (m *Manager) placePluginProcess(p backendplugin.Plugin) {
+	processPlugin, ok := p.(backendplugin.ProcessPlugin)
+	if !ok || m.processScope(p.PluginID()) != processScopeCgroup {
+		return
+	}
+
+	pid, running := processPlugin.Pid()
+	if !running {
+		return
+	}
+
+	dir := filepath.Join(m.Cfg.PluginsProcessCgroupPath, p.PluginID())
+	if err := moveProcessToCgroup(dir, pid, m.processMemoryMaxBytes(p.PluginID())); err != nil {
+		p.Logger().Warn("Failed to move plugin process to its cgroup", "pid", pid, "cgroup", dir, "error", err)
+		return
+	}
+
+	p.Logger().Debug("Moved plugin process to its cgroup", "pid", pid, "cgroup", dir)
+}
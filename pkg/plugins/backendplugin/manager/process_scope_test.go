@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+type testWrappedPlugin struct {
+	*testPlugin
+	wrap func(cmd *exec.Cmd) *exec.Cmd
+}
+
+func (p *testWrappedPlugin) WrapProcess(wrap func(cmd *exec.Cmd) *exec.Cmd) {
+	p.wrap = wrap
+}
+
+func TestManager_ProcessScope(t *testing.T) {
+	t.Run("Should start plugin processes in systemd scopes", func(t *testing.T) {
+		m := &Manager{Cfg: &setting.Cfg{
+			PluginsProcessScope: "systemd",
+			PluginSettings: setting.PluginSettings{
+				"test": map[string]string{"process_memory_max_bytes": "536870912"},
+			},
+		}}
+		p := &testWrappedPlugin{testPlugin: &testPlugin{pluginID: "test", logger: log.New("test")}}
+		m.scopePluginProcess(p)
+		require.NotNil(t, p.wrap)
+
+		cmd := exec.Command("/plugins/test/gpx_test", "-standalone")
+		cmd.Env = []string{"GF_VERSION=8.2.0"}
+		wrapped := p.wrap(cmd)
+		require.Equal(t, "systemd-run", wrapped.Args[0])
+		require.Contains(t, wrapped.Args, "--scope")
+		require.Contains(t, wrapped.Args, "MemoryMax=536870912")
+		require.Equal(t, []string{"--", "/plugins/test/gpx_test", "-standalone"}, wrapped.Args[len(wrapped.Args)-3:])
+		require.Equal(t, cmd.Env, wrapped.Env)
+	})
+
+	t.Run("Should start plugin processes without scope as is", func(t *testing.T) {
+		m := &Manager{Cfg: &setting.Cfg{
+			PluginsProcessScope: "systemd",
+			PluginSettings:      setting.PluginSettings{"test": map[string]string{"process_scope": "none"}},
+		}}
+		p := &testWrappedPlugin{testPlugin: &testPlugin{pluginID: "test", logger: log.New("test")}}
+		m.scopePluginProcess(p)
+		require.Nil(t, p.wrap)
+	})
+
+	t.Run("Should name systemd scopes after the plugin", func(t *testing.T) {
+		cmd := exec.Command("/plugins/test/gpx_test")
+		wrapped := systemdScopeCommand("test", 0, true)(cmd)
+		require.Regexp(t, "^grafana-plugin-test-[0-9]+$", wrapped.Args[5])
+		require.Contains(t, wrapped.Args, "--user")
+		require.NotContains(t, wrapped.Args, "--property")
+	})
+
+	t.Run("Should move plugin processes to their cgroup", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("cgroups are only supported on Linux")
+		}
+
+		// a regular directory stands in for the cgroup file system
+		cgroupPath := t.TempDir()
+		m := &Manager{Cfg: &setting.Cfg{
+			PluginsProcessScope:          "cgroup",
+			PluginsProcessCgroupPath:     cgroupPath,
+			PluginsProcessMemoryMaxBytes: 1048576,
+		}}
+		m.placePluginProcess(&testPidPlugin{
+			testPlugin:        &testPlugin{pluginID: "test", logger: log.New("test")},
+			testProcessPlugin: testProcessPlugin{pid: 1234, running: true},
+		})
+
+		for file, expected := range map[string]string{
+			"cgroup.procs":     "1234",
+			"memory.max":       strconv.Itoa(1048576),
+			"memory.oom.group": "1",
+		} {
+			content, err := ioutil.ReadFile(filepath.Join(cgroupPath, "test", file))
+			require.NoError(t, err)
+			require.Equal(t, expected, string(content))
+		}
+	})
+}
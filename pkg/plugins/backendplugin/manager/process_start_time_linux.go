@@ -0,0 +1,24 @@
+//go:build linux
+// +build linux
+
+package manager
+
+import (
+	"github.com/prometheus/procfs"
+)
+
+// processStartTime returns the start time of a process in clock ticks since boot, which tells it apart from later
+// processes reusing its process ID.
+func processStartTime(pid int) (uint64, error) {
+	proc, err := procfs.NewProc(pid)
+	if err != nil {
+		return 0, err
+	}
+
+	stat, err := proc.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return stat.Starttime, nil
+}
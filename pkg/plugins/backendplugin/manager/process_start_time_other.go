@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package manager
+
+// processStartTime is only supported on Linux.
+func processStartTime(pid int) (uint64, error) {
+	return 0, errProcessStartTimeUnsupported
+}
@@ -0,0 +1,32 @@
+//go:build linux
+// +build linux
+
+package manager
+
+import (
+	"github.com/prometheus/procfs"
+)
+
+// readProcessStats reads the resource usage of a process from procfs.
+func readProcessStats(pid int) (processStats, error) {
+	proc, err := procfs.NewProc(pid)
+	if err != nil {
+		return processStats{}, err
+	}
+
+	stat, err := proc.Stat()
+	if err != nil {
+		return processStats{}, err
+	}
+
+	fds, err := proc.FileDescriptorsLen()
+	if err != nil {
+		return processStats{}, err
+	}
+
+	return processStats{
+		residentMemoryBytes: float64(stat.ResidentMemory()),
+		cpuSeconds:          stat.CPUTime(),
+		openFDs:             float64(fds),
+	}, nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package manager
+
+// readProcessStats is only supported on Linux.
+func readProcessStats(pid int) (processStats, error) {
+	return processStats{}, errProcessStatsUnsupported
+}
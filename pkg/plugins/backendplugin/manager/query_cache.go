@@ -0,0 +1,312 @@
+package manager
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var pluginStaleQueryResults *prometheus.CounterVec
+
+func init() {
+	pluginStaleQueryResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "plugin_stale_query_results_total",
+		Help:      "The total amount of stale cached data query results served while the plugin was unavailable",
+	}, []string{"plugin_id"})
+
+	prometheus.MustRegister(pluginStaleQueryResults)
+}
+
+// defaultQueryCacheMaxEntries is the maximum number of entries of the query cache if none is configured.
+const defaultQueryCacheMaxEntries = 10000
+
+// queryDataCache caches the results of data queries. It holds at most maxEntries entries, evicting the least
+// recently used ones, so that ad hoc queries, such as the ones of Explore, can't grow it without bounds. The zero
+// value is ready to use.
+type queryDataCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+type queryCacheEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func (c *queryDataCache) getItem(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, exists := c.entries[key]
+	if !exists {
+		return nil, false
+	}
+	entry := el.Value.(*queryCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+
+	return entry.value, true
+}
+
+func (c *queryDataCache) setItem(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+		c.lru = list.New()
+	}
+	entry := &queryCacheEntry{key: key, value: value, expires: time.Now().Add(ttl)}
+	if el, exists := c.entries[key]; exists {
+		el.Value = entry
+		c.lru.MoveToFront(el)
+	} else {
+		c.entries[key] = c.lru.PushFront(entry)
+	}
+
+	maxEntries := c.maxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultQueryCacheMaxEntries
+	}
+	for c.lru.Len() > maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*queryCacheEntry).key)
+	}
+}
+
+func (c *queryDataCache) get(key string) (*backend.QueryDataResponse, bool) {
+	item, exists := c.getItem(key)
+	if !exists {
+		return nil, false
+	}
+
+	// return a copy, so callers modifying the response don't modify the cached result
+	return copyQueryDataResponse(item.(*backend.QueryDataResponse)), true
+}
+
+// set caches a copy of resp, so the caller can keep modifying the response it returns.
+func (c *queryDataCache) set(key string, resp *backend.QueryDataResponse, ttl time.Duration) {
+	c.setItem(key, copyQueryDataResponse(resp), ttl)
+}
+
+// staleQueryDataResult is the last result of the queries of a request, served when the plugin is unavailable.
+type staleQueryDataResult struct {
+	resp     *backend.QueryDataResponse
+	cachedAt time.Time
+}
+
+func (c *queryDataCache) setStale(key string, resp *backend.QueryDataResponse, cachedAt time.Time, ttl time.Duration) {
+	c.setItem(key, staleQueryDataResult{resp: copyQueryDataResponse(resp), cachedAt: cachedAt}, ttl)
+}
+
+// getStale returns the last result cached with setStale, annotated with a notice that it's stale.
+func (c *queryDataCache) getStale(key string) (*backend.QueryDataResponse, time.Time, bool) {
+	item, exists := c.getItem(key)
+	if !exists {
+		return nil, time.Time{}, false
+	}
+
+	stale := item.(staleQueryDataResult)
+	notice := data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text: fmt.Sprintf("The data source is unavailable, showing results cached at %s",
+			stale.cachedAt.UTC().Format(time.RFC3339)),
+	}
+	resp := copyQueryDataResponse(stale.resp)
+	for _, r := range resp.Responses {
+		for _, f := range r.Frames {
+			if f.Meta == nil {
+				f.Meta = &data.FrameMeta{}
+			}
+			f.Meta.Notices = append(f.Meta.Notices, notice)
+		}
+	}
+
+	return resp, stale.cachedAt, true
+}
+
+// copyQueryDataResponse returns a copy of resp whose responses, frames and frame metadata can be modified without
+// modifying resp. The fields of the frames, holding the query results, are shared.
+func copyQueryDataResponse(resp *backend.QueryDataResponse) *backend.QueryDataResponse {
+	copied := backend.NewQueryDataResponse()
+	for refID, r := range resp.Responses {
+		if r.Frames != nil {
+			frames := make(data.Frames, 0, len(r.Frames))
+			for _, f := range r.Frames {
+				if f == nil {
+					frames = append(frames, nil)
+					continue
+				}
+				frame := *f
+				frame.Fields = append([]*data.Field(nil), f.Fields...)
+				if f.Meta != nil {
+					meta := *f.Meta
+					meta.Notices = append([]data.Notice(nil), f.Meta.Notices...)
+					frame.Meta = &meta
+				}
+				frames = append(frames, &frame)
+			}
+			r.Frames = frames
+		}
+		copied.Responses[refID] = r
+	}
+
+	return copied
+}
+
+type queryCacheJSONModel struct {
+	QueryCacheTTL *int `json:"queryCacheTTL"`
+}
+
+// queryCacheTTL returns how long results of data queries are cached. The TTL in seconds can be set per data
+// source with the queryCacheTTL JSON data field, per plugin with the query_cache_ttl setting and globally.
+// 0 means results aren't cached.
+func (m *Manager) queryCacheTTL(pCtx backend.PluginContext) time.Duration {
+	ttl := getPluginIntSetting(pCtx.PluginID, "query_cache_ttl", m.Cfg, m.Cfg.PluginsQueryCacheTTL)
+	if dis := pCtx.DataSourceInstanceSettings; dis != nil && len(dis.JSONData) > 0 {
+		model := queryCacheJSONModel{}
+		if err := json.Unmarshal(dis.JSONData, &model); err == nil && model.QueryCacheTTL != nil {
+			ttl = *model.QueryCacheTTL
+		}
+	}
+
+	if ttl <= 0 {
+		return 0
+	}
+
+	return time.Duration(ttl) * time.Second
+}
+
+// queryCacheStaleTTL returns how long after they expired cached results of data queries are served when the plugin
+// is unavailable, which can be set per plugin with the query_cache_stale_ttl setting and globally. 0 means stale
+// results aren't served.
+func (m *Manager) queryCacheStaleTTL(pluginID string) time.Duration {
+	ttl := getPluginIntSetting(pluginID, "query_cache_stale_ttl", m.Cfg, m.Cfg.PluginsQueryCacheStaleTTL)
+	if ttl <= 0 {
+		return 0
+	}
+
+	return time.Duration(ttl) * time.Second
+}
+
+// queryCacheKey returns the cache key of a data query request. The key is built from the plugin, the data source,
+// the request headers and the normalized queries, where the time ranges are truncated to buckets of the cache TTL
+// so repeated refreshes of relative time ranges hit the cache.
+func queryCacheKey(req *backend.QueryDataRequest, ttl time.Duration) (string, error) {
+	return buildQueryCacheKey(req, ttl, true)
+}
+
+// queryStaleCacheKey returns the key of the last result of the queries of a data query request for time ranges of
+// the same duration, so that results of relative time ranges can be served stale after the time range moved on,
+// but not for a range of another duration, such as the last 7 days instead of the last hour.
+func queryStaleCacheKey(req *backend.QueryDataRequest) (string, error) {
+	key, err := buildQueryCacheKey(req, 0, false)
+	if err != nil {
+		return "", err
+	}
+
+	return "stale/" + key, nil
+}
+
+func buildQueryCacheKey(req *backend.QueryDataRequest, ttl time.Duration, withTimeRange bool) (string, error) {
+	type cacheKeyQuery struct {
+		RefID         string          `json:"refId"`
+		QueryType     string          `json:"queryType"`
+		MaxDataPoints int64           `json:"maxDataPoints"`
+		Interval      time.Duration   `json:"interval"`
+		From          int64           `json:"from"`
+		To            int64           `json:"to"`
+		Duration      time.Duration   `json:"duration"`
+		JSON          json.RawMessage `json:"json"`
+	}
+
+	queries := make([]cacheKeyQuery, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		normalized, err := normalizeJSON(q.JSON)
+		if err != nil {
+			return "", err
+		}
+
+		query := cacheKeyQuery{
+			RefID:         q.RefID,
+			QueryType:     q.QueryType,
+			MaxDataPoints: q.MaxDataPoints,
+			Interval:      q.Interval,
+			JSON:          normalized,
+		}
+		if withTimeRange {
+			query.From = q.TimeRange.From.Truncate(ttl).Unix()
+			query.To = q.TimeRange.To.Truncate(ttl).Unix()
+		} else {
+			query.Duration = q.TimeRange.Duration()
+		}
+		queries = append(queries, query)
+	}
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].RefID < queries[j].RefID
+	})
+
+	var dsUID string
+	if dis := req.PluginContext.DataSourceInstanceSettings; dis != nil {
+		dsUID = dis.UID
+	}
+
+	b, err := json.Marshal(struct {
+		Headers map[string]string `json:"headers"`
+		Queries []cacheKeyQuery   `json:"queries"`
+	}{req.Headers, queries})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(b)
+	return fmt.Sprintf("%s/%d/%s/%s", req.PluginContext.PluginID, req.PluginContext.OrgID, dsUID,
+		hex.EncodeToString(hash[:])), nil
+}
+
+// normalizeJSON returns raw with object keys sorted and insignificant whitespace removed.
+func normalizeJSON(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return raw, nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+// isCacheableQueryDataResponse reports whether none of the query results of resp are errors.
+func isCacheableQueryDataResponse(resp *backend.QueryDataResponse) bool {
+	if resp == nil {
+		return false
+	}
+
+	for _, r := range resp.Responses {
+		if r.Error != nil {
+			return false
+		}
+	}
+
+	return true
+}
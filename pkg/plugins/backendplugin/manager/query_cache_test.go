@@ -0,0 +1,222 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCacheKey(t *testing.T) {
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	newRequest := func(queryJSON string, to time.Time) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				PluginID:                   "test",
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds"},
+			},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				JSON:      json.RawMessage(queryJSON),
+				TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to},
+			}},
+		}
+	}
+
+	key, err := queryCacheKey(newRequest(`{"a": 1, "b": 2}`, now), time.Minute)
+	require.NoError(t, err)
+
+	sameKey, err := queryCacheKey(newRequest(`{"b":2,"a":1}`, now.Add(30*time.Second)), time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, key, sameKey)
+
+	otherKey, err := queryCacheKey(newRequest(`{"a": 1, "b": 2}`, now.Add(time.Minute)), time.Minute)
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherKey)
+
+	otherKey, err = queryCacheKey(newRequest(`{"a": 1, "b": 3}`, now), time.Minute)
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherKey)
+
+	_, err = queryCacheKey(newRequest(`{`, now), time.Minute)
+	require.Error(t, err)
+
+	staleKey, err := queryStaleCacheKey(newRequest(`{"a": 1, "b": 2}`, now))
+	require.NoError(t, err)
+	sameKey, err = queryStaleCacheKey(newRequest(`{"a": 1, "b": 2}`, now.Add(time.Hour)))
+	require.NoError(t, err)
+	require.Equal(t, staleKey, sameKey)
+	require.NotEqual(t, key, staleKey)
+
+	longerRange := newRequest(`{"a": 1, "b": 2}`, now)
+	longerRange.Queries[0].TimeRange.From = now.Add(-7 * 24 * time.Hour)
+	otherKey, err = queryStaleCacheKey(longerRange)
+	require.NoError(t, err)
+	require.NotEqual(t, staleKey, otherKey)
+}
+
+func TestQueryDataCache(t *testing.T) {
+	newResponse := func() *backend.QueryDataResponse {
+		resp := backend.NewQueryDataResponse()
+		resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{data.NewFrame("A")}}
+		return resp
+	}
+
+	t.Run("Should not be affected by modifying the cached response", func(t *testing.T) {
+		c := queryDataCache{}
+		resp := newResponse()
+		c.set("key", resp, time.Minute)
+
+		resp.Responses["B"] = backend.DataResponse{}
+		resp.Responses["A"].Frames[0].Name = "modified"
+		resp.Responses["A"].Frames[0].Meta = &data.FrameMeta{ExecutedQueryString: "modified"}
+
+		cached, exists := c.get("key")
+		require.True(t, exists)
+		require.Equal(t, newResponse(), cached)
+	})
+
+	t.Run("Should not be affected by modifying a returned response", func(t *testing.T) {
+		c := queryDataCache{}
+		c.set("key", newResponse(), time.Minute)
+
+		resp, exists := c.get("key")
+		require.True(t, exists)
+		resp.Responses["A"].Frames[0].Name = "modified"
+
+		cached, exists := c.get("key")
+		require.True(t, exists)
+		require.Equal(t, newResponse(), cached)
+	})
+
+	t.Run("Should evict the least recently used results beyond the maximum number of entries", func(t *testing.T) {
+		c := queryDataCache{maxEntries: 2}
+		c.set("a", newResponse(), time.Minute)
+		c.setStale("b", newResponse(), time.Now(), time.Minute)
+		_, exists := c.get("a")
+		require.True(t, exists)
+
+		c.set("c", newResponse(), time.Minute)
+		require.Equal(t, 2, c.lru.Len())
+		_, _, exists = c.getStale("b")
+		require.False(t, exists)
+		_, exists = c.get("a")
+		require.True(t, exists)
+		_, exists = c.get("c")
+		require.True(t, exists)
+	})
+
+	t.Run("Should not return expired results", func(t *testing.T) {
+		c := queryDataCache{}
+		c.set("key", newResponse(), -time.Second)
+
+		_, exists := c.get("key")
+		require.False(t, exists)
+		require.Zero(t, c.lru.Len())
+	})
+}
+
+func TestManager_ServeStaleQueryData(t *testing.T) {
+	newRequest := func(to time.Time) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				PluginID:                   "test",
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds"},
+			},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				JSON:      json.RawMessage(`{}`),
+				TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to},
+			}},
+		}
+	}
+
+	setup := func(staleTTL int) (*Manager, *testPlugin) {
+		m, p := newStreamTestManager(nil)
+		m.Cfg.PluginsQueryCacheTTL = 60
+		m.Cfg.PluginsQueryCacheStaleTTL = staleTTL
+		p.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{data.NewFrame("A")}}
+			return resp, nil
+		}
+		return m, p
+	}
+
+	failQueries := func(p *testPlugin) {
+		p.QueryDataHandlerFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			return nil, backendplugin.ErrPluginUnavailable
+		}
+	}
+
+	now := time.Now()
+
+	t.Run("Should serve the last result of an unavailable plugin annotated as stale", func(t *testing.T) {
+		m, p := setup(600)
+		_, err := m.QueryData(context.Background(), newRequest(now))
+		require.NoError(t, err)
+
+		failQueries(p)
+		resp, err := m.QueryData(context.Background(), newRequest(now.Add(time.Hour)))
+		require.NoError(t, err)
+		frames := resp.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Len(t, frames[0].Meta.Notices, 1)
+		require.Equal(t, data.NoticeSeverityWarning, frames[0].Meta.Notices[0].Severity)
+
+		// the notice shouldn't be added to the cached result
+		resp, err = m.QueryData(context.Background(), newRequest(now.Add(time.Hour)))
+		require.NoError(t, err)
+		require.Len(t, resp.Responses["A"].Frames[0].Meta.Notices, 1)
+	})
+
+	t.Run("Should serve the last result of a crashed plugin", func(t *testing.T) {
+		m, p := setup(600)
+		_, err := m.QueryData(context.Background(), newRequest(now))
+		require.NoError(t, err)
+
+		m.pluginFailures.fail(p.PluginID(), time.Now())
+		resp, err := m.QueryData(context.Background(), newRequest(now.Add(time.Hour)))
+		require.NoError(t, err)
+		require.Len(t, resp.Responses["A"].Frames, 1)
+	})
+
+	t.Run("Should return the error when serving stale results is disabled", func(t *testing.T) {
+		m, p := setup(0)
+		_, err := m.QueryData(context.Background(), newRequest(now))
+		require.NoError(t, err)
+
+		failQueries(p)
+		_, err = m.QueryData(context.Background(), newRequest(now.Add(time.Hour)))
+		require.ErrorIs(t, err, backendplugin.ErrPluginUnavailable)
+	})
+
+	t.Run("Should return the error when there's no cached result", func(t *testing.T) {
+		m, p := setup(600)
+		failQueries(p)
+		_, err := m.QueryData(context.Background(), newRequest(now))
+		require.ErrorIs(t, err, backendplugin.ErrPluginUnavailable)
+	})
+}
+
+func TestQueryCacheTTL(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.PluginsQueryCacheTTL = 10
+	m := &Manager{Cfg: cfg}
+
+	require.Equal(t, 10*time.Second, m.queryCacheTTL(backend.PluginContext{PluginID: "test"}))
+
+	cfg.PluginSettings = setting.PluginSettings{"test": map[string]string{"query_cache_ttl": "20"}}
+	require.Equal(t, 20*time.Second, m.queryCacheTTL(backend.PluginContext{PluginID: "test"}))
+
+	require.Equal(t, time.Duration(0), m.queryCacheTTL(backend.PluginContext{
+		PluginID:                   "test",
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(`{"queryCacheTTL":0}`)},
+	}))
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// querySemaphores limits the number of in-flight data queries per data source. The zero value is ready to use.
+type querySemaphores struct {
+	mu         sync.Mutex
+	semaphores map[string]chan struct{}
+}
+
+// acquire waits up to queueTimeout for one of the limit slots of key to become available and returns a function
+// releasing it. If queueTimeout is 0 the query is rejected immediately when all slots are in use.
+func (s *querySemaphores) acquire(ctx context.Context, key string, limit int, queueTimeout time.Duration) (func(), error) {
+	s.mu.Lock()
+	if s.semaphores == nil {
+		s.semaphores = map[string]chan struct{}{}
+	}
+	sem, exists := s.semaphores[key]
+	if !exists || cap(sem) != limit {
+		sem = make(chan struct{}, limit)
+		s.semaphores[key] = sem
+	}
+	s.mu.Unlock()
+
+	release := func() {
+		<-sem
+	}
+
+	select {
+	case sem <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if queueTimeout <= 0 {
+		return nil, fmt.Errorf("%w: limit is %d", backendplugin.ErrTooManyQueries, limit)
+	}
+
+	timer := time.NewTimer(queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case sem <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: limit is %d", backendplugin.ErrTooManyQueries, limit)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type queryConcurrencyJSONModel struct {
+	MaxConcurrentQueries *int `json:"maxConcurrentQueries"`
+}
+
+// queryMaxConcurrency returns the maximum number of in-flight data queries per data source. It can be set per data
+// source with the maxConcurrentQueries JSON data field, per plugin with the query_max_concurrency setting and
+// globally. 0 means unlimited.
+func (m *Manager) queryMaxConcurrency(pCtx backend.PluginContext) int {
+	limit := getPluginIntSetting(pCtx.PluginID, "query_max_concurrency", m.Cfg, m.Cfg.PluginsQueryMaxConcurrency)
+	if dis := pCtx.DataSourceInstanceSettings; dis != nil && len(dis.JSONData) > 0 {
+		model := queryConcurrencyJSONModel{}
+		if err := json.Unmarshal(dis.JSONData, &model); err == nil && model.MaxConcurrentQueries != nil {
+			limit = *model.MaxConcurrentQueries
+		}
+	}
+
+	return limit
+}
+
+// acquireQuerySlot enforces the data source concurrency limit for a data query. The returned function must be
+// called when the query is done.
+func (m *Manager) acquireQuerySlot(ctx context.Context, pCtx backend.PluginContext) (func(), error) {
+	limit := m.queryMaxConcurrency(pCtx)
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	key := fmt.Sprintf("%s/%d", pCtx.PluginID, pCtx.OrgID)
+	if dis := pCtx.DataSourceInstanceSettings; dis != nil {
+		key = fmt.Sprintf("%s/%d/%d", pCtx.PluginID, pCtx.OrgID, dis.ID)
+	}
+
+	queueTimeout := getPluginIntSetting(pCtx.PluginID, "query_queue_timeout", m.Cfg, m.Cfg.PluginsQueryQueueTimeout)
+	return m.querySemaphores.acquire(ctx, key, limit, time.Duration(queueTimeout)*time.Second)
+}
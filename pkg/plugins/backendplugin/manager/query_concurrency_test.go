@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerySemaphores(t *testing.T) {
+	s := querySemaphores{}
+
+	release, err := s.acquire(context.Background(), "ds", 1, 0)
+	require.NoError(t, err)
+
+	t.Run("Should reject when limit is reached and not queueing", func(t *testing.T) {
+		_, err := s.acquire(context.Background(), "ds", 1, 0)
+		require.ErrorIs(t, err, backendplugin.ErrTooManyQueries)
+	})
+
+	t.Run("Should reject when queue timeout expires", func(t *testing.T) {
+		_, err := s.acquire(context.Background(), "ds", 1, 10*time.Millisecond)
+		require.ErrorIs(t, err, backendplugin.ErrTooManyQueries)
+	})
+
+	t.Run("Should not limit other keys", func(t *testing.T) {
+		otherRelease, err := s.acquire(context.Background(), "other", 1, 0)
+		require.NoError(t, err)
+		otherRelease()
+	})
+
+	t.Run("Should acquire queued slot when released", func(t *testing.T) {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			release()
+		}()
+
+		release, err := s.acquire(context.Background(), "ds", 1, time.Minute)
+		require.NoError(t, err)
+		release()
+	})
+}
@@ -0,0 +1,182 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// queryResultLimiter limits the total number of frame rows and the total size of the frames returned for a data
+// query request. Frames exceeding the limits are truncated and get a warning notice added to their metadata. The
+// limits apply to the results returned to the caller, once the response of the plugin is decoded, so they don't
+// limit the memory used to receive the response.
+type queryResultLimiter struct {
+	maxRows  int
+	maxBytes int
+	rows     int
+	bytes    int
+}
+
+// newQueryResultLimiter returns the result limiter for a data query request of a plugin, or nil if the results
+// aren't limited.
+func (m *Manager) newQueryResultLimiter(pluginID string) *queryResultLimiter {
+	maxRows := getPluginIntSetting(pluginID, "query_max_rows", m.Cfg, m.Cfg.PluginsQueryMaxRows)
+	maxBytes := getPluginIntSetting(pluginID, "query_max_bytes", m.Cfg, m.Cfg.PluginsQueryMaxBytes)
+	if maxRows <= 0 && maxBytes <= 0 {
+		return nil
+	}
+
+	return &queryResultLimiter{maxRows: maxRows, maxBytes: maxBytes}
+}
+
+// limitResponse truncates the frames of all query results of resp. Results are processed in the order of the
+// queries of the request.
+func (l *queryResultLimiter) limitResponse(req *backend.QueryDataRequest, resp *backend.QueryDataResponse) {
+	if l == nil || resp == nil {
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, q := range req.Queries {
+		if r, exists := resp.Responses[q.RefID]; exists && !seen[q.RefID] {
+			seen[q.RefID] = true
+			resp.Responses[q.RefID] = l.limit(r)
+		}
+	}
+
+	for refID, r := range resp.Responses {
+		if !seen[refID] {
+			resp.Responses[refID] = l.limit(r)
+		}
+	}
+}
+
+// limit truncates the frames of a query result when the limits are exceeded.
+func (l *queryResultLimiter) limit(resp backend.DataResponse) backend.DataResponse {
+	if l == nil || len(resp.Frames) == 0 {
+		return resp
+	}
+
+	frames := make(data.Frames, 0, len(resp.Frames))
+	for _, frame := range resp.Frames {
+		rows := frame.Rows()
+		allowedRows := rows
+		reason := ""
+
+		if l.maxRows > 0 && l.rows+allowedRows > l.maxRows {
+			allowedRows = l.maxRows - l.rows
+			reason = fmt.Sprintf("the limit of %d rows", l.maxRows)
+		}
+
+		if l.maxBytes > 0 && rows > 0 {
+			size := frameSize(frame)
+			if l.bytes+size > l.maxBytes {
+				// assume rows are of similar size
+				byteRows := int(int64(l.maxBytes-l.bytes) * int64(rows) / int64(size))
+				if byteRows < allowedRows {
+					allowedRows = byteRows
+					reason = fmt.Sprintf("the limit of %d bytes", l.maxBytes)
+				}
+			}
+			l.bytes += size * allowedRows / rows
+		}
+
+		if allowedRows < 0 {
+			allowedRows = 0
+		}
+		l.rows += allowedRows
+
+		if allowedRows < rows {
+			frame = truncateFrame(frame, allowedRows)
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text: fmt.Sprintf("Query result was truncated from %d to %d rows because the results exceed %s",
+					rows, allowedRows, reason),
+			})
+		}
+		frames = append(frames, frame)
+	}
+
+	resp.Frames = frames
+	return resp
+}
+
+// frameSize returns the estimated size in bytes of the values of a frame, computed from the lengths of its fields
+// and the sizes of their elements rather than by serializing the frame.
+func frameSize(frame *data.Frame) int {
+	size := 0
+	for _, field := range frame.Fields {
+		size += fieldSize(field)
+	}
+	return size
+}
+
+func fieldSize(field *data.Field) int {
+	rows := field.Len()
+	fieldType := field.Type()
+
+	size := 0
+	if fieldType.Nullable() {
+		// validity bitmap
+		size += (rows + 7) / 8
+	}
+
+	switch fieldType {
+	case data.FieldTypeString:
+		for i := 0; i < rows; i++ {
+			size += len(field.At(i).(string))
+		}
+	case data.FieldTypeNullableString:
+		for i := 0; i < rows; i++ {
+			if s := field.At(i).(*string); s != nil {
+				size += len(*s)
+			}
+		}
+	default:
+		size += rows * fieldElementSize(fieldType.NonNullableType())
+	}
+
+	return size
+}
+
+// fieldElementSize returns the size in bytes of an element of a field of a fixed size type.
+func fieldElementSize(fieldType data.FieldType) int {
+	switch fieldType {
+	case data.FieldTypeInt8, data.FieldTypeUint8, data.FieldTypeBool:
+		return 1
+	case data.FieldTypeInt16, data.FieldTypeUint16:
+		return 2
+	case data.FieldTypeInt32, data.FieldTypeUint32, data.FieldTypeFloat32:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// truncateFrame returns a copy of frame containing only its first rows.
+func truncateFrame(frame *data.Frame, rows int) *data.Frame {
+	truncated := &data.Frame{
+		Name:   frame.Name,
+		RefID:  frame.RefID,
+		Meta:   frame.Meta,
+		Fields: make(data.Fields, 0, len(frame.Fields)),
+	}
+	if frame.Meta != nil {
+		meta := *frame.Meta
+		truncated.Meta = &meta
+	}
+
+	for _, field := range frame.Fields {
+		fieldCopy := data.NewFieldFromFieldType(field.Type(), rows)
+		fieldCopy.Name = field.Name
+		fieldCopy.Labels = field.Labels.Copy()
+		fieldCopy.Config = field.Config
+		for i := 0; i < rows; i++ {
+			fieldCopy.Set(i, field.CopyAt(i))
+		}
+		truncated.Fields = append(truncated.Fields, fieldCopy)
+	}
+
+	return truncated
+}
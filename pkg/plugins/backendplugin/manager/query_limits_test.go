@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryResultLimiter(t *testing.T) {
+	newFrame := func(rows int) *data.Frame {
+		values := make([]int64, rows)
+		for i := range values {
+			values[i] = int64(i)
+		}
+		return data.NewFrame("frame", data.NewField("value", data.Labels{"a": "b"}, values))
+	}
+
+	t.Run("Should truncate frames exceeding row limit in query order", func(t *testing.T) {
+		req := &backend.QueryDataRequest{Queries: []backend.DataQuery{{RefID: "A"}, {RefID: "B"}}}
+		resp := backend.NewQueryDataResponse()
+		resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{newFrame(3), newFrame(3)}}
+		resp.Responses["B"] = backend.DataResponse{Frames: data.Frames{newFrame(3)}}
+
+		l := &queryResultLimiter{maxRows: 4}
+		l.limitResponse(req, resp)
+
+		a := resp.Responses["A"].Frames
+		require.Equal(t, 3, a[0].Rows())
+		require.Nil(t, a[0].Meta)
+		require.Equal(t, 1, a[1].Rows())
+		require.Equal(t, data.Labels{"a": "b"}, a[1].Fields[0].Labels)
+		require.Len(t, a[1].Meta.Notices, 1)
+		require.Equal(t, data.NoticeSeverityWarning, a[1].Meta.Notices[0].Severity)
+
+		b := resp.Responses["B"].Frames
+		require.Equal(t, 0, b[0].Rows())
+		require.Len(t, b[0].Meta.Notices, 1)
+	})
+
+	t.Run("Should truncate frames exceeding byte limit", func(t *testing.T) {
+		frame := newFrame(1000)
+		size := frameSize(frame)
+		require.Greater(t, size, 0)
+
+		l := &queryResultLimiter{maxBytes: size / 2}
+		resp := l.limit(backend.DataResponse{Frames: data.Frames{frame}})
+		require.Less(t, resp.Frames[0].Rows(), 1000)
+		require.Greater(t, resp.Frames[0].Rows(), 0)
+		require.Len(t, resp.Frames[0].Meta.Notices, 1)
+	})
+
+	t.Run("Should estimate frame sizes from field types", func(t *testing.T) {
+		s := "four"
+		frame := data.NewFrame("frame",
+			data.NewField("int64", nil, []int64{1, 2}),
+			data.NewField("int8", nil, []int8{1, 2}),
+			data.NewField("string", nil, []string{"a", "bcd"}),
+			data.NewField("nullable", nil, []*string{&s, nil}),
+		)
+		require.Equal(t, 16+2+4+1+4, frameSize(frame))
+	})
+
+	t.Run("Nil limiter should not limit results", func(t *testing.T) {
+		var l *queryResultLimiter
+		resp := l.limit(backend.DataResponse{Frames: data.Frames{newFrame(3)}})
+		require.Equal(t, 3, resp.Frames[0].Rows())
+	})
+}
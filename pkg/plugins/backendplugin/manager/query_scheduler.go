@@ -0,0 +1,181 @@
+package manager
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// fairQueryQueue limits the number of in-flight data queries of a plugin and, when the limit is reached, hands out
+// free slots to the waiting queries of the organizations in weighted round-robin order, so a single organization
+// can't starve the others.
+type fairQueryQueue struct {
+	mu      sync.Mutex
+	limit   int
+	running int
+	waiting map[int64][]chan struct{}
+	// orgs are the organizations with waiting queries in round-robin order
+	orgs []int64
+	// turns is the number of slots handed out to the current organization in its turn
+	turns int
+}
+
+func newFairQueryQueue(limit int) *fairQueryQueue {
+	return &fairQueryQueue{
+		limit:   limit,
+		waiting: map[int64][]chan struct{}{},
+	}
+}
+
+// acquire waits for a free slot for a query of orgID, and returns a function releasing it. weight is the number of
+// consecutive slots handed out to the organization in its turn when queries are waiting.
+func (q *fairQueryQueue) acquire(ctx context.Context, orgID int64, weight func(orgID int64) int) (func(), error) {
+	q.mu.Lock()
+	if q.running < q.limit && len(q.orgs) == 0 {
+		q.running++
+		q.mu.Unlock()
+		return func() { q.release(weight) }, nil
+	}
+
+	ready := make(chan struct{})
+	if _, exists := q.waiting[orgID]; !exists {
+		q.orgs = append(q.orgs, orgID)
+	}
+	q.waiting[orgID] = append(q.waiting[orgID], ready)
+	q.mu.Unlock()
+
+	select {
+	case <-ready:
+		return func() { q.release(weight) }, nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		removed := q.remove(orgID, ready)
+		q.mu.Unlock()
+		if !removed {
+			// the slot was handed out while the context was canceled
+			q.release(weight)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (q *fairQueryQueue) release(weight func(orgID int64) int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.running--
+	for q.running < q.limit && len(q.orgs) > 0 {
+		orgID := q.orgs[0]
+		waiters := q.waiting[orgID]
+		close(waiters[0])
+		q.running++
+		q.turns++
+
+		if len(waiters) == 1 {
+			delete(q.waiting, orgID)
+			q.orgs = q.orgs[1:]
+			q.turns = 0
+			continue
+		}
+
+		q.waiting[orgID] = waiters[1:]
+		if q.turns >= weight(orgID) {
+			// move the organization to the end of the round
+			q.orgs = append(q.orgs[1:], orgID)
+			q.turns = 0
+		}
+	}
+}
+
+// remove removes a waiting query, and reports whether it was still waiting.
+func (q *fairQueryQueue) remove(orgID int64, ready chan struct{}) bool {
+	waiters := q.waiting[orgID]
+	for i, w := range waiters {
+		if w != ready {
+			continue
+		}
+
+		waiters = append(waiters[:i], waiters[i+1:]...)
+		if len(waiters) > 0 {
+			q.waiting[orgID] = waiters
+			return true
+		}
+
+		delete(q.waiting, orgID)
+		for j, id := range q.orgs {
+			if id == orgID {
+				if j == 0 {
+					q.turns = 0
+				}
+				q.orgs = append(q.orgs[:j], q.orgs[j+1:]...)
+				break
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// fairQueryQueues holds the fair query queue of each plugin. The zero value is ready to use.
+type fairQueryQueues struct {
+	mu     sync.Mutex
+	queues map[string]*fairQueryQueue
+}
+
+func (q *fairQueryQueues) get(pluginID string, limit int) *fairQueryQueue {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queues == nil {
+		q.queues = map[string]*fairQueryQueue{}
+	}
+	queue, exists := q.queues[pluginID]
+	if !exists || queue.limit != limit {
+		queue = newFairQueryQueue(limit)
+		q.queues[pluginID] = queue
+	}
+
+	return queue
+}
+
+// acquireFairQuerySlot waits for a query slot of the plugin when the number of in-flight queries of the plugin is
+// limited. The returned function must be called when the query is done.
+func (m *Manager) acquireFairQuerySlot(ctx context.Context, pluginID string, orgID int64) (func(), error) {
+	limit := getPluginIntSetting(pluginID, "query_scheduler_max_concurrency", m.Cfg,
+		m.Cfg.PluginsQuerySchedulerMaxConcurrency)
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	return m.fairQueryQueues.get(pluginID, limit).acquire(ctx, orgID, m.queryOrgWeight)
+}
+
+// queryOrgWeight returns the scheduling weight of an organization, defaulting to 1.
+func (m *Manager) queryOrgWeight(orgID int64) int {
+	if weight, exists := m.Cfg.PluginsQueryOrgWeights[orgID]; exists && weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+// acquireQuerySlots enforces the data source concurrency limit and the fair scheduling of the queries of a plugin.
+// The returned function must be called when the query is done.
+func (m *Manager) acquireQuerySlots(ctx context.Context, pluginID string, pCtx backend.PluginContext) (func(), error) {
+	release, err := m.acquireQuerySlot(ctx, pCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseFair, err := m.acquireFairQuerySlot(ctx, pluginID, pCtx.OrgID)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	return func() {
+		releaseFair()
+		release()
+	}, nil
+}
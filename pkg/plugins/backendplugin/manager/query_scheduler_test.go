@@ -0,0 +1,75 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFairQueryQueue(t *testing.T) {
+	weights := map[int64]int{1: 1, 2: 1, 3: 2}
+	weight := func(orgID int64) int {
+		return weights[orgID]
+	}
+
+	q := newFairQueryQueue(1)
+	release, err := q.acquire(context.Background(), 1, weight)
+	require.NoError(t, err)
+
+	granted := make(chan int64, 10)
+	queued := 0
+	enqueue := func(orgID int64) {
+		go func() {
+			release, err := q.acquire(context.Background(), orgID, weight)
+			require.NoError(t, err)
+			granted <- orgID
+			release()
+		}()
+
+		queued++
+		require.Eventually(t, func() bool {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			n := 0
+			for _, waiters := range q.waiting {
+				n += len(waiters)
+			}
+			return n == queued
+		}, time.Second, time.Millisecond)
+	}
+
+	// block the queue while enqueueing, so granted order only depends on scheduling
+	enqueue(1)
+	enqueue(1)
+	enqueue(1)
+	enqueue(2)
+	enqueue(3)
+	enqueue(3)
+	enqueue(3)
+
+	release()
+
+	var order []int64
+	for i := 0; i < queued; i++ {
+		order = append(order, <-granted)
+	}
+	require.Equal(t, []int64{1, 2, 3, 3, 1, 3, 1}, order)
+
+	t.Run("Should stop waiting when context is canceled", func(t *testing.T) {
+		release, err := q.acquire(context.Background(), 1, weight)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = q.acquire(ctx, 2, weight)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Empty(t, q.orgs)
+
+		release()
+		release, err = q.acquire(context.Background(), 2, weight)
+		require.NoError(t, err)
+		release()
+	})
+}
@@ -0,0 +1,202 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	gocache "github.com/patrickmn/go-cache"
+)
+
+// maxCachedResourceResponseBytes is the maximum size of a resource response body that is cached.
+const maxCachedResourceResponseBytes = 1024 * 1024
+
+type cachedResourceResponse struct {
+	status  int
+	headers http.Header
+	body    []byte
+}
+
+// resourceResponseCache caches responses of idempotent resource calls. The zero value is ready to use.
+type resourceResponseCache struct {
+	once  sync.Once
+	cache *gocache.Cache
+}
+
+func (c *resourceResponseCache) get(key string) (*cachedResourceResponse, bool) {
+	c.init()
+	item, exists := c.cache.Get(key)
+	if !exists {
+		return nil, false
+	}
+
+	return item.(*cachedResourceResponse), true
+}
+
+func (c *resourceResponseCache) set(key string, resp *cachedResourceResponse, ttl time.Duration) {
+	c.init()
+	c.cache.Set(key, resp, ttl)
+}
+
+func (c *resourceResponseCache) init() {
+	c.once.Do(func() {
+		c.cache = gocache.New(gocache.NoExpiration, 5*time.Minute)
+	})
+}
+
+// resourceCacheTTL returns how long GET responses of a plugin resource path are cached, 0 means not cached.
+func (m *Manager) resourceCacheTTL(pluginID string, path string) time.Duration {
+	ttl := getPluginIntSetting(pluginID, "resource_cache_ttl", m.Cfg, m.Cfg.PluginsResourceCacheTTL)
+	if ttl <= 0 {
+		return 0
+	}
+
+	if paths := getPluginStringListSetting(pluginID, "resource_cache_paths", m.Cfg, nil); len(paths) > 0 {
+		matches := false
+		for _, p := range paths {
+			if strings.HasPrefix(strings.TrimPrefix(path, "/"), strings.TrimPrefix(p, "/")) {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			return 0
+		}
+	}
+
+	return time.Duration(ttl) * time.Second
+}
+
+// callResourceCached serves GET resource calls from the resource response cache if caching is enabled for
+// the requested path, otherwise the call is forwarded to the plugin and a successful response is cached.
+func (m *Manager) callResourceCached(w http.ResponseWriter, req *http.Request, pCtx backend.PluginContext) error {
+	ttl := m.resourceCacheTTL(pCtx.PluginID, req.URL.Path)
+	if ttl <= 0 || req.Method != http.MethodGet || websocket.IsWebSocketUpgrade(req) {
+		return m.callResourceInternal(w, req, pCtx)
+	}
+
+	requestDirectives := parseCacheControl(req.Header.Get("Cache-Control"))
+	key := resourceCacheKey(req, pCtx)
+	if !requestDirectives.noCache && !requestDirectives.noStore {
+		if resp, exists := m.resourceCache.get(key); exists {
+			for k, values := range resp.headers {
+				w.Header()[k] = values
+			}
+			w.WriteHeader(resp.status)
+			_, err := w.Write(resp.body)
+			return err
+		}
+	}
+
+	rec := &cacheResponseRecorder{ResponseWriter: w}
+	if err := m.callResourceInternal(rec, req, pCtx); err != nil {
+		return err
+	}
+
+	if rec.status != http.StatusOK || rec.tooLarge || requestDirectives.noStore {
+		return nil
+	}
+
+	responseDirectives := parseCacheControl(rec.headers.Get("Cache-Control"))
+	if responseDirectives.noCache || responseDirectives.noStore {
+		return nil
+	}
+	if responseDirectives.maxAge != nil {
+		ttl = time.Duration(*responseDirectives.maxAge) * time.Second
+	}
+	if ttl > 0 {
+		m.resourceCache.set(key, &cachedResourceResponse{
+			status:  rec.status,
+			headers: rec.headers,
+			body:    rec.body.Bytes(),
+		}, ttl)
+	}
+
+	return nil
+}
+
+// resourceCacheKey returns the cache key of a resource call, scoped to the data source, organization and user.
+func resourceCacheKey(req *http.Request, pCtx backend.PluginContext) string {
+	var dsID int64
+	if pCtx.DataSourceInstanceSettings != nil {
+		dsID = pCtx.DataSourceInstanceSettings.ID
+	}
+	var login string
+	if pCtx.User != nil {
+		login = pCtx.User.Login
+	}
+
+	return fmt.Sprintf("%s/%d/%d/%s:%s?%s#%s", pCtx.PluginID, dsID, pCtx.OrgID, login, req.URL.Path,
+		req.URL.RawQuery, req.Header.Get("Accept-Encoding"))
+}
+
+type cacheControl struct {
+	noCache bool
+	noStore bool
+	maxAge  *int
+}
+
+func parseCacheControl(header string) cacheControl {
+	cc := cacheControl{}
+	for _, directive := range strings.Split(header, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-cache":
+			cc.noCache = true
+		case directive == "no-store":
+			cc.noStore = true
+		case strings.HasPrefix(directive, "max-age="):
+			if maxAge, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				cc.maxAge = &maxAge
+			}
+		}
+	}
+
+	return cc
+}
+
+// cacheResponseRecorder writes a resource response through to the client while recording it for caching.
+type cacheResponseRecorder struct {
+	http.ResponseWriter
+	status   int
+	headers  http.Header
+	body     bytes.Buffer
+	tooLarge bool
+}
+
+func (r *cacheResponseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+		r.headers = r.ResponseWriter.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *cacheResponseRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	if !r.tooLarge {
+		if r.body.Len()+len(p) > maxCachedResourceResponseBytes {
+			r.tooLarge = true
+			r.body.Reset()
+		} else {
+			r.body.Write(p)
+		}
+	}
+
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *cacheResponseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,23 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	cc := parseCacheControl("public, Max-Age=30")
+	require.False(t, cc.noCache)
+	require.False(t, cc.noStore)
+	require.NotNil(t, cc.maxAge)
+	require.Equal(t, 30, *cc.maxAge)
+
+	cc = parseCacheControl("no-cache, no-store")
+	require.True(t, cc.noCache)
+	require.True(t, cc.noStore)
+	require.Nil(t, cc.maxAge)
+
+	cc = parseCacheControl("")
+	require.Equal(t, cacheControl{}, cc)
+}
@@ -0,0 +1,162 @@
+package manager
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressResponseWriter compresses a resource response if the client accepts gzip or deflate encoding, the
+// plugin didn't already encode the response, the content type is allowed and the first chunk of the body is
+// at least minBytes large. Writing the status code is delayed until the first chunk of the body is written.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding     string
+	minBytes     int
+	contentTypes []string
+	status       int
+	wroteHeader  bool
+	compressor   compressor
+}
+
+func newCompressResponseWriter(w http.ResponseWriter, acceptEncoding string, minBytes int, contentTypes []string) *compressResponseWriter {
+	return &compressResponseWriter{
+		ResponseWriter: w,
+		encoding:       negotiateEncoding(acceptEncoding),
+		minBytes:       minBytes,
+		contentTypes:   contentTypes,
+	}
+}
+
+func (cw *compressResponseWriter) WriteHeader(status int) {
+	if cw.wroteHeader || cw.status != 0 {
+		return
+	}
+	cw.status = status
+}
+
+func (cw *compressResponseWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		if cw.shouldCompress(len(p)) {
+			cw.startCompression()
+		}
+		cw.writeHeader()
+	}
+
+	if cw.compressor != nil {
+		return cw.compressor.Write(p)
+	}
+
+	return cw.ResponseWriter.Write(p)
+}
+
+func (cw *compressResponseWriter) Flush() {
+	if cw.compressor != nil {
+		_ = cw.compressor.Flush()
+	}
+
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close writes any pending status code and flushes the remaining compressed data.
+func (cw *compressResponseWriter) Close() error {
+	if !cw.wroteHeader && cw.status != 0 {
+		cw.writeHeader()
+	}
+
+	if cw.compressor != nil {
+		return cw.compressor.Close()
+	}
+
+	return nil
+}
+
+func (cw *compressResponseWriter) writeHeader() {
+	cw.wroteHeader = true
+	if cw.status != 0 {
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+}
+
+func (cw *compressResponseWriter) shouldCompress(size int) bool {
+	if cw.encoding == "" || size < cw.minBytes {
+		return false
+	}
+
+	if cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+
+	header := cw.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	for _, contentType := range cw.contentTypes {
+		if strings.EqualFold(mediaType, contentType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cw *compressResponseWriter) startCompression() {
+	header := cw.Header()
+	header.Set("Content-Encoding", cw.encoding)
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+
+	if cw.encoding == "gzip" {
+		cw.compressor = gzip.NewWriter(cw.ResponseWriter)
+		return
+	}
+
+	// flate.NewWriter only fails for invalid compression levels
+	cw.compressor, _ = flate.NewWriter(cw.ResponseWriter, flate.DefaultCompression)
+}
+
+// negotiateEncoding returns the preferred supported encoding listed in an Accept-Encoding header value,
+// or an empty string if neither gzip nor deflate is accepted.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		encoding := strings.ToLower(strings.TrimSpace(params[0]))
+		disabled := false
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil && q == 0 {
+				disabled = true
+			}
+		}
+		accepted[encoding] = !disabled
+	}
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+
+	return ""
+}
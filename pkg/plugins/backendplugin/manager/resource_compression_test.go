@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressResponseWriter(t *testing.T) {
+	contentTypes := []string{"application/json"}
+	body := strings.Repeat("a", 100)
+
+	t.Run("Should compress allowed content type above threshold", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		cw := newCompressResponseWriter(w, "deflate, gzip;q=0.9", 10, contentTypes)
+		cw.Header().Set("Content-Type", "application/json; charset=utf-8")
+		cw.WriteHeader(http.StatusCreated)
+		_, err := cw.Write([]byte(body))
+		require.NoError(t, err)
+		require.NoError(t, cw.Close())
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		require.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+		r, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		decompressed, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, body, string(decompressed))
+	})
+
+	t.Run("Should not compress", func(t *testing.T) {
+		tcs := []struct {
+			desc            string
+			acceptEncoding  string
+			contentType     string
+			contentEncoding string
+			body            string
+		}{
+			{desc: "when client doesn't accept compression", acceptEncoding: "br, gzip;q=0", contentType: "application/json", body: body},
+			{desc: "when content type isn't allowed", acceptEncoding: "gzip", contentType: "image/png", body: body},
+			{desc: "when plugin already encoded the response", acceptEncoding: "gzip", contentType: "application/json", contentEncoding: "br", body: body},
+			{desc: "when body is below threshold", acceptEncoding: "gzip", contentType: "application/json", body: "a"},
+		}
+
+		for _, tc := range tcs {
+			t.Run(tc.desc, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				cw := newCompressResponseWriter(w, tc.acceptEncoding, 10, contentTypes)
+				cw.Header().Set("Content-Type", tc.contentType)
+				if tc.contentEncoding != "" {
+					cw.Header().Set("Content-Encoding", tc.contentEncoding)
+				}
+				cw.WriteHeader(http.StatusOK)
+				_, err := cw.Write([]byte(tc.body))
+				require.NoError(t, err)
+				require.NoError(t, cw.Close())
+
+				require.Equal(t, tc.contentEncoding, w.Header().Get("Content-Encoding"))
+				require.Equal(t, tc.body, w.Body.String())
+			})
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+const (
+	// resourceUserHeader is the login of the user making a resource call.
+	resourceUserHeader = "X-Grafana-User"
+	// resourceOrgIDHeader is the ID of the organization a resource call is made in.
+	resourceOrgIDHeader = "X-Grafana-Org-Id"
+)
+
+// resourceRequestHeaders returns the request headers forwarded to a plugin in a resource call. If an allowlist is
+// configured only the listed headers are forwarded, and headers in the denylist are never forwarded. Headers
+// identifying the user and organization are never forwarded from the caller, if enabled they're set by Grafana.
+func (m *Manager) resourceRequestHeaders(header http.Header, pCtx backend.PluginContext) map[string][]string {
+	allowlist := headerSet(getPluginStringListSetting(pCtx.PluginID, "resource_header_allowlist", m.Cfg,
+		m.Cfg.PluginsResourceHeaderAllowlist))
+	denylist := headerSet(getPluginStringListSetting(pCtx.PluginID, "resource_header_denylist", m.Cfg,
+		m.Cfg.PluginsResourceHeaderDenylist))
+
+	headers := make(map[string][]string, len(header))
+	for k, values := range header {
+		name := http.CanonicalHeaderKey(k)
+		// identity headers are only set by Grafana, so that callers can't impersonate other users to plugins
+		if name == resourceUserHeader || name == resourceOrgIDHeader {
+			continue
+		}
+		if len(allowlist) > 0 && !allowlist[name] {
+			continue
+		}
+		if denylist[name] {
+			continue
+		}
+		headers[k] = values
+	}
+
+	forwardIdentity := m.Cfg.PluginsResourceForwardIdentityHeaders
+	if value, exists := m.Cfg.PluginSettingsFor(pCtx.PluginID)["resource_forward_identity_headers"]; exists {
+		if b, err := strconv.ParseBool(value); err == nil {
+			forwardIdentity = b
+		}
+	}
+	if forwardIdentity {
+		headers[resourceOrgIDHeader] = []string{strconv.FormatInt(pCtx.OrgID, 10)}
+		if pCtx.User != nil {
+			headers[resourceUserHeader] = []string{pCtx.User.Login}
+		}
+	}
+
+	return headers
+}
+
+func headerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[http.CanonicalHeaderKey(name)] = true
+	}
+	return set
+}
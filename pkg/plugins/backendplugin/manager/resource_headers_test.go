@@ -0,0 +1,69 @@
+package manager
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceRequestHeaders(t *testing.T) {
+	header := http.Header{
+		"Authorization":  {"Bearer token"},
+		"Content-Type":   {"application/json"},
+		"X-Custom":       {"value"},
+		"X-Grafana-User": {"spoofed"},
+	}
+	pCtx := backend.PluginContext{PluginID: "test", OrgID: 2, User: &backend.User{Login: "admin"}}
+
+	t.Run("Should forward all headers but identity headers by default", func(t *testing.T) {
+		m := &Manager{Cfg: setting.NewCfg()}
+		require.Equal(t, map[string][]string{
+			"Authorization": {"Bearer token"},
+			"Content-Type":  {"application/json"},
+			"X-Custom":      {"value"},
+		}, m.resourceRequestHeaders(header, pCtx))
+	})
+
+	t.Run("Should drop spoofed identity headers when forwarding is disabled", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PluginsResourceHeaderAllowlist = []string{"X-Grafana-User", "X-Grafana-Org-Id", "X-Custom"}
+		m := &Manager{Cfg: cfg}
+		spoofed := http.Header{
+			"X-Grafana-User":   {"admin"},
+			"x-grafana-org-id": {"1"},
+			"X-Custom":         {"value"},
+		}
+		require.Equal(t, map[string][]string{"X-Custom": {"value"}}, m.resourceRequestHeaders(spoofed, pCtx))
+	})
+
+	t.Run("Should apply allowlist and denylist", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PluginsResourceHeaderDenylist = []string{"authorization"}
+		m := &Manager{Cfg: cfg}
+		headers := m.resourceRequestHeaders(header, pCtx)
+		require.NotContains(t, headers, "Authorization")
+		require.Contains(t, headers, "X-Custom")
+
+		cfg.PluginSettings = setting.PluginSettings{
+			"test": map[string]string{"resource_header_allowlist": "content-type,authorization"},
+		}
+		headers = m.resourceRequestHeaders(header, pCtx)
+		require.Equal(t, map[string][]string{"Content-Type": {"application/json"}}, headers)
+	})
+
+	t.Run("Should add identity headers when enabled", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PluginsResourceForwardIdentityHeaders = true
+		m := &Manager{Cfg: cfg}
+		headers := m.resourceRequestHeaders(header, pCtx)
+		require.Equal(t, []string{"admin"}, headers["X-Grafana-User"])
+		require.Equal(t, []string{"2"}, headers["X-Grafana-Org-Id"])
+
+		headers = m.resourceRequestHeaders(header, backend.PluginContext{PluginID: "test", OrgID: 1})
+		require.NotContains(t, headers, "X-Grafana-User")
+		require.Equal(t, []string{"1"}, headers["X-Grafana-Org-Id"])
+	})
+}
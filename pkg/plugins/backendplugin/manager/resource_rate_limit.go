@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"golang.org/x/time/rate"
+)
+
+const (
+	resourceRateLimitScopePlugin = "plugin"
+	resourceRateLimitScopeOrg    = "org"
+	resourceRateLimitScopeUser   = "user"
+)
+
+// resourceRateLimiterIdleTimeout is how long a token bucket is kept after its last use, at least until it's full again.
+// Evicting a full bucket doesn't change the limit, as a new bucket starts full, so that the buckets of users or
+// organizations that stopped calling a plugin don't accumulate.
+const resourceRateLimiterIdleTimeout = 10 * time.Minute
+
+// resourceRateLimiter keeps a token bucket per plugin, optionally further scoped by organization or user.
+// The zero value is ready to use.
+type resourceRateLimiter struct {
+	mu        sync.Mutex
+	limiters  map[string]*resourceLimiter
+	lastSweep time.Time
+}
+
+type resourceLimiter struct {
+	*rate.Limiter
+	lastUsed time.Time
+}
+
+// idle reports whether the limiter is unused long enough to be evicted.
+func (l *resourceLimiter) idle(now time.Time) bool {
+	timeout := resourceRateLimiterIdleTimeout
+	if l.Limit() > 0 {
+		if refill := time.Duration(float64(l.Burst()) / float64(l.Limit()) * float64(time.Second)); refill > timeout {
+			timeout = refill
+		}
+	}
+
+	return now.Sub(l.lastUsed) > timeout
+}
+
+// sweep evicts idle limiters, at most once per resourceRateLimiterIdleTimeout. l.mu must be held.
+func (l *resourceRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < resourceRateLimiterIdleTimeout {
+		return
+	}
+	l.lastSweep = now
+
+	for key, limiter := range l.limiters {
+		if limiter.idle(now) {
+			delete(l.limiters, key)
+		}
+	}
+}
+
+// allow reports whether a resource call is allowed, and otherwise how long the caller should wait before retrying.
+func (l *resourceRateLimiter) allow(key string, limit float64, burst int, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	if l.limiters == nil {
+		l.limiters = map[string]*resourceLimiter{}
+		l.lastSweep = now
+	}
+	l.sweep(now)
+	limiter, exists := l.limiters[key]
+	if !exists || limiter.Limit() != rate.Limit(limit) || limiter.Burst() != burst {
+		limiter = &resourceLimiter{Limiter: rate.NewLimiter(rate.Limit(limit), burst)}
+		l.limiters[key] = limiter
+	}
+	limiter.lastUsed = now
+	l.mu.Unlock()
+
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second
+	}
+
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// allowResourceCall applies the resource rate limit configured for the plugin of pCtx.
+func (m *Manager) allowResourceCall(pCtx backend.PluginContext) (bool, time.Duration) {
+	limit := getPluginIntSetting(pCtx.PluginID, "resource_rate_limit", m.Cfg, m.Cfg.PluginsResourceRateLimit)
+	if limit <= 0 {
+		return true, 0
+	}
+
+	burst := getPluginIntSetting(pCtx.PluginID, "resource_rate_limit_burst", m.Cfg, m.Cfg.PluginsResourceRateLimitBurst)
+	if burst < 1 {
+		burst = limit
+	}
+
+	key := pCtx.PluginID
+	switch m.Cfg.PluginsResourceRateLimitScope {
+	case resourceRateLimitScopeOrg:
+		key = fmt.Sprintf("%s/org/%d", pCtx.PluginID, pCtx.OrgID)
+	case resourceRateLimitScopeUser:
+		if pCtx.User != nil {
+			key = fmt.Sprintf("%s/org/%d/user/%s", pCtx.PluginID, pCtx.OrgID, pCtx.User.Login)
+		}
+	}
+
+	return m.resourceRateLimiter.allow(key, float64(limit), burst, time.Now())
+}
@@ -0,0 +1,69 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceRateLimiter(t *testing.T) {
+	t.Run("Should allow burst and then ask to retry", func(t *testing.T) {
+		l := resourceRateLimiter{}
+		now := time.Now()
+
+		for i := 0; i < 2; i++ {
+			allowed, _ := l.allow("plugin", 1, 2, now)
+			require.True(t, allowed)
+		}
+
+		allowed, retryAfter := l.allow("plugin", 1, 2, now)
+		require.False(t, allowed)
+		require.Equal(t, time.Second, retryAfter)
+
+		allowed, _ = l.allow("plugin", 1, 2, now.Add(time.Second))
+		require.True(t, allowed)
+	})
+
+	t.Run("Should evict limiters once they're idle and full", func(t *testing.T) {
+		l := resourceRateLimiter{}
+		now := time.Now()
+
+		allowed, _ := l.allow("idle", 1, 1, now)
+		require.True(t, allowed)
+		allowed, _ = l.allow("slow", 0.001, 1, now)
+		require.True(t, allowed)
+		allowed, _ = l.allow("active", 1, 1, now)
+		require.True(t, allowed)
+
+		later := now.Add(resourceRateLimiterIdleTimeout + time.Second)
+		allowed, _ = l.allow("active", 1, 1, later)
+		require.True(t, allowed)
+		require.NotContains(t, l.limiters, "idle")
+		require.Contains(t, l.limiters, "active")
+		// the bucket of slow isn't full yet, so it's kept to keep limiting
+		require.Contains(t, l.limiters, "slow")
+		allowed, _ = l.allow("slow", 0.001, 1, later)
+		require.False(t, allowed)
+	})
+
+	t.Run("Should limit per configured scope", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PluginsResourceRateLimitScope = resourceRateLimitScopeOrg
+		cfg.PluginSettings = setting.PluginSettings{
+			"test": map[string]string{"resource_rate_limit": "1"},
+		}
+		m := &Manager{Cfg: cfg}
+
+		allowed, _ := m.allowResourceCall(backend.PluginContext{PluginID: "test", OrgID: 1})
+		require.True(t, allowed)
+		allowed, _ = m.allowResourceCall(backend.PluginContext{PluginID: "test", OrgID: 1})
+		require.False(t, allowed)
+		allowed, _ = m.allowResourceCall(backend.PluginContext{PluginID: "test", OrgID: 2})
+		require.True(t, allowed)
+		allowed, _ = m.allowResourceCall(backend.PluginContext{PluginID: "other", OrgID: 1})
+		require.True(t, allowed)
+	})
+}
@@ -0,0 +1,156 @@
+package manager
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+const (
+	// resourceUploadOffsetHeader is the offset in bytes of a request body chunk sent to a plugin.
+	resourceUploadOffsetHeader = "X-Grafana-Upload-Offset"
+	// resourceUploadFinalHeader is set to true on the last request body chunk sent to a plugin.
+	resourceUploadFinalHeader = "X-Grafana-Upload-Final"
+)
+
+var errResourceRequestTooLarge = errors.New("resource request too large")
+
+// resourceRequestMaxBytes returns the maximum allowed size of a resource request body of a plugin,
+// 0 means unlimited.
+func (m *Manager) resourceRequestMaxBytes(pluginID string) int {
+	return getPluginIntSetting(pluginID, "resource_request_max_bytes", m.Cfg, m.Cfg.PluginsResourceRequestMaxBytes)
+}
+
+// resourceRequestChunkSize returns the size of the chunks resource request bodies of a plugin are sent in,
+// 0 means request bodies are sent in one piece.
+func (m *Manager) resourceRequestChunkSize(pluginID string) int {
+	return getPluginIntSetting(pluginID, "resource_request_chunk_size", m.Cfg, 0)
+}
+
+// checkResourceRequestSize fails with errResourceRequestTooLarge if the declared contentLength of a request is
+// larger than maxBytes and maxBytes is greater than 0, so that such requests are rejected before their body is read.
+// An unknown content length of -1 passes the check, the size of the body is then limited while it's read.
+func checkResourceRequestSize(contentLength int64, maxBytes int) error {
+	if maxBytes > 0 && contentLength > int64(maxBytes) {
+		return fmt.Errorf("%w: limit is %d bytes", errResourceRequestTooLarge, maxBytes)
+	}
+	return nil
+}
+
+// readResourceRequestBody reads a complete request body, failing with errResourceRequestTooLarge if it's larger
+// than maxBytes and maxBytes is greater than 0.
+func readResourceRequestBody(body io.Reader, maxBytes int) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+
+	if maxBytes > 0 {
+		body = io.LimitReader(body, int64(maxBytes)+1)
+	}
+
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	if maxBytes > 0 && len(b) > maxBytes {
+		return nil, fmt.Errorf("%w: limit is %d bytes", errResourceRequestTooLarge, maxBytes)
+	}
+
+	return b, nil
+}
+
+// callResourceChunked sends a request body to a plugin as a sequence of resource calls carrying at most
+// chunkSize bytes each, so large uploads don't need to be buffered in memory. Every chunk is sent with the
+// X-Grafana-Upload-Offset header and the last one with X-Grafana-Upload-Final: true. The response of the last
+// chunk is streamed to the client, while the responses of the other chunks are only forwarded if they're
+// errors, which also aborts the upload.
+func (m *Manager) callResourceChunked(w http.ResponseWriter, req *http.Request, p backendplugin.Plugin,
+	crReq *backend.CallResourceRequest, chunkSize int, maxBytes int) error {
+	body := bufio.NewReaderSize(req.Body, chunkSize)
+	buf := make([]byte, chunkSize)
+	offset := 0
+
+	for {
+		n, err := io.ReadFull(body, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+
+		if maxBytes > 0 && offset+n > maxBytes {
+			return fmt.Errorf("%w: limit is %d bytes", errResourceRequestTooLarge, maxBytes)
+		}
+
+		final := err != nil
+		if !final {
+			if _, peekErr := body.Peek(1); peekErr != nil {
+				final = true
+			}
+		}
+
+		chunkReq := *crReq
+		chunkReq.Headers = make(map[string][]string, len(crReq.Headers)+2)
+		for k, v := range crReq.Headers {
+			// the length of the complete body doesn't apply to the chunk
+			if k == "Content-Length" {
+				continue
+			}
+			chunkReq.Headers[k] = v
+		}
+		chunkReq.Headers[resourceUploadOffsetHeader] = []string{strconv.Itoa(offset)}
+		chunkReq.Headers[resourceUploadFinalHeader] = []string{strconv.FormatBool(final)}
+		chunkReq.Body = buf[:n]
+
+		if final {
+			return m.callResourceStream(w, req, p, &chunkReq)
+		}
+
+		rec := &chunkResponseRecorder{header: http.Header{}}
+		if err := m.callResourceStream(rec, req, p, &chunkReq); err != nil {
+			return err
+		}
+
+		if rec.status >= http.StatusBadRequest {
+			for k, values := range rec.header {
+				w.Header()[k] = values
+			}
+			w.WriteHeader(rec.status)
+			_, err := w.Write(rec.body)
+			return err
+		}
+
+		offset += n
+	}
+}
+
+// chunkResponseRecorder records the response to an intermediate request body chunk.
+type chunkResponseRecorder struct {
+	header http.Header
+	status int
+	body   []byte
+}
+
+func (r *chunkResponseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *chunkResponseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *chunkResponseRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body = append(r.body, p...)
+	return len(p), nil
+}
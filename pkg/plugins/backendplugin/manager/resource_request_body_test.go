@@ -0,0 +1,32 @@
+package manager
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadResourceRequestBody(t *testing.T) {
+	body, err := readResourceRequestBody(strings.NewReader("abcd"), 4)
+	require.NoError(t, err)
+	require.Equal(t, "abcd", string(body))
+
+	body, err = readResourceRequestBody(strings.NewReader("abcd"), 0)
+	require.NoError(t, err)
+	require.Equal(t, "abcd", string(body))
+
+	_, err = readResourceRequestBody(strings.NewReader("abcde"), 4)
+	require.ErrorIs(t, err, errResourceRequestTooLarge)
+
+	body, err = readResourceRequestBody(nil, 4)
+	require.NoError(t, err)
+	require.Nil(t, body)
+}
+
+func TestCheckResourceRequestSize(t *testing.T) {
+	require.NoError(t, checkResourceRequestSize(4, 4))
+	require.NoError(t, checkResourceRequestSize(5, 0))
+	require.NoError(t, checkResourceRequestSize(-1, 4))
+	require.ErrorIs(t, checkResourceRequestSize(5, 4), errResourceRequestTooLarge)
+}
@@ -4,29 +4,58 @@ import (
 	"context"
 	"errors"
 	"io"
+	"sync"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
-func newCallResourceResponseStream(ctx context.Context) *callResourceResponseStream {
+// responseChunkBufferSize is the size of the pooled buffers coalescing small resource response chunks.
+const responseChunkBufferSize = 32 * 1024
+
+// responseChunkBufferPool holds the buffers used for coalescing resource response chunks, so that streaming a
+// response doesn't allocate a buffer per response.
+var responseChunkBufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, responseChunkBufferSize)
+		return &b
+	},
+}
+
+// newCallResourceResponseStream creates a stream buffering up to bufferSize responses. Once the buffer is full
+// Send blocks until the receiving side catches up, the stream is closed or the context is done.
+func newCallResourceResponseStream(ctx context.Context, bufferSize int) *callResourceResponseStream {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+
 	return &callResourceResponseStream{
 		ctx:    ctx,
-		stream: make(chan *backend.CallResourceResponse),
+		stream: make(chan *backend.CallResourceResponse, bufferSize),
+		done:   make(chan struct{}),
 	}
 }
 
+// callResourceResponseStream passes resource responses from a plugin to the receiving side. The stream channel
+// is never closed, so that a Send racing with Close can't panic; closing the stream closes done instead, which
+// unblocks pending sends and ends receiving once the buffered responses have been received.
 type callResourceResponseStream struct {
 	ctx    context.Context
 	stream chan *backend.CallResourceResponse
+	done   chan struct{}
+	mu     sync.Mutex
 	closed bool
 }
 
 func (s *callResourceResponseStream) Send(res *backend.CallResourceResponse) error {
-	if s.closed {
+	select {
+	case <-s.done:
 		return errors.New("cannot send to a closed stream")
+	default:
 	}
 
 	select {
+	case <-s.done:
+		return errors.New("cannot send to a closed stream")
 	case <-s.ctx.Done():
 		return errors.New("cancelled")
 	case s.stream <- res:
@@ -38,20 +67,32 @@ func (s *callResourceResponseStream) Recv() (*backend.CallResourceResponse, erro
 	select {
 	case <-s.ctx.Done():
 		return nil, s.ctx.Err()
-	case res, ok := <-s.stream:
-		if !ok {
+	case res := <-s.stream:
+		return res, nil
+	case <-s.done:
+		select {
+		case res := <-s.stream:
+			return res, nil
+		default:
 			return nil, io.EOF
 		}
-		return res, nil
 	}
 }
 
+// Buffered returns the number of responses waiting to be received.
+func (s *callResourceResponseStream) Buffered() int {
+	return len(s.stream)
+}
+
 func (s *callResourceResponseStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if s.closed {
 		return errors.New("cannot close a closed stream")
 	}
 
-	close(s.stream)
+	close(s.done)
 	s.closed = true
 	return nil
 }
@@ -0,0 +1,130 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallResourceResponseStream(t *testing.T) {
+	t.Run("Should buffer responses up to the buffer size", func(t *testing.T) {
+		stream := newCallResourceResponseStream(context.Background(), 2)
+
+		require.NoError(t, stream.Send(&backend.CallResourceResponse{Body: []byte("a")}))
+		require.NoError(t, stream.Send(&backend.CallResourceResponse{Body: []byte("b")}))
+		require.NoError(t, stream.Close())
+
+		res, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, "a", string(res.Body))
+		res, err = stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, "b", string(res.Body))
+		_, err = stream.Recv()
+		require.Equal(t, io.EOF, err)
+	})
+
+	t.Run("Should block send when buffer is full until context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		stream := newCallResourceResponseStream(ctx, 1)
+
+		require.NoError(t, stream.Send(&backend.CallResourceResponse{}))
+		require.Error(t, stream.Send(&backend.CallResourceResponse{}))
+	})
+
+	t.Run("Should not be able to send to a closed stream", func(t *testing.T) {
+		stream := newCallResourceResponseStream(context.Background(), 1)
+		require.NoError(t, stream.Close())
+		require.Error(t, stream.Send(&backend.CallResourceResponse{}))
+		require.Error(t, stream.Close())
+	})
+
+	t.Run("Should unblock a pending send when the stream is closed", func(t *testing.T) {
+		stream := newCallResourceResponseStream(context.Background(), 0)
+		sendErrCh := make(chan error, 1)
+		go func() {
+			sendErrCh <- stream.Send(&backend.CallResourceResponse{})
+		}()
+
+		require.NoError(t, stream.Close())
+		select {
+		case err := <-sendErrCh:
+			require.Error(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("send is still blocked after closing the stream")
+		}
+	})
+}
+
+// countingResponseWriter counts the writes to a response recorder.
+type countingResponseWriter struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *countingResponseWriter) Write(b []byte) (int, error) {
+	if len(b) > 0 {
+		w.writes++
+	}
+	return w.ResponseRecorder.Write(b)
+}
+
+func TestFlushStream(t *testing.T) {
+	plugin := &testPlugin{pluginID: "test-plugin", logger: log.New("test")}
+
+	t.Run("Should coalesce buffered small chunks", func(t *testing.T) {
+		stream := newCallResourceResponseStream(context.Background(), 3)
+		require.NoError(t, stream.Send(&backend.CallResourceResponse{
+			Status:  http.StatusOK,
+			Headers: map[string][]string{"Content-Type": {"text/csv"}},
+			Body:    []byte("a,b\n"),
+		}))
+		require.NoError(t, stream.Send(&backend.CallResourceResponse{Body: []byte("1,2\n")}))
+		require.NoError(t, stream.Send(&backend.CallResourceResponse{Body: []byte("3,4\n")}))
+		require.NoError(t, stream.Close())
+
+		w := &countingResponseWriter{ResponseRecorder: httptest.NewRecorder()}
+		require.NoError(t, flushStream(plugin, stream, w, 0))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		require.Equal(t, "a,b\n1,2\n3,4\n", w.Body.String())
+		require.Equal(t, 1, w.writes)
+		require.True(t, w.Flushed)
+	})
+
+	t.Run("Should write large chunks as is", func(t *testing.T) {
+		large := bytes.Repeat([]byte("x"), responseChunkBufferSize+1)
+		stream := newCallResourceResponseStream(context.Background(), 2)
+		require.NoError(t, stream.Send(&backend.CallResourceResponse{Status: http.StatusOK, Body: []byte("a")}))
+		require.NoError(t, stream.Send(&backend.CallResourceResponse{Body: large}))
+		require.NoError(t, stream.Close())
+
+		w := &countingResponseWriter{ResponseRecorder: httptest.NewRecorder()}
+		require.NoError(t, flushStream(plugin, stream, w, 0))
+		require.Equal(t, append([]byte("a"), large...), w.Body.Bytes())
+		require.Equal(t, 2, w.writes)
+	})
+
+	t.Run("Should write each chunk when no more chunks are buffered", func(t *testing.T) {
+		stream := newCallResourceResponseStream(context.Background(), 0)
+		go func() {
+			_ = stream.Send(&backend.CallResourceResponse{Status: http.StatusOK, Body: []byte("a")})
+			_ = stream.Send(&backend.CallResourceResponse{Body: []byte("b")})
+			_ = stream.Close()
+		}()
+
+		w := &countingResponseWriter{ResponseRecorder: httptest.NewRecorder()}
+		require.NoError(t, flushStream(plugin, stream, w, 0))
+		require.Equal(t, "ab", w.Body.String())
+		require.Equal(t, 2, w.writes)
+	})
+}
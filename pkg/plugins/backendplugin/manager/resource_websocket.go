@@ -0,0 +1,206 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/instrumentation"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// resourceWebSocketMessageSizeLimit is the maximum size in bytes of a message received from a WebSocket client.
+const resourceWebSocketMessageSizeLimit = 1024 * 1024
+
+// resourceWebSocketMaxInFlight is the maximum number of concurrent resource calls of a bidirectional WebSocket
+// connection.
+const resourceWebSocketMaxInFlight = 16
+
+// The modes of proxying WebSocket connections to plugin resources.
+const (
+	// resourceWebSocketModeSequential sends a message to the plugin once the call of the previous one completed.
+	resourceWebSocketModeSequential = "sequential"
+	// resourceWebSocketModeBidirectional sends every message to the plugin as soon as it's received.
+	resourceWebSocketModeBidirectional = "bidirectional"
+)
+
+const (
+	// resourceWebSocketSessionHeader identifies the WebSocket connection of a resource call in bidirectional mode.
+	resourceWebSocketSessionHeader = "X-Grafana-Websocket-Session"
+	// resourceWebSocketMessageHeader is the sequence number of the WebSocket message of a resource call in
+	// bidirectional mode, starting at 1.
+	resourceWebSocketMessageHeader = "X-Grafana-Websocket-Message"
+)
+
+var resourceWebSocketUpgrader = websocket.Upgrader{}
+
+// proxyResourceWebSocket upgrades a resource call to a WebSocket connection. Every message received from the
+// client is sent to the plugin as the body of a resource call, with the original path, method and headers,
+// and every response chunk the plugin sends back is written to the client as a message of the same type. Unless the
+// plugin is configured with resource_websocket_mode = bidirectional, a message is only read once the resource call
+// of the previous one completed.
+func (m *Manager) proxyResourceWebSocket(w http.ResponseWriter, req *http.Request, p backendplugin.Plugin,
+	crReq *backend.CallResourceRequest) error {
+	conn, err := resourceWebSocketUpgrader.Upgrade(w, req, nil)
+	if err != nil {
+		// the upgrader already replied to the client
+		p.Logger().Debug("Failed to upgrade resource call to WebSocket", "error", err)
+		return nil
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			p.Logger().Debug("Failed to close WebSocket connection", "error", err)
+		}
+	}()
+	conn.SetReadLimit(resourceWebSocketMessageSizeLimit)
+
+	headers := map[string][]string{}
+	for k, values := range crReq.Headers {
+		if isWebSocketHandshakeHeader(k) {
+			continue
+		}
+		headers[k] = values
+	}
+
+	sender := &webSocketResponseSender{conn: conn, mu: &sync.Mutex{}}
+	if m.resourceWebSocketMode(p.PluginID()) == resourceWebSocketModeBidirectional {
+		m.proxyBidirectionalResourceWebSocket(req.Context(), conn, p, crReq, headers, sender)
+		return nil
+	}
+
+	for {
+		messageType, body, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				p.Logger().Debug("Failed to read WebSocket message", "error", err)
+			}
+			return nil
+		}
+
+		msgReq := *crReq
+		msgReq.Headers = headers
+		msgReq.Body = body
+		err = instrumentation.InstrumentCallResourceRequest(p.PluginID(), func() error {
+			return m.callPluginResource(req.Context(), p, &msgReq, sender.withMessageType(messageType))
+		})
+		if err != nil {
+			p.Logger().Error("Failed to call resource over WebSocket", "error", err)
+			sender.closeWithError()
+			return nil
+		}
+	}
+}
+
+// proxyBidirectionalResourceWebSocket proxies a WebSocket connection to a plugin in bidirectional mode: every
+// message received from the client is sent to the plugin in a concurrent resource call, so the client can keep
+// sending messages while the plugin streams responses to previous ones. The calls of a connection share a session
+// ID header and carry the sequence number of their message, so that the plugin can correlate them. At most
+// resourceWebSocketMaxInFlight calls run at once, reading further messages waits for one of them to complete.
+func (m *Manager) proxyBidirectionalResourceWebSocket(ctx context.Context, conn *websocket.Conn, p backendplugin.Plugin,
+	crReq *backend.CallResourceRequest, headers map[string][]string, sender *webSocketResponseSender) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	sessionID := util.GenerateShortUID()
+	inFlight := make(chan struct{}, resourceWebSocketMaxInFlight)
+	var failOnce sync.Once
+	fail := func() {
+		failOnce.Do(func() {
+			sender.closeWithError()
+			cancel()
+			// unblock reading the next message
+			_ = conn.SetReadDeadline(time.Now())
+		})
+	}
+
+	for seq := 1; ; seq++ {
+		messageType, body, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() == nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				p.Logger().Debug("Failed to read WebSocket message", "error", err)
+			}
+			return
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		msgReq := *crReq
+		msgReq.Headers = make(map[string][]string, len(headers)+2)
+		for k, v := range headers {
+			msgReq.Headers[k] = v
+		}
+		msgReq.Headers[resourceWebSocketSessionHeader] = []string{sessionID}
+		msgReq.Headers[resourceWebSocketMessageHeader] = []string{strconv.Itoa(seq)}
+		msgReq.Body = body
+
+		seq := seq
+		wg.Add(1)
+		m.goPlugin(ctx, p.PluginID(), goroutineWebSocketMessage, func(ctx context.Context) {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+
+			err := instrumentation.InstrumentCallResourceRequest(p.PluginID(), func() error {
+				return m.callPluginResource(ctx, p, &msgReq, sender.withMessageType(messageType))
+			})
+			if err != nil && ctx.Err() == nil {
+				p.Logger().Error("Failed to call resource over WebSocket", "message", seq, "error", err)
+				fail()
+			}
+		})
+	}
+}
+
+// resourceWebSocketMode returns how WebSocket connections to the resources of a plugin are proxied.
+func (m *Manager) resourceWebSocketMode(pluginID string) string {
+	return getPluginStringSetting(pluginID, "resource_websocket_mode", m.Cfg, resourceWebSocketModeSequential)
+}
+
+func isWebSocketHandshakeHeader(key string) bool {
+	key = http.CanonicalHeaderKey(key)
+	return key == "Connection" || key == "Upgrade" || strings.HasPrefix(key, "Sec-Websocket-")
+}
+
+// webSocketResponseSender writes resource response chunks to a WebSocket connection. Senders of the same connection
+// share a mutex, since a connection supports a single concurrent writer.
+type webSocketResponseSender struct {
+	conn        *websocket.Conn
+	mu          *sync.Mutex
+	messageType int
+}
+
+// withMessageType returns a sender of the same connection writing messages of the given type.
+func (s *webSocketResponseSender) withMessageType(messageType int) *webSocketResponseSender {
+	return &webSocketResponseSender{conn: s.conn, mu: s.mu, messageType: messageType}
+}
+
+func (s *webSocketResponseSender) Send(res *backend.CallResourceResponse) error {
+	if len(res.Body) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.conn.WriteMessage(s.messageType, res.Body)
+}
+
+// closeWithError tells the client that a resource call failed and the connection is being closed.
+func (s *webSocketResponseSender) closeWithError() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "Failed to call resource")
+	_ = s.conn.WriteMessage(websocket.CloseMessage, closeMsg)
+}
@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var pluginSlowQueries *prometheus.CounterVec
+
+func init() {
+	pluginSlowQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "plugin_slow_queries_total",
+		Help:      "The total amount of plugin data queries slower than the slow query threshold",
+	}, []string{"plugin_id"})
+
+	prometheus.MustRegister(pluginSlowQueries)
+}
+
+// slowQueryThreshold returns the duration after which a data query of a plugin is considered slow, which can be
+// set per plugin with the slow_query_threshold setting and globally. Zero means slow queries aren't logged.
+func (m *Manager) slowQueryThreshold(pluginID string) time.Duration {
+	threshold := getPluginIntSetting(pluginID, "slow_query_threshold", m.Cfg, m.Cfg.PluginsSlowQueryThreshold)
+	if threshold <= 0 {
+		return 0
+	}
+
+	return time.Duration(threshold) * time.Second
+}
+
+// logSlowQuery logs and counts the data query req of plugin p if it took longer than the slow query threshold.
+func (m *Manager) logSlowQuery(p backendplugin.Plugin, req *backend.QueryDataRequest, elapsed time.Duration, err error) {
+	threshold := m.slowQueryThreshold(p.PluginID())
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+
+	pluginSlowQueries.WithLabelValues(p.PluginID()).Inc()
+
+	refIDs := make([]string, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		refIDs = append(refIDs, q.RefID)
+	}
+	sort.Strings(refIDs)
+
+	var dsUID string
+	if req.PluginContext.DataSourceInstanceSettings != nil {
+		dsUID = req.PluginContext.DataSourceInstanceSettings.UID
+	}
+
+	logParams := []interface{}{
+		"orgId", req.PluginContext.OrgID,
+		"datasourceUid", dsUID,
+		"refIds", refIDs,
+		"duration", elapsed,
+		"threshold", threshold,
+	}
+	if err != nil {
+		logParams = append(logParams, "error", err)
+	}
+	p.Logger().Warn("Slow query", logParams...)
+}
@@ -0,0 +1,57 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_LogSlowQuery(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.PluginsSlowQueryThreshold = 5
+	cfg.PluginSettings = setting.PluginSettings{
+		"fast-plugin":  {"slow_query_threshold": "1"},
+		"quiet-plugin": {"slow_query_threshold": "0"},
+	}
+	m := &Manager{Cfg: cfg, logger: log.New("test")}
+
+	require.Equal(t, 5*time.Second, m.slowQueryThreshold("test-plugin"))
+	require.Equal(t, time.Second, m.slowQueryThreshold("fast-plugin"))
+	require.Zero(t, m.slowQueryThreshold("quiet-plugin"))
+
+	req := &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			OrgID:                      1,
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds"},
+		},
+		Queries: []backend.DataQuery{{RefID: "B"}, {RefID: "A"}},
+	}
+
+	tcs := []struct {
+		pluginID string
+		elapsed  time.Duration
+		slow     bool
+	}{
+		{pluginID: "test-plugin", elapsed: 2 * time.Second, slow: false},
+		{pluginID: "test-plugin", elapsed: 6 * time.Second, slow: true},
+		{pluginID: "fast-plugin", elapsed: 2 * time.Second, slow: true},
+		{pluginID: "quiet-plugin", elapsed: time.Minute, slow: false},
+	}
+	for _, tc := range tcs {
+		p := &testPlugin{pluginID: tc.pluginID, logger: log.New("test")}
+		before := testutil.ToFloat64(pluginSlowQueries.WithLabelValues(tc.pluginID))
+		m.logSlowQuery(p, req, tc.elapsed, nil)
+
+		expected := before
+		if tc.slow {
+			expected++
+		}
+		require.Equal(t, expected, testutil.ToFloat64(pluginSlowQueries.WithLabelValues(tc.pluginID)),
+			"plugin %s, elapsed %s", tc.pluginID, tc.elapsed)
+	}
+}
@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// The policies applied when the buffer of a stream subscriber is full.
+const (
+	streamOverflowPolicyDropOldest = "drop_oldest"
+	streamOverflowPolicyDisconnect = "disconnect"
+)
+
+// streamDropReasonOverflow is the reason for dropping stream messages a subscriber doesn't receive fast enough.
+const streamDropReasonOverflow = "overflow"
+
+// streamSubscriber is a subscriber of a plugin stream. Packets are buffered per subscriber, so that a slow
+// subscriber doesn't block the plugin and the other subscribers of the stream.
+type streamSubscriber struct {
+	sender         *backend.StreamSender
+	packets        chan []byte
+	overflowPolicy string
+	// failed receives the error the subscriber is disconnected with, after which it receives no more packets.
+	failed chan error
+}
+
+func newStreamSubscriber(sender *backend.StreamSender, bufferSize int, overflowPolicy string) *streamSubscriber {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+
+	return &streamSubscriber{
+		sender:         sender,
+		packets:        make(chan []byte, bufferSize),
+		overflowPolicy: overflowPolicy,
+		failed:         make(chan error, 1),
+	}
+}
+
+// enqueue buffers a packet for the subscriber, applying the overflow policy when the buffer is full. It returns
+// the number of dropped packets and false if the subscriber must be disconnected. Packets are only enqueued by the
+// goroutine running the stream.
+func (s *streamSubscriber) enqueue(data []byte) (int, bool) {
+	dropped := 0
+	for {
+		select {
+		case s.packets <- data:
+			return dropped, true
+		default:
+		}
+
+		if s.overflowPolicy == streamOverflowPolicyDisconnect {
+			return dropped, false
+		}
+
+		select {
+		case <-s.packets:
+			dropped++
+		default:
+		}
+	}
+}
+
+// flush sends the buffered packets to the subscriber.
+func (s *streamSubscriber) flush() error {
+	for {
+		select {
+		case data := <-s.packets:
+			if err := s.sender.SendBytes(data); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// deliver enqueues a packet of a plugin for all subscribers of the stream, disconnecting the ones whose overflow
+// policy requires it.
+func (s *pluginStream) deliver(subscribers []*streamSubscriber, data []byte) {
+	for _, sub := range subscribers {
+		dropped, ok := sub.enqueue(data)
+		if dropped > 0 {
+			pluginStreamDroppedMessages.WithLabelValues(s.pluginID, streamDropReasonOverflow).Add(float64(dropped))
+		}
+		if ok {
+			continue
+		}
+
+		pluginStreamDroppedMessages.WithLabelValues(s.pluginID, streamDropReasonOverflow).Inc()
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+		sub.failed <- backendplugin.ErrStreamSubscriberTooSlow
+	}
+}
+
+// newPluginStreamSubscriber returns a subscriber of a stream of a plugin sending packets to sender.
+func (m *Manager) newPluginStreamSubscriber(pluginID string, sender *backend.StreamSender) *streamSubscriber {
+	bufferSize := getPluginIntSetting(pluginID, "stream_subscriber_buffer_size", m.Cfg, m.Cfg.PluginsStreamSubscriberBufferSize)
+	return newStreamSubscriber(sender, bufferSize, m.Cfg.PluginsStreamOverflowPolicy)
+}
@@ -0,0 +1,104 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingPacketSender blocks sending packets until it's released.
+type blockingPacketSender struct {
+	testPacketSender
+	release chan struct{}
+}
+
+func (s *blockingPacketSender) Send(packet *backend.StreamPacket) error {
+	<-s.release
+	return s.testPacketSender.Send(packet)
+}
+
+func TestStreamSubscriber_Enqueue(t *testing.T) {
+	t.Run("Should drop the oldest packets when the buffer is full", func(t *testing.T) {
+		sub := newStreamSubscriber(nil, 2, streamOverflowPolicyDropOldest)
+		for _, data := range []string{"1", "2", "3", "4"} {
+			_, ok := sub.enqueue([]byte(data))
+			require.True(t, ok)
+		}
+
+		require.Equal(t, "3", string(<-sub.packets))
+		require.Equal(t, "4", string(<-sub.packets))
+	})
+
+	t.Run("Should ask to disconnect when the buffer is full", func(t *testing.T) {
+		sub := newStreamSubscriber(nil, 1, streamOverflowPolicyDisconnect)
+		dropped, ok := sub.enqueue([]byte("1"))
+		require.True(t, ok)
+		require.Zero(t, dropped)
+
+		_, ok = sub.enqueue([]byte("2"))
+		require.False(t, ok)
+	})
+}
+
+func TestManager_StreamBackpressure(t *testing.T) {
+	req := &backend.RunStreamRequest{PluginContext: backend.PluginContext{PluginID: "test"}, Path: "random"}
+	channel := streamChannel(req.PluginContext, req.Path)
+
+	runUntilDone := func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	t.Run("Should not hold up fast subscribers with a slow one", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{runStream: runUntilDone})
+		m.Cfg.PluginsStreamSubscriberBufferSize = 1
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		slow := &blockingPacketSender{release: make(chan struct{})}
+		fast := &testPacketSender{}
+		for _, sender := range []backend.StreamPacketSender{slow, fast} {
+			sender := sender
+			go func() {
+				_ = m.RunStream(ctx, req, backend.NewStreamSender(sender))
+			}()
+		}
+		require.Eventually(t, func() bool { return subscriberCount(m, "test", channel) == 2 }, time.Second,
+			10*time.Millisecond)
+
+		stream := m.pluginStreams.streams["test"][channel]
+		for i := 0; i < 5; i++ {
+			require.NoError(t, stream.Send(&backend.StreamPacket{Data: []byte(`{}`)}))
+			require.Eventually(t, func() bool { return len(fast.get()) == i+1 }, time.Second, time.Millisecond)
+		}
+		close(slow.release)
+		require.Eventually(t, func() bool { return len(slow.get()) > 0 }, time.Second, 10*time.Millisecond)
+		require.Less(t, len(slow.get()), 5)
+	})
+
+	t.Run("Should disconnect a slow subscriber", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{runStream: runUntilDone})
+		m.Cfg.PluginsStreamSubscriberBufferSize = 1
+		m.Cfg.PluginsStreamOverflowPolicy = streamOverflowPolicyDisconnect
+
+		slow := &blockingPacketSender{release: make(chan struct{})}
+		done := make(chan error, 1)
+		go func() {
+			done <- m.RunStream(context.Background(), req, backend.NewStreamSender(slow))
+		}()
+		require.Eventually(t, func() bool { return subscriberCount(m, "test", channel) == 1 }, time.Second,
+			10*time.Millisecond)
+
+		stream := m.pluginStreams.streams["test"][channel]
+		for i := 0; i < 3; i++ {
+			require.NoError(t, stream.Send(&backend.StreamPacket{Data: []byte(`{}`)}))
+		}
+		close(slow.release)
+		require.ErrorIs(t, <-done, backendplugin.ErrStreamSubscriberTooSlow)
+		waitForStreams(t, m, "test", 0)
+	})
+}
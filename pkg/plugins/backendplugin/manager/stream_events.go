@@ -0,0 +1,174 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// streamEventsKeepAliveInterval is the interval of comments sent to keep idle server-sent event streams open.
+var streamEventsKeepAliveInterval = 30 * time.Second
+
+var (
+	errStreamNotFound         = errors.New("stream not found")
+	errStreamPermissionDenied = errors.New("stream permission denied")
+	errStreamEventsNotFlushed = errors.New("response writer doesn't support flushing")
+)
+
+// ServeStreamEvents subscribes to a stream channel of a registered backend plugin and sends its packets to the client
+// as server-sent events, for clients that can't connect to Grafana Live with WebSockets, until the client
+// disconnects or the plugin ends the stream.
+func (m *Manager) ServeStreamEvents(pCtx backend.PluginContext, reqCtx *models.ReqContext, path string) {
+	err := m.serveStreamEvents(reqCtx.Resp, reqCtx.Req, pCtx, path)
+	if err != nil {
+		handleStreamEventsError(err, reqCtx)
+	}
+}
+
+// serveStreamEvents bridges the stream of a plugin on path to server-sent events written to w. Errors are only
+// returned as long as the response isn't started, later ones are sent to the client as an error event.
+func (m *Manager) serveStreamEvents(w http.ResponseWriter, req *http.Request, pCtx backend.PluginContext,
+	path string) error {
+	p, registered := m.getForContext(pCtx)
+	if !registered {
+		return backendplugin.ErrPluginNotRegistered
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errStreamEventsNotFlushed
+	}
+
+	ctx := req.Context()
+	resp, err := m.SubscribeStream(ctx, &backend.SubscribeStreamRequest{PluginContext: pCtx, Path: path})
+	if err != nil {
+		return err
+	}
+	switch resp.Status {
+	case backend.SubscribeStreamStatusOK:
+	case backend.SubscribeStreamStatusNotFound:
+		return errStreamNotFound
+	default:
+		return errStreamPermissionDenied
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sender := &streamEventSender{w: w, flusher: flusher}
+	if resp.InitialData != nil {
+		if err := sender.send("initial", resp.InitialData.Data()); err != nil {
+			return nil
+		}
+	}
+
+	// the keep-alive goroutine is stopped before returning, as the response writer can't be used once the
+	// handler returned
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(streamEventsKeepAliveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := sender.keepAlive(); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	err = m.RunStream(ctx, &backend.RunStreamRequest{PluginContext: pCtx, Path: path}, backend.NewStreamSender(sender))
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		p.Logger().Debug("Stream of server-sent events failed", "path", path, "error", err)
+		data, _ := json.Marshal(map[string]string{"message": err.Error()})
+		_ = sender.send("error", data)
+		return nil
+	}
+	_ = sender.send("end", []byte("{}"))
+
+	return nil
+}
+
+// streamEventSender writes the packets of a plugin stream as server-sent events of the default message type.
+type streamEventSender struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (s *streamEventSender) Send(packet *backend.StreamPacket) error {
+	return s.send("", packet.Data)
+}
+
+// send writes an event of the given type, or of the default message type if it's empty, with data split over as
+// many data lines as it has lines.
+func (s *streamEventSender) send(event string, data []byte) error {
+	var buf bytes.Buffer
+	if event != "" {
+		buf.WriteString("event: " + event + "\n")
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(bytes.TrimSuffix(line, []byte("\r")))
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+
+	return s.write(buf.Bytes())
+}
+
+// keepAlive writes a comment, which clients ignore but which keeps proxies from closing idle connections.
+func (s *streamEventSender) keepAlive() error {
+	return s.write([]byte(": keep-alive\n\n"))
+}
+
+func (s *streamEventSender) write(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(b); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+func handleStreamEventsError(err error, reqCtx *models.ReqContext) {
+	notFound := string(backendplugin.ErrorCodeNotFound)
+	switch {
+	case errors.Is(err, backendplugin.ErrPluginNotRegistered):
+		reqCtx.JsonApiErrWithCode(http.StatusNotFound, "Plugin not found", notFound, err)
+	case errors.Is(err, errStreamNotFound), errors.Is(err, backendplugin.ErrMethodNotImplemented):
+		reqCtx.JsonApiErrWithCode(http.StatusNotFound, "Stream not found", notFound, err)
+	case errors.Is(err, errStreamPermissionDenied):
+		reqCtx.JsonApiErr(http.StatusForbidden, "Permission denied", err)
+	default:
+		code := backendplugin.ErrorCodeOf(err)
+		message := "Failed to subscribe to stream"
+		if code == backendplugin.ErrorCodeUnavailable {
+			message = "Plugin unavailable"
+		}
+		reqCtx.JsonApiErrWithCode(code.HTTPStatus(), message, string(code), err)
+	}
+}
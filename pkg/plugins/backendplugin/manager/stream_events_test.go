@@ -0,0 +1,161 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ServeStreamEvents(t *testing.T) {
+	pCtx := backend.PluginContext{PluginID: "test", OrgID: 1}
+
+	t.Run("Should send the packets of the stream as server-sent events", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				if err := sender.SendJSON([]byte(`{"value":1}`)); err != nil {
+					return err
+				}
+				return sender.SendJSON([]byte("{\n\"value\":2\n}"))
+			},
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/plugins/test/streams/random", nil)
+		err := m.serveStreamEvents(w, req, pCtx, "random")
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		require.Equal(t, "data: {\"value\":1}\n\n"+
+			"data: {\ndata: \"value\":2\ndata: }\n\n"+
+			"event: end\ndata: {}\n\n", w.Body.String())
+		waitForStreams(t, m, "test", 0)
+	})
+
+	t.Run("Should send an error event when the stream fails", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				return errors.New("boom")
+			},
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/plugins/test/streams/random", nil)
+		err := m.serveStreamEvents(w, req, pCtx, "random")
+		require.NoError(t, err)
+
+		require.Equal(t, "event: error\ndata: {\"message\":\"failed to run stream: boom\"}\n\n", w.Body.String())
+	})
+
+	t.Run("Should stop the stream when the client disconnects", func(t *testing.T) {
+		started := make(chan struct{})
+		m, _ := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				close(started)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/plugins/test/streams/random", nil).WithContext(ctx)
+		go func() {
+			<-started
+			cancel()
+		}()
+		err := m.serveStreamEvents(w, req, pCtx, "random")
+		require.NoError(t, err)
+
+		require.Empty(t, w.Body.String())
+		waitForStreams(t, m, "test", 0)
+	})
+
+	t.Run("Should stop sending keep-alive comments before returning", func(t *testing.T) {
+		interval := streamEventsKeepAliveInterval
+		streamEventsKeepAliveInterval = time.Millisecond
+		defer func() { streamEventsKeepAliveInterval = interval }()
+
+		m, _ := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				time.Sleep(20 * time.Millisecond)
+				return nil
+			},
+		})
+
+		w := &returnedResponseRecorder{ResponseRecorder: httptest.NewRecorder()}
+		req := httptest.NewRequest(http.MethodGet, "/api/plugins/test/streams/random", nil)
+		err := m.serveStreamEvents(w, req, pCtx, "random")
+		require.NoError(t, err)
+		w.setReturned()
+
+		require.Contains(t, w.Body.String(), ": keep-alive\n\n")
+		time.Sleep(20 * time.Millisecond)
+		require.Zero(t, w.writesAfterReturn())
+	})
+
+	t.Run("Should not start the response when the subscription is refused", func(t *testing.T) {
+		for status, expected := range map[backend.SubscribeStreamStatus]error{
+			backend.SubscribeStreamStatusNotFound:         errStreamNotFound,
+			backend.SubscribeStreamStatusPermissionDenied: errStreamPermissionDenied,
+		} {
+			m, _ := newStreamTestManager(&testStreamHandler{subscribeStatus: status})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/plugins/test/streams/random", nil)
+			err := m.serveStreamEvents(w, req, pCtx, "random")
+			require.ErrorIs(t, err, expected)
+			require.False(t, w.Flushed)
+		}
+	})
+
+	t.Run("Should return an error when the plugin isn't registered", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/plugins/unknown/streams/random", nil)
+		err := m.serveStreamEvents(w, req, backend.PluginContext{PluginID: "unknown"}, "random")
+		require.ErrorIs(t, err, backendplugin.ErrPluginNotRegistered)
+	})
+}
+
+// returnedResponseRecorder is a response recorder counting the writes after the handler writing to it returned.
+type returnedResponseRecorder struct {
+	*httptest.ResponseRecorder
+
+	mu             sync.Mutex
+	returned       bool
+	lateWriteCount int
+}
+
+func (r *returnedResponseRecorder) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.returned {
+		r.lateWriteCount++
+	}
+	return r.ResponseRecorder.Write(b)
+}
+
+func (r *returnedResponseRecorder) setReturned() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.returned = true
+}
+
+func (r *returnedResponseRecorder) writesAfterReturn() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lateWriteCount
+}
@@ -0,0 +1,61 @@
+package manager
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	pluginStreamChannels        *prometheus.GaugeVec
+	pluginStreamSubscribers     *prometheus.GaugeVec
+	pluginStreamMessages        *prometheus.CounterVec
+	pluginStreamDroppedMessages *prometheus.CounterVec
+)
+
+func init() {
+	pluginStreamChannels = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "plugin_stream_channels",
+		Help:      "Number of stream channels backend plugins are running a stream on",
+	}, []string{"plugin_id"})
+
+	pluginStreamSubscribers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "plugin_stream_subscribers",
+		Help:      "Number of subscribers of the streams of backend plugins",
+	}, []string{"plugin_id"})
+
+	pluginStreamMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "plugin_stream_messages_total",
+		Help:      "The total amount of stream messages sent by backend plugins",
+	}, []string{"plugin_id"})
+
+	pluginStreamDroppedMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "plugin_stream_dropped_messages_total",
+		Help:      "The total amount of plugin stream messages dropped by Grafana",
+	}, []string{"plugin_id", "reason"})
+
+	prometheus.MustRegister(pluginStreamChannels, pluginStreamSubscribers, pluginStreamMessages,
+		pluginStreamDroppedMessages)
+}
+
+// updateMetrics updates the channel and subscriber gauges of a plugin. The caller must hold s.mu.
+func (s *pluginStreams) updateMetrics(pluginID string) {
+	channels := s.streams[pluginID]
+	if len(channels) == 0 {
+		pluginStreamChannels.DeleteLabelValues(pluginID)
+		pluginStreamSubscribers.DeleteLabelValues(pluginID)
+		return
+	}
+
+	subscribers := 0
+	for _, stream := range channels {
+		stream.mu.RLock()
+		subscribers += len(stream.subscribers)
+		stream.mu.RUnlock()
+	}
+
+	pluginStreamChannels.WithLabelValues(pluginID).Set(float64(len(channels)))
+	pluginStreamSubscribers.WithLabelValues(pluginID).Set(float64(subscribers))
+}
@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginStreamMetrics(t *testing.T) {
+	m, _ := newStreamTestManager(&testStreamHandler{
+		runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	messages := pluginStreamMessages.WithLabelValues("test")
+	sentBefore := testutil.ToFloat64(messages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 3)
+	for _, path := range []string{"a", "a", "b"} {
+		req := &backend.RunStreamRequest{PluginContext: backend.PluginContext{PluginID: "test"}, Path: path}
+		go func() {
+			done <- m.RunStream(ctx, req, backend.NewStreamSender(&testPacketSender{}))
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(pluginStreamSubscribers.WithLabelValues("test")) == 3
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, float64(2), testutil.ToFloat64(pluginStreamChannels.WithLabelValues("test")))
+
+	stream := m.pluginStreams.streams["test"][streamChannel(backend.PluginContext{}, "a")]
+	require.NoError(t, stream.Send(&backend.StreamPacket{Data: []byte(`{}`)}))
+	require.Equal(t, float64(1), testutil.ToFloat64(messages)-sentBefore)
+
+	cancel()
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+	waitForStreams(t, m, "test", 0)
+	require.Zero(t, testutil.ToFloat64(pluginStreamChannels.WithLabelValues("test")))
+	require.Zero(t, testutil.ToFloat64(pluginStreamSubscribers.WithLabelValues("test")))
+}
@@ -0,0 +1,71 @@
+package manager
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// The reasons for dropping stream messages.
+const (
+	streamDropReasonRateLimit      = "rate_limit"
+	streamDropReasonBandwidthLimit = "bandwidth_limit"
+)
+
+// streamRateLimiter limits the message rate and bandwidth of a plugin stream channel. A nil limiter allows
+// everything.
+type streamRateLimiter struct {
+	messages *rate.Limiter
+	bytes    *rate.Limiter
+}
+
+// allow reports whether a message of the given size can be sent, and otherwise the reason for dropping it.
+func (l *streamRateLimiter) allow(size int, now time.Time) (bool, string) {
+	if l == nil {
+		return true, ""
+	}
+
+	var message *rate.Reservation
+	if l.messages != nil {
+		message = l.messages.ReserveN(now, 1)
+		if !message.OK() || message.DelayFrom(now) > 0 {
+			message.CancelAt(now)
+			return false, streamDropReasonRateLimit
+		}
+	}
+
+	if l.bytes != nil {
+		reservation := l.bytes.ReserveN(now, size)
+		if !reservation.OK() || reservation.DelayFrom(now) > 0 {
+			reservation.CancelAt(now)
+			if message != nil {
+				message.CancelAt(now)
+			}
+			return false, streamDropReasonBandwidthLimit
+		}
+	}
+
+	return true, ""
+}
+
+// newStreamRateLimiter returns the rate limiter of a stream channel of a plugin, nil if it's not limited.
+func (m *Manager) newStreamRateLimiter(pluginID string) *streamRateLimiter {
+	var limiter streamRateLimiter
+
+	if limit := getPluginIntSetting(pluginID, "stream_rate_limit", m.Cfg, m.Cfg.PluginsStreamRateLimit); limit > 0 {
+		burst := getPluginIntSetting(pluginID, "stream_rate_limit_burst", m.Cfg, m.Cfg.PluginsStreamRateLimitBurst)
+		if burst < 1 {
+			burst = limit
+		}
+		limiter.messages = rate.NewLimiter(rate.Limit(limit), burst)
+	}
+
+	if limit := getPluginIntSetting(pluginID, "stream_bandwidth_limit", m.Cfg, m.Cfg.PluginsStreamBandwidthLimit); limit > 0 {
+		limiter.bytes = rate.NewLimiter(rate.Limit(limit), limit)
+	}
+
+	if limiter.messages == nil && limiter.bytes == nil {
+		return nil
+	}
+	return &limiter
+}
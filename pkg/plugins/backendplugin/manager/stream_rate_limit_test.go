@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamRateLimiter(t *testing.T) {
+	t.Run("Should not limit streams by default", func(t *testing.T) {
+		m := &Manager{Cfg: setting.NewCfg()}
+		limiter := m.newStreamRateLimiter("test")
+		require.Nil(t, limiter)
+
+		allowed, _ := limiter.allow(1024, time.Now())
+		require.True(t, allowed)
+	})
+
+	t.Run("Should limit the message rate", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PluginsStreamRateLimit = 1
+		cfg.PluginSettings = setting.PluginSettings{
+			"test": map[string]string{"stream_rate_limit_burst": "2"},
+		}
+		limiter := (&Manager{Cfg: cfg}).newStreamRateLimiter("test")
+		now := time.Now()
+
+		for i := 0; i < 2; i++ {
+			allowed, _ := limiter.allow(10, now)
+			require.True(t, allowed)
+		}
+
+		allowed, reason := limiter.allow(10, now)
+		require.False(t, allowed)
+		require.Equal(t, streamDropReasonRateLimit, reason)
+
+		allowed, _ = limiter.allow(10, now.Add(time.Second))
+		require.True(t, allowed)
+	})
+
+	t.Run("Should limit the bandwidth without using message tokens", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PluginsStreamRateLimit = 1
+		cfg.PluginsStreamBandwidthLimit = 100
+		limiter := (&Manager{Cfg: cfg}).newStreamRateLimiter("test")
+		now := time.Now()
+
+		allowed, reason := limiter.allow(101, now)
+		require.False(t, allowed)
+		require.Equal(t, streamDropReasonBandwidthLimit, reason)
+
+		allowed, _ = limiter.allow(100, now)
+		require.True(t, allowed)
+	})
+}
+
+func TestPluginStream_RateLimit(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.PluginsStreamRateLimit = 1
+	m := &Manager{Cfg: cfg}
+
+	sub := newStreamSubscriber(backend.NewStreamSender(&testPacketSender{}), 10, streamOverflowPolicyDropOldest)
+	stream, _ := m.pluginStreams.subscribe("rate-limited", "1//path", sub, m.newStreamRateLimiter("rate-limited"), nil)
+
+	dropped := pluginStreamDroppedMessages.WithLabelValues("rate-limited", streamDropReasonRateLimit)
+	before := testutil.ToFloat64(dropped)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, stream.Send(&backend.StreamPacket{Data: []byte(`{}`)}))
+	}
+	require.Len(t, sub.packets, 1)
+	require.Equal(t, float64(2), testutil.ToFloat64(dropped)-before)
+}
@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"sync"
+	"time"
+)
+
+// streamReplayBuffer holds the recent messages of a plugin stream channel, so that subscribers joining the channel
+// receive the data they missed during a brief interruption, such as a reconnecting client or a restarted stream. It
+// outlives the streams of the channel until its messages expire. A nil buffer holds nothing.
+type streamReplayBuffer struct {
+	window time.Duration
+	// size is the maximum number of buffered messages, so that replaying them never overflows the buffer of a new
+	// subscriber.
+	size int
+
+	mu       sync.Mutex
+	messages []streamReplayMessage
+}
+
+type streamReplayMessage struct {
+	received time.Time
+	data     []byte
+}
+
+// add buffers a message received at now, dropping the ones that expired or exceed the size of the buffer.
+func (b *streamReplayBuffer) add(data []byte, now time.Time) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.messages = append(b.messages, streamReplayMessage{received: now, data: data})
+	b.trim(now)
+}
+
+// recent returns the messages that are still in the window at now, oldest first.
+func (b *streamReplayBuffer) recent(now time.Time) [][]byte {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trim(now)
+	messages := make([][]byte, 0, len(b.messages))
+	for _, message := range b.messages {
+		messages = append(messages, message.data)
+	}
+	return messages
+}
+
+// expired reports whether all messages of the buffer expired at now.
+func (b *streamReplayBuffer) expired(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trim(now)
+	return len(b.messages) == 0
+}
+
+// trim drops the expired messages and the oldest ones exceeding the size of the buffer. The caller must hold b.mu.
+func (b *streamReplayBuffer) trim(now time.Time) {
+	first := 0
+	if excess := len(b.messages) - b.size; excess > 0 {
+		first = excess
+	}
+	for first < len(b.messages) && now.Sub(b.messages[first].received) > b.window {
+		first++
+	}
+	if first == 0 {
+		return
+	}
+
+	b.messages = append(b.messages[:0], b.messages[first:]...)
+}
+
+// newStreamReplayBuffer returns a buffer for the recent messages of a stream channel of a plugin, nil if its
+// messages aren't replayed.
+func (m *Manager) newStreamReplayBuffer(pluginID string) *streamReplayBuffer {
+	window := getPluginIntSetting(pluginID, "stream_replay_window", m.Cfg, m.Cfg.PluginsStreamReplayWindow)
+	if window <= 0 {
+		return nil
+	}
+
+	size := getPluginIntSetting(pluginID, "stream_subscriber_buffer_size", m.Cfg, m.Cfg.PluginsStreamSubscriberBufferSize)
+	if size < 1 {
+		size = 1
+	}
+	return &streamReplayBuffer{window: time.Duration(window) * time.Second, size: size}
+}
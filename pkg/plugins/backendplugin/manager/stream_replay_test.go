@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamReplayBuffer(t *testing.T) {
+	t.Run("Should not replay streams by default", func(t *testing.T) {
+		m := &Manager{Cfg: setting.NewCfg()}
+		replay := m.newStreamReplayBuffer("test")
+		require.Nil(t, replay)
+
+		replay.add([]byte("1"), time.Now())
+		require.Empty(t, replay.recent(time.Now()))
+	})
+
+	t.Run("Should drop expired messages", func(t *testing.T) {
+		replay := &streamReplayBuffer{window: time.Second, size: 10}
+		now := time.Now()
+
+		replay.add([]byte("1"), now)
+		replay.add([]byte("2"), now.Add(500*time.Millisecond))
+		require.Equal(t, [][]byte{[]byte("1"), []byte("2")}, replay.recent(now.Add(time.Second)))
+		require.Equal(t, [][]byte{[]byte("2")}, replay.recent(now.Add(1200*time.Millisecond)))
+		require.True(t, replay.expired(now.Add(2*time.Second)))
+	})
+
+	t.Run("Should keep at most the subscriber buffer size of messages", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PluginsStreamReplayWindow = 10
+		cfg.PluginSettings = setting.PluginSettings{
+			"test": map[string]string{"stream_subscriber_buffer_size": "2"},
+		}
+		replay := (&Manager{Cfg: cfg}).newStreamReplayBuffer("test")
+		now := time.Now()
+
+		for _, data := range []string{"1", "2", "3"} {
+			replay.add([]byte(data), now)
+		}
+		require.Equal(t, [][]byte{[]byte("2"), []byte("3")}, replay.recent(now))
+	})
+}
+
+func TestManager_StreamReplay(t *testing.T) {
+	req := &backend.RunStreamRequest{PluginContext: backend.PluginContext{PluginID: "test"}, Path: "random"}
+
+	t.Run("Should replay recent messages to subscribers of a restarted stream", func(t *testing.T) {
+		runs := make(chan int, 2)
+		m, _ := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				run := len(runs) + 1
+				runs <- run
+				if run == 1 {
+					if err := sender.SendJSON([]byte(`{"value":1}`)); err != nil {
+						return err
+					}
+					return sender.SendJSON([]byte(`{"value":2}`))
+				}
+				if err := sender.SendJSON([]byte(`{"value":3}`)); err != nil {
+					return err
+				}
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+		m.Cfg.PluginsStreamReplayWindow = 10
+
+		first := &testPacketSender{}
+		require.NoError(t, m.RunStream(context.Background(), req, backend.NewStreamSender(first)))
+		require.Equal(t, []string{`{"value":1}`, `{"value":2}`}, first.get())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		second := &testPacketSender{}
+		done := make(chan error, 1)
+		go func() {
+			done <- m.RunStream(ctx, req, backend.NewStreamSender(second))
+		}()
+
+		require.Eventually(t, func() bool { return len(second.get()) == 3 }, time.Second, 10*time.Millisecond)
+		require.Equal(t, []string{`{"value":1}`, `{"value":2}`, `{"value":3}`}, second.get())
+
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	})
+
+	t.Run("Should drop the replay buffers of expired channels", func(t *testing.T) {
+		m, _ := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				return sender.SendJSON([]byte(`{}`))
+			},
+		})
+		m.Cfg.PluginsStreamReplayWindow = 1
+
+		require.NoError(t, m.RunStream(context.Background(), req, backend.NewStreamSender(&testPacketSender{})))
+		replay := m.pluginStreams.replays["test"][streamChannel(req.PluginContext, req.Path)]
+		require.NotNil(t, replay)
+
+		m.pluginStreams.mu.Lock()
+		m.pluginStreams.pruneReplays("test", time.Now().Add(2*time.Second))
+		m.pluginStreams.mu.Unlock()
+		require.Empty(t, m.pluginStreams.replays)
+	})
+}
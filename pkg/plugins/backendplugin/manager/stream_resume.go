@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// streamResumeTimeout returns how long an interrupted stream of a plugin waits for its process to be restarted
+// before it's ended, 0 meaning streams aren't resumed.
+func (m *Manager) streamResumeTimeout(pluginID string) time.Duration {
+	timeout := getPluginIntSetting(pluginID, "stream_resume_timeout", m.Cfg, m.Cfg.PluginsStreamResumeTimeout)
+	if timeout <= 0 {
+		return 0
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+// streamInterrupted reports whether a stream of plugin p ended with err because the plugin process exited.
+func streamInterrupted(p backendplugin.Plugin, err error) bool {
+	if err == nil || !p.IsManaged() {
+		return false
+	}
+
+	return p.Exited() || errors.Is(err, backendplugin.ErrPluginUnavailable) || status.Code(err) == codes.Unavailable
+}
+
+// runStreamWithResume runs the stream of plugin p on a channel. When the stream is interrupted because the plugin
+// process exited, it's run again on the same channel once the restart loop has brought the process back, so its
+// subscribers keep receiving packets. The plugin SDK doesn't pass the sequence of the last sent packet to the
+// plugin, so a resumed stream starts from the current state of the plugin.
+func (m *Manager) runStreamWithResume(ctx context.Context, p backendplugin.Plugin, channel string,
+	req *backend.RunStreamRequest, stream *pluginStream) error {
+	pluginID := p.PluginID()
+
+	var deadline time.Time
+	for {
+		sequence := stream.sequence()
+		err := tracePluginRequest(ctx, pluginID, "runStream", func(ctx context.Context) error {
+			return p.RunStream(ctx, req, backend.NewStreamSender(stream))
+		})
+		if ctx.Err() != nil || !streamInterrupted(p, err) {
+			return err
+		}
+
+		timeout := m.streamResumeTimeout(pluginID)
+		if timeout <= 0 {
+			return err
+		}
+		// The stream gets the full timeout again if it sent packets since it was last resumed.
+		if deadline.IsZero() || stream.sequence() != sequence {
+			deadline = time.Now().Add(timeout)
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 || !waitForPluginProcess(ctx, p, remaining) {
+			return err
+		}
+
+		p.Logger().Info("Resuming stream interrupted by plugin restart", "channel", channel,
+			"lastSequence", stream.sequence(), "error", err)
+	}
+}
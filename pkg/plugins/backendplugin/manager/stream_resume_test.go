@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_StreamResume(t *testing.T) {
+	req := &backend.RunStreamRequest{PluginContext: backend.PluginContext{PluginID: "test"}, Path: "random"}
+
+	t.Run("Should resume a stream interrupted by a plugin restart", func(t *testing.T) {
+		var runs int32
+		var p *testPlugin
+		m, p := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				run := atomic.AddInt32(&runs, 1)
+				if err := sender.SendJSON([]byte(fmt.Sprintf(`{"run":%d}`, run))); err != nil {
+					return err
+				}
+				if run == 1 {
+					p.kill()
+					go func() {
+						time.Sleep(50 * time.Millisecond)
+						_ = p.Start(context.Background())
+					}()
+					return backendplugin.ErrPluginUnavailable
+				}
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+		p.managed = true
+		m.Cfg.PluginsStreamResumeTimeout = 5
+
+		sender := &testPacketSender{}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- m.RunStream(ctx, req, backend.NewStreamSender(sender))
+		}()
+
+		require.Eventually(t, func() bool { return len(sender.get()) == 2 }, 2*time.Second, 10*time.Millisecond)
+		require.Equal(t, []string{`{"run":1}`, `{"run":2}`}, sender.get())
+		require.Equal(t, int32(2), atomic.LoadInt32(&runs))
+
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	})
+
+	t.Run("Should end a stream that isn't interrupted by the plugin process", func(t *testing.T) {
+		var runs int32
+		m, p := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				atomic.AddInt32(&runs, 1)
+				return errors.New("boom")
+			},
+		})
+		p.managed = true
+		m.Cfg.PluginsStreamResumeTimeout = 5
+
+		err := m.RunStream(context.Background(), req, backend.NewStreamSender(&testPacketSender{}))
+		require.EqualError(t, err, "failed to run stream: boom")
+		require.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	})
+
+	t.Run("Should end an interrupted stream when resuming is disabled", func(t *testing.T) {
+		m, p := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				return backendplugin.ErrPluginUnavailable
+			},
+		})
+		p.managed = true
+
+		err := m.RunStream(context.Background(), req, backend.NewStreamSender(&testPacketSender{}))
+		require.ErrorIs(t, err, backendplugin.ErrPluginUnavailable)
+	})
+
+	t.Run("Should end an interrupted stream when the plugin isn't restarted in time", func(t *testing.T) {
+		m, p := newStreamTestManager(&testStreamHandler{
+			runStream: func(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+				return backendplugin.ErrPluginUnavailable
+			},
+		})
+		p.managed = true
+		p.kill()
+		m.Cfg.PluginsStreamResumeTimeout = 1
+
+		start := time.Now()
+		err := m.RunStream(context.Background(), req, backend.NewStreamSender(&testPacketSender{}))
+		require.ErrorIs(t, err, backendplugin.ErrPluginUnavailable)
+		require.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+}
@@ -0,0 +1,38 @@
+package backendplugin
+
+import "time"
+
+// PluginStatus is the process status of a backend plugin, computed by the backend plugin manager.
+type PluginStatus string
+
+const (
+	// PluginStatusNotStarted means the plugin process was never started, or failed to start.
+	PluginStatusNotStarted PluginStatus = "notStarted"
+	// PluginStatusRunning means the plugin process is running.
+	PluginStatusRunning PluginStatus = "running"
+	// PluginStatusDegraded means the plugin process exited after having been started, and is waiting to be
+	// restarted.
+	PluginStatusDegraded PluginStatus = "degraded"
+	// PluginStatusCrashLooping means the plugin process keeps exiting and being restarted.
+	PluginStatusCrashLooping PluginStatus = "crashLooping"
+	// PluginStatusDecommissioned means the plugin was decommissioned and won't be restarted.
+	PluginStatusDecommissioned PluginStatus = "decommissioned"
+	// PluginStatusFailed means the plugin process exceeded its restart budget and won't be restarted until the
+	// plugin is restarted or reloaded.
+	PluginStatusFailed PluginStatus = "failed"
+)
+
+// PluginState is the process state of a registered backend plugin.
+type PluginState struct {
+	PluginID string
+	Status   PluginStatus
+	Managed  bool
+	// Restarts is the number of times the plugin process was restarted after exiting.
+	Restarts int
+	// LastRestart is when the plugin process was last restarted, zero if never.
+	LastRestart time.Time
+	// StartedAt is when the plugin process was last started, zero if never.
+	StartedAt time.Time
+	// LastError is the error of the last failed start of the plugin process, empty if none.
+	LastError string
+}
@@ -0,0 +1,21 @@
+package backendplugin
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// QueryDataMiddleware wraps plugin data queries, for example to audit queries, modify requests, observe responses
+// or reject queries, without modifying the manager. A middleware can short-circuit a query by returning a
+// response or an error without calling next.
+type QueryDataMiddleware interface {
+	// WrapQueryDataHandler returns a backend.QueryDataHandler wrapping next.
+	WrapQueryDataHandler(next backend.QueryDataHandler) backend.QueryDataHandler
+}
+
+// QueryDataMiddlewareFunc is an adapter to allow the use of ordinary functions as QueryDataMiddleware.
+type QueryDataMiddlewareFunc func(next backend.QueryDataHandler) backend.QueryDataHandler
+
+// WrapQueryDataHandler calls fn(next).
+func (fn QueryDataMiddlewareFunc) WrapQueryDataHandler(next backend.QueryDataHandler) backend.QueryDataHandler {
+	return fn(next)
+}
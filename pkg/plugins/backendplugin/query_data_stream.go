@@ -0,0 +1,26 @@
+package backendplugin
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// QueryDataResponseSender sends the result of a single query of a data query request.
+type QueryDataResponseSender interface {
+	Send(refID string, resp backend.DataResponse) error
+}
+
+// QueryDataResponseSenderFunc is an adapter to allow the use of ordinary functions as QueryDataResponseSender.
+type QueryDataResponseSenderFunc func(refID string, resp backend.DataResponse) error
+
+// Send calls fn(refID, resp).
+func (fn QueryDataResponseSenderFunc) Send(refID string, resp backend.DataResponse) error {
+	return fn(refID, resp)
+}
+
+// QueryDataStreamHandler is implemented by plugins able to return the results of the queries of a data query
+// request incrementally, as soon as each of them is available.
+type QueryDataStreamHandler interface {
+	QueryDataStream(ctx context.Context, req *backend.QueryDataRequest, sender QueryDataResponseSender) error
+}
@@ -0,0 +1,36 @@
+package backendplugin
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ResourceHandler handles plugin resource calls.
+type ResourceHandler interface {
+	// CallResource calls a plugin resource and writes the response to w.
+	CallResource(w http.ResponseWriter, req *http.Request, pCtx backend.PluginContext) error
+}
+
+// ResourceHandlerFunc is an adapter to allow the use of ordinary functions as ResourceHandler.
+type ResourceHandlerFunc func(w http.ResponseWriter, req *http.Request, pCtx backend.PluginContext) error
+
+// CallResource calls fn(w, req, pCtx).
+func (fn ResourceHandlerFunc) CallResource(w http.ResponseWriter, req *http.Request, pCtx backend.PluginContext) error {
+	return fn(w, req, pCtx)
+}
+
+// ResourceMiddleware wraps plugin resource calls, for example to add authorization, logging, caching or
+// header rewriting, without modifying the manager.
+type ResourceMiddleware interface {
+	// WrapResourceHandler returns a ResourceHandler wrapping next.
+	WrapResourceHandler(next ResourceHandler) ResourceHandler
+}
+
+// ResourceMiddlewareFunc is an adapter to allow the use of ordinary functions as ResourceMiddleware.
+type ResourceMiddlewareFunc func(next ResourceHandler) ResourceHandler
+
+// WrapResourceHandler calls fn(next).
+func (fn ResourceMiddlewareFunc) WrapResourceHandler(next ResourceHandler) ResourceHandler {
+	return fn(next)
+}
@@ -0,0 +1,21 @@
+package backendplugin
+
+// RuntimeStats are the runtime stats of the backend plugin subsystem, used for attributing performance issues to
+// plugins.
+type RuntimeStats struct {
+	// Goroutines is the total number of goroutines of the Grafana server.
+	Goroutines int `json:"goroutines"`
+	// ProfilingLabels tells whether goroutines running plugin requests are labeled with the plugin ID in profiles.
+	ProfilingLabels bool                 `json:"profilingLabels"`
+	Plugins         []PluginRuntimeStats `json:"plugins"`
+}
+
+// PluginRuntimeStats are the runtime stats of a registered backend plugin.
+type PluginRuntimeStats struct {
+	PluginID string `json:"pluginId"`
+	// InFlightRequests is the number of requests to the plugin that haven't completed yet.
+	InFlightRequests int `json:"inFlightRequests"`
+	// Goroutines is the number of goroutines run for the plugin by kind, like restart loops and response
+	// streaming.
+	Goroutines map[string]int `json:"goroutines"`
+}
@@ -0,0 +1,23 @@
+package backendplugin
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// StreamAuthorizer decides whether a user may subscribe to a plugin stream channel before the plugin is asked, for
+// example to check the permissions of the user on the organization or data source of the channel, so that streams
+// are subject to the same access control as data queries.
+type StreamAuthorizer interface {
+	// AuthorizeStream returns whether the user of the plugin context of req may subscribe to the channel.
+	AuthorizeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (bool, error)
+}
+
+// StreamAuthorizerFunc is an adapter to allow the use of ordinary functions as StreamAuthorizer.
+type StreamAuthorizerFunc func(ctx context.Context, req *backend.SubscribeStreamRequest) (bool, error)
+
+// AuthorizeStream calls fn(ctx, req).
+func (fn StreamAuthorizerFunc) AuthorizeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (bool, error) {
+	return fn(ctx, req)
+}
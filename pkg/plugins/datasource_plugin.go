@@ -26,9 +26,10 @@ type DataSourcePlugin struct {
 	Routes       []*AppPluginRoute `json:"routes"`
 	Streaming    bool              `json:"streaming"`
 
-	Backend    bool   `json:"backend,omitempty"`
-	Executable string `json:"executable,omitempty"`
-	SDK        bool   `json:"sdk,omitempty"`
+	Backend    bool             `json:"backend,omitempty"`
+	Executable string           `json:"executable,omitempty"`
+	Container  *PluginContainer `json:"container,omitempty"`
+	SDK        bool             `json:"sdk,omitempty"`
 }
 
 func (p *DataSourcePlugin) Load(decoder *json.Decoder, base *PluginBase, backendPluginManager backendplugin.Manager) (
@@ -41,6 +42,9 @@ func (p *DataSourcePlugin) Load(decoder *json.Decoder, base *PluginBase, backend
 		cmd := ComposePluginStartCommand(p.Executable)
 		fullpath := filepath.Join(base.PluginDir, cmd)
 		factory := grpcplugin.NewBackendPlugin(p.Id, fullpath)
+		if p.Container != nil && p.Container.Image != "" {
+			factory = grpcplugin.NewContainerBackendPlugin(p.Id, fullpath, p.Container.Image)
+		}
 		if err := backendPluginManager.RegisterAndStart(context.Background(), p.Id, factory); err != nil {
 			return nil, errutil.Wrapf(err, "failed to register backend plugin")
 		}
@@ -5,4 +5,31 @@ type ErrorCode string
 type PluginError struct {
 	ErrorCode `json:"errorCode"`
 	PluginID  string `json:"pluginId,omitempty"`
+	// Path is the directory of the plugin that failed to load.
+	Path string `json:"path,omitempty"`
+	// Message describes why the plugin failed to load.
+	Message string `json:"message,omitempty"`
+}
+
+// InitStage is the stage of initializing plugins at which a plugin directory failed.
+type InitStage string
+
+const (
+	// InitStageScan is the stage of walking a plugin directory for plugins.
+	InitStageScan InitStage = "scan"
+	// InitStageLoad is the stage of reading the plugin.json file and module of a plugin.
+	InitStageLoad InitStage = "load"
+	// InitStageRegister is the stage of loading a plugin and registering its backend.
+	InitStageRegister InitStage = "register"
+)
+
+// InitFailure describes a plugin directory that failed to initialize. Grafana starts without the plugins of the
+// directory.
+type InitFailure struct {
+	// Path is the plugin directory, or the scanned directory if it couldn't be walked.
+	Path string `json:"path"`
+	// PluginID is the ID of the plugin, if known.
+	PluginID string    `json:"pluginId,omitempty"`
+	Stage    InitStage `json:"stage"`
+	Error    string    `json:"error"`
 }
@@ -51,7 +51,8 @@ func defaultLogoPath(pluginType string) string {
 
 func (fp *FrontendPluginBase) setPathsBasedOnApp(app *AppPlugin, cfg *setting.Cfg) {
 	appSubPath := strings.ReplaceAll(strings.Replace(fp.PluginDir, app.PluginDir, "", 1), "\\", "/")
-	fp.IncludedInAppId = app.Id
+	fp.Parent = &app.PluginBase
+	app.Children = append(app.Children, &fp.PluginBase)
 	fp.BaseUrl = app.BaseUrl
 
 	if isExternalPlugin(app.PluginDir, cfg) {
@@ -17,6 +17,8 @@ type Manager interface {
 	GetPlugin(id string) *PluginBase
 	// GetApp gets an app plugin with a certain ID.
 	GetApp(id string) *AppPlugin
+	// GetPanel gets a panel plugin with a certain ID.
+	GetPanel(id string) *PanelPlugin
 	// DataSourceCount gets the number of data sources.
 	DataSourceCount() int
 	// DataSources gets all data sources.
@@ -33,15 +35,22 @@ type Manager interface {
 	GrafanaLatestVersion() string
 	// GrafanaHasUpdate returns whether Grafana has an update.
 	GrafanaHasUpdate() bool
-	// Plugins gets all plugins.
-	Plugins() []*PluginBase
+	// Plugins gets all plugins, or the plugins of the given types, sorted by ID.
+	Plugins(pluginTypes ...string) []*PluginBase
+	// ListPlugins lists a page of the plugins matching query, sorted by name.
+	ListPlugins(query PluginListQuery) (PluginListResult, error)
 	// StaticRoutes gets all static routes.
 	StaticRoutes() []*PluginStaticRoute
 	// GetPluginSettings gets settings for a certain plugin.
 	GetPluginSettings(orgID int64) (map[string]*models.PluginSettingInfoDTO, error)
+	// GetAppSettings gets the settings of an installed app plugin in an organization.
+	GetAppSettings(orgID int64, pluginID string) (*models.PluginSetting, error)
+	// UpdateAppSettings updates the settings of an installed app plugin in an organization and notifies its
+	// backend of the change.
+	UpdateAppSettings(cmd *models.UpdatePluginSettingCmd) error
 	// GetPluginDashboards gets dashboards for a certain org/plugin.
 	GetPluginDashboards(orgID int64, pluginID string) ([]*PluginDashboardInfoDTO, error)
-	// GetPluginMarkdown gets markdown for a certain plugin/name.
+	// GetPluginMarkdown gets markdown for a certain plugin/name, if included in the plugin signature.
 	GetPluginMarkdown(pluginID string, name string) ([]byte, error)
 	// ImportDashboard imports a dashboard.
 	ImportDashboard(pluginID, path string, orgID, folderID int64, dashboardModel *simplejson.Json,
@@ -49,14 +58,40 @@ type Manager interface {
 		requestHandler DataRequestHandler) (PluginDashboardInfoDTO, *models.Dashboard, error)
 	// ScanningErrors returns plugin scanning errors encountered.
 	ScanningErrors() []PluginError
+	// InitFailures returns the plugin directories that failed to initialize, by path.
+	InitFailures() []InitFailure
 	// LoadPluginDashboard loads a plugin dashboard.
 	LoadPluginDashboard(pluginID, path string) (*models.Dashboard, error)
 	// IsAppInstalled returns whether an app is installed.
 	IsAppInstalled(id string) bool
-	// Install installs a plugin.
-	Install(ctx context.Context, pluginID, version string) error
+	// Install installs a plugin, or upgrades it to version. Install and uninstall operations of the same plugin are
+	// serialized.
+	Install(ctx context.Context, pluginID, version string, opts InstallOpts) error
+	// EnsureInstalled installs a plugin unless it's installed, replacing the installed version if version is
+	// set and differs. It returns whether the plugin was installed.
+	EnsureInstalled(ctx context.Context, pluginID, version string) (bool, error)
 	// Uninstall uninstalls a plugin.
 	Uninstall(ctx context.Context, pluginID string) error
+	// InstallCanary installs version of an installed backend plugin side by side with it, to handle the requests
+	// selected by the canary settings of the plugin.
+	InstallCanary(ctx context.Context, pluginID, version string) error
+	// RemoveCanary stops and removes the canary version of a plugin.
+	RemoveCanary(ctx context.Context, pluginID string) error
+	// PromoteCanary upgrades a plugin to the version of its canary version.
+	PromoteCanary(ctx context.Context, pluginID string) error
+	// CanaryVersion returns the version of the canary version of a plugin, false if it has none.
+	CanaryVersion(pluginID string) (string, bool)
+	// CheckUpdate checks whether a newer version of an installed plugin is available.
+	CheckUpdate(pluginID string) (PluginUpdate, error)
+	// Scan reports which plugins would be loaded from the provided directories without loading them, and the
+	// errors of the plugins that couldn't be loaded. Unsigned plugins are only refused if requireSigned is set.
+	Scan(pluginDirs []string, requireSigned bool) ([]ScannedPlugin, error)
+	// PluginsHealth returns an aggregated health summary of the backend plugin processes.
+	PluginsHealth() PluginsHealth
+	// PluginStates returns all registered plugins with the runtime state of their backend process.
+	PluginStates() []PluginRuntimeState
+	// DependencyGraph returns the dependency graph of the plugins.
+	DependencyGraph() PluginDependencyGraph
 }
 
 type ImportDashboardInput struct {
@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// angularPatterns are found in the modules of plugins using Angular, which import the Angular plugin SDK, extend
+// its controllers or reference Angular templates and APIs.
+var angularPatterns = [][]byte{
+	[]byte("app/plugins/sdk"),
+	[]byte("PanelCtrl"),
+	[]byte("QueryCtrl"),
+	[]byte("ConfigCtrl"),
+	[]byte("AnnotationsQueryCtrl"),
+	[]byte("angular.isNumber("),
+	[]byte("editor.html"),
+	[]byte("ctrl.annotation"),
+	[]byte("getLegacyAngularInjector"),
+}
+
+// detectAngular returns whether the module.js of the plugin in pluginDir uses Angular. Plugins without a
+// module.js, such as backend only plugins, don't.
+func detectAngular(pluginDir string) (bool, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `pluginDir` is based
+	// on plugin the folder structure on disk and not user input.
+	module, err := ioutil.ReadFile(filepath.Join(pluginDir, "module.js"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, pattern := range angularPatterns {
+		if bytes.Contains(module, pattern) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	angularModule = `define(["app/plugins/sdk"], function(sdk) {
+		var ClockCtrl = function() {};
+		ClockCtrl.prototype = Object.create(sdk.PanelCtrl.prototype);
+		return { PanelCtrl: ClockCtrl };
+	});`
+	reactModule = `define(["@grafana/data"], function(data) {
+		return { plugin: new data.PanelPlugin(function() {}) };
+	});`
+)
+
+func TestDetectAngular(t *testing.T) {
+	tcs := []struct {
+		module          string
+		angularDetected bool
+	}{
+		{module: angularModule, angularDetected: true},
+		{module: reactModule, angularDetected: false},
+		{module: `System.register(["app/plugins/sdk"], function(e) {})`, angularDetected: true},
+		{module: `templateUrl: "partials/query.editor.html"`, angularDetected: true},
+	}
+	for i, tc := range tcs {
+		t.Run(fmt.Sprintf("module %d", i), func(t *testing.T) {
+			pluginDir := t.TempDir()
+			require.NoError(t, ioutil.WriteFile(filepath.Join(pluginDir, "module.js"), []byte(tc.module), 0600))
+
+			angularDetected, err := detectAngular(pluginDir)
+			require.NoError(t, err)
+			assert.Equal(t, tc.angularDetected, angularDetected)
+		})
+	}
+
+	t.Run("plugins without module.js don't use Angular", func(t *testing.T) {
+		angularDetected, err := detectAngular(t.TempDir())
+		require.NoError(t, err)
+		assert.False(t, angularDetected)
+	})
+}
+
+func TestPluginManager_Angular(t *testing.T) {
+	writePanel := func(t *testing.T, pluginsDir, id, module string) {
+		dir := filepath.Join(pluginsDir, id)
+		require.NoError(t, os.MkdirAll(dir, 0750))
+		pluginJSON := fmt.Sprintf(`{"type": "panel", "id": %q, "name": %q, "info": {"version": "1.0.0"}}`, id, id)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugin.json"), []byte(pluginJSON), 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "module.js"), []byte(module), 0600))
+	}
+	newAngularManager := func(t *testing.T, pluginsDir string, block bool) *PluginManager {
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.BackendPluginManager = &fakeBackendPluginManager{}
+			pm.Cfg.PluginsPath = pluginsDir
+			pm.Cfg.PluginsAllowUnsigned = []string{"angular-panel", "react-panel"}
+			pm.Cfg.PluginsBlockAngular = block
+		})
+		require.NoError(t, pm.init())
+		return pm
+	}
+	angularErrors := func(pm *PluginManager) []string {
+		ids := []string{}
+		for _, e := range pm.ScanningErrors() {
+			if e.ErrorCode == angularBlocked {
+				ids = append(ids, e.PluginID)
+			}
+		}
+		return ids
+	}
+
+	t.Run("Flags plugins using Angular", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writePanel(t, pluginsDir, "angular-panel", angularModule)
+		writePanel(t, pluginsDir, "react-panel", reactModule)
+		pm := newAngularManager(t, pluginsDir, false)
+
+		require.NotNil(t, pm.GetPlugin("angular-panel"))
+		assert.True(t, pm.GetPlugin("angular-panel").AngularDetected)
+		require.NotNil(t, pm.GetPlugin("react-panel"))
+		assert.False(t, pm.GetPlugin("react-panel").AngularDetected)
+		assert.Empty(t, angularErrors(pm))
+
+		// core plugins are never flagged
+		for _, p := range pm.Plugins() {
+			if p.IsCorePlugin {
+				assert.False(t, p.AngularDetected, p.Id)
+			}
+		}
+	})
+
+	t.Run("Refuses plugins using Angular if blocked", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writePanel(t, pluginsDir, "angular-panel", angularModule)
+		writePanel(t, pluginsDir, "react-panel", reactModule)
+		pm := newAngularManager(t, pluginsDir, true)
+
+		assert.Nil(t, pm.GetPlugin("angular-panel"))
+		assert.NotNil(t, pm.GetPlugin("react-panel"))
+		assert.NotNil(t, pm.GetPlugin("graph"))
+		assert.Equal(t, []string{"angular-panel"}, angularErrors(pm))
+
+		t.Run("Loads refused plugins once they're migrated from Angular", func(t *testing.T) {
+			writePanel(t, pluginsDir, "angular-panel", reactModule)
+			require.NoError(t, pm.initExternalPlugins())
+
+			require.NotNil(t, pm.GetPlugin("angular-panel"))
+			assert.False(t, pm.GetPlugin("angular-panel").AngularDetected)
+			assert.Empty(t, angularErrors(pm))
+		})
+	})
+}
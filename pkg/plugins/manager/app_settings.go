@@ -0,0 +1,107 @@
+package manager
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// GetAppSettings returns the settings of an installed app plugin in an organization. Apps without stored
+// settings get their defaults, being enabled and pinned if they're auto enabled.
+func (pm *PluginManager) GetAppSettings(orgID int64, pluginID string) (*models.PluginSetting, error) {
+	app := pm.GetApp(pluginID)
+	if app == nil {
+		return nil, plugins.PluginNotFoundError{PluginID: pluginID}
+	}
+
+	query := &models.GetPluginSettingByIdQuery{OrgId: orgID, PluginId: pluginID}
+	if err := bus.Dispatch(query); err != nil {
+		if !errors.Is(err, models.ErrPluginSettingNotFound) {
+			return nil, err
+		}
+		return &models.PluginSetting{
+			OrgId:    orgID,
+			PluginId: pluginID,
+			Enabled:  app.AutoEnabled,
+			Pinned:   app.AutoEnabled,
+			JsonData: map[string]interface{}{},
+		}, nil
+	}
+
+	return query.Result, nil
+}
+
+// UpdateAppSettings updates the settings of an installed app plugin in an organization, keeping the stored plugin
+// version unless cmd sets one. A PluginSettingUpdated event is published once the settings are stored, on which the
+// cached settings passed to the backend of the plugin are dropped, so that its next request carries the new
+// settings and the plugin replaces its instance for the organization. Enabling or disabling an app enables or
+// disables the plugins it bundles, which follow the settings of the app unless they have their own.
+func (pm *PluginManager) UpdateAppSettings(cmd *models.UpdatePluginSettingCmd) error {
+	app := pm.GetApp(cmd.PluginId)
+	if app == nil {
+		return plugins.PluginNotFoundError{PluginID: cmd.PluginId}
+	}
+
+	if cmd.PluginVersion == "" {
+		query := &models.GetPluginSettingByIdQuery{OrgId: cmd.OrgId, PluginId: cmd.PluginId}
+		if err := bus.Dispatch(query); err != nil {
+			if !errors.Is(err, models.ErrPluginSettingNotFound) {
+				return err
+			}
+		} else {
+			cmd.PluginVersion = query.Result.PluginVersion
+		}
+	}
+
+	if err := pm.storePluginSettings(cmd); err != nil {
+		return err
+	}
+
+	for _, child := range app.Children {
+		query := &models.GetPluginSettingByIdQuery{OrgId: cmd.OrgId, PluginId: child.Id}
+		if err := bus.Dispatch(query); err != nil {
+			if errors.Is(err, models.ErrPluginSettingNotFound) {
+				continue
+			}
+			return err
+		}
+		if query.Result.Enabled == cmd.Enabled {
+			continue
+		}
+
+		if err := pm.storePluginSettings(&models.UpdatePluginSettingCmd{
+			OrgId:         cmd.OrgId,
+			PluginId:      child.Id,
+			Enabled:       cmd.Enabled,
+			Pinned:        query.Result.Pinned,
+			JsonData:      query.Result.JsonData,
+			PluginVersion: query.Result.PluginVersion,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storePluginSettings stores the settings of a plugin and publishes the update.
+func (pm *PluginManager) storePluginSettings(cmd *models.UpdatePluginSettingCmd) error {
+	if err := bus.Dispatch(cmd); err != nil {
+		return err
+	}
+
+	if err := bus.Publish(&events.PluginSettingUpdated{
+		Timestamp: time.Now(),
+		PluginID:  cmd.PluginId,
+		OrgID:     cmd.OrgId,
+		Enabled:   cmd.Enabled,
+	}); err != nil {
+		pm.log.Warn("Failed to publish plugin setting update", "pluginId", cmd.PluginId, "error", err)
+	}
+
+	return nil
+}
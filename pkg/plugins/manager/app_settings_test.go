@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSettings(t *testing.T) {
+	cfg := &setting.Cfg{
+		FeatureToggles: map[string]bool{},
+		PluginSettings: setting.PluginSettings{
+			"test-app": map[string]string{
+				"path": "testdata/test-app",
+			},
+		},
+	}
+	pm := newManager(cfg, &sqlstore.SQLStore{}, &fakeBackendPluginManager{})
+	err := pm.init()
+	require.NoError(t, err)
+
+	stored := map[int64]*models.PluginSetting{}
+	bus.AddHandler("test", func(query *models.GetPluginSettingByIdQuery) error {
+		if ps, exists := stored[query.OrgId]; exists && query.PluginId == ps.PluginId {
+			query.Result = ps
+			return nil
+		}
+		return models.ErrPluginSettingNotFound
+	})
+	bus.AddHandler("test", func(cmd *models.UpdatePluginSettingCmd) error {
+		stored[cmd.OrgId] = &models.PluginSetting{
+			OrgId:         cmd.OrgId,
+			PluginId:      cmd.PluginId,
+			Enabled:       cmd.Enabled,
+			Pinned:        cmd.Pinned,
+			JsonData:      cmd.JsonData,
+			PluginVersion: cmd.PluginVersion,
+		}
+		return nil
+	})
+	var updates []*events.PluginSettingUpdated
+	bus.AddEventListener(func(evt *events.PluginSettingUpdated) error {
+		updates = append(updates, evt)
+		return nil
+	})
+
+	t.Run("Should return the default settings of an app without stored settings", func(t *testing.T) {
+		settings, err := pm.GetAppSettings(1, "test-app")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), settings.OrgId)
+		require.Equal(t, "test-app", settings.PluginId)
+		require.False(t, settings.Enabled)
+		require.Empty(t, settings.JsonData)
+	})
+
+	t.Run("Should update the settings of an app and publish the update", func(t *testing.T) {
+		stored[2] = &models.PluginSetting{OrgId: 2, PluginId: "test-app", PluginVersion: "1.0.0"}
+
+		err := pm.UpdateAppSettings(&models.UpdatePluginSettingCmd{
+			OrgId:    2,
+			PluginId: "test-app",
+			Enabled:  true,
+			Pinned:   true,
+			JsonData: map[string]interface{}{"key": "value"},
+		})
+		require.NoError(t, err)
+
+		settings, err := pm.GetAppSettings(2, "test-app")
+		require.NoError(t, err)
+		require.True(t, settings.Enabled)
+		require.True(t, settings.Pinned)
+		require.Equal(t, map[string]interface{}{"key": "value"}, settings.JsonData)
+		require.Equal(t, "1.0.0", settings.PluginVersion)
+
+		require.Len(t, updates, 1)
+		require.Equal(t, "test-app", updates[0].PluginID)
+		require.Equal(t, int64(2), updates[0].OrgID)
+		require.True(t, updates[0].Enabled)
+	})
+
+	t.Run("Should return not found for plugins that aren't installed apps", func(t *testing.T) {
+		_, err := pm.GetAppSettings(1, "unknown")
+		require.ErrorAs(t, err, &plugins.PluginNotFoundError{})
+
+		err = pm.UpdateAppSettings(&models.UpdatePluginSettingCmd{OrgId: 1, PluginId: "unknown"})
+		require.ErrorAs(t, err, &plugins.PluginNotFoundError{})
+	})
+}
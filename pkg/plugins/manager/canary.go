@@ -0,0 +1,209 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// canariesDirName is the directory in the plugins directory the canary versions of plugins are installed in, each
+// in a directory named after the plugin, along with their dependencies. The plugin scanner skips it.
+const canariesDirName = ".grafana-plugin-canaries"
+
+// canaryBackendPluginManager registers the backends of the plugins loaded with it as canary versions.
+type canaryBackendPluginManager struct {
+	backendplugin.Manager
+	registered bool
+}
+
+func (m *canaryBackendPluginManager) RegisterAndStart(ctx context.Context, pluginID string,
+	factory backendplugin.PluginFactoryFunc) error {
+	if err := m.Manager.RegisterCanary(ctx, pluginID, factory); err != nil {
+		return err
+	}
+	m.registered = true
+	return nil
+}
+
+// canaryDir returns the directory the canary version of a plugin and its dependencies are installed in.
+func (pm *PluginManager) canaryDir(pluginID string) string {
+	return filepath.Join(pm.Cfg.PluginsPath, canariesDirName, pluginID)
+}
+
+// CanaryVersion returns the version of the canary version of a plugin, false if it has none.
+func (pm *PluginManager) CanaryVersion(pluginID string) (string, bool) {
+	pm.canariesMu.Lock()
+	defer pm.canariesMu.Unlock()
+
+	canary, exists := pm.canaries[pluginID]
+	if !exists {
+		return "", false
+	}
+	return canary.Info.Version, true
+}
+
+// InstallCanary installs version of an installed backend plugin side by side with it, replacing its previous canary
+// version, and routes the requests selected by the canary settings of the plugin to it. It waits for other install
+// and uninstall operations of the plugin to complete.
+func (pm *PluginManager) InstallCanary(ctx context.Context, pluginID, version string) error {
+	unlock := pm.installLocks.lock(pluginID)
+	defer unlock()
+
+	plugin := pm.GetPlugin(pluginID)
+	if plugin == nil {
+		return plugins.ErrPluginNotInstalled
+	}
+	if plugin.IsCorePlugin {
+		return plugins.ErrInstallCorePlugin
+	}
+	if !pm.BackendPluginManager.IsRegistered(pluginID) {
+		return plugins.ErrCanaryNotBackend
+	}
+	if plugins.VersionsEqual(plugin.Info.Version, version) {
+		return plugins.DuplicatePluginError{PluginID: pluginID, ExistingPluginDir: plugin.PluginDir}
+	}
+
+	canaryDir := pm.canaryDir(pluginID)
+	if err := os.MkdirAll(canaryDir, 0750); err != nil {
+		return fmt.Errorf("failed to create canary directory of plugin %s: %w", pluginID, err)
+	}
+	if err := pm.pluginInstaller.Install(ctx, pluginID, version, canaryDir, "", grafanaComURL); err != nil {
+		return err
+	}
+
+	return pm.loadCanary(ctx, pluginID)
+}
+
+// RemoveCanary stops and removes the canary version of a plugin, so that the installed version handles all requests
+// again.
+func (pm *PluginManager) RemoveCanary(ctx context.Context, pluginID string) error {
+	unlock := pm.installLocks.lock(pluginID)
+	defer unlock()
+
+	if _, exists := pm.CanaryVersion(pluginID); !exists {
+		return plugins.ErrCanaryNotInstalled
+	}
+	if err := pm.BackendPluginManager.UnregisterCanary(ctx, pluginID); err != nil {
+		return err
+	}
+
+	pm.removeCanary(pluginID)
+	return nil
+}
+
+// PromoteCanary replaces a plugin with the version of its canary version, which is removed, so that the new version
+// handles all requests. The canary version may be lower than the installed version.
+func (pm *PluginManager) PromoteCanary(ctx context.Context, pluginID string) error {
+	version, exists := pm.CanaryVersion(pluginID)
+	if !exists {
+		return plugins.ErrCanaryNotInstalled
+	}
+
+	// upgrading the plugin removes its canary version
+	return pm.Install(ctx, pluginID, version, plugins.InstallOpts{AllowDowngrade: true})
+}
+
+// removeCanary removes the files of the canary version of a plugin, whose backend is no longer registered.
+func (pm *PluginManager) removeCanary(pluginID string) {
+	pm.canariesMu.Lock()
+	delete(pm.canaries, pluginID)
+	pm.canariesMu.Unlock()
+
+	if err := os.RemoveAll(pm.canaryDir(pluginID)); err != nil {
+		pm.log.Warn("Failed to remove canary version of plugin", "pluginId", pluginID, "error", err)
+	}
+}
+
+// loadCanaries loads the canary versions of the installed plugins, which were installed before Grafana started.
+func (pm *PluginManager) loadCanaries() {
+	entries, err := ioutil.ReadDir(filepath.Join(pm.Cfg.PluginsPath, canariesDirName))
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		pluginID := entry.Name()
+		if pm.GetPlugin(pluginID) == nil {
+			pm.log.Warn("Skipping canary version of plugin that isn't installed", "pluginId", pluginID)
+			continue
+		}
+		if err := pm.loadCanary(context.Background(), pluginID); err != nil {
+			pm.log.Error("Failed to load canary version of plugin", "pluginId", pluginID, "error", err)
+		}
+	}
+}
+
+// loadCanary validates the signature of the canary version of a plugin and registers its backend.
+func (pm *PluginManager) loadCanary(ctx context.Context, pluginID string) error {
+	canaryDir := pm.canaryDir(pluginID)
+	scanner := pm.newScanner(canaryDir, true)
+	if err := util.Walk(canaryDir, true, true, scanner.walker); err != nil {
+		return fmt.Errorf("failed to scan canary version of plugin %s: %w", pluginID, err)
+	}
+
+	for dpath, canary := range scanner.plugins {
+		if canary.Id != pluginID {
+			continue
+		}
+
+		canary.Root = scanner.findRoot(dpath)
+		if signingError := scanner.validateSignature(canary); signingError != nil {
+			return fmt.Errorf("canary version of plugin %s has an invalid signature: %s", pluginID,
+				signingError.ErrorCode)
+		}
+
+		var loader plugins.PluginLoader
+		switch canary.Type {
+		case "datasource":
+			loader = &plugins.DataSourcePlugin{}
+		case "app":
+			loader = &plugins.AppPlugin{}
+		default:
+			return plugins.ErrCanaryNotBackend
+		}
+
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because the path is based on plugin the folder
+		// structure on disk and not user input.
+		reader, err := os.Open(filepath.Join(canary.PluginDir, "plugin.json"))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := reader.Close(); err != nil {
+				pm.log.Warn("Failed to close JSON file", "pluginId", pluginID, "err", err)
+			}
+		}()
+
+		backendPM := &canaryBackendPluginManager{Manager: pm.BackendPluginManager}
+		if _, err := loader.Load(json.NewDecoder(reader), canary, backendPM); err != nil {
+			return err
+		}
+		if !backendPM.registered {
+			return plugins.ErrCanaryNotBackend
+		}
+
+		pm.canariesMu.Lock()
+		if pm.canaries == nil {
+			pm.canaries = map[string]*plugins.PluginBase{}
+		}
+		pm.canaries[pluginID] = canary
+		pm.canariesMu.Unlock()
+
+		pm.log.Info("Loaded canary version of plugin", "pluginId", pluginID, "version", canary.Info.Version)
+		return nil
+	}
+
+	return fmt.Errorf("canary version of plugin %s not found in %s", pluginID, canaryDir)
+}
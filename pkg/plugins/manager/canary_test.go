@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginManager_Canary(t *testing.T) {
+	writePlugin := func(t *testing.T, dir, version string) {
+		pluginJSON, err := ioutil.ReadFile("testdata/installer/plugin/plugin.json")
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "test"), 0750))
+		pluginJSON = []byte(strings.Replace(string(pluginJSON), `"version": "1.0.0"`,
+			`"version": "`+version+`"`, 1))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test", "plugin.json"), pluginJSON, 0600))
+	}
+	newCanaryManager := func(t *testing.T, pluginsDir string) (*PluginManager, *fakeBackendPluginManager) {
+		fm := &fakeBackendPluginManager{}
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.BackendPluginManager = fm
+			pm.Cfg.PluginsPath = pluginsDir
+			pm.Cfg.PluginsAllowUnsigned = []string{"test"}
+		})
+		require.NoError(t, pm.init())
+		pm.pluginInstaller = &fakePluginInstaller{}
+		return pm, fm
+	}
+
+	t.Run("Loads canary versions installed before startup", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writePlugin(t, pluginsDir, "1.0.0")
+		writePlugin(t, filepath.Join(pluginsDir, canariesDirName, "test"), "2.0.0")
+
+		pm, fm := newCanaryManager(t, pluginsDir)
+
+		// the canary version isn't loaded as a regular plugin
+		require.NotNil(t, pm.GetPlugin("test"))
+		assert.Equal(t, "1.0.0", pm.GetPlugin("test").Info.Version)
+		assert.Equal(t, []string{"test"}, fm.registeredPlugins)
+
+		version, exists := pm.CanaryVersion("test")
+		require.True(t, exists)
+		assert.Equal(t, "2.0.0", version)
+		assert.Equal(t, []string{"test"}, fm.canaries)
+
+		t.Run("Removes the canary version", func(t *testing.T) {
+			require.NoError(t, pm.RemoveCanary(context.Background(), "test"))
+
+			_, exists := pm.CanaryVersion("test")
+			assert.False(t, exists)
+			assert.Empty(t, fm.canaries)
+			assert.NoDirExists(t, filepath.Join(pluginsDir, canariesDirName, "test"))
+
+			err := pm.RemoveCanary(context.Background(), "test")
+			assert.Equal(t, plugins.ErrCanaryNotInstalled, err)
+		})
+	})
+
+	t.Run("Removes the canary version when the plugin is uninstalled", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writePlugin(t, pluginsDir, "1.0.0")
+		writePlugin(t, filepath.Join(pluginsDir, canariesDirName, "test"), "2.0.0")
+
+		pm, _ := newCanaryManager(t, pluginsDir)
+		require.NoError(t, pm.Uninstall(context.Background(), "test"))
+
+		_, exists := pm.CanaryVersion("test")
+		assert.False(t, exists)
+		assert.NoDirExists(t, filepath.Join(pluginsDir, canariesDirName, "test"))
+	})
+
+	t.Run("Won't install canary versions of plugins that aren't installed", func(t *testing.T) {
+		pm, _ := newCanaryManager(t, t.TempDir())
+
+		err := pm.InstallCanary(context.Background(), "test", "2.0.0")
+		assert.Equal(t, plugins.ErrPluginNotInstalled, err)
+		assert.Equal(t, 0, pm.pluginInstaller.(*fakePluginInstaller).installCount)
+	})
+
+	t.Run("Won't install the installed version as canary version", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writePlugin(t, pluginsDir, "1.0.0")
+		pm, _ := newCanaryManager(t, pluginsDir)
+
+		err := pm.InstallCanary(context.Background(), "test", "1.0.0")
+		assert.IsType(t, plugins.DuplicatePluginError{}, err)
+		assert.Equal(t, 0, pm.pluginInstaller.(*fakePluginInstaller).installCount)
+	})
+
+	t.Run("Won't install canary versions of plugins without backend", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writePlugin(t, pluginsDir, "1.0.0")
+		pm, fm := newCanaryManager(t, pluginsDir)
+		fm.registeredPlugins = nil
+
+		err := pm.InstallCanary(context.Background(), "test", "2.0.0")
+		assert.Equal(t, plugins.ErrCanaryNotBackend, err)
+	})
+
+	t.Run("Won't promote plugins without canary version", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writePlugin(t, pluginsDir, "1.0.0")
+		pm, _ := newCanaryManager(t, pluginsDir)
+
+		err := pm.PromoteCanary(context.Background(), "test")
+		assert.Equal(t, plugins.ErrCanaryNotInstalled, err)
+	})
+}
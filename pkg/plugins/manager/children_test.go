@@ -0,0 +1,132 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginManager_ChildPlugins(t *testing.T) {
+	writePlugin := func(t *testing.T, dir, typ, id string) {
+		require.NoError(t, os.MkdirAll(dir, 0750))
+		pluginJSON := fmt.Sprintf(`{"type": %q, "id": %q, "name": %q, "info": {"version": "1.0.0"}}`, typ, id, id)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugin.json"), []byte(pluginJSON), 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "module.js"), []byte(reactModule), 0600))
+	}
+	newChildrenManager := func(t *testing.T) *PluginManager {
+		pluginsDir := t.TempDir()
+		appDir := filepath.Join(pluginsDir, "test-app")
+		writePlugin(t, appDir, "app", "test-app")
+		writePlugin(t, filepath.Join(appDir, "panels", "child-panel"), "panel", "child-panel")
+		writePlugin(t, filepath.Join(appDir, "datasources", "child-datasource"), "datasource", "child-datasource")
+		writePlugin(t, filepath.Join(pluginsDir, "other-panel"), "panel", "other-panel")
+
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.BackendPluginManager = &fakeBackendPluginManager{}
+			pm.Cfg.PluginsPath = pluginsDir
+			pm.Cfg.PluginsAllowUnsigned = []string{"test-app", "child-panel", "child-datasource", "other-panel"}
+		})
+		require.NoError(t, pm.init())
+		pm.pluginInstaller = &fakePluginInstaller{}
+		return pm
+	}
+	childIDs := func(plugin *plugins.PluginBase) []string {
+		ids := []string{}
+		for _, child := range plugin.Children {
+			ids = append(ids, child.Id)
+		}
+		return ids
+	}
+
+	t.Run("Links the plugins bundled by an app to the app", func(t *testing.T) {
+		pm := newChildrenManager(t)
+
+		app := pm.GetPlugin("test-app")
+		require.NotNil(t, app)
+		assert.Nil(t, app.Parent)
+		assert.ElementsMatch(t, []string{"child-panel", "child-datasource"}, childIDs(app))
+		for _, id := range []string{"child-panel", "child-datasource"} {
+			child := pm.GetPlugin(id)
+			require.NotNil(t, child, id)
+			assert.Same(t, app, child.Parent, id)
+		}
+
+		other := pm.GetPlugin("other-panel")
+		require.NotNil(t, other)
+		assert.Nil(t, other.Parent)
+
+		t.Run("Keeps the links when rescanning the plugins directory", func(t *testing.T) {
+			require.NoError(t, pm.initExternalPlugins())
+
+			app := pm.GetPlugin("test-app")
+			assert.ElementsMatch(t, []string{"child-panel", "child-datasource"}, childIDs(app))
+			assert.Same(t, app, pm.GetPlugin("child-panel").Parent)
+		})
+	})
+
+	t.Run("Unloads the plugins bundled by an app along with the app", func(t *testing.T) {
+		pm := newChildrenManager(t)
+
+		require.NoError(t, pm.Uninstall(context.Background(), "test-app"))
+		assert.Nil(t, pm.GetPlugin("test-app"))
+		assert.Nil(t, pm.GetPlugin("child-panel"))
+		assert.Nil(t, pm.GetPlugin("child-datasource"))
+		assert.NotNil(t, pm.GetPlugin("other-panel"))
+	})
+
+	t.Run("Cascades enabling an app to the stored settings of its children", func(t *testing.T) {
+		pm := newChildrenManager(t)
+
+		stored := map[string]*models.PluginSetting{
+			"child-panel": {OrgId: 1, PluginId: "child-panel", Pinned: true, PluginVersion: "1.0.0"},
+		}
+		bus.AddHandler("test", func(query *models.GetPluginSettingByIdQuery) error {
+			if ps, exists := stored[query.PluginId]; exists && query.OrgId == ps.OrgId {
+				query.Result = ps
+				return nil
+			}
+			return models.ErrPluginSettingNotFound
+		})
+		bus.AddHandler("test", func(cmd *models.UpdatePluginSettingCmd) error {
+			stored[cmd.PluginId] = &models.PluginSetting{
+				OrgId:         cmd.OrgId,
+				PluginId:      cmd.PluginId,
+				Enabled:       cmd.Enabled,
+				Pinned:        cmd.Pinned,
+				JsonData:      cmd.JsonData,
+				PluginVersion: cmd.PluginVersion,
+			}
+			return nil
+		})
+
+		require.NoError(t, pm.UpdateAppSettings(&models.UpdatePluginSettingCmd{
+			OrgId:    1,
+			PluginId: "test-app",
+			Enabled:  true,
+		}))
+		require.Contains(t, stored, "test-app")
+		assert.True(t, stored["test-app"].Enabled)
+		require.Contains(t, stored, "child-panel")
+		assert.True(t, stored["child-panel"].Enabled)
+		assert.True(t, stored["child-panel"].Pinned)
+		assert.Equal(t, "1.0.0", stored["child-panel"].PluginVersion)
+		// children without stored settings follow their app without settings of their own
+		assert.NotContains(t, stored, "child-datasource")
+
+		require.NoError(t, pm.UpdateAppSettings(&models.UpdatePluginSettingCmd{
+			OrgId:    1,
+			PluginId: "test-app",
+			Enabled:  false,
+		}))
+		assert.False(t, stored["child-panel"].Enabled)
+	})
+}
@@ -0,0 +1,240 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// versionConstraint parses a version constraint of plugin.json, nil if there is none. A bare version, whose parts
+// may be wildcards, is the minimum version, as most plugins set it to the version they were written for.
+func versionConstraint(constraint string) (*semver.Constraints, error) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" || constraint == "*" {
+		return nil, nil
+	}
+
+	if !strings.ContainsAny(constraint, " <>=!~^,|") {
+		minimum := strings.NewReplacer("x", "0", "X", "0", "*", "0").Replace(constraint)
+		if _, err := semver.NewVersion(minimum); err == nil {
+			constraint = ">=" + minimum
+		}
+	}
+
+	return semver.NewConstraint(constraint)
+}
+
+// grafanaVersion returns the version of Grafana plugin dependencies are checked against, without its pre-release
+// part, so that pre-releases satisfy the constraints of their release. It returns nil for development builds.
+func (pm *PluginManager) grafanaVersion() *semver.Version {
+	version, err := semver.NewVersion(pm.Cfg.BuildVersion)
+	if err != nil {
+		return nil
+	}
+
+	release, err := version.SetPrerelease("")
+	if err != nil {
+		return nil
+	}
+	return &release
+}
+
+// dependencyNode returns the node of a plugin in the dependency graph. The dependencies of core plugins are always
+// satisfied, as they ship with Grafana, and so are those on plugins whose version can't be parsed.
+func dependencyNode(plugin *plugins.PluginBase, grafanaVersion *semver.Version,
+	available map[string]*plugins.PluginBase) (plugins.PluginDependencyNode, []string) {
+	node := plugins.PluginDependencyNode{
+		ID:                      plugin.Id,
+		Type:                    plugin.Type,
+		Version:                 plugin.Info.Version,
+		GrafanaVersion:          plugin.Dependencies.GrafanaVersion,
+		GrafanaVersionSatisfied: true,
+		Dependencies:            []plugins.PluginDependencyEdge{},
+		Dependents:              []string{},
+	}
+
+	var problems []string
+	if !plugin.IsCorePlugin && grafanaVersion != nil {
+		constraint, err := versionConstraint(plugin.Dependencies.GrafanaVersion)
+		if err != nil {
+			node.GrafanaVersionSatisfied = false
+			problems = append(problems, fmt.Sprintf("invalid Grafana version constraint %q: %s",
+				plugin.Dependencies.GrafanaVersion, err))
+		} else if constraint != nil && !constraint.Check(grafanaVersion) {
+			node.GrafanaVersionSatisfied = false
+			problems = append(problems, fmt.Sprintf("requires Grafana %s, but this is Grafana %s",
+				plugin.Dependencies.GrafanaVersion, grafanaVersion))
+		}
+	}
+
+	for _, dependency := range plugin.Dependencies.Plugins {
+		edge := plugins.PluginDependencyEdge{
+			ID:        dependency.Id,
+			Type:      dependency.Type,
+			Version:   dependency.Version,
+			Satisfied: true,
+		}
+
+		installed, exists := available[dependency.Id]
+		switch {
+		case !exists:
+			edge.Satisfied = false
+			problems = append(problems, fmt.Sprintf("requires plugin %s %s, which isn't installed",
+				dependency.Id, dependency.Version))
+		case installed.IsCorePlugin || plugin.IsCorePlugin:
+			edge.Installed = true
+			edge.InstalledVersion = installed.Info.Version
+		default:
+			edge.Installed = true
+			edge.InstalledVersion = installed.Info.Version
+
+			constraint, err := versionConstraint(dependency.Version)
+			if err != nil {
+				edge.Satisfied = false
+				problems = append(problems, fmt.Sprintf("invalid version constraint %q of plugin %s: %s",
+					dependency.Version, dependency.Id, err))
+				break
+			}
+			if version, err := semver.NewVersion(installed.Info.Version); err == nil && constraint != nil &&
+				!constraint.Check(version) {
+				edge.Satisfied = false
+				problems = append(problems, fmt.Sprintf("requires plugin %s %s, but %s is installed",
+					dependency.Id, dependency.Version, installed.Info.Version))
+			}
+		}
+
+		node.Dependencies = append(node.Dependencies, edge)
+	}
+
+	node.Satisfied = len(problems) == 0
+	return node, problems
+}
+
+// validateDependencies checks the dependencies of the loaded plugins and rebuilds the dependency graph. Plugins
+// whose dependencies aren't satisfied are flagged with a load error, and refused if dependencies are enforced,
+// which may in turn leave the dependencies of other plugins unsatisfied. Refused plugins are loaded again by the
+// next scan, so that installing a missing dependency resolves them.
+func (pm *PluginManager) validateDependencies(ctx context.Context) {
+	pm.dependenciesMu.Lock()
+	defer pm.dependenciesMu.Unlock()
+
+	if pm.refusedPlugins == nil {
+		pm.refusedPlugins = map[string]*plugins.PluginBase{}
+	}
+
+	grafanaVersion := pm.grafanaVersion()
+	available := map[string]*plugins.PluginBase{}
+	for _, p := range pm.Plugins() {
+		available[p.Id] = p
+		delete(pm.refusedPlugins, p.Id)
+	}
+
+	nodes := map[string]plugins.PluginDependencyNode{}
+	problems := map[string][]string{}
+	for {
+		refused := false
+		for id, p := range available {
+			node, pluginProblems := dependencyNode(p, grafanaVersion, available)
+			nodes[id] = node
+			if len(pluginProblems) == 0 {
+				delete(problems, id)
+				continue
+			}
+
+			problems[id] = pluginProblems
+			if pm.Cfg.PluginsEnforceDependencies {
+				pm.refusedPlugins[id] = p
+				delete(available, id)
+				refused = true
+			}
+		}
+		if !refused {
+			break
+		}
+	}
+
+	for id, p := range pm.refusedPlugins {
+		if pm.GetPlugin(id) != nil {
+			pm.log.Warn("Refusing plugin whose dependencies aren't satisfied", "pluginId", id,
+				"problems", strings.Join(problems[id], "; "))
+			if err := pm.unload(ctx, p); err != nil {
+				pm.log.Error("Failed to unload plugin whose dependencies aren't satisfied", "pluginId", id,
+					"error", err)
+			}
+		}
+	}
+
+	// plugins refused by earlier validations stay in the graph, as they're only loaded again by the scan of their
+	// plugin directory
+	for id, p := range pm.refusedPlugins {
+		if _, exists := nodes[id]; !exists {
+			nodes[id], problems[id] = dependencyNode(p, grafanaVersion, available)
+		}
+	}
+
+	pm.pluginErrorsMu.Lock()
+	for dir, e := range pm.pluginLoadErrors {
+		if e.ErrorCode == dependencyUnsatisfied {
+			delete(pm.pluginLoadErrors, dir)
+		}
+	}
+	for id, pluginProblems := range problems {
+		if len(pluginProblems) == 0 {
+			continue
+		}
+
+		p := pm.refusedPlugins[id]
+		if p == nil {
+			p = available[id]
+			pm.log.Warn("Plugin dependencies aren't satisfied", "pluginId", id,
+				"problems", strings.Join(pluginProblems, "; "))
+		}
+		pm.pluginLoadErrors[p.PluginDir] = plugins.PluginError{
+			ErrorCode: dependencyUnsatisfied,
+			PluginID:  id,
+			Path:      p.PluginDir,
+			Message:   strings.Join(pluginProblems, "; "),
+		}
+	}
+	pm.pluginErrorsMu.Unlock()
+
+	graph := plugins.PluginDependencyGraph{
+		Enforced: pm.Cfg.PluginsEnforceDependencies,
+		Plugins:  make([]plugins.PluginDependencyNode, 0, len(nodes)),
+	}
+	if grafanaVersion != nil {
+		graph.GrafanaVersion = grafanaVersion.String()
+	}
+	dependents := map[string][]string{}
+	for id, node := range nodes {
+		for _, dependency := range node.Dependencies {
+			dependents[dependency.ID] = append(dependents[dependency.ID], id)
+		}
+	}
+	for id, node := range nodes {
+		_, node.Loaded = available[id]
+		if ids, exists := dependents[id]; exists {
+			sort.Strings(ids)
+			node.Dependents = ids
+		}
+		graph.Plugins = append(graph.Plugins, node)
+	}
+	sort.Slice(graph.Plugins, func(i, j int) bool {
+		return graph.Plugins[i].ID < graph.Plugins[j].ID
+	})
+	pm.dependencyGraph = graph
+}
+
+// DependencyGraph returns the dependency graph of the loaded plugins and of the plugins refused because their
+// dependencies aren't satisfied, sorted by plugin ID.
+func (pm *PluginManager) DependencyGraph() plugins.PluginDependencyGraph {
+	pm.dependenciesMu.Lock()
+	defer pm.dependenciesMu.Unlock()
+
+	return pm.dependencyGraph
+}
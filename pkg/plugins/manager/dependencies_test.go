@@ -0,0 +1,158 @@
+package manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionConstraint(t *testing.T) {
+	tcs := []struct {
+		constraint string
+		version    string
+		satisfied  bool
+	}{
+		{constraint: "", version: "1.0.0", satisfied: true},
+		{constraint: "*", version: "1.0.0", satisfied: true},
+		{constraint: "7.x.x", version: "8.1.0", satisfied: true},
+		{constraint: "7.x.x", version: "6.7.0", satisfied: false},
+		{constraint: "1.0.0", version: "1.2.0", satisfied: true},
+		{constraint: "1.2.0", version: "1.0.0", satisfied: false},
+		{constraint: ">=8.0.0", version: "8.1.0", satisfied: true},
+		{constraint: ">=8.0.0, <9.0.0", version: "9.0.0", satisfied: false},
+		{constraint: "^1.2.0", version: "2.0.0", satisfied: false},
+		{constraint: "~1.2.0", version: "1.2.5", satisfied: true},
+	}
+	for _, tc := range tcs {
+		t.Run(fmt.Sprintf("%q with %s", tc.constraint, tc.version), func(t *testing.T) {
+			constraint, err := versionConstraint(tc.constraint)
+			require.NoError(t, err)
+
+			satisfied := constraint == nil || constraint.Check(semver.MustParse(tc.version))
+			assert.Equal(t, tc.satisfied, satisfied)
+		})
+	}
+
+	_, err := versionConstraint(">=banana")
+	assert.Error(t, err)
+}
+
+func TestPluginManager_Dependencies(t *testing.T) {
+	writePanel := func(t *testing.T, pluginsDir, id, version, dependencies string) {
+		dir := filepath.Join(pluginsDir, id)
+		require.NoError(t, os.MkdirAll(dir, 0750))
+		pluginJSON := fmt.Sprintf(`{"type": "panel", "id": %q, "name": %q, "info": {"version": %q},
+			"dependencies": %s}`, id, id, version, dependencies)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugin.json"), []byte(pluginJSON), 0600))
+	}
+	newDependenciesManager := func(t *testing.T, pluginsDir string, enforce bool) *PluginManager {
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.BackendPluginManager = &fakeBackendPluginManager{}
+			pm.Cfg.PluginsPath = pluginsDir
+			pm.Cfg.PluginsAllowUnsigned = []string{"base", "extension", "addon", "modern"}
+			pm.Cfg.PluginsEnforceDependencies = enforce
+			pm.Cfg.BuildVersion = "8.1.0-beta1"
+		})
+		require.NoError(t, pm.init())
+		return pm
+	}
+	graphNode := func(t *testing.T, pm *PluginManager, id string) plugins.PluginDependencyNode {
+		for _, node := range pm.DependencyGraph().Plugins {
+			if node.ID == id {
+				return node
+			}
+		}
+		require.FailNow(t, "plugin not found in dependency graph", id)
+		return plugins.PluginDependencyNode{}
+	}
+	dependencyErrors := func(pm *PluginManager) map[string]string {
+		errs := map[string]string{}
+		for _, e := range pm.ScanningErrors() {
+			if e.ErrorCode == dependencyUnsatisfied {
+				errs[e.PluginID] = e.Message
+			}
+		}
+		return errs
+	}
+
+	t.Run("Builds the dependency graph", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writePanel(t, pluginsDir, "base", "1.2.0", `{"grafanaVersion": "7.x.x"}`)
+		writePanel(t, pluginsDir, "extension", "1.0.0",
+			`{"grafanaVersion": ">=8.0.0", "plugins": [{"type": "panel", "id": "base", "version": "^1.0.0"}]}`)
+		pm := newDependenciesManager(t, pluginsDir, false)
+
+		graph := pm.DependencyGraph()
+		assert.Equal(t, "8.1.0", graph.GrafanaVersion)
+		assert.False(t, graph.Enforced)
+
+		base := graphNode(t, pm, "base")
+		assert.True(t, base.Satisfied)
+		assert.True(t, base.Loaded)
+		assert.Equal(t, []string{"extension"}, base.Dependents)
+
+		extension := graphNode(t, pm, "extension")
+		assert.True(t, extension.Satisfied)
+		assert.True(t, extension.GrafanaVersionSatisfied)
+		assert.Equal(t, []plugins.PluginDependencyEdge{
+			{ID: "base", Type: "panel", Version: "^1.0.0", Installed: true, InstalledVersion: "1.2.0", Satisfied: true},
+		}, extension.Dependencies)
+		assert.Empty(t, dependencyErrors(pm))
+	})
+
+	t.Run("Flags plugins whose dependencies aren't satisfied", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writePanel(t, pluginsDir, "base", "1.2.0", `{}`)
+		writePanel(t, pluginsDir, "extension", "1.0.0",
+			`{"plugins": [{"type": "panel", "id": "base", "version": ">=2.0.0"}]}`)
+		writePanel(t, pluginsDir, "modern", "1.0.0", `{"grafanaVersion": ">=9.0.0"}`)
+		pm := newDependenciesManager(t, pluginsDir, false)
+
+		assert.NotNil(t, pm.GetPlugin("extension"))
+		assert.NotNil(t, pm.GetPlugin("modern"))
+		assert.False(t, graphNode(t, pm, "extension").Satisfied)
+		assert.True(t, graphNode(t, pm, "extension").Loaded)
+		assert.False(t, graphNode(t, pm, "modern").GrafanaVersionSatisfied)
+		assert.Equal(t, map[string]string{
+			"extension": "requires plugin base >=2.0.0, but 1.2.0 is installed",
+			"modern":    "requires Grafana >=9.0.0, but this is Grafana 8.1.0",
+		}, dependencyErrors(pm))
+	})
+
+	t.Run("Refuses plugins whose dependencies aren't satisfied if enforced", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writePanel(t, pluginsDir, "extension", "1.0.0",
+			`{"plugins": [{"type": "panel", "id": "base", "version": "1.0.0"}]}`)
+		writePanel(t, pluginsDir, "addon", "1.0.0",
+			`{"plugins": [{"type": "panel", "id": "extension", "version": "1.0.0"}]}`)
+		pm := newDependenciesManager(t, pluginsDir, true)
+
+		// the addon loses its dependency when the extension is refused
+		assert.Nil(t, pm.GetPlugin("extension"))
+		assert.Nil(t, pm.GetPlugin("addon"))
+		assert.False(t, graphNode(t, pm, "extension").Loaded)
+		assert.False(t, graphNode(t, pm, "addon").Loaded)
+		assert.Equal(t, map[string]string{
+			"extension": "requires plugin base 1.0.0, which isn't installed",
+			"addon":     "requires plugin extension 1.0.0, which isn't installed",
+		}, dependencyErrors(pm))
+
+		t.Run("Loads refused plugins when their dependencies are installed", func(t *testing.T) {
+			writePanel(t, pluginsDir, "base", "1.0.0", `{}`)
+			require.NoError(t, pm.initExternalPlugins())
+
+			assert.NotNil(t, pm.GetPlugin("extension"))
+			assert.NotNil(t, pm.GetPlugin("addon"))
+			assert.True(t, graphNode(t, pm, "addon").Loaded)
+			assert.True(t, graphNode(t, pm, "addon").Satisfied)
+			assert.Empty(t, dependencyErrors(pm))
+		})
+	})
+}
@@ -0,0 +1,17 @@
+package manager
+
+import (
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// warnIfDeprecated logs a warning if the plugin is deprecated, so admins learn about it before the plugin stops
+// working.
+func (pm *PluginManager) warnIfDeprecated(plugin *plugins.PluginBase) {
+	deprecation := plugin.DeprecationInfo()
+	if deprecation == nil {
+		return
+	}
+
+	pm.log.Warn("Plugin is deprecated", "pluginId", plugin.Id, "reason", deprecation.Reason,
+		"replacedBy", deprecation.ReplacedBy)
+}
@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginManager_Deprecation(t *testing.T) {
+	pluginsDir := t.TempDir()
+	writePanel := func(t *testing.T, id, deprecation string) {
+		dir := filepath.Join(pluginsDir, id)
+		require.NoError(t, os.MkdirAll(dir, 0750))
+		pluginJSON := fmt.Sprintf(`{"type": "panel", "id": %q, "name": %q, "info": {"version": "1.0.0"}%s}`,
+			id, id, deprecation)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugin.json"), []byte(pluginJSON), 0600))
+	}
+	writePanel(t, "deprecated-panel", `, "deprecation": {"reason": "Unmaintained", "replacedBy": "new-panel"}`)
+	writePanel(t, "other-panel", "")
+
+	pm := createManager(t, func(pm *PluginManager) {
+		pm.BackendPluginManager = &fakeBackendPluginManager{}
+		pm.Cfg.PluginsPath = pluginsDir
+		pm.Cfg.PluginsAllowUnsigned = []string{"deprecated-panel", "other-panel"}
+	})
+	require.NoError(t, pm.init())
+
+	t.Run("Reads the deprecation of plugins from their metadata", func(t *testing.T) {
+		deprecated := pm.GetPlugin("deprecated-panel")
+		require.NotNil(t, deprecated)
+		assert.Equal(t, &plugins.PluginDeprecation{Reason: "Unmaintained", ReplacedBy: "new-panel"},
+			deprecated.DeprecationInfo())
+
+		other := pm.GetPlugin("other-panel")
+		require.NotNil(t, other)
+		assert.Nil(t, other.DeprecationInfo())
+	})
+
+	t.Run("Reads the deprecation of plugins from the catalog", func(t *testing.T) {
+		pm.updateGrafanaNetInfo([]grafanaNetPlugin{
+			{Slug: "deprecated-panel", Version: "1.0.0", Deprecation: &plugins.PluginDeprecation{Reason: "Abandoned"}},
+			{Slug: "other-panel", Version: "1.1.0", Deprecation: &plugins.PluginDeprecation{Reason: "Abandoned"}},
+		})
+
+		// the metadata of a plugin takes precedence over the catalog
+		assert.Equal(t, "Unmaintained", pm.GetPlugin("deprecated-panel").DeprecationInfo().Reason)
+		other := pm.GetPlugin("other-panel")
+		assert.Equal(t, &plugins.PluginDeprecation{Reason: "Abandoned"}, other.DeprecationInfo())
+		assert.True(t, other.GrafanaNetHasUpdate)
+
+		t.Run("Clears the deprecation once the catalog no longer reports it", func(t *testing.T) {
+			pm.updateGrafanaNetInfo([]grafanaNetPlugin{{Slug: "other-panel", Version: "1.1.0"}})
+
+			assert.Nil(t, pm.GetPlugin("other-panel").DeprecationInfo())
+		})
+	})
+}
@@ -5,7 +5,25 @@ import (
 )
 
 const (
-	signatureMissing  plugins.ErrorCode = "signatureMissing"
-	signatureModified plugins.ErrorCode = "signatureModified"
-	signatureInvalid  plugins.ErrorCode = "signatureInvalid"
+	signatureMissing      plugins.ErrorCode = "signatureMissing"
+	signatureModified     plugins.ErrorCode = "signatureModified"
+	signatureInvalid      plugins.ErrorCode = "signatureInvalid"
+	invalidPluginJSON     plugins.ErrorCode = "invalidPluginJson"
+	missingExecutable     plugins.ErrorCode = "missingExecutable"
+	dependencyUnsatisfied plugins.ErrorCode = "dependencyUnsatisfied"
+	angularBlocked        plugins.ErrorCode = "angularBlocked"
 )
+
+// pluginLoadError is an error preventing a plugin found by a scan from being loaded.
+type pluginLoadError struct {
+	code plugins.ErrorCode
+	err  error
+}
+
+func (e pluginLoadError) Error() string {
+	return e.err.Error()
+}
+
+func (e pluginLoadError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package manager
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive advisory lock of the file at path, creating it if it doesn't exist, and waits until
+// the lock is released by other processes. It returns a function releasing the lock.
+func lockFile(path string) (func(), error) {
+	// nolint:gosec
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
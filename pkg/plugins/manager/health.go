@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// PluginsHealth returns an aggregated health summary of the backend plugin processes.
+func (pm *PluginManager) PluginsHealth() plugins.PluginsHealth {
+	health := plugins.PluginsHealth{Plugins: []plugins.PluginHealth{}}
+	for _, state := range pm.BackendPluginManager.PluginStates() {
+		switch state.Status {
+		case backendplugin.PluginStatusNotStarted:
+			health.NotStarted++
+		case backendplugin.PluginStatusRunning:
+			health.Running++
+		case backendplugin.PluginStatusDegraded:
+			health.Degraded++
+		case backendplugin.PluginStatusCrashLooping:
+			health.CrashLooping++
+		case backendplugin.PluginStatusDecommissioned:
+			health.Decommissioned++
+		case backendplugin.PluginStatusFailed:
+			health.Failed++
+		}
+
+		pluginHealth := plugins.PluginHealth{
+			ID:       state.PluginID,
+			Status:   state.Status,
+			Managed:  state.Managed,
+			Restarts: state.Restarts,
+		}
+		if !state.LastRestart.IsZero() {
+			lastRestart := state.LastRestart
+			pluginHealth.LastRestart = &lastRestart
+		}
+		health.Plugins = append(health.Plugins, pluginHealth)
+	}
+
+	return health
+}
+
+// PluginStates returns all registered plugins with the runtime state of their backend process, sorted by ID.
+func (pm *PluginManager) PluginStates() []plugins.PluginRuntimeState {
+	processes := map[string]backendplugin.PluginState{}
+	for _, state := range pm.BackendPluginManager.PluginStates() {
+		processes[state.PluginID] = state
+	}
+
+	now := time.Now()
+	states := []plugins.PluginRuntimeState{}
+	for _, p := range pm.Plugins() {
+		state := plugins.PluginRuntimeState{
+			ID:        p.Id,
+			Name:      p.Name,
+			Type:      p.Type,
+			Version:   p.Info.Version,
+			Signature: p.Signature,
+			Backend:   p.Backend,
+		}
+
+		if process, exists := processes[p.Id]; exists {
+			state.Process = &plugins.PluginProcessState{
+				Status:    process.Status,
+				Managed:   process.Managed,
+				LastError: process.LastError,
+				Restarts:  process.Restarts,
+			}
+			if !process.LastRestart.IsZero() {
+				lastRestart := process.LastRestart
+				state.Process.LastRestart = &lastRestart
+			}
+			if !process.StartedAt.IsZero() {
+				startedAt := process.StartedAt
+				state.Process.StartedAt = &startedAt
+				if process.Status == backendplugin.PluginStatusRunning {
+					state.Process.UptimeSeconds = int64(now.Sub(startedAt).Seconds())
+				}
+			}
+		}
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].ID < states[j].ID
+	})
+
+	return states
+}
@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginManager_PluginsHealth(t *testing.T) {
+	lastRestart := time.Now()
+	pm := &PluginManager{
+		BackendPluginManager: &fakeBackendPluginManager{
+			pluginStates: []backendplugin.PluginState{
+				{PluginID: "a", Status: backendplugin.PluginStatusRunning, Managed: true},
+				{PluginID: "b", Status: backendplugin.PluginStatusCrashLooping, Managed: true, Restarts: 3, LastRestart: lastRestart},
+				{PluginID: "c", Status: backendplugin.PluginStatusDegraded},
+				{PluginID: "d", Status: backendplugin.PluginStatusFailed, Managed: true},
+				{PluginID: "e", Status: backendplugin.PluginStatusNotStarted, LastError: "failed"},
+			},
+		},
+	}
+
+	health := pm.PluginsHealth()
+	require.False(t, health.Healthy())
+	require.Equal(t, 1, health.Running)
+	require.Equal(t, 1, health.CrashLooping)
+	require.Equal(t, 1, health.Degraded)
+	require.Equal(t, 1, health.NotStarted)
+	require.Equal(t, 0, health.Decommissioned)
+	require.Equal(t, 1, health.Failed)
+	require.Equal(t, []plugins.PluginHealth{
+		{ID: "a", Status: backendplugin.PluginStatusRunning, Managed: true},
+		{ID: "b", Status: backendplugin.PluginStatusCrashLooping, Managed: true, Restarts: 3, LastRestart: &lastRestart},
+		{ID: "c", Status: backendplugin.PluginStatusDegraded},
+		{ID: "d", Status: backendplugin.PluginStatusFailed, Managed: true},
+		{ID: "e", Status: backendplugin.PluginStatusNotStarted},
+	}, health.Plugins)
+}
+
+func TestPluginManager_PluginStates(t *testing.T) {
+	startedAt := time.Now().Add(-time.Hour)
+	pm := &PluginManager{
+		BackendPluginManager: &fakeBackendPluginManager{
+			pluginStates: []backendplugin.PluginState{
+				{PluginID: "backend", Status: backendplugin.PluginStatusRunning, Managed: true, StartedAt: startedAt},
+				{PluginID: "failing", Status: backendplugin.PluginStatusNotStarted, Managed: true, LastError: "failed"},
+			},
+		},
+	}
+	pm.updateRegistry(func(r *pluginRegistry) {
+		r.plugins = map[string]*plugins.PluginBase{
+			"panel":   {Id: "panel", Name: "Panel", Type: "panel", Info: plugins.PluginInfo{Version: "1.0.0"}},
+			"backend": {Id: "backend", Name: "Backend", Type: "datasource", Backend: true, Signature: plugins.PluginSignatureValid},
+			"failing": {Id: "failing", Name: "Failing", Type: "datasource", Backend: true},
+		}
+	})
+
+	states := pm.PluginStates()
+	require.Len(t, states, 3)
+
+	require.Equal(t, "backend", states[0].ID)
+	require.Equal(t, plugins.PluginSignatureValid, states[0].Signature)
+	require.NotNil(t, states[0].Process)
+	require.Equal(t, backendplugin.PluginStatusRunning, states[0].Process.Status)
+	require.Equal(t, &startedAt, states[0].Process.StartedAt)
+	require.GreaterOrEqual(t, states[0].Process.UptimeSeconds, int64(3600))
+
+	require.Equal(t, "failing", states[1].ID)
+	require.Equal(t, "failed", states[1].Process.LastError)
+	require.Zero(t, states[1].Process.UptimeSeconds)
+
+	require.Equal(t, plugins.PluginRuntimeState{
+		ID:      "panel",
+		Name:    "Panel",
+		Type:    "panel",
+		Version: "1.0.0",
+	}, states[2])
+}
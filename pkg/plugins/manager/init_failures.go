@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"sort"
+
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// recordInitFailure records that the plugin directory at path failed to initialize at stage, replacing any earlier
+// failure of the directory.
+func (pm *PluginManager) recordInitFailure(path, pluginID string, stage plugins.InitStage, err error) {
+	pm.log.Error("Failed to initialize plugin", "path", path, "pluginId", pluginID, "stage", stage, "error", err)
+
+	pm.initFailuresMu.Lock()
+	defer pm.initFailuresMu.Unlock()
+
+	if pm.initFailures == nil {
+		pm.initFailures = map[string]plugins.InitFailure{}
+	}
+	pm.initFailures[path] = plugins.InitFailure{
+		Path:     path,
+		PluginID: pluginID,
+		Stage:    stage,
+		Error:    err.Error(),
+	}
+}
+
+// clearInitFailure removes the failure of the plugin directory at path, after it initialized.
+func (pm *PluginManager) clearInitFailure(path string) {
+	pm.initFailuresMu.Lock()
+	defer pm.initFailuresMu.Unlock()
+
+	delete(pm.initFailures, path)
+}
+
+// initFailure returns the latest failure of the plugin with the provided ID, if any.
+func (pm *PluginManager) initFailure(pluginID string) (plugins.InitFailure, bool) {
+	pm.initFailuresMu.Lock()
+	defer pm.initFailuresMu.Unlock()
+
+	for _, failure := range pm.initFailures {
+		if failure.PluginID == pluginID {
+			return failure, true
+		}
+	}
+	return plugins.InitFailure{}, false
+}
+
+// InitFailures returns the plugin directories that failed to initialize, sorted by path. Failures are recorded
+// instead of failing the startup, so that one broken plugin doesn't prevent Grafana from starting.
+func (pm *PluginManager) InitFailures() []plugins.InitFailure {
+	pm.initFailuresMu.Lock()
+	defer pm.initFailuresMu.Unlock()
+
+	failures := make([]plugins.InitFailure, 0, len(pm.initFailures))
+	for _, failure := range pm.initFailures {
+		failures = append(failures, failure)
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Path < failures[j].Path
+	})
+	return failures
+}
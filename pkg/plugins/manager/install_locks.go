@@ -0,0 +1,29 @@
+package manager
+
+import (
+	"sync"
+)
+
+// pluginInstallLocks serializes the install, upgrade and uninstall operations of each plugin, so concurrent
+// operations on the same plugin don't corrupt its directory. The zero value is ready to use.
+type pluginInstallLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock locks installing and uninstalling a plugin, returning a function unlocking it.
+func (l *pluginInstallLocks) lock(pluginID string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*sync.Mutex{}
+	}
+	lock, exists := l.locks[pluginID]
+	if !exists {
+		lock = &sync.Mutex{}
+		l.locks[pluginID] = lock
+	}
+	l.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
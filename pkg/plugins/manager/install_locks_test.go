@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type slowPluginInstaller struct {
+	fakePluginInstaller
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (i *slowPluginInstaller) Install(ctx context.Context, pluginID, version, pluginsDirectory, pluginZipURL,
+	pluginRepoURL string) error {
+	i.mu.Lock()
+	i.installCount++
+	i.inFlight++
+	if i.inFlight > i.maxInFlight {
+		i.maxInFlight = i.inFlight
+	}
+	i.mu.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	i.mu.Lock()
+	i.inFlight--
+	i.mu.Unlock()
+	return nil
+}
+
+func TestPluginManager_InstallLocks(t *testing.T) {
+	pm := createManager(t, func(pm *PluginManager) {
+		pm.BackendPluginManager = &fakeBackendPluginManager{}
+	})
+	require.NoError(t, pm.init())
+
+	installer := &slowPluginInstaller{}
+	pm.pluginInstaller = installer
+	pm.Cfg.PluginsPath = "testdata/installer"
+
+	var wg sync.WaitGroup
+	installed := make([]bool, 2)
+	for i := range installed {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			installed[i], err = pm.EnsureInstalled(context.Background(), "test", "")
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	// the second operation waits for the first, and then finds the plugin installed
+	assert.ElementsMatch(t, []bool{true, false}, installed)
+	assert.Equal(t, 1, installer.installCount)
+	assert.Equal(t, 1, installer.maxInFlight)
+	assert.NotNil(t, pm.GetPlugin("test"))
+}
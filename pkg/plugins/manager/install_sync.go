@@ -0,0 +1,93 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// pluginInstallationStore stores the plugins installed and uninstalled by the Grafana instances sharing a database.
+type pluginInstallationStore interface {
+	GetPluginInstallations(ctx context.Context) ([]*models.PluginInstallation, error)
+	SavePluginInstallation(ctx context.Context, pluginID, version string, installed bool) error
+}
+
+// installationSyncInterval returns the interval of installing and uninstalling the plugins installed and
+// uninstalled by other Grafana instances, or 0 if plugin installations aren't synchronized.
+func (pm *PluginManager) installationSyncInterval() time.Duration {
+	if pm.installationStore == nil || pm.Cfg.PluginsHASyncInterval <= 0 {
+		return 0
+	}
+	return time.Duration(pm.Cfg.PluginsHASyncInterval) * time.Second
+}
+
+// recordInstallation records that a plugin was installed, with its installed version, or uninstalled, for the
+// other Grafana instances to do the same.
+func (pm *PluginManager) recordInstallation(ctx context.Context, pluginID string, installed bool) {
+	if pm.installationSyncInterval() <= 0 {
+		return
+	}
+
+	var version string
+	if installed {
+		plugin := pm.GetPlugin(pluginID)
+		if plugin == nil {
+			return
+		}
+		version = plugin.Info.Version
+	}
+
+	if err := pm.installationStore.SavePluginInstallation(ctx, pluginID, version, installed); err != nil {
+		pm.log.Error("Failed to record plugin installation for other Grafana instances", "pluginId", pluginID,
+			"error", err)
+	}
+}
+
+// syncInstallations installs and uninstalls the plugins installed and uninstalled by other Grafana instances, so
+// that all instances converge on the same plugins and versions.
+func (pm *PluginManager) syncInstallations(ctx context.Context) {
+	installations, err := pm.installationStore.GetPluginInstallations(ctx)
+	if err != nil {
+		pm.log.Error("Failed to get plugins installed by other Grafana instances", "error", err)
+		return
+	}
+
+	for _, installation := range installations {
+		pm.syncInstallation(ctx, installation)
+	}
+}
+
+func (pm *PluginManager) syncInstallation(ctx context.Context, installation *models.PluginInstallation) {
+	pluginID := installation.PluginId
+	unlock := pm.installLocks.lock(pluginID)
+	defer unlock()
+
+	plugin := pm.GetPlugin(pluginID)
+	switch {
+	case installation.Installed && (plugin == nil || !plugins.VersionsEqual(plugin.Info.Version, installation.Version)):
+		pm.log.Info("Installing plugin installed by another Grafana instance", "pluginId", pluginID,
+			"version", installation.Version)
+		// the other instance already allowed the version to replace a higher one
+		opts := plugins.InstallOpts{AllowDowngrade: true}
+		install := func() error { return pm.install(ctx, pluginID, installation.Version, opts) }
+		if err := pm.modifyPluginsDir(install); err != nil {
+			pm.log.Error("Failed to install plugin installed by another Grafana instance", "pluginId", pluginID,
+				"version", installation.Version, "error", err)
+		}
+	case !installation.Installed && plugin != nil && !plugin.IsCorePlugin:
+		pm.log.Info("Uninstalling plugin uninstalled by another Grafana instance", "pluginId", pluginID)
+		if err := pm.modifyPluginsDir(func() error { return pm.uninstallAndRemoveData(ctx, pluginID) }); err != nil {
+			// plugins outside of the plugins directory, such as bundled ones, are only uninstalled where they
+			// aren't installed that way
+			if errors.Is(err, plugins.ErrUninstallOutsideOfPluginDir) {
+				pm.log.Debug("Not uninstalling plugin outside of the plugins directory", "pluginId", pluginID)
+				return
+			}
+			pm.log.Error("Failed to uninstall plugin uninstalled by another Grafana instance", "pluginId", pluginID,
+				"error", err)
+		}
+	}
+}
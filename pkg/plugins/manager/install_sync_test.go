@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePluginInstallationStore struct {
+	installations map[string]*models.PluginInstallation
+}
+
+func (s *fakePluginInstallationStore) GetPluginInstallations(ctx context.Context) ([]*models.PluginInstallation, error) {
+	var result []*models.PluginInstallation
+	for _, installation := range s.installations {
+		result = append(result, installation)
+	}
+	return result, nil
+}
+
+func (s *fakePluginInstallationStore) SavePluginInstallation(ctx context.Context, pluginID, version string,
+	installed bool) error {
+	s.installations[pluginID] = &models.PluginInstallation{PluginId: pluginID, Version: version, Installed: installed}
+	return nil
+}
+
+func TestPluginManager_InstallationSync(t *testing.T) {
+	newSyncedManager := func(t *testing.T) (*PluginManager, *fakePluginInstaller, *fakePluginInstallationStore) {
+		fm := &fakeBackendPluginManager{}
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.BackendPluginManager = fm
+		})
+		require.NoError(t, pm.init())
+
+		installer := &fakePluginInstaller{}
+		pm.pluginInstaller = installer
+		store := &fakePluginInstallationStore{installations: map[string]*models.PluginInstallation{}}
+		pm.installationStore = store
+		pm.Cfg.PluginsPath = "testdata/installer"
+		pm.Cfg.PluginsHASyncInterval = 10
+		return pm, installer, store
+	}
+
+	t.Run("Records installs and uninstalls", func(t *testing.T) {
+		pm, _, store := newSyncedManager(t)
+
+		require.NoError(t, pm.Install(context.Background(), "test", "1.0.0", plugins.InstallOpts{}))
+		assert.Equal(t, &models.PluginInstallation{PluginId: "test", Version: "1.0.0", Installed: true},
+			store.installations["test"])
+
+		require.NoError(t, pm.Uninstall(context.Background(), "test"))
+		assert.Equal(t, &models.PluginInstallation{PluginId: "test", Installed: false}, store.installations["test"])
+	})
+
+	t.Run("Doesn't record installs when disabled", func(t *testing.T) {
+		pm, _, store := newSyncedManager(t)
+		pm.Cfg.PluginsHASyncInterval = 0
+
+		require.NoError(t, pm.Install(context.Background(), "test", "1.0.0", plugins.InstallOpts{}))
+		assert.Empty(t, store.installations)
+	})
+
+	t.Run("Installs plugins installed by other instances", func(t *testing.T) {
+		pm, installer, store := newSyncedManager(t)
+		store.installations["test"] = &models.PluginInstallation{PluginId: "test", Version: "1.0.0", Installed: true}
+
+		pm.syncInstallations(context.Background())
+		assert.Equal(t, 1, installer.installCount)
+		assert.NotNil(t, pm.GetPlugin("test"))
+
+		// the installed version is already installed
+		pm.syncInstallations(context.Background())
+		assert.Equal(t, 1, installer.installCount)
+	})
+
+	t.Run("Uninstalls plugins uninstalled by other instances", func(t *testing.T) {
+		pm, installer, store := newSyncedManager(t)
+		require.NoError(t, pm.Install(context.Background(), "test", "1.0.0", plugins.InstallOpts{}))
+		store.installations["test"] = &models.PluginInstallation{PluginId: "test", Installed: false}
+
+		pm.syncInstallations(context.Background())
+		assert.Equal(t, 1, installer.uninstallCount)
+		assert.Nil(t, pm.GetPlugin("test"))
+
+		pm.syncInstallations(context.Background())
+		assert.Equal(t, 1, installer.uninstallCount)
+	})
+
+	t.Run("Doesn't uninstall core plugins", func(t *testing.T) {
+		pm, installer, store := newSyncedManager(t)
+		store.installations["graphite"] = &models.PluginInstallation{PluginId: "graphite", Installed: false}
+
+		pm.syncInstallations(context.Background())
+		assert.Equal(t, 0, installer.uninstallCount)
+		assert.NotNil(t, pm.GetPlugin("graphite"))
+	})
+}
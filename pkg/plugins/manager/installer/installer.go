@@ -423,6 +423,7 @@ func (i *Installer) GetUpdateInfo(pluginID, version, pluginRepoURL string) (plug
 
 	return plugins.UpdateInfo{
 		PluginZipURL: fmt.Sprintf("%s/%s/versions/%s/download", pluginRepoURL, pluginID, v.Version),
+		Version:      v.Version,
 	}, nil
 }
 
@@ -453,6 +454,15 @@ func (i *Installer) selectVersion(plugin *Plugin, version string) (*Version, err
 			break
 		}
 	}
+	// the requested version may be written differently, such as 2.1 for 2.1.0
+	if len(ver.Version) == 0 {
+		for _, v := range plugin.Versions {
+			if plugins.VersionsEqual(v.Version, version) {
+				ver = v
+				break
+			}
+		}
+	}
 
 	if len(ver.Version) == 0 {
 		i.log.Debugf("Requested plugin version %s v%s not found but potential fallback version '%s' was found",
@@ -517,15 +527,13 @@ func (i *Installer) extractFiles(archiveFile string, pluginID string, dest strin
 	}
 	i.log.Debug(fmt.Sprintf("Extracting archive %q to %q...", archiveFile, dest))
 
-	existingInstallDir := filepath.Join(dest, pluginID)
-	if _, err := os.Stat(existingInstallDir); !os.IsNotExist(err) {
-		i.log.Debugf("Removing existing installation of plugin %s", existingInstallDir)
-		err = os.RemoveAll(existingInstallDir)
-		if err != nil {
-			return err
-		}
-	}
+	return ExtractStaged(dest, pluginID, func(stagingDir string) error {
+		return i.extractArchive(archiveFile, pluginID, stagingDir, dest, allowSymlinks)
+	})
+}
 
+// extractArchive extracts the plugin archive into the staging directory dest of the plugins directory pluginsDir.
+func (i *Installer) extractArchive(archiveFile, pluginID, dest, pluginsDir string, allowSymlinks bool) error {
 	r, err := zip.OpenReader(archiveFile)
 	defer func() {
 		if err := r.Close(); err != nil {
@@ -546,7 +554,7 @@ func (i *Installer) extractFiles(archiveFile string, pluginID string, dest strin
 			strings.HasPrefix(zf.Name, ".."+string(os.PathSeparator)) {
 			return fmt.Errorf(
 				"archive member %q tries to write outside of plugin directory: %q, this can be a security risk",
-				zf.Name, dest)
+				zf.Name, pluginsDir)
 		}
 
 		dstPath := filepath.Clean(filepath.Join(dest, removeGitBuildFromName(zf.Name, pluginID)))
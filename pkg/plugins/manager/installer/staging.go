@@ -0,0 +1,86 @@
+package installer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// StagingDirPrefix is the prefix of the directories in the plugins directory that plugin archives are extracted to
+// before the plugin is moved into place. The plugin loader skips them, so it never sees a partially extracted plugin.
+const StagingDirPrefix = ".grafana-plugin-install-"
+
+// ExtractStaged installs plugin pluginID into pluginsDir transactionally. extract extracts the plugin archive into a
+// staging directory in pluginsDir, where the plugin is expected in the pluginID subdirectory. Only when it succeeded
+// is the existing installation of the plugin replaced by renaming, so a failed or interrupted install never leaves a
+// partially extracted plugin directory behind, and a failed install keeps the existing installation.
+func ExtractStaged(pluginsDir, pluginID string, extract func(stagingDir string) error) error {
+	removeStagingDirs(pluginsDir, pluginID)
+
+	// the staging directory is created in the plugins directory, so it's on the same file system and the plugin can
+	// be renamed into place
+	stagingDir, err := ioutil.TempDir(pluginsDir, StagingDirPrefix+pluginID+"-")
+	if err != nil {
+		if os.IsPermission(err) {
+			return fmt.Errorf(permissionsDeniedMessage, pluginsDir)
+		}
+
+		return errutil.Wrap("failed to create staging directory", err)
+	}
+	defer func() {
+		// the staging directory contains the previous installation of the plugin after the swap
+		_ = os.RemoveAll(stagingDir)
+	}()
+
+	if err := extract(stagingDir); err != nil {
+		return err
+	}
+
+	stagedDir := filepath.Join(stagingDir, pluginID)
+	if fi, err := os.Stat(stagedDir); err != nil || !fi.IsDir() {
+		return fmt.Errorf("plugin archive doesn't contain any files of plugin %s", pluginID)
+	}
+
+	installDir := filepath.Join(pluginsDir, pluginID)
+	previousDir := filepath.Join(stagingDir, "previous")
+	if err := os.Rename(installDir, previousDir); err != nil && !os.IsNotExist(err) {
+		return errutil.Wrap("failed to move existing plugin installation", err)
+	}
+
+	if err := os.Rename(stagedDir, installDir); err != nil {
+		if restoreErr := os.Rename(previousDir, installDir); restoreErr != nil && !os.IsNotExist(restoreErr) {
+			return fmt.Errorf("failed to move plugin into plugins directory: %v, and to restore existing installation: %w",
+				err, restoreErr)
+		}
+
+		return errutil.Wrap("failed to move plugin into plugins directory", err)
+	}
+
+	return nil
+}
+
+// removeStagingDirs removes the staging directories of plugin pluginID left behind by interrupted installs.
+func removeStagingDirs(pluginsDir, pluginID string) {
+	entries, err := ioutil.ReadDir(pluginsDir)
+	if err != nil {
+		return
+	}
+
+	prefix := StagingDirPrefix + pluginID + "-"
+	for _, e := range entries {
+		// the suffix ioutil.TempDir adds is numeric, which tells staging directories apart from those of plugins
+		// whose ID starts with pluginID
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+		if suffix := strings.TrimPrefix(e.Name(), prefix); suffix == "" || strings.Trim(suffix, "0123456789") != "" {
+			continue
+		}
+
+		_ = os.RemoveAll(filepath.Join(pluginsDir, e.Name()))
+	}
+}
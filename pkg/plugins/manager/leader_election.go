@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// pluginLeaderLeaseName is the name of the leader lease of the background plugin tasks.
+const pluginLeaderLeaseName = "plugins"
+
+// pluginLeaderLeaseIntervals is the number of election intervals a leader lease lasts, so that a single slow or
+// failed renewal doesn't hand over leadership.
+const pluginLeaderLeaseIntervals = 3
+
+// pluginLeaderStore elects the leader among the Grafana instances sharing a database.
+type pluginLeaderStore interface {
+	TryAcquirePluginLeaderLease(ctx context.Context, name, instanceID string, ttl time.Duration) (bool, error)
+	ReleasePluginLeaderLease(ctx context.Context, name, instanceID string) error
+}
+
+// leaderElectionInterval returns the interval of electing the Grafana instance running the background plugin tasks,
+// or 0 if every instance runs them.
+func (pm *PluginManager) leaderElectionInterval() time.Duration {
+	if pm.leaderStore == nil || pm.Cfg.PluginsLeaderElectionInterval <= 0 {
+		return 0
+	}
+	return time.Duration(pm.Cfg.PluginsLeaderElectionInterval) * time.Second
+}
+
+// IsLeader returns whether this Grafana instance runs the background plugin tasks, like checking for plugin updates.
+// Without leader election every instance does. All instances serve plugins regardless.
+func (pm *PluginManager) IsLeader() bool {
+	return pm.leaderElectionInterval() <= 0 || atomic.LoadInt32(&pm.leader) == 1
+}
+
+// electLeader acquires or renews the leader lease of this Grafana instance, and returns whether it just became the
+// leader.
+func (pm *PluginManager) electLeader(ctx context.Context) bool {
+	ttl := pluginLeaderLeaseIntervals * pm.leaderElectionInterval()
+	acquired, err := pm.leaderStore.TryAcquirePluginLeaderLease(ctx, pluginLeaderLeaseName, pm.instanceID, ttl)
+	if err != nil {
+		// step down, since the lease can't be renewed and another instance takes over once it expired
+		pm.log.Error("Failed to acquire plugin leader lease", "error", err)
+	}
+	return pm.setLeader(acquired && err == nil)
+}
+
+// resignLeader releases the leader lease held by this Grafana instance, so that another instance takes over right
+// away.
+func (pm *PluginManager) resignLeader() {
+	if atomic.LoadInt32(&pm.leader) == 0 {
+		return
+	}
+
+	pm.setLeader(false)
+	// ctx is done when resigning on shutdown
+	if err := pm.leaderStore.ReleasePluginLeaderLease(context.Background(), pluginLeaderLeaseName,
+		pm.instanceID); err != nil {
+		pm.log.Warn("Failed to release plugin leader lease", "error", err)
+	}
+}
+
+// setLeader records whether this Grafana instance is the leader, publishes changes on the bus and returns whether it
+// just became the leader.
+func (pm *PluginManager) setLeader(leader bool) bool {
+	var value int32
+	if leader {
+		value = 1
+	}
+	if atomic.SwapInt32(&pm.leader, value) == value {
+		return false
+	}
+
+	if leader {
+		pm.log.Info("Elected leader for background plugin tasks", "instanceId", pm.instanceID)
+	} else {
+		pm.log.Info("Stopped being leader for background plugin tasks", "instanceId", pm.instanceID)
+	}
+	if err := bus.Publish(&models.PluginLeadershipChanged{Leader: leader}); err != nil {
+		pm.log.Warn("Failed to publish plugin leadership change", "error", err)
+	}
+	return leader
+}
@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePluginLeaderStore struct {
+	holder string
+	ttl    time.Duration
+	err    error
+}
+
+func (s *fakePluginLeaderStore) TryAcquirePluginLeaderLease(ctx context.Context, name, instanceID string,
+	ttl time.Duration) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	if s.holder != "" && s.holder != instanceID {
+		return false, nil
+	}
+	s.holder, s.ttl = instanceID, ttl
+	return true, nil
+}
+
+func (s *fakePluginLeaderStore) ReleasePluginLeaderLease(ctx context.Context, name, instanceID string) error {
+	if s.holder == instanceID {
+		s.holder = ""
+	}
+	return nil
+}
+
+func TestPluginManager_LeaderElection(t *testing.T) {
+	newElectingManager := func(t *testing.T, store *fakePluginLeaderStore, instanceID string) (*PluginManager,
+		*[]bool) {
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.BackendPluginManager = &fakeBackendPluginManager{}
+		})
+		pm.leaderStore = store
+		pm.instanceID = instanceID
+		pm.Cfg.PluginsLeaderElectionInterval = 10
+
+		var changes []bool
+		bus.AddEventListener(func(event *models.PluginLeadershipChanged) error {
+			changes = append(changes, event.Leader)
+			return nil
+		})
+		return pm, &changes
+	}
+
+	t.Run("Every instance is the leader without leader election", func(t *testing.T) {
+		pm := createManager(t)
+		assert.True(t, pm.IsLeader())
+	})
+
+	t.Run("Elects a single leader", func(t *testing.T) {
+		bus.ClearBusHandlers()
+		t.Cleanup(bus.ClearBusHandlers)
+
+		store := &fakePluginLeaderStore{}
+		a, changes := newElectingManager(t, store, "a")
+		b, _ := newElectingManager(t, store, "b")
+		assert.False(t, a.IsLeader())
+
+		assert.True(t, a.electLeader(context.Background()))
+		assert.False(t, b.electLeader(context.Background()))
+		assert.True(t, a.IsLeader())
+		assert.False(t, b.IsLeader())
+		assert.Equal(t, 30*time.Second, store.ttl)
+
+		// renewing the lease doesn't change leadership
+		assert.False(t, a.electLeader(context.Background()))
+		assert.True(t, a.IsLeader())
+		assert.Equal(t, []bool{true}, *changes)
+
+		a.resignLeader()
+		assert.False(t, a.IsLeader())
+		assert.True(t, b.electLeader(context.Background()))
+		assert.True(t, b.IsLeader())
+	})
+
+	t.Run("Steps down when the lease can't be renewed", func(t *testing.T) {
+		bus.ClearBusHandlers()
+		t.Cleanup(bus.ClearBusHandlers)
+
+		store := &fakePluginLeaderStore{}
+		pm, changes := newElectingManager(t, store, "a")
+		require.True(t, pm.electLeader(context.Background()))
+
+		store.err = errors.New("database is down")
+		assert.False(t, pm.electLeader(context.Background()))
+		assert.False(t, pm.IsLeader())
+		assert.Equal(t, []bool{true, false}, *changes)
+	})
+}
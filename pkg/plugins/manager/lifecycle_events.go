@@ -0,0 +1,108 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// lifecycleEventRetention is how long plugin lifecycle events are stored for the other Grafana instances.
+const lifecycleEventRetention = time.Hour
+
+// pluginLifecycleEventStore stores the plugin lifecycle events of the Grafana instances sharing a database.
+type pluginLifecycleEventStore interface {
+	InsertPluginLifecycleEvent(ctx context.Context, event *models.PluginLifecycleEvent) error
+	GetPluginLifecycleEvents(ctx context.Context, afterID int64) ([]*models.PluginLifecycleEvent, error)
+	GetLatestPluginLifecycleEventID(ctx context.Context) (int64, error)
+	DeletePluginLifecycleEvents(ctx context.Context, olderThan time.Time) error
+}
+
+// clusterEventsInterval returns the interval of publishing the plugin lifecycle events of the other Grafana instances,
+// or 0 if plugin lifecycle events aren't shared with them.
+func (pm *PluginManager) clusterEventsInterval() time.Duration {
+	if pm.lifecycleEventStore == nil || pm.Cfg.PluginsClusterEventsInterval <= 0 {
+		return 0
+	}
+	return time.Duration(pm.Cfg.PluginsClusterEventsInterval) * time.Second
+}
+
+// publishLifecycleEvent publishes a lifecycle event of a plugin on the bus.
+func (pm *PluginManager) publishLifecycleEvent(pluginID string, eventType models.PluginLifecycleEventType) {
+	event := &models.PluginLifecycleEvent{PluginId: pluginID, Type: eventType, Created: time.Now()}
+	if plugin := pm.GetPlugin(pluginID); plugin != nil {
+		event.Version = plugin.Info.Version
+	}
+
+	if err := bus.Publish(event); err != nil {
+		pm.log.Warn("Failed to publish plugin lifecycle event", "pluginId", pluginID, "type", eventType, "error", err)
+	}
+}
+
+// handlePluginLifecycleEvent stores the lifecycle events of the plugins of this Grafana instance, for the other
+// instances to publish them.
+func (pm *PluginManager) handlePluginLifecycleEvent(event *models.PluginLifecycleEvent) error {
+	if event.Remote || pm.clusterEventsInterval() <= 0 {
+		return nil
+	}
+
+	stored := *event
+	stored.InstanceId = pm.instanceID
+	if err := pm.lifecycleEventStore.InsertPluginLifecycleEvent(context.Background(), &stored); err != nil {
+		pm.log.Error("Failed to store plugin lifecycle event for other Grafana instances", "pluginId", event.PluginId,
+			"type", event.Type, "error", err)
+	}
+	return nil
+}
+
+// initClusterEvents skips the plugin lifecycle events stored before this Grafana instance started.
+func (pm *PluginManager) initClusterEvents(ctx context.Context) {
+	latestID, err := pm.lifecycleEventStore.GetLatestPluginLifecycleEventID(ctx)
+	if err != nil {
+		pm.log.Error("Failed to get latest plugin lifecycle event", "error", err)
+		return
+	}
+	pm.lastLifecycleEventID = latestID
+}
+
+// publishClusterEvents publishes the plugin lifecycle events of the other Grafana instances stored since they were
+// last published, marked as remote. When other instances installed or uninstalled plugins, the plugins installed on
+// this instance are synchronized and the shared plugins directory is reloaded right away, if enabled.
+func (pm *PluginManager) publishClusterEvents(ctx context.Context) {
+	events, err := pm.lifecycleEventStore.GetPluginLifecycleEvents(ctx, pm.lastLifecycleEventID)
+	if err != nil {
+		pm.log.Error("Failed to get plugin lifecycle events of other Grafana instances", "error", err)
+		return
+	}
+
+	installsChanged := false
+	for _, event := range events {
+		pm.lastLifecycleEventID = event.Id
+		if event.InstanceId == pm.instanceID {
+			continue
+		}
+
+		event.Remote = true
+		if err := bus.Publish(event); err != nil {
+			pm.log.Warn("Failed to publish plugin lifecycle event of another Grafana instance", "pluginId",
+				event.PluginId, "type", event.Type, "error", err)
+		}
+		if event.Type == models.PluginInstalled || event.Type == models.PluginUninstalled {
+			installsChanged = true
+		}
+	}
+
+	if installsChanged {
+		if pm.installationSyncInterval() > 0 {
+			pm.syncInstallations(ctx)
+		}
+		if pm.sharedPluginsDirInterval() > 0 {
+			pm.reloadSharedPluginsDir(ctx)
+		}
+	}
+
+	if err := pm.lifecycleEventStore.DeletePluginLifecycleEvents(ctx, time.Now().Add(-lifecycleEventRetention)); err != nil {
+		pm.log.Warn("Failed to delete expired plugin lifecycle events", "error", err)
+	}
+}
@@ -0,0 +1,126 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePluginLifecycleEventStore struct {
+	events        []*models.PluginLifecycleEvent
+	deletedBefore time.Time
+}
+
+func (s *fakePluginLifecycleEventStore) InsertPluginLifecycleEvent(ctx context.Context,
+	event *models.PluginLifecycleEvent) error {
+	event.Id = int64(len(s.events) + 1)
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *fakePluginLifecycleEventStore) GetPluginLifecycleEvents(ctx context.Context,
+	afterID int64) ([]*models.PluginLifecycleEvent, error) {
+	var result []*models.PluginLifecycleEvent
+	for _, event := range s.events {
+		if event.Id > afterID {
+			copied := *event
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (s *fakePluginLifecycleEventStore) GetLatestPluginLifecycleEventID(ctx context.Context) (int64, error) {
+	return int64(len(s.events)), nil
+}
+
+func (s *fakePluginLifecycleEventStore) DeletePluginLifecycleEvents(ctx context.Context, olderThan time.Time) error {
+	s.deletedBefore = olderThan
+	return nil
+}
+
+func TestPluginManager_LifecycleEvents(t *testing.T) {
+	newClusterManager := func(t *testing.T) (*PluginManager, *fakePluginLifecycleEventStore,
+		*[]*models.PluginLifecycleEvent) {
+		bus.ClearBusHandlers()
+		t.Cleanup(bus.ClearBusHandlers)
+
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.BackendPluginManager = &fakeBackendPluginManager{}
+		})
+		require.NoError(t, pm.init())
+
+		store := &fakePluginLifecycleEventStore{}
+		pm.lifecycleEventStore = store
+		pm.instanceID = "this"
+		pm.Cfg.PluginsPath = "testdata/installer"
+		pm.Cfg.PluginsClusterEventsInterval = 10
+		pm.pluginInstaller = &fakePluginInstaller{}
+
+		var published []*models.PluginLifecycleEvent
+		bus.AddEventListener(pm.handlePluginLifecycleEvent)
+		bus.AddEventListener(func(event *models.PluginLifecycleEvent) error {
+			published = append(published, event)
+			return nil
+		})
+		return pm, store, &published
+	}
+
+	t.Run("Publishes and stores installs and uninstalls", func(t *testing.T) {
+		pm, store, published := newClusterManager(t)
+
+		require.NoError(t, pm.Install(context.Background(), "test", "1.0.0", plugins.InstallOpts{}))
+		require.NoError(t, pm.Uninstall(context.Background(), "test"))
+
+		require.Len(t, *published, 2)
+		assert.Equal(t, models.PluginInstalled, (*published)[0].Type)
+		assert.Equal(t, models.PluginUninstalled, (*published)[1].Type)
+
+		require.Len(t, store.events, 2)
+		for _, event := range store.events {
+			assert.Equal(t, "test", event.PluginId)
+			assert.Equal(t, "this", event.InstanceId)
+		}
+	})
+
+	t.Run("Doesn't store events when disabled", func(t *testing.T) {
+		pm, store, published := newClusterManager(t)
+		pm.Cfg.PluginsClusterEventsInterval = 0
+
+		require.NoError(t, pm.Install(context.Background(), "test", "1.0.0", plugins.InstallOpts{}))
+		assert.Len(t, *published, 1)
+		assert.Empty(t, store.events)
+	})
+
+	t.Run("Publishes events of other instances as remote", func(t *testing.T) {
+		pm, store, published := newClusterManager(t)
+		store.events = []*models.PluginLifecycleEvent{
+			{Id: 1, PluginId: "old", Type: models.PluginFailed, InstanceId: "other"},
+		}
+		pm.initClusterEvents(context.Background())
+
+		store.events = append(store.events,
+			&models.PluginLifecycleEvent{Id: 2, PluginId: "own", Type: models.PluginFailed, InstanceId: "this"},
+			&models.PluginLifecycleEvent{Id: 3, PluginId: "remote", Type: models.PluginDecommissioned,
+				InstanceId: "other"},
+		)
+		pm.publishClusterEvents(context.Background())
+
+		require.Len(t, *published, 1)
+		assert.Equal(t, "remote", (*published)[0].PluginId)
+		assert.True(t, (*published)[0].Remote)
+		assert.Equal(t, int64(3), pm.lastLifecycleEventID)
+		// remote events aren't stored again
+		assert.Len(t, store.events, 3)
+		assert.WithinDuration(t, time.Now().Add(-lifecycleEventRetention), store.deletedBefore, time.Minute)
+
+		pm.publishClusterEvents(context.Background())
+		assert.Len(t, *published, 1)
+	})
+}
@@ -0,0 +1,138 @@
+package manager
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// ListPlugins lists a page of the plugins matching query, sorted by name. Alpha plugins, unless they're enabled
+// in the configuration, and built-in data sources are never listed.
+func (pm *PluginManager) ListPlugins(query plugins.PluginListQuery) (plugins.PluginListResult, error) {
+	settings, err := pm.GetPluginSettings(query.OrgID)
+	if err != nil {
+		return plugins.PluginListResult{}, err
+	}
+
+	return pm.listPlugins(query, settings), nil
+}
+
+func (pm *PluginManager) listPlugins(query plugins.PluginListQuery,
+	settings map[string]*models.PluginSettingInfoDTO) plugins.PluginListResult {
+	registry := pm.registry()
+	terms := strings.Fields(strings.ToLower(query.Query))
+
+	matching := make([]plugins.PluginListItem, 0)
+	for _, p := range pm.Plugins(query.Types...) {
+		if p.State == plugins.PluginStateAlpha && !pm.Cfg.PluginsEnableAlpha {
+			continue
+		}
+		if ds, exists := registry.dataSources[p.Id]; exists && ds.BuiltIn {
+			continue
+		}
+		if !matchesListQuery(p, settings[p.Id], query) || !matchesSearchTerms(p, terms) {
+			continue
+		}
+
+		matching = append(matching, plugins.PluginListItem{Plugin: p, Settings: settings[p.Id]})
+	}
+
+	// plugins are sorted by ID, which keeps plugins with the same name in a stable order
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].Plugin.Name < matching[j].Plugin.Name
+	})
+
+	result := plugins.PluginListResult{TotalCount: len(matching), Plugins: matching}
+	if query.PerPage > 0 {
+		page := query.Page
+		if page < 1 {
+			page = 1
+		}
+
+		start := (page - 1) * query.PerPage
+		if start > len(matching) {
+			start = len(matching)
+		}
+		end := start + query.PerPage
+		if end > len(matching) {
+			end = len(matching)
+		}
+		result.Plugins = matching[start:end]
+	}
+
+	return result
+}
+
+// matchesListQuery reports whether plugin p with settings matches the filters of query.
+func matchesListQuery(p *plugins.PluginBase, settings *models.PluginSettingInfoDTO, query plugins.PluginListQuery) bool {
+	if query.Signature != "" && p.Signature != query.Signature {
+		return false
+	}
+
+	if query.Category != "" && p.Category != query.Category {
+		return false
+	}
+
+	if query.State != "" {
+		state := p.State
+		if state == "" {
+			state = plugins.PluginStateStable
+		}
+		if state != query.State {
+			return false
+		}
+	}
+
+	for _, keyword := range query.Keywords {
+		if !hasKeyword(p, keyword) {
+			return false
+		}
+	}
+
+	if query.Core != nil && p.IsCorePlugin != *query.Core {
+		return false
+	}
+
+	if query.Embedded != nil && (p.Parent != nil) != *query.Embedded {
+		return false
+	}
+
+	if query.HasUpdate != nil && p.GrafanaNetHasUpdate != *query.HasUpdate {
+		return false
+	}
+
+	if query.Enabled != nil && (settings != nil && settings.Enabled) != *query.Enabled {
+		return false
+	}
+
+	return true
+}
+
+// hasKeyword reports whether plugin p has keyword, ignoring case.
+func hasKeyword(p *plugins.PluginBase, keyword string) bool {
+	for _, k := range p.Info.Keywords {
+		if strings.EqualFold(k, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSearchTerms reports whether each of the lower case terms of a full-text query is contained in the ID,
+// name, description, author, category or keywords of plugin p.
+func matchesSearchTerms(p *plugins.PluginBase, terms []string) bool {
+	if len(terms) == 0 {
+		return true
+	}
+
+	text := strings.ToLower(strings.Join(append([]string{p.Id, p.Name, p.Info.Description, p.Info.Author.Name,
+		p.Category}, p.Info.Keywords...), "\n"))
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginManager_ListPlugins(t *testing.T) {
+	pm := &PluginManager{Cfg: setting.NewCfg()}
+	pm.updateRegistry(func(r *pluginRegistry) {
+		r.plugins["clock"] = &plugins.PluginBase{Id: "clock", Name: "Clock", Type: "panel",
+			Signature: plugins.PluginSignatureValid, GrafanaNetHasUpdate: true, State: plugins.PluginStateBeta,
+			Info: plugins.PluginInfo{Description: "Shows the current time", Author: plugins.PluginInfoLink{Name: "Grafana Labs"},
+				Keywords: []string{"Time", "countdown"}}}
+		r.plugins["graph"] = &plugins.PluginBase{Id: "graph", Name: "Graph", Type: "panel",
+			Signature: plugins.PluginSignatureInternal, IsCorePlugin: true}
+		r.plugins["app"] = &plugins.PluginBase{Id: "app", Name: "App", Type: "app",
+			Signature: plugins.PluginSignatureValid, Category: "cloud",
+			Info: plugins.PluginInfo{Description: "Monitors the cloud", Keywords: []string{"time"}}}
+		r.plugins["app-panel"] = &plugins.PluginBase{Id: "app-panel", Name: "App panel", Type: "panel",
+			Signature: plugins.PluginSignatureValid, Parent: r.plugins["app"]}
+		r.plugins["alpha"] = &plugins.PluginBase{Id: "alpha", Name: "Alpha", Type: "panel",
+			State: plugins.PluginStateAlpha}
+		r.plugins["grafana"] = &plugins.PluginBase{Id: "grafana", Name: "Grafana", Type: "datasource"}
+		r.dataSources["grafana"] = &plugins.DataSourcePlugin{BuiltIn: true}
+	})
+	settings := map[string]*models.PluginSettingInfoDTO{
+		"clock": {PluginId: "clock", Enabled: true},
+		"graph": {PluginId: "graph", Enabled: true},
+		"app":   {PluginId: "app", Enabled: false},
+	}
+
+	pluginIDs := func(result plugins.PluginListResult) []string {
+		ids := make([]string, 0, len(result.Plugins))
+		for _, item := range result.Plugins {
+			ids = append(ids, item.Plugin.Id)
+		}
+		return ids
+	}
+	yes, no := true, false
+
+	t.Run("Should list all plugins sorted by name", func(t *testing.T) {
+		result := pm.listPlugins(plugins.PluginListQuery{}, settings)
+		require.Equal(t, 4, result.TotalCount)
+		require.Equal(t, []string{"app", "app-panel", "clock", "graph"}, pluginIDs(result))
+		require.Equal(t, settings["clock"], result.Plugins[2].Settings)
+	})
+
+	t.Run("Should filter plugins", func(t *testing.T) {
+		require.Equal(t, []string{"app-panel", "clock", "graph"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Types: []string{"panel"}}, settings)))
+		require.Equal(t, []string{"graph"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Signature: plugins.PluginSignatureInternal}, settings)))
+		require.Equal(t, []string{"clock", "graph"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Enabled: &yes}, settings)))
+		require.Equal(t, []string{"app", "app-panel"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Enabled: &no}, settings)))
+		require.Equal(t, []string{"clock"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{HasUpdate: &yes}, settings)))
+		require.Equal(t, []string{"graph"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Core: &yes}, settings)))
+		require.Equal(t, []string{"app", "clock", "graph"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Embedded: &no}, settings)))
+	})
+
+	t.Run("Should search plugins", func(t *testing.T) {
+		require.Equal(t, []string{"app"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Category: "cloud"}, settings)))
+		require.Equal(t, []string{"app", "clock"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Keywords: []string{"TIME"}}, settings)))
+		require.Equal(t, []string{"clock"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Keywords: []string{"time", "countdown"}}, settings)))
+		require.Equal(t, []string{"clock"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{State: plugins.PluginStateBeta}, settings)))
+		require.Equal(t, []string{"app", "app-panel", "graph"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{State: plugins.PluginStateStable}, settings)))
+
+		// each word of a full-text query has to be contained in one of the searched fields
+		require.Equal(t, []string{"app", "app-panel"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Query: "APP"}, settings)))
+		require.Equal(t, []string{"clock"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Query: "grafana  current"}, settings)))
+		require.Equal(t, []string{"app"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{Query: "cloud time"}, settings)))
+		require.Empty(t, pluginIDs(pm.listPlugins(plugins.PluginListQuery{Query: "cloud countdown"}, settings)))
+	})
+
+	t.Run("Should paginate plugins", func(t *testing.T) {
+		result := pm.listPlugins(plugins.PluginListQuery{Page: 2, PerPage: 3}, settings)
+		require.Equal(t, 4, result.TotalCount)
+		require.Equal(t, []string{"graph"}, pluginIDs(result))
+
+		result = pm.listPlugins(plugins.PluginListQuery{Page: 3, PerPage: 3}, settings)
+		require.Equal(t, 4, result.TotalCount)
+		require.Empty(t, result.Plugins)
+
+		result = pm.listPlugins(plugins.PluginListQuery{PerPage: 2}, settings)
+		require.Equal(t, []string{"app", "app-panel"}, pluginIDs(result))
+	})
+
+	t.Run("Should list alpha plugins if enabled", func(t *testing.T) {
+		pm.Cfg.PluginsEnableAlpha = true
+		t.Cleanup(func() {
+			pm.Cfg.PluginsEnableAlpha = false
+		})
+
+		require.Equal(t, []string{"alpha"},
+			pluginIDs(pm.listPlugins(plugins.PluginListQuery{PerPage: 1}, settings)))
+	})
+}
@@ -11,17 +11,20 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/fs"
 	"github.com/grafana/grafana/pkg/infra/log"
-	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/plugins/manager/installer"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
@@ -34,7 +37,8 @@ var (
 )
 
 const (
-	grafanaComURL = "https://grafana.com/api/plugins"
+	grafanaComURL    = "https://grafana.com/api/plugins"
+	pluginIgnoreFile = ".pluginignore"
 )
 
 type unsignedPluginConditionFunc = func(plugin *plugins.PluginBase) bool
@@ -42,12 +46,15 @@ type unsignedPluginConditionFunc = func(plugin *plugins.PluginBase) bool
 type PluginScanner struct {
 	pluginPath                    string
 	errors                        []error
+	loadErrors                    []plugins.PluginError
 	backendPluginManager          backendplugin.Manager
 	cfg                           *setting.Cfg
 	requireSigned                 bool
 	log                           log.Logger
 	plugins                       map[string]*plugins.PluginBase
 	allowUnsignedPluginsCondition unsignedPluginConditionFunc
+	maxDepth                      int
+	ignorePatterns                []string
 }
 
 type PluginManager struct {
@@ -64,36 +71,77 @@ type PluginManager struct {
 	grafanaLatestVersion          string
 	grafanaHasUpdate              bool
 	pluginScanningErrors          map[string]plugins.PluginError
-
-	renderer     *plugins.RendererPlugin
-	dataSources  map[string]*plugins.DataSourcePlugin
-	plugins      map[string]*plugins.PluginBase
-	panels       map[string]*plugins.PanelPlugin
-	apps         map[string]*plugins.AppPlugin
-	staticRoutes []*plugins.PluginStaticRoute
-	pluginsMu    sync.RWMutex
+	// pluginLoadErrors are the errors of plugins whose plugin.json couldn't be loaded, by plugin directory.
+	pluginLoadErrors map[string]plugins.PluginError
+	// pluginErrorsMu guards scanningErrors, pluginScanningErrors and pluginLoadErrors, which are written by scans
+	// of concurrent installs.
+	pluginErrorsMu sync.Mutex
+	// initFailures are the plugin directories that failed to initialize, by path.
+	initFailures   map[string]plugins.InitFailure
+	initFailuresMu sync.Mutex
+
+	// registrySnapshot holds the current *pluginRegistry, replaced while holding registryMu.
+	registrySnapshot atomic.Value
+	registryMu       sync.Mutex
+	// loadMu serializes scanning the external plugin directories and registering the plugins found.
+	loadMu       sync.Mutex
+	installLocks pluginInstallLocks
+	// installationStore shares the plugins installed and uninstalled with the other Grafana instances.
+	installationStore pluginInstallationStore
+	// pluginsDirGeneration is the install generation of the shared plugins directory the plugins were last loaded
+	// from, accessed atomically.
+	pluginsDirGeneration int64
+	// pluginsDirReloadMu serializes reloading the plugins of the shared plugins directory.
+	pluginsDirReloadMu sync.Mutex
+	// lifecycleEventStore shares the plugin lifecycle events with the other Grafana instances, which tell the events
+	// of this instance apart by instanceID. lastLifecycleEventID is the ID of the event last published.
+	lifecycleEventStore  pluginLifecycleEventStore
+	instanceID           string
+	lastLifecycleEventID int64
+	// leaderStore elects the Grafana instance running the background plugin tasks. leader is 1 while this instance
+	// is the leader, accessed atomically.
+	leaderStore pluginLeaderStore
+	leader      int32
+	// canaries are the canary versions of installed plugins, by plugin ID.
+	canaries   map[string]*plugins.PluginBase
+	canariesMu sync.Mutex
+	// dependencyGraph is the dependency graph of the plugins as of their last validation. refusedPlugins are the
+	// plugins refused because their dependencies aren't satisfied, by plugin ID.
+	dependencyGraph plugins.PluginDependencyGraph
+	refusedPlugins  map[string]*plugins.PluginBase
+	dependenciesMu  sync.Mutex
+	// accessControl registers the roles declared by plugins.
+	accessControl accesscontrol.AccessControl
 }
 
-func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, backendPM backendplugin.Manager) (*PluginManager, error) {
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, backendPM backendplugin.Manager,
+	ac accesscontrol.AccessControl) (*PluginManager, error) {
 	pm := newManager(cfg, sqlStore, backendPM)
+	pm.accessControl = ac
 	if err := pm.init(); err != nil {
 		return nil, err
 	}
+	bus.AddEventListener(pm.handlePluginLifecycleEvent)
 	return pm, nil
 }
 
 func newManager(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, backendPM backendplugin.Manager) *PluginManager {
-	return &PluginManager{
+	pm := &PluginManager{
 		Cfg:                  cfg,
 		SQLStore:             sqlStore,
 		BackendPluginManager: backendPM,
-		dataSources:          map[string]*plugins.DataSourcePlugin{},
-		plugins:              map[string]*plugins.PluginBase{},
-		panels:               map[string]*plugins.PanelPlugin{},
-		apps:                 map[string]*plugins.AppPlugin{},
 		pluginScanningErrors: map[string]plugins.PluginError{},
+		pluginLoadErrors:     map[string]plugins.PluginError{},
+		initFailures:         map[string]plugins.InitFailure{},
 		log:                  log.New("plugins"),
+		instanceID:           util.GenerateShortUID(),
+	}
+	if sqlStore != nil {
+		pm.installationStore = sqlStore
+		pm.lifecycleEventStore = sqlStore
+		pm.leaderStore = sqlStore
 	}
+	return pm
 }
 
 func (pm *PluginManager) init() error {
@@ -116,14 +164,23 @@ func (pm *PluginManager) init() error {
 	}
 	if exists {
 		if err := pm.scan(plugDir, false); err != nil {
-			return errutil.Wrapf(err, "failed to scan bundled plugins directory '%s'", plugDir)
+			pm.recordInitFailure(plugDir, "", plugins.InitStageScan, err)
 		}
 	}
 
-	return pm.initExternalPlugins()
+	pm.recordPluginsDirGeneration()
+	if err := pm.initExternalPlugins(); err != nil {
+		return err
+	}
+
+	pm.loadCanaries()
+	return nil
 }
 
 func (pm *PluginManager) initExternalPlugins() error {
+	pm.loadMu.Lock()
+	defer pm.loadMu.Unlock()
+
 	// check if plugins dir exists
 	exists, err := fs.Exists(pm.Cfg.PluginsPath)
 	if err != nil {
@@ -139,50 +196,52 @@ func (pm *PluginManager) initExternalPlugins() error {
 	} else {
 		pm.log.Debug("Scanning external plugins directory", "dir", pm.Cfg.PluginsPath)
 		if err := pm.scan(pm.Cfg.PluginsPath, true); err != nil {
-			return errutil.Wrapf(err, "failed to scan external plugins directory '%s'",
-				pm.Cfg.PluginsPath)
+			pm.recordInitFailure(pm.Cfg.PluginsPath, "", plugins.InitStageScan, err)
 		}
 	}
 
-	if err := pm.scanPluginPaths(); err != nil {
-		return err
-	}
+	pm.scanPluginPaths()
 
-	var staticRoutesList []*plugins.PluginStaticRoute
-	for _, panel := range pm.Panels() {
-		staticRoutes := panel.InitFrontendPlugin(pm.Cfg)
-		staticRoutesList = append(staticRoutesList, staticRoutes...)
-	}
+	pm.validateDependencies(context.Background())
+	return nil
+}
 
-	for _, ds := range pm.DataSources() {
-		staticRoutes := ds.InitFrontendPlugin(pm.Cfg)
-		staticRoutesList = append(staticRoutesList, staticRoutes...)
+func (pm *PluginManager) Run(ctx context.Context) error {
+	var leaderTicks <-chan time.Time
+	if interval := pm.leaderElectionInterval(); interval > 0 {
+		pm.electLeader(ctx)
+		defer pm.resignLeader()
+		leaderTicker := time.NewTicker(interval)
+		defer leaderTicker.Stop()
+		leaderTicks = leaderTicker.C
 	}
 
-	for _, app := range pm.Apps() {
-		staticRoutes := app.InitApp(pm.panels, pm.dataSources, pm.Cfg)
-		staticRoutesList = append(staticRoutesList, staticRoutes...)
+	if pm.IsLeader() {
+		pm.checkForUpdates()
 	}
 
-	if pm.Renderer() != nil {
-		staticRoutes := pm.renderer.InitFrontendPlugin(pm.Cfg)
-		staticRoutesList = append(staticRoutesList, staticRoutes...)
+	var syncTicks <-chan time.Time
+	if interval := pm.installationSyncInterval(); interval > 0 {
+		pm.syncInstallations(ctx)
+		syncTicker := time.NewTicker(interval)
+		defer syncTicker.Stop()
+		syncTicks = syncTicker.C
 	}
-	pm.staticRoutes = staticRoutesList
 
-	for _, p := range pm.Plugins() {
-		if p.IsCorePlugin {
-			p.Signature = plugins.PluginSignatureInternal
-		} else {
-			metrics.SetPluginBuildInformation(p.Id, p.Type, p.Info.Version, string(p.Signature))
-		}
+	var clusterEventTicks <-chan time.Time
+	if interval := pm.clusterEventsInterval(); interval > 0 {
+		pm.initClusterEvents(ctx)
+		clusterEventTicker := time.NewTicker(interval)
+		defer clusterEventTicker.Stop()
+		clusterEventTicks = clusterEventTicker.C
 	}
 
-	return nil
-}
-
-func (pm *PluginManager) Run(ctx context.Context) error {
-	pm.checkForUpdates()
+	var sharedDirTicks <-chan time.Time
+	if interval := pm.sharedPluginsDirInterval(); interval > 0 {
+		sharedDirTicker := time.NewTicker(interval)
+		defer sharedDirTicker.Stop()
+		sharedDirTicks = sharedDirTicker.C
+	}
 
 	ticker := time.NewTicker(time.Minute * 10)
 	run := true
@@ -190,7 +249,19 @@ func (pm *PluginManager) Run(ctx context.Context) error {
 	for run {
 		select {
 		case <-ticker.C:
-			pm.checkForUpdates()
+			if pm.IsLeader() {
+				pm.checkForUpdates()
+			}
+		case <-leaderTicks:
+			if pm.electLeader(ctx) {
+				pm.checkForUpdates()
+			}
+		case <-syncTicks:
+			pm.syncInstallations(ctx)
+		case <-sharedDirTicks:
+			pm.reloadSharedPluginsDir(ctx)
+		case <-clusterEventTicks:
+			pm.publishClusterEvents(ctx)
 		case <-ctx.Done():
 			run = false
 		}
@@ -200,25 +271,17 @@ func (pm *PluginManager) Run(ctx context.Context) error {
 }
 
 func (pm *PluginManager) Renderer() *plugins.RendererPlugin {
-	pm.pluginsMu.RLock()
-	defer pm.pluginsMu.RUnlock()
-
-	return pm.renderer
+	return pm.registry().renderer
 }
 
 func (pm *PluginManager) GetDataSource(id string) *plugins.DataSourcePlugin {
-	pm.pluginsMu.RLock()
-	defer pm.pluginsMu.RUnlock()
-
-	return pm.dataSources[id]
+	registry := pm.registry()
+	return registry.dataSources[pm.resolveAlias(registry, id)]
 }
 
 func (pm *PluginManager) DataSources() []*plugins.DataSourcePlugin {
-	pm.pluginsMu.RLock()
-	defer pm.pluginsMu.RUnlock()
-
 	var rslt []*plugins.DataSourcePlugin
-	for _, ds := range pm.dataSources {
+	for _, ds := range pm.registry().dataSources {
 		rslt = append(rslt, ds)
 	}
 
@@ -226,44 +289,40 @@ func (pm *PluginManager) DataSources() []*plugins.DataSourcePlugin {
 }
 
 func (pm *PluginManager) DataSourceCount() int {
-	pm.pluginsMu.RLock()
-	defer pm.pluginsMu.RUnlock()
-
-	return len(pm.dataSources)
+	return len(pm.registry().dataSources)
 }
 
 func (pm *PluginManager) PanelCount() int {
-	pm.pluginsMu.RLock()
-	defer pm.pluginsMu.RUnlock()
-
-	return len(pm.panels)
+	return len(pm.registry().panels)
 }
 
 func (pm *PluginManager) AppCount() int {
-	pm.pluginsMu.RLock()
-	defer pm.pluginsMu.RUnlock()
-
-	return len(pm.apps)
+	return len(pm.registry().apps)
 }
 
-func (pm *PluginManager) Plugins() []*plugins.PluginBase {
-	pm.pluginsMu.RLock()
-	defer pm.pluginsMu.RUnlock()
+// Plugins returns all plugins, or the plugins of the given types, sorted by ID.
+func (pm *PluginManager) Plugins(pluginTypes ...string) []*plugins.PluginBase {
+	registry := pm.registry()
+	if len(pluginTypes) == 0 {
+		return append([]*plugins.PluginBase(nil), registry.sortedPlugins...)
+	}
 
 	var rslt []*plugins.PluginBase
-	for _, p := range pm.plugins {
-		rslt = append(rslt, p)
+	for _, pluginType := range pluginTypes {
+		rslt = append(rslt, registry.pluginsByType[pluginType]...)
+	}
+	if len(pluginTypes) > 1 {
+		sort.Slice(rslt, func(i, j int) bool {
+			return rslt[i].Id < rslt[j].Id
+		})
 	}
 
 	return rslt
 }
 
 func (pm *PluginManager) Apps() []*plugins.AppPlugin {
-	pm.pluginsMu.RLock()
-	defer pm.pluginsMu.RUnlock()
-
 	var rslt []*plugins.AppPlugin
-	for _, p := range pm.apps {
+	for _, p := range pm.registry().apps {
 		rslt = append(rslt, p)
 	}
 
@@ -271,11 +330,8 @@ func (pm *PluginManager) Apps() []*plugins.AppPlugin {
 }
 
 func (pm *PluginManager) Panels() []*plugins.PanelPlugin {
-	pm.pluginsMu.RLock()
-	defer pm.pluginsMu.RUnlock()
-
 	var rslt []*plugins.PanelPlugin
-	for _, p := range pm.panels {
+	for _, p := range pm.registry().panels {
 		rslt = append(rslt, p)
 	}
 
@@ -283,17 +339,32 @@ func (pm *PluginManager) Panels() []*plugins.PanelPlugin {
 }
 
 func (pm *PluginManager) GetPlugin(id string) *plugins.PluginBase {
-	pm.pluginsMu.RLock()
-	defer pm.pluginsMu.RUnlock()
-
-	return pm.plugins[id]
+	registry := pm.registry()
+	return registry.plugins[pm.resolveAlias(registry, id)]
 }
 
 func (pm *PluginManager) GetApp(id string) *plugins.AppPlugin {
-	pm.pluginsMu.RLock()
-	defer pm.pluginsMu.RUnlock()
+	registry := pm.registry()
+	return registry.apps[pm.resolveAlias(registry, id)]
+}
+
+func (pm *PluginManager) GetPanel(id string) *plugins.PanelPlugin {
+	registry := pm.registry()
+	return registry.panels[pm.resolveAlias(registry, id)]
+}
+
+// resolveAlias returns the ID of the plugin that is loaded in place of the plugin with the provided ID, as
+// configured for renamed or forked plugins. The provided ID is returned if a plugin with that ID is registered,
+// so an installed plugin always takes precedence over an alias, or if no alias is configured.
+func (pm *PluginManager) resolveAlias(registry *pluginRegistry, id string) string {
+	if _, exists := registry.plugins[id]; exists {
+		return id
+	}
+	if aliasedID, exists := pm.Cfg.PluginIDAliases[id]; exists {
+		return aliasedID
+	}
 
-	return pm.apps[id]
+	return id
 }
 
 func (pm *PluginManager) GrafanaLatestVersion() string {
@@ -305,24 +376,28 @@ func (pm *PluginManager) GrafanaHasUpdate() bool {
 }
 
 // scanPluginPaths scans configured plugin paths.
-func (pm *PluginManager) scanPluginPaths() error {
-	for pluginID, settings := range pm.Cfg.PluginSettings {
+func (pm *PluginManager) scanPluginPaths() {
+	for pluginID, settings := range pm.Cfg.AllPluginSettings() {
 		path, exists := settings["path"]
 		if !exists || path == "" {
 			continue
 		}
 
 		if err := pm.scan(path, true); err != nil {
-			return errutil.Wrapf(err, "failed to scan directory configured for plugin '%s': '%s'", pluginID, path)
+			pm.recordInitFailure(path, pluginID, plugins.InitStageScan, err)
 		}
 	}
-
-	return nil
 }
 
-// scan a directory for plugins.
-func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
-	scanner := &PluginScanner{
+// newScanner creates a scanner for a plugin directory, honoring the configured scan depth and any
+// .pluginignore file found at the root of the directory.
+func (pm *PluginManager) newScanner(pluginDir string, requireSigned bool) *PluginScanner {
+	ignorePatterns, err := readPluginIgnoreFile(pluginDir)
+	if err != nil {
+		pm.log.Warn("Failed to read plugin ignore file", "pluginDir", pluginDir, "err", err)
+	}
+
+	return &PluginScanner{
 		pluginPath:                    pluginDir,
 		backendPluginManager:          pm.BackendPluginManager,
 		cfg:                           pm.Cfg,
@@ -330,7 +405,14 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 		log:                           pm.log,
 		plugins:                       map[string]*plugins.PluginBase{},
 		allowUnsignedPluginsCondition: pm.AllowUnsignedPluginsCondition,
+		maxDepth:                      pm.Cfg.PluginsScanMaxDepth,
+		ignorePatterns:                ignorePatterns,
 	}
+}
+
+// scan a directory for plugins.
+func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
+	scanner := pm.newScanner(pluginDir, requireSigned)
 
 	// 1st pass: Scan plugins, also mapping plugins to their respective directories
 	if err := util.Walk(pluginDir, true, true, scanner.walker); err != nil {
@@ -347,6 +429,11 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 		}
 		return err
 	}
+	pm.clearInitFailure(pluginDir)
+
+	for _, loadErr := range scanner.loadErrors {
+		pm.setLoadError(loadErr)
+	}
 
 	pm.log.Debug("Initial plugin loading done")
 
@@ -376,37 +463,67 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 		"renderer":   plugins.RendererPlugin{},
 	}
 
-	// 2nd pass: Validate and register plugins
+	// 2nd pass: Validate and load plugins, which are then registered at once. Plugins that fail are skipped and
+	// recorded as init failures.
+	batch := &registryBatch{}
 	for dpath, plugin := range scanner.plugins {
-		// Try to find any root plugin
-		ancestors := strings.Split(dpath, string(filepath.Separator))
-		ancestors = ancestors[0 : len(ancestors)-1]
-		aPath := ""
-		if runtime.GOOS != "windows" && filepath.IsAbs(dpath) {
-			aPath = "/"
-		}
-		for _, a := range ancestors {
-			aPath = filepath.Join(aPath, a)
-			if root, ok := scanner.plugins[aPath]; ok {
-				plugin.Root = root
-				break
-			}
-		}
+		plugin.Root = scanner.findRoot(dpath)
 
 		pm.log.Debug("Found plugin", "id", plugin.Id, "signature", plugin.Signature, "hasRoot", plugin.Root != nil)
 		signingError := scanner.validateSignature(plugin)
 		if signingError != nil {
 			pm.log.Debug("Failed to validate plugin signature. Will skip loading", "id", plugin.Id,
 				"signature", plugin.Signature, "status", signingError.ErrorCode)
-			pm.pluginScanningErrors[plugin.Id] = *signingError
+			signingError.PluginID = plugin.Id
+			signingError.Path = plugin.PluginDir
+			pm.setScanningError(*signingError)
 			continue
 		}
 
+		// The plugin is still loaded if its executable is missing, as its frontend may work without it, but the
+		// error is reported since its backend can't be started.
+		if !strings.HasPrefix(plugin.PluginDir, pm.Cfg.StaticRootPath) {
+			if err := checkPluginExecutable(plugin); err != nil {
+				pm.log.Warn("Plugin executable is missing", "id", plugin.Id, "error", err)
+				pm.setLoadError(plugins.PluginError{
+					ErrorCode: missingExecutable,
+					PluginID:  plugin.Id,
+					Path:      plugin.PluginDir,
+					Message:   err.Error(),
+				})
+			}
+		}
+
+		// Angular is only detected for external plugins, as the modules of core plugins are bundled
+		if !strings.HasPrefix(plugin.PluginDir, pm.Cfg.StaticRootPath) {
+			pm.clearLoadError(plugin.PluginDir, angularBlocked)
+
+			angularDetected, err := detectAngular(plugin.PluginDir)
+			if err != nil {
+				pm.recordInitFailure(plugin.PluginDir, plugin.Id, plugins.InitStageLoad, err)
+				continue
+			}
+			plugin.AngularDetected = angularDetected
+
+			if angularDetected && pm.Cfg.PluginsBlockAngular {
+				pm.log.Warn("Refusing plugin using Angular", "id", plugin.Id)
+				pm.setLoadError(plugins.PluginError{
+					ErrorCode: angularBlocked,
+					PluginID:  plugin.Id,
+					Path:      plugin.PluginDir,
+					Message:   "plugin uses Angular, which is blocked by the configuration",
+				})
+				continue
+			}
+		}
+
 		pm.log.Debug("Attempting to add plugin", "id", plugin.Id)
 
 		pluginGoType, exists := pluginTypes[plugin.Type]
 		if !exists {
-			return fmt.Errorf("unknown plugin type %q", plugin.Type)
+			pm.recordInitFailure(plugin.PluginDir, plugin.Id, plugins.InitStageLoad,
+				fmt.Errorf("unknown plugin type %q", plugin.Type))
+			continue
 		}
 
 		jsonFPath := filepath.Join(plugin.PluginDir, "plugin.json")
@@ -416,7 +533,8 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 			module := filepath.Join(plugin.PluginDir, "module.js")
 			exists, err := fs.Exists(module)
 			if err != nil {
-				return err
+				pm.recordInitFailure(plugin.PluginDir, plugin.Id, plugins.InitStageLoad, err)
+				continue
 			}
 			if !exists {
 				scanner.log.Warn("Plugin missing module.js",
@@ -431,7 +549,8 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 		// on plugin the folder structure on disk and not user input.
 		reader, err := os.Open(jsonFPath)
 		if err != nil {
-			return err
+			pm.recordInitFailure(plugin.PluginDir, plugin.Id, plugins.InitStageLoad, err)
+			continue
 		}
 		defer func() {
 			if err := reader.Close(); err != nil {
@@ -444,10 +563,13 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 		loader := reflect.New(reflect.TypeOf(pluginGoType)).Interface().(plugins.PluginLoader)
 
 		// Load the full plugin, and add it to manager
-		if err := pm.loadPlugin(jsonParser, plugin, scanner, loader); err != nil {
-			return err
+		if err := pm.loadPlugin(jsonParser, plugin, scanner, loader, batch); err != nil {
+			pm.recordInitFailure(plugin.PluginDir, plugin.Id, plugins.InitStageRegister, err)
+			continue
 		}
+		pm.clearInitFailure(plugin.PluginDir)
 	}
+	pm.registerBatch(batch)
 
 	if len(scanner.errors) > 0 {
 		var errStr []string
@@ -455,35 +577,85 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 			errStr = append(errStr, err.Error())
 		}
 		pm.log.Warn("Some plugin scanning errors were found", "errors", strings.Join(errStr, ", "))
+		pm.pluginErrorsMu.Lock()
 		pm.scanningErrors = scanner.errors
+		pm.pluginErrorsMu.Unlock()
 	}
 
 	return nil
 }
 
+// Scan scans the provided directories and reports which plugins would be loaded from them, including their
+// signature state and any validation errors. Directories whose plugin.json couldn't be loaded are reported with
+// their error only. Unsigned plugins are only refused when requireSigned is set. Nothing is registered or started.
+func (pm *PluginManager) Scan(pluginDirs []string, requireSigned bool) ([]plugins.ScannedPlugin, error) {
+	var result []plugins.ScannedPlugin
+	for _, pluginDir := range pluginDirs {
+		scanner := pm.newScanner(pluginDir, requireSigned)
+
+		if err := util.Walk(pluginDir, true, true, scanner.walker); err != nil {
+			return nil, errutil.Wrapf(err, "failed to scan directory '%s'", pluginDir)
+		}
+
+		for i := range scanner.loadErrors {
+			loadErr := scanner.loadErrors[i]
+			result = append(result, plugins.ScannedPlugin{
+				PluginDir: loadErr.Path,
+				Error:     &loadErr,
+			})
+		}
+
+		for dpath, plugin := range scanner.plugins {
+			plugin.Root = scanner.findRoot(dpath)
+
+			scanned := plugins.ScannedPlugin{
+				ID:        plugin.Id,
+				Type:      plugin.Type,
+				Version:   plugin.Info.Version,
+				PluginDir: plugin.PluginDir,
+			}
+
+			if signingError := scanner.validateSignature(plugin); signingError != nil {
+				scanned.Error = &plugins.PluginError{ErrorCode: signingError.ErrorCode, PluginID: plugin.Id}
+			} else if existing := pm.GetPlugin(plugin.Id); existing != nil {
+				scanned.AlreadyLoaded = true
+			}
+			scanned.Signature = plugin.Signature
+			scanned.SignatureType = plugin.SignatureType
+			scanned.SignatureOrg = plugin.SignatureOrg
+
+			result = append(result, scanned)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PluginDir < result[j].PluginDir
+	})
+
+	return result, nil
+}
+
 func (pm *PluginManager) loadPlugin(jsonParser *json.Decoder, pluginBase *plugins.PluginBase,
-	scanner *PluginScanner, loader plugins.PluginLoader) error {
+	scanner *PluginScanner, loader plugins.PluginLoader, batch *registryBatch) error {
 	plug, err := loader.Load(jsonParser, pluginBase, scanner.backendPluginManager)
 	if err != nil {
 		return err
 	}
 
-	pm.pluginsMu.Lock()
-	defer pm.pluginsMu.Unlock()
-
 	var pb *plugins.PluginBase
+	var register func()
 	switch p := plug.(type) {
 	case *plugins.DataSourcePlugin:
-		pm.dataSources[p.Id] = p
+		register = func() { batch.dataSources = append(batch.dataSources, p) }
 		pb = &p.PluginBase
 	case *plugins.PanelPlugin:
-		pm.panels[p.Id] = p
+		register = func() { batch.panels = append(batch.panels, p) }
 		pb = &p.PluginBase
 	case *plugins.RendererPlugin:
-		pm.renderer = p
+		register = func() { batch.renderer = p }
 		pb = &p.PluginBase
 	case *plugins.AppPlugin:
-		pm.apps[p.Id] = p
+		register = func() { batch.apps = append(batch.apps, p) }
 		pb = &p.PluginBase
 	default:
 		panic(fmt.Sprintf("Unrecognized plugin type %T", plug))
@@ -513,9 +685,15 @@ func (pm *PluginManager) loadPlugin(jsonParser *json.Decoder, pluginBase *plugin
 	pb.SignatureType = pluginBase.SignatureType
 	pb.SignatureOrg = pluginBase.SignatureOrg
 	pb.SignedFiles = pluginBase.SignedFiles
+	pb.AngularDetected = pluginBase.AngularDetected
+
+	if err := pm.declareRoles(pb); err != nil {
+		return fmt.Errorf("failed to declare roles: %w", err)
+	}
 
-	pm.plugins[pb.Id] = pb
+	register()
 	pm.log.Debug("Successfully added plugin", "id", pb.Id)
+	pm.warnIfDeprecated(pb)
 	return nil
 }
 
@@ -531,7 +709,28 @@ func (s *PluginScanner) walker(currentPath string, f os.FileInfo, err error) err
 		return util.ErrWalkSkipDir
 	}
 
+	// plugins which are being installed, or whose install was interrupted, are extracted in staging directories
+	if f.IsDir() && strings.HasPrefix(f.Name(), installer.StagingDirPrefix) {
+		return util.ErrWalkSkipDir
+	}
+
+	// canary versions of plugins are loaded separately
+	if f.IsDir() && f.Name() == canariesDirName {
+		return util.ErrWalkSkipDir
+	}
+
+	if s.isIgnored(currentPath) {
+		s.log.Debug("Skipping ignored path", "path", currentPath)
+		if f.IsDir() {
+			return util.ErrWalkSkipDir
+		}
+		return nil
+	}
+
 	if f.IsDir() {
+		if s.maxDepth > 0 && s.depth(currentPath) > s.maxDepth {
+			return util.ErrWalkSkipDir
+		}
 		return nil
 	}
 
@@ -542,6 +741,17 @@ func (s *PluginScanner) walker(currentPath string, f os.FileInfo, err error) err
 	if err := s.loadPlugin(currentPath); err != nil {
 		s.log.Error("Failed to load plugin", "error", err, "pluginPath", filepath.Dir(currentPath))
 		s.errors = append(s.errors, err)
+
+		code := invalidPluginJSON
+		var loadErr pluginLoadError
+		if errors.As(err, &loadErr) {
+			code = loadErr.code
+		}
+		s.loadErrors = append(s.loadErrors, plugins.PluginError{
+			ErrorCode: code,
+			Path:      filepath.Dir(currentPath),
+			Message:   err.Error(),
+		})
 	}
 
 	return nil
@@ -566,18 +776,18 @@ func (s *PluginScanner) loadPlugin(pluginJSONFilePath string) error {
 	jsonParser := json.NewDecoder(reader)
 	pluginCommon := plugins.PluginBase{}
 	if err := jsonParser.Decode(&pluginCommon); err != nil {
-		return err
+		return pluginLoadError{code: invalidPluginJSON, err: err}
 	}
 
 	if pluginCommon.Id == "" || pluginCommon.Type == "" {
-		return errors.New("did not find type or id properties in plugin.json")
+		return pluginLoadError{code: invalidPluginJSON, err: errors.New("did not find type or id properties in plugin.json")}
 	}
 
 	pluginCommon.PluginDir = filepath.Dir(pluginJSONFilePath)
 	signatureState, err := getPluginSignatureState(s.log, &pluginCommon)
 	if err != nil {
 		s.log.Warn("Could not get plugin signature state", "pluginID", pluginCommon.Id, "err", err)
-		return err
+		return pluginLoadError{code: signatureInvalid, err: err}
 	}
 	pluginCommon.Signature = signatureState.Status
 	pluginCommon.SignatureType = signatureState.Type
@@ -589,6 +799,85 @@ func (s *PluginScanner) loadPlugin(pluginJSONFilePath string) error {
 	return nil
 }
 
+// depth returns how many directory levels path is below the scanned plugin directory. Paths outside of it,
+// such as resolved symbolic links, are considered to be at the top level.
+func (s *PluginScanner) depth(path string) int {
+	rel, err := filepath.Rel(s.pluginPath, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return 0
+	}
+
+	return len(strings.Split(rel, string(filepath.Separator)))
+}
+
+// isIgnored returns whether path matches any of the patterns from the scanned directory's .pluginignore file.
+// Patterns are matched against both the path relative to the scanned directory and the base name.
+func (s *PluginScanner) isIgnored(path string) bool {
+	if len(s.ignorePatterns) == 0 {
+		return false
+	}
+
+	rel, err := filepath.Rel(s.pluginPath, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, pattern := range s.ignorePatterns {
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readPluginIgnoreFile reads the glob patterns listed in the .pluginignore file of pluginDir, if any.
+// Empty lines and lines starting with # are skipped.
+func readPluginIgnoreFile(pluginDir string) ([]string, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `pluginDir` comes from configuration.
+	data, err := ioutil.ReadFile(filepath.Join(pluginDir, pluginIgnoreFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, strings.TrimSuffix(line, "/"))
+	}
+
+	return patterns, nil
+}
+
+// findRoot returns the closest ancestor plugin of the plugin located at pluginDir, if any.
+func (s *PluginScanner) findRoot(pluginDir string) *plugins.PluginBase {
+	ancestors := strings.Split(pluginDir, string(filepath.Separator))
+	ancestors = ancestors[0 : len(ancestors)-1]
+	aPath := ""
+	if runtime.GOOS != "windows" && filepath.IsAbs(pluginDir) {
+		aPath = "/"
+	}
+	for _, a := range ancestors {
+		aPath = filepath.Join(aPath, a)
+		if root, ok := s.plugins[aPath]; ok {
+			return root
+		}
+	}
+
+	return nil
+}
+
 func (*PluginScanner) IsBackendOnlyPlugin(pluginType string) bool {
 	return pluginType == "renderer"
 }
@@ -627,9 +916,11 @@ func (s *PluginScanner) validateSignature(plugin *plugins.PluginBase) *plugins.P
 	case plugins.PluginSignatureUnsigned:
 		if allowed := s.allowUnsigned(plugin); !allowed {
 			s.log.Debug("Plugin is unsigned", "pluginID", plugin.Id)
-			s.errors = append(s.errors, fmt.Errorf("plugin '%s' is unsigned", plugin.Id))
+			err := fmt.Errorf("plugin '%s' is unsigned", plugin.Id)
+			s.errors = append(s.errors, err)
 			return &plugins.PluginError{
 				ErrorCode: signatureMissing,
+				Message:   err.Error(),
 			}
 		}
 		s.log.Warn("Running an unsigned plugin", "pluginID", plugin.Id, "pluginDir",
@@ -637,15 +928,19 @@ func (s *PluginScanner) validateSignature(plugin *plugins.PluginBase) *plugins.P
 		return nil
 	case plugins.PluginSignatureInvalid:
 		s.log.Debug("Plugin has an invalid signature", "pluginID", plugin.Id)
-		s.errors = append(s.errors, fmt.Errorf("plugin '%s' has an invalid signature", plugin.Id))
+		err := fmt.Errorf("plugin '%s' has an invalid signature", plugin.Id)
+		s.errors = append(s.errors, err)
 		return &plugins.PluginError{
 			ErrorCode: signatureInvalid,
+			Message:   err.Error(),
 		}
 	case plugins.PluginSignatureModified:
 		s.log.Debug("Plugin has a modified signature", "pluginID", plugin.Id)
-		s.errors = append(s.errors, fmt.Errorf("plugin '%s' has a modified signature", plugin.Id))
+		err := fmt.Errorf("plugin '%s' has a modified signature", plugin.Id)
+		s.errors = append(s.errors, err)
 		return &plugins.PluginError{
 			ErrorCode: signatureModified,
+			Message:   err.Error(),
 		}
 	default:
 		panic(fmt.Sprintf("Plugin '%s' has an unrecognized plugin signature state '%s'", plugin.Id, plugin.Signature))
@@ -670,34 +965,122 @@ func (s *PluginScanner) allowUnsigned(plugin *plugins.PluginBase) bool {
 	return false
 }
 
-// ScanningErrors returns plugin scanning errors encountered.
+// ScanningErrors returns plugin scanning errors encountered, including errors of plugins whose plugin.json
+// couldn't be loaded.
 func (pm *PluginManager) ScanningErrors() []plugins.PluginError {
-	scanningErrs := make([]plugins.PluginError, 0)
+	pm.pluginErrorsMu.Lock()
+	defer pm.pluginErrorsMu.Unlock()
+
+	scanningErrs := make([]plugins.PluginError, 0, len(pm.pluginScanningErrors)+len(pm.pluginLoadErrors))
 	for id, e := range pm.pluginScanningErrors {
 		scanningErrs = append(scanningErrs, plugins.PluginError{
 			ErrorCode: e.ErrorCode,
 			PluginID:  id,
+			Path:      e.Path,
+			Message:   e.Message,
 		})
 	}
+	for _, e := range pm.pluginLoadErrors {
+		scanningErrs = append(scanningErrs, e)
+	}
 	return scanningErrs
 }
 
-func (pm *PluginManager) GetPluginMarkdown(pluginId string, name string) ([]byte, error) {
-	plug, exists := pm.plugins[pluginId]
+// setScanningError records the signature error of a plugin, by plugin ID.
+func (pm *PluginManager) setScanningError(e plugins.PluginError) {
+	pm.pluginErrorsMu.Lock()
+	defer pm.pluginErrorsMu.Unlock()
+
+	pm.pluginScanningErrors[e.PluginID] = e
+}
+
+// setLoadError records the error of a plugin that couldn't be loaded, by plugin directory.
+func (pm *PluginManager) setLoadError(e plugins.PluginError) {
+	pm.pluginErrorsMu.Lock()
+	defer pm.pluginErrorsMu.Unlock()
+
+	pm.pluginLoadErrors[e.Path] = e
+}
+
+// clearLoadError removes the load error of the plugin directory dir if it has one of codes, or any error if no
+// code is provided.
+func (pm *PluginManager) clearLoadError(dir string, codes ...plugins.ErrorCode) {
+	pm.pluginErrorsMu.Lock()
+	defer pm.pluginErrorsMu.Unlock()
+
+	e, exists := pm.pluginLoadErrors[dir]
 	if !exists {
+		return
+	}
+	if len(codes) == 0 {
+		delete(pm.pluginLoadErrors, dir)
+		return
+	}
+	for _, code := range codes {
+		if e.ErrorCode == code {
+			delete(pm.pluginLoadErrors, dir)
+			return
+		}
+	}
+}
+
+// pluginExecutable is the part of plugin.json describing the executable of a backend plugin.
+type pluginExecutable struct {
+	Backend    bool   `json:"backend"`
+	Executable string `json:"executable"`
+}
+
+// checkPluginExecutable returns an error if plugin is a backend plugin whose executable for the current OS and
+// architecture is missing.
+func checkPluginExecutable(plugin *plugins.PluginBase) error {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `plugin.PluginDir` is based
+	// on plugin the folder structure on disk and not user input.
+	data, err := ioutil.ReadFile(filepath.Join(plugin.PluginDir, "plugin.json"))
+	if err != nil {
+		return err
+	}
+
+	var exe pluginExecutable
+	if err := json.Unmarshal(data, &exe); err != nil {
+		return err
+	}
+	if exe.Executable == "" || (!exe.Backend && plugin.Type != "renderer") {
+		return nil
+	}
+
+	path := filepath.Join(plugin.PluginDir, plugins.ComposePluginStartCommand(exe.Executable))
+	exists, err := fs.Exists(path)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("plugin executable %q not found", path)
+	}
+
+	return nil
+}
+
+// GetPluginMarkdown gets a markdown file of a plugin from its directory. Files not included in the signature of
+// signed plugins aren't returned, since they could have been tampered with.
+func (pm *PluginManager) GetPluginMarkdown(pluginId string, name string) ([]byte, error) {
+	plug := pm.GetPlugin(pluginId)
+	if plug == nil {
 		return nil, plugins.PluginNotFoundError{PluginID: pluginId}
 	}
 
 	// nolint:gosec
 	// We can ignore the gosec G304 warning on this one because `plug.PluginDir` is based
 	// on plugin the folder structure on disk and not user input.
-	path := filepath.Join(plug.PluginDir, fmt.Sprintf("%s.md", strings.ToUpper(name)))
+	fileName := fmt.Sprintf("%s.md", strings.ToUpper(name))
+	path := filepath.Join(plug.PluginDir, fileName)
 	exists, err := fs.Exists(path)
 	if err != nil {
 		return nil, err
 	}
 	if !exists {
-		path = filepath.Join(plug.PluginDir, fmt.Sprintf("%s.md", strings.ToLower(name)))
+		fileName = fmt.Sprintf("%s.md", strings.ToLower(name))
+		path = filepath.Join(plug.PluginDir, fileName)
 	}
 
 	exists, err = fs.Exists(path)
@@ -708,6 +1091,10 @@ func (pm *PluginManager) GetPluginMarkdown(pluginId string, name string) ([]byte
 		return make([]byte, 0), nil
 	}
 
+	if !plug.IncludedInSignature(fileName) {
+		return nil, plugins.ErrPluginMarkdownNotSigned
+	}
+
 	// nolint:gosec
 	// We can ignore the gosec G304 warning on this one because `plug.PluginDir` is based
 	// on plugin the folder structure on disk and not user input.
@@ -718,11 +1105,28 @@ func (pm *PluginManager) GetPluginMarkdown(pluginId string, name string) ([]byte
 	return data, nil
 }
 
+// StaticRoutes returns the static routes of all plugins, which are kept up to date when plugins are registered
+// and unregistered.
 func (pm *PluginManager) StaticRoutes() []*plugins.PluginStaticRoute {
-	return pm.staticRoutes
+	return pm.registry().staticRoutes
+}
+
+// Install installs a plugin, or upgrades it to version. Replacing the plugin with a lower version requires
+// opts.AllowDowngrade. It waits for other install and uninstall operations of the plugin to complete.
+func (pm *PluginManager) Install(ctx context.Context, pluginID, version string, opts plugins.InstallOpts) error {
+	unlock := pm.installLocks.lock(pluginID)
+	defer unlock()
+
+	if err := pm.modifyPluginsDir(func() error { return pm.install(ctx, pluginID, version, opts) }); err != nil {
+		return err
+	}
+
+	pm.recordInstallation(ctx, pluginID, true)
+	pm.publishLifecycleEvent(pluginID, models.PluginInstalled)
+	return nil
 }
 
-func (pm *PluginManager) Install(ctx context.Context, pluginID, version string) error {
+func (pm *PluginManager) install(ctx context.Context, pluginID, version string, opts plugins.InstallOpts) error {
 	plugin := pm.GetPlugin(pluginID)
 
 	var pluginZipURL string
@@ -731,7 +1135,7 @@ func (pm *PluginManager) Install(ctx context.Context, pluginID, version string)
 			return plugins.ErrInstallCorePlugin
 		}
 
-		if plugin.Info.Version == version {
+		if plugins.VersionsEqual(plugin.Info.Version, version) {
 			return plugins.DuplicatePluginError{
 				PluginID:          pluginID,
 				ExistingPluginDir: plugin.PluginDir,
@@ -744,10 +1148,29 @@ func (pm *PluginManager) Install(ctx context.Context, pluginID, version string)
 			return err
 		}
 
+		// the update information resolves the latest version if none is requested
+		if updateInfo.Version != "" {
+			version = updateInfo.Version
+		}
+		if plugins.VersionsEqual(plugin.Info.Version, version) {
+			return plugins.DuplicatePluginError{
+				PluginID:          pluginID,
+				ExistingPluginDir: plugin.PluginDir,
+			}
+		}
+		if cmp, err := plugins.CompareVersions(version, plugin.Info.Version); err == nil && cmp < 0 &&
+			!opts.AllowDowngrade {
+			return plugins.DowngradeError{
+				PluginID:         pluginID,
+				InstalledVersion: plugin.Info.Version,
+				Version:          version,
+			}
+		}
+
 		pluginZipURL = updateInfo.PluginZipURL
 
-		// remove existing installation of plugin
-		err = pm.Uninstall(context.Background(), plugin.Id)
+		// remove existing installation of plugin, keeping its data directory for the new version
+		err = pm.uninstall(context.Background(), plugin.Id)
 		if err != nil {
 			return err
 		}
@@ -763,10 +1186,66 @@ func (pm *PluginManager) Install(ctx context.Context, pluginID, version string)
 		return err
 	}
 
+	if failure, failed := pm.initFailure(pluginID); failed && pm.GetPlugin(pluginID) == nil {
+		return fmt.Errorf("failed to %s plugin %s: %s", failure.Stage, pluginID, failure.Error)
+	}
+
 	return nil
 }
 
+// EnsureInstalled installs a plugin unless it's installed, replacing the installed version if version is set and
+// differs, even if it's lower. It returns whether the plugin was installed.
+func (pm *PluginManager) EnsureInstalled(ctx context.Context, pluginID, version string) (bool, error) {
+	unlock := pm.installLocks.lock(pluginID)
+	defer unlock()
+
+	if plugin := pm.GetPlugin(pluginID); plugin != nil && (version == "" || plugins.VersionsEqual(plugin.Info.Version,
+		version)) {
+		return false, nil
+	}
+
+	// the version is pinned, so replacing a higher installed version is intended
+	opts := plugins.InstallOpts{AllowDowngrade: true}
+	if err := pm.modifyPluginsDir(func() error { return pm.install(ctx, pluginID, version, opts) }); err != nil {
+		return false, err
+	}
+
+	pm.recordInstallation(ctx, pluginID, true)
+	pm.publishLifecycleEvent(pluginID, models.PluginInstalled)
+	return true, nil
+}
+
+// Uninstall uninstalls a plugin and removes its data directory. It waits for other install and uninstall operations
+// of the plugin to complete.
 func (pm *PluginManager) Uninstall(ctx context.Context, pluginID string) error {
+	unlock := pm.installLocks.lock(pluginID)
+	defer unlock()
+
+	if err := pm.modifyPluginsDir(func() error { return pm.uninstallAndRemoveData(ctx, pluginID) }); err != nil {
+		return err
+	}
+	// the plugins depending on the plugin lose their dependency
+	pm.validateDependencies(ctx)
+
+	pm.recordInstallation(ctx, pluginID, false)
+	pm.publishLifecycleEvent(pluginID, models.PluginUninstalled)
+	return nil
+}
+
+func (pm *PluginManager) uninstallAndRemoveData(ctx context.Context, pluginID string) error {
+	if err := pm.uninstall(ctx, pluginID); err != nil {
+		return err
+	}
+
+	if dataPath := pm.Cfg.PluginDataPath(pluginID); dataPath != "" {
+		if err := os.RemoveAll(filepath.Dir(dataPath)); err != nil {
+			pm.log.Warn("Failed to remove plugin data directory", "pluginId", pluginID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (pm *PluginManager) uninstall(ctx context.Context, pluginID string) error {
 	plugin := pm.GetPlugin(pluginID)
 	if plugin == nil {
 		return plugins.ErrPluginNotInstalled
@@ -777,53 +1256,88 @@ func (pm *PluginManager) Uninstall(ctx context.Context, pluginID string) error {
 	}
 
 	// extra security check to ensure we only remove plugins that are located in the configured plugins directory
-	path, err := filepath.Rel(pm.Cfg.PluginsPath, plugin.PluginDir)
-	if err != nil || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+	if !pm.inPluginsDir(plugin) {
 		return plugins.ErrUninstallOutsideOfPluginDir
 	}
 
-	if pm.BackendPluginManager.IsRegistered(pluginID) {
-		err := pm.BackendPluginManager.UnregisterAndStop(ctx, pluginID)
-		if err != nil {
-			return err
-		}
-	}
-
-	err = pm.unregister(plugin)
-	if err != nil {
+	if err := pm.unload(ctx, plugin); err != nil {
 		return err
 	}
+	// unloading the plugin stopped its canary version, which is replaced by upgrades
+	pm.removeCanary(pluginID)
 
 	return pm.pluginInstaller.Uninstall(ctx, plugin.PluginDir)
 }
 
-func (pm *PluginManager) unregister(plugin *plugins.PluginBase) error {
-	pm.pluginsMu.Lock()
-	defer pm.pluginsMu.Unlock()
+// inPluginsDir returns whether a plugin is located in the configured plugins directory.
+func (pm *PluginManager) inPluginsDir(plugin *plugins.PluginBase) bool {
+	path, err := filepath.Rel(pm.Cfg.PluginsPath, plugin.PluginDir)
+	return err == nil && !strings.HasPrefix(path, ".."+string(filepath.Separator))
+}
 
-	switch plugin.Type {
-	case "panel":
-		delete(pm.panels, plugin.Id)
-	case "datasource":
-		delete(pm.dataSources, plugin.Id)
-	case "app":
-		delete(pm.apps, plugin.Id)
-	case "renderer":
-		pm.renderer = nil
+// unload stops and unregisters a plugin without removing its files. The children of an app plugin are unloaded
+// along with it, as they're bundled with it.
+func (pm *PluginManager) unload(ctx context.Context, plugin *plugins.PluginBase) error {
+	// the registered plugins are copied when they're modified, so the children are looked up by ID
+	for _, child := range plugin.Children {
+		registered := pm.GetPlugin(child.Id)
+		if registered == nil || registered.Parent == nil || registered.Parent.Id != plugin.Id {
+			continue
+		}
+		if err := pm.unload(ctx, registered); err != nil {
+			return err
+		}
 	}
 
-	delete(pm.plugins, plugin.Id)
+	pluginID := plugin.Id
+	// a decommissioned plugin is still registered, and must be unregistered for the plugin to be loaded again
+	if bp, registered := pm.BackendPluginManager.GetRegistered(pluginID); registered {
+		// stop routing new requests to the plugin and give its in-flight requests time to complete, rather than
+		// killing them with its process
+		if !bp.IsDecommissioned() {
+			if err := pm.BackendPluginManager.DecommissionPlugin(ctx, pluginID); err != nil {
+				return err
+			}
+		}
 
-	pm.removeStaticRoute(plugin.Id)
+		err := pm.BackendPluginManager.UnregisterAndStop(ctx, pluginID)
+		if err != nil {
+			return err
+		}
+	}
 
-	return nil
+	return pm.unregister(plugin)
 }
 
-func (pm *PluginManager) removeStaticRoute(pluginID string) {
-	for i, route := range pm.staticRoutes {
-		if pluginID == route.PluginId {
-			pm.staticRoutes = append(pm.staticRoutes[:i], pm.staticRoutes[i+1:]...)
-			return
+func (pm *PluginManager) unregister(plugin *plugins.PluginBase) error {
+	pm.updateRegistry(func(r *pluginRegistry) {
+		if parent := plugin.Parent; parent != nil && r.plugins[parent.Id] != nil {
+			parent = r.copyPlugin(parent.Id)
+			children := make([]*plugins.PluginBase, 0, len(parent.Children))
+			for _, child := range parent.Children {
+				if child.Id != plugin.Id {
+					children = append(children, child)
+				}
+			}
+			parent.Children = children
 		}
-	}
+
+		switch plugin.Type {
+		case "panel":
+			delete(r.panels, plugin.Id)
+		case "datasource":
+			delete(r.dataSources, plugin.Id)
+		case "app":
+			delete(r.apps, plugin.Id)
+		case "renderer":
+			r.renderer = nil
+		}
+
+		delete(r.plugins, plugin.Id)
+		delete(r.staticRoutesByPlugin, plugin.Id)
+	})
+	pm.clearLoadError(plugin.PluginDir)
+	pm.removeRoles(plugin)
+
+	return nil
 }
@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -17,6 +21,7 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/ini.v1"
@@ -58,10 +63,10 @@ func TestPluginManager_Init(t *testing.T) {
 		assert.Empty(t, pm.scanningErrors)
 		verifyCorePluginCatalogue(t, pm)
 
-		assert.NotEmpty(t, pm.apps)
-		assert.Equal(t, "app/plugins/datasource/graphite/module", pm.dataSources["graphite"].Module)
-		assert.Equal(t, "public/plugins/test-app/img/logo_large.png", pm.apps["test-app"].Info.Logos.Large)
-		assert.Equal(t, "public/plugins/test-app/img/screenshot2.png", pm.apps["test-app"].Info.Screenshots[1].Path)
+		assert.NotEmpty(t, pm.registry().apps)
+		assert.Equal(t, "app/plugins/datasource/graphite/module", pm.registry().dataSources["graphite"].Module)
+		assert.Equal(t, "public/plugins/test-app/img/logo_large.png", pm.registry().apps["test-app"].Info.Logos.Large)
+		assert.Equal(t, "public/plugins/test-app/img/screenshot2.png", pm.registry().apps["test-app"].Info.Screenshots[1].Path)
 	})
 
 	t.Run("With external back-end plugin lacking signature (production)", func(t *testing.T) {
@@ -105,7 +110,7 @@ func TestPluginManager_Init(t *testing.T) {
 		const pluginID = "test-panel"
 
 		assert.Equal(t, []error{fmt.Errorf(`plugin '%s' is unsigned`, pluginID)}, pm.scanningErrors)
-		assert.Nil(t, pm.panels[pluginID])
+		assert.Nil(t, pm.registry().panels[pluginID])
 		assert.Nil(t, pm.GetPlugin(pluginID))
 	})
 
@@ -119,7 +124,7 @@ func TestPluginManager_Init(t *testing.T) {
 		pluginID := "test-panel"
 
 		assert.Empty(t, pm.scanningErrors)
-		assert.NotNil(t, pm.panels[pluginID])
+		assert.NotNil(t, pm.registry().panels[pluginID])
 
 		plugin := pm.GetPlugin(pluginID)
 		assert.NotNil(t, plugin)
@@ -197,9 +202,9 @@ func TestPluginManager_Init(t *testing.T) {
 		require.Empty(t, pm.scanningErrors)
 
 		// capture manager plugin state
-		datasources := pm.dataSources
-		panels := pm.panels
-		apps := pm.apps
+		datasources := pm.registry().dataSources
+		panels := pm.registry().panels
+		apps := pm.registry().apps
 
 		verifyPluginManagerState := func() {
 			assert.Empty(t, pm.scanningErrors)
@@ -239,14 +244,14 @@ func TestPluginManager_Init(t *testing.T) {
 				},
 				Module:  "plugins/test/module",
 				BaseUrl: "public/plugins/test",
-			}, pm.plugins[pluginID]); diff != "" {
+			}, pm.registry().plugins[pluginID]); diff != "" {
 				t.Errorf("result mismatch (-want +got) %s\n", diff)
 			}
 
 			ds := pm.GetDataSource(pluginID)
 			assert.NotNil(t, ds)
 			assert.Equal(t, pluginID, ds.Id)
-			assert.Equal(t, pm.plugins[pluginID], &ds.FrontendPluginBase.PluginBase)
+			assert.Equal(t, pm.registry().plugins[pluginID], &ds.FrontendPluginBase.PluginBase)
 
 			assert.Len(t, pm.StaticRoutes(), 1)
 			assert.Equal(t, pluginID, pm.StaticRoutes()[0].PluginId)
@@ -263,9 +268,9 @@ func TestPluginManager_Init(t *testing.T) {
 			verifyPluginManagerState()
 
 			assert.Empty(t, pm.scanningErrors)
-			assert.True(t, reflect.DeepEqual(datasources, pm.dataSources))
-			assert.True(t, reflect.DeepEqual(panels, pm.panels))
-			assert.True(t, reflect.DeepEqual(apps, pm.apps))
+			assert.True(t, reflect.DeepEqual(datasources, pm.registry().dataSources))
+			assert.True(t, reflect.DeepEqual(panels, pm.registry().panels))
+			assert.True(t, reflect.DeepEqual(apps, pm.registry().apps))
 		})
 	})
 
@@ -283,7 +288,7 @@ func TestPluginManager_Init(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, []error{fmt.Errorf(`plugin 'test' has an invalid signature`)}, pm.scanningErrors)
-		assert.Nil(t, pm.plugins[("test")])
+		assert.Nil(t, pm.registry().plugins[("test")])
 	})
 
 	t.Run("With back-end plugin with valid v2 private signature (plugin root URL ignores trailing slash)", func(t *testing.T) {
@@ -304,15 +309,15 @@ func TestPluginManager_Init(t *testing.T) {
 		require.Empty(t, pm.scanningErrors)
 
 		const pluginID = "test"
-		assert.NotNil(t, pm.plugins[pluginID])
-		assert.Equal(t, "datasource", pm.plugins[pluginID].Type)
-		assert.Equal(t, "Test", pm.plugins[pluginID].Name)
-		assert.Equal(t, pluginID, pm.plugins[pluginID].Id)
-		assert.Equal(t, "1.0.0", pm.plugins[pluginID].Info.Version)
-		assert.Equal(t, plugins.PluginSignatureValid, pm.plugins[pluginID].Signature)
-		assert.Equal(t, plugins.PrivateType, pm.plugins[pluginID].SignatureType)
-		assert.Equal(t, "Will Browne", pm.plugins[pluginID].SignatureOrg)
-		assert.False(t, pm.plugins[pluginID].IsCorePlugin)
+		assert.NotNil(t, pm.registry().plugins[pluginID])
+		assert.Equal(t, "datasource", pm.registry().plugins[pluginID].Type)
+		assert.Equal(t, "Test", pm.registry().plugins[pluginID].Name)
+		assert.Equal(t, pluginID, pm.registry().plugins[pluginID].Id)
+		assert.Equal(t, "1.0.0", pm.registry().plugins[pluginID].Info.Version)
+		assert.Equal(t, plugins.PluginSignatureValid, pm.registry().plugins[pluginID].Signature)
+		assert.Equal(t, plugins.PrivateType, pm.registry().plugins[pluginID].SignatureType)
+		assert.Equal(t, "Will Browne", pm.registry().plugins[pluginID].SignatureOrg)
+		assert.False(t, pm.registry().plugins[pluginID].IsCorePlugin)
 	})
 
 	t.Run("With back-end plugin with valid v2 private signature", func(t *testing.T) {
@@ -330,15 +335,15 @@ func TestPluginManager_Init(t *testing.T) {
 		require.Empty(t, pm.scanningErrors)
 
 		const pluginID = "test"
-		assert.NotNil(t, pm.plugins[pluginID])
-		assert.Equal(t, "datasource", pm.plugins[pluginID].Type)
-		assert.Equal(t, "Test", pm.plugins[pluginID].Name)
-		assert.Equal(t, pluginID, pm.plugins[pluginID].Id)
-		assert.Equal(t, "1.0.0", pm.plugins[pluginID].Info.Version)
-		assert.Equal(t, plugins.PluginSignatureValid, pm.plugins[pluginID].Signature)
-		assert.Equal(t, plugins.PrivateType, pm.plugins[pluginID].SignatureType)
-		assert.Equal(t, "Will Browne", pm.plugins[pluginID].SignatureOrg)
-		assert.False(t, pm.plugins[pluginID].IsCorePlugin)
+		assert.NotNil(t, pm.registry().plugins[pluginID])
+		assert.Equal(t, "datasource", pm.registry().plugins[pluginID].Type)
+		assert.Equal(t, "Test", pm.registry().plugins[pluginID].Name)
+		assert.Equal(t, pluginID, pm.registry().plugins[pluginID].Id)
+		assert.Equal(t, "1.0.0", pm.registry().plugins[pluginID].Info.Version)
+		assert.Equal(t, plugins.PluginSignatureValid, pm.registry().plugins[pluginID].Signature)
+		assert.Equal(t, plugins.PrivateType, pm.registry().plugins[pluginID].SignatureType)
+		assert.Equal(t, "Will Browne", pm.registry().plugins[pluginID].SignatureOrg)
+		assert.False(t, pm.registry().plugins[pluginID].IsCorePlugin)
 	})
 
 	t.Run("With back-end plugin with modified v2 signature (missing file from plugin dir)", func(t *testing.T) {
@@ -354,7 +359,7 @@ func TestPluginManager_Init(t *testing.T) {
 		err := pm.init()
 		require.NoError(t, err)
 		assert.Equal(t, []error{fmt.Errorf(`plugin 'test' has a modified signature`)}, pm.scanningErrors)
-		assert.Nil(t, pm.plugins[("test")])
+		assert.Nil(t, pm.registry().plugins[("test")])
 	})
 
 	t.Run("With back-end plugin with modified v2 signature (unaccounted file in plugin dir)", func(t *testing.T) {
@@ -370,7 +375,7 @@ func TestPluginManager_Init(t *testing.T) {
 		err := pm.init()
 		require.NoError(t, err)
 		assert.Equal(t, []error{fmt.Errorf(`plugin 'test' has a modified signature`)}, pm.scanningErrors)
-		assert.Nil(t, pm.plugins[("test")])
+		assert.Nil(t, pm.registry().plugins[("test")])
 	})
 
 	t.Run("With plugin that contains symlink file + directory", func(t *testing.T) {
@@ -417,7 +422,252 @@ func TestPluginManager_Init(t *testing.T) {
 		// This plugin should be properly registered, even though it is symlinked to plugins dir
 		require.Empty(t, pm.scanningErrors)
 		const pluginID = "test-app"
-		assert.NotNil(t, pm.plugins[pluginID])
+		assert.NotNil(t, pm.registry().plugins[pluginID])
+	})
+}
+
+func TestPluginManager_Scan(t *testing.T) {
+	pm := createManager(t, func(pm *PluginManager) {
+		pm.Cfg.Env = setting.Prod
+	})
+
+	result, err := pm.Scan([]string{"testdata/unsigned-datasource", "testdata/valid-v2-signature"}, true)
+	require.NoError(t, err)
+	require.Equal(t, []plugins.ScannedPlugin{
+		{
+			ID:        "test",
+			Type:      "datasource",
+			PluginDir: "testdata/unsigned-datasource/plugin",
+			Signature: plugins.PluginSignatureUnsigned,
+			Error:     &plugins.PluginError{ErrorCode: signatureMissing, PluginID: "test"},
+		},
+		{
+			ID:            "test",
+			Type:          "datasource",
+			Version:       "1.0.0",
+			PluginDir:     "testdata/valid-v2-signature/plugin",
+			Signature:     plugins.PluginSignatureValid,
+			SignatureType: plugins.GrafanaType,
+			SignatureOrg:  "Grafana Labs",
+		},
+	}, result)
+
+	assert.Empty(t, pm.Plugins())
+	assert.Empty(t, pm.StaticRoutes())
+
+	t.Run("Should accept unsigned plugins unless signatures are required", func(t *testing.T) {
+		result, err := pm.Scan([]string{"testdata/unsigned-datasource"}, false)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, plugins.PluginSignatureUnsigned, result[0].Signature)
+		assert.Nil(t, result[0].Error)
+	})
+
+	t.Run("Should report plugins whose plugin.json can't be loaded", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		malformedDir := filepath.Join(pluginsDir, "malformed")
+		incompleteDir := filepath.Join(pluginsDir, "incomplete")
+		require.NoError(t, os.MkdirAll(malformedDir, 0750))
+		require.NoError(t, os.MkdirAll(incompleteDir, 0750))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(malformedDir, "plugin.json"), []byte(`{"id": "malformed"`), 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(incompleteDir, "plugin.json"), []byte(`{"id": "incomplete"}`), 0600))
+
+		result, err := pm.Scan([]string{pluginsDir}, true)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, incompleteDir, result[0].PluginDir)
+		assert.Empty(t, result[0].ID)
+		require.NotNil(t, result[0].Error)
+		assert.Equal(t, invalidPluginJSON, result[0].Error.ErrorCode)
+		assert.Contains(t, result[0].Error.Message, "did not find type or id")
+		assert.Equal(t, malformedDir, result[1].PluginDir)
+		require.NotNil(t, result[1].Error)
+		assert.Equal(t, invalidPluginJSON, result[1].Error.ErrorCode)
+	})
+}
+
+func TestPluginManager_ScanningErrors(t *testing.T) {
+	pluginsDir := t.TempDir()
+	invalidDir := filepath.Join(pluginsDir, "invalid")
+	backendDir := filepath.Join(pluginsDir, "backend")
+	require.NoError(t, os.MkdirAll(invalidDir, 0750))
+	require.NoError(t, os.MkdirAll(backendDir, 0750))
+	err := ioutil.WriteFile(filepath.Join(invalidDir, "plugin.json"), []byte(`{"id": "invalid"`), 0600)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(backendDir, "plugin.json"),
+		[]byte(`{"id": "backend", "type": "datasource", "name": "Backend", "backend": true, "executable": "gpx_backend"}`), 0600)
+	require.NoError(t, err)
+
+	pm := createManager(t, func(pm *PluginManager) {
+		pm.Cfg.PluginsPath = pluginsDir
+		pm.Cfg.Env = setting.Dev
+	})
+	err = pm.init()
+	require.NoError(t, err)
+
+	scanningErrs := pm.ScanningErrors()
+	sort.Slice(scanningErrs, func(i, j int) bool {
+		return scanningErrs[i].Path < scanningErrs[j].Path
+	})
+	require.Len(t, scanningErrs, 2)
+
+	assert.Equal(t, missingExecutable, scanningErrs[0].ErrorCode)
+	assert.Equal(t, "backend", scanningErrs[0].PluginID)
+	assert.Equal(t, backendDir, scanningErrs[0].Path)
+	assert.Contains(t, scanningErrs[0].Message, "gpx_backend")
+	assert.NotNil(t, pm.GetDataSource("backend"))
+
+	assert.Equal(t, invalidPluginJSON, scanningErrs[1].ErrorCode)
+	assert.Empty(t, scanningErrs[1].PluginID)
+	assert.Equal(t, invalidDir, scanningErrs[1].Path)
+	assert.NotEmpty(t, scanningErrs[1].Message)
+}
+
+func TestPluginManager_ScanningErrorsConcurrency(t *testing.T) {
+	pm := createManager(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		dir := fmt.Sprintf("/plugins/%d", i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				pm.setLoadError(plugins.PluginError{ErrorCode: missingExecutable, Path: dir})
+				pm.setScanningError(plugins.PluginError{ErrorCode: signatureMissing, PluginID: dir})
+				pm.clearLoadError(dir, angularBlocked)
+				pm.clearLoadError(dir)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = pm.ScanningErrors()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, pm.ScanningErrors(), 4)
+}
+
+func TestPluginManager_InitFailures(t *testing.T) {
+	pluginsDir := t.TempDir()
+	writePlugin := func(t *testing.T, name, pluginJSON string) string {
+		t.Helper()
+		dir := filepath.Join(pluginsDir, name)
+		require.NoError(t, os.MkdirAll(dir, 0750))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugin.json"), []byte(pluginJSON), 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "module.js"), []byte(""), 0600))
+		return dir
+	}
+	unknownDir := writePlugin(t, "unknown", `{"id": "unknown", "type": "widget", "name": "Unknown"}`)
+	backendDir := writePlugin(t, "backend",
+		`{"id": "backend", "type": "datasource", "name": "Backend", "backend": true, "executable": "gpx_backend"}`)
+	writePlugin(t, "panel", `{"id": "panel", "type": "panel", "name": "Panel"}`)
+
+	pm := createManager(t, func(pm *PluginManager) {
+		pm.Cfg.PluginsPath = pluginsDir
+		pm.Cfg.Env = setting.Dev
+		pm.BackendPluginManager = &fakeBackendPluginManager{registerErr: errors.New("failed to start")}
+	})
+	err := pm.init()
+	require.NoError(t, err)
+
+	require.Equal(t, []plugins.InitFailure{
+		{Path: backendDir, PluginID: "backend", Stage: plugins.InitStageRegister,
+			Error: "failed to register backend plugin: failed to start"},
+		{Path: unknownDir, PluginID: "unknown", Stage: plugins.InitStageLoad, Error: `unknown plugin type "widget"`},
+	}, pm.InitFailures())
+	assert.NotNil(t, pm.GetPlugin("panel"))
+	assert.Nil(t, pm.GetPlugin("backend"))
+	assert.Nil(t, pm.GetPlugin("unknown"))
+
+	t.Run("Should clear failures of plugins initialized later", func(t *testing.T) {
+		pm.BackendPluginManager = &fakeBackendPluginManager{}
+		err := pm.initExternalPlugins()
+		require.NoError(t, err)
+
+		require.Len(t, pm.InitFailures(), 1)
+		assert.Equal(t, "unknown", pm.InitFailures()[0].PluginID)
+		assert.NotNil(t, pm.GetPlugin("backend"))
+	})
+}
+
+func TestPluginManager_NestedPlugins(t *testing.T) {
+	const pluginsDir = "testdata/nested-plugins"
+
+	scannedIDs := func(t *testing.T, pm *PluginManager) []string {
+		t.Helper()
+
+		result, err := pm.Scan([]string{pluginsDir}, false)
+		require.NoError(t, err)
+
+		var ids []string
+		for _, p := range result {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	t.Run("Should find nested plugins and skip ignored paths", func(t *testing.T) {
+		pm := createManager(t)
+		require.Equal(t, []string{"test-y", "test-x"}, scannedIDs(t, pm))
+	})
+
+	t.Run("Should not descend deeper than the configured max depth", func(t *testing.T) {
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.Cfg.PluginsScanMaxDepth = 3
+		})
+		require.Equal(t, []string{"test-x"}, scannedIDs(t, pm))
+	})
+}
+
+func TestPluginManager_IDAliases(t *testing.T) {
+	pm := createManager(t, func(pm *PluginManager) {
+		pm.Cfg.PluginSettings = setting.PluginSettings{
+			"test-app": map[string]string{
+				"path": "testdata/test-app",
+			},
+		}
+		pm.Cfg.PluginIDAliases = map[string]string{
+			"old-app": "test-app",
+		}
+	})
+	err := pm.init()
+	require.NoError(t, err)
+
+	require.NotNil(t, pm.GetPlugin("old-app"))
+	require.Equal(t, pm.GetPlugin("test-app"), pm.GetPlugin("old-app"))
+	require.Equal(t, pm.GetApp("test-app"), pm.GetApp("old-app"))
+	require.True(t, pm.IsAppInstalled("old-app"))
+	require.Nil(t, pm.GetPlugin("unknown-app"))
+
+	t.Run("Should prefer an installed plugin over an alias of its ID", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		for _, id := range []string{"old-panel", "new-panel"} {
+			dir := filepath.Join(pluginsDir, id)
+			require.NoError(t, os.MkdirAll(dir, 0750))
+			pluginJSON := fmt.Sprintf(`{"type": "panel", "id": %q, "name": %q}`, id, id)
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugin.json"), []byte(pluginJSON), 0600))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "module.js"), []byte(reactModule), 0600))
+		}
+
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.Cfg.PluginsPath = pluginsDir
+			pm.Cfg.PluginsAllowUnsigned = []string{"old-panel", "new-panel"}
+			pm.Cfg.PluginIDAliases = map[string]string{
+				"old-panel":  "new-panel",
+				"gone-panel": "new-panel",
+			}
+		})
+		require.NoError(t, pm.init())
+
+		require.NotNil(t, pm.GetPanel("old-panel"))
+		assert.Equal(t, "old-panel", pm.GetPanel("old-panel").Id)
+		assert.Equal(t, "old-panel", pm.GetPlugin("old-panel").Id)
+		require.NotNil(t, pm.GetPanel("gone-panel"))
+		assert.Equal(t, "new-panel", pm.GetPanel("gone-panel").Id)
 	})
 }
 
@@ -441,6 +691,52 @@ func TestPluginManager_IsBackendOnlyPlugin(t *testing.T) {
 	}
 }
 
+func TestPluginManager_GetPluginMarkdown(t *testing.T) {
+	pluginDir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(pluginDir, "README.md"), []byte("# Readme"), 0600)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(pluginDir, "changelog.md"), []byte("# Changelog"), 0600)
+	require.NoError(t, err)
+
+	pm := &PluginManager{
+		Cfg: setting.NewCfg(),
+	}
+	pm.updateRegistry(func(r *pluginRegistry) {
+		r.plugins = map[string]*plugins.PluginBase{
+			"unsigned": {Id: "unsigned", PluginDir: pluginDir},
+			"signed":   {Id: "signed", PluginDir: pluginDir, SignedFiles: plugins.PluginFiles{"README.md": struct{}{}}},
+		}
+	})
+
+	t.Run("Should return markdown files of plugin", func(t *testing.T) {
+		content, err := pm.GetPluginMarkdown("unsigned", "readme")
+		require.NoError(t, err)
+		require.Equal(t, "# Readme", string(content))
+
+		content, err = pm.GetPluginMarkdown("unsigned", "CHANGELOG")
+		require.NoError(t, err)
+		require.Equal(t, "# Changelog", string(content))
+
+		content, err = pm.GetPluginMarkdown("unsigned", "license")
+		require.NoError(t, err)
+		require.Empty(t, content)
+	})
+
+	t.Run("Should not return markdown files not included in plugin signature", func(t *testing.T) {
+		content, err := pm.GetPluginMarkdown("signed", "readme")
+		require.NoError(t, err)
+		require.Equal(t, "# Readme", string(content))
+
+		_, err = pm.GetPluginMarkdown("signed", "changelog")
+		require.ErrorIs(t, err, plugins.ErrPluginMarkdownNotSigned)
+	})
+
+	t.Run("Should return not found error of unknown plugin", func(t *testing.T) {
+		_, err := pm.GetPluginMarkdown("unknown", "readme")
+		require.ErrorAs(t, err, &plugins.PluginNotFoundError{})
+	})
+}
+
 func TestPluginManager_Installer(t *testing.T) {
 	t.Run("Install plugin after manager init", func(t *testing.T) {
 		fm := &fakeBackendPluginManager{}
@@ -462,7 +758,7 @@ func TestPluginManager_Installer(t *testing.T) {
 		pluginID := "test"
 		pluginFolder := pm.Cfg.PluginsPath + "/plugin"
 
-		err = pm.Install(context.Background(), pluginID, "1.0.0")
+		err = pm.Install(context.Background(), pluginID, "1.0.0", plugins.InstallOpts{})
 		require.NoError(t, err)
 
 		assert.Equal(t, 1, installer.installCount)
@@ -473,7 +769,7 @@ func TestPluginManager_Installer(t *testing.T) {
 		verifyCorePluginCatalogue(t, pm)
 
 		// verify plugin has been loaded successfully
-		assert.NotNil(t, pm.plugins[pluginID])
+		assert.NotNil(t, pm.registry().plugins[pluginID])
 		if diff := cmp.Diff(&plugins.PluginBase{
 			Type:  "datasource",
 			Name:  "Test",
@@ -505,21 +801,21 @@ func TestPluginManager_Installer(t *testing.T) {
 			},
 			Module:  "plugins/test/module",
 			BaseUrl: "public/plugins/test",
-		}, pm.plugins[pluginID]); diff != "" {
+		}, pm.registry().plugins[pluginID]); diff != "" {
 			t.Errorf("result mismatch (-want +got) %s\n", diff)
 		}
 
 		ds := pm.GetDataSource(pluginID)
 		assert.NotNil(t, ds)
 		assert.Equal(t, pluginID, ds.Id)
-		assert.Equal(t, pm.plugins[pluginID], &ds.FrontendPluginBase.PluginBase)
+		assert.Equal(t, pm.registry().plugins[pluginID], &ds.FrontendPluginBase.PluginBase)
 
 		assert.Len(t, pm.StaticRoutes(), 1)
 		assert.Equal(t, pluginID, pm.StaticRoutes()[0].PluginId)
 		assert.Equal(t, pluginFolder, pm.StaticRoutes()[0].Directory)
 
 		t.Run("Won't install if already installed", func(t *testing.T) {
-			err := pm.Install(context.Background(), pluginID, "1.0.0")
+			err := pm.Install(context.Background(), pluginID, "1.0.0", plugins.InstallOpts{})
 			require.Equal(t, plugins.DuplicatePluginError{
 				PluginID:          pluginID,
 				ExistingPluginDir: pluginFolder,
@@ -527,11 +823,16 @@ func TestPluginManager_Installer(t *testing.T) {
 		})
 
 		t.Run("Uninstall base case", func(t *testing.T) {
+			fm.registeredPlugins = append(fm.registeredPlugins, pluginID)
+
 			err := pm.Uninstall(context.Background(), pluginID)
 			require.NoError(t, err)
 
 			assert.Equal(t, 1, installer.installCount)
 			assert.Equal(t, 1, installer.uninstallCount)
+			// the backend plugin is drained before it's stopped
+			assert.Equal(t, []string{pluginID}, fm.decommissioned)
+			assert.False(t, fm.IsRegistered(pluginID))
 
 			assert.Nil(t, pm.GetDataSource(pluginID))
 			assert.Nil(t, pm.GetPlugin(pluginID))
@@ -545,6 +846,98 @@ func TestPluginManager_Installer(t *testing.T) {
 	})
 }
 
+func TestPluginManager_UninstallDecommissioned(t *testing.T) {
+	fm := &fakeBackendPluginManager{}
+	pm := createManager(t, func(pm *PluginManager) {
+		pm.BackendPluginManager = fm
+	})
+	err := pm.init()
+	require.NoError(t, err)
+	installer := &fakePluginInstaller{}
+	pm.pluginInstaller = installer
+	pm.Cfg.PluginsPath = "testdata/installer"
+
+	pluginID := "test"
+	err = pm.Install(context.Background(), pluginID, "1.0.0", plugins.InstallOpts{})
+	require.NoError(t, err)
+	require.True(t, fm.IsRegistered(pluginID))
+
+	err = fm.DecommissionPlugin(context.Background(), pluginID)
+	require.NoError(t, err)
+	require.False(t, fm.IsRegistered(pluginID))
+
+	err = pm.Uninstall(context.Background(), pluginID)
+	require.NoError(t, err)
+	// the plugin isn't drained again, but it's unregistered
+	assert.Equal(t, []string{pluginID}, fm.decommissioned)
+	_, registered := fm.GetRegistered(pluginID)
+	assert.False(t, registered)
+	assert.Nil(t, pm.GetPlugin(pluginID))
+
+	err = pm.Install(context.Background(), pluginID, "1.0.0", plugins.InstallOpts{})
+	require.NoError(t, err)
+	assert.True(t, fm.IsRegistered(pluginID))
+	assert.NotNil(t, pm.GetPlugin(pluginID))
+	assert.Equal(t, 2, installer.installCount)
+	assert.Equal(t, 1, installer.uninstallCount)
+}
+
+func TestPluginManager_InstallVersions(t *testing.T) {
+	pluginsDir := t.TempDir()
+	pluginJSON, err := ioutil.ReadFile("testdata/installer/plugin/plugin.json")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(pluginsDir, "test"), 0750))
+	pluginJSON = []byte(strings.Replace(string(pluginJSON), `"version": "1.0.0"`, `"version": "2.0.0"`, 1))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(pluginsDir, "test", "plugin.json"), pluginJSON, 0600))
+
+	pm := createManager(t, func(pm *PluginManager) {
+		pm.BackendPluginManager = &fakeBackendPluginManager{}
+		pm.Cfg.PluginsPath = pluginsDir
+		pm.Cfg.PluginsAllowUnsigned = []string{"test"}
+	})
+	require.NoError(t, pm.init())
+	installer := &fakePluginInstaller{}
+	pm.pluginInstaller = installer
+
+	t.Run("Won't install the same version written differently", func(t *testing.T) {
+		err := pm.Install(context.Background(), "test", "2.0", plugins.InstallOpts{})
+		require.Equal(t, plugins.DuplicatePluginError{
+			PluginID:          "test",
+			ExistingPluginDir: filepath.Join(pluginsDir, "test"),
+		}, err)
+		assert.Equal(t, 0, installer.installCount)
+	})
+
+	t.Run("Won't install the latest version if it's installed", func(t *testing.T) {
+		installer.updateInfo = plugins.UpdateInfo{Version: "2.0.0"}
+		err := pm.Install(context.Background(), "test", "", plugins.InstallOpts{})
+		require.ErrorIs(t, err, plugins.DuplicatePluginError{})
+		assert.Equal(t, 0, installer.installCount)
+	})
+
+	t.Run("Won't downgrade unless allowed", func(t *testing.T) {
+		installer.updateInfo = plugins.UpdateInfo{Version: "1.0.0"}
+		err := pm.Install(context.Background(), "test", "1.0.0", plugins.InstallOpts{})
+		require.Equal(t, plugins.DowngradeError{
+			PluginID:         "test",
+			InstalledVersion: "2.0.0",
+			Version:          "1.0.0",
+		}, err)
+		assert.Equal(t, 0, installer.installCount)
+
+		err = pm.Install(context.Background(), "test", "1.0.0", plugins.InstallOpts{AllowDowngrade: true})
+		require.NoError(t, err)
+		assert.Equal(t, 1, installer.installCount)
+	})
+
+	t.Run("Upgrades to a pre-release of a higher version", func(t *testing.T) {
+		installer.updateInfo = plugins.UpdateInfo{Version: "2.1.0-beta1"}
+		err := pm.Install(context.Background(), "test", "2.1.0-beta1", plugins.InstallOpts{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, installer.installCount)
+	})
+}
+
 func verifyCorePluginCatalogue(t *testing.T, pm *PluginManager) {
 	t.Helper()
 
@@ -600,13 +993,13 @@ func verifyCorePluginCatalogue(t *testing.T, pm *PluginManager) {
 	}
 
 	for _, p := range panels {
-		assert.NotNil(t, pm.plugins[p])
-		assert.NotNil(t, pm.panels[p])
+		assert.NotNil(t, pm.registry().plugins[p])
+		assert.NotNil(t, pm.registry().panels[p])
 	}
 
 	for _, ds := range datasources {
-		assert.NotNil(t, pm.plugins[ds])
-		assert.NotNil(t, pm.dataSources[ds])
+		assert.NotNil(t, pm.registry().plugins[ds])
+		assert.NotNil(t, pm.registry().dataSources[ds])
 	}
 }
 
@@ -618,41 +1011,136 @@ func verifyBundledPlugins(t *testing.T, pm *PluginManager) {
 	}
 
 	for pluginID, pluginDir := range bundledPlugins {
-		assert.NotNil(t, pm.plugins[pluginID])
-		for _, route := range pm.staticRoutes {
+		assert.NotNil(t, pm.registry().plugins[pluginID])
+		for _, route := range pm.registry().staticRoutes {
 			if pluginID == route.PluginId {
 				assert.True(t, strings.HasPrefix(route.Directory, pm.Cfg.BundledPluginsPath+"/"+pluginDir))
 			}
 		}
 	}
 
-	assert.NotNil(t, pm.dataSources["input"])
+	assert.NotNil(t, pm.registry().dataSources["input"])
 }
 
 type fakeBackendPluginManager struct {
 	registeredPlugins []string
+	canaries          []string
+	decommissioned    []string
+	pluginStates      []backendplugin.PluginState
+	registerErr       error
 }
 
 func (f *fakeBackendPluginManager) Register(pluginID string, factory backendplugin.PluginFactoryFunc) error {
+	if f.registerErr != nil {
+		return f.registerErr
+	}
+	if _, exists := f.GetRegistered(pluginID); exists {
+		return fmt.Errorf("backend plugin %s already registered", pluginID)
+	}
 	f.registeredPlugins = append(f.registeredPlugins, pluginID)
+	var decommissioned []string
+	for _, id := range f.decommissioned {
+		if id != pluginID {
+			decommissioned = append(decommissioned, id)
+		}
+	}
+	f.decommissioned = decommissioned
 	return nil
 }
 
 func (f *fakeBackendPluginManager) RegisterAndStart(ctx context.Context, pluginID string, factory backendplugin.PluginFactoryFunc) error {
-	f.registeredPlugins = append(f.registeredPlugins, pluginID)
-	return nil
+	return f.Register(pluginID, factory)
 }
 
 func (f *fakeBackendPluginManager) Get(pluginID string) (backendplugin.Plugin, bool) {
 	return nil, false
 }
 
+func (f *fakeBackendPluginManager) GetRegistered(pluginID string) (backendplugin.Plugin, bool) {
+	for _, existingPlugin := range f.registeredPlugins {
+		if pluginID == existingPlugin {
+			return &fakeBackendPlugin{decommissioned: f.isDecommissioned(pluginID)}, true
+		}
+	}
+	return nil, false
+}
+
+// isDecommissioned returns whether the plugin was decommissioned since it was last registered.
+func (f *fakeBackendPluginManager) isDecommissioned(pluginID string) bool {
+	for _, decommissioned := range f.decommissioned {
+		if pluginID == decommissioned {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeBackendPluginManager) PluginStates() []backendplugin.PluginState {
+	return f.pluginStates
+}
+
+func (f *fakeBackendPluginManager) PluginCapabilities(ctx context.Context, pluginID string) (backendplugin.PluginCapabilities, error) {
+	return backendplugin.PluginCapabilities{}, nil
+}
+
+func (f *fakeBackendPluginManager) LogLevel(pluginID string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeBackendPluginManager) SetLogLevel(ctx context.Context, pluginID string, level string) error {
+	return nil
+}
+
+func (f *fakeBackendPluginManager) ProcessOutput(pluginID string) ([]byte, error) {
+	return nil, nil
+}
+
+func (f *fakeBackendPluginManager) StreamLogs(ctx context.Context, pluginID string, level string) (<-chan backendplugin.LogEntry, error) {
+	return nil, nil
+}
+
+func (f *fakeBackendPluginManager) RestartPlugin(ctx context.Context, pluginID string) error {
+	return nil
+}
+
+func (f *fakeBackendPluginManager) ReloadPlugin(ctx context.Context, pluginID string) error {
+	return nil
+}
+
+func (f *fakeBackendPluginManager) DecommissionPlugin(ctx context.Context, pluginID string) error {
+	f.decommissioned = append(f.decommissioned, pluginID)
+	return nil
+}
+
+func (f *fakeBackendPluginManager) GatherMetrics() ([]*dto.MetricFamily, error) {
+	return nil, nil
+}
+
+func (f *fakeBackendPluginManager) RuntimeStats() backendplugin.RuntimeStats {
+	return backendplugin.RuntimeStats{}
+}
+
+func (f *fakeBackendPluginManager) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	return nil, nil
+}
+
+func (f *fakeBackendPluginManager) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return nil, nil
+}
+
+func (f *fakeBackendPluginManager) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	return nil
+}
+
+func (f *fakeBackendPluginManager) ServeStreamEvents(pCtx backend.PluginContext, reqCtx *models.ReqContext, path string) {
+}
+
 func (f *fakeBackendPluginManager) UnregisterAndStop(ctx context.Context, pluginID string) error {
 	var result []string
 
 	for _, existingPlugin := range f.registeredPlugins {
 		if pluginID != existingPlugin {
-			result = append(result, pluginID)
+			result = append(result, existingPlugin)
 		}
 	}
 
@@ -660,13 +1148,28 @@ func (f *fakeBackendPluginManager) UnregisterAndStop(ctx context.Context, plugin
 	return nil
 }
 
-func (f *fakeBackendPluginManager) IsRegistered(pluginID string) bool {
-	for _, existingPlugin := range f.registeredPlugins {
-		if pluginID == existingPlugin {
-			return true
+func (f *fakeBackendPluginManager) RegisterCanary(ctx context.Context, pluginID string, factory backendplugin.PluginFactoryFunc) error {
+	if f.registerErr != nil {
+		return f.registerErr
+	}
+	f.canaries = append(f.canaries, pluginID)
+	return nil
+}
+
+func (f *fakeBackendPluginManager) UnregisterCanary(ctx context.Context, pluginID string) error {
+	var result []string
+	for _, canary := range f.canaries {
+		if canary != pluginID {
+			result = append(result, canary)
 		}
 	}
-	return false
+	f.canaries = result
+	return nil
+}
+
+func (f *fakeBackendPluginManager) IsRegistered(pluginID string) bool {
+	p, exists := f.GetRegistered(pluginID)
+	return exists && !p.IsDecommissioned()
 }
 
 func (f *fakeBackendPluginManager) StartPlugin(ctx context.Context, pluginID string) error {
@@ -681,18 +1184,56 @@ func (f *fakeBackendPluginManager) CheckHealth(ctx context.Context, pCtx backend
 	return nil, nil
 }
 
+func (f *fakeBackendPluginManager) ValidateConfig(ctx context.Context, pCtx backend.PluginContext) (*backendplugin.ValidateConfigResult, error) {
+	return &backendplugin.ValidateConfigResult{}, nil
+}
+
+func (f *fakeBackendPluginManager) CachedHealthCheck(pluginID string, dataSourceUID string) (backendplugin.HealthCheckStatus, bool) {
+	return backendplugin.HealthCheckStatus{}, false
+}
+
+func (f *fakeBackendPluginManager) HealthCheckHistory(pluginID string, dataSourceUID string) backendplugin.HealthCheckHistory {
+	return backendplugin.HealthCheckHistory{}
+}
+
 func (f *fakeBackendPluginManager) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	return nil, nil
 }
 
+func (f *fakeBackendPluginManager) QueryDataStream(ctx context.Context, req *backend.QueryDataRequest,
+	sender backendplugin.QueryDataResponseSender) error {
+	return nil
+}
+
 func (f *fakeBackendPluginManager) CallResource(pluginConfig backend.PluginContext, ctx *models.ReqContext, path string) {
 }
 
+func (f *fakeBackendPluginManager) RegisterResourceMiddleware(middleware backendplugin.ResourceMiddleware) {
+}
+
+func (f *fakeBackendPluginManager) RegisterQueryDataMiddleware(middleware backendplugin.QueryDataMiddleware) {
+}
+
+func (f *fakeBackendPluginManager) RegisterStreamAuthorizer(authorizer backendplugin.StreamAuthorizer) {
+}
+
 var _ backendplugin.Manager = &fakeBackendPluginManager{}
 
+type fakeBackendPlugin struct {
+	backendplugin.Plugin
+
+	decommissioned bool
+}
+
+func (p *fakeBackendPlugin) IsDecommissioned() bool {
+	return p.decommissioned
+}
+
 type fakePluginInstaller struct {
 	installCount   int
 	uninstallCount int
+	updateInfo     plugins.UpdateInfo
+	updateInfoErr  error
 }
 
 func (f *fakePluginInstaller) Install(ctx context.Context, pluginID, version, pluginsDirectory, pluginZipURL, pluginRepoURL string) error {
@@ -706,7 +1247,7 @@ func (f *fakePluginInstaller) Uninstall(ctx context.Context, pluginPath string)
 }
 
 func (f *fakePluginInstaller) GetUpdateInfo(pluginID, version, pluginRepoURL string) (plugins.UpdateInfo, error) {
-	return plugins.UpdateInfo{}, nil
+	return f.updateInfo, f.updateInfoErr
 }
 
 func createManager(t *testing.T, cbs ...func(*PluginManager)) *PluginManager {
@@ -1,6 +1,8 @@
 package manager
 
 import (
+	"sort"
+
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 )
@@ -16,7 +18,13 @@ func (pm *PluginManager) GetPluginSettings(orgID int64) (map[string]*models.Plug
 		pluginMap[plug.PluginId] = plug
 	}
 
-	for _, pluginDef := range pm.Plugins() {
+	// the children of apps are resolved after the apps, whose settings they follow
+	pluginDefs := pm.Plugins()
+	sort.SliceStable(pluginDefs, func(i, j int) bool {
+		return pluginDefs[i].Parent == nil && pluginDefs[j].Parent != nil
+	})
+
+	for _, pluginDef := range pluginDefs {
 		// ignore entries that exists
 		if _, ok := pluginMap[pluginDef.Id]; ok {
 			continue
@@ -30,17 +38,17 @@ func (pm *PluginManager) GetPluginSettings(orgID int64) (map[string]*models.Plug
 		}
 
 		// apps are disabled by default unless autoEnabled: true
-		if app, exists := pm.apps[pluginDef.Id]; exists {
+		if app, exists := pm.registry().apps[pluginDef.Id]; exists {
 			opt.Enabled = app.AutoEnabled
 			opt.Pinned = app.AutoEnabled
 		}
 
 		// if it's included in app check app settings
-		if pluginDef.IncludedInAppId != "" {
+		if pluginDef.Parent != nil {
 			// app components are by default disabled
 			opt.Enabled = false
 
-			if appSettings, ok := pluginMap[pluginDef.IncludedInAppId]; ok {
+			if appSettings, ok := pluginMap[pluginDef.Parent.Id]; ok {
 				opt.Enabled = appSettings.Enabled
 			}
 		}
@@ -65,19 +73,21 @@ func (pm *PluginManager) GetEnabledPlugins(orgID int64) (*plugins.EnabledPlugins
 
 	for _, app := range pm.Apps() {
 		if b, ok := pluginSettingMap[app.Id]; ok {
-			app.Pinned = b.Pinned
-			enabledPlugins.Apps = append(enabledPlugins.Apps, app)
+			// the registered app is shared, the org's pinned setting is set on a copy
+			enabledApp := *app
+			enabledApp.Pinned = b.Pinned
+			enabledPlugins.Apps = append(enabledPlugins.Apps, &enabledApp)
 		}
 	}
 
 	// add all plugins that are not part of an App.
-	for dsID, ds := range pm.dataSources {
+	for dsID, ds := range pm.registry().dataSources {
 		if _, exists := pluginSettingMap[ds.Id]; exists {
 			enabledPlugins.DataSources[dsID] = ds
 		}
 	}
 
-	for _, panel := range pm.panels {
+	for _, panel := range pm.registry().panels {
 		if _, exists := pluginSettingMap[panel.Id]; exists {
 			enabledPlugins.Panels = append(enabledPlugins.Panels, panel)
 		}
@@ -88,6 +98,5 @@ func (pm *PluginManager) GetEnabledPlugins(orgID int64) (*plugins.EnabledPlugins
 
 // IsAppInstalled checks if an app plugin with provided plugin ID is installed.
 func (pm *PluginManager) IsAppInstalled(pluginID string) bool {
-	_, exists := pm.apps[pluginID]
-	return exists
+	return pm.GetApp(pluginID) != nil
 }
@@ -0,0 +1,297 @@
+package manager
+
+import (
+	"sort"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// pluginRegistry is an immutable snapshot of the registered plugins. Registering and unregistering plugins
+// replaces the snapshot of the plugin manager with an updated copy, so that reading the registered plugins, done
+// on every request, doesn't need locking.
+type pluginRegistry struct {
+	renderer    *plugins.RendererPlugin
+	dataSources map[string]*plugins.DataSourcePlugin
+	plugins     map[string]*plugins.PluginBase
+	panels      map[string]*plugins.PanelPlugin
+	apps        map[string]*plugins.AppPlugin
+
+	// staticRoutesByPlugin holds the static routes of the plugins with an initialized frontend by plugin ID.
+	staticRoutesByPlugin map[string][]*plugins.PluginStaticRoute
+
+	// sortedPlugins and pluginsByType index the plugins, sorted by ID. staticRoutes holds the static routes of
+	// all plugins, sorted by plugin ID.
+	sortedPlugins []*plugins.PluginBase
+	pluginsByType map[string][]*plugins.PluginBase
+	staticRoutes  []*plugins.PluginStaticRoute
+}
+
+// clone returns a copy of the registry which can be updated without affecting r.
+func (r *pluginRegistry) clone() *pluginRegistry {
+	clone := &pluginRegistry{
+		renderer:    r.renderer,
+		dataSources: make(map[string]*plugins.DataSourcePlugin, len(r.dataSources)),
+		plugins:     make(map[string]*plugins.PluginBase, len(r.plugins)),
+		panels:      make(map[string]*plugins.PanelPlugin, len(r.panels)),
+		apps:        make(map[string]*plugins.AppPlugin, len(r.apps)),
+
+		staticRoutesByPlugin: make(map[string][]*plugins.PluginStaticRoute, len(r.staticRoutesByPlugin)),
+	}
+	for id, p := range r.dataSources {
+		clone.dataSources[id] = p
+	}
+	for id, p := range r.plugins {
+		clone.plugins[id] = p
+	}
+	for id, p := range r.panels {
+		clone.panels[id] = p
+	}
+	for id, p := range r.apps {
+		clone.apps[id] = p
+	}
+	for id, routes := range r.staticRoutesByPlugin {
+		clone.staticRoutesByPlugin[id] = routes
+	}
+
+	return clone
+}
+
+// index rebuilds the indexes of the plugins of the registry.
+func (r *pluginRegistry) index() {
+	r.sortedPlugins = make([]*plugins.PluginBase, 0, len(r.plugins))
+	for _, p := range r.plugins {
+		r.sortedPlugins = append(r.sortedPlugins, p)
+	}
+	sort.Slice(r.sortedPlugins, func(i, j int) bool {
+		return r.sortedPlugins[i].Id < r.sortedPlugins[j].Id
+	})
+
+	r.pluginsByType = map[string][]*plugins.PluginBase{}
+	for _, p := range r.sortedPlugins {
+		r.pluginsByType[p.Type] = append(r.pluginsByType[p.Type], p)
+	}
+
+	pluginIDs := make([]string, 0, len(r.staticRoutesByPlugin))
+	for id := range r.staticRoutesByPlugin {
+		pluginIDs = append(pluginIDs, id)
+	}
+	sort.Strings(pluginIDs)
+
+	r.staticRoutes = []*plugins.PluginStaticRoute{}
+	for _, id := range pluginIDs {
+		r.staticRoutes = append(r.staticRoutes, r.staticRoutesByPlugin[id]...)
+	}
+}
+
+// copyPlugin replaces a registered plugin with a copy, which can be modified without modifying previous snapshots,
+// and returns the copy. The app bundling the plugin, or the plugins bundled by the app, are copied along, so that
+// the links between them point to the copies.
+func (r *pluginRegistry) copyPlugin(pluginID string) *plugins.PluginBase {
+	p, exists := r.plugins[pluginID]
+	if !exists {
+		return nil
+	}
+
+	root := p
+	if p.Parent != nil && r.plugins[p.Parent.Id] == p.Parent {
+		root = p.Parent
+	}
+	rootCopy := r.copyOne(root.Id)
+	if len(root.Children) > 0 {
+		children := make([]*plugins.PluginBase, 0, len(root.Children))
+		for _, child := range root.Children {
+			if r.plugins[child.Id] != child {
+				children = append(children, child)
+				continue
+			}
+			childCopy := r.copyOne(child.Id)
+			childCopy.Parent = rootCopy
+			children = append(children, childCopy)
+		}
+		rootCopy.Children = children
+	}
+
+	return r.plugins[pluginID]
+}
+
+// copyOne replaces a registered plugin with a copy, without copying the plugins linked to it.
+func (r *pluginRegistry) copyOne(pluginID string) *plugins.PluginBase {
+	var pb *plugins.PluginBase
+	if app, exists := r.apps[pluginID]; exists {
+		cp := *app
+		r.apps[pluginID] = &cp
+		pb = &cp.PluginBase
+	} else if panel, exists := r.panels[pluginID]; exists {
+		cp := *panel
+		r.panels[pluginID] = &cp
+		pb = &cp.PluginBase
+	} else if ds, exists := r.dataSources[pluginID]; exists {
+		cp := *ds
+		r.dataSources[pluginID] = &cp
+		pb = &cp.PluginBase
+	} else if r.renderer != nil && r.renderer.Id == pluginID {
+		cp := *r.renderer
+		r.renderer = &cp
+		pb = &cp.PluginBase
+	} else {
+		cp := *r.plugins[pluginID]
+		pb = &cp
+	}
+	r.plugins[pluginID] = pb
+
+	return pb
+}
+
+// registry returns the current snapshot of the registered plugins, which must not be modified.
+func (pm *PluginManager) registry() *pluginRegistry {
+	if r, ok := pm.registrySnapshot.Load().(*pluginRegistry); ok {
+		return r
+	}
+	return &pluginRegistry{}
+}
+
+// updateRegistry applies update to a copy of the current snapshot of the registered plugins, which then replaces
+// the current snapshot. The plugins of the snapshot are shared with the previous snapshot, update must replace the
+// plugins it modifies with copies, for example with copyPlugin.
+func (pm *PluginManager) updateRegistry(update func(r *pluginRegistry)) {
+	pm.registryMu.Lock()
+	defer pm.registryMu.Unlock()
+
+	r := pm.registry().clone()
+	update(r)
+	r.index()
+	pm.registrySnapshot.Store(r)
+}
+
+// registryBatch collects the plugins loaded by a scan, which are registered all at once, so that the registry is
+// copied and indexed once per scan rather than once per plugin. The plugins of a batch aren't visible before the
+// batch is registered, so they can be initialized in place.
+type registryBatch struct {
+	renderer    *plugins.RendererPlugin
+	dataSources []*plugins.DataSourcePlugin
+	panels      []*plugins.PanelPlugin
+	apps        []*plugins.AppPlugin
+}
+
+// plugins returns the plugins of the batch.
+func (b *registryBatch) plugins() []*plugins.PluginBase {
+	var result []*plugins.PluginBase
+	if b.renderer != nil {
+		result = append(result, &b.renderer.PluginBase)
+	}
+	for _, ds := range b.dataSources {
+		result = append(result, &ds.PluginBase)
+	}
+	for _, panel := range b.panels {
+		result = append(result, &panel.PluginBase)
+	}
+	for _, app := range b.apps {
+		result = append(result, &app.PluginBase)
+	}
+
+	return result
+}
+
+// registerBatch initializes the frontends of the plugins of batch and registers them in a single update of the
+// registry, linking the plugins bundled by apps to their app.
+func (pm *PluginManager) registerBatch(batch *registryBatch) {
+	loaded := batch.plugins()
+	if len(loaded) == 0 {
+		return
+	}
+
+	staticRoutes := map[string][]*plugins.PluginStaticRoute{}
+	for _, panel := range batch.panels {
+		staticRoutes[panel.Id] = panel.InitFrontendPlugin(pm.Cfg)
+	}
+	for _, ds := range batch.dataSources {
+		staticRoutes[ds.Id] = ds.InitFrontendPlugin(pm.Cfg)
+	}
+	for _, app := range batch.apps {
+		staticRoutes[app.Id] = app.InitApp(pm.Cfg)
+	}
+	if batch.renderer != nil {
+		staticRoutes[batch.renderer.Id] = batch.renderer.InitFrontendPlugin(pm.Cfg)
+	}
+
+	isNew := make(map[string]bool, len(loaded))
+	for _, p := range loaded {
+		isNew[p.Id] = true
+		if p.IsCorePlugin {
+			p.Signature = plugins.PluginSignatureInternal
+		} else {
+			metrics.SetPluginBuildInformation(p.Id, p.Type, p.Info.Version, string(p.Signature))
+		}
+	}
+
+	pm.updateRegistry(func(r *pluginRegistry) {
+		if batch.renderer != nil {
+			r.renderer = batch.renderer
+		}
+		for _, ds := range batch.dataSources {
+			r.dataSources[ds.Id] = ds
+		}
+		for _, panel := range batch.panels {
+			r.panels[panel.Id] = panel
+		}
+		for _, app := range batch.apps {
+			r.apps[app.Id] = app
+		}
+		for _, p := range loaded {
+			r.plugins[p.Id] = p
+		}
+		for pluginID, routes := range staticRoutes {
+			r.staticRoutesByPlugin[pluginID] = routes
+		}
+
+		pm.linkChildPlugins(r, isNew)
+	})
+}
+
+// linkChildPlugins links the panels and data sources bundled by apps to their app, where either the app or the
+// bundled plugin is new. Registered plugins are copied before they're linked.
+func (pm *PluginManager) linkChildPlugins(r *pluginRegistry, isNew map[string]bool) {
+	appIDs := make([]string, 0, len(r.apps))
+	for appID := range r.apps {
+		appIDs = append(appIDs, appID)
+	}
+	sort.Strings(appIDs)
+
+	for _, appID := range appIDs {
+		app := r.apps[appID]
+		var childIDs []string
+		for id, p := range r.plugins {
+			if p.Parent != nil || (p.Type != "panel" && p.Type != "datasource") || !app.Bundles(p.PluginDir) {
+				continue
+			}
+			// links between registered plugins have already been made
+			if !isNew[appID] && !isNew[id] {
+				continue
+			}
+			childIDs = append(childIDs, id)
+		}
+		if len(childIDs) == 0 {
+			continue
+		}
+
+		if !isNew[appID] {
+			r.copyPlugin(appID)
+			app = r.apps[appID]
+		}
+		panels := map[string]*plugins.PanelPlugin{}
+		dataSources := map[string]*plugins.DataSourcePlugin{}
+		for _, id := range childIDs {
+			if !isNew[id] {
+				r.copyPlugin(id)
+			}
+			if panel, exists := r.panels[id]; exists {
+				panels[id] = panel
+			}
+			if ds, exists := r.dataSources[id]; exists {
+				dataSources[id] = ds
+			}
+		}
+		app.LinkChildPlugins(panels, dataSources, pm.Cfg)
+	}
+}
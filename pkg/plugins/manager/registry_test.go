@@ -0,0 +1,194 @@
+package manager
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginManager_Registry(t *testing.T) {
+	t.Run("Should not modify previous snapshots when updating registry", func(t *testing.T) {
+		pm := &PluginManager{Cfg: setting.NewCfg()}
+		pm.updateRegistry(func(r *pluginRegistry) {
+			r.plugins["panel"] = &plugins.PluginBase{Id: "panel", Type: "panel"}
+			r.staticRoutesByPlugin["panel"] = []*plugins.PluginStaticRoute{{PluginId: "panel"}}
+			r.staticRoutesByPlugin["app"] = []*plugins.PluginStaticRoute{{PluginId: "app"}}
+		})
+		snapshot := pm.registry()
+
+		err := pm.unregister(&plugins.PluginBase{Id: "panel", Type: "panel"})
+		require.NoError(t, err)
+
+		require.Contains(t, snapshot.plugins, "panel")
+		require.Len(t, snapshot.staticRoutes, 2)
+		require.Nil(t, pm.GetPlugin("panel"))
+		require.Len(t, pm.StaticRoutes(), 1)
+		require.Equal(t, "app", pm.StaticRoutes()[0].PluginId)
+	})
+
+	t.Run("Should read registry while it's updated", func(t *testing.T) {
+		pm := &PluginManager{Cfg: setting.NewCfg()}
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				pm.updateRegistry(func(r *pluginRegistry) {
+					r.dataSources["ds"] = &plugins.DataSourcePlugin{}
+					r.plugins["ds"] = &plugins.PluginBase{Id: "ds"}
+				})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_ = pm.Plugins()
+				_ = pm.GetDataSource("ds")
+				_ = pm.StaticRoutes()
+			}
+		}()
+		wg.Wait()
+
+		require.NotNil(t, pm.GetPlugin("ds"))
+	})
+	t.Run("Should return plugins by type", func(t *testing.T) {
+		pm := &PluginManager{Cfg: setting.NewCfg()}
+		pm.updateRegistry(func(r *pluginRegistry) {
+			r.plugins["panel-b"] = &plugins.PluginBase{Id: "panel-b", Type: "panel"}
+			r.plugins["panel-a"] = &plugins.PluginBase{Id: "panel-a", Type: "panel"}
+			r.plugins["ds"] = &plugins.PluginBase{Id: "ds", Type: "datasource"}
+			r.plugins["app"] = &plugins.PluginBase{Id: "app", Type: "app"}
+		})
+
+		pluginIDs := func(ps []*plugins.PluginBase) []string {
+			ids := make([]string, 0, len(ps))
+			for _, p := range ps {
+				ids = append(ids, p.Id)
+			}
+			return ids
+		}
+
+		require.Equal(t, []string{"app", "ds", "panel-a", "panel-b"}, pluginIDs(pm.Plugins()))
+		require.Equal(t, []string{"panel-a", "panel-b"}, pluginIDs(pm.Plugins("panel")))
+		require.Equal(t, []string{"app", "panel-a", "panel-b"}, pluginIDs(pm.Plugins("panel", "app")))
+		require.Empty(t, pm.Plugins("renderer"))
+
+		err := pm.unregister(&plugins.PluginBase{Id: "panel-a", Type: "panel"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"panel-b"}, pluginIDs(pm.Plugins("panel")))
+	})
+
+	t.Run("Should cache static routes until plugins are unregistered", func(t *testing.T) {
+		pm := &PluginManager{Cfg: setting.NewCfg()}
+		pm.updateRegistry(func(r *pluginRegistry) {
+			r.staticRoutesByPlugin["panel-b"] = []*plugins.PluginStaticRoute{{PluginId: "panel-b"}}
+			r.staticRoutesByPlugin["panel-a"] = []*plugins.PluginStaticRoute{{PluginId: "panel-a"}}
+			r.staticRoutesByPlugin["core"] = nil
+		})
+
+		routes := pm.StaticRoutes()
+		require.Len(t, routes, 2)
+		require.Equal(t, "panel-a", routes[0].PluginId)
+		require.Equal(t, "panel-b", routes[1].PluginId)
+		require.Contains(t, pm.registry().staticRoutesByPlugin, "core")
+		require.Same(t, routes[0], pm.StaticRoutes()[0])
+
+		err := pm.unregister(&plugins.PluginBase{Id: "panel-a", Type: "panel"})
+		require.NoError(t, err)
+		require.Len(t, pm.StaticRoutes(), 1)
+		require.Equal(t, "panel-b", pm.StaticRoutes()[0].PluginId)
+		require.NotContains(t, pm.registry().staticRoutesByPlugin, "panel-a")
+	})
+}
+
+func TestPluginManager_registerBatch(t *testing.T) {
+	newManager := func() *PluginManager {
+		cfg := setting.NewCfg()
+		cfg.StaticRootPath = filepath.FromSlash("/grafana/public")
+		return &PluginManager{Cfg: cfg}
+	}
+	newApp := func() *plugins.AppPlugin {
+		return &plugins.AppPlugin{FrontendPluginBase: plugins.FrontendPluginBase{PluginBase: plugins.PluginBase{
+			Id: "app", Type: "app", PluginDir: filepath.FromSlash("/var/plugins/app"),
+		}}}
+	}
+	newPanel := func() *plugins.PanelPlugin {
+		return &plugins.PanelPlugin{FrontendPluginBase: plugins.FrontendPluginBase{PluginBase: plugins.PluginBase{
+			Id: "panel", Type: "panel", PluginDir: filepath.FromSlash("/var/plugins/app/panel"),
+		}}}
+	}
+
+	t.Run("Should register the plugins of a batch at once and link them", func(t *testing.T) {
+		pm := newManager()
+		pm.registerBatch(&registryBatch{apps: []*plugins.AppPlugin{newApp()}, panels: []*plugins.PanelPlugin{newPanel()}})
+
+		app := pm.GetApp("app")
+		panel := pm.GetPanel("panel")
+		require.NotNil(t, app)
+		require.NotNil(t, panel)
+		require.Same(t, &app.PluginBase, panel.Parent)
+		require.Equal(t, []*plugins.PluginBase{&panel.PluginBase}, app.Children)
+		require.Same(t, pm.GetPlugin("panel"), &panel.PluginBase)
+		require.Len(t, pm.StaticRoutes(), 2)
+	})
+
+	t.Run("Should copy a registered app before linking a plugin it bundles", func(t *testing.T) {
+		pm := newManager()
+		pm.registerBatch(&registryBatch{apps: []*plugins.AppPlugin{newApp()}})
+		snapshot := pm.registry()
+		app := pm.GetApp("app")
+
+		pm.registerBatch(&registryBatch{panels: []*plugins.PanelPlugin{newPanel()}})
+
+		require.Empty(t, app.Children)
+		require.Same(t, app, snapshot.apps["app"])
+		require.NotContains(t, snapshot.plugins, "panel")
+		linked := pm.GetApp("app")
+		require.NotSame(t, app, linked)
+		require.Same(t, &linked.PluginBase, pm.GetPlugin("app"))
+		require.Len(t, linked.Children, 1)
+		require.Same(t, &linked.PluginBase, pm.GetPanel("panel").Parent)
+	})
+
+	t.Run("Should copy a registered panel before linking it to the app bundling it", func(t *testing.T) {
+		pm := newManager()
+		pm.registerBatch(&registryBatch{panels: []*plugins.PanelPlugin{newPanel()}})
+		panel := pm.GetPanel("panel")
+		module := panel.Module
+
+		pm.registerBatch(&registryBatch{apps: []*plugins.AppPlugin{newApp()}})
+
+		require.Nil(t, panel.Parent)
+		require.Equal(t, module, panel.Module)
+		require.Same(t, &pm.GetApp("app").PluginBase, pm.GetPanel("panel").Parent)
+		require.Equal(t, "plugins/app/panel/module", pm.GetPanel("panel").Module)
+	})
+
+	t.Run("Should copy the app of an unregistered plugin", func(t *testing.T) {
+		pm := newManager()
+		pm.registerBatch(&registryBatch{apps: []*plugins.AppPlugin{newApp()}, panels: []*plugins.PanelPlugin{newPanel()}})
+		app := pm.GetApp("app")
+
+		err := pm.unregister(pm.GetPlugin("panel"))
+		require.NoError(t, err)
+
+		require.Len(t, app.Children, 1)
+		require.Empty(t, pm.GetApp("app").Children)
+		require.Same(t, &pm.GetApp("app").PluginBase, pm.GetPlugin("app"))
+	})
+
+	t.Run("Should set the signature of core plugins before registering them", func(t *testing.T) {
+		pm := newManager()
+		panel := newPanel()
+		panel.PluginDir = filepath.FromSlash("/grafana/public/app/plugins/panel/panel")
+		pm.registerBatch(&registryBatch{panels: []*plugins.PanelPlugin{panel}})
+
+		require.True(t, pm.GetPlugin("panel").IsCorePlugin)
+		require.Equal(t, plugins.PluginSignatureInternal, pm.GetPlugin("panel").Signature)
+	})
+}
@@ -0,0 +1,39 @@
+package manager
+
+import (
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// declareRoles declares the roles of a plugin to the access control service, replacing the roles declared by the
+// versions of the plugin loaded before.
+func (pm *PluginManager) declareRoles(plugin *plugins.PluginBase) error {
+	if pm.accessControl == nil || len(plugin.Roles) == 0 {
+		return nil
+	}
+
+	registrations := make([]accesscontrol.RoleRegistration, 0, len(plugin.Roles))
+	for _, r := range plugin.Roles {
+		role := accesscontrol.RoleDTO{
+			Name:        r.Role.Name,
+			Description: r.Role.Description,
+			Version:     r.Role.Version,
+			Permissions: make([]accesscontrol.Permission, 0, len(r.Role.Permissions)),
+		}
+		for _, p := range r.Role.Permissions {
+			role.Permissions = append(role.Permissions, accesscontrol.Permission{Action: p.Action, Scope: p.Scope})
+		}
+		registrations = append(registrations, accesscontrol.RoleRegistration{Role: role, Grants: r.Grants})
+	}
+
+	return pm.accessControl.DeclarePluginRoles(plugin.Id, registrations...)
+}
+
+// removeRoles removes the roles declared by a plugin from the access control service.
+func (pm *PluginManager) removeRoles(plugin *plugins.PluginBase) {
+	if pm.accessControl == nil {
+		return
+	}
+
+	pm.accessControl.RemovePluginRoles(plugin.Id)
+}
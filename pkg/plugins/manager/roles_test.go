@@ -0,0 +1,91 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginManager_Roles(t *testing.T) {
+	writeApp := func(t *testing.T, pluginsDir, id, roles string) {
+		dir := filepath.Join(pluginsDir, id)
+		require.NoError(t, os.MkdirAll(dir, 0750))
+		pluginJSON := fmt.Sprintf(`{"type": "app", "id": %q, "name": %q, "info": {"version": "1.0.0"},
+			"roles": %s}`, id, id, roles)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugin.json"), []byte(pluginJSON), 0600))
+	}
+	newRolesManager := func(t *testing.T, pluginsDir string, ac accesscontrol.AccessControl) *PluginManager {
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.BackendPluginManager = &fakeBackendPluginManager{}
+			pm.Cfg.PluginsPath = pluginsDir
+			pm.Cfg.PluginsAllowUnsigned = []string{"test-app"}
+			pm.accessControl = ac
+		})
+		require.NoError(t, pm.init())
+		pm.pluginInstaller = &fakePluginInstaller{}
+		return pm
+	}
+
+	t.Run("Declares the roles of plugins and removes them on uninstall", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writeApp(t, pluginsDir, "test-app", `[{
+			"role": {"name": "plugins:test-app:reader", "description": "Read reports", "version": 2,
+				"permissions": [{"action": "test-app.reports:read", "scope": "test-app.reports:*"}]},
+			"grants": ["Viewer"]
+		}]`)
+		ac := mock.New()
+		pm := newRolesManager(t, pluginsDir, ac)
+
+		require.NotNil(t, pm.GetPlugin("test-app"))
+		require.Equal(t, []interface{}{[]interface{}{"test-app", []accesscontrol.RoleRegistration{{
+			Role: accesscontrol.RoleDTO{
+				Name:        "plugins:test-app:reader",
+				Description: "Read reports",
+				Version:     2,
+				Permissions: []accesscontrol.Permission{
+					{Action: "test-app.reports:read", Scope: "test-app.reports:*"},
+				},
+			},
+			Grants: []string{"Viewer"},
+		}}}}, ac.Calls.DeclarePluginRoles)
+
+		require.NoError(t, pm.Uninstall(context.Background(), "test-app"))
+		assert.Equal(t, []interface{}{[]interface{}{"test-app"}}, ac.Calls.RemovePluginRoles)
+	})
+
+	t.Run("Doesn't declare roles of plugins without roles", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writeApp(t, pluginsDir, "test-app", `[]`)
+		ac := mock.New()
+		pm := newRolesManager(t, pluginsDir, ac)
+
+		require.NotNil(t, pm.GetPlugin("test-app"))
+		assert.Empty(t, ac.Calls.DeclarePluginRoles)
+	})
+
+	t.Run("Won't load plugins with invalid roles", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		writeApp(t, pluginsDir, "test-app", `[{"role": {"name": "fixed:reports:reader"}, "grants": ["Viewer"]}]`)
+		ac := mock.New()
+		ac.DeclarePluginRolesFunc = func(string, ...accesscontrol.RoleRegistration) error {
+			return accesscontrol.ErrPluginRolePrefixMissing
+		}
+		pm := newRolesManager(t, pluginsDir, ac)
+
+		assert.Nil(t, pm.GetPlugin("test-app"))
+		failures := pm.InitFailures()
+		require.Len(t, failures, 1)
+		assert.Equal(t, "test-app", failures[0].PluginID)
+		assert.Equal(t, plugins.InitStageRegister, failures[0].Stage)
+		assert.Contains(t, failures[0].Error, accesscontrol.ErrPluginRolePrefixMissing.Error())
+	})
+}
@@ -0,0 +1,182 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+const (
+	// pluginsDirLockFile is the file in the plugins directory locked while plugins are installed and uninstalled.
+	pluginsDirLockFile = ".grafana-plugins.lock"
+	// pluginsDirGenerationFile is the file in the plugins directory with its install generation, which is incremented
+	// every time plugins are installed and uninstalled.
+	pluginsDirGenerationFile = ".grafana-plugins.generation"
+)
+
+// sharedPluginsDirInterval returns the interval of checking whether other Grafana instances changed the plugins in the
+// plugins directory, or 0 if the plugins directory isn't shared with other instances.
+func (pm *PluginManager) sharedPluginsDirInterval() time.Duration {
+	return time.Duration(pm.Cfg.PluginsSharedPathInterval) * time.Second
+}
+
+// modifyPluginsDir runs modify, which installs or uninstalls plugins, while holding the lock of the plugins directory
+// if it's shared with other Grafana instances, so that their installs don't interfere with each other. The install
+// generation of the plugins directory is incremented afterwards, even if modify failed, since it may have changed the
+// directory partially.
+func (pm *PluginManager) modifyPluginsDir(modify func() error) error {
+	if pm.sharedPluginsDirInterval() <= 0 {
+		return modify()
+	}
+
+	unlock, err := lockFile(filepath.Join(pm.Cfg.PluginsPath, pluginsDirLockFile))
+	if err != nil {
+		return fmt.Errorf("failed to lock plugins directory: %w", err)
+	}
+	defer unlock()
+
+	generation, genErr := readPluginsDirGeneration(pm.Cfg.PluginsPath)
+	err = modify()
+	if genErr != nil {
+		pm.log.Error("Failed to read install generation of plugins directory", "error", genErr)
+		return err
+	}
+
+	if writeErr := writePluginsDirGeneration(pm.Cfg.PluginsPath, generation+1); writeErr != nil {
+		pm.log.Error("Failed to write install generation of plugins directory", "error", writeErr)
+		return err
+	}
+	// if other instances changed the plugins since they were last loaded, they're still to be reloaded
+	atomic.CompareAndSwapInt64(&pm.pluginsDirGeneration, generation, generation+1)
+	return err
+}
+
+// recordPluginsDirGeneration records the install generation of the shared plugins directory before its plugins are
+// loaded.
+func (pm *PluginManager) recordPluginsDirGeneration() {
+	if pm.sharedPluginsDirInterval() <= 0 {
+		return
+	}
+
+	generation, err := readPluginsDirGeneration(pm.Cfg.PluginsPath)
+	if err != nil {
+		pm.log.Error("Failed to read install generation of plugins directory", "error", err)
+		return
+	}
+	atomic.StoreInt64(&pm.pluginsDirGeneration, generation)
+}
+
+// reloadSharedPluginsDir reloads the plugins of the shared plugins directory if other Grafana instances installed or
+// uninstalled plugins since they were last loaded. Plugins whose files were removed are unloaded, plugins whose
+// version changed are unloaded and loaded again, and new plugins are loaded.
+func (pm *PluginManager) reloadSharedPluginsDir(ctx context.Context) {
+	pm.pluginsDirReloadMu.Lock()
+	defer pm.pluginsDirReloadMu.Unlock()
+
+	generation, err := readPluginsDirGeneration(pm.Cfg.PluginsPath)
+	if err != nil {
+		pm.log.Error("Failed to read install generation of plugins directory", "error", err)
+		return
+	}
+	if generation == atomic.LoadInt64(&pm.pluginsDirGeneration) {
+		return
+	}
+
+	pm.log.Info("Reloading plugins changed by another Grafana instance", "generation", generation)
+	for _, plugin := range pm.Plugins() {
+		// children are reloaded along with their app
+		if plugin.IsCorePlugin || plugin.Parent != nil || !pm.inPluginsDir(plugin) {
+			continue
+		}
+		pm.reloadSharedPlugin(ctx, plugin)
+	}
+
+	atomic.StoreInt64(&pm.pluginsDirGeneration, generation)
+	if err := pm.initExternalPlugins(); err != nil {
+		pm.log.Error("Failed to load plugins changed by another Grafana instance", "error", err)
+	}
+}
+
+// reloadSharedPlugin unloads a plugin of the shared plugins directory if its files were removed or its version
+// changed.
+func (pm *PluginManager) reloadSharedPlugin(ctx context.Context, plugin *plugins.PluginBase) {
+	unlock := pm.installLocks.lock(plugin.Id)
+	defer unlock()
+
+	version, err := readPluginVersion(plugin.PluginDir)
+	if err == nil && version == plugin.Info.Version {
+		return
+	}
+
+	pm.log.Info("Unloading plugin changed by another Grafana instance", "pluginId", plugin.Id)
+	if err := pm.unload(ctx, plugin); err != nil {
+		pm.log.Error("Failed to unload plugin changed by another Grafana instance", "pluginId", plugin.Id,
+			"error", err)
+	}
+}
+
+// readPluginVersion returns the version in the plugin.json file of a plugin directory.
+func readPluginVersion(pluginDir string) (string, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because the plugin directory comes from the plugin registry
+	data, err := ioutil.ReadFile(filepath.Join(pluginDir, "plugin.json"))
+	if err != nil {
+		return "", err
+	}
+
+	var pluginJSON struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(data, &pluginJSON); err != nil {
+		return "", err
+	}
+	return pluginJSON.Info.Version, nil
+}
+
+// readPluginsDirGeneration returns the install generation of a plugins directory, which is 0 until plugins are
+// installed or uninstalled.
+func readPluginsDirGeneration(pluginsDir string) (int64, error) {
+	// nolint:gosec
+	data, err := ioutil.ReadFile(filepath.Join(pluginsDir, pluginsDirGenerationFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// writePluginsDirGeneration replaces the install generation of a plugins directory, so that it's never read partially
+// written.
+func writePluginsDirGeneration(pluginsDir string, generation int64) error {
+	tmp, err := ioutil.TempFile(pluginsDir, pluginsDirGenerationFile+"-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.WriteString(strconv.FormatInt(generation, 10)); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(pluginsDir, pluginsDirGenerationFile))
+}
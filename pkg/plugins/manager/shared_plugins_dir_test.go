@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginManager_SharedPluginsDir(t *testing.T) {
+	// newSharedManager returns a manager loading plugins from the shared plugins directory pluginsDir
+	newSharedManager := func(t *testing.T, pluginsDir string) *PluginManager {
+		pm := createManager(t, func(pm *PluginManager) {
+			pm.BackendPluginManager = &fakeBackendPluginManager{}
+			pm.Cfg.PluginsPath = pluginsDir
+			pm.Cfg.PluginsSharedPathInterval = 10
+			pm.Cfg.PluginsAllowUnsigned = []string{"test"}
+		})
+		require.NoError(t, pm.init())
+		pm.pluginInstaller = &fakePluginInstaller{}
+		return pm
+	}
+	writePlugin := func(t *testing.T, pluginsDir, version string) {
+		pluginJSON, err := ioutil.ReadFile("testdata/installer/plugin/plugin.json")
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Join(pluginsDir, "test"), 0750))
+		pluginJSON = []byte(strings.Replace(string(pluginJSON), `"version": "1.0.0"`,
+			`"version": "`+version+`"`, 1))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(pluginsDir, "test", "plugin.json"), pluginJSON, 0600))
+	}
+
+	t.Run("Increments the install generation when plugins are installed", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		pm := newSharedManager(t, pluginsDir)
+
+		err := pm.Install(context.Background(), "test", "1.0.0", plugins.InstallOpts{})
+		require.NoError(t, err)
+
+		generation, err := readPluginsDirGeneration(pluginsDir)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), generation)
+		assert.Equal(t, int64(1), pm.pluginsDirGeneration)
+		assert.FileExists(t, filepath.Join(pluginsDir, pluginsDirLockFile))
+	})
+
+	t.Run("Reloads plugins changed by other instances", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		pm := newSharedManager(t, pluginsDir)
+		other := newSharedManager(t, pluginsDir)
+
+		// another instance installs the plugin
+		require.NoError(t, other.modifyPluginsDir(func() error {
+			writePlugin(t, pluginsDir, "1.0.0")
+			return nil
+		}))
+		pm.reloadSharedPluginsDir(context.Background())
+		require.NotNil(t, pm.GetPlugin("test"))
+		assert.Equal(t, "1.0.0", pm.GetPlugin("test").Info.Version)
+
+		// another instance upgrades the plugin
+		require.NoError(t, other.modifyPluginsDir(func() error {
+			writePlugin(t, pluginsDir, "2.0.0")
+			return nil
+		}))
+		pm.reloadSharedPluginsDir(context.Background())
+		require.NotNil(t, pm.GetPlugin("test"))
+		assert.Equal(t, "2.0.0", pm.GetPlugin("test").Info.Version)
+
+		// another instance uninstalls the plugin
+		require.NoError(t, other.modifyPluginsDir(func() error {
+			return os.RemoveAll(filepath.Join(pluginsDir, "test"))
+		}))
+		pm.reloadSharedPluginsDir(context.Background())
+		assert.Nil(t, pm.GetPlugin("test"))
+	})
+
+	t.Run("Doesn't reload plugins without changes", func(t *testing.T) {
+		pluginsDir := t.TempDir()
+		pm := newSharedManager(t, pluginsDir)
+
+		// plugins copied without incrementing the generation aren't loaded
+		writePlugin(t, pluginsDir, "1.0.0")
+		pm.reloadSharedPluginsDir(context.Background())
+		assert.Nil(t, pm.GetPlugin("test"))
+	})
+}
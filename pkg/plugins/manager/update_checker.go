@@ -2,12 +2,16 @@ package manager
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/manager/installer"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/hashicorp/go-version"
 )
@@ -17,8 +21,9 @@ var (
 )
 
 type grafanaNetPlugin struct {
-	Slug    string `json:"slug"`
-	Version string `json:"version"`
+	Slug        string                     `json:"slug"`
+	Version     string                     `json:"version"`
+	Deprecation *plugins.PluginDeprecation `json:"deprecation"`
 }
 
 type gitHubLatest struct {
@@ -28,7 +33,7 @@ type gitHubLatest struct {
 
 func (pm *PluginManager) getAllExternalPluginSlugs() string {
 	var result []string
-	for _, plug := range pm.plugins {
+	for _, plug := range pm.registry().plugins {
 		if plug.IsCorePlugin {
 			continue
 		}
@@ -71,22 +76,7 @@ func (pm *PluginManager) checkForUpdates() {
 		return
 	}
 
-	for _, plug := range pm.Plugins() {
-		for _, gplug := range gNetPlugins {
-			if gplug.Slug == plug.Id {
-				plug.GrafanaNetVersion = gplug.Version
-
-				plugVersion, err1 := version.NewVersion(plug.Info.Version)
-				gplugVersion, err2 := version.NewVersion(gplug.Version)
-
-				if err1 != nil || err2 != nil {
-					plug.GrafanaNetHasUpdate = plug.Info.Version != plug.GrafanaNetVersion
-				} else {
-					plug.GrafanaNetHasUpdate = plugVersion.LessThan(gplugVersion)
-				}
-			}
-		}
-	}
+	pm.updateGrafanaNetInfo(gNetPlugins)
 
 	resp2, err := httpClient.Get("https://raw.githubusercontent.com/grafana/grafana/main/latest.json")
 	if err != nil {
@@ -125,3 +115,77 @@ func (pm *PluginManager) checkForUpdates() {
 		pm.grafanaHasUpdate = currVersion.LessThan(latestVersion)
 	}
 }
+
+// updateGrafanaNetInfo updates the latest versions and the deprecations of the installed plugins reported by
+// grafana.com. Plugins the catalog newly deprecates are logged, unless their metadata already deprecates them.
+func (pm *PluginManager) updateGrafanaNetInfo(gNetPlugins []grafanaNetPlugin) {
+	var deprecated []*plugins.PluginBase
+	pm.updateRegistry(func(r *pluginRegistry) {
+		for _, gplug := range gNetPlugins {
+			if r.plugins[gplug.Slug] == nil {
+				continue
+			}
+			plug := r.copyPlugin(gplug.Slug)
+			plug.GrafanaNetVersion = gplug.Version
+
+			plugVersion, err1 := version.NewVersion(plug.Info.Version)
+			gplugVersion, err2 := version.NewVersion(gplug.Version)
+
+			if err1 != nil || err2 != nil {
+				plug.GrafanaNetHasUpdate = plug.Info.Version != plug.GrafanaNetVersion
+			} else {
+				plug.GrafanaNetHasUpdate = plugVersion.LessThan(gplugVersion)
+			}
+
+			newlyDeprecated := plug.GrafanaNetDeprecation == nil && gplug.Deprecation != nil
+			plug.GrafanaNetDeprecation = gplug.Deprecation
+			if newlyDeprecated && plug.Deprecation == nil {
+				deprecated = append(deprecated, plug)
+			}
+		}
+	})
+
+	for _, plug := range deprecated {
+		pm.warnIfDeprecated(plug)
+	}
+}
+
+// CheckUpdate checks whether the plugin repository has a newer version of an installed plugin, supported by the
+// running system.
+func (pm *PluginManager) CheckUpdate(pluginID string) (plugins.PluginUpdate, error) {
+	plugin := pm.GetPlugin(pluginID)
+	if plugin == nil {
+		return plugins.PluginUpdate{}, plugins.PluginNotFoundError{PluginID: pluginID}
+	}
+	if plugin.IsCorePlugin {
+		return plugins.PluginUpdate{}, plugins.ErrUpdateCorePlugin
+	}
+
+	update := plugins.PluginUpdate{
+		PluginID:         plugin.Id,
+		InstalledVersion: plugin.Info.Version,
+	}
+
+	updateInfo, err := pm.pluginInstaller.GetUpdateInfo(plugin.Id, "", grafanaComURL)
+	if err != nil {
+		var unsupported installer.ErrVersionUnsupported
+		if errors.As(err, &unsupported) {
+			return update, nil
+		}
+		return plugins.PluginUpdate{}, err
+	}
+
+	update.LatestVersion = updateInfo.Version
+	installedVersion, err1 := version.NewVersion(plugin.Info.Version)
+	latestVersion, err2 := version.NewVersion(updateInfo.Version)
+	if err1 != nil || err2 != nil {
+		update.UpdateAvailable = plugin.Info.Version != updateInfo.Version
+	} else {
+		update.UpdateAvailable = installedVersion.LessThan(latestVersion)
+	}
+	if update.UpdateAvailable {
+		update.ChangelogURL = fmt.Sprintf("%s/grafana/plugins/%s?tab=changelog", pm.Cfg.GrafanaComURL, plugin.Id)
+	}
+
+	return update, nil
+}
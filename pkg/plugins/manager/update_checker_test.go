@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/manager/installer"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginManager_CheckUpdate(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.GrafanaComURL = "https://grafana.com"
+	fakeInstaller := &fakePluginInstaller{}
+	pm := &PluginManager{
+		Cfg:             cfg,
+		pluginInstaller: fakeInstaller,
+	}
+	pm.updateRegistry(func(r *pluginRegistry) {
+		r.plugins = map[string]*plugins.PluginBase{
+			"test": {Id: "test", Info: plugins.PluginInfo{Version: "1.2.0"}},
+			"core": {Id: "core", IsCorePlugin: true},
+		}
+	})
+
+	t.Run("Should report available update", func(t *testing.T) {
+		fakeInstaller.updateInfo = plugins.UpdateInfo{Version: "1.10.0"}
+
+		update, err := pm.CheckUpdate("test")
+		require.NoError(t, err)
+		require.Equal(t, plugins.PluginUpdate{
+			PluginID:         "test",
+			InstalledVersion: "1.2.0",
+			LatestVersion:    "1.10.0",
+			UpdateAvailable:  true,
+			ChangelogURL:     "https://grafana.com/grafana/plugins/test?tab=changelog",
+		}, update)
+	})
+
+	t.Run("Should not report older versions as update", func(t *testing.T) {
+		fakeInstaller.updateInfo = plugins.UpdateInfo{Version: "1.1.0"}
+
+		update, err := pm.CheckUpdate("test")
+		require.NoError(t, err)
+		require.False(t, update.UpdateAvailable)
+		require.Equal(t, "1.1.0", update.LatestVersion)
+		require.Empty(t, update.ChangelogURL)
+	})
+
+	t.Run("Should not report update if no version is supported", func(t *testing.T) {
+		fakeInstaller.updateInfoErr = installer.ErrVersionUnsupported{PluginID: "test"}
+		t.Cleanup(func() { fakeInstaller.updateInfoErr = nil })
+
+		update, err := pm.CheckUpdate("test")
+		require.NoError(t, err)
+		require.False(t, update.UpdateAvailable)
+		require.Empty(t, update.LatestVersion)
+	})
+
+	t.Run("Should return errors for unknown and core plugins", func(t *testing.T) {
+		_, err := pm.CheckUpdate("unknown")
+		require.ErrorAs(t, err, &plugins.PluginNotFoundError{})
+
+		_, err = pm.CheckUpdate("core")
+		require.ErrorIs(t, err, plugins.ErrUpdateCorePlugin)
+	})
+}
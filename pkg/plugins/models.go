@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
@@ -19,6 +20,10 @@ var (
 	ErrUninstallCorePlugin         = errors.New("cannot uninstall a Core plugin")
 	ErrUninstallOutsideOfPluginDir = errors.New("cannot uninstall a plugin outside")
 	ErrPluginNotInstalled          = errors.New("plugin is not installed")
+	ErrUpdateCorePlugin            = errors.New("cannot update a Core plugin")
+	ErrPluginMarkdownNotSigned     = errors.New("plugin markdown file is not included in the plugin signature")
+	ErrCanaryNotInstalled          = errors.New("plugin has no canary version")
+	ErrCanaryNotBackend            = errors.New("only backend plugins can have canary versions")
 )
 
 type PluginNotFoundError struct {
@@ -44,6 +49,30 @@ func (e DuplicatePluginError) Is(err error) bool {
 	return ok
 }
 
+// DowngradeError is returned when installing a lower version of an installed plugin without allowing downgrades.
+type DowngradeError struct {
+	PluginID         string
+	InstalledVersion string
+	Version          string
+}
+
+func (e DowngradeError) Error() string {
+	return fmt.Sprintf("plugin with ID '%s' is installed in the higher version %s than %s", e.PluginID,
+		e.InstalledVersion, e.Version)
+}
+
+func (e DowngradeError) Is(err error) bool {
+	// nolint:errorlint
+	_, ok := err.(DowngradeError)
+	return ok
+}
+
+// InstallOpts are the options of installing a plugin.
+type InstallOpts struct {
+	// AllowDowngrade allows replacing an installed plugin with a lower version.
+	AllowDowngrade bool
+}
+
 // PluginLoader can load a plugin.
 type PluginLoader interface {
 	// Load loads a plugin and returns it.
@@ -52,31 +81,39 @@ type PluginLoader interface {
 
 // PluginBase is the base plugin type.
 type PluginBase struct {
-	Type         string                `json:"type"`
-	Name         string                `json:"name"`
-	Id           string                `json:"id"`
-	Info         PluginInfo            `json:"info"`
-	Dependencies PluginDependencies    `json:"dependencies"`
-	Includes     []*PluginInclude      `json:"includes"`
-	Module       string                `json:"module"`
-	BaseUrl      string                `json:"baseUrl"`
-	Category     string                `json:"category"`
-	HideFromList bool                  `json:"hideFromList,omitempty"`
-	Preload      bool                  `json:"preload"`
-	State        PluginState           `json:"state,omitempty"`
-	Signature    PluginSignatureStatus `json:"signature"`
-	Backend      bool                  `json:"backend"`
-
-	IncludedInAppId string              `json:"-"`
+	Type         string                   `json:"type"`
+	Name         string                   `json:"name"`
+	Id           string                   `json:"id"`
+	Info         PluginInfo               `json:"info"`
+	Dependencies PluginDependencies       `json:"dependencies"`
+	Includes     []*PluginInclude         `json:"includes"`
+	Roles        []PluginRoleRegistration `json:"roles,omitempty"`
+	Module       string                   `json:"module"`
+	BaseUrl      string                   `json:"baseUrl"`
+	Category     string                   `json:"category"`
+	HideFromList bool                     `json:"hideFromList,omitempty"`
+	Preload      bool                     `json:"preload"`
+	State        PluginState              `json:"state,omitempty"`
+	Deprecation  *PluginDeprecation       `json:"deprecation,omitempty"`
+	Signature    PluginSignatureStatus    `json:"signature"`
+	Backend      bool                     `json:"backend"`
+
 	PluginDir       string              `json:"-"`
 	DefaultNavUrl   string              `json:"-"`
 	IsCorePlugin    bool                `json:"-"`
 	SignatureType   PluginSignatureType `json:"-"`
 	SignatureOrg    string              `json:"-"`
 	SignedFiles     PluginFiles         `json:"-"`
+	AngularDetected bool                `json:"-"`
 
-	GrafanaNetVersion   string `json:"-"`
-	GrafanaNetHasUpdate bool   `json:"-"`
+	GrafanaNetVersion     string             `json:"-"`
+	GrafanaNetHasUpdate   bool               `json:"-"`
+	GrafanaNetDeprecation *PluginDeprecation `json:"-"`
+
+	// Parent is the app plugin bundling the plugin, which it was loaded as a child of. Children are the plugins
+	// bundled by an app plugin.
+	Parent   *PluginBase   `json:"-"`
+	Children []*PluginBase `json:"-"`
 
 	Root *PluginBase
 }
@@ -98,6 +135,12 @@ func (p *PluginBase) IncludedInSignature(file string) bool {
 	return true
 }
 
+// PluginContainer describes the container image of a backend plugin, which the plugin runs in when Grafana is
+// configured to run backend plugins in containers.
+type PluginContainer struct {
+	Image string `json:"image"`
+}
+
 type PluginDependencies struct {
 	GrafanaVersion string                 `json:"grafanaVersion"`
 	Plugins        []PluginDependencyItem `json:"plugins"`
@@ -126,6 +169,42 @@ func (e PluginInclude) GetSlugOrUIDLink() string {
 	}
 }
 
+// PluginDeprecation describes why a plugin is deprecated, and which plugin replaces it.
+type PluginDeprecation struct {
+	Reason     string `json:"reason,omitempty"`
+	ReplacedBy string `json:"replacedBy,omitempty"`
+}
+
+// DeprecationInfo returns the deprecation of the plugin declared by its metadata, or else by the plugin catalog. It
+// returns nil if the plugin isn't deprecated.
+func (pb *PluginBase) DeprecationInfo() *PluginDeprecation {
+	if pb.Deprecation != nil {
+		return pb.Deprecation
+	}
+	return pb.GrafanaNetDeprecation
+}
+
+// PluginRoleRegistration is a role a plugin declares for fine-grained access control, and the built-in roles
+// ("Viewer", "Editor", "Admin") or "Grafana Admin" it's granted to.
+type PluginRoleRegistration struct {
+	Role   PluginRole `json:"role"`
+	Grants []string   `json:"grants"`
+}
+
+// PluginRole is a role declared by a plugin. Its name is prefixed with "plugins:<plugin ID>:".
+type PluginRole struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Version     int64              `json:"version"`
+	Permissions []PluginPermission `json:"permissions"`
+}
+
+// PluginPermission is a permission granted by a plugin role. Its action is prefixed with the plugin ID.
+type PluginPermission struct {
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+}
+
 type PluginDependencyItem struct {
 	Type    string `json:"type"`
 	Id      string `json:"id"`
@@ -149,6 +228,7 @@ type PluginInfo struct {
 	Screenshots []PluginScreenshots `json:"screenshots"`
 	Version     string              `json:"version"`
 	Updated     string              `json:"updated"`
+	Keywords    []string            `json:"keywords,omitempty"`
 }
 
 type PluginInfoLink struct {
@@ -177,6 +257,153 @@ type EnabledPlugins struct {
 	Apps        []*AppPlugin
 }
 
+// ScannedPlugin describes a plugin found by a scan that doesn't register or start anything. Plugins whose
+// plugin.json couldn't be loaded only have their PluginDir and Error set.
+type ScannedPlugin struct {
+	ID            string                `json:"id"`
+	Type          string                `json:"type"`
+	Version       string                `json:"version"`
+	PluginDir     string                `json:"pluginDir"`
+	Signature     PluginSignatureStatus `json:"signature"`
+	SignatureType PluginSignatureType   `json:"signatureType,omitempty"`
+	SignatureOrg  string                `json:"signatureOrg,omitempty"`
+	AlreadyLoaded bool                  `json:"alreadyLoaded"`
+	Error         *PluginError          `json:"error,omitempty"`
+}
+
+// PluginDependencyGraph is the dependency graph of the loaded plugins and of the plugins refused because their
+// dependencies aren't satisfied.
+type PluginDependencyGraph struct {
+	GrafanaVersion string `json:"grafanaVersion"`
+	// Enforced is whether plugins whose dependencies aren't satisfied are refused, rather than only flagged.
+	Enforced bool                   `json:"enforced"`
+	Plugins  []PluginDependencyNode `json:"plugins"`
+}
+
+// PluginDependencyNode is a plugin in the dependency graph.
+type PluginDependencyNode struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	// GrafanaVersion is the constraint on the Grafana version, empty if none.
+	GrafanaVersion          string `json:"grafanaVersion,omitempty"`
+	GrafanaVersionSatisfied bool   `json:"grafanaVersionSatisfied"`
+	// Dependencies are the plugins the plugin depends on.
+	Dependencies []PluginDependencyEdge `json:"dependencies"`
+	// Dependents are the IDs of the plugins depending on the plugin, sorted.
+	Dependents []string `json:"dependents"`
+	// Satisfied is whether the Grafana version and all dependencies satisfy the constraints of the plugin.
+	Satisfied bool `json:"satisfied"`
+	// Loaded is false if the plugin was refused because its dependencies aren't satisfied.
+	Loaded bool `json:"loaded"`
+}
+
+// PluginDependencyEdge is a dependency of a plugin on another plugin.
+type PluginDependencyEdge struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Version is the constraint on the version of the dependency.
+	Version string `json:"version"`
+	// Installed is whether the dependency is loaded.
+	Installed        bool   `json:"installed"`
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	Satisfied        bool   `json:"satisfied"`
+}
+
+// PluginsHealth is an aggregated health summary of the backend plugin processes.
+type PluginsHealth struct {
+	NotStarted     int            `json:"notStarted"`
+	Running        int            `json:"running"`
+	Degraded       int            `json:"degraded"`
+	CrashLooping   int            `json:"crashLooping"`
+	Decommissioned int            `json:"decommissioned"`
+	Failed         int            `json:"failed"`
+	Plugins        []PluginHealth `json:"plugins,omitempty"`
+}
+
+// Healthy returns whether no backend plugin is crash-looping or failed.
+func (h PluginsHealth) Healthy() bool {
+	return h.CrashLooping == 0 && h.Failed == 0
+}
+
+// PluginHealth is the health of a backend plugin process.
+type PluginHealth struct {
+	ID          string                     `json:"id"`
+	Status      backendplugin.PluginStatus `json:"status"`
+	Managed     bool                       `json:"managed"`
+	Restarts    int                        `json:"restarts"`
+	LastRestart *time.Time                 `json:"lastRestart,omitempty"`
+}
+
+// PluginRuntimeState is a registered plugin with the runtime state of its backend process.
+type PluginRuntimeState struct {
+	ID        string                `json:"id"`
+	Name      string                `json:"name"`
+	Type      string                `json:"type"`
+	Version   string                `json:"version"`
+	Signature PluginSignatureStatus `json:"signature"`
+	Backend   bool                  `json:"backend"`
+	// Process is the state of the backend process, nil for plugins without a registered backend.
+	Process *PluginProcessState `json:"process,omitempty"`
+}
+
+// PluginProcessState is the runtime state of a backend plugin process.
+type PluginProcessState struct {
+	Status        backendplugin.PluginStatus `json:"status"`
+	Managed       bool                       `json:"managed"`
+	LastError     string                     `json:"lastError,omitempty"`
+	Restarts      int                        `json:"restarts"`
+	LastRestart   *time.Time                 `json:"lastRestart,omitempty"`
+	StartedAt     *time.Time                 `json:"startedAt,omitempty"`
+	UptimeSeconds int64                      `json:"uptimeSeconds"`
+}
+
+// PluginListQuery filters and paginates the plugins listed by Manager.ListPlugins. Empty and nil filters match
+// all plugins.
+type PluginListQuery struct {
+	// OrgID is the organization whose plugin settings are used for filtering by enabled state.
+	OrgID int64
+	// Query is a full-text query. Each of its words has to be contained, ignoring case, in the ID, name,
+	// description, author, category or keywords of a plugin.
+	Query    string
+	Types    []string
+	Category string
+	// Keywords are the keywords a plugin has to have all of, ignoring case.
+	Keywords  []string
+	Signature PluginSignatureStatus
+	State     PluginState
+	Enabled   *bool
+	HasUpdate *bool
+	Core      *bool
+	Embedded  *bool
+	// Page is the 1-based page of plugins to list and PerPage the number of plugins per page. If PerPage is 0,
+	// all matching plugins are listed.
+	Page    int
+	PerPage int
+}
+
+// PluginListItem is a listed plugin with its settings in the organization of the query.
+type PluginListItem struct {
+	Plugin   *PluginBase
+	Settings *models.PluginSettingInfoDTO
+}
+
+// PluginListResult is a page of listed plugins, sorted by name, with the total number of matching plugins.
+type PluginListResult struct {
+	TotalCount int
+	Plugins    []PluginListItem
+}
+
 type UpdateInfo struct {
 	PluginZipURL string
+	Version      string
+}
+
+// PluginUpdate tells whether a newer version of an installed plugin, supported by the running system, is available.
+type PluginUpdate struct {
+	PluginID         string `json:"pluginId"`
+	InstalledVersion string `json:"installedVersion"`
+	LatestVersion    string `json:"latestVersion,omitempty"`
+	UpdateAvailable  bool   `json:"updateAvailable"`
+	ChangelogURL     string `json:"changelogUrl,omitempty"`
 }
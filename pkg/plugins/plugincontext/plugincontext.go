@@ -1,6 +1,7 @@
 package plugincontext
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"time"
@@ -8,22 +9,26 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
+	"github.com/grafana/grafana/pkg/plugins/secrets"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
 func ProvideService(bus bus.Bus, cacheService *localcache.CacheService, pluginManager plugins.Manager,
 	dataSourceCache datasources.CacheService) *Provider {
-	return &Provider{
+	p := &Provider{
 		Bus:             bus,
 		CacheService:    cacheService,
 		PluginManager:   pluginManager,
 		DataSourceCache: dataSourceCache,
 	}
+	bus.AddEventListener(p.handlePluginSettingUpdated)
+	return p
 }
 
 type Provider struct {
@@ -59,8 +64,16 @@ func (p *Provider) Get(pluginID string, datasourceUID string, user *models.Signe
 		if err != nil {
 			return pc, false, errutil.Wrap("Failed to unmarshal plugin json data", err)
 		}
-		decryptedSecureJSONData = ps.DecryptedValues()
+		var secretsChanged time.Time
+		decryptedSecureJSONData, secretsChanged, err = secrets.Resolve(context.Background(),
+			secrets.AppOwner(ps.OrgId, pluginID), ps.DecryptedValues())
+		if err != nil {
+			return pc, false, errutil.Wrap("Failed to resolve plugin secrets", err)
+		}
 		updated = ps.Updated
+		if secretsChanged.After(updated) {
+			updated = secretsChanged
+		}
 	}
 
 	pCtx := backend.PluginContext{
@@ -110,3 +123,10 @@ func (p *Provider) getCachedPluginSettings(pluginID string, user *models.SignedI
 	p.CacheService.Set(cacheKey, query.Result, pluginSettingsCacheTTL)
 	return query.Result, nil
 }
+
+// handlePluginSettingUpdated drops the cached settings of a plugin whose settings were updated, so that the
+// plugin context of its next request carries the new settings.
+func (p *Provider) handlePluginSettingUpdated(evt *events.PluginSettingUpdated) error {
+	p.CacheService.Delete(pluginSettingsCachePrefix + evt.PluginID)
+	return nil
+}
@@ -0,0 +1,60 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// awsSecretsManagerProvider gets secrets from AWS Secrets Manager, with the credentials and region of the default
+// AWS credential chain. References are the ID or ARN of a secret, followed by #<key> to get a key of a secret
+// stored as a JSON object.
+type awsSecretsManagerProvider struct {
+	once   sync.Once
+	client *secretsmanager.SecretsManager
+	err    error
+}
+
+func (p *awsSecretsManagerProvider) GetSecret(ctx context.Context, reference string) (string, error) {
+	p.once.Do(func() {
+		var sess *session.Session
+		sess, p.err = session.NewSessionWithOptions(session.Options{SharedConfigState: session.SharedConfigEnable})
+		if p.err == nil {
+			p.client = secretsmanager.New(sess)
+		}
+	})
+	if p.err != nil {
+		return "", p.err
+	}
+
+	secretID, key := reference, ""
+	// ARNs contain colons but no #, so the last # separates the key
+	if i := strings.LastIndex(reference, "#"); i > 0 {
+		secretID, key = reference[:i], reference[i+1:]
+	}
+
+	out, err := p.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(secretID)})
+	if err != nil {
+		return "", err
+	}
+	secret := aws.StringValue(out.SecretString)
+	if key == "" {
+		return secret, nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", fmt.Errorf("secret %q isn't a JSON object: %w", secretID, err)
+	}
+	value, ok := values[key].(string)
+	if !ok {
+		return "", fmt.Errorf("secret %q doesn't have a string value for %q", secretID, key)
+	}
+	return value, nil
+}
@@ -0,0 +1,255 @@
+// Package secrets resolves the secureJsonData values passed to plugins from external secrets managers, so that
+// secrets don't have to be stored in the Grafana database.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider gets secrets from an external secrets manager.
+type Provider interface {
+	// GetSecret returns the current value of the secret a reference points to.
+	GetSecret(ctx context.Context, reference string) (string, error)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		"vault":               &vaultProvider{},
+		"aws_secrets_manager": &awsSecretsManagerProvider{},
+	}
+	policy Policy
+)
+
+// Policy restricts the secrets that can be referenced, as the secureJsonData of data sources and apps is edited by
+// organization admins while the providers have the access of the Grafana server. No provider is enabled by default.
+type Policy struct {
+	// Providers are the names of the enabled providers. References to the secrets of other providers are kept as is.
+	Providers []string
+	// AllowedReferences are the prefixes of the references allowed by provider name, such as Vault paths or AWS
+	// secret ARNs, in which {orgId} is replaced by the organization of the owner of the secrets. References that
+	// don't have any of the prefixes of their provider aren't resolved.
+	AllowedReferences map[string][]string
+}
+
+// ErrReferenceNotAllowed is returned when resolving a secret reference that isn't allowed by the policy.
+var ErrReferenceNotAllowed = errors.New("secret reference not allowed")
+
+// parentReferenceRegex matches the .. segments of references, which could escape the allowed prefixes.
+var parentReferenceRegex = regexp.MustCompile(`(^|/)\.\.(/|#|$)`)
+
+// RegisterProvider registers a provider of the secrets referenced by values of the form $__<name>{<reference>},
+// replacing any provider registered with the same name. It's only used once enabled by the policy.
+func RegisterProvider(name string, provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	providers[name] = provider
+}
+
+// SetPolicy replaces the policy restricting the secrets that can be referenced, forgetting the resolved secrets.
+func SetPolicy(p Policy) {
+	providersMu.Lock()
+	policy = p
+	providersMu.Unlock()
+
+	cache.Lock()
+	defer cache.Unlock()
+	cache.entries = map[string]*ownerSecrets{}
+}
+
+// getProvider returns a registered provider enabled by the policy.
+func getProvider(name string) (Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	for _, enabled := range policy.Providers {
+		if enabled == name {
+			provider, exists := providers[name]
+			return provider, exists
+		}
+	}
+	return nil, false
+}
+
+// isAllowed reports whether the policy allows owner to reference a secret of a provider.
+func isAllowed(owner Owner, name string, reference string) bool {
+	if parentReferenceRegex.MatchString(reference) {
+		return false
+	}
+
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	for _, prefix := range policy.AllowedReferences[name] {
+		prefix = strings.ReplaceAll(prefix, "{orgId}", strconv.FormatInt(owner.OrgID, 10))
+		if prefix != "" && strings.HasPrefix(reference, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshInterval is how long resolved secrets are used before they're resolved again, picking up rotated secrets.
+var RefreshInterval = time.Minute
+
+var referenceRegex = regexp.MustCompile(`^\$__(\w+){(.+)}$`)
+
+type resolvedSecrets struct {
+	source     map[string]string
+	values     map[string]string
+	resolvedAt time.Time
+	changedAt  time.Time
+}
+
+// ownerSecrets holds the secrets resolved for an owner. Its mutex is held while the secrets are resolved, so that
+// concurrent requests of the owner resolve them once, without blocking the requests of other owners.
+type ownerSecrets struct {
+	mu       sync.Mutex
+	resolved *resolvedSecrets
+}
+
+var cache = struct {
+	sync.Mutex
+	entries map[string]*ownerSecrets
+}{entries: map[string]*ownerSecrets{}}
+
+// Owner identifies the data source or app whose secrets are resolved.
+type Owner struct {
+	// OrgID is the organization of the owner, which the allowed references of the policy can depend on.
+	OrgID int64
+	key   string
+}
+
+// DataSourceOwner returns the owner of the secrets of the data source with id in the organization with orgID. New
+// data sources have a zero id.
+func DataSourceOwner(orgID int64, id int64) Owner {
+	return Owner{OrgID: orgID, key: fmt.Sprintf("datasource/%d/%d", orgID, id)}
+}
+
+// AppOwner returns the owner of the secrets of the app plugin with pluginID in the organization with orgID.
+func AppOwner(orgID int64, pluginID string) Owner {
+	return Owner{OrgID: orgID, key: fmt.Sprintf("app/%d/%s", orgID, pluginID)}
+}
+
+// Forget removes the cached secrets of owner, for example once it's deleted.
+func Forget(owner Owner) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	delete(cache.entries, owner.key)
+}
+
+func getOwnerSecrets(owner Owner) *ownerSecrets {
+	cache.Lock()
+	defer cache.Unlock()
+
+	secrets, exists := cache.entries[owner.key]
+	if !exists {
+		secrets = &ownerSecrets{}
+		cache.entries[owner.key] = secrets
+	}
+	return secrets
+}
+
+// Resolve returns the decrypted secureJsonData values of owner, such as a data source, with the values referencing
+// a secret of a registered provider replaced by the secret. It also returns when the secrets last changed, zero
+// until a rotated secret is resolved, which the caller uses as the update time of the instance settings of the
+// plugin so that it replaces its instance. Secrets are cached by owner for RefreshInterval, the returned values are
+// a copy which the caller may modify. References not allowed by the policy return ErrReferenceNotAllowed.
+func Resolve(ctx context.Context, owner Owner, values map[string]string) (map[string]string, time.Time, error) {
+	if !hasReferences(values) {
+		Forget(owner)
+		return values, time.Time{}, nil
+	}
+
+	secrets := getOwnerSecrets(owner)
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+
+	entry := secrets.resolved
+	exists := entry != nil && sameValues(entry.source, values)
+	if exists && time.Since(entry.resolvedAt) < RefreshInterval {
+		return copyValues(entry.values), entry.changedAt, nil
+	}
+
+	resolved := make(map[string]string, len(values))
+	for key, value := range values {
+		resolved[key] = value
+		match := referenceRegex.FindStringSubmatch(value)
+		if match == nil {
+			continue
+		}
+		provider, registered := getProvider(match[1])
+		if !registered {
+			continue
+		}
+		if !isAllowed(owner, match[1], match[2]) {
+			return nil, time.Time{}, fmt.Errorf("failed to resolve secret of %q: %w", key, ErrReferenceNotAllowed)
+		}
+
+		secret, err := provider.GetSecret(ctx, match[2])
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to resolve secret of %q: %w", key, err)
+		}
+		resolved[key] = secret
+	}
+
+	var changedAt time.Time
+	if exists {
+		changedAt = entry.changedAt
+		if !sameValues(entry.values, resolved) {
+			changedAt = time.Now()
+		}
+	}
+	secrets.resolved = &resolvedSecrets{
+		source:     copyValues(values),
+		values:     resolved,
+		resolvedAt: time.Now(),
+		changedAt:  changedAt,
+	}
+
+	return copyValues(resolved), changedAt, nil
+}
+
+// hasReferences reports whether any of values references a secret of a registered provider enabled by the policy.
+func hasReferences(values map[string]string) bool {
+	for _, value := range values {
+		if match := referenceRegex.FindStringSubmatch(value); match != nil {
+			if _, registered := getProvider(match[1]); registered {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func sameValues(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, exists := b[key]; !exists || other != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func copyValues(values map[string]string) map[string]string {
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		result[key] = value
+	}
+
+	return result
+}
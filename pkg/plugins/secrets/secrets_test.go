@@ -0,0 +1,246 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	secrets map[string]string
+	calls   int
+}
+
+func (p *fakeProvider) GetSecret(_ context.Context, reference string) (string, error) {
+	p.calls++
+	secret, exists := p.secrets[reference]
+	if !exists {
+		return "", errors.New("secret not found")
+	}
+	return secret, nil
+}
+
+type blockingProvider struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) GetSecret(_ context.Context, _ string) (string, error) {
+	close(p.started)
+	<-p.release
+	return "blocked", nil
+}
+
+func testOwner(key string) Owner {
+	return Owner{OrgID: 1, key: key}
+}
+
+func TestResolve(t *testing.T) {
+	provider := &fakeProvider{secrets: map[string]string{"db#password": "s3cret"}}
+	RegisterProvider("fake", provider)
+	SetPolicy(Policy{
+		Providers:         []string{"fake", "blocking"},
+		AllowedReferences: map[string][]string{"fake": {"db#"}, "blocking": {"db"}},
+	})
+	refreshInterval := RefreshInterval
+	t.Cleanup(func() {
+		RefreshInterval = refreshInterval
+		SetPolicy(Policy{})
+	})
+
+	t.Run("Should return values without references as is", func(t *testing.T) {
+		values := map[string]string{"password": "plain", "other": "$__unknown{db#password}"}
+		resolved, changed, err := Resolve(context.Background(), testOwner("plain"), values)
+		require.NoError(t, err)
+		require.Equal(t, values, resolved)
+		require.True(t, changed.IsZero())
+	})
+
+	t.Run("Should resolve references and cache the secrets", func(t *testing.T) {
+		RefreshInterval = time.Minute
+		provider.calls = 0
+		values := map[string]string{"password": "$__fake{db#password}", "token": "plain"}
+
+		for i := 0; i < 2; i++ {
+			resolved, changed, err := Resolve(context.Background(), testOwner("cached"), values)
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"password": "s3cret", "token": "plain"}, resolved)
+			require.True(t, changed.IsZero())
+		}
+		require.Equal(t, 1, provider.calls)
+	})
+
+	t.Run("Should report when a resolved secret is rotated", func(t *testing.T) {
+		RefreshInterval = 0
+		values := map[string]string{"password": "$__fake{db#password}"}
+
+		_, changed, err := Resolve(context.Background(), testOwner("rotated"), values)
+		require.NoError(t, err)
+		require.True(t, changed.IsZero())
+
+		_, changed, err = Resolve(context.Background(), testOwner("rotated"), values)
+		require.NoError(t, err)
+		require.True(t, changed.IsZero())
+
+		provider.secrets["db#password"] = "n3w"
+		t.Cleanup(func() {
+			provider.secrets["db#password"] = "s3cret"
+		})
+		start := time.Now()
+		resolved, changed, err := Resolve(context.Background(), testOwner("rotated"), values)
+		require.NoError(t, err)
+		require.Equal(t, "n3w", resolved["password"])
+		require.False(t, changed.Before(start))
+	})
+
+	t.Run("Should return a copy of the cached secrets", func(t *testing.T) {
+		RefreshInterval = time.Minute
+		values := map[string]string{"password": "$__fake{db#password}"}
+
+		resolved, _, err := Resolve(context.Background(), testOwner("copied"), values)
+		require.NoError(t, err)
+		resolved["password"] = "modified"
+
+		resolved, _, err = Resolve(context.Background(), testOwner("copied"), values)
+		require.NoError(t, err)
+		require.Equal(t, "s3cret", resolved["password"])
+	})
+
+	t.Run("Should forget the secrets of an owner", func(t *testing.T) {
+		RefreshInterval = time.Minute
+		provider.calls = 0
+		values := map[string]string{"password": "$__fake{db#password}"}
+
+		_, _, err := Resolve(context.Background(), testOwner("forgotten"), values)
+		require.NoError(t, err)
+		Forget(testOwner("forgotten"))
+		require.NotContains(t, cache.entries, testOwner("forgotten").key)
+
+		_, _, err = Resolve(context.Background(), testOwner("forgotten"), values)
+		require.NoError(t, err)
+		require.Equal(t, 2, provider.calls)
+
+		_, _, err = Resolve(context.Background(), testOwner("forgotten"), map[string]string{"password": "plain"})
+		require.NoError(t, err)
+		require.NotContains(t, cache.entries, testOwner("forgotten").key)
+	})
+
+	t.Run("Should not wait for the secrets of other owners to be resolved", func(t *testing.T) {
+		RefreshInterval = time.Minute
+		blocking := &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+		RegisterProvider("blocking", blocking)
+		t.Cleanup(func() {
+			providersMu.Lock()
+			defer providersMu.Unlock()
+			delete(providers, "blocking")
+		})
+
+		done := make(chan error)
+		go func() {
+			_, _, err := Resolve(context.Background(), testOwner("blocked"), map[string]string{"password": "$__blocking{db}"})
+			done <- err
+		}()
+		<-blocking.started
+
+		resolved, _, err := Resolve(context.Background(), testOwner("unblocked"), map[string]string{"password": "$__fake{db#password}"})
+		require.NoError(t, err)
+		require.Equal(t, "s3cret", resolved["password"])
+
+		close(blocking.release)
+		require.NoError(t, <-done)
+	})
+
+	t.Run("Should keep references of providers that aren't enabled", func(t *testing.T) {
+		RegisterProvider("disabled", provider)
+		t.Cleanup(func() {
+			providersMu.Lock()
+			defer providersMu.Unlock()
+			delete(providers, "disabled")
+		})
+		provider.calls = 0
+
+		values := map[string]string{"password": "$__disabled{db#password}"}
+		resolved, _, err := Resolve(context.Background(), testOwner("disabled"), values)
+		require.NoError(t, err)
+		require.Equal(t, values, resolved)
+		require.Zero(t, provider.calls)
+	})
+
+	t.Run("Should only resolve references with an allowed prefix", func(t *testing.T) {
+		SetPolicy(Policy{
+			Providers:         []string{"fake"},
+			AllowedReferences: map[string][]string{"fake": {"org-{orgId}/"}},
+		})
+		t.Cleanup(func() {
+			SetPolicy(Policy{
+				Providers:         []string{"fake", "blocking"},
+				AllowedReferences: map[string][]string{"fake": {"db#"}, "blocking": {"db"}},
+			})
+		})
+		provider.secrets["org-1/db#password"] = "org1"
+		provider.secrets["org-2/db#password"] = "org2"
+		provider.calls = 0
+
+		resolved, _, err := Resolve(context.Background(), DataSourceOwner(1, 1),
+			map[string]string{"password": "$__fake{org-1/db#password}"})
+		require.NoError(t, err)
+		require.Equal(t, "org1", resolved["password"])
+
+		for _, reference := range []string{"org-2/db#password", "db#password", "org-1/../org-2/db#password"} {
+			_, _, err := Resolve(context.Background(), DataSourceOwner(1, 1),
+				map[string]string{"password": "$__fake{" + reference + "}"})
+			require.ErrorIs(t, err, ErrReferenceNotAllowed, reference)
+		}
+		require.Equal(t, 1, provider.calls)
+
+		resolved, _, err = Resolve(context.Background(), AppOwner(2, "app"),
+			map[string]string{"password": "$__fake{org-2/db#password}"})
+		require.NoError(t, err)
+		require.Equal(t, "org2", resolved["password"])
+	})
+
+	t.Run("Should return an error when a secret can't be resolved", func(t *testing.T) {
+		_, _, err := Resolve(context.Background(), testOwner("missing"), map[string]string{"password": "$__fake{db#missing}"})
+		require.EqualError(t, err, "failed to resolve secret of \"password\": secret not found")
+	})
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/grafana":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"v2"},"metadata":{"version":3}}}`))
+		case "/v1/kv/grafana":
+			_, _ = w.Write([]byte(`{"data":{"password":"v1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "token")
+
+	provider := &vaultProvider{}
+	secret, err := provider.GetSecret(context.Background(), "secret/data/grafana#password")
+	require.NoError(t, err)
+	require.Equal(t, "v2", secret)
+
+	secret, err = provider.GetSecret(context.Background(), "kv/grafana#password")
+	require.NoError(t, err)
+	require.Equal(t, "v1", secret)
+
+	_, err = provider.GetSecret(context.Background(), "kv/other#password")
+	require.EqualError(t, err, "vault returned status 404 for \"kv/other\"")
+
+	_, err = provider.GetSecret(context.Background(), "kv/grafana")
+	require.EqualError(t, err, "invalid secret reference \"kv/grafana\", expected <path>#<key>")
+}
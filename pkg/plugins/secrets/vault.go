@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// vaultProvider gets secrets from a HashiCorp Vault KV secrets engine, addressed by the standard VAULT_ADDR and
+// VAULT_TOKEN environment variables. References have the form <path>#<key>, such as secret/data/grafana#password.
+type vaultProvider struct {
+	client *http.Client
+}
+
+func (p *vaultProvider) GetSecret(ctx context.Context, reference string) (string, error) {
+	path, key, err := splitReference(reference)
+	if err != nil {
+		return "", err
+	}
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR isn't set")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+
+	client := p.client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d for %q", resp.StatusCode, path)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	data := body.Data
+	// the KV version 2 engine nests the secret in the data of the response
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("secret %q doesn't have a string value for %q", path, key)
+	}
+	return value, nil
+}
+
+// splitReference splits a reference of the form <path>#<key>.
+func splitReference(reference string) (string, string, error) {
+	i := strings.LastIndex(reference, "#")
+	if i <= 0 || i == len(reference)-1 {
+		return "", "", fmt.Errorf("invalid secret reference %q, expected <path>#<key>", reference)
+	}
+
+	return reference[:i], reference[i+1:], nil
+}
@@ -22,6 +22,9 @@ type PluginState string
 
 const (
 	PluginStateAlpha PluginState = "alpha"
+	PluginStateBeta  PluginState = "beta"
+	// PluginStateStable is the state of plugins which aren't pre-releases, whose plugin.json doesn't set a state.
+	PluginStateStable PluginState = "stable"
 )
 
 type PluginSignatureType string
@@ -0,0 +1,29 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver"
+)
+
+// CompareVersions compares two plugin versions as semantic versions, so that "2.1" equals "2.1.0" and pre-releases
+// precede their release. It returns -1, 0 or 1 if a is lower than, equal to or greater than b. Versions that aren't
+// semantic versions are only equal if they're the same, and can't be compared otherwise.
+func CompareVersions(a, b string) (int, error) {
+	versionA, errA := semver.NewVersion(a)
+	versionB, errB := semver.NewVersion(b)
+	if errA != nil || errB != nil {
+		if a == b {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("cannot compare plugin versions %q and %q", a, b)
+	}
+
+	return versionA.Compare(versionB), nil
+}
+
+// VersionsEqual returns whether two plugin versions are the same semantic version.
+func VersionsEqual(a, b string) bool {
+	cmp, err := CompareVersions(a, b)
+	return err == nil && cmp == 0
+}
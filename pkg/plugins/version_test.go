@@ -0,0 +1,39 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tcs := []struct {
+		a, b string
+		cmp  int
+	}{
+		{a: "1.0.0", b: "1.0.0", cmp: 0},
+		{a: "2.1", b: "2.1.0", cmp: 0},
+		{a: "v2.1.0", b: "2.1.0", cmp: 0},
+		{a: "1.2.0", b: "1.10.0", cmp: -1},
+		{a: "2.0.0", b: "1.10.0", cmp: 1},
+		{a: "2.1.0-beta1", b: "2.1.0", cmp: -1},
+		{a: "2.1.0-beta2", b: "2.1.0-beta1", cmp: 1},
+		{a: "nightly", b: "nightly", cmp: 0},
+	}
+	for _, tc := range tcs {
+		t.Run(fmt.Sprintf("%s with %s", tc.a, tc.b), func(t *testing.T) {
+			cmp, err := CompareVersions(tc.a, tc.b)
+			require.NoError(t, err)
+			assert.Equal(t, tc.cmp, cmp)
+			assert.Equal(t, tc.cmp == 0, VersionsEqual(tc.a, tc.b))
+		})
+	}
+
+	t.Run("Versions that aren't semantic versions can't be compared", func(t *testing.T) {
+		_, err := CompareVersions("nightly", "1.0.0")
+		require.Error(t, err)
+		assert.False(t, VersionsEqual("nightly", "1.0.0"))
+	})
+}
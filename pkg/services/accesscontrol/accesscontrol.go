@@ -20,6 +20,13 @@ type AccessControl interface {
 	// DeclareFixedRoles allow the caller to declare, to the service, fixed roles and their
 	// assignments to organization roles ("Viewer", "Editor", "Admin") or "Grafana Admin"
 	DeclareFixedRoles(...RoleRegistration) error
+
+	// DeclarePluginRoles allow a plugin to declare its roles and their assignments, replacing the roles it
+	// declared before
+	DeclarePluginRoles(pluginID string, registrations ...RoleRegistration) error
+
+	// RemovePluginRoles removes the roles declared by a plugin and their assignments
+	RemovePluginRoles(pluginID string)
 }
 
 func HasAccess(ac AccessControl, c *models.ReqContext) func(fallback func(*models.ReqContext) bool, evaluator Evaluator) bool {
@@ -3,6 +3,10 @@ package accesscontrol
 import "errors"
 
 var (
-	ErrFixedRolePrefixMissing = errors.New("fixed role should be prefixed with '" + FixedRolePrefix + "'")
-	ErrInvalidBuiltinRole     = errors.New("built-in role is not valid")
+	ErrFixedRolePrefixMissing  = errors.New("fixed role should be prefixed with '" + FixedRolePrefix + "'")
+	ErrInvalidBuiltinRole      = errors.New("built-in role is not valid")
+	ErrPluginRolePrefixMissing = errors.New("plugin role should be prefixed with '" + PluginRolePrefix +
+		"<plugin ID>:'")
+	ErrPluginActionPrefixMissing = errors.New("plugin role action should be prefixed with '<plugin ID>.' or " +
+		"'<plugin ID>:'")
 )
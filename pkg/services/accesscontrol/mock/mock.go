@@ -18,6 +18,8 @@ type Calls struct {
 	GetUserPermissions  []interface{}
 	IsDisabled          []interface{}
 	DeclareFixedRoles   []interface{}
+	DeclarePluginRoles  []interface{}
+	RemovePluginRoles   []interface{}
 	GetUserBuiltInRoles []interface{}
 	RegisterFixedRoles  []interface{}
 }
@@ -38,6 +40,7 @@ type Mock struct {
 	GetUserPermissionsFunc  func(context.Context, *models.SignedInUser) ([]*accesscontrol.Permission, error)
 	IsDisabledFunc          func() bool
 	DeclareFixedRolesFunc   func(...accesscontrol.RoleRegistration) error
+	DeclarePluginRolesFunc  func(string, ...accesscontrol.RoleRegistration) error
 	GetUserBuiltInRolesFunc func(user *models.SignedInUser) []string
 	RegisterFixedRolesFunc  func() error
 }
@@ -123,6 +126,23 @@ func (m *Mock) DeclareFixedRoles(registrations ...accesscontrol.RoleRegistration
 	return nil
 }
 
+// DeclarePluginRoles allow a plugin to declare its roles and their assignments, replacing the roles it declared
+// before
+// This mock returns no error unless an override is provided.
+func (m *Mock) DeclarePluginRoles(pluginID string, registrations ...accesscontrol.RoleRegistration) error {
+	m.Calls.DeclarePluginRoles = append(m.Calls.DeclarePluginRoles, []interface{}{pluginID, registrations})
+	// Use override if provided
+	if m.DeclarePluginRolesFunc != nil {
+		return m.DeclarePluginRolesFunc(pluginID, registrations...)
+	}
+	return nil
+}
+
+// RemovePluginRoles removes the roles declared by a plugin and their assignments
+func (m *Mock) RemovePluginRoles(pluginID string) {
+	m.Calls.RemovePluginRoles = append(m.Calls.RemovePluginRoles, []interface{}{pluginID})
+}
+
 // GetUserBuiltInRoles returns the list of organizational roles ("Viewer", "Editor", "Admin")
 // or "Grafana Admin" associated to a user
 // This mock returns m.builtInRoles unless an override is provided.
@@ -162,3 +162,6 @@ const (
 const RoleGrafanaAdmin = "Grafana Admin"
 
 const FixedRolePrefix = "fixed:"
+
+// PluginRolePrefix prefixes the roles declared by plugins, followed by the plugin ID and a colon.
+const PluginRolePrefix = "plugins:"
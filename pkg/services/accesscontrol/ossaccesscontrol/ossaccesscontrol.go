@@ -2,6 +2,7 @@ package ossaccesscontrol
 
 import (
 	"context"
+	"sync"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics"
@@ -28,6 +29,13 @@ type OSSAccessControlService struct {
 	UsageStats    usagestats.Service
 	Log           log.Logger
 	registrations accesscontrol.RegistrationList
+
+	// pluginRoles are the role registrations declared by plugins, by plugin ID. Once the declared roles are
+	// registered, plugin roles are registered as they're declared. rolesMu guards them and the fixed roles, which
+	// plugins change at runtime.
+	pluginRoles map[string][]accesscontrol.RoleRegistration
+	registered  bool
+	rolesMu     sync.RWMutex
 }
 
 func (ac *OSSAccessControlService) IsDisabled() bool {
@@ -73,6 +81,9 @@ func (ac *OSSAccessControlService) GetUserPermissions(ctx context.Context, user
 	timer := prometheus.NewTimer(metrics.MAccessPermissionsSummary)
 	defer timer.ObserveDuration()
 
+	ac.rolesMu.RLock()
+	defer ac.rolesMu.RUnlock()
+
 	builtinRoles := ac.GetUserBuiltInRoles(user)
 	permissions := make([]*accesscontrol.Permission, 0)
 	for _, builtin := range builtinRoles {
@@ -145,11 +156,20 @@ func (ac *OSSAccessControlService) RegisterFixedRoles() error {
 	if ac.IsDisabled() {
 		return nil
 	}
+	ac.rolesMu.Lock()
+	defer ac.rolesMu.Unlock()
+
 	var err error
 	ac.registrations.Range(func(registration accesscontrol.RoleRegistration) bool {
 		ac.registerFixedRole(registration.Role, registration.Grants)
 		return true
 	})
+	for _, registrations := range ac.pluginRoles {
+		for _, registration := range registrations {
+			ac.registerFixedRole(registration.Role, registration.Grants)
+		}
+	}
+	ac.registered = true
 	return err
 }
 
@@ -183,3 +203,68 @@ func (ac *OSSAccessControlService) DeclareFixedRoles(registrations ...accesscont
 
 	return nil
 }
+
+// DeclarePluginRoles allow a plugin to declare its roles and their assignments to organization roles ("Viewer",
+// "Editor", "Admin") or "Grafana Admin", replacing the roles it declared before. Role names are prefixed with
+// "plugins:<plugin ID>:", and actions with the plugin ID.
+func (ac *OSSAccessControlService) DeclarePluginRoles(pluginID string, registrations ...accesscontrol.RoleRegistration) error {
+	// If accesscontrol is disabled no need to register roles
+	if ac.IsDisabled() {
+		return nil
+	}
+
+	for _, r := range registrations {
+		if err := accesscontrol.ValidatePluginRole(pluginID, r.Role); err != nil {
+			return err
+		}
+
+		if err := accesscontrol.ValidateBuiltInRoles(r.Grants); err != nil {
+			return err
+		}
+	}
+
+	ac.rolesMu.Lock()
+	defer ac.rolesMu.Unlock()
+
+	ac.removePluginRoles(pluginID)
+	if len(registrations) == 0 {
+		return nil
+	}
+
+	if ac.pluginRoles == nil {
+		ac.pluginRoles = map[string][]accesscontrol.RoleRegistration{}
+	}
+	ac.pluginRoles[pluginID] = registrations
+	// roles declared after the registration of the declared roles are registered right away
+	if ac.registered {
+		for _, r := range registrations {
+			ac.registerFixedRole(r.Role, r.Grants)
+		}
+	}
+
+	return nil
+}
+
+// RemovePluginRoles removes the roles declared by a plugin and their assignments
+func (ac *OSSAccessControlService) RemovePluginRoles(pluginID string) {
+	ac.rolesMu.Lock()
+	defer ac.rolesMu.Unlock()
+
+	ac.removePluginRoles(pluginID)
+}
+
+func (ac *OSSAccessControlService) removePluginRoles(pluginID string) {
+	for _, r := range ac.pluginRoles[pluginID] {
+		delete(accesscontrol.FixedRoles, r.Role.Name)
+		for builtInRole, assignments := range accesscontrol.FixedRoleGrants {
+			kept := make([]string, 0, len(assignments))
+			for _, assignedRole := range assignments {
+				if assignedRole != r.Role.Name {
+					kept = append(kept, assignedRole)
+				}
+			}
+			accesscontrol.FixedRoleGrants[builtInRole] = kept
+		}
+	}
+	delete(ac.pluginRoles, pluginID)
+}
@@ -518,3 +518,86 @@ func TestOSSAccessControlService_RegisterFixedRoles(t *testing.T) {
 		})
 	}
 }
+
+func TestOSSAccessControlService_DeclarePluginRoles(t *testing.T) {
+	readerRole := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version: 1,
+			Name:    "plugins:test-app:reader",
+			Permissions: []accesscontrol.Permission{
+				{Action: "test-app.reports:read"},
+			},
+		},
+		Grants: []string{"Viewer"},
+	}
+	writerRole := accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Version: 1,
+			Name:    "plugins:test-app:writer",
+			Permissions: []accesscontrol.Permission{
+				{Action: "test-app.reports:write"},
+			},
+		},
+		Grants: []string{"Editor"},
+	}
+	viewer := &models.SignedInUser{OrgRole: models.ROLE_VIEWER}
+	editor := &models.SignedInUser{OrgRole: models.ROLE_EDITOR}
+	hasPermission := func(t *testing.T, ac *OSSAccessControlService, user *models.SignedInUser, action string) bool {
+		hasAccess, err := ac.Evaluate(context.Background(), user, accesscontrol.EvalPermission(action))
+		require.NoError(t, err)
+		return hasAccess
+	}
+
+	t.Run("should validate the roles of plugins", func(t *testing.T) {
+		ac := setupTestEnv(t)
+
+		err := ac.DeclarePluginRoles("test-app", accesscontrol.RoleRegistration{
+			Role: accesscontrol.RoleDTO{Name: "fixed:test-app:reader"},
+		})
+		require.ErrorIs(t, err, accesscontrol.ErrPluginRolePrefixMissing)
+
+		err = ac.DeclarePluginRoles("test-app", accesscontrol.RoleRegistration{
+			Role: accesscontrol.RoleDTO{
+				Name:        "plugins:test-app:admin",
+				Permissions: []accesscontrol.Permission{{Action: accesscontrol.ActionUsersDelete}},
+			},
+		})
+		require.ErrorIs(t, err, accesscontrol.ErrPluginActionPrefixMissing)
+
+		err = ac.DeclarePluginRoles("test-app", accesscontrol.RoleRegistration{
+			Role:   readerRole.Role,
+			Grants: []string{"Reader"},
+		})
+		require.ErrorIs(t, err, accesscontrol.ErrInvalidBuiltinRole)
+	})
+
+	t.Run("should register plugin roles declared before and after the fixed roles are registered", func(t *testing.T) {
+		ac := setupTestEnv(t)
+		t.Cleanup(func() { ac.RemovePluginRoles("test-app") })
+
+		require.NoError(t, ac.DeclarePluginRoles("test-app", readerRole))
+		assert.False(t, hasPermission(t, ac, viewer, "test-app.reports:read"))
+
+		require.NoError(t, ac.RegisterFixedRoles())
+		assert.True(t, hasPermission(t, ac, viewer, "test-app.reports:read"))
+
+		// redeclaring the roles of a plugin replaces them
+		require.NoError(t, ac.DeclarePluginRoles("test-app", writerRole))
+		assert.False(t, hasPermission(t, ac, viewer, "test-app.reports:read"))
+		assert.True(t, hasPermission(t, ac, editor, "test-app.reports:write"))
+	})
+
+	t.Run("should remove plugin roles and their assignments", func(t *testing.T) {
+		ac := setupTestEnv(t)
+		require.NoError(t, ac.RegisterFixedRoles())
+		require.NoError(t, ac.DeclarePluginRoles("test-app", readerRole, writerRole))
+
+		ac.RemovePluginRoles("test-app")
+		assert.False(t, hasPermission(t, ac, editor, "test-app.reports:read"))
+		assert.False(t, hasPermission(t, ac, editor, "test-app.reports:write"))
+		assert.NotContains(t, accesscontrol.FixedRoles, readerRole.Role.Name)
+		assert.NotContains(t, accesscontrol.FixedRoleGrants[string(models.ROLE_VIEWER)], readerRole.Role.Name)
+		// fixed roles are kept
+		assert.True(t, hasPermission(t, ac, editor, accesscontrol.ActionDatasourcesExplore))
+	})
+}
@@ -253,6 +253,20 @@ func ValidateFixedRole(role RoleDTO) error {
 	return nil
 }
 
+// ValidatePluginRole errors when a role declared by a plugin isn't prefixed with the plugin ID, or grants actions
+// of other plugins or of Grafana itself
+func ValidatePluginRole(pluginID string, role RoleDTO) error {
+	if !strings.HasPrefix(role.Name, PluginRolePrefix+pluginID+":") {
+		return ErrPluginRolePrefixMissing
+	}
+	for _, p := range role.Permissions {
+		if !strings.HasPrefix(p.Action, pluginID+".") && !strings.HasPrefix(p.Action, pluginID+":") {
+			return fmt.Errorf("'%s' %w", p.Action, ErrPluginActionPrefixMissing)
+		}
+	}
+	return nil
+}
+
 // ValidateBuiltInRoles errors when a built-in role does not match expected pattern
 func ValidateBuiltInRoles(builtInRoles []string) error {
 	for _, br := range builtInRoles {
@@ -4,16 +4,21 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 )
 
 func ProvideCacheService(cacheService *localcache.CacheService, sqlStore *sqlstore.SQLStore) *CacheServiceImpl {
-	return &CacheServiceImpl{
+	dc := &CacheServiceImpl{
 		CacheService: cacheService,
 		SQLStore:     sqlStore,
 	}
+	bus.AddEventListener(dc.handleDataSourceUpdated)
+	bus.AddEventListener(dc.handleDataSourceDeleted)
+	return dc
 }
 
 type CacheService interface {
@@ -88,6 +93,30 @@ func (dc *CacheServiceImpl) GetDatasourceByUID(
 	return ds, nil
 }
 
+// handleDataSourceUpdated drops an updated data source from the cache, so that its new settings are used right away.
+func (dc *CacheServiceImpl) handleDataSourceUpdated(evt *events.DataSourceUpdated) error {
+	dc.invalidate(evt.OrgID, evt.ID, evt.UID)
+	return nil
+}
+
+func (dc *CacheServiceImpl) handleDataSourceDeleted(evt *events.DataSourceDeleted) error {
+	dc.invalidate(evt.OrgID, evt.ID, evt.UID)
+	return nil
+}
+
+func (dc *CacheServiceImpl) invalidate(orgID int64, id int64, uid string) {
+	if cached, found := dc.CacheService.Get(idKey(id)); found {
+		// the event may not have the UID of the data source
+		if ds := cached.(*models.DataSource); ds.Uid != "" {
+			dc.CacheService.Delete(uidKey(ds.OrgId, ds.Uid))
+		}
+	}
+	dc.CacheService.Delete(idKey(id))
+	if uid != "" {
+		dc.CacheService.Delete(uidKey(orgID, uid))
+	}
+}
+
 func idKey(id int64) string {
 	return fmt.Sprintf("ds-%d", id)
 }
@@ -0,0 +1,67 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+// PluginEventsChannel is the channel the lifecycle events of plugins are published to
+const PluginEventsChannel = "grafana/plugins/events"
+
+// PluginsHandler manages all the `grafana/plugins/*` channels. It relays the lifecycle events of plugins on all
+// Grafana instances to the clients connected to this instance.
+type PluginsHandler struct {
+	// Channels returns the channels clients of this instance are subscribed to
+	Channels func() []string
+	// LocalPublisher publishes data to the clients of this instance subscribed to channel. Every instance
+	// receives the lifecycle events itself, so they must not be published to the other instances again.
+	LocalPublisher func(channel string, data []byte) error
+}
+
+// GetHandlerForPath called on init
+func (h *PluginsHandler) GetHandlerForPath(_ string) (models.ChannelHandler, error) {
+	return h, nil // all plugin channels share the same handler
+}
+
+// OnSubscribe lets any signed in user subscribe to the lifecycle events of plugins
+func (h *PluginsHandler) OnSubscribe(_ context.Context, _ *models.SignedInUser, e models.SubscribeEvent) (models.SubscribeReply, backend.SubscribeStreamStatus, error) {
+	if e.Path != "events" {
+		logger.Error("Unknown plugins channel", "path", e.Path)
+		return models.SubscribeReply{}, backend.SubscribeStreamStatusNotFound, nil
+	}
+
+	return models.SubscribeReply{}, backend.SubscribeStreamStatusOK, nil
+}
+
+// OnPublish is not allowed, the lifecycle events are only published by Grafana
+func (h *PluginsHandler) OnPublish(_ context.Context, _ *models.SignedInUser, _ models.PublishEvent) (models.PublishReply, backend.PublishStreamStatus, error) {
+	return models.PublishReply{}, backend.PublishStreamStatusPermissionDenied, nil
+}
+
+// HandlePluginLifecycleEvent publishes a lifecycle event of a plugin to the clients of this instance subscribed to
+// the events channel of any organization.
+func (h *PluginsHandler) HandlePluginLifecycleEvent(event *models.PluginLifecycleEvent) error {
+	var data []byte
+	for _, channel := range h.Channels() {
+		if _, channelID, err := orgchannel.StripOrgID(channel); err != nil || channelID != PluginEventsChannel {
+			continue
+		}
+
+		if data == nil {
+			var err error
+			if data, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+		if err := h.LocalPublisher(channel, data); err != nil {
+			logger.Warn("Failed to publish plugin lifecycle event", "channel", channel, "error", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package features
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginsHandler_OnSubscribe(t *testing.T) {
+	h := &PluginsHandler{}
+
+	_, status, err := h.OnSubscribe(context.Background(), &models.SignedInUser{OrgId: 1}, models.SubscribeEvent{
+		Channel: "grafana/plugins/events",
+		Path:    "events",
+	})
+	require.NoError(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusOK, status)
+
+	_, status, err = h.OnSubscribe(context.Background(), &models.SignedInUser{OrgId: 1}, models.SubscribeEvent{
+		Channel: "grafana/plugins/unknown",
+		Path:    "unknown",
+	})
+	require.NoError(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusNotFound, status)
+}
+
+func TestPluginsHandler_HandlePluginLifecycleEvent(t *testing.T) {
+	published := map[string]string{}
+	h := &PluginsHandler{
+		Channels: func() []string {
+			return []string{"1/grafana/plugins/events", "2/grafana/plugins/events", "2/grafana/dashboard/uid/abc"}
+		},
+		LocalPublisher: func(channel string, data []byte) error {
+			published[channel] = string(data)
+			return nil
+		},
+	}
+
+	err := h.HandlePluginLifecycleEvent(&models.PluginLifecycleEvent{
+		PluginId: "test",
+		Type:     models.PluginInstalled,
+		Remote:   true,
+	})
+	require.NoError(t, err)
+	require.Len(t, published, 2)
+	require.Contains(t, published["1/grafana/plugins/events"], `"pluginId":"test"`)
+	require.Contains(t, published["2/grafana/plugins/events"], `"type":"installed"`)
+}
@@ -19,11 +19,13 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/plugins/manager"
 	"github.com/grafana/grafana/pkg/plugins/plugincontext"
 	"github.com/grafana/grafana/pkg/services/datasources"
@@ -211,6 +213,15 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 	g.GrafanaScope.Features["dashboard"] = dash
 	g.GrafanaScope.Features["broadcast"] = features.NewBroadcastRunner(g.storage)
 
+	plugins := &features.PluginsHandler{
+		Channels: func() []string { return node.Hub().Channels() },
+		LocalPublisher: func(channel string, data []byte) error {
+			return node.Hub().BroadcastPublication(channel, &centrifuge.Publication{Data: data}, centrifuge.StreamPosition{})
+		},
+	}
+	g.GrafanaScope.Features["plugins"] = plugins
+	bus.AddEventListener(plugins.HandlePluginLifecycleEvent)
+
 	g.surveyCaller = survey.NewCaller(managedStreamRunner, node)
 	err = g.surveyCaller.SetupHandlers()
 	if err != nil {
@@ -330,6 +341,11 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 
 	g.registerUsageMetrics()
 
+	// subscriptions to plugin streams, through Live or server-sent events, get the access control of data queries
+	if pluginManager != nil && pluginManager.BackendPluginManager != nil {
+		pluginManager.BackendPluginManager.RegisterStreamAuthorizer(backendplugin.StreamAuthorizerFunc(g.authorizeStream))
+	}
+
 	return g, nil
 }
 
@@ -374,16 +390,13 @@ type GrafanaLive struct {
 	usageStats        usageStats
 }
 
+// getStreamPlugin returns the stream handler of a registered backend plugin. Stream calls go through the backend
+// plugin manager, which routes them to the plugin and manages its running streams.
 func (g *GrafanaLive) getStreamPlugin(pluginID string) (backend.StreamHandler, error) {
-	plugin, ok := g.PluginManager.BackendPluginManager.Get(pluginID)
-	if !ok {
+	if !g.PluginManager.BackendPluginManager.IsRegistered(pluginID) {
 		return nil, fmt.Errorf("plugin not found: %s", pluginID)
 	}
-	streamHandler, ok := plugin.(backend.StreamHandler)
-	if !ok {
-		return nil, fmt.Errorf("%s plugin does not implement StreamHandler: %#v", pluginID, plugin)
-	}
-	return streamHandler, nil
+	return g.PluginManager.BackendPluginManager, nil
 }
 
 func (g *GrafanaLive) Run(ctx context.Context) error {
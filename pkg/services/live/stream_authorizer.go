@@ -0,0 +1,55 @@
+package live
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+)
+
+// authorizeStream is the default authorizer of subscriptions to plugin stream channels, applying the access control
+// of data queries: the subscriber must belong to the organization of the plugin context, and a data source stream
+// is only allowed if the data source belongs to that organization and the subscriber may query it. The subscriber
+// is the signed in user of ctx, set by the Live and server-sent events handlers.
+func (g *GrafanaLive) authorizeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (bool, error) {
+	dsSettings := req.PluginContext.DataSourceInstanceSettings
+	user, ok := livecontext.GetContextSignedUser(ctx)
+	if !ok {
+		// streams of data sources are only available to known users, other streams are left to the plugin
+		return dsSettings == nil, nil
+	}
+	if user.OrgId != req.PluginContext.OrgID {
+		return false, nil
+	}
+	if dsSettings == nil {
+		return true, nil
+	}
+
+	// data sources are looked up in the organization of the user
+	ds, err := g.DataSourceCache.GetDatasourceByUID(dsSettings.UID, user, false)
+	if err != nil {
+		if errors.Is(err, models.ErrDataSourceNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if ds.Id != dsSettings.ID || ds.Type != req.PluginContext.PluginID {
+		return false, nil
+	}
+
+	dsFilterQuery := models.DatasourcesPermissionFilterQuery{
+		User:        user,
+		Datasources: []*models.DataSource{ds},
+	}
+	if err := bus.Dispatch(&dsFilterQuery); err != nil {
+		if !errors.Is(err, bus.ErrHandlerNotFound) {
+			return false, err
+		}
+		return true, nil
+	}
+
+	return len(dsFilterQuery.Result) > 0, nil
+}
@@ -0,0 +1,103 @@
+package live
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDataSourceCache struct {
+	dataSources []*models.DataSource
+}
+
+func (c *fakeDataSourceCache) GetDatasource(datasourceID int64, user *models.SignedInUser, skipCache bool) (*models.DataSource, error) {
+	for _, ds := range c.dataSources {
+		if ds.Id == datasourceID && ds.OrgId == user.OrgId {
+			return ds, nil
+		}
+	}
+	return nil, models.ErrDataSourceNotFound
+}
+
+func (c *fakeDataSourceCache) GetDatasourceByUID(datasourceUID string, user *models.SignedInUser, skipCache bool) (*models.DataSource, error) {
+	for _, ds := range c.dataSources {
+		if ds.Uid == datasourceUID && ds.OrgId == user.OrgId {
+			return ds, nil
+		}
+	}
+	return nil, models.ErrDataSourceNotFound
+}
+
+func TestGrafanaLive_authorizeStream(t *testing.T) {
+	g := &GrafanaLive{DataSourceCache: &fakeDataSourceCache{dataSources: []*models.DataSource{
+		{Id: 1, Uid: "ds1", OrgId: 1, Type: "test"},
+		{Id: 2, Uid: "ds2", OrgId: 2, Type: "test"},
+	}}}
+	user := &models.SignedInUser{UserId: 1, OrgId: 1}
+	userCtx := livecontext.SetContextSignedUser(context.Background(), user)
+	dsRequest := func(orgID int64, id int64, uid string) *backend.SubscribeStreamRequest {
+		return &backend.SubscribeStreamRequest{PluginContext: backend.PluginContext{
+			OrgID:                      orgID,
+			PluginID:                   "test",
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: id, UID: uid},
+		}}
+	}
+
+	t.Run("Should allow stream of data source of the organization of the user", func(t *testing.T) {
+		allowed, err := g.authorizeStream(userCtx, dsRequest(1, 1, "ds1"))
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	t.Run("Should deny stream of data source of another organization", func(t *testing.T) {
+		allowed, err := g.authorizeStream(userCtx, dsRequest(2, 2, "ds2"))
+		require.NoError(t, err)
+		require.False(t, allowed)
+
+		allowed, err = g.authorizeStream(userCtx, dsRequest(1, 2, "ds2"))
+		require.NoError(t, err)
+		require.False(t, allowed)
+	})
+
+	t.Run("Should deny stream of data source without user", func(t *testing.T) {
+		allowed, err := g.authorizeStream(context.Background(), dsRequest(1, 1, "ds1"))
+		require.NoError(t, err)
+		require.False(t, allowed)
+	})
+
+	t.Run("Should check plugin streams against the organization of the user", func(t *testing.T) {
+		allowed, err := g.authorizeStream(userCtx, &backend.SubscribeStreamRequest{
+			PluginContext: backend.PluginContext{OrgID: 1, PluginID: "app"},
+		})
+		require.NoError(t, err)
+		require.True(t, allowed)
+
+		allowed, err = g.authorizeStream(userCtx, &backend.SubscribeStreamRequest{
+			PluginContext: backend.PluginContext{OrgID: 2, PluginID: "app"},
+		})
+		require.NoError(t, err)
+		require.False(t, allowed)
+	})
+
+	t.Run("Should deny stream of data source the user may not query", func(t *testing.T) {
+		t.Cleanup(bus.ClearBusHandlers)
+		var filtered []*models.DataSource
+		bus.AddHandler("test", func(query *models.DatasourcesPermissionFilterQuery) error {
+			require.Same(t, user, query.User)
+			filtered = query.Datasources
+			query.Result = []*models.DataSource{}
+			return nil
+		})
+
+		allowed, err := g.authorizeStream(userCtx, dsRequest(1, 1, "ds1"))
+		require.NoError(t, err)
+		require.False(t, allowed)
+		require.Len(t, filtered, 1)
+		require.Equal(t, "ds1", filtered[0].Uid)
+	})
+}
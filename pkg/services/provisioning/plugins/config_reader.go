@@ -89,6 +89,14 @@ func (cr *configReaderImpl) parsePluginConfig(path string, file os.FileInfo) (*p
 func validateRequiredField(apps []*pluginsAsConfig) error {
 	for i := range apps {
 		var errStrings []string
+		for index, plugin := range apps[i].Plugins {
+			if plugin.PluginID == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("plugin item %d in configuration doesn't contain required field type", index+1),
+				)
+			}
+		}
 		for index, app := range apps[i].Apps {
 			if app.PluginID == "" {
 				errStrings = append(
@@ -106,14 +114,23 @@ func validateRequiredField(apps []*pluginsAsConfig) error {
 	return nil
 }
 
+// validatePluginsConfig checks that the provisioned apps are installed, or declared in a plugins section so that
+// they're installed before being provisioned.
 func (cr *configReaderImpl) validatePluginsConfig(apps []*pluginsAsConfig) error {
+	declared := map[string]bool{}
+	for i := range apps {
+		for _, plugin := range apps[i].Plugins {
+			declared[plugin.PluginID] = true
+		}
+	}
+
 	for i := range apps {
 		if apps[i].Apps == nil {
 			continue
 		}
 
 		for _, app := range apps[i].Apps {
-			if !cr.pluginManager.IsAppInstalled(app.PluginID) {
+			if !declared[app.PluginID] && !cr.pluginManager.IsAppInstalled(app.PluginID) {
 				return fmt.Errorf("app plugin not installed: %q", app.PluginID)
 			}
 		}
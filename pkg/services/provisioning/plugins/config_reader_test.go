@@ -15,6 +15,8 @@ const (
 	emptyFolder       = "./testdata/test-configs/empty_folder"
 	unknownApp        = "./testdata/test-configs/unknown-app"
 	correctProperties = "./testdata/test-configs/correct-properties"
+	declaredPlugins   = "./testdata/test-configs/declared-plugins"
+	incorrectPlugins  = "./testdata/test-configs/incorrect-plugins"
 )
 
 func TestConfigReader(t *testing.T) {
@@ -45,6 +47,27 @@ func TestConfigReader(t *testing.T) {
 		require.Equal(t, "app item 1 in configuration doesn't contain required field type", err.Error())
 	})
 
+	t.Run("Plugin without type should return error", func(t *testing.T) {
+		cfgProvider := newConfigReader(log.New("test logger"), nil)
+		_, err := cfgProvider.readConfig(incorrectPlugins)
+		require.Error(t, err)
+		require.Equal(t, "plugin item 1 in configuration doesn't contain required field type", err.Error())
+	})
+
+	t.Run("Can read declared plugins and the apps they provide", func(t *testing.T) {
+		cfgProvider := newConfigReader(log.New("test logger"), fakePluginManager{})
+		cfg, err := cfgProvider.readConfig(declaredPlugins)
+		require.NoError(t, err)
+		require.Len(t, cfg, 1)
+
+		require.Equal(t, []*pluginFromConfig{
+			{PluginID: "test-plugin-3", Version: "1.2.0"},
+			{PluginID: "test-plugin"},
+		}, cfg[0].Plugins)
+		require.Len(t, cfg[0].Apps, 1)
+		require.Equal(t, "test-plugin-3", cfg[0].Apps[0].PluginID)
+	})
+
 	t.Run("Can read correct properties", func(t *testing.T) {
 		pm := fakePluginManager{
 			apps: map[string]*plugins.AppPlugin{
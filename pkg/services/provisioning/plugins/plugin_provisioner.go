@@ -1,7 +1,9 @@
 package plugins
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -14,17 +16,35 @@ import (
 func Provision(configDirectory string, pluginManager plugins.Manager) error {
 	logger := log.New("provisioning.plugins")
 	ap := PluginProvisioner{
-		log:         logger,
-		cfgProvider: newConfigReader(logger, pluginManager),
+		log:           logger,
+		cfgProvider:   newConfigReader(logger, pluginManager),
+		pluginManager: pluginManager,
 	}
 	return ap.applyChanges(configDirectory)
 }
 
-// PluginProvisioner is responsible for provisioning apps based on
+// PluginProvisioner is responsible for installing plugins and provisioning apps based on
 // configuration read by the `configReader`
 type PluginProvisioner struct {
-	log         log.Logger
-	cfgProvider configReader
+	log           log.Logger
+	cfgProvider   configReader
+	pluginManager plugins.Manager
+}
+
+// install installs the declared plugins which aren't installed, or whose installed version differs from the one
+// they're pinned to.
+func (ap *PluginProvisioner) install(cfg *pluginsAsConfig) error {
+	for _, plugin := range cfg.Plugins {
+		installed, err := ap.pluginManager.EnsureInstalled(context.Background(), plugin.PluginID, plugin.Version)
+		if err != nil {
+			return fmt.Errorf("failed to install plugin %q: %w", plugin.PluginID, err)
+		}
+		if installed {
+			ap.log.Info("Installed plugin from configuration", "pluginId", plugin.PluginID, "version", plugin.Version)
+		}
+	}
+
+	return nil
 }
 
 func (ap *PluginProvisioner) apply(cfg *pluginsAsConfig) error {
@@ -73,6 +93,13 @@ func (ap *PluginProvisioner) applyChanges(configPath string) error {
 		return err
 	}
 
+	// Install all declared plugins first, so that apps declared in other files can be provisioned.
+	for _, cfg := range configs {
+		if err := ap.install(cfg); err != nil {
+			return err
+		}
+	}
+
 	for _, cfg := range configs {
 		if err := ap.apply(cfg); err != nil {
 			return err
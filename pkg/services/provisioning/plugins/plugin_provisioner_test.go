@@ -1,12 +1,14 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/stretchr/testify/require"
 )
 
@@ -85,6 +87,65 @@ func TestPluginProvisioner(t *testing.T) {
 	})
 }
 
+func TestPluginProvisioner_Install(t *testing.T) {
+	t.Run("Should install declared plugins before provisioning apps", func(t *testing.T) {
+		var calls []string
+		bus.AddHandler("test", func(query *models.GetPluginSettingByIdQuery) error {
+			return models.ErrPluginSettingNotFound
+		})
+		bus.AddHandler("test", func(cmd *models.UpdatePluginSettingCmd) error {
+			calls = append(calls, "provision "+cmd.PluginId)
+			return nil
+		})
+
+		pm := &fakeInstallingPluginManager{calls: &calls, installed: map[string]string{"test-plugin": "1.0.0"}}
+		reader := &testConfigReader{result: []*pluginsAsConfig{
+			{Apps: []*appFromConfig{{PluginID: "test-plugin-3", OrgID: 1, Enabled: true}}},
+			{Plugins: []*pluginFromConfig{
+				{PluginID: "test-plugin-3", Version: "1.2.0"},
+				{PluginID: "test-plugin"},
+			}},
+		}}
+		ap := PluginProvisioner{log: log.New("test"), cfgProvider: reader, pluginManager: pm}
+		err := ap.applyChanges("")
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"install test-plugin-3 1.2.0", "provision test-plugin-3"}, calls)
+		require.Equal(t, map[string]string{"test-plugin": "1.0.0", "test-plugin-3": "1.2.0"}, pm.installed)
+	})
+
+	t.Run("Should return error when a plugin can't be installed", func(t *testing.T) {
+		pm := &fakeInstallingPluginManager{calls: &[]string{}, err: errors.New("version not found")}
+		reader := &testConfigReader{result: []*pluginsAsConfig{
+			{Plugins: []*pluginFromConfig{{PluginID: "test-plugin", Version: "9.9.9"}}},
+		}}
+		ap := PluginProvisioner{log: log.New("test"), cfgProvider: reader, pluginManager: pm}
+		err := ap.applyChanges("")
+		require.EqualError(t, err, "failed to install plugin \"test-plugin\": version not found")
+	})
+}
+
+type fakeInstallingPluginManager struct {
+	plugins.Manager
+
+	calls     *[]string
+	installed map[string]string
+	err       error
+}
+
+func (pm *fakeInstallingPluginManager) EnsureInstalled(_ context.Context, pluginID, version string) (bool, error) {
+	if pm.err != nil {
+		return false, pm.err
+	}
+	if installed, exists := pm.installed[pluginID]; exists && (version == "" || installed == version) {
+		return false, nil
+	}
+
+	*pm.calls = append(*pm.calls, "install "+pluginID+" "+version)
+	pm.installed[pluginID] = version
+	return true, nil
+}
+
 type testConfigReader struct {
 	result []*pluginsAsConfig
 	err    error
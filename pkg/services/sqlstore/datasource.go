@@ -298,6 +298,15 @@ func UpdateDataSource(cmd *models.UpdateDataSourceCommand) error {
 		err = updateIsDefaultFlag(ds, sess)
 
 		cmd.Result = ds
+
+		sess.publishAfterCommit(&events.DataSourceUpdated{
+			Timestamp: time.Now(),
+			Name:      cmd.Name,
+			ID:        cmd.Id,
+			UID:       cmd.Uid,
+			OrgID:     cmd.OrgId,
+			Type:      cmd.Type,
+		})
 		return err
 	})
 }
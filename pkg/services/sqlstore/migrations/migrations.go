@@ -53,6 +53,9 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 	}
 	ualert.RerunDashAlertMigration(mg)
 	addKVStoreMigrations(mg)
+	addPluginInstallationMigrations(mg)
+	addPluginLifecycleEventMigrations(mg)
+	addPluginLeaderLeaseMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
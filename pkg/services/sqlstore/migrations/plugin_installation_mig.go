@@ -0,0 +1,23 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addPluginInstallationMigrations(mg *Migrator) {
+	pluginInstallationV1 := Table{
+		Name: "plugin_installation",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "plugin_id", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "version", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "installed", Type: DB_Bool, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"plugin_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create plugin_installation table v1", NewAddTableMigration(pluginInstallationV1))
+
+	mg.AddMigration("add index plugin_installation.plugin_id", NewAddIndexMigration(pluginInstallationV1, pluginInstallationV1.Indices[0]))
+}
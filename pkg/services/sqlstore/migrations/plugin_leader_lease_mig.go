@@ -0,0 +1,17 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addPluginLeaderLeaseMigrations(mg *Migrator) {
+	pluginLeaderLeaseV1 := Table{
+		Name: "plugin_leader_lease",
+		Columns: []*Column{
+			{Name: "name", Type: DB_NVarchar, Length: 190, IsPrimaryKey: true},
+			{Name: "instance_id", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "expires", Type: DB_BigInt, Nullable: false},
+			{Name: "version", Type: DB_BigInt, Nullable: false},
+		},
+	}
+
+	mg.AddMigration("create plugin_leader_lease table v1", NewAddTableMigration(pluginLeaderLeaseV1))
+}
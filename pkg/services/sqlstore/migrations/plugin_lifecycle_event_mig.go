@@ -0,0 +1,25 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addPluginLifecycleEventMigrations(mg *Migrator) {
+	pluginLifecycleEventV1 := Table{
+		Name: "plugin_lifecycle_event",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "plugin_id", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "type", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "version", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "error", Type: DB_Text, Nullable: false},
+			{Name: "instance_id", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"created"}},
+		},
+	}
+
+	mg.AddMigration("create plugin_lifecycle_event table v1", NewAddTableMigration(pluginLifecycleEventV1))
+
+	mg.AddMigration("add index plugin_lifecycle_event.created", NewAddIndexMigration(pluginLifecycleEventV1, pluginLifecycleEventV1.Indices[0]))
+}
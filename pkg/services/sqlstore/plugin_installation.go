@@ -0,0 +1,42 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// GetPluginInstallations returns the installation states of all plugins installed or uninstalled by any Grafana
+// instance, sorted by plugin ID.
+func (ss *SQLStore) GetPluginInstallations(ctx context.Context) ([]*models.PluginInstallation, error) {
+	var installations []*models.PluginInstallation
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		return sess.Asc("plugin_id").Find(&installations)
+	})
+	return installations, err
+}
+
+// SavePluginInstallation records that a plugin was installed with version, or uninstalled.
+func (ss *SQLStore) SavePluginInstallation(ctx context.Context, pluginID, version string, installed bool) error {
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		var installation models.PluginInstallation
+		exists, err := sess.Where("plugin_id=?", pluginID).Get(&installation)
+		if err != nil {
+			return err
+		}
+
+		installation.PluginId = pluginID
+		installation.Version = version
+		installation.Installed = installed
+		installation.Updated = time.Now()
+
+		if !exists {
+			_, err = sess.Insert(&installation)
+			return err
+		}
+
+		_, err = sess.ID(installation.Id).UseBool("installed").Cols("version", "installed", "updated").Update(&installation)
+		return err
+	})
+}
@@ -0,0 +1,37 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginInstallationDataAccess(t *testing.T) {
+	ss := InitTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, ss.SavePluginInstallation(ctx, "plugin-b", "1.0.0", true))
+	require.NoError(t, ss.SavePluginInstallation(ctx, "plugin-a", "2.0.0", true))
+
+	installations, err := ss.GetPluginInstallations(ctx)
+	require.NoError(t, err)
+	require.Len(t, installations, 2)
+	require.Equal(t, "plugin-a", installations[0].PluginId)
+	require.Equal(t, "2.0.0", installations[0].Version)
+	require.True(t, installations[0].Installed)
+
+	t.Run("Should update the installation of a plugin", func(t *testing.T) {
+		require.NoError(t, ss.SavePluginInstallation(ctx, "plugin-b", "", false))
+
+		installations, err := ss.GetPluginInstallations(ctx)
+		require.NoError(t, err)
+		require.Len(t, installations, 2)
+		require.Equal(t, "plugin-b", installations[1].PluginId)
+		require.Empty(t, installations[1].Version)
+		require.False(t, installations[1].Installed)
+	})
+}
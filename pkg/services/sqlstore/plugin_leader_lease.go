@@ -0,0 +1,58 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// TryAcquirePluginLeaderLease acquires or renews the leader lease with name for the Grafana instance with
+// instanceID until ttl from now. It returns false if another instance holds the lease and it hasn't expired yet,
+// or if another instance acquired it concurrently.
+func (ss *SQLStore) TryAcquirePluginLeaderLease(ctx context.Context, name, instanceID string,
+	ttl time.Duration) (bool, error) {
+	var acquired bool
+	err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		now := time.Now()
+		lease := models.PluginLeaderLease{}
+		exists, err := sess.Where("name = ?", name).Get(&lease)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			lease = models.PluginLeaderLease{Name: name, InstanceId: instanceID, Expires: now.Add(ttl).Unix(), Version: 1}
+			if _, err := sess.Insert(&lease); err != nil {
+				return err
+			}
+			acquired = true
+			return nil
+		}
+
+		if lease.InstanceId != instanceID && lease.Expires > now.Unix() {
+			return nil
+		}
+
+		// the version guards against another instance acquiring the expired lease concurrently
+		res, err := sess.Exec("UPDATE plugin_leader_lease SET instance_id = ?, expires = ?, version = ? WHERE name = ? AND version = ?",
+			instanceID, now.Add(ttl).Unix(), lease.Version+1, name, lease.Version)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		acquired = affected == 1
+		return err
+	})
+	return acquired, err
+}
+
+// ReleasePluginLeaderLease expires the leader lease with name if the Grafana instance with instanceID holds it, so
+// that another instance can acquire it right away.
+func (ss *SQLStore) ReleasePluginLeaderLease(ctx context.Context, name, instanceID string) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.Exec("UPDATE plugin_leader_lease SET expires = 0 WHERE name = ? AND instance_id = ?", name,
+			instanceID)
+		return err
+	})
+}
@@ -0,0 +1,55 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginLeaderLeaseDataAccess(t *testing.T) {
+	ss := InitTestDB(t)
+	ctx := context.Background()
+
+	acquired, err := ss.TryAcquirePluginLeaderLease(ctx, "plugins", "instance-a", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	t.Run("Should renew the lease of the holder", func(t *testing.T) {
+		acquired, err := ss.TryAcquirePluginLeaderLease(ctx, "plugins", "instance-a", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+	})
+
+	t.Run("Should not grant a lease held by another instance", func(t *testing.T) {
+		acquired, err := ss.TryAcquirePluginLeaderLease(ctx, "plugins", "instance-b", time.Minute)
+		require.NoError(t, err)
+		require.False(t, acquired)
+	})
+
+	t.Run("Should grant other leases", func(t *testing.T) {
+		acquired, err := ss.TryAcquirePluginLeaderLease(ctx, "other", "instance-b", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+	})
+
+	t.Run("Should grant a released lease to another instance", func(t *testing.T) {
+		require.NoError(t, ss.ReleasePluginLeaderLease(ctx, "plugins", "instance-b"))
+		acquired, err := ss.TryAcquirePluginLeaderLease(ctx, "plugins", "instance-b", time.Minute)
+		require.NoError(t, err)
+		require.False(t, acquired)
+
+		require.NoError(t, ss.ReleasePluginLeaderLease(ctx, "plugins", "instance-a"))
+		acquired, err = ss.TryAcquirePluginLeaderLease(ctx, "plugins", "instance-b", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		acquired, err = ss.TryAcquirePluginLeaderLease(ctx, "plugins", "instance-a", time.Minute)
+		require.NoError(t, err)
+		require.False(t, acquired)
+	})
+}
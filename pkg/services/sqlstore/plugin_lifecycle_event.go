@@ -0,0 +1,44 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// InsertPluginLifecycleEvent stores a lifecycle event of a plugin for the other Grafana instances.
+func (ss *SQLStore) InsertPluginLifecycleEvent(ctx context.Context, event *models.PluginLifecycleEvent) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.Insert(event)
+		return err
+	})
+}
+
+// GetPluginLifecycleEvents returns the plugin lifecycle events stored after the event with ID afterID, oldest
+// first.
+func (ss *SQLStore) GetPluginLifecycleEvents(ctx context.Context, afterID int64) ([]*models.PluginLifecycleEvent, error) {
+	var events []*models.PluginLifecycleEvent
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		return sess.Where("id > ?", afterID).Asc("id").Find(&events)
+	})
+	return events, err
+}
+
+// GetLatestPluginLifecycleEventID returns the ID of the plugin lifecycle event stored last, or 0 if there are none.
+func (ss *SQLStore) GetLatestPluginLifecycleEventID(ctx context.Context) (int64, error) {
+	var event models.PluginLifecycleEvent
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.Desc("id").Limit(1).Get(&event)
+		return err
+	})
+	return event.Id, err
+}
+
+// DeletePluginLifecycleEvents deletes the plugin lifecycle events stored before olderThan.
+func (ss *SQLStore) DeletePluginLifecycleEvents(ctx context.Context, olderThan time.Time) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.Where("created < ?", olderThan).Delete(&models.PluginLifecycleEvent{})
+		return err
+	})
+}
@@ -0,0 +1,50 @@
+//go:build integration
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginLifecycleEventDataAccess(t *testing.T) {
+	ss := InitTestDB(t)
+	ctx := context.Background()
+
+	latestID, err := ss.GetLatestPluginLifecycleEventID(ctx)
+	require.NoError(t, err)
+	require.Zero(t, latestID)
+
+	old := &models.PluginLifecycleEvent{PluginId: "plugin-a", Type: models.PluginInstalled, Version: "1.0.0",
+		InstanceId: "instance-a", Created: time.Now().Add(-2 * time.Hour)}
+	require.NoError(t, ss.InsertPluginLifecycleEvent(ctx, old))
+	recent := &models.PluginLifecycleEvent{PluginId: "plugin-b", Type: models.PluginFailed, Error: "crashed",
+		InstanceId: "instance-b", Created: time.Now()}
+	require.NoError(t, ss.InsertPluginLifecycleEvent(ctx, recent))
+
+	latestID, err = ss.GetLatestPluginLifecycleEventID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, recent.Id, latestID)
+
+	events, err := ss.GetPluginLifecycleEvents(ctx, old.Id)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "plugin-b", events[0].PluginId)
+	require.Equal(t, models.PluginFailed, events[0].Type)
+	require.Equal(t, "crashed", events[0].Error)
+	require.Equal(t, "instance-b", events[0].InstanceId)
+
+	t.Run("Should delete old events", func(t *testing.T) {
+		require.NoError(t, ss.DeletePluginLifecycleEvents(ctx, time.Now().Add(-time.Hour)))
+
+		events, err := ss.GetPluginLifecycleEvents(ctx, 0)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, recent.Id, events[0].Id)
+	})
+}
@@ -16,6 +16,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
@@ -184,6 +185,9 @@ type Cfg struct {
 	Raw    *ini.File
 	Logger log.Logger
 
+	// loadedConfigFiles are the configuration files loaded, from the defaults to the custom configuration.
+	loadedConfigFiles []string
+
 	// HTTP Server Settings
 	CertFile         string
 	KeyFile          string
@@ -251,16 +255,81 @@ type Cfg struct {
 	// CSPTemplate contains the Content Security Policy template.
 	CSPTemplate string
 
-	TempDataLifetime                 time.Duration
-	PluginsEnableAlpha               bool
-	PluginsAppsSkipVerifyTLS         bool
-	PluginSettings                   PluginSettings
-	PluginsAllowUnsigned             []string
-	PluginCatalogURL                 string
-	PluginAdminEnabled               bool
-	PluginAdminExternalManageEnabled bool
-	DisableSanitizeHtml              bool
-	EnterpriseLicensePath            string
+	TempDataLifetime         time.Duration
+	PluginsEnableAlpha       bool
+	PluginsAppsSkipVerifyTLS bool
+	// PluginSettings are the settings of the [plugin.<plugin id>] sections loaded at startup. They're read with
+	// PluginSettingsFor and AllPluginSettings, which return the settings replaced by ReloadPluginSettings.
+	PluginSettings PluginSettings
+	// reloadedPluginSettings holds the PluginSettings replacing PluginSettings once settings were reloaded.
+	reloadedPluginSettings                 atomic.Value
+	PluginsAllowUnsigned                   []string
+	PluginCatalogURL                       string
+	PluginAdminEnabled                     bool
+	PluginAdminExternalManageEnabled       bool
+	PluginsScanMaxDepth                    int
+	PluginIDAliases                        map[string]string
+	PluginSecretsProviders                 []string
+	PluginSecretsAllowedReferences         map[string][]string
+	PluginsResourceResponseBufferSize      int
+	PluginsResourceResponseMaxBytes        int
+	PluginsResourceCompressionEnabled      bool
+	PluginsResourceCompressionMinBytes     int
+	PluginsResourceCompressionContentTypes []string
+	PluginsResourceRateLimit               int
+	PluginsResourceRateLimitBurst          int
+	PluginsResourceRateLimitScope          string
+	PluginsResourceTimeout                 int
+	PluginsQueryTimeout                    int
+	PluginsDefaultTimeout                  int
+	PluginsResourceCacheTTL                int
+	PluginsResourceRequestMaxBytes         int
+	PluginsResourceHeaderAllowlist         []string
+	PluginsResourceHeaderDenylist          []string
+	PluginsResourceForwardIdentityHeaders  bool
+	PluginsQueryCacheTTL                   int
+	PluginsQueryCacheStaleTTL              int
+	PluginsQueryCacheMaxEntries            int
+	PluginsQueryMaxConcurrency             int
+	PluginsQueryQueueTimeout               int
+	PluginsQueryRetryTimeout               int
+	PluginsQuerySchedulerMaxConcurrency    int
+	PluginsQueryOrgWeights                 map[int64]int
+	PluginsQueryMaxRows                    int
+	PluginsQueryMaxBytes                   int
+	PluginsStreamRateLimit                 int
+	PluginsStreamRateLimitBurst            int
+	PluginsStreamBandwidthLimit            int
+	PluginsStreamResumeTimeout             int
+	PluginsStreamSubscriberBufferSize      int
+	PluginsStreamOverflowPolicy            string
+	PluginsStreamReplayWindow              int
+	PluginsHealthCheckInterval             int
+	PluginsHealthCheckPlugins              []string
+	PluginsWarmUpPlugins                   []string
+	PluginsBackgroundWorkers               int
+	PluginsProfilingLabels                 bool
+	PluginsProcessMetricsInterval          int
+	PluginsLogDirectory                    string
+	PluginsDataDirectory                   string
+	PluginsTransportTLS                    bool
+	PluginsLogToMainLog                    bool
+	PluginsMetricsScrapeInterval           int
+	PluginsSlowQueryThreshold              int
+	PluginsRestartBudget                   int
+	PluginsHASyncInterval                  int
+	PluginsContainerRuntime                string
+	PluginsProcessScope                    string
+	PluginsProcessCgroupPath               string
+	PluginsProcessMemoryMaxBytes           int
+	PluginsSharedPathInterval              int
+	PluginsClusterEventsInterval           int
+	PluginsLeaderElectionInterval          int
+	PluginsUnixSocketDir                   string
+	PluginsEnforceDependencies             bool
+	PluginsBlockAngular                    bool
+	DisableSanitizeHtml                    bool
+	EnterpriseLicensePath                  string
 
 	// Metrics
 	MetricsEndpointEnabled           bool
@@ -687,6 +756,7 @@ func (cfg *Cfg) loadSpecifiedConfigFile(configFile string, masterFile *ini.File)
 	}
 
 	configFiles = append(configFiles, configFile)
+	cfg.loadedConfigFiles = append(cfg.loadedConfigFiles, configFile)
 	return nil
 }
 
@@ -694,6 +764,7 @@ func (cfg *Cfg) loadConfiguration(args CommandLineArgs) (*ini.File, error) {
 	// load config defaults
 	defaultConfigFile := path.Join(HomePath, "conf/defaults.ini")
 	configFiles = append(configFiles, defaultConfigFile)
+	cfg.loadedConfigFiles = []string{defaultConfigFile}
 
 	// check if config file exists
 	if _, err := os.Stat(defaultConfigFile); os.IsNotExist(err) {
@@ -937,6 +1008,100 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	cfg.PluginCatalogURL = pluginsSection.Key("plugin_catalog_url").MustString("https://grafana.com/grafana/plugins/")
 	cfg.PluginAdminEnabled = pluginsSection.Key("plugin_admin_enabled").MustBool(false)
 	cfg.PluginAdminExternalManageEnabled = pluginsSection.Key("plugin_admin_external_manage_enabled").MustBool(false)
+	cfg.PluginsScanMaxDepth = pluginsSection.Key("scan_max_depth").MustInt(0)
+	cfg.PluginsResourceResponseBufferSize = pluginsSection.Key("resource_response_buffer_size").MustInt(10)
+	cfg.PluginsResourceResponseMaxBytes = pluginsSection.Key("resource_response_max_bytes").MustInt(0)
+	cfg.PluginsResourceCompressionEnabled = pluginsSection.Key("resource_compression_enabled").MustBool(false)
+	cfg.PluginsResourceCompressionMinBytes = pluginsSection.Key("resource_compression_min_bytes").MustInt(1024)
+	cfg.PluginsResourceCompressionContentTypes = util.SplitString(pluginsSection.Key("resource_compression_content_types").
+		MustString("application/json,text/plain,text/csv,text/html,text/css,application/javascript"))
+	cfg.PluginsResourceRateLimit = pluginsSection.Key("resource_rate_limit").MustInt(0)
+	cfg.PluginsResourceRateLimitBurst = pluginsSection.Key("resource_rate_limit_burst").MustInt(0)
+	cfg.PluginsResourceRateLimitScope = pluginsSection.Key("resource_rate_limit_scope").In("plugin", []string{"plugin", "org", "user"})
+	cfg.PluginsResourceTimeout = pluginsSection.Key("resource_timeout").MustInt(0)
+	cfg.PluginsQueryTimeout = pluginsSection.Key("query_timeout").MustInt(0)
+	cfg.PluginsDefaultTimeout = pluginsSection.Key("default_timeout").MustInt(300)
+	cfg.PluginsResourceCacheTTL = pluginsSection.Key("resource_cache_ttl").MustInt(0)
+	cfg.PluginsResourceRequestMaxBytes = pluginsSection.Key("resource_request_max_bytes").MustInt(0)
+	cfg.PluginsResourceHeaderAllowlist = util.SplitString(pluginsSection.Key("resource_header_allowlist").MustString(""))
+	cfg.PluginsResourceHeaderDenylist = util.SplitString(pluginsSection.Key("resource_header_denylist").MustString(""))
+	cfg.PluginsResourceForwardIdentityHeaders = pluginsSection.Key("resource_forward_identity_headers").MustBool(false)
+	cfg.PluginsQueryCacheTTL = pluginsSection.Key("query_cache_ttl").MustInt(0)
+	cfg.PluginsQueryCacheStaleTTL = pluginsSection.Key("query_cache_stale_ttl").MustInt(0)
+	cfg.PluginsQueryCacheMaxEntries = pluginsSection.Key("query_cache_max_entries").MustInt(10000)
+	cfg.PluginsQueryMaxConcurrency = pluginsSection.Key("query_max_concurrency").MustInt(0)
+	cfg.PluginsQueryQueueTimeout = pluginsSection.Key("query_queue_timeout").MustInt(30)
+	cfg.PluginsQueryRetryTimeout = pluginsSection.Key("query_retry_timeout").MustInt(5)
+	cfg.PluginsQuerySchedulerMaxConcurrency = pluginsSection.Key("query_scheduler_max_concurrency").MustInt(0)
+	cfg.PluginsQueryOrgWeights = make(map[int64]int)
+	for _, orgWeight := range util.SplitString(pluginsSection.Key("query_org_weights").MustString("")) {
+		parts := strings.SplitN(orgWeight, ":", 2)
+		if len(parts) != 2 {
+			cfg.Logger.Warn("Ignoring invalid query organization weight, expected format is org-id:weight", "weight", orgWeight)
+			continue
+		}
+		orgID, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			cfg.Logger.Warn("Ignoring invalid query organization weight, expected format is org-id:weight", "weight", orgWeight)
+			continue
+		}
+		weight, err := strconv.Atoi(parts[1])
+		if err != nil || weight < 1 {
+			cfg.Logger.Warn("Ignoring invalid query organization weight, expected format is org-id:weight", "weight", orgWeight)
+			continue
+		}
+		cfg.PluginsQueryOrgWeights[orgID] = weight
+	}
+	cfg.PluginsQueryMaxRows = pluginsSection.Key("query_max_rows").MustInt(0)
+	cfg.PluginsQueryMaxBytes = pluginsSection.Key("query_max_bytes").MustInt(0)
+	cfg.PluginsStreamRateLimit = pluginsSection.Key("stream_rate_limit").MustInt(0)
+	cfg.PluginsStreamRateLimitBurst = pluginsSection.Key("stream_rate_limit_burst").MustInt(0)
+	cfg.PluginsStreamBandwidthLimit = pluginsSection.Key("stream_bandwidth_limit").MustInt(0)
+	cfg.PluginsStreamResumeTimeout = pluginsSection.Key("stream_resume_timeout").MustInt(30)
+	cfg.PluginsStreamSubscriberBufferSize = pluginsSection.Key("stream_subscriber_buffer_size").MustInt(100)
+	cfg.PluginsStreamOverflowPolicy = pluginsSection.Key("stream_overflow_policy").In("drop_oldest", []string{"drop_oldest", "disconnect"})
+	cfg.PluginsStreamReplayWindow = pluginsSection.Key("stream_replay_window").MustInt(0)
+	cfg.PluginsHealthCheckInterval = pluginsSection.Key("health_check_interval").MustInt(0)
+	cfg.PluginsHealthCheckPlugins = util.SplitString(pluginsSection.Key("health_check_plugins").MustString(""))
+	cfg.PluginsWarmUpPlugins = util.SplitString(pluginsSection.Key("warm_up_plugins").MustString(""))
+	cfg.PluginsBackgroundWorkers = pluginsSection.Key("background_workers").MustInt(10)
+	cfg.PluginsProfilingLabels = pluginsSection.Key("profiling_labels").MustBool(false)
+	cfg.PluginsProcessMetricsInterval = pluginsSection.Key("process_metrics_interval").MustInt(15)
+	cfg.PluginsLogDirectory = pluginsSection.Key("log_directory").MustString("")
+	cfg.PluginsLogToMainLog = pluginsSection.Key("log_to_main_log").MustBool(false)
+	cfg.PluginsTransportTLS = pluginsSection.Key("transport_tls").MustBool(false)
+	if dataDirectory := pluginsSection.Key("data_directory").String(); dataDirectory != "" {
+		cfg.PluginsDataDirectory = makeAbsolute(dataDirectory, cfg.DataPath)
+	}
+	cfg.PluginsMetricsScrapeInterval = pluginsSection.Key("metrics_scrape_interval").MustInt(0)
+	cfg.PluginsSlowQueryThreshold = pluginsSection.Key("slow_query_threshold").MustInt(0)
+	cfg.PluginsRestartBudget = pluginsSection.Key("restart_budget").MustInt(10)
+	cfg.PluginsHASyncInterval = pluginsSection.Key("ha_sync_interval").MustInt(0)
+	cfg.PluginsContainerRuntime = pluginsSection.Key("container_runtime").MustString("")
+	cfg.PluginsProcessScope = pluginsSection.Key("process_scope").MustString("")
+	cfg.PluginsProcessCgroupPath = pluginsSection.Key("process_cgroup_path").MustString("/sys/fs/cgroup/grafana-plugins")
+	cfg.PluginsProcessMemoryMaxBytes = pluginsSection.Key("process_memory_max_bytes").MustInt(0)
+	cfg.PluginsSharedPathInterval = pluginsSection.Key("shared_path_interval").MustInt(0)
+	cfg.PluginsClusterEventsInterval = pluginsSection.Key("cluster_events_interval").MustInt(0)
+	cfg.PluginsLeaderElectionInterval = pluginsSection.Key("leader_election_interval").MustInt(0)
+	cfg.PluginsUnixSocketDir = pluginsSection.Key("unix_socket_dir").MustString("")
+	cfg.PluginsEnforceDependencies = pluginsSection.Key("enforce_dependencies").MustBool(false)
+	cfg.PluginsBlockAngular = pluginsSection.Key("block_angular").MustBool(false)
+	cfg.PluginIDAliases = make(map[string]string)
+	for _, alias := range util.SplitString(pluginsSection.Key("id_aliases").MustString("")) {
+		parts := strings.SplitN(alias, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			cfg.Logger.Warn("Ignoring invalid plugin ID alias, expected format is old-id:new-id", "alias", alias)
+			continue
+		}
+		cfg.PluginIDAliases[parts[0]] = parts[1]
+	}
+	cfg.PluginSecretsProviders = util.SplitString(pluginsSection.Key("secrets_providers").MustString(""))
+	cfg.PluginSecretsAllowedReferences = make(map[string][]string)
+	for _, provider := range cfg.PluginSecretsProviders {
+		key := "secrets_" + provider + "_allowed_references"
+		cfg.PluginSecretsAllowedReferences[provider] = util.SplitString(pluginsSection.Key(key).MustString(""))
+	}
 
 	// Read and populate feature toggles list
 	featureTogglesSection := iniFile.Section("feature_toggles")
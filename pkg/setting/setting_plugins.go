@@ -1,8 +1,18 @@
 package setting
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 
+	"golang.org/x/net/http/httpproxy"
 	"gopkg.in/ini.v1"
 )
 
@@ -23,3 +33,220 @@ func extractPluginSettings(sections []*ini.Section) PluginSettings {
 
 	return psMap
 }
+
+// PluginSettingsFor returns the settings of the [plugin.<plugin id>] section of a plugin, which must not be modified.
+func (cfg *Cfg) PluginSettingsFor(pluginID string) map[string]string {
+	return cfg.AllPluginSettings()[pluginID]
+}
+
+// AllPluginSettings returns the settings of all [plugin.<plugin id>] sections, which must not be modified.
+func (cfg *Cfg) AllPluginSettings() PluginSettings {
+	if reloaded, ok := cfg.reloadedPluginSettings.Load().(PluginSettings); ok {
+		return reloaded
+	}
+	return cfg.PluginSettings
+}
+
+// pluginSettingsReloadMu serializes reloads of plugin settings, which replace all the settings.
+var pluginSettingsReloadMu sync.Mutex
+
+// ReloadPluginSettings re-reads the settings of a plugin from the configuration files and environment variables,
+// replacing the settings returned by PluginSettingsFor and AllPluginSettings, and returns them. Settings given as command line properties aren't re-read.
+func (cfg *Cfg) ReloadPluginSettings(pluginID string) (map[string]string, error) {
+	sectionName := "plugin." + pluginID
+	parsedFile := ini.Empty()
+	parsedFile.BlockMode = false
+	section := parsedFile.Section(sectionName)
+
+	for i, configFile := range cfg.loadedConfigFiles {
+		file, err := ini.Load(configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", configFile, err)
+		}
+
+		fileSection, err := file.GetSection(sectionName)
+		if err != nil {
+			continue
+		}
+		for _, key := range fileSection.Keys() {
+			// like when loading the configuration, empty values don't override the defaults
+			if i > 0 && key.Value() == "" {
+				continue
+			}
+			section.Key(key.Name()).SetValue(key.Value())
+		}
+	}
+
+	for _, key := range section.Keys() {
+		if envValue := os.Getenv(envKey(sectionName, key.Name())); envValue != "" {
+			key.SetValue(envValue)
+		}
+	}
+
+	if err := expandConfig(parsedFile); err != nil {
+		return nil, err
+	}
+
+	settings := section.KeysHash()
+
+	// the settings are replaced rather than updated in place, as they are read concurrently
+	pluginSettingsReloadMu.Lock()
+	defer pluginSettingsReloadMu.Unlock()
+	current := cfg.AllPluginSettings()
+	pluginSettings := make(PluginSettings, len(current)+1)
+	for id, s := range current {
+		pluginSettings[id] = s
+	}
+	pluginSettings[pluginID] = settings
+	cfg.reloadedPluginSettings.Store(pluginSettings)
+
+	return settings, nil
+}
+
+// PluginDataPath returns the writable data directory of a plugin, in which it keeps caches and state across
+// restarts, or an empty string if plugins don't have data directories.
+func (cfg *Cfg) PluginDataPath(pluginID string) string {
+	if cfg.PluginsDataDirectory == "" {
+		return ""
+	}
+	return filepath.Join(cfg.PluginsDataDirectory, pluginID, "data")
+}
+
+// PluginTempPath returns the temporary directory of a plugin, which is emptied every time the plugin is loaded, or
+// an empty string if plugins don't have data directories.
+func (cfg *Cfg) PluginTempPath(pluginID string) string {
+	if cfg.PluginsDataDirectory == "" {
+		return ""
+	}
+	return filepath.Join(cfg.PluginsDataDirectory, pluginID, "tmp")
+}
+
+// PluginPidPath returns the directory of the pid files of the running processes of a plugin, or an empty string if
+// plugins don't have data directories.
+func (cfg *Cfg) PluginPidPath(pluginID string) string {
+	if cfg.PluginsDataDirectory == "" {
+		return ""
+	}
+	return filepath.Join(cfg.PluginsDataDirectory, pluginID, "pids")
+}
+
+// PluginProxy is the outbound HTTP proxy configuration of a plugin.
+type PluginProxy struct {
+	// URL is the URL of the proxy of HTTP and HTTPS requests.
+	URL string
+	// NoProxy is the comma separated list of hosts, domains and IP ranges requested without proxy, in the format
+	// of the NO_PROXY environment variable.
+	NoProxy string
+}
+
+// PluginProxy returns the outbound HTTP proxy of a plugin, configured by the proxy_url and no_proxy settings of its
+// [plugin.<plugin id>] section, and false if the plugin doesn't have one.
+func (cfg *Cfg) PluginProxy(pluginID string) (PluginProxy, bool) {
+	settings := cfg.PluginSettingsFor(pluginID)
+	proxy := PluginProxy{URL: settings["proxy_url"], NoProxy: settings["no_proxy"]}
+	return proxy, proxy.URL != ""
+}
+
+// Env returns the environment variables configuring the proxy of a plugin process.
+func (p PluginProxy) Env() []string {
+	return []string{
+		"HTTP_PROXY=" + p.URL,
+		"HTTPS_PROXY=" + p.URL,
+		"NO_PROXY=" + p.NoProxy,
+		"http_proxy=" + p.URL,
+		"https_proxy=" + p.URL,
+		"no_proxy=" + p.NoProxy,
+	}
+}
+
+// ProxyFunc returns a function for http.Transport.Proxy sending requests through the proxy, unless their host
+// matches NoProxy.
+func (p PluginProxy) ProxyFunc() func(*http.Request) (*url.URL, error) {
+	proxyFunc := (&httpproxy.Config{HTTPProxy: p.URL, HTTPSProxy: p.URL, NoProxy: p.NoProxy}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+}
+
+// PluginTransportTLS is the TLS configuration of the connection of Grafana to a backend plugin process.
+type PluginTransportTLS struct {
+	// CertFile and KeyFile are the client certificate Grafana presents to the plugin.
+	CertFile string
+	KeyFile  string
+	// CAFile is the certificate authority verifying the certificate of the plugin.
+	CAFile string
+	// ServerName is the name the certificate of the plugin is verified for, localhost if empty.
+	ServerName string
+}
+
+// PluginRemoteAddress returns the address of the gRPC server of a backend plugin running outside of Grafana, or the
+// dns, consul or kubernetes address its replicas are discovered with, set with remote_address in the
+// [plugin.<plugin id>] section of the plugin, and false if Grafana runs the plugin process.
+func (cfg *Cfg) PluginRemoteAddress(pluginID string) (string, bool) {
+	address := cfg.PluginSettingsFor(pluginID)["remote_address"]
+	return address, address != ""
+}
+
+// PluginTransportTLS returns the TLS configuration of the connection to a backend plugin, enabled by transport_tls in
+// the [plugins] section or in the [plugin.<plugin id>] section of the plugin, and false if the plugin connects without
+// TLS. Plugins with the transport_tls_cert_file, transport_tls_key_file and transport_tls_ca_file settings always
+// connect with TLS.
+func (cfg *Cfg) PluginTransportTLS(pluginID string) (PluginTransportTLS, bool) {
+	settings := cfg.PluginSettingsFor(pluginID)
+	transportTLS := PluginTransportTLS{
+		CertFile:   settings["transport_tls_cert_file"],
+		KeyFile:    settings["transport_tls_key_file"],
+		CAFile:     settings["transport_tls_ca_file"],
+		ServerName: settings["transport_tls_server_name"],
+	}
+	if transportTLS.hasCertificates() {
+		return transportTLS, true
+	}
+
+	enabled := cfg.PluginsTransportTLS
+	if value, exists := settings["transport_tls"]; exists {
+		enabled = value == "true"
+	}
+	return transportTLS, enabled
+}
+
+func (t PluginTransportTLS) hasCertificates() bool {
+	return t.CertFile != "" || t.KeyFile != "" || t.CAFile != ""
+}
+
+// Config returns the client TLS configuration of the certificates, or nil if certificates are generated
+// automatically every time the plugin starts.
+func (t PluginTransportTLS) Config() (*tls.Config, error) {
+	if !t.hasCertificates() {
+		return nil, nil
+	}
+	if t.CertFile == "" || t.KeyFile == "" || t.CAFile == "" {
+		return nil, errors.New("transport_tls_cert_file, transport_tls_key_file and transport_tls_ca_file must all be set")
+	}
+
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because the path comes from the plugin settings
+	ca, err := os.ReadFile(t.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate authority: %w", err)
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates found in %q", t.CAFile)
+	}
+
+	serverName := t.ServerName
+	if serverName == "" {
+		serverName = "localhost"
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      rootCAs,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
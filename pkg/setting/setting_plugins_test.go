@@ -1,8 +1,22 @@
 package setting
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -41,3 +55,158 @@ func TestPluginSettings(t *testing.T) {
 	require.Equal(t, ps["plugin2"]["key3"], "value3")
 	require.Equal(t, ps["plugin2"]["key4"], "value4")
 }
+
+func TestReloadPluginSettings(t *testing.T) {
+	dir := t.TempDir()
+	defaultsFile := filepath.Join(dir, "defaults.ini")
+	customFile := filepath.Join(dir, "custom.ini")
+	err := ioutil.WriteFile(defaultsFile, []byte("[plugin.plugin]\nkey1 = default1\nkey2 = default2\n"), 0600)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(customFile, []byte("[plugin.plugin]\nkey1 =\nkey2 = custom2\n"), 0600)
+	require.NoError(t, err)
+
+	err = os.Setenv("GF_PLUGIN_PLUGIN_KEY1", "env1")
+	require.NoError(t, err)
+	defer func() {
+		err := os.Unsetenv("GF_PLUGIN_PLUGIN_KEY1")
+		require.NoError(t, err)
+	}()
+
+	cfg := NewCfg()
+	cfg.loadedConfigFiles = []string{defaultsFile, customFile}
+	cfg.PluginSettings = PluginSettings{
+		"plugin":  {"key1": "value1"},
+		"plugin2": {"key3": "value3"},
+	}
+
+	settings, err := cfg.ReloadPluginSettings("plugin")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"key1": "env1", "key2": "custom2"}, settings)
+	require.Equal(t, PluginSettings{
+		"plugin":  {"key1": "env1", "key2": "custom2"},
+		"plugin2": {"key3": "value3"},
+	}, cfg.AllPluginSettings())
+	require.Equal(t, settings, cfg.PluginSettingsFor("plugin"))
+
+	t.Run("Should read settings while they're reloaded", func(t *testing.T) {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				_, err := cfg.ReloadPluginSettings("plugin")
+				assert.NoError(t, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				_ = cfg.PluginSettingsFor("plugin")["key1"]
+				_, _ = cfg.PluginProxy("plugin")
+			}
+		}()
+		wg.Wait()
+
+		require.Equal(t, "env1", cfg.PluginSettingsFor("plugin")["key1"])
+	})
+}
+
+func TestPluginProxy(t *testing.T) {
+	cfg := NewCfg()
+	cfg.PluginSettings = PluginSettings{
+		"proxied": map[string]string{"proxy_url": "http://proxy:3128", "no_proxy": "localhost,.internal"},
+	}
+
+	_, exists := cfg.PluginProxy("direct")
+	require.False(t, exists)
+
+	proxy, exists := cfg.PluginProxy("proxied")
+	require.True(t, exists)
+
+	proxyFunc := proxy.ProxyFunc()
+	for target, expected := range map[string]string{
+		"https://example.com/api":     "http://proxy:3128",
+		"http://db.internal:8080/api": "",
+		"http://localhost:9090/api":   "",
+	} {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+		proxyURL, err := proxyFunc(req)
+		require.NoError(t, err)
+		if expected == "" {
+			require.Nil(t, proxyURL, target)
+			continue
+		}
+		require.Equal(t, expected, proxyURL.String(), target)
+	}
+}
+
+func TestPluginTransportTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeTestCertificate(t, certFile, keyFile)
+
+	cfg := NewCfg()
+	cfg.PluginSettings = PluginSettings{
+		"auto":     map[string]string{"transport_tls": "true"},
+		"disabled": map[string]string{"transport_tls": "false"},
+		"certificates": map[string]string{
+			"transport_tls_cert_file":   certFile,
+			"transport_tls_key_file":    keyFile,
+			"transport_tls_ca_file":     certFile,
+			"transport_tls_server_name": "plugin.internal",
+		},
+		"incomplete": map[string]string{"transport_tls_cert_file": certFile},
+	}
+
+	_, secured := cfg.PluginTransportTLS("plain")
+	require.False(t, secured)
+
+	transportTLS, secured := cfg.PluginTransportTLS("auto")
+	require.True(t, secured)
+	tlsConfig, err := transportTLS.Config()
+	require.NoError(t, err)
+	require.Nil(t, tlsConfig)
+
+	transportTLS, secured = cfg.PluginTransportTLS("certificates")
+	require.True(t, secured)
+	tlsConfig, err = transportTLS.Config()
+	require.NoError(t, err)
+	require.Len(t, tlsConfig.Certificates, 1)
+	require.NotNil(t, tlsConfig.RootCAs)
+	require.Equal(t, "plugin.internal", tlsConfig.ServerName)
+
+	transportTLS, secured = cfg.PluginTransportTLS("incomplete")
+	require.True(t, secured)
+	_, err = transportTLS.Config()
+	require.Error(t, err)
+
+	cfg.PluginsTransportTLS = true
+	_, secured = cfg.PluginTransportTLS("plain")
+	require.True(t, secured)
+	_, secured = cfg.PluginTransportTLS("disabled")
+	require.False(t, secured)
+}
+
+func writeTestCertificate(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+}
@@ -15,51 +15,11 @@ import (
 // nolint:staticcheck // plugins.DataQuery deprecated
 func dataPluginQueryAdapter(pluginID string, handler backend.QueryDataHandler, oAuthService oauthtoken.OAuthTokenService) plugins.DataPluginFunc {
 	return plugins.DataPluginFunc(func(ctx context.Context, ds *models.DataSource, query plugins.DataQuery) (plugins.DataResponse, error) {
-		instanceSettings, err := modelToInstanceSettings(ds)
+		req, err := newQueryDataRequest(ctx, pluginID, ds, query, oAuthService)
 		if err != nil {
 			return plugins.DataResponse{}, err
 		}
 
-		if query.Headers == nil {
-			query.Headers = make(map[string]string)
-		}
-
-		if oAuthService.IsOAuthPassThruEnabled(ds) {
-			if token := oAuthService.GetCurrentOAuthToken(ctx, query.User); token != nil {
-				delete(query.Headers, "Authorization")
-				query.Headers["Authorization"] = fmt.Sprintf("%s %s", token.Type(), token.AccessToken)
-			}
-		}
-
-		req := &backend.QueryDataRequest{
-			PluginContext: backend.PluginContext{
-				OrgID:                      ds.OrgId,
-				PluginID:                   pluginID,
-				User:                       adapters.BackendUserFromSignedInUser(query.User),
-				DataSourceInstanceSettings: instanceSettings,
-			},
-			Queries: []backend.DataQuery{},
-			Headers: query.Headers,
-		}
-
-		for _, q := range query.Queries {
-			modelJSON, err := q.Model.MarshalJSON()
-			if err != nil {
-				return plugins.DataResponse{}, err
-			}
-			req.Queries = append(req.Queries, backend.DataQuery{
-				RefID:         q.RefID,
-				Interval:      time.Duration(q.IntervalMS) * time.Millisecond,
-				MaxDataPoints: q.MaxDataPoints,
-				TimeRange: backend.TimeRange{
-					From: query.TimeRange.GetFromAsTimeUTC(),
-					To:   query.TimeRange.GetToAsTimeUTC(),
-				},
-				QueryType: q.QueryType,
-				JSON:      modelJSON,
-			})
-		}
-
 		resp, err := handler.QueryData(ctx, req)
 		if err != nil {
 			return plugins.DataResponse{}, err
@@ -93,6 +53,58 @@ func dataPluginQueryAdapter(pluginID string, handler backend.QueryDataHandler, o
 	})
 }
 
+// newQueryDataRequest converts a legacy data query to a backend plugin data query request.
+// nolint:staticcheck // plugins.DataQuery deprecated
+func newQueryDataRequest(ctx context.Context, pluginID string, ds *models.DataSource, query plugins.DataQuery,
+	oAuthService oauthtoken.OAuthTokenService) (*backend.QueryDataRequest, error) {
+	instanceSettings, err := modelToInstanceSettings(ds)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Headers == nil {
+		query.Headers = make(map[string]string)
+	}
+
+	if oAuthService.IsOAuthPassThruEnabled(ds) {
+		if token := oAuthService.GetCurrentOAuthToken(ctx, query.User); token != nil {
+			delete(query.Headers, "Authorization")
+			query.Headers["Authorization"] = fmt.Sprintf("%s %s", token.Type(), token.AccessToken)
+		}
+	}
+
+	req := &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			OrgID:                      ds.OrgId,
+			PluginID:                   pluginID,
+			User:                       adapters.BackendUserFromSignedInUser(query.User),
+			DataSourceInstanceSettings: instanceSettings,
+		},
+		Queries: []backend.DataQuery{},
+		Headers: query.Headers,
+	}
+
+	for _, q := range query.Queries {
+		modelJSON, err := q.Model.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		req.Queries = append(req.Queries, backend.DataQuery{
+			RefID:         q.RefID,
+			Interval:      time.Duration(q.IntervalMS) * time.Millisecond,
+			MaxDataPoints: q.MaxDataPoints,
+			TimeRange: backend.TimeRange{
+				From: query.TimeRange.GetFromAsTimeUTC(),
+				To:   query.TimeRange.GetToAsTimeUTC(),
+			},
+			QueryType: q.QueryType,
+			JSON:      modelJSON,
+		})
+	}
+
+	return req, nil
+}
+
 func modelToInstanceSettings(ds *models.DataSource) (*backend.DataSourceInstanceSettings, error) {
 	jsonDataBytes, err := ds.JsonData.MarshalJSON()
 	if err != nil {
@@ -68,6 +68,40 @@ func (s *Service) HandleRequest(ctx context.Context, ds *models.DataSource, quer
 	return dataPluginQueryAdapter(ds.Type, s.BackendPluginManager, s.OAuthTokenService).DataQuery(ctx, ds, query)
 }
 
+// HandleStreamingRequest handles a data request like HandleRequest, sending the result of each query to sender as
+// soon as the data source returns it.
+//nolint: staticcheck // plugins.DataQuery deprecated
+func (s *Service) HandleStreamingRequest(ctx context.Context, ds *models.DataSource, query plugins.DataQuery,
+	sender backendplugin.QueryDataResponseSender) error {
+	if _, exists := s.registry[ds.Type]; exists {
+		resp, err := s.HandleRequest(ctx, ds, query)
+		if err != nil {
+			return err
+		}
+
+		qdr, err := resp.ToBackendDataResponse()
+		if err != nil {
+			return err
+		}
+
+		for _, q := range query.Queries {
+			if r, exists := qdr.Responses[q.RefID]; exists {
+				if err := sender.Send(q.RefID, r); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	req, err := newQueryDataRequest(ctx, ds.Type, ds, query, s.OAuthTokenService)
+	if err != nil {
+		return err
+	}
+
+	return s.BackendPluginManager.QueryDataStream(ctx, req, sender)
+}
+
 // RegisterQueryHandler registers a query handler factory.
 // This is only exposed for tests!
 //nolint: staticcheck // plugins.DataPlugin deprecated
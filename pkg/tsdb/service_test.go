@@ -77,6 +77,50 @@ func TestHandleRequest(t *testing.T) {
 	})
 }
 
+func TestHandleStreamingRequest(t *testing.T) {
+	t.Run("Should send query results of registered query handler", func(t *testing.T) {
+		req := plugins.DataQuery{
+			Queries: []plugins.DataSubQuery{
+				{RefID: "A", DataSource: &models.DataSource{Id: 1, Type: "test"}},
+				{RefID: "B", DataSource: &models.DataSource{Id: 1, Type: "test"}},
+			},
+		}
+
+		svc, exe, _ := createService()
+		exe.Return("A", plugins.DataTimeSeriesSlice{plugins.DataTimeSeries{Name: "argh"}})
+		exe.Return("B", plugins.DataTimeSeriesSlice{plugins.DataTimeSeries{Name: "barg"}})
+
+		var refIDs []string
+		err := svc.HandleStreamingRequest(context.TODO(), &models.DataSource{Id: 1, Type: "test"}, req,
+			backendplugin.QueryDataResponseSenderFunc(func(refID string, resp backend.DataResponse) error {
+				refIDs = append(refIDs, refID)
+				require.Len(t, resp.Frames, 1)
+				return nil
+			}))
+		require.NoError(t, err)
+		require.Equal(t, []string{"A", "B"}, refIDs)
+	})
+
+	t.Run("Should stream from backend plugin manager for query with unregistered type", func(t *testing.T) {
+		svc, _, manager := createService()
+		ds := &models.DataSource{Id: 12, Type: "unregisteredType", JsonData: simplejson.New()}
+		req := plugins.DataQuery{
+			TimeRange: &plugins.DataTimeRange{},
+			Queries: []plugins.DataSubQuery{
+				{RefID: "A", DataSource: ds, Model: simplejson.New()},
+			},
+		}
+
+		err := svc.HandleStreamingRequest(context.Background(), ds, req, backendplugin.QueryDataResponseSenderFunc(
+			func(refID string, resp backend.DataResponse) error {
+				return nil
+			}))
+		require.NoError(t, err)
+		require.NotNil(t, manager.streamedRequest)
+		require.Equal(t, "unregisteredType", manager.streamedRequest.PluginContext.PluginID)
+	})
+}
+
 //nolint: staticcheck // plugins.DataPlugin deprecated
 type resultsFn func(context plugins.DataQuery) plugins.DataQueryResult
 
@@ -116,6 +160,13 @@ func (e *fakeExecutor) HandleQuery(refId string, fn resultsFn) {
 type fakeBackendPM struct {
 	backendplugin.Manager
 	backend.QueryDataHandlerFunc
+	streamedRequest *backend.QueryDataRequest
+}
+
+func (m *fakeBackendPM) QueryDataStream(ctx context.Context, req *backend.QueryDataRequest,
+	sender backendplugin.QueryDataResponseSender) error {
+	m.streamedRequest = req
+	return nil
 }
 
 func (m *fakeBackendPM) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {